@@ -232,7 +232,7 @@ func (b *Builder) processTask(
 		nGramSkip    = uint64(b.limits.BloomNGramSkip(tenant))
 		maxBlockSize = uint64(b.limits.BloomCompactorMaxBlockSize(tenant))
 		maxBloomSize = uint64(b.limits.BloomCompactorMaxBloomSize(tenant))
-		blockOpts    = v1.NewBlockOptions(blockEnc, nGramSize, nGramSkip, maxBlockSize, maxBloomSize)
+		blockOpts    = v1.NewBlockOptions(blockEnc, b.limits.BloomIndexStructuredMetadata(tenant), nGramSize, nGramSkip, maxBlockSize, maxBloomSize)
 		created      []bloomshipper.Meta
 		totalSeries  int
 		bytesAdded   int
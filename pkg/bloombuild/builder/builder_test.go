@@ -176,6 +176,10 @@ func (f fakeLimits) BloomNGramSkip(_ string) int {
 	panic("implement me")
 }
 
+func (f fakeLimits) BloomIndexStructuredMetadata(_ string) bool {
+	panic("implement me")
+}
+
 func (f fakeLimits) BloomCompactorMaxBlockSize(_ string) int {
 	panic("implement me")
 }
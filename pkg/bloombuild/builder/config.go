@@ -35,6 +35,7 @@ type Limits interface {
 	BloomBlockEncoding(tenantID string) string
 	BloomNGramLength(tenantID string) int
 	BloomNGramSkip(tenantID string) int
+	BloomIndexStructuredMetadata(tenantID string) bool
 	BloomCompactorMaxBlockSize(tenantID string) int
 	BloomCompactorMaxBloomSize(tenantID string) int
 }
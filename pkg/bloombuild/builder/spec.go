@@ -93,6 +93,7 @@ func NewSimpleBloomGenerator(
 			opts.Schema.NGramLen(),
 			opts.Schema.NGramSkip(),
 			int(opts.UnencodedBlockOptions.MaxBloomSizeBytes),
+			opts.Schema.IndexesStructuredMetadata(),
 			metrics.bloomMetrics,
 		),
 	}
@@ -193,6 +193,10 @@ func (m mockLimits) BloomBlockEncoding(_ string) string {
 	return chunkenc.EncNone.String()
 }
 
+func (m mockLimits) BloomIndexStructuredMetadata(_ string) bool {
+	panic("implement me")
+}
+
 func (m mockLimits) BloomCompactorMaxBlockSize(_ string) int {
 	panic("implement me")
 }
@@ -95,4 +95,5 @@ type Limits interface {
 	BloomCompactorMaxBlockSize(tenantID string) int
 	BloomCompactorMaxBloomSize(tenantID string) int
 	BloomBlockEncoding(tenantID string) string
+	BloomIndexStructuredMetadata(tenantID string) bool
 }
@@ -232,11 +232,12 @@ func (g *Gateway) FilterChunkRefs(ctx context.Context, req *logproto.FilterChunk
 	}
 
 	filters := v1.ExtractTestableLineFilters(req.Plan.AST)
-	stats.NumFilters = len(filters)
-	g.metrics.receivedFilters.Observe(float64(len(filters)))
+	metadataFilters := v1.ExtractTestableMetadataFilters(req.Plan.AST)
+	stats.NumFilters = len(filters) + len(metadataFilters)
+	g.metrics.receivedFilters.Observe(float64(stats.NumFilters))
 
 	// Shortcut if request does not contain filters
-	if len(filters) == 0 {
+	if stats.NumFilters == 0 {
 		stats.Status = labelSuccess
 		return &logproto.FilterChunkRefResponse{
 			ChunkRefs: req.Refs,
@@ -268,7 +269,7 @@ func (g *Gateway) FilterChunkRefs(ctx context.Context, req *logproto.FilterChunk
 	stats.NumTasks = len(seriesByDay)
 
 	sp.LogKV(
-		"filters", len(filters),
+		"filters", stats.NumFilters,
 		"days", len(seriesByDay),
 		"blocks", len(req.Blocks),
 		"series_requested", len(req.Refs),
@@ -283,6 +284,7 @@ func (g *Gateway) FilterChunkRefs(ctx context.Context, req *logproto.FilterChunk
 	responses := make([][]v1.Output, 0, len(seriesByDay))
 	for _, seriesForDay := range seriesByDay {
 		task := newTask(ctx, tenantID, seriesForDay, filters, blocks)
+		task.metadataFilters = metadataFilters
 		// TODO(owen-d): include capacity in constructor?
 		task.responses = responsesPool.Get(len(seriesForDay.series))
 		tasks = append(tasks, task)
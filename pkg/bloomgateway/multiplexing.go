@@ -57,6 +57,9 @@ type Task struct {
 	series []*logproto.GroupedChunkRefs
 	// filters of the original request
 	filters []syntax.LineFilterExpr
+	// filters of the original request whose terms may also be found in the
+	// structured metadata, only testable against blocks indexing it
+	metadataFilters []syntax.LineFilterExpr
 	// blocks that were resolved on the index gateway and sent with the request
 	blocks []bloomshipper.BlockRef
 	// from..through date of the task's chunks
@@ -128,7 +131,19 @@ func (t Task) Copy(series []*logproto.GroupedChunkRefs) Task {
 		table:    t.table,
 		ctx:      t.ctx,
 		done:     make(chan struct{}),
+
+		metadataFilters: t.metadataFilters,
+	}
+}
+
+// allFilters returns the line filters and the metadata filters of the task.
+func (t Task) allFilters() []syntax.LineFilterExpr {
+	if len(t.metadataFilters) == 0 {
+		return t.filters
 	}
+	filters := make([]syntax.LineFilterExpr, 0, len(t.filters)+len(t.metadataFilters))
+	filters = append(filters, t.filters...)
+	return append(filters, t.metadataFilters...)
 }
 
 func (t Task) RequestIter(
@@ -137,7 +152,7 @@ func (t Task) RequestIter(
 	return &requestIterator{
 		recorder: t.recorder,
 		series:   v1.NewSliceIter(t.series),
-		search:   v1.FiltersToBloomTest(tokenizer, t.filters...),
+		search:   v1.FiltersToBloomTest(tokenizer, t.allFilters()...),
 		channel:  t.resCh,
 		curr:     v1.Request{},
 	}
@@ -154,6 +154,12 @@ func (p *processor) processBlock(_ context.Context, blockQuerier *v1.BlockQuerie
 	iters := make([]v1.PeekingIterator[v1.Request], 0, len(tasks))
 
 	for _, task := range tasks {
+		// Blocks whose blooms don't index the structured metadata cannot tell
+		// whether a search term is part of the metadata.
+		if !schema.IndexesStructuredMetadata() {
+			task.metadataFilters = nil
+		}
+
 		// NB(owen-d): can be helpful for debugging, but is noisy
 		// and don't feel like threading this through a configuration
 
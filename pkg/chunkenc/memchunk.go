@@ -1256,31 +1256,33 @@ func (hb *headBlock) SampleIterator(ctx context.Context, mint, maxt int64, extra
 
 	for _, e := range hb.entries {
 		stats.AddHeadChunkBytes(int64(len(e.s)))
-		value, parsedLabels, ok := extractor.ProcessString(e.t, e.s, e.structuredMetadata...)
+		samples, ok := extractor.ProcessString(e.t, e.s, e.structuredMetadata...)
 		if !ok {
 			continue
 		}
 		stats.AddPostFilterLines(1)
-		var (
-			found bool
-			s     *logproto.Series
-		)
-
-		lbs := parsedLabels.String()
-		if s, found = series[lbs]; !found {
-			s = &logproto.Series{
-				Labels:     lbs,
-				Samples:    SamplesPool.Get(len(hb.entries)).([]logproto.Sample)[:0],
-				StreamHash: baseHash,
+		for _, sample := range samples {
+			var (
+				found bool
+				s     *logproto.Series
+			)
+
+			lbs := sample.Labels.String()
+			if s, found = series[lbs]; !found {
+				s = &logproto.Series{
+					Labels:     lbs,
+					Samples:    SamplesPool.Get(len(hb.entries)).([]logproto.Sample)[:0],
+					StreamHash: baseHash,
+				}
+				series[lbs] = s
 			}
-			series[lbs] = s
-		}
 
-		s.Samples = append(s.Samples, logproto.Sample{
-			Timestamp: e.t,
-			Value:     value,
-			Hash:      xxhash.Sum64(unsafeGetBytes(e.s)),
-		})
+			s.Samples = append(s.Samples, logproto.Sample{
+				Timestamp: e.t,
+				Value:     sample.Value,
+				Hash:      xxhash.Sum64(unsafeGetBytes(e.s)),
+			})
+		}
 	}
 
 	if extractor.ReferencedStructuredMetadata() {
@@ -1658,24 +1660,38 @@ type sampleBufferedIterator struct {
 
 	cur        logproto.Sample
 	currLabels log.LabelsResult
+
+	// samples are the samples of the current line not returned yet, copied as
+	// the extractor reuses them.
+	samples []log.ExtractedSample
 }
 
 func (e *sampleBufferedIterator) Next() bool {
+	if len(e.samples) > 0 {
+		e.nextSample()
+		return true
+	}
 	for e.bufferedIterator.Next() {
-		val, labels, ok := e.extractor.Process(e.currTs, e.currLine, e.currStructuredMetadata...)
+		samples, ok := e.extractor.Process(e.currTs, e.currLine, e.currStructuredMetadata...)
 		if !ok {
 			continue
 		}
 		e.stats.AddPostFilterLines(1)
-		e.currLabels = labels
-		e.cur.Value = val
+		e.samples = append(e.samples[:0], samples...)
 		e.cur.Hash = xxhash.Sum64(e.currLine)
 		e.cur.Timestamp = e.currTs
+		e.nextSample()
 		return true
 	}
 	return false
 }
 
+func (e *sampleBufferedIterator) nextSample() {
+	e.currLabels = e.samples[0].Labels
+	e.cur.Value = e.samples[0].Value
+	e.samples = e.samples[1:]
+}
+
 func (e *sampleBufferedIterator) Close() error {
 	if e.extractor.ReferencedStructuredMetadata() {
 		e.stats.SetQueryReferencedStructuredMetadata()
@@ -503,6 +503,46 @@ func TestSerialization(t *testing.T) {
 	}
 }
 
+func TestMemChunk_MultipleSamplesPerLine(t *testing.T) {
+	for _, testData := range allPossibleFormats {
+		t.Run(testNameWithFormats(EncSnappy, testData.chunkFormat, testData.headBlockFmt), func(t *testing.T) {
+			chk := newMemChunkWithFormat(testData.chunkFormat, EncSnappy, testData.headBlockFmt, testBlockSize, testTargetSize)
+			const numLines = 10
+			for i := 0; i < numLines; i++ {
+				require.NoError(t, chk.Append(logprotoEntry(int64(i), fmt.Sprintf("in=%d out=%d", i, 2*i))))
+				if i == numLines/2 {
+					// the first lines are read from a block and the others from the head block.
+					require.NoError(t, chk.cut())
+				}
+			}
+
+			ex, err := log.MultiLabelExtractorWithStages(
+				[]string{"in", "out"}, []string{log.ConvertFloat, log.ConvertFloat}, nil, false, false,
+				[]log.Stage{log.NewLogfmtParser(false, false)}, log.NoopStage,
+			)
+			require.NoError(t, err)
+
+			it := chk.SampleIterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), ex.ForStream(labels.Labels{}))
+			values := map[string][]float64{}
+			for it.Next() {
+				values[it.Labels()] = append(values[it.Labels()], it.Sample().Value)
+			}
+			require.NoError(t, it.Error())
+			require.NoError(t, it.Close())
+
+			var in, out []float64
+			for i := 0; i < numLines; i++ {
+				in = append(in, float64(i))
+				out = append(out, float64(2*i))
+			}
+			require.Equal(t, map[string][]float64{
+				labels.FromStrings(log.UnwrappedLabel, "in").String():  in,
+				labels.FromStrings(log.UnwrappedLabel, "out").String(): out,
+			}, values)
+		})
+	}
+}
+
 func TestChunkFilling(t *testing.T) {
 	for _, testData := range allPossibleFormats {
 		for _, enc := range testEncoding {
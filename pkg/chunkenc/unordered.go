@@ -310,30 +310,32 @@ func (hb *unorderedHeadBlock) SampleIterator(
 		mint,
 		maxt,
 		func(statsCtx *stats.Context, ts int64, line string, structuredMetadataSymbols symbols) error {
-			value, parsedLabels, ok := extractor.ProcessString(ts, line, hb.symbolizer.Lookup(structuredMetadataSymbols)...)
+			samples, ok := extractor.ProcessString(ts, line, hb.symbolizer.Lookup(structuredMetadataSymbols)...)
 			if !ok {
 				return nil
 			}
 			statsCtx.AddPostFilterLines(1)
-			var (
-				found bool
-				s     *logproto.Series
-			)
-			lbs := parsedLabels.String()
-			s, found = series[lbs]
-			if !found {
-				s = &logproto.Series{
-					Labels:     lbs,
-					Samples:    SamplesPool.Get(hb.lines).([]logproto.Sample)[:0],
-					StreamHash: baseHash,
+			for _, sample := range samples {
+				var (
+					found bool
+					s     *logproto.Series
+				)
+				lbs := sample.Labels.String()
+				s, found = series[lbs]
+				if !found {
+					s = &logproto.Series{
+						Labels:     lbs,
+						Samples:    SamplesPool.Get(hb.lines).([]logproto.Sample)[:0],
+						StreamHash: baseHash,
+					}
+					series[lbs] = s
 				}
-				series[lbs] = s
+				s.Samples = append(s.Samples, logproto.Sample{
+					Timestamp: ts,
+					Value:     sample.Value,
+					Hash:      xxhash.Sum64(unsafeGetBytes(line)),
+				})
 			}
-			s.Samples = append(s.Samples, logproto.Sample{
-				Timestamp: ts,
-				Value:     value,
-				Hash:      xxhash.Sum64(unsafeGetBytes(line)),
-			})
 			return nil
 		},
 	)
@@ -247,7 +247,7 @@ func (g *Gateway) GetChunkRef(ctx context.Context, req *logproto.GetChunkRefRequ
 
 	// Extract LineFiltersExpr from the plan. If there is none, we can short-circuit and return before making a req
 	// to the bloom-gateway (through the g.bloomQuerier)
-	if len(v1.ExtractTestableLineFilters(req.Plan.AST)) == 0 {
+	if !v1.HasTestableFilters(req.Plan.AST) {
 		return result, nil
 	}
 
@@ -464,7 +464,7 @@ func (g *Gateway) boundedShards(
 
 	// 2) filter via blooms if enabled
 	filters := syntax.ExtractLineFilters(p.Plan().AST)
-	if g.bloomQuerier != nil && len(filters) > 0 {
+	if g.bloomQuerier != nil && (len(filters) > 0 || v1.HasTestableFilters(p.Plan().AST)) {
 		filtered, err = g.bloomQuerier.FilterChunkRefs(ctx, instanceID, req.From, req.Through, refs, p.Plan())
 		if err != nil {
 			return err
@@ -109,6 +109,7 @@ type Config struct {
 	ChunkFilterer          chunk.RequestChunkFilterer     `yaml:"-"`
 	PipelineWrapper        lokilog.PipelineWrapper        `yaml:"-"`
 	SampleExtractorWrapper lokilog.SampleExtractorWrapper `yaml:"-"`
+	Enrichment             *lokilog.Enrichment            `yaml:"-"`
 
 	// Optional wrapper that can be used to modify the behaviour of the ingester
 	Wrapper Wrapper `yaml:"-"`
@@ -902,6 +903,8 @@ func (i *Ingester) Query(req *logproto.QueryRequest, queryServer logproto.Querie
 			AST: parsed,
 		}
 	}
+	// the geoip and cidr_label stages use the enrichment data of this process.
+	req.Plan = &plan.QueryPlan{AST: syntax.WithEnrichment(req.Plan.AST, i.cfg.Enrichment)}
 
 	instanceID, err := tenant.TenantID(ctx)
 	if err != nil {
@@ -970,6 +973,7 @@ func (i *Ingester) QuerySample(req *logproto.SampleQueryRequest, queryServer log
 			AST: parsed,
 		}
 	}
+	req.Plan = &plan.QueryPlan{AST: syntax.WithEnrichment(req.Plan.AST, i.cfg.Enrichment)}
 
 	instanceID, err := tenant.TenantID(ctx)
 	if err != nil {
@@ -1346,6 +1350,7 @@ func (i *Ingester) Tail(req *logproto.TailRequest, queryServer logproto.Querier_
 			AST: parsed,
 		}
 	}
+	req.Plan = &plan.QueryPlan{AST: syntax.WithEnrichment(req.Plan.AST, i.cfg.Enrichment)}
 
 	instanceID, err := tenant.TenantID(queryServer.Context())
 	if err != nil {
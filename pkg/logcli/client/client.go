@@ -1,6 +1,8 @@
 package client
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/base64"
 	"fmt"
@@ -45,6 +47,13 @@ const (
 	HTTPQueryTags           = "X-Query-Tags"
 	HTTPCacheControl        = "Cache-Control"
 	HTTPCacheControlNoCache = "no-cache"
+
+	// Server-sent events of streamed query responses.
+	eventStreamType = "text/event-stream"
+	eventPartial    = "partial"
+	eventProgress   = "progress"
+	eventResult     = "result"
+	eventError      = "error"
 )
 
 var userAgent = fmt.Sprintf("loki-logcli/%s", build.Version)
@@ -64,6 +73,17 @@ type Client interface {
 	GetDetectedFields(queryStr string, fieldLimit, lineLimit int, start, end time.Time, step time.Duration, quiet bool) (*loghttp.DetectedFieldsResponse, error)
 }
 
+// StreamingClient can stream the results of range queries, which are
+// returned as their parts complete.
+type StreamingClient interface {
+	QueryRangeStream(queryStr string, limit int, start, end time.Time, direction logproto.Direction, step, interval time.Duration, quiet bool, partial PartialResultFunc) (*loghttp.QueryResponse, error)
+}
+
+// PartialResultFunc receives the parts of the result of a streamed query.
+// Final parts are in the result as is and in its order, when the others only
+// give a preview of it.
+type PartialResultFunc func(resp *loghttp.QueryResponse, final bool)
+
 // Tripperware can wrap a roundtripper.
 type Tripperware func(http.RoundTripper) http.RoundTripper
 type BackoffConfig struct {
@@ -106,6 +126,35 @@ func (c *DefaultClient) Query(queryStr string, limit int, time time.Time, direct
 // excluding interfacer b/c it suggests taking the interface promql.Node instead of logproto.Direction b/c it happens to have a String() method
 // nolint:interfacer
 func (c *DefaultClient) QueryRange(queryStr string, limit int, start, end time.Time, direction logproto.Direction, step, interval time.Duration, quiet bool) (*loghttp.QueryResponse, error) {
+	return c.doQuery(queryRangePath, queryRangeParams(queryStr, limit, start, end, direction, step, interval), quiet)
+}
+
+// QueryRangeStream uses the /api/v1/query_range endpoint to execute a range
+// query whose result is streamed, passing its parts to partial as they complete.
+// nolint:interfacer
+func (c *DefaultClient) QueryRangeStream(queryStr string, limit int, start, end time.Time, direction logproto.Direction, step, interval time.Duration, quiet bool, partial PartialResultFunc) (*loghttp.QueryResponse, error) {
+	resp, err := c.send(queryRangePath, queryRangeParams(queryStr, limit, start, end, direction, step, interval), quiet, eventStreamType)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Println("error closing body", err)
+		}
+	}()
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), eventStreamType) {
+		// the server doesn't stream results.
+		var r loghttp.QueryResponse
+		if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+			return nil, err
+		}
+		return &r, nil
+	}
+	return readEvents(resp.Body, partial)
+}
+
+func queryRangeParams(queryStr string, limit int, start, end time.Time, direction logproto.Direction, step, interval time.Duration) string {
 	params := util.NewQueryStringBuilder()
 	params.SetString("query", queryStr)
 	params.SetInt32("limit", limit)
@@ -123,7 +172,57 @@ func (c *DefaultClient) QueryRange(queryStr string, limit int, start, end time.T
 		params.SetFloat("interval", interval.Seconds())
 	}
 
-	return c.doQuery(queryRangePath, params.Encode(), quiet)
+	return params.Encode()
+}
+
+// readEvents reads the server-sent events of a streamed query response up to
+// its result.
+func readEvents(r io.Reader, partial PartialResultFunc) (*loghttp.QueryResponse, error) {
+	var (
+		br    = bufio.NewReader(r)
+		event string
+		data  bytes.Buffer
+	)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			return nil, fmt.Errorf("query response ended before its result")
+		}
+		if err != nil {
+			return nil, err
+		}
+		line = bytes.TrimSuffix(line, []byte("\n"))
+
+		switch {
+		case len(line) == 0:
+			// an empty line ends an event.
+			switch event {
+			case eventPartial, eventProgress:
+				var resp loghttp.QueryResponse
+				if err := json.Unmarshal(data.Bytes(), &resp); err != nil {
+					return nil, err
+				}
+				partial(&resp, event == eventPartial)
+			case eventResult:
+				var resp loghttp.QueryResponse
+				if err := json.Unmarshal(data.Bytes(), &resp); err != nil {
+					return nil, err
+				}
+				return &resp, nil
+			case eventError:
+				return nil, fmt.Errorf("query failed: %s", data.String())
+			}
+			event = ""
+			data.Reset()
+		case bytes.HasPrefix(line, []byte("event: ")):
+			event = string(line[len("event: "):])
+		case bytes.HasPrefix(line, []byte("data: ")):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.Write(line[len("data: "):])
+		}
+	}
 }
 
 // ListLabelNames uses the /api/v1/label endpoint to list label names
@@ -274,22 +373,41 @@ func (c *DefaultClient) doQuery(
 }
 
 func (c *DefaultClient) doRequest(path, query string, quiet bool, out interface{}) error {
-	us, err := buildURL(c.Address, path, query)
+	resp, err := c.send(path, query, quiet, "")
 	if err != nil {
 		return err
 	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Println("error closing body", err)
+		}
+	}()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send sends a request, accepting the given content type when it is set,
+// and returns its successful response.
+func (c *DefaultClient) send(path, query string, quiet bool, accept string) (*http.Response, error) {
+	us, err := buildURL(c.Address, path, query)
+	if err != nil {
+		return nil, err
+	}
 	if !quiet {
 		log.Print(us)
 	}
 
 	req, err := http.NewRequest("GET", us, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	h, err := c.getHTTPRequestHeader()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if accept != "" {
+		h.Set("Accept", accept)
 	}
 	req.Header = h
 
@@ -301,14 +419,14 @@ func (c *DefaultClient) doRequest(path, query string, quiet bool, out interface{
 	if c.ProxyURL != "" {
 		prox, err := url.Parse(c.ProxyURL)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		clientConfig.ProxyURL = config.URL{URL: prox}
 	}
 
 	client, err := config.NewClientFromConfig(clientConfig, "promtail", config.WithHTTP2Disabled())
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if c.Tripperware != nil {
 		client.Transport = c.Tripperware(client.Transport)
@@ -351,15 +469,9 @@ func (c *DefaultClient) doRequest(path, query string, quiet bool, out interface{
 
 	}
 	if !success {
-		return fmt.Errorf("run out of attempts while querying the server")
+		return nil, fmt.Errorf("run out of attempts while querying the server")
 	}
-
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Println("error closing body", err)
-		}
-	}()
-	return json.NewDecoder(resp.Body).Decode(out)
+	return resp, nil
 }
 
 // nolint:goconst
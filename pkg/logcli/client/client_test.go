@@ -3,9 +3,12 @@ package client
 import (
 	"encoding/base64"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/loki/v3/pkg/loghttp"
 )
 
 func Test_buildURL(t *testing.T) {
@@ -78,3 +81,25 @@ func Test_getHTTPRequestHeader(t *testing.T) {
 		})
 	}
 }
+
+func Test_readEvents(t *testing.T) {
+	stream := `{"status":"success","data":{"resultType":"streams","result":[{"stream":{"foo":"bar"},"values":[["1","line"]]}]}}`
+	body := "event: partial\ndata: " + stream + "\n\n" +
+		"event: progress\ndata: " + stream + "\n\n" +
+		"event: result\ndata: " + stream + "\n\n"
+
+	var finals []bool
+	resp, err := readEvents(strings.NewReader(body), func(resp *loghttp.QueryResponse, final bool) {
+		assert.Equal(t, loghttp.ResultType(loghttp.ResultTypeStream), resp.Data.ResultType)
+		finals = append(finals, final)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, finals)
+	assert.Len(t, resp.Data.Result.(loghttp.Streams), 1)
+
+	_, err = readEvents(strings.NewReader("event: error\ndata: failed\ndata: query\n\n"), nil)
+	assert.EqualError(t, err, "query failed: failed\nquery")
+
+	_, err = readEvents(strings.NewReader("event: partial\ndata: "+stream+"\n\n"), func(*loghttp.QueryResponse, bool) {})
+	assert.Error(t, err)
+}
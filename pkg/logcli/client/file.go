@@ -225,6 +225,10 @@ func (l *limiter) MaxQuerySeries(_ context.Context, _ string) int {
 	return l.n
 }
 
+func (l *limiter) MaxQueryJoinEntries(_ context.Context, _ string) int {
+	return l.n
+}
+
 func (l *limiter) MaxQueryRange(_ context.Context, _ string) time.Duration {
 	return 0 * time.Second
 }
@@ -80,6 +80,10 @@ type Query struct {
 	// If MergeParts is false, this parameter has no effect, part files will be kept.
 	// Otherwise, if this is true, the part files will not be deleted once they have been merged.
 	KeepParts bool
+
+	// If true, the logs of range queries are printed as the parts of their
+	// results complete, when the client can stream results.
+	Stream bool
 }
 
 // DoQuery executes the query and prints out the results
@@ -151,7 +155,28 @@ func (q *Query) DoQuery(c client.Client, out output.LogOutput, statistics bool)
 				// correct amount of new logs knowing there will be some overlapping logs returned.
 				bs = q.Limit - total + len(lastEntry)
 			}
-			resp, err = c.QueryRange(q.QueryString, bs, start, end, d, q.Step, q.Interval, q.Quiet)
+			var streamed bool
+			if sc, ok := c.(client.StreamingClient); ok && q.Stream {
+				resultLength = 0
+				resp, err = sc.QueryRangeStream(q.QueryString, bs, start, end, d, q.Step, q.Interval, q.Quiet, func(partial *loghttp.QueryResponse, final bool) {
+					if !final || partial.Data.ResultType != loghttp.ResultTypeStream {
+						// only the final parts of log results can be printed
+						// ahead of the result.
+						if !q.Quiet {
+							log.Println("Received a partial result")
+						}
+						return
+					}
+					n, entry := result.PrintResult(partial.Data.Result, out, lastEntry)
+					if n > 0 {
+						resultLength += n
+						lastEntry = entry
+					}
+					streamed = true
+				})
+			} else {
+				resp, err = c.QueryRange(q.QueryString, bs, start, end, d, q.Step, q.Interval, q.Quiet)
+			}
 			if err != nil {
 				log.Fatalf("Query failed: %+v", err)
 			}
@@ -160,7 +185,9 @@ func (q *Query) DoQuery(c client.Client, out output.LogOutput, statistics bool)
 				result.PrintStats(resp.Data.Statistics)
 			}
 
-			resultLength, lastEntry = result.PrintResult(resp.Data.Result, out, lastEntry)
+			if !streamed {
+				resultLength, lastEntry = result.PrintResult(resp.Data.Result, out, lastEntry)
+			}
 			// Was not a log stream query, or no results, no more batching
 			if resultLength <= 0 {
 				break
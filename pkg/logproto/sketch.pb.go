@@ -171,6 +171,151 @@ func (m *QuantileSketchSample) GetMetric() []*LabelPair {
 	return nil
 }
 
+type CountDistinctSketchMatrix struct {
+	Values []*CountDistinctSketchVector `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+}
+
+func (m *CountDistinctSketchMatrix) Reset()      { *m = CountDistinctSketchMatrix{} }
+func (*CountDistinctSketchMatrix) ProtoMessage() {}
+func (*CountDistinctSketchMatrix) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7f9fd40e59b87ff3, []int{3}
+}
+func (m *CountDistinctSketchMatrix) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *CountDistinctSketchMatrix) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_CountDistinctSketchMatrix.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *CountDistinctSketchMatrix) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CountDistinctSketchMatrix.Merge(m, src)
+}
+func (m *CountDistinctSketchMatrix) XXX_Size() int {
+	return m.Size()
+}
+func (m *CountDistinctSketchMatrix) XXX_DiscardUnknown() {
+	xxx_messageInfo_CountDistinctSketchMatrix.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CountDistinctSketchMatrix proto.InternalMessageInfo
+
+func (m *CountDistinctSketchMatrix) GetValues() []*CountDistinctSketchVector {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+type CountDistinctSketchVector struct {
+	Samples []*CountDistinctSketchSample `protobuf:"bytes,1,rep,name=samples,proto3" json:"samples,omitempty"`
+}
+
+func (m *CountDistinctSketchVector) Reset()      { *m = CountDistinctSketchVector{} }
+func (*CountDistinctSketchVector) ProtoMessage() {}
+func (*CountDistinctSketchVector) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7f9fd40e59b87ff3, []int{4}
+}
+func (m *CountDistinctSketchVector) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *CountDistinctSketchVector) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_CountDistinctSketchVector.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *CountDistinctSketchVector) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CountDistinctSketchVector.Merge(m, src)
+}
+func (m *CountDistinctSketchVector) XXX_Size() int {
+	return m.Size()
+}
+func (m *CountDistinctSketchVector) XXX_DiscardUnknown() {
+	xxx_messageInfo_CountDistinctSketchVector.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CountDistinctSketchVector proto.InternalMessageInfo
+
+func (m *CountDistinctSketchVector) GetSamples() []*CountDistinctSketchSample {
+	if m != nil {
+		return m.Samples
+	}
+	return nil
+}
+
+type CountDistinctSketchSample struct {
+	Hyperloglog []byte       `protobuf:"bytes,1,opt,name=hyperloglog,proto3" json:"hyperloglog,omitempty"`
+	TimestampMs int64        `protobuf:"varint,2,opt,name=timestamp_ms,json=timestampMs,proto3" json:"timestamp_ms,omitempty"`
+	Metric      []*LabelPair `protobuf:"bytes,3,rep,name=metric,proto3" json:"metric,omitempty"`
+}
+
+func (m *CountDistinctSketchSample) Reset()      { *m = CountDistinctSketchSample{} }
+func (*CountDistinctSketchSample) ProtoMessage() {}
+func (*CountDistinctSketchSample) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7f9fd40e59b87ff3, []int{5}
+}
+func (m *CountDistinctSketchSample) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *CountDistinctSketchSample) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_CountDistinctSketchSample.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *CountDistinctSketchSample) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CountDistinctSketchSample.Merge(m, src)
+}
+func (m *CountDistinctSketchSample) XXX_Size() int {
+	return m.Size()
+}
+func (m *CountDistinctSketchSample) XXX_DiscardUnknown() {
+	xxx_messageInfo_CountDistinctSketchSample.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CountDistinctSketchSample proto.InternalMessageInfo
+
+func (m *CountDistinctSketchSample) GetHyperloglog() []byte {
+	if m != nil {
+		return m.Hyperloglog
+	}
+	return nil
+}
+
+func (m *CountDistinctSketchSample) GetTimestampMs() int64 {
+	if m != nil {
+		return m.TimestampMs
+	}
+	return 0
+}
+
+func (m *CountDistinctSketchSample) GetMetric() []*LabelPair {
+	if m != nil {
+		return m.Metric
+	}
+	return nil
+}
+
 type QuantileSketch struct {
 	// Types that are valid to be assigned to Sketch:
 	//	*QuantileSketch_Tdigest
@@ -181,7 +326,7 @@ type QuantileSketch struct {
 func (m *QuantileSketch) Reset()      { *m = QuantileSketch{} }
 func (*QuantileSketch) ProtoMessage() {}
 func (*QuantileSketch) Descriptor() ([]byte, []int) {
-	return fileDescriptor_7f9fd40e59b87ff3, []int{3}
+	return fileDescriptor_7f9fd40e59b87ff3, []int{6}
 }
 func (m *QuantileSketch) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -267,7 +412,7 @@ type TDigest struct {
 func (m *TDigest) Reset()      { *m = TDigest{} }
 func (*TDigest) ProtoMessage() {}
 func (*TDigest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_7f9fd40e59b87ff3, []int{4}
+	return fileDescriptor_7f9fd40e59b87ff3, []int{7}
 }
 func (m *TDigest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -332,7 +477,7 @@ type TDigest_Centroid struct {
 func (m *TDigest_Centroid) Reset()      { *m = TDigest_Centroid{} }
 func (*TDigest_Centroid) ProtoMessage() {}
 func (*TDigest_Centroid) Descriptor() ([]byte, []int) {
-	return fileDescriptor_7f9fd40e59b87ff3, []int{4, 0}
+	return fileDescriptor_7f9fd40e59b87ff3, []int{7, 0}
 }
 func (m *TDigest_Centroid) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -385,7 +530,7 @@ type CountMinSketch struct {
 func (m *CountMinSketch) Reset()      { *m = CountMinSketch{} }
 func (*CountMinSketch) ProtoMessage() {}
 func (*CountMinSketch) Descriptor() ([]byte, []int) {
-	return fileDescriptor_7f9fd40e59b87ff3, []int{5}
+	return fileDescriptor_7f9fd40e59b87ff3, []int{8}
 }
 func (m *CountMinSketch) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -444,7 +589,7 @@ type TopK struct {
 func (m *TopK) Reset()      { *m = TopK{} }
 func (*TopK) ProtoMessage() {}
 func (*TopK) Descriptor() ([]byte, []int) {
-	return fileDescriptor_7f9fd40e59b87ff3, []int{6}
+	return fileDescriptor_7f9fd40e59b87ff3, []int{9}
 }
 func (m *TopK) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -502,7 +647,7 @@ type TopK_Pair struct {
 func (m *TopK_Pair) Reset()      { *m = TopK_Pair{} }
 func (*TopK_Pair) ProtoMessage() {}
 func (*TopK_Pair) Descriptor() ([]byte, []int) {
-	return fileDescriptor_7f9fd40e59b87ff3, []int{6, 0}
+	return fileDescriptor_7f9fd40e59b87ff3, []int{9, 0}
 }
 func (m *TopK_Pair) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -552,7 +697,7 @@ type TopKMatrix struct {
 func (m *TopKMatrix) Reset()      { *m = TopKMatrix{} }
 func (*TopKMatrix) ProtoMessage() {}
 func (*TopKMatrix) Descriptor() ([]byte, []int) {
-	return fileDescriptor_7f9fd40e59b87ff3, []int{7}
+	return fileDescriptor_7f9fd40e59b87ff3, []int{10}
 }
 func (m *TopKMatrix) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -596,7 +741,7 @@ type TopKMatrix_Vector struct {
 func (m *TopKMatrix_Vector) Reset()      { *m = TopKMatrix_Vector{} }
 func (*TopKMatrix_Vector) ProtoMessage() {}
 func (*TopKMatrix_Vector) Descriptor() ([]byte, []int) {
-	return fileDescriptor_7f9fd40e59b87ff3, []int{7, 0}
+	return fileDescriptor_7f9fd40e59b87ff3, []int{10, 0}
 }
 func (m *TopKMatrix_Vector) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -643,6 +788,9 @@ func init() {
 	proto.RegisterType((*QuantileSketchMatrix)(nil), "logproto.QuantileSketchMatrix")
 	proto.RegisterType((*QuantileSketchVector)(nil), "logproto.QuantileSketchVector")
 	proto.RegisterType((*QuantileSketchSample)(nil), "logproto.QuantileSketchSample")
+	proto.RegisterType((*CountDistinctSketchMatrix)(nil), "logproto.CountDistinctSketchMatrix")
+	proto.RegisterType((*CountDistinctSketchVector)(nil), "logproto.CountDistinctSketchVector")
+	proto.RegisterType((*CountDistinctSketchSample)(nil), "logproto.CountDistinctSketchSample")
 	proto.RegisterType((*QuantileSketch)(nil), "logproto.QuantileSketch")
 	proto.RegisterType((*TDigest)(nil), "logproto.TDigest")
 	proto.RegisterType((*TDigest_Centroid)(nil), "logproto.TDigest.Centroid")
@@ -656,47 +804,50 @@ func init() {
 func init() { proto.RegisterFile("pkg/logproto/sketch.proto", fileDescriptor_7f9fd40e59b87ff3) }
 
 var fileDescriptor_7f9fd40e59b87ff3 = []byte{
-	// 626 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x54, 0x41, 0x4f, 0xd4, 0x4e,
-	0x14, 0xef, 0xfc, 0x77, 0xff, 0xcb, 0xf2, 0x16, 0x88, 0x8e, 0xc4, 0xd4, 0xc5, 0x4c, 0xd6, 0x1e,
-	0x84, 0x68, 0xdc, 0x4d, 0x20, 0x21, 0x9c, 0xc1, 0x03, 0x89, 0xa2, 0x38, 0x10, 0x63, 0x4c, 0x8c,
-	0x19, 0xda, 0xa1, 0x3b, 0xd9, 0xb6, 0xd3, 0x74, 0x66, 0x01, 0x6f, 0x7e, 0x02, 0x63, 0xfc, 0x14,
-	0x5e, 0xfd, 0x08, 0xde, 0x3c, 0x72, 0xe4, 0x28, 0xe5, 0xe2, 0x91, 0x8f, 0x60, 0x66, 0xda, 0x2e,
-	0x74, 0x89, 0xd1, 0xd3, 0xce, 0xfb, 0xbd, 0xdf, 0xfb, 0xcd, 0x6f, 0xde, 0xeb, 0x5b, 0xb8, 0x97,
-	0x8e, 0xc2, 0x41, 0x24, 0xc3, 0x34, 0x93, 0x5a, 0x0e, 0xd4, 0x88, 0x6b, 0x7f, 0xd8, 0xb7, 0x01,
-	0x6e, 0x57, 0x70, 0x77, 0xa9, 0x46, 0xaa, 0x0e, 0x05, 0xcd, 0x7b, 0x01, 0x8b, 0xaf, 0xc6, 0x2c,
-	0xd1, 0x22, 0xe2, 0x7b, 0xb6, 0x7c, 0x87, 0xe9, 0x4c, 0x9c, 0xe0, 0x75, 0x68, 0x1d, 0xb1, 0x68,
-	0xcc, 0x95, 0x8b, 0x7a, 0x8d, 0x95, 0xce, 0x2a, 0xe9, 0x4f, 0x0a, 0xeb, 0xfc, 0xd7, 0xdc, 0xd7,
-	0x32, 0xa3, 0x25, 0xdb, 0xdb, 0x9d, 0xd6, 0x2b, 0xf2, 0x78, 0x03, 0x66, 0x14, 0x8b, 0xd3, 0xe8,
-	0xef, 0x82, 0x7b, 0x96, 0x46, 0x2b, 0xba, 0xf7, 0x09, 0x4d, 0x4b, 0x16, 0x0c, 0xfc, 0x10, 0xd0,
-	0xa1, 0x8b, 0x7a, 0x68, 0xa5, 0xb3, 0xea, 0xfe, 0x49, 0x8c, 0xa2, 0x43, 0xfc, 0x00, 0xe6, 0xb4,
-	0x88, 0xb9, 0xd2, 0x2c, 0x4e, 0xdf, 0xc7, 0xca, 0xfd, 0xaf, 0x87, 0x56, 0x1a, 0xb4, 0x33, 0xc1,
-	0x76, 0x14, 0x7e, 0x0c, 0xad, 0x98, 0xeb, 0x4c, 0xf8, 0x6e, 0xc3, 0x9a, 0xbb, 0x73, 0xa5, 0xf7,
-	0x9c, 0x1d, 0xf0, 0x68, 0x97, 0x89, 0x8c, 0x96, 0x14, 0x2f, 0x84, 0x85, 0xfa, 0x25, 0xf8, 0x09,
-	0xcc, 0xe8, 0x40, 0x84, 0x5c, 0xe9, 0xd2, 0xcf, 0xed, 0xab, 0xfa, 0xfd, 0xa7, 0x36, 0xb1, 0xed,
-	0xd0, 0x8a, 0x83, 0xef, 0x43, 0x3b, 0x08, 0x8a, 0x61, 0x59, 0x33, 0x73, 0xdb, 0x0e, 0x9d, 0x20,
-	0x9b, 0x6d, 0x68, 0x15, 0x27, 0xef, 0x3b, 0x82, 0x99, 0xb2, 0x1c, 0xdf, 0x82, 0x46, 0x2c, 0x12,
-	0x2b, 0x8f, 0xa8, 0x39, 0x5a, 0x84, 0x9d, 0x58, 0x01, 0x83, 0xb0, 0x13, 0xdc, 0x83, 0x8e, 0x2f,
-	0xe3, 0x34, 0xe3, 0x4a, 0x09, 0x99, 0xb8, 0x0d, 0x9b, 0xb9, 0x0e, 0xe1, 0x0d, 0x98, 0x4d, 0x33,
-	0xe9, 0x73, 0xa5, 0x78, 0xe0, 0x36, 0xed, 0x53, 0xbb, 0x37, 0xac, 0xf6, 0xb7, 0x78, 0xa2, 0x33,
-	0x29, 0x02, 0x7a, 0x45, 0xee, 0xae, 0x43, 0xbb, 0x82, 0x31, 0x86, 0x66, 0xcc, 0x59, 0x65, 0xc6,
-	0x9e, 0xf1, 0x5d, 0x68, 0x1d, 0x73, 0x11, 0x0e, 0x75, 0x69, 0xa8, 0x8c, 0xbc, 0x37, 0xb0, 0xb0,
-	0x25, 0xc7, 0x89, 0xde, 0x11, 0x49, 0xd9, 0xac, 0x45, 0xf8, 0x3f, 0xe0, 0xa9, 0x1e, 0xda, 0xf2,
-	0x79, 0x5a, 0x04, 0x06, 0x3d, 0x16, 0x81, 0x2e, 0x1a, 0x32, 0x4f, 0x8b, 0x00, 0x77, 0xa1, 0xed,
-	0x9b, 0x6a, 0x9e, 0x29, 0x3b, 0x99, 0x79, 0x3a, 0x89, 0xbd, 0x6f, 0x08, 0x9a, 0xfb, 0x32, 0x7d,
-	0x86, 0x1f, 0x41, 0xc3, 0x8f, 0xd5, 0xcd, 0x2f, 0xa1, 0x7e, 0x2f, 0x35, 0x24, 0xbc, 0x0c, 0xcd,
-	0x48, 0x28, 0x63, 0x72, 0x6a, 0xcc, 0x46, 0xa9, 0x6f, 0xc7, 0x6c, 0x09, 0xa6, 0x97, 0xc3, 0x0f,
-	0x29, 0xcf, 0x22, 0x19, 0x46, 0x32, 0xb4, 0xbd, 0x9c, 0xa3, 0xd7, 0xa1, 0xee, 0x2a, 0x34, 0x0d,
-	0xdf, 0x38, 0xe7, 0x47, 0x3c, 0x29, 0x46, 0x3f, 0x4b, 0x8b, 0xc0, 0xa0, 0xd6, 0x69, 0xf5, 0x1e,
-	0x1b, 0x78, 0x5f, 0x10, 0x80, 0xb9, 0xa9, 0x5c, 0xb2, 0xb5, 0xa9, 0x25, 0x5b, 0xaa, 0xfb, 0x29,
-	0x58, 0xfd, 0xfa, 0x86, 0x75, 0x5f, 0x42, 0xab, 0xdc, 0x29, 0x0f, 0x9a, 0x5a, 0xa6, 0xa3, 0xf2,
-	0xe5, 0x0b, 0xf5, 0x62, 0x6a, 0x73, 0xff, 0xf0, 0xf1, 0x6f, 0xbe, 0x3b, 0x3d, 0x27, 0xce, 0xd9,
-	0x39, 0x71, 0x2e, 0xcf, 0x09, 0xfa, 0x98, 0x13, 0xf4, 0x35, 0x27, 0xe8, 0x47, 0x4e, 0xd0, 0x69,
-	0x4e, 0xd0, 0xcf, 0x9c, 0xa0, 0x5f, 0x39, 0x71, 0x2e, 0x73, 0x82, 0x3e, 0x5f, 0x10, 0xe7, 0xf4,
-	0x82, 0x38, 0x67, 0x17, 0xc4, 0x79, 0xbb, 0x1c, 0x0a, 0x3d, 0x1c, 0x1f, 0xf4, 0x7d, 0x19, 0x0f,
-	0xc2, 0x8c, 0x1d, 0xb2, 0x84, 0x0d, 0x22, 0x39, 0x12, 0x83, 0xa3, 0xb5, 0xc1, 0xf5, 0x3f, 0x9c,
-	0x83, 0x96, 0xfd, 0x59, 0xfb, 0x1d, 0x00, 0x00, 0xff, 0xff, 0x9e, 0x82, 0x05, 0x2f, 0xac, 0x04,
-	0x00, 0x00,
+	// 680 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x54, 0x41, 0x4f, 0x13, 0x4f,
+	0x14, 0xdf, 0xa1, 0xfd, 0x97, 0xf2, 0x0a, 0xe4, 0xef, 0xd8, 0x98, 0x6d, 0x31, 0x93, 0xba, 0x26,
+	0x42, 0x34, 0xb6, 0x09, 0x24, 0x84, 0xc4, 0x78, 0x01, 0x0e, 0x24, 0x8a, 0xe2, 0x40, 0x0c, 0x21,
+	0x31, 0x66, 0xd9, 0x0e, 0xdb, 0x49, 0x77, 0x77, 0x36, 0x3b, 0x53, 0xc0, 0x9b, 0x5f, 0x40, 0x63,
+	0xfc, 0x14, 0x5e, 0xfd, 0x08, 0xde, 0x3c, 0x72, 0xe4, 0x28, 0xe5, 0xe2, 0x91, 0x8f, 0x60, 0x76,
+	0x76, 0xb7, 0x65, 0x17, 0x50, 0x0f, 0x9e, 0x3a, 0xef, 0x37, 0xbf, 0xf7, 0xf6, 0x37, 0xbf, 0xf7,
+	0x5e, 0xa1, 0x11, 0xf6, 0xdd, 0x8e, 0x27, 0xdc, 0x30, 0x12, 0x4a, 0x74, 0x64, 0x9f, 0x29, 0xa7,
+	0xd7, 0xd6, 0x01, 0xae, 0x66, 0x70, 0x73, 0x2e, 0x47, 0xca, 0x0e, 0x09, 0xcd, 0x7a, 0x01, 0xf5,
+	0x57, 0x03, 0x3b, 0x50, 0xdc, 0x63, 0xdb, 0x3a, 0x7d, 0xd3, 0x56, 0x11, 0x3f, 0xc6, 0xcb, 0x50,
+	0x39, 0xb4, 0xbd, 0x01, 0x93, 0x26, 0x6a, 0x95, 0x16, 0x6a, 0x8b, 0xa4, 0x3d, 0x4a, 0xcc, 0xf3,
+	0x5f, 0x33, 0x47, 0x89, 0x88, 0xa6, 0x6c, 0x6b, 0x0b, 0xea, 0xd7, 0xdd, 0xe3, 0x15, 0x98, 0x94,
+	0xb6, 0x1f, 0x7a, 0x7f, 0x2e, 0xb8, 0xad, 0x69, 0x34, 0xa3, 0x5b, 0x1f, 0x11, 0xd4, 0xaf, 0x63,
+	0xe0, 0x07, 0x80, 0x0e, 0x4c, 0xd4, 0x42, 0x0b, 0xb5, 0x45, 0xf3, 0xa6, 0x62, 0x14, 0x1d, 0xe0,
+	0x7b, 0x30, 0xad, 0xb8, 0xcf, 0xa4, 0xb2, 0xfd, 0xf0, 0xad, 0x2f, 0xcd, 0x89, 0x16, 0x5a, 0x28,
+	0xd1, 0xda, 0x08, 0xdb, 0x94, 0xf8, 0x11, 0x54, 0x7c, 0xa6, 0x22, 0xee, 0x98, 0x25, 0x2d, 0xee,
+	0xf6, 0xb8, 0xde, 0x73, 0x7b, 0x9f, 0x79, 0x5b, 0x36, 0x8f, 0x68, 0x4a, 0xb1, 0x76, 0xa1, 0xb1,
+	0x26, 0x06, 0x81, 0x5a, 0xe7, 0x52, 0xf1, 0xc0, 0x51, 0x39, 0xdf, 0x9e, 0x14, 0x7c, 0xbb, 0x3f,
+	0xae, 0x74, 0x4d, 0x52, 0xc1, 0xbc, 0x3d, 0x68, 0xdc, 0x48, 0xc2, 0x4f, 0x8b, 0x0e, 0xfe, 0xbe,
+	0x74, 0xd1, 0xc6, 0x0f, 0x08, 0x1a, 0x37, 0xd2, 0x70, 0x0b, 0x6a, 0xbd, 0x77, 0x21, 0x8b, 0x3c,
+	0xe1, 0x7a, 0xc2, 0xd5, 0xae, 0x4e, 0xd3, 0xcb, 0xd0, 0x3f, 0x77, 0xd1, 0x85, 0xd9, 0x7c, 0xab,
+	0xf0, 0x63, 0x98, 0x54, 0x5d, 0xee, 0x32, 0xa9, 0xd2, 0xae, 0xde, 0x1a, 0xe7, 0xef, 0xac, 0xeb,
+	0x8b, 0x0d, 0x83, 0x66, 0x1c, 0x7c, 0x17, 0xaa, 0xdd, 0x6e, 0x32, 0xf2, 0x5a, 0xcc, 0xf4, 0x86,
+	0x41, 0x47, 0xc8, 0x6a, 0x15, 0x2a, 0xc9, 0xc9, 0xfa, 0x86, 0x60, 0x32, 0x4d, 0xc7, 0xff, 0x43,
+	0xc9, 0xe7, 0x81, 0x2e, 0x8f, 0x68, 0x7c, 0xd4, 0x88, 0x7d, 0x6c, 0x4e, 0xa4, 0x88, 0x7d, 0x1c,
+	0x5b, 0xe1, 0x08, 0x3f, 0x8c, 0x98, 0x94, 0x5c, 0x04, 0x66, 0x49, 0xdf, 0x5c, 0x86, 0xf0, 0x0a,
+	0x4c, 0x85, 0x91, 0x70, 0x98, 0x94, 0xac, 0x6b, 0x96, 0xf5, 0x53, 0x9b, 0x57, 0xa4, 0xb6, 0xd7,
+	0x58, 0xa0, 0x22, 0xc1, 0xbb, 0x74, 0x4c, 0x6e, 0x2e, 0x43, 0x35, 0x83, 0x31, 0x86, 0xb2, 0xcf,
+	0xec, 0x4c, 0x8c, 0x3e, 0xe3, 0x3b, 0x50, 0x39, 0x62, 0xdc, 0xed, 0xa9, 0x54, 0x50, 0x1a, 0x59,
+	0xbb, 0x30, 0xab, 0x7b, 0xb7, 0xc9, 0x83, 0xd4, 0xac, 0x3a, 0xfc, 0xd7, 0x65, 0xa1, 0xea, 0xe9,
+	0xf4, 0x19, 0x9a, 0x04, 0x31, 0x7a, 0xc4, 0xbb, 0x2a, 0x31, 0x64, 0x86, 0x26, 0x01, 0x6e, 0x42,
+	0xd5, 0x89, 0xb3, 0x59, 0x24, 0x75, 0x67, 0x66, 0xe8, 0x28, 0xb6, 0xbe, 0x22, 0x28, 0xef, 0x88,
+	0xf0, 0x19, 0x7e, 0x08, 0x25, 0xc7, 0x97, 0x57, 0xf7, 0x29, 0xff, 0x5d, 0x1a, 0x93, 0xf0, 0x3c,
+	0x94, 0x3d, 0x2e, 0x63, 0x91, 0x85, 0x36, 0xc7, 0x95, 0xda, 0xba, 0xcd, 0x9a, 0x50, 0x1c, 0xab,
+	0xd2, 0x95, 0xb1, 0x6a, 0x2e, 0x42, 0x39, 0xe6, 0xc7, 0xca, 0xd9, 0x21, 0x0b, 0x92, 0xd6, 0x4f,
+	0xd1, 0x24, 0x88, 0x51, 0xad, 0x34, 0x7b, 0x8f, 0x0e, 0xac, 0xcf, 0x08, 0x20, 0xfe, 0x52, 0xba,
+	0x72, 0x4b, 0x85, 0x95, 0x9b, 0xcb, 0xeb, 0x49, 0x58, 0xed, 0xfc, 0xaa, 0x35, 0x5f, 0x42, 0x25,
+	0xdd, 0x2b, 0x0b, 0xca, 0x4a, 0x84, 0xfd, 0xf4, 0xe5, 0xb3, 0xf9, 0x64, 0xaa, 0xef, 0xfe, 0x62,
+	0xf8, 0x57, 0xdf, 0x9c, 0x9c, 0x11, 0xe3, 0xf4, 0x8c, 0x18, 0x17, 0x67, 0x04, 0xbd, 0x1f, 0x12,
+	0xf4, 0x65, 0x48, 0xd0, 0xf7, 0x21, 0x41, 0x27, 0x43, 0x82, 0x7e, 0x0c, 0x09, 0xfa, 0x39, 0x24,
+	0xc6, 0xc5, 0x90, 0xa0, 0x4f, 0xe7, 0xc4, 0x38, 0x39, 0x27, 0xc6, 0xe9, 0x39, 0x31, 0xf6, 0xe6,
+	0x5d, 0xae, 0x7a, 0x83, 0xfd, 0xb6, 0x23, 0xfc, 0x8e, 0x1b, 0xd9, 0x07, 0x76, 0x60, 0x77, 0x3c,
+	0xd1, 0xe7, 0x9d, 0xc3, 0xa5, 0xce, 0xe5, 0xbf, 0xed, 0xfd, 0x8a, 0xfe, 0x59, 0xfa, 0x35, 0x00,
+	0x7e, 0xc5, 0x90, 0x17, 0xf2, 0x05, 0x00, 0x00,
 }
 
 func (this *QuantileSketchMatrix) Equal(that interface{}) bool {
@@ -792,6 +943,99 @@ func (this *QuantileSketchSample) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *CountDistinctSketchMatrix) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*CountDistinctSketchMatrix)
+	if !ok {
+		that2, ok := that.(CountDistinctSketchMatrix)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Values) != len(that1.Values) {
+		return false
+	}
+	for i := range this.Values {
+		if !this.Values[i].Equal(that1.Values[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *CountDistinctSketchVector) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*CountDistinctSketchVector)
+	if !ok {
+		that2, ok := that.(CountDistinctSketchVector)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Samples) != len(that1.Samples) {
+		return false
+	}
+	for i := range this.Samples {
+		if !this.Samples[i].Equal(that1.Samples[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *CountDistinctSketchSample) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*CountDistinctSketchSample)
+	if !ok {
+		that2, ok := that.(CountDistinctSketchSample)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !bytes.Equal(this.Hyperloglog, that1.Hyperloglog) {
+		return false
+	}
+	if this.TimestampMs != that1.TimestampMs {
+		return false
+	}
+	if len(this.Metric) != len(that1.Metric) {
+		return false
+	}
+	for i := range this.Metric {
+		if !this.Metric[i].Equal(that1.Metric[i]) {
+			return false
+		}
+	}
+	return true
+}
 func (this *QuantileSketch) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
@@ -1128,6 +1372,44 @@ func (this *QuantileSketchSample) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *CountDistinctSketchMatrix) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&logproto.CountDistinctSketchMatrix{")
+	if this.Values != nil {
+		s = append(s, "Values: "+fmt.Sprintf("%#v", this.Values)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *CountDistinctSketchVector) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&logproto.CountDistinctSketchVector{")
+	if this.Samples != nil {
+		s = append(s, "Samples: "+fmt.Sprintf("%#v", this.Samples)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *CountDistinctSketchSample) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&logproto.CountDistinctSketchSample{")
+	s = append(s, "Hyperloglog: "+fmt.Sprintf("%#v", this.Hyperloglog)+",\n")
+	s = append(s, "TimestampMs: "+fmt.Sprintf("%#v", this.TimestampMs)+",\n")
+	if this.Metric != nil {
+		s = append(s, "Metric: "+fmt.Sprintf("%#v", this.Metric)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func (this *QuantileSketch) GoString() string {
 	if this == nil {
 		return "nil"
@@ -1382,7 +1664,7 @@ func (m *QuantileSketchSample) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func (m *QuantileSketch) Marshal() (dAtA []byte, err error) {
+func (m *CountDistinctSketchMatrix) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -1392,35 +1674,158 @@ func (m *QuantileSketch) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *QuantileSketch) MarshalTo(dAtA []byte) (int, error) {
+func (m *CountDistinctSketchMatrix) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *QuantileSketch) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *CountDistinctSketchMatrix) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
-	if m.Sketch != nil {
-		{
-			size := m.Sketch.Size()
-			i -= size
-			if _, err := m.Sketch.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
+	if len(m.Values) > 0 {
+		for iNdEx := len(m.Values) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Values[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintSketch(dAtA, i, uint64(size))
 			}
+			i--
+			dAtA[i] = 0xa
 		}
 	}
 	return len(dAtA) - i, nil
 }
 
-func (m *QuantileSketch_Tdigest) MarshalTo(dAtA []byte) (int, error) {
-	return m.MarshalToSizedBuffer(dAtA[:m.Size()])
+func (m *CountDistinctSketchVector) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
 }
 
-func (m *QuantileSketch_Tdigest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *CountDistinctSketchVector) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CountDistinctSketchVector) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
-	if m.Tdigest != nil {
+	_ = i
+	var l int
+	_ = l
+	if len(m.Samples) > 0 {
+		for iNdEx := len(m.Samples) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Samples[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintSketch(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *CountDistinctSketchSample) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CountDistinctSketchSample) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CountDistinctSketchSample) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Metric) > 0 {
+		for iNdEx := len(m.Metric) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Metric[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintSketch(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if m.TimestampMs != 0 {
+		i = encodeVarintSketch(dAtA, i, uint64(m.TimestampMs))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Hyperloglog) > 0 {
+		i -= len(m.Hyperloglog)
+		copy(dAtA[i:], m.Hyperloglog)
+		i = encodeVarintSketch(dAtA, i, uint64(len(m.Hyperloglog)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *QuantileSketch) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *QuantileSketch) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QuantileSketch) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Sketch != nil {
+		{
+			size := m.Sketch.Size()
+			i -= size
+			if _, err := m.Sketch.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *QuantileSketch_Tdigest) MarshalTo(dAtA []byte) (int, error) {
+	return m.MarshalToSizedBuffer(dAtA[:m.Size()])
+}
+
+func (m *QuantileSketch_Tdigest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.Tdigest != nil {
 		{
 			size, err := m.Tdigest.MarshalToSizedBuffer(dAtA[:i])
 			if err != nil {
@@ -1821,6 +2226,58 @@ func (m *QuantileSketchSample) Size() (n int) {
 	return n
 }
 
+func (m *CountDistinctSketchMatrix) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Values) > 0 {
+		for _, e := range m.Values {
+			l = e.Size()
+			n += 1 + l + sovSketch(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *CountDistinctSketchVector) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Samples) > 0 {
+		for _, e := range m.Samples {
+			l = e.Size()
+			n += 1 + l + sovSketch(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *CountDistinctSketchSample) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Hyperloglog)
+	if l > 0 {
+		n += 1 + l + sovSketch(uint64(l))
+	}
+	if m.TimestampMs != 0 {
+		n += 1 + sovSketch(uint64(m.TimestampMs))
+	}
+	if len(m.Metric) > 0 {
+		for _, e := range m.Metric {
+			l = e.Size()
+			n += 1 + l + sovSketch(uint64(l))
+		}
+	}
+	return n
+}
+
 func (m *QuantileSketch) Size() (n int) {
 	if m == nil {
 		return 0
@@ -2041,6 +2498,53 @@ func (this *QuantileSketchSample) String() string {
 	}, "")
 	return s
 }
+func (this *CountDistinctSketchMatrix) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForValues := "[]*CountDistinctSketchVector{"
+	for _, f := range this.Values {
+		repeatedStringForValues += strings.Replace(f.String(), "CountDistinctSketchVector", "CountDistinctSketchVector", 1) + ","
+	}
+	repeatedStringForValues += "}"
+	s := strings.Join([]string{`&CountDistinctSketchMatrix{`,
+		`Values:` + repeatedStringForValues + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *CountDistinctSketchVector) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForSamples := "[]*CountDistinctSketchSample{"
+	for _, f := range this.Samples {
+		repeatedStringForSamples += strings.Replace(f.String(), "CountDistinctSketchSample", "CountDistinctSketchSample", 1) + ","
+	}
+	repeatedStringForSamples += "}"
+	s := strings.Join([]string{`&CountDistinctSketchVector{`,
+		`Samples:` + repeatedStringForSamples + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *CountDistinctSketchSample) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMetric := "[]*LabelPair{"
+	for _, f := range this.Metric {
+		repeatedStringForMetric += strings.Replace(fmt.Sprintf("%v", f), "LabelPair", "LabelPair", 1) + ","
+	}
+	repeatedStringForMetric += "}"
+	s := strings.Join([]string{`&CountDistinctSketchSample{`,
+		`Hyperloglog:` + fmt.Sprintf("%v", this.Hyperloglog) + `,`,
+		`TimestampMs:` + fmt.Sprintf("%v", this.TimestampMs) + `,`,
+		`Metric:` + repeatedStringForMetric + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func (this *QuantileSketch) String() string {
 	if this == nil {
 		return "nil"
@@ -2490,6 +2994,320 @@ func (m *QuantileSketchSample) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *CountDistinctSketchMatrix) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSketch
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CountDistinctSketchMatrix: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CountDistinctSketchMatrix: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Values", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSketch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSketch
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSketch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Values = append(m.Values, &CountDistinctSketchVector{})
+			if err := m.Values[len(m.Values)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSketch(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSketch
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthSketch
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *CountDistinctSketchVector) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSketch
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CountDistinctSketchVector: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CountDistinctSketchVector: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Samples", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSketch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSketch
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSketch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Samples = append(m.Samples, &CountDistinctSketchSample{})
+			if err := m.Samples[len(m.Samples)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSketch(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSketch
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthSketch
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *CountDistinctSketchSample) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSketch
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CountDistinctSketchSample: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CountDistinctSketchSample: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hyperloglog", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSketch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthSketch
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSketch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Hyperloglog = append(m.Hyperloglog[:0], dAtA[iNdEx:postIndex]...)
+			if m.Hyperloglog == nil {
+				m.Hyperloglog = []byte{}
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TimestampMs", wireType)
+			}
+			m.TimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSketch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metric", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSketch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSketch
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSketch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Metric = append(m.Metric, &LabelPair{})
+			if err := m.Metric[len(m.Metric)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSketch(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSketch
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthSketch
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *QuantileSketch) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
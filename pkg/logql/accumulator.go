@@ -71,6 +71,38 @@ func (a *QuantileSketchAccumulator) Result() []logqlmodel.Result {
 	return []logqlmodel.Result{{Data: a.matrix}}
 }
 
+type CountDistinctSketchAccumulator struct {
+	matrix ProbabilisticCountDistinctMatrix
+}
+
+// newCountDistinctSketchAccumulator returns an accumulator for sharded
+// probabilistic count distinct queries that merges results as they come in.
+func newCountDistinctSketchAccumulator() *CountDistinctSketchAccumulator {
+	return &CountDistinctSketchAccumulator{}
+}
+
+func (a *CountDistinctSketchAccumulator) Accumulate(_ context.Context, res logqlmodel.Result, _ int) error {
+	if res.Data.Type() != CountDistinctSketchMatrixType {
+		return fmt.Errorf("unexpected matrix data type: got (%s), want (%s)", res.Data.Type(), CountDistinctSketchMatrixType)
+	}
+	data, ok := res.Data.(ProbabilisticCountDistinctMatrix)
+	if !ok {
+		return fmt.Errorf("unexpected matrix type: got (%T), want (ProbabilisticCountDistinctMatrix)", res.Data)
+	}
+	if a.matrix == nil {
+		a.matrix = data
+		return nil
+	}
+
+	var err error
+	a.matrix, err = a.matrix.Merge(data)
+	return err
+}
+
+func (a *CountDistinctSketchAccumulator) Result() []logqlmodel.Result {
+	return []logqlmodel.Result{{Data: a.matrix}}
+}
+
 // heap impl for keeping only the top n results across m streams
 // importantly, AccumulatedStreams is _bounded_, so it will only
 // store the top `limit` results across all streams.
@@ -0,0 +1,77 @@
+package logql
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+)
+
+// shiftedParams moves the time range of the query back by the shift of a
+// changes_vs expression, keeping the same step.
+type shiftedParams struct {
+	Params
+	shift time.Duration
+}
+
+func (p shiftedParams) Start() time.Time { return p.Params.Start().Add(-p.shift) }
+func (p shiftedParams) End() time.Time   { return p.Params.End().Add(-p.shift) }
+
+// changesVsBinOp subtracts the shifted leg from the current one, matching
+// series with identical labels.
+func changesVsBinOp(expr *syntax.ChangesVsExpr) *syntax.BinOpExpr {
+	return &syntax.BinOpExpr{
+		SampleExpr: expr.Left,
+		RHS:        expr.Left,
+		Op:         syntax.OpTypeSub,
+		Opts: &syntax.BinOpOptions{
+			VectorMatching: &syntax.VectorMatching{Card: syntax.CardOneToOne},
+		},
+	}
+}
+
+// newChangesVsEvaluator evaluates the compared query over the query range and
+// over the range shifted by the shift of the expression, and subtracts the
+// shifted results from the current ones step by step.
+func newChangesVsEvaluator(
+	ctx context.Context,
+	evFactory SampleEvaluatorFactory,
+	expr *syntax.ChangesVsExpr,
+	q Params,
+) (StepEvaluator, error) {
+	var lse, rse StepEvaluator
+
+	ctx, cancel := context.WithCancel(ctx)
+	g := errgroup.Group{}
+
+	// load both legs in parallel
+	g.Go(func() error {
+		var err error
+		lse, err = evFactory.NewStepEvaluator(ctx, evFactory, expr.Left, q)
+		if err != nil {
+			cancel()
+		}
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		rse, err = evFactory.NewStepEvaluator(ctx, evFactory, expr.Left, shiftedParams{Params: q, shift: expr.Shift})
+		if err != nil {
+			cancel()
+		}
+		return err
+	})
+
+	// ensure both sides are loaded before returning the combined evaluator
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return &BinOpStepEvaluator{
+		rse:  rse,
+		lse:  lse,
+		expr: changesVsBinOp(expr),
+	}, nil
+}
@@ -0,0 +1,83 @@
+package logql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/logql/sketch"
+	"github.com/grafana/loki/v3/pkg/logqlmodel"
+)
+
+func TestProbabilisticCountDistinctMatrixSerialization(t *testing.T) {
+	s := sketch.NewDistinctSketch()
+	for i := uint64(0); i < 100; i++ {
+		s.AddHash(i * 0x9E3779B97F4A7C15)
+	}
+
+	matrix := ProbabilisticCountDistinctMatrix([]ProbabilisticCountDistinctVector{
+		[]ProbabilisticCountDistinctSample{
+			{T: 42, F: s, Metric: []labels.Label{{Name: "foo", Value: "bar"}}},
+		},
+	})
+
+	proto, err := matrix.ToProto()
+	require.NoError(t, err)
+
+	actual, err := ProbabilisticCountDistinctMatrixFromProto(proto)
+	require.NoError(t, err)
+	require.Len(t, actual, 1)
+	require.Len(t, actual[0], 1)
+	require.Equal(t, int64(42), actual[0][0].T)
+	require.Equal(t, labels.FromStrings("foo", "bar"), actual[0][0].Metric)
+	require.Equal(t, s.Count(), actual[0][0].F.Count())
+}
+
+func TestProbabilisticCountDistinctVectorMerge(t *testing.T) {
+	sample := func(metric labels.Labels, from, to uint64) ProbabilisticCountDistinctSample {
+		s := sketch.NewDistinctSketch()
+		for i := from; i < to; i++ {
+			s.AddHash(i * 0x9E3779B97F4A7C15)
+		}
+		return ProbabilisticCountDistinctSample{F: s, Metric: metric}
+	}
+	foo, bar := labels.FromStrings("app", "foo"), labels.FromStrings("app", "bar")
+
+	// the values counted on both sides are only counted once.
+	left := ProbabilisticCountDistinctVector{sample(foo, 0, 100)}
+	right := ProbabilisticCountDistinctVector{sample(foo, 50, 150), sample(bar, 0, 10)}
+	merged, err := left.Merge(right)
+	require.NoError(t, err)
+	require.Len(t, merged, 2)
+	require.Equal(t, foo, merged[0].Metric)
+	require.Equal(t, uint64(150), merged[0].F.Count())
+	require.Equal(t, bar, merged[1].Metric)
+	require.Equal(t, uint64(10), merged[1].F.Count())
+}
+
+func TestCountDistinctSketchStepEvaluatorError(t *testing.T) {
+	iter := errorRangeVectorIterator{
+		result: ProbabilisticCountDistinctVector([]ProbabilisticCountDistinctSample{
+			{T: 43, F: nil, Metric: labels.Labels{{Name: logqlmodel.ErrorLabel, Value: "my error"}}},
+		}),
+	}
+	ev := CountDistinctSketchStepEvaluator{
+		iter: iter,
+	}
+	ok, _, _ := ev.Next()
+	require.False(t, ok)
+
+	err := ev.Error()
+	require.ErrorContains(t, err, "my error")
+}
+
+func TestJoinCountDistinctSketchVectorError(t *testing.T) {
+	result := ProbabilisticCountDistinctVector{}
+	ev := errorStepEvaluator{
+		err: errors.New("could not evaluate"),
+	}
+	_, err := MergeCountDistinctSketchVector(true, result, ev, LiteralParams{})
+	require.ErrorContains(t, err, "could not evaluate")
+}
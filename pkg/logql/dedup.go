@@ -0,0 +1,247 @@
+package logql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"golang.org/x/exp/maps"
+
+	"github.com/grafana/loki/v3/pkg/iter"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/log"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/logqlmodel"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/metadata"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase/definitions"
+)
+
+// DedupStage returns the dedup stage of a log query, if any.
+func DedupStage(expr syntax.LogSelectorExpr) *log.Dedup {
+	d := syntax.DedupStage(expr)
+	if d == nil {
+		return nil
+	}
+	return log.NewDedup(d.Labels, d.Within)
+}
+
+// readDedupedStreams reads the entries of the iterator, collapsing identical
+// entries, and returns the first size of them. Once the size is reached, the
+// entries are still read to count the ones collapsed into the returned
+// entries, until none can be collapsed anymore.
+func readDedupedStreams(i iter.EntryIterator, dedup *log.Dedup, size uint32) (logqlmodel.Streams, error) {
+	d := newDedupEntries(dedup, size)
+	for i.Next() {
+		if !d.add(i.Labels(), i.Entry()) {
+			break
+		}
+	}
+	return d.streams(), i.Error()
+}
+
+type dedupedLabels struct {
+	metric labels.Labels
+	count  int64
+}
+
+type dedupedEntry struct {
+	labels labels.Labels
+	entry  logproto.Entry
+	count  int64
+}
+
+// dedupEntries collapses identical entries added in the direction of the query
+// into the first of them, counting the entries collapsed into it in the
+// DedupCountLabel label.
+type dedupEntries struct {
+	dedup   *log.Dedup
+	limit   int
+	groups  map[string]int // the last entry of each key
+	entries []dedupedEntry
+
+	metrics map[string]dedupedLabels
+}
+
+func newDedupEntries(dedup *log.Dedup, limit uint32) *dedupEntries {
+	return &dedupEntries{
+		dedup:   dedup,
+		limit:   int(limit),
+		groups:  map[string]int{},
+		metrics: map[string]dedupedLabels{},
+	}
+}
+
+// add collapses an entry into an identical entry or adds it to the entries
+// until the limit of entries is reached. The entry counts for the entries
+// already collapsed into it by a downstream query. It returns false once the
+// entries added next can't be collapsed into the entries anymore.
+func (d *dedupEntries) add(lbs string, entry logproto.Entry) bool {
+	l := d.labels(lbs)
+	key := d.dedup.Key(entry.Line, func(name string) string {
+		if v := l.metric.Get(name); v != "" {
+			return v
+		}
+		for _, adapters := range [][]logproto.LabelAdapter{entry.Parsed, entry.StructuredMetadata} {
+			for _, a := range adapters {
+				if a.Name == name {
+					return a.Value
+				}
+			}
+		}
+		return ""
+	})
+
+	if i, ok := d.groups[key]; ok && d.dedup.Collapse(d.entries[i].entry.Timestamp, entry.Timestamp) {
+		d.entries[i].count += l.count
+		return true
+	}
+	if len(d.entries) >= d.limit {
+		// the entries added next are further from the last entry than this
+		// one, so they can only be collapsed without duration.
+		return len(d.entries) > 0 && d.dedup.Collapse(d.entries[len(d.entries)-1].entry.Timestamp, entry.Timestamp)
+	}
+	d.groups[key] = len(d.entries)
+	d.entries = append(d.entries, dedupedEntry{labels: l.metric, entry: entry, count: l.count})
+	return true
+}
+
+// labels returns the labels of an entry without the count of a downstream
+// dedup stage, and that count.
+func (d *dedupEntries) labels(lbs string) dedupedLabels {
+	l, ok := d.metrics[lbs]
+	if ok {
+		return l
+	}
+	metric, err := syntax.ParseLabels(lbs)
+	if err != nil {
+		metric = labels.EmptyLabels()
+	}
+	l = dedupedLabels{metric: metric, count: 1}
+	if v := metric.Get(log.DedupCountLabel); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			l.count = n
+		}
+		l.metric = labels.NewBuilder(metric).Del(log.DedupCountLabel).Labels()
+	}
+	d.metrics[lbs] = l
+	return l
+}
+
+// streams returns the entries grouped by stream, the labels of an entry being
+// extended with its count. Streams are ordered by labels and their entries are
+// in the direction of the query as they were added.
+func (d *dedupEntries) streams() logqlmodel.Streams {
+	byLabels := map[string]*logproto.Stream{}
+	for _, e := range d.entries {
+		lbs := labels.NewBuilder(e.labels).Set(log.DedupCountLabel, strconv.FormatInt(e.count, 10)).Labels().String()
+		s, ok := byLabels[lbs]
+		if !ok {
+			s = &logproto.Stream{Labels: lbs}
+			byLabels[lbs] = s
+		}
+		s.Entries = append(s.Entries, e.entry)
+	}
+
+	result := make(logqlmodel.Streams, 0, len(byLabels))
+	for _, s := range byLabels {
+		result = append(result, *s)
+	}
+	sort.Sort(result)
+	return result
+}
+
+type accumulatedEntry struct {
+	labels string
+	entry  logproto.Entry
+}
+
+// DedupStreamsAccumulator is the accumulator of log queries using a dedup
+// stage. Identical entries of different downstream queries are collapsed
+// again, adding up their counts.
+type DedupStreamsAccumulator struct {
+	dedup   *log.Dedup
+	limit   uint32
+	forward bool
+	entries []accumulatedEntry
+
+	stats    stats.Result        // for accumulating statistics from downstream requests
+	headers  map[string][]string // for accumulating headers from downstream requests
+	warnings map[string]struct{} // for accumulating warnings from downstream requests
+}
+
+func NewDedupStreamsAccumulator(dedup *log.Dedup, limit uint32, dir logproto.Direction) *DedupStreamsAccumulator {
+	return &DedupStreamsAccumulator{
+		dedup:    dedup,
+		limit:    limit,
+		forward:  dir == logproto.FORWARD,
+		headers:  make(map[string][]string),
+		warnings: make(map[string]struct{}),
+	}
+}
+
+func (acc *DedupStreamsAccumulator) Accumulate(_ context.Context, x logqlmodel.Result, _ int) error {
+	// See AccumulatedStreams.Accumulate for setting the shard count here.
+	if x.Statistics.Summary.Shards == 0 {
+		x.Statistics.Summary.Shards = 1
+	}
+	acc.stats.Merge(x.Statistics)
+	metadata.ExtendHeaders(acc.headers, x.Headers)
+
+	for _, w := range x.Warnings {
+		acc.warnings[w] = struct{}{}
+	}
+
+	got, ok := x.Data.(logqlmodel.Streams)
+	if !ok {
+		return fmt.Errorf("unexpected response type during response result accumulation. Got (%T), wanted %s", x.Data, logqlmodel.ValueTypeStreams)
+	}
+	for _, s := range got {
+		for _, e := range s.Entries {
+			acc.entries = append(acc.entries, accumulatedEntry{labels: s.Labels, entry: e})
+		}
+	}
+	return nil
+}
+
+func (acc *DedupStreamsAccumulator) Result() []logqlmodel.Result {
+	// identical entries are collapsed into the first of them in the direction
+	// of the query, regardless of the downstream query they come from.
+	sort.SliceStable(acc.entries, func(i, j int) bool {
+		if acc.forward {
+			return acc.entries[i].entry.Timestamp.Before(acc.entries[j].entry.Timestamp)
+		}
+		return acc.entries[j].entry.Timestamp.Before(acc.entries[i].entry.Timestamp)
+	})
+	d := newDedupEntries(acc.dedup, acc.limit)
+	for _, e := range acc.entries {
+		if !d.add(e.labels, e.entry) {
+			break
+		}
+	}
+
+	res := logqlmodel.Result{
+		// stats & headers are already aggregated in the context
+		Data:       d.streams(),
+		Statistics: acc.stats,
+		Headers:    make([]*definitions.PrometheusResponseHeader, 0, len(acc.headers)),
+	}
+
+	for name, vals := range acc.headers {
+		res.Headers = append(
+			res.Headers,
+			&definitions.PrometheusResponseHeader{
+				Name:   name,
+				Values: vals,
+			},
+		)
+	}
+
+	warnings := maps.Keys(acc.warnings)
+	sort.Strings(warnings)
+	res.Warnings = warnings
+
+	return []logqlmodel.Result{res}
+}
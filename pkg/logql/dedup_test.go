@@ -0,0 +1,129 @@
+package logql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/log"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/logqlmodel"
+)
+
+func Test_DedupStage(t *testing.T) {
+	for _, tc := range []struct {
+		query    string
+		expected *log.Dedup
+	}{
+		{`{app="foo"} | logfmt`, nil},
+		{`{app="foo"} | dedup`, log.NewDedup(nil, 0)},
+		{`{app="foo"} | json | dedup by (pod, level) within 1m | limit 10`, log.NewDedup([]string{"pod", "level"}, time.Minute)},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := syntax.ParseLogSelector(tc.query, true)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, DedupStage(expr))
+		})
+	}
+}
+
+func TestDedupStreamsAccumulator(t *testing.T) {
+	entry := func(ts int64, line string) logproto.Entry {
+		return logproto.Entry{Timestamp: time.Unix(ts, 0), Line: line}
+	}
+
+	for _, tc := range []struct {
+		direction logproto.Direction
+		expected  logqlmodel.Streams
+	}{
+		{
+			logproto.FORWARD,
+			logqlmodel.Streams{
+				{Labels: `{__dedup_count__="1", app="foo", pod="a"}`, Entries: []logproto.Entry{entry(4, "stopped")}},
+				{Labels: `{__dedup_count__="1", app="foo", pod="b"}`, Entries: []logproto.Entry{entry(3, "started")}},
+				{Labels: `{__dedup_count__="5", app="foo", pod="a"}`, Entries: []logproto.Entry{entry(1, "crash")}},
+			},
+		},
+		{
+			logproto.BACKWARD,
+			logqlmodel.Streams{
+				{Labels: `{__dedup_count__="1", app="foo", pod="a"}`, Entries: []logproto.Entry{entry(4, "stopped")}},
+				{Labels: `{__dedup_count__="1", app="foo", pod="b"}`, Entries: []logproto.Entry{entry(3, "started")}},
+				{Labels: `{__dedup_count__="5", app="foo", pod="b"}`, Entries: []logproto.Entry{entry(2, "crash")}},
+			},
+		},
+	} {
+		t.Run(tc.direction.String(), func(t *testing.T) {
+			acc := NewDedupStreamsAccumulator(log.NewDedup(nil, 0), 3, tc.direction)
+
+			// the counts of the entries collapsed by the downstream queries
+			// are added up, the labels of the first entry are kept.
+			require.NoError(t, acc.Accumulate(context.Background(), logqlmodel.Result{
+				Data: logqlmodel.Streams{
+					{Labels: `{__dedup_count__="3", app="foo", pod="a"}`, Entries: []logproto.Entry{entry(1, "crash")}},
+					{Labels: `{__dedup_count__="1", app="foo", pod="a"}`, Entries: []logproto.Entry{entry(4, "stopped")}},
+				},
+			}, 0))
+			require.NoError(t, acc.Accumulate(context.Background(), logqlmodel.Result{
+				Data: logqlmodel.Streams{
+					{Labels: `{__dedup_count__="2", app="foo", pod="b"}`, Entries: []logproto.Entry{entry(2, "crash")}},
+					{Labels: `{__dedup_count__="1", app="foo", pod="b"}`, Entries: []logproto.Entry{entry(3, "started")}},
+				},
+			}, 1))
+
+			res := acc.Result()[0]
+			require.Equal(t, int64(2), res.Statistics.Summary.Shards)
+			require.Equal(t, tc.expected, res.Data)
+		})
+	}
+}
+
+func TestDedupEntries_Within(t *testing.T) {
+	d := newDedupEntries(log.NewDedup([]string{"pod"}, time.Minute), 10)
+	for _, e := range []struct {
+		labels string
+		ts     int64
+	}{
+		{`{pod="a"}`, 0},
+		{`{pod="b"}`, 10},
+		{`{pod="a"}`, 60},
+		{`{pod="a"}`, 61},
+		{`{pod="a"}`, 120},
+	} {
+		require.True(t, d.add(e.labels, logproto.Entry{Timestamp: time.Unix(e.ts, 0), Line: "crash"}))
+	}
+	require.Equal(t, logqlmodel.Streams{
+		{Labels: `{__dedup_count__="1", pod="b"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(10, 0), Line: "crash"}}},
+		{Labels: `{__dedup_count__="2", pod="a"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(0, 0), Line: "crash"}, {Timestamp: time.Unix(61, 0), Line: "crash"}}},
+	}, d.streams())
+}
+
+func TestDedupEntries_Limit(t *testing.T) {
+	entry := func(ts int64, line string) logproto.Entry {
+		return logproto.Entry{Timestamp: time.Unix(ts, 0), Line: line}
+	}
+
+	// the entries after the limit are still collapsed into the returned ones.
+	d := newDedupEntries(log.NewDedup(nil, 0), 2)
+	for _, e := range []logproto.Entry{entry(0, "crash"), entry(1, "started"), entry(2, "stopped"), entry(3, "crash"), entry(4, "started"), entry(5, "crash")} {
+		require.True(t, d.add(`{pod="a"}`, e))
+	}
+	require.Equal(t, logqlmodel.Streams{
+		{Labels: `{__dedup_count__="2", pod="a"}`, Entries: []logproto.Entry{entry(1, "started")}},
+		{Labels: `{__dedup_count__="3", pod="a"}`, Entries: []logproto.Entry{entry(0, "crash")}},
+	}, d.streams())
+
+	// until they are further than the duration from the last returned entry.
+	d = newDedupEntries(log.NewDedup(nil, time.Minute), 1)
+	require.True(t, d.add(`{pod="a"}`, entry(0, "crash")))
+	require.True(t, d.add(`{pod="a"}`, entry(10, "stopped")))
+	require.True(t, d.add(`{pod="a"}`, entry(30, "crash")))
+	require.True(t, d.add(`{pod="a"}`, entry(60, "crash")))
+	require.False(t, d.add(`{pod="a"}`, entry(61, "stopped")))
+	require.Equal(t, logqlmodel.Streams{
+		{Labels: `{__dedup_count__="3", pod="a"}`, Entries: []logproto.Entry{entry(0, "crash")}},
+	}, d.streams())
+}
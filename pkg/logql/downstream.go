@@ -301,6 +301,50 @@ func (e *QuantileSketchMergeExpr) Walk(f syntax.WalkFn) {
 	}
 }
 
+// CountDistinctSketchEvalExpr evaluates a count distinct sketch to the
+// estimated count.
+type CountDistinctSketchEvalExpr struct {
+	syntax.SampleExpr
+	countDistinctMergeExpr *CountDistinctSketchMergeExpr
+}
+
+func (e CountDistinctSketchEvalExpr) String() string {
+	return fmt.Sprintf("countDistinctSketchEval<%s>", e.countDistinctMergeExpr.String())
+}
+
+func (e *CountDistinctSketchEvalExpr) Walk(f syntax.WalkFn) {
+	f(e)
+	e.countDistinctMergeExpr.Walk(f)
+}
+
+type CountDistinctSketchMergeExpr struct {
+	syntax.SampleExpr
+	downstreams []DownstreamSampleExpr
+}
+
+func (e CountDistinctSketchMergeExpr) String() string {
+	var sb strings.Builder
+	for i, d := range e.downstreams {
+		if i >= defaultMaxDepth {
+			break
+		}
+
+		if i > 0 {
+			sb.WriteString(" ++ ")
+		}
+
+		sb.WriteString(d.String())
+	}
+	return fmt.Sprintf("countDistinctSketchMerge<%s>", sb.String())
+}
+
+func (e *CountDistinctSketchMergeExpr) Walk(f syntax.WalkFn) {
+	f(e)
+	for _, d := range e.downstreams {
+		d.Walk(f)
+	}
+}
+
 type MergeFirstOverTimeExpr struct {
 	syntax.SampleExpr
 	downstreams []DownstreamSampleExpr
@@ -527,6 +571,36 @@ func (ev *DownstreamEvaluator) NewStepEvaluator(
 		}
 		inner := NewQuantileSketchMatrixStepEvaluator(matrix, params)
 		return NewQuantileSketchVectorStepEvaluator(inner, *e.quantile), nil
+	case *CountDistinctSketchEvalExpr:
+		var queries []DownstreamQuery
+		if e.countDistinctMergeExpr != nil {
+			for _, d := range e.countDistinctMergeExpr.downstreams {
+				qry := DownstreamQuery{
+					Params: ParamsWithExpressionOverride{
+						Params:             ParamOverridesFromShard(params, d.shard),
+						ExpressionOverride: d.SampleExpr,
+					},
+				}
+				queries = append(queries, qry)
+			}
+		}
+
+		acc := newCountDistinctSketchAccumulator()
+		results, err := ev.Downstream(ctx, queries, acc)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(results) != 1 {
+			return nil, fmt.Errorf("unexpected results length for sharded count distinct: got (%d), want (1)", len(results))
+		}
+
+		matrix, ok := results[0].Data.(ProbabilisticCountDistinctMatrix)
+		if !ok {
+			return nil, fmt.Errorf("unexpected matrix type: got (%T), want (ProbabilisticCountDistinctMatrix)", results[0].Data)
+		}
+		inner := NewCountDistinctSketchMatrixStepEvaluator(matrix, params)
+		return NewCountDistinctSketchVectorStepEvaluator(inner), nil
 	case *MergeFirstOverTimeExpr:
 		queries := make([]DownstreamQuery, len(e.downstreams))
 
@@ -615,7 +689,7 @@ func (ev *DownstreamEvaluator) NewIterator(
 	switch e := expr.(type) {
 	case DownstreamLogSelectorExpr:
 		// downstream to a querier
-		acc := NewStreamAccumulator(params)
+		acc := newLogAccumulator(e.LogSelectorExpr, params)
 		results, err := ev.Downstream(ctx, []DownstreamQuery{{
 			Params: ParamsWithExpressionOverride{
 				Params:             ParamOverridesFromShard(params, e.shard),
@@ -641,7 +715,7 @@ func (ev *DownstreamEvaluator) NewIterator(
 			cur = cur.next
 		}
 
-		acc := NewStreamAccumulator(params)
+		acc := newLogAccumulator(e.LogSelectorExpr, params)
 		results, err := ev.Downstream(ctx, queries, acc)
 		if err != nil {
 			return nil, err
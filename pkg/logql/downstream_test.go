@@ -55,6 +55,8 @@ func TestMappingEquivalence(t *testing.T) {
 		{`max(count(rate({a=~".+"}[1s])))`, false, nil},
 		{`max(sum by (cluster) (rate({a=~".+"}[1s]))) / count(rate({a=~".+"}[1s]))`, false, nil},
 		{`sum(rate({a=~".+"} |= "foo" != "foo"[1s]) or vector(1))`, false, nil},
+		{`{a=~".+"} | logfmt | sort_by(value desc) | limit 10`, false, nil},
+		{`{a=~".+"} | logfmt | dedup by (value) within 1m | limit 10`, false, nil},
 		{`avg_over_time({a=~".+"} | logfmt | unwrap value [1s])`, false, nil},
 		{`avg_over_time({a=~".+"} | logfmt | unwrap value [1s]) by (a)`, true, nil},
 		{`quantile_over_time(0.99, {a=~".+"} | logfmt | unwrap value [1s])`, true, []string{ShardQuantileOverTime}},
@@ -144,6 +146,8 @@ func TestMappingEquivalenceSketches(t *testing.T) {
 	}{
 		{`quantile_over_time(0.70, {a=~".+"} | logfmt | unwrap value [1s]) by (a)`, 0.05},
 		{`quantile_over_time(0.99, {a=~".+"} | logfmt | unwrap value [1s]) by (a)`, 0.02},
+		{`count_distinct_over_time({a=~".+"} | logfmt | unwrap value [1s]) by (a)`, 0.02},
+		{`count_distinct_over_time({a=~".+"} | logfmt | unwrap b [5s]) by (a)`, 0.02},
 	} {
 		q := NewMockQuerier(
 			shards,
@@ -171,7 +175,7 @@ func TestMappingEquivalenceSketches(t *testing.T) {
 			ctx := user.InjectOrgID(context.Background(), "fake")
 
 			strategy := NewPowerOfTwoStrategy(ConstantShards(shards))
-			mapper := NewShardMapper(strategy, nilShardMetrics, []string{ShardQuantileOverTime})
+			mapper := NewShardMapper(strategy, nilShardMetrics, []string{ShardQuantileOverTime, ShardCountDistinctOverTime})
 			_, _, mapped, err := mapper.Parse(params.GetExpression())
 			require.NoError(t, err)
 
@@ -207,7 +211,7 @@ func TestMappingEquivalenceSketches(t *testing.T) {
 			ctx := user.InjectOrgID(context.Background(), "fake")
 
 			strategy := NewPowerOfTwoStrategy(ConstantShards(shards))
-			mapper := NewShardMapper(strategy, nilShardMetrics, []string{ShardQuantileOverTime})
+			mapper := NewShardMapper(strategy, nilShardMetrics, []string{ShardQuantileOverTime, ShardCountDistinctOverTime})
 			_, _, mapped, err := mapper.Parse(params.GetExpression())
 			require.NoError(t, err)
 
@@ -320,6 +324,8 @@ func TestRangeMappingEquivalence(t *testing.T) {
 		{`rate({a=~".+"}[2s])`, time.Second},
 		{`rate({a=~".+"} | unwrap b [2s])`, time.Second},
 		{`bytes_rate({a=~".+"}[2s])`, time.Second},
+		{`histogram_over_time({a=~".+"} | unwrap b [2s], 1, 5)`, time.Second},
+		{`histogram_over_time({a=~".+"} | unwrap b [2s], 1, 5) by (a)`, time.Second},
 
 		// sum
 		{`sum(bytes_over_time({a=~".+"}[2s]))`, time.Second},
@@ -343,6 +349,7 @@ func TestRangeMappingEquivalence(t *testing.T) {
 		{`sum by (a) (min_over_time({a=~".+"} | unwrap b [2s]) by (a))`, time.Second},
 		{`sum by (a) (rate({a=~".+"}[2s]))`, time.Second},
 		{`sum by (a) (rate({a=~".+"} | unwrap b [2s]))`, time.Second},
+		{`sum by (le) (histogram_over_time({a=~".+"} | unwrap b [2s], 1, 5))`, time.Second},
 		{`sum by (a) (bytes_rate({a=~".+"}[2s]))`, time.Second},
 
 		// count
@@ -507,6 +514,10 @@ func TestRangeMappingEquivalence(t *testing.T) {
 		// label_replace
 		{`label_replace(sum by (a) (count_over_time({a=~".+"}[3s])), "", "", "", "")`, time.Second},
 		{`label_replace(sum by (a) (count_over_time({a=~".+"}[3s])), "foo", "$1", "a", "(.*)")`, time.Second},
+
+		// label functions
+		{`label_join(sum by (a) (count_over_time({a=~".+"}[3s])), "foo", "-", "a", "a")`, time.Second},
+		{`label_truncate(sum by (a) (count_over_time({a=~".+"}[3s])), "foo", "a", 1)`, time.Second},
 	} {
 		q := NewMockQuerier(
 			shards,
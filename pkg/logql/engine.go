@@ -28,6 +28,7 @@ import (
 
 	"github.com/grafana/loki/v3/pkg/iter"
 	"github.com/grafana/loki/v3/pkg/logproto"
+	lokilog "github.com/grafana/loki/v3/pkg/logql/log"
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
@@ -148,6 +149,10 @@ type EngineOpts struct {
 
 	// LogExecutingQuery will control if we log the query when Exec is called.
 	LogExecutingQuery bool `yaml:"-"`
+
+	// Enrichment is the data used by the geoip and cidr_label stages of the
+	// queries. These stages are rejected when it's nil.
+	Enrichment *lokilog.Enrichment `yaml:"-"`
 }
 
 func (opts *EngineOpts) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
@@ -193,6 +198,7 @@ func (ng *Engine) Query(params Params) Query {
 		record:       true,
 		logExecQuery: ng.opts.LogExecutingQuery,
 		limits:       ng.limits,
+		enrichment:   ng.opts.Enrichment,
 	}
 }
 
@@ -209,6 +215,7 @@ type query struct {
 	evaluator    EvaluatorFactory
 	record       bool
 	logExecQuery bool
+	enrichment   *lokilog.Enrichment
 }
 
 func (q *query) resultLength(res promql_parser.Value) int {
@@ -221,6 +228,8 @@ func (q *query) resultLength(res promql_parser.Value) int {
 		return int(r.Lines())
 	case ProbabilisticQuantileMatrix:
 		return len(r)
+	case ProbabilisticCountDistinctMatrix:
+		return len(r)
 	default:
 		// for `scalar` or `string` or any other return type, we just return `0` as result length.
 		return 0
@@ -292,13 +301,13 @@ func (q *query) Eval(ctx context.Context) (promql_parser.Value, error) {
 		return nil, logqlmodel.ErrBlocked
 	}
 
-	switch e := q.params.GetExpression().(type) {
+	switch e := syntax.WithEnrichment(q.params.GetExpression(), q.enrichment).(type) {
 	case syntax.SampleExpr:
 		value, err := q.evalSample(ctx, e)
 		return value, err
 
 	case syntax.LogSelectorExpr:
-		itr, err := q.evaluator.NewIterator(ctx, e, q.params)
+		itr, err := q.newEntryIterator(ctx, e, q.params)
 		if err != nil {
 			return nil, err
 		}
@@ -309,7 +318,14 @@ func (q *query) Eval(ctx context.Context) (promql_parser.Value, error) {
 		}
 
 		defer util.LogErrorWithContext(ctx, "closing iterator", itr.Close)
-		streams, err := readStreams(itr, q.params.Limit(), q.params.Direction(), q.params.Interval())
+		sortBy, limit := SortAndLimit(e, q.params.Limit())
+		if sortBy != nil {
+			return readSortedStreams(itr, sortBy, limit, q.params.Direction())
+		}
+		if dedup := DedupStage(e); dedup != nil {
+			return readDedupedStreams(itr, dedup, limit)
+		}
+		streams, err := readStreams(itr, limit, q.params.Direction(), q.params.Interval())
 		return streams, err
 	default:
 		return nil, fmt.Errorf("unexpected type (%T): cannot evaluate", e)
@@ -376,6 +392,8 @@ func (q *query) evalSample(ctx context.Context, expr syntax.SampleExpr) (promql_
 			return q.JoinSampleVector(next, vec, stepEvaluator, maxSeries)
 		case ProbabilisticQuantileVector:
 			return MergeQuantileSketchVector(next, vec, stepEvaluator, q.params)
+		case ProbabilisticCountDistinctVector:
+			return MergeCountDistinctSketchVector(next, vec, stepEvaluator, q.params)
 		default:
 			return nil, fmt.Errorf("unsupported result type: %T", r)
 		}
@@ -464,6 +482,11 @@ func (q *query) checkIntervalLimit(expr syntax.SampleExpr, limit time.Duration)
 				return
 			}
 			err = fmt.Errorf("%w: [%s] > [%s]", logqlmodel.ErrIntervalLimit, model.Duration(e.Left.Interval), model.Duration(limit))
+		case *syntax.SubqueryExpr:
+			if e.Range <= limit {
+				return
+			}
+			err = fmt.Errorf("%w: [%s] > [%s]", logqlmodel.ErrIntervalLimit, model.Duration(e.Range), model.Duration(limit))
 		}
 	})
 	return err
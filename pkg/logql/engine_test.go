@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
@@ -26,6 +28,7 @@ import (
 
 	"github.com/grafana/loki/v3/pkg/iter"
 	"github.com/grafana/loki/v3/pkg/logproto"
+	lokilog "github.com/grafana/loki/v3/pkg/logql/log"
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
@@ -456,6 +459,56 @@ func TestEngine_LogsInstantQuery(t *testing.T) {
 				},
 			},
 		},
+		{
+			`label_map(
+				label_join(sum(count_over_time({app=~"foo|bar"} |~".+bar" [1m])) by (namespace,app), "id", "/", "namespace", "app"),
+				"tier",
+				"namespace",
+				{"a"="gold"}
+				)`, time.Unix(60, 0), logproto.FORWARD, 100,
+			[][]logproto.Series{
+				{
+					newSeries(testSize, factor(10, identity), `{app="foo", namespace="a"}`),
+					newSeries(testSize, factor(10, identity), `{app="bar", namespace="b"}`),
+				},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(0, 0), End: time.Unix(60, 0), Selector: `sum by (namespace,app) (count_over_time({app=~"foo|bar"} |~".+bar" [1m])) `}},
+			},
+			promql.Vector{
+				promql.Sample{
+					T: 60 * 1000, F: 6,
+					Metric: labels.FromStrings("app", "bar",
+						"id", "b/bar",
+						"namespace", "b",
+					),
+				},
+				promql.Sample{
+					T: 60 * 1000, F: 6,
+					Metric: labels.FromStrings("app", "foo",
+						"id", "a/foo",
+						"namespace", "a",
+						"tier", "gold",
+					),
+				},
+			},
+		},
+		{
+			`label_truncate(label_upper(sum(count_over_time({app=~"foo|bar"} |~".+bar" [1m])) by (app), "app", "app"), "short", "app", 2)`, time.Unix(60, 0), logproto.FORWARD, 100,
+			[][]logproto.Series{
+				{
+					newSeries(testSize, factor(10, identity), `{app="foo"}`),
+					newSeries(testSize, factor(10, identity), `{app="bar"}`),
+				},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(0, 0), End: time.Unix(60, 0), Selector: `sum by (app) (count_over_time({app=~"foo|bar"} |~".+bar" [1m])) `}},
+			},
+			promql.Vector{
+				promql.Sample{T: 60 * 1000, F: 6, Metric: labels.FromStrings("app", "BAR", "short", "BA")},
+				promql.Sample{T: 60 * 1000, F: 6, Metric: labels.FromStrings("app", "FOO", "short", "FO")},
+			},
+		},
 		{
 			`count(count_over_time({app=~"foo|bar"} |~".+bar" [1m])) without (app)`, time.Unix(60, 0), logproto.FORWARD, 100,
 			[][]logproto.Series{
@@ -1063,6 +1116,63 @@ func TestEngine_RangeQuery(t *testing.T) {
 			},
 			logqlmodel.Streams([]logproto.Stream{newBackwardIntervalStream(testSize, 30, 3*time.Second, identity, `{app="barf"}`)}),
 		},
+		{
+			`{app="foo"} | join on(trace_id) within 5s {app="bar"}`, time.Unix(0, 0), time.Unix(30, 0), 0, 0, logproto.FORWARD, 10,
+			[][]logproto.Stream{
+				{newStream(testSize, identity, `{app="foo", trace_id="1"}`), newStream(testSize, identity, `{app="foo", trace_id="2"}`)},
+				{{Labels: `{app="bar", svc="backend", trace_id="1"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(3, 0), Line: "3"}}}},
+			},
+			[]SelectLogParams{
+				{&logproto.QueryRequest{Direction: logproto.FORWARD, Start: time.Unix(0, 0), End: time.Unix(30, 0), Limit: math.MaxUint32, Selector: `{app="foo"}`}},
+				{&logproto.QueryRequest{Direction: logproto.FORWARD, Start: time.Unix(-5, 0), End: time.Unix(35, 0), Limit: math.MaxUint32, Selector: `{app="bar"}`}},
+			},
+			logqlmodel.Streams([]logproto.Stream{newStream(9, identity, `{app="foo", svc="backend", trace_id="1"}`)}),
+		},
+		{
+			`{app="foo"} | join on(trace_id) within 5s {app="bar"} | limit 3`, time.Unix(0, 0), time.Unix(30, 0), 0, 0, logproto.FORWARD, 10,
+			[][]logproto.Stream{
+				{newStream(testSize, identity, `{app="foo", trace_id="1"}`), newStream(testSize, identity, `{app="foo", trace_id="2"}`)},
+				{{Labels: `{app="bar", svc="backend", trace_id="1"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(3, 0), Line: "3"}}}},
+			},
+			[]SelectLogParams{
+				{&logproto.QueryRequest{Direction: logproto.FORWARD, Start: time.Unix(0, 0), End: time.Unix(30, 0), Limit: math.MaxUint32, Selector: `{app="foo"}`}},
+				{&logproto.QueryRequest{Direction: logproto.FORWARD, Start: time.Unix(-5, 0), End: time.Unix(35, 0), Limit: math.MaxUint32, Selector: `{app="bar"}`}},
+			},
+			logqlmodel.Streams([]logproto.Stream{newStream(3, identity, `{app="foo", svc="backend", trace_id="1"}`)}),
+		},
+		{
+			`{app="foo"} | sort_by(duration desc) | limit 3`, time.Unix(0, 0), time.Unix(30, 0), 0, 0, logproto.FORWARD, 10,
+			[][]logproto.Stream{
+				{
+					{Labels: `{app="foo", duration="3s"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(1, 0), Line: "1"}, {Timestamp: time.Unix(2, 0), Line: "2"}}},
+					{Labels: `{app="foo", duration="1s"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(3, 0), Line: "3"}}},
+					{Labels: `{app="foo", duration="200ms"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(4, 0), Line: "4"}, {Timestamp: time.Unix(5, 0), Line: "5"}}},
+				},
+			},
+			[]SelectLogParams{
+				{&logproto.QueryRequest{Direction: logproto.FORWARD, Start: time.Unix(0, 0), End: time.Unix(30, 0), Limit: math.MaxUint32, Selector: `{app="foo"} | sort_by(duration desc) | limit 3`}},
+			},
+			logqlmodel.Streams([]logproto.Stream{
+				{Labels: `{app="foo", duration="1s"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(3, 0), Line: "3"}}},
+				{Labels: `{app="foo", duration="3s"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(1, 0), Line: "1"}, {Timestamp: time.Unix(2, 0), Line: "2"}}},
+			}),
+		},
+		{
+			`{app="foo"} | dedup within 10s | limit 2`, time.Unix(0, 0), time.Unix(30, 0), 0, 0, logproto.FORWARD, 10,
+			[][]logproto.Stream{
+				{
+					{Labels: `{app="foo", pod="a"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(1, 0), Line: "crash"}, {Timestamp: time.Unix(3, 0), Line: "crash"}, {Timestamp: time.Unix(20, 0), Line: "crash"}}},
+					{Labels: `{app="foo", pod="b"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(2, 0), Line: "crash"}, {Timestamp: time.Unix(4, 0), Line: "started"}, {Timestamp: time.Unix(5, 0), Line: "other"}}},
+				},
+			},
+			[]SelectLogParams{
+				{&logproto.QueryRequest{Direction: logproto.FORWARD, Start: time.Unix(0, 0), End: time.Unix(30, 0), Limit: math.MaxUint32, Selector: `{app="foo"} | dedup within 10s | limit 2`}},
+			},
+			logqlmodel.Streams([]logproto.Stream{
+				{Labels: `{__dedup_count__="1", app="foo", pod="b"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(4, 0), Line: "started"}}},
+				{Labels: `{__dedup_count__="3", app="foo", pod="a"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(1, 0), Line: "crash"}}},
+			}),
+		},
 		{
 			`rate({app="foo"} |~".+bar" [1m])`, time.Unix(60, 0), time.Unix(120, 0), time.Minute, 0, logproto.BACKWARD, 10,
 			[][]logproto.Series{
@@ -2274,6 +2384,96 @@ func TestEngine_RangeQuery(t *testing.T) {
 				},
 			},
 		},
+		{
+			`sum_over_time(count_over_time({app="foo"}[10s])[1m:10s])`, time.Unix(20, 0), time.Unix(40, 0), 20 * time.Second, 0, logproto.FORWARD, 10,
+			[][]logproto.Series{
+				{
+					{
+						Labels: `{app="foo"}`,
+						Samples: []logproto.Sample{
+							{Timestamp: time.Unix(5, 0).UnixNano(), Hash: 1, Value: 1.},
+							{Timestamp: time.Unix(15, 0).UnixNano(), Hash: 2, Value: 1.},
+							{Timestamp: time.Unix(16, 0).UnixNano(), Hash: 3, Value: 1.},
+							{Timestamp: time.Unix(25, 0).UnixNano(), Hash: 4, Value: 1.},
+							{Timestamp: time.Unix(26, 0).UnixNano(), Hash: 5, Value: 1.},
+							{Timestamp: time.Unix(27, 0).UnixNano(), Hash: 6, Value: 1.},
+						},
+					},
+				},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(-50, 0), End: time.Unix(40, 0), Selector: `count_over_time({app="foo"}[10s])`}},
+			},
+			promql.Matrix{
+				promql.Series{
+					Metric: labels.FromStrings("app", "foo"),
+					Floats: []promql.FPoint{{T: 20 * 1000, F: 3}, {T: 40 * 1000, F: 6}},
+				},
+			},
+		},
+		{
+			`changes_vs(count_over_time({app="foo"}[10s]), 1m)`, time.Unix(80, 0), time.Unix(100, 0), 20 * time.Second, 0, logproto.FORWARD, 10,
+			[][]logproto.Series{
+				{
+					{
+						Labels: `{app="foo"}`,
+						Samples: []logproto.Sample{
+							{Timestamp: time.Unix(75, 0).UnixNano(), Hash: 1, Value: 1.},
+							{Timestamp: time.Unix(76, 0).UnixNano(), Hash: 2, Value: 1.},
+							{Timestamp: time.Unix(95, 0).UnixNano(), Hash: 3, Value: 1.},
+						},
+					},
+				},
+				{
+					{
+						Labels: `{app="foo"}`,
+						Samples: []logproto.Sample{
+							{Timestamp: time.Unix(15, 0).UnixNano(), Hash: 4, Value: 1.},
+							{Timestamp: time.Unix(35, 0).UnixNano(), Hash: 5, Value: 1.},
+							{Timestamp: time.Unix(36, 0).UnixNano(), Hash: 6, Value: 1.},
+							{Timestamp: time.Unix(37, 0).UnixNano(), Hash: 7, Value: 1.},
+						},
+					},
+				},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(70, 0), End: time.Unix(100, 0), Selector: `count_over_time({app="foo"}[10s])`}},
+				{&logproto.SampleQueryRequest{Start: time.Unix(10, 0), End: time.Unix(40, 0), Selector: `count_over_time({app="foo"}[10s])`}},
+			},
+			promql.Matrix{
+				promql.Series{
+					Metric: labels.FromStrings("app", "foo"),
+					Floats: []promql.FPoint{{T: 80 * 1000, F: 1.}, {T: 100 * 1000, F: -2.}},
+				},
+			},
+		},
+		{
+			`deriv(count_over_time({app="foo"}[10s])[30s:10s])`, time.Unix(20, 0), time.Unix(40, 0), 20 * time.Second, 0, logproto.FORWARD, 10,
+			[][]logproto.Series{
+				{
+					{
+						Labels: `{app="foo"}`,
+						Samples: []logproto.Sample{
+							{Timestamp: time.Unix(5, 0).UnixNano(), Hash: 1, Value: 1.},
+							{Timestamp: time.Unix(15, 0).UnixNano(), Hash: 2, Value: 1.},
+							{Timestamp: time.Unix(16, 0).UnixNano(), Hash: 3, Value: 1.},
+							{Timestamp: time.Unix(25, 0).UnixNano(), Hash: 4, Value: 1.},
+							{Timestamp: time.Unix(26, 0).UnixNano(), Hash: 5, Value: 1.},
+							{Timestamp: time.Unix(27, 0).UnixNano(), Hash: 6, Value: 1.},
+						},
+					},
+				},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(-20, 0), End: time.Unix(40, 0), Selector: `count_over_time({app="foo"}[10s])`}},
+			},
+			promql.Matrix{
+				promql.Series{
+					Metric: labels.FromStrings("app", "foo"),
+					Floats: []promql.FPoint{{T: 20 * 1000, F: 0.1}, {T: 40 * 1000, F: 0.1}},
+				},
+			},
+		},
 	} {
 		test := test
 		t.Run(fmt.Sprintf("%s %s", test.qs, test.direction), func(t *testing.T) {
@@ -2356,6 +2556,56 @@ func TestEngine_Metadata(t *testing.T) {
 	}, r.Headers)
 }
 
+// pipelineQuerier builds the pipelines and extractors of the queries, as the
+// store does.
+type pipelineQuerier struct{}
+
+func (pipelineQuerier) SelectLogs(_ context.Context, p SelectLogParams) (iter.EntryIterator, error) {
+	expr, err := p.LogSelector()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := expr.Pipeline(); err != nil {
+		return nil, err
+	}
+	return iter.NoopIterator, nil
+}
+
+func (pipelineQuerier) SelectSamples(_ context.Context, p SelectSampleParams) (iter.SampleIterator, error) {
+	expr, err := p.Expr()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := expr.Extractor(); err != nil {
+		return nil, err
+	}
+	return iter.NoopIterator, nil
+}
+
+func TestEngine_Enrichment(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ranges.yaml"), []byte("office: [10.1.0.0/16]\n"), 0o644))
+
+	for _, qs := range []string{
+		`{app="foo"} | logfmt | cidr_label(src_ip, "ranges.yaml")`,
+		`sum by (src_ip_network) (count_over_time({app="foo"} | logfmt | cidr_label(src_ip, "ranges.yaml") [5m]))`,
+	} {
+		t.Run(qs, func(t *testing.T) {
+			params, err := NewLiteralParams(qs, time.Unix(0, 0), time.Unix(60, 0), time.Minute, 0, logproto.FORWARD, 100, nil, nil)
+			require.NoError(t, err)
+			ctx := user.InjectOrgID(context.Background(), "fake")
+
+			eng := NewEngine(EngineOpts{}, pipelineQuerier{}, NoLimits, log.NewNopLogger())
+			_, err = eng.Query(params).Exec(ctx)
+			require.Error(t, err)
+
+			eng = NewEngine(EngineOpts{Enrichment: lokilog.NewEnrichment(nil, dir)}, pipelineQuerier{}, NoLimits, log.NewNopLogger())
+			_, err = eng.Query(params).Exec(ctx)
+			require.NoError(t, err)
+		})
+	}
+}
+
 func TestEngine_LogsInstantQuery_Vector(t *testing.T) {
 	eng := NewEngine(EngineOpts{}, &statsQuerier{}, NoLimits, log.NewNopLogger())
 	now := time.Now()
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/prometheus/model/labels"
@@ -295,11 +297,22 @@ func NewDefaultEvaluator(querier Querier, maxLookBackPeriod time.Duration) *Defa
 }
 
 func (ev *DefaultEvaluator) NewIterator(ctx context.Context, expr syntax.LogSelectorExpr, q Params) (iter.EntryIterator, error) {
+	limit := q.Limit()
+	if sortBy, _ := syntax.SortStages(expr); sortBy != nil {
+		// entries are only limited once ordered by the sort_by stage, all of
+		// them must be selected.
+		limit = math.MaxUint32
+	}
+	if syntax.DedupStage(expr) != nil {
+		// entries are only limited once identical ones are collapsed by the
+		// dedup stage.
+		limit = math.MaxUint32
+	}
 	params := SelectLogParams{
 		QueryRequest: &logproto.QueryRequest{
 			Start:     q.Start(),
 			End:       q.End(),
-			Limit:     q.Limit(),
+			Limit:     limit,
 			Direction: q.Direction(),
 			Selector:  expr.String(),
 			Shards:    q.Shards(),
@@ -365,10 +378,16 @@ func (ev *DefaultEvaluator) NewStepEvaluator(
 			return nil, err
 		}
 		return newRangeAggEvaluator(iter.NewPeekingSampleIterator(it), e, q, e.Left.Offset)
+	case *syntax.SubqueryExpr:
+		return newSubqueryEvaluator(ctx, nextEvFactory, e, q)
+	case *syntax.ChangesVsExpr:
+		return newChangesVsEvaluator(ctx, nextEvFactory, e, q)
 	case *syntax.BinOpExpr:
 		return newBinOpStepEvaluator(ctx, nextEvFactory, e, q)
 	case *syntax.LabelReplaceExpr:
 		return newLabelReplaceEvaluator(ctx, nextEvFactory, e, q)
+	case *syntax.LabelFunctionExpr:
+		return newLabelFunctionEvaluator(ctx, nextEvFactory, e, q)
 	case *syntax.VectorExpr:
 		val, err := e.Value()
 		if err != nil {
@@ -651,6 +670,17 @@ func newRangeAggEvaluator(
 		return &QuantileSketchStepEvaluator{
 			iter: iter,
 		}, nil
+	case syntax.OpRangeTypeCountDistinctSketch:
+		iter := newCountDistinctSketchIterator(
+			it,
+			expr.Left.Interval.Nanoseconds(),
+			q.Step().Nanoseconds(),
+			q.Start().UnixNano(), q.End().UnixNano(), o.Nanoseconds(),
+		)
+
+		return &CountDistinctSketchStepEvaluator{
+			iter: iter,
+		}, nil
 	case syntax.OpRangeTypeFirstWithTimestamp:
 		iter := newFirstWithTimestampIterator(
 			it,
@@ -670,6 +700,29 @@ func newRangeAggEvaluator(
 			q.Start().UnixNano(), q.End().UnixNano(), o.Nanoseconds(),
 		)
 
+		return &RangeVectorEvaluator{
+			iter: iter,
+		}, nil
+	case syntax.OpRangeTypeHistogram:
+		iter := newHistogramIterator(
+			it,
+			expr.Buckets,
+			expr.Left.Interval.Nanoseconds(),
+			q.Step().Nanoseconds(),
+			q.Start().UnixNano(), q.End().UnixNano(), o.Nanoseconds(),
+		)
+
+		return &RangeVectorEvaluator{
+			iter: iter,
+		}, nil
+	case syntax.OpRangeTypeDeriv, syntax.OpRangeTypePredictLinear, syntax.OpRangeTypeHoltWinters:
+		iter := newTrendIterator(
+			it, expr,
+			expr.Left.Interval.Nanoseconds(),
+			q.Step().Nanoseconds(),
+			q.Start().UnixNano(), q.End().UnixNano(), o.Nanoseconds(),
+		)
+
 		return &RangeVectorEvaluator{
 			iter: iter,
 		}, nil
@@ -1292,6 +1345,101 @@ func (e *LabelReplaceEvaluator) Error() error {
 	return e.nextEvaluator.Error()
 }
 
+func newLabelFunctionEvaluator(
+	ctx context.Context,
+	evFactory SampleEvaluatorFactory,
+	expr *syntax.LabelFunctionExpr,
+	q Params,
+) (*LabelFunctionEvaluator, error) {
+	nextEvaluator, err := evFactory.NewStepEvaluator(ctx, evFactory, expr.Left, q)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LabelFunctionEvaluator{
+		nextEvaluator: nextEvaluator,
+		expr:          expr,
+		buf:           make([]byte, 0, 1024),
+	}, nil
+}
+
+// LabelFunctionEvaluator sets the destination label of a label function on
+// each series returned by the next evaluator.
+type LabelFunctionEvaluator struct {
+	nextEvaluator StepEvaluator
+	labelCache    map[uint64]labels.Labels
+	expr          *syntax.LabelFunctionExpr
+	buf           []byte
+}
+
+func (e *LabelFunctionEvaluator) Next() (bool, int64, StepResult) {
+	next, ts, r := e.nextEvaluator.Next()
+	if !next {
+		return false, 0, SampleVector{}
+	}
+	vec := r.SampleVector()
+	if e.labelCache == nil {
+		e.labelCache = make(map[uint64]labels.Labels, len(vec))
+	}
+	var hash uint64
+	for i, s := range vec {
+		hash, e.buf = s.Metric.HashWithoutLabels(e.buf)
+		if labels, ok := e.labelCache[hash]; ok {
+			vec[i].Metric = labels
+			continue
+		}
+		value, ok := e.value(s.Metric)
+		if !ok {
+			e.labelCache[hash] = s.Metric
+			continue
+		}
+		lb := labels.NewBuilder(s.Metric).Del(e.expr.Dst)
+		if value != "" {
+			lb.Set(e.expr.Dst, value)
+		}
+		outLbs := lb.Labels()
+		e.labelCache[hash] = outLbs
+		vec[i].Metric = outLbs
+	}
+	return next, ts, SampleVector(vec)
+}
+
+// value returns the new value of the destination label, or false if the
+// series must be left unchanged.
+func (e *LabelFunctionEvaluator) value(lbs labels.Labels) (string, bool) {
+	switch e.expr.Operation {
+	case syntax.OpLabelJoin:
+		values := make([]string, 0, len(e.expr.Src))
+		for _, src := range e.expr.Src {
+			values = append(values, lbs.Get(src))
+		}
+		return strings.Join(values, e.expr.Separator), true
+	case syntax.OpLabelMap:
+		value, ok := e.expr.Mapping[lbs.Get(e.expr.Src[0])]
+		return value, ok
+	case syntax.OpLabelLower:
+		return strings.ToLower(lbs.Get(e.expr.Src[0])), true
+	case syntax.OpLabelUpper:
+		return strings.ToUpper(lbs.Get(e.expr.Src[0])), true
+	case syntax.OpLabelTruncate:
+		value := lbs.Get(e.expr.Src[0])
+		if utf8.RuneCountInString(value) <= e.expr.Length {
+			return value, true
+		}
+		return string([]rune(value)[:e.expr.Length]), true
+	default:
+		return "", false
+	}
+}
+
+func (e *LabelFunctionEvaluator) Close() error {
+	return e.nextEvaluator.Close()
+}
+
+func (e *LabelFunctionEvaluator) Error() error {
+	return e.nextEvaluator.Error()
+}
+
 // This is to replace missing timeseries during absent_over_time aggregation.
 func absentLabels(expr syntax.SampleExpr) (labels.Labels, error) {
 	m := labels.Labels{}
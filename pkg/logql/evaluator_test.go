@@ -272,6 +272,10 @@ func TestEmptyNestedEvaluator(t *testing.T) {
 			desc: "LabelReplaceEvaluator",
 			ev:   &LabelReplaceEvaluator{nextEvaluator: &emptyEvaluator{}},
 		},
+		{
+			desc: "LabelFunctionEvaluator",
+			ev:   &LabelFunctionEvaluator{nextEvaluator: &emptyEvaluator{}},
+		},
 		{
 			desc: "BinOpStepEvaluator",
 			ev:   &BinOpStepEvaluator{rse: &emptyEvaluator{}},
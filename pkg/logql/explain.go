@@ -14,6 +14,11 @@ func (e *LabelReplaceEvaluator) Explain(parent Node) {
 	e.nextEvaluator.Explain(b)
 }
 
+func (e *LabelFunctionEvaluator) Explain(parent Node) {
+	b := parent.Childf("[%s, %s] LabelFunction", e.expr.Operation, e.expr.Dst)
+	e.nextEvaluator.Explain(b)
+}
+
 func (e *VectorAggEvaluator) Explain(parent Node) {
 	b := parent.Childf("[%s, %s] VectorAgg", e.expr.Operation, e.expr.Grouping)
 	e.nextEvaluator.Explain(b)
@@ -0,0 +1,107 @@
+package logql
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+
+	"github.com/grafana/loki/v3/pkg/iter"
+)
+
+// newHistogramIterator returns an iterator that counts the samples of each
+// window into cumulative buckets, emitting one series per bucket with the
+// upper bound set as the `le` label.
+func newHistogramIterator(
+	it iter.PeekingSampleIterator,
+	buckets []float64,
+	selRange, step, start, end, offset int64) RangeVectorIterator {
+	inner := &batchRangeVectorIterator{
+		iter:     it,
+		step:     step,
+		end:      end,
+		selRange: selRange,
+		metrics:  map[string]labels.Labels{},
+		window:   map[string]*promql.Series{},
+		agg:      nil,
+		current:  start - step, // first loop iteration will set it to start
+		offset:   offset,
+	}
+	return &histogramBatchRangeVectorIterator{
+		batchRangeVectorIterator: inner,
+		buckets:                  buckets,
+		bucketLabels:             map[string][]labels.Labels{},
+	}
+}
+
+type histogramBatchRangeVectorIterator struct {
+	*batchRangeVectorIterator
+	buckets []float64
+	// bucketLabels caches the labels of each bucket series keyed by the
+	// labels of the source series.
+	bucketLabels map[string][]labels.Labels
+	counts       []float64
+	at           []promql.Sample
+}
+
+// At aggregates the underlying window by counting the samples of each series
+// into the configured buckets.
+func (r *histogramBatchRangeVectorIterator) At() (int64, StepResult) {
+	if r.at == nil {
+		r.at = make([]promql.Sample, 0, len(r.window)*(len(r.buckets)+1))
+	}
+	if r.counts == nil {
+		r.counts = make([]float64, len(r.buckets)+1)
+	}
+	r.at = r.at[:0]
+	// convert ts from nano to milli seconds as the iterator work with nanoseconds
+	ts := r.current/1e+6 + r.offset/1e+6
+	for key, series := range r.window {
+		r.count(series.Floats)
+		lbs := r.labelsFor(key, series.Metric)
+		var cumulative float64
+		for i, c := range r.counts {
+			cumulative += c
+			r.at = append(r.at, promql.Sample{
+				F:      cumulative,
+				T:      ts,
+				Metric: lbs[i],
+			})
+		}
+	}
+	return ts, SampleVector(r.at)
+}
+
+// count resets the bucket counters and counts the given samples into them.
+// Values above the last upper bound, and NaN values, land in the implicit
+// +Inf bucket.
+func (r *histogramBatchRangeVectorIterator) count(samples []promql.FPoint) {
+	for i := range r.counts {
+		r.counts[i] = 0
+	}
+	for _, s := range samples {
+		if math.IsNaN(s.F) {
+			r.counts[len(r.buckets)]++
+			continue
+		}
+		r.counts[sort.SearchFloat64s(r.buckets, s.F)]++
+	}
+}
+
+func (r *histogramBatchRangeVectorIterator) labelsFor(key string, metric labels.Labels) []labels.Labels {
+	if lbs, ok := r.bucketLabels[key]; ok {
+		return lbs
+	}
+	lbs := make([]labels.Labels, 0, len(r.buckets)+1)
+	b := labels.NewBuilder(metric)
+	for _, upper := range r.buckets {
+		b.Set(labels.BucketLabel, strconv.FormatFloat(upper, 'f', -1, 64))
+		lbs = append(lbs, b.Labels())
+	}
+	b.Set(labels.BucketLabel, "+Inf")
+	lbs = append(lbs, b.Labels())
+	r.bucketLabels[key] = lbs
+	return lbs
+}
@@ -0,0 +1,340 @@
+package logql
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/iter"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/logqlmodel"
+	"github.com/grafana/loki/v3/pkg/util"
+	"github.com/grafana/loki/v3/pkg/util/validation"
+)
+
+// joinParams overrides the expression, time range and limit of a query to
+// select the entries of one side of a join.
+type joinParams struct {
+	Params
+	expr       syntax.LogSelectorExpr
+	start, end time.Time
+}
+
+func (p joinParams) QueryString() string        { return p.expr.String() }
+func (p joinParams) GetExpression() syntax.Expr { return p.expr }
+func (p joinParams) Start() time.Time           { return p.start }
+func (p joinParams) End() time.Time             { return p.end }
+
+// Limit is lifted since entries without a match are dropped by the join; the
+// limit of the query is applied on the joined entries.
+func (p joinParams) Limit() uint32 { return math.MaxUint32 }
+
+// newEntryIterator returns the iterator of a log query. Joins are evaluated on
+// the entries of their two sides, any other expression is passed to the evaluator.
+func (q *query) newEntryIterator(ctx context.Context, expr syntax.LogSelectorExpr, params Params) (iter.EntryIterator, error) {
+	join, ok := expr.(*syntax.JoinExpr)
+	if !ok {
+		return q.evaluator.NewIterator(ctx, expr, params)
+	}
+
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	maxEntriesCapture := func(id string) int { return q.limits.MaxQueryJoinEntries(ctx, id) }
+	maxEntries := validation.SmallestPositiveIntPerTenant(tenantIDs, maxEntriesCapture)
+
+	left, err := q.newEntryIterator(ctx, join.Left, joinParams{
+		Params: params,
+		expr:   join.Left,
+		start:  params.Start(),
+		end:    params.End(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	// entries of the right side can match entries of the left side up to
+	// the join duration before the start and after the end of the query.
+	rightExpr := withoutSortStages(join.Right)
+	right, err := q.newEntryIterator(ctx, rightExpr, joinParams{
+		Params: params,
+		expr:   rightExpr,
+		start:  params.Start().Add(-join.Within),
+		end:    params.End().Add(join.Within),
+	})
+	if err != nil {
+		_ = left.Close()
+		return nil, err
+	}
+	return newJoinIterator(left, right, join, params.Direction(), maxEntries), nil
+}
+
+// withoutSortStages returns the right side of a join without the sort_by, limit
+// and dedup stages ending it, which apply to the joined entries.
+func withoutSortStages(expr syntax.LogSelectorExpr) syntax.LogSelectorExpr {
+	p, ok := expr.(*syntax.PipelineExpr)
+	if !ok {
+		return expr
+	}
+	n := len(p.MultiStages)
+	for ; n > 0; n-- {
+		switch p.MultiStages[n-1].(type) {
+		case *syntax.SortByExpr, *syntax.LimitExpr, *syntax.DedupExpr:
+			continue
+		}
+		break
+	}
+	switch n {
+	case len(p.MultiStages):
+		return expr
+	case 0:
+		return p.Left
+	}
+	return &syntax.PipelineExpr{Left: p.Left, MultiStages: p.MultiStages[:n]}
+}
+
+type joinEntry struct {
+	key    string
+	ts     int64
+	stream string
+	labels labels.Labels
+}
+
+// streamLabels are the parsed labels of the stream of buffered entries of the
+// right side, along with the number of these entries.
+type streamLabels struct {
+	labels labels.Labels
+	refs   int
+}
+
+// parsedLabels are the last parsed labels of a side of the join.
+type parsedLabels struct {
+	stream string
+	labels labels.Labels
+	ok     bool
+}
+
+type joinResult struct {
+	labels string
+	hash   uint64
+}
+
+// joinIterator iterates over the entries of the left side of a join that have
+// at least one entry of the right side with the same join labels within the
+// join duration. The entries of the right side are buffered in a sliding
+// window which is bounded by maxEntries.
+type joinIterator struct {
+	left, right iter.EntryIterator
+	on          []string
+	within      int64
+	forward     bool
+	maxEntries  int
+
+	// rightOK is true when the current entry of the right iterator has not
+	// been buffered yet.
+	rightOK bool
+	// window holds the buffered entries of the right side in iteration
+	// order, byKey the same entries grouped by join key.
+	window []*joinEntry
+	byKey  map[string][]*joinEntry
+
+	// streams holds the labels of the streams of the buffered entries, they
+	// are evicted along with their last entry.
+	streams             map[string]*streamLabels
+	lastLeft, lastRight parsedLabels
+	buf                 []byte
+
+	entry   logproto.Entry
+	results []joinResult
+	idx     int
+
+	err error
+}
+
+func newJoinIterator(left, right iter.EntryIterator, expr *syntax.JoinExpr, direction logproto.Direction, maxEntries int) iter.EntryIterator {
+	it := &joinIterator{
+		left:       left,
+		right:      right,
+		on:         expr.On,
+		within:     expr.Within.Nanoseconds(),
+		forward:    direction == logproto.FORWARD,
+		maxEntries: maxEntries,
+		byKey:      map[string][]*joinEntry{},
+		streams:    map[string]*streamLabels{},
+	}
+	it.rightOK = right.Next()
+	return it
+}
+
+func (it *joinIterator) Next() bool {
+	it.idx++
+	if it.idx < len(it.results) {
+		return true
+	}
+	it.results = it.results[:0]
+	it.idx = 0
+
+	for it.err == nil && it.left.Next() {
+		entry := it.left.Entry()
+		lbs, ok := it.parse(&it.lastLeft, it.left.Labels())
+		if !ok {
+			continue
+		}
+		ts := entry.Timestamp.UnixNano()
+		it.evict(ts)
+		if err := it.fill(ts); err != nil {
+			it.err = err
+			return false
+		}
+
+		key, ok := it.key(lbs)
+		if !ok {
+			continue
+		}
+		it.match(lbs, it.byKey[key])
+		if len(it.results) > 0 {
+			it.entry = entry
+			return true
+		}
+	}
+	return false
+}
+
+// fill buffers the entries of the right side within the window of the given
+// timestamp. Entries before the start of the window are skipped.
+func (it *joinIterator) fill(ts int64) error {
+	for ; it.rightOK; it.rightOK = it.right.Next() {
+		rts := it.right.Entry().Timestamp.UnixNano()
+		if it.forward && rts > ts+it.within || !it.forward && rts < ts-it.within {
+			return nil
+		}
+		if it.forward && rts < ts-it.within || !it.forward && rts > ts+it.within {
+			continue
+		}
+		stream := it.right.Labels()
+		lbs, ok := it.parse(&it.lastRight, stream)
+		if !ok {
+			continue
+		}
+		key, ok := it.key(lbs)
+		if !ok {
+			continue
+		}
+		if it.maxEntries > 0 && len(it.window) >= it.maxEntries {
+			return logqlmodel.NewJoinLimitError(it.maxEntries)
+		}
+		e := &joinEntry{key: key, ts: rts, stream: stream, labels: lbs}
+		it.window = append(it.window, e)
+		it.byKey[key] = append(it.byKey[key], e)
+		if s, ok := it.streams[stream]; ok {
+			s.refs++
+		} else {
+			it.streams[stream] = &streamLabels{labels: lbs, refs: 1}
+		}
+	}
+	return nil
+}
+
+// evict removes the buffered entries of the right side that are before the
+// start of the window of the given timestamp.
+func (it *joinIterator) evict(ts int64) {
+	var n int
+	for _, e := range it.window {
+		if it.forward && e.ts >= ts-it.within || !it.forward && e.ts <= ts+it.within {
+			break
+		}
+		// entries of a key are buffered in the same order as the window.
+		if entries := it.byKey[e.key][1:]; len(entries) > 0 {
+			it.byKey[e.key] = entries
+		} else {
+			delete(it.byKey, e.key)
+		}
+		if s := it.streams[e.stream]; s.refs > 1 {
+			s.refs--
+		} else {
+			delete(it.streams, e.stream)
+		}
+		n++
+	}
+	if n > 0 {
+		it.window = append(it.window[:0], it.window[n:]...)
+	}
+}
+
+// match computes the distinct label sets of the joined entries.
+func (it *joinIterator) match(left labels.Labels, entries []*joinEntry) {
+	seen := make(map[uint64]struct{}, len(entries))
+	for _, e := range entries {
+		b := labels.NewBuilder(left)
+		e.labels.Range(func(l labels.Label) {
+			if !left.Has(l.Name) {
+				b.Set(l.Name, l.Value)
+			}
+		})
+		lbs := b.Labels()
+		hash := lbs.Hash()
+		if _, ok := seen[hash]; ok {
+			continue
+		}
+		seen[hash] = struct{}{}
+		it.results = append(it.results, joinResult{labels: lbs.String(), hash: hash})
+	}
+}
+
+// key returns the values of the join labels, or false if one of them is missing.
+func (it *joinIterator) key(lbs labels.Labels) (string, bool) {
+	it.buf = it.buf[:0]
+	for i, name := range it.on {
+		v := lbs.Get(name)
+		if v == "" {
+			return "", false
+		}
+		if i > 0 {
+			it.buf = append(it.buf, '\xff')
+		}
+		it.buf = append(it.buf, v...)
+	}
+	return string(it.buf), true
+}
+
+// parse returns the labels of a stream, parsed once for the last stream of
+// each side and for the streams of the buffered entries.
+func (it *joinIterator) parse(last *parsedLabels, stream string) (labels.Labels, bool) {
+	if last.stream == stream {
+		return last.labels, last.ok
+	}
+	if s, ok := it.streams[stream]; ok {
+		*last = parsedLabels{stream: stream, labels: s.labels, ok: true}
+		return s.labels, true
+	}
+	metric, err := syntax.ParseLabels(stream)
+	*last = parsedLabels{stream: stream, labels: metric, ok: err == nil}
+	return metric, err == nil
+}
+
+func (it *joinIterator) Entry() logproto.Entry { return it.entry }
+
+func (it *joinIterator) Labels() string { return it.results[it.idx].labels }
+
+func (it *joinIterator) StreamHash() uint64 { return it.results[it.idx].hash }
+
+func (it *joinIterator) Error() error {
+	if it.err != nil {
+		return it.err
+	}
+	if err := it.left.Error(); err != nil {
+		return err
+	}
+	return it.right.Error()
+}
+
+func (it *joinIterator) Close() error {
+	var errs util.MultiError
+	errs.Add(it.left.Close())
+	errs.Add(it.right.Close())
+	return errs.Err()
+}
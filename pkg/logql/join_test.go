@@ -0,0 +1,124 @@
+package logql
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/iter"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/logqlmodel"
+)
+
+func newJoinTestStream(direction logproto.Direction, lbs string, ts ...int64) logproto.Stream {
+	s := logproto.Stream{Labels: lbs}
+	for _, t := range ts {
+		e := logproto.Entry{Timestamp: time.Unix(t, 0), Line: lbs}
+		if direction == logproto.BACKWARD {
+			s.Entries = append([]logproto.Entry{e}, s.Entries...)
+			continue
+		}
+		s.Entries = append(s.Entries, e)
+	}
+	return s
+}
+
+func Test_JoinIterator(t *testing.T) {
+	expr := syntax.MustParseExpr(`{app="gw"} | join on(trace_id) within 10s {app="be"}`).(*syntax.JoinExpr)
+
+	for _, direction := range []logproto.Direction{logproto.FORWARD, logproto.BACKWARD} {
+		t.Run(direction.String(), func(t *testing.T) {
+			left := iter.NewStreamsIterator([]logproto.Stream{
+				newJoinTestStream(direction, `{app="gw", trace_id="a"}`, 0, 30),
+				newJoinTestStream(direction, `{app="gw", trace_id="b"}`, 5, 60),
+				newJoinTestStream(direction, `{app="gw"}`, 10),
+			}, direction)
+			right := iter.NewStreamsIterator([]logproto.Stream{
+				newJoinTestStream(direction, `{app="be", trace_id="a", pod="1"}`, 8),
+				newJoinTestStream(direction, `{app="be", trace_id="a", pod="2"}`, 9, 25),
+				newJoinTestStream(direction, `{app="be", trace_id="b", pod="1"}`, 40),
+			}, direction)
+
+			it := newJoinIterator(left, right, expr, direction, 0)
+			var actual []string
+			for it.Next() {
+				actual = append(actual, it.Entry().Timestamp.UTC().Format(time.TimeOnly)+" "+it.Labels())
+			}
+			require.NoError(t, it.Error())
+			require.NoError(t, it.Close())
+
+			expected := []string{
+				`00:00:00 {app="gw", pod="1", trace_id="a"}`,
+				`00:00:00 {app="gw", pod="2", trace_id="a"}`,
+				`00:00:30 {app="gw", pod="2", trace_id="a"}`,
+			}
+			if direction == logproto.BACKWARD {
+				expected = []string{
+					`00:00:30 {app="gw", pod="2", trace_id="a"}`,
+					`00:00:00 {app="gw", pod="1", trace_id="a"}`,
+					`00:00:00 {app="gw", pod="2", trace_id="a"}`,
+				}
+			}
+			require.ElementsMatch(t, expected, actual)
+			require.Equal(t, expected[0], actual[0])
+		})
+	}
+}
+
+func Test_JoinIterator_Limit(t *testing.T) {
+	expr := syntax.MustParseExpr(`{app="gw"} | join on(trace_id) within 10s {app="be"}`).(*syntax.JoinExpr)
+
+	left := iter.NewStreamsIterator([]logproto.Stream{
+		newJoinTestStream(logproto.FORWARD, `{app="gw", trace_id="a"}`, 10),
+	}, logproto.FORWARD)
+	right := iter.NewStreamsIterator([]logproto.Stream{
+		newJoinTestStream(logproto.FORWARD, `{app="be", trace_id="a"}`, 1, 2, 3, 4),
+	}, logproto.FORWARD)
+
+	it := newJoinIterator(left, right, expr, logproto.FORWARD, 3)
+	require.False(t, it.Next())
+	require.ErrorIs(t, it.Error(), logqlmodel.ErrLimit)
+}
+
+func Test_JoinIterator_EvictsStreams(t *testing.T) {
+	expr := syntax.MustParseExpr(`{app="gw"} | join on(trace_id) within 10s {app="be"}`).(*syntax.JoinExpr)
+
+	var leftStreams, rightStreams []logproto.Stream
+	for i := int64(0); i < 10; i++ {
+		leftStreams = append(leftStreams, newJoinTestStream(logproto.FORWARD, fmt.Sprintf(`{app="gw", trace_id="%d"}`, i), i*100))
+		rightStreams = append(rightStreams, newJoinTestStream(logproto.FORWARD, fmt.Sprintf(`{app="be", trace_id="%d"}`, i), i*100+1, i*100+2))
+	}
+	left := iter.NewStreamsIterator(leftStreams, logproto.FORWARD)
+	right := iter.NewStreamsIterator(rightStreams, logproto.FORWARD)
+
+	it := newJoinIterator(left, right, expr, logproto.FORWARD, 0).(*joinIterator)
+	var n int
+	for it.Next() {
+		n++
+		// only the stream of the buffered entries is kept.
+		require.Len(t, it.window, 2)
+		require.Len(t, it.streams, 1)
+	}
+	require.NoError(t, it.Error())
+	require.Equal(t, 10, n)
+}
+
+func Test_withoutSortStages(t *testing.T) {
+	for _, tc := range []struct {
+		in, expected string
+	}{
+		{`{app="be"}`, `{app="be"}`},
+		{`{app="be"} | json`, `{app="be"} | json`},
+		{`{app="be"} | json | limit 5`, `{app="be"} | json`},
+		{`{app="be"} | sort_by(duration) | limit 5`, `{app="be"}`},
+		{`{app="be"} | json | dedup within 1m`, `{app="be"} | json`},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			expr := syntax.MustParseExpr(tc.in).(syntax.LogSelectorExpr)
+			require.Equal(t, tc.expected, withoutSortStages(expr).String())
+		})
+	}
+}
@@ -9,12 +9,13 @@ import (
 )
 
 var (
-	NoLimits = &fakeLimits{maxSeries: math.MaxInt32}
+	NoLimits = &fakeLimits{maxSeries: math.MaxInt32, maxJoinEntries: math.MaxInt32}
 )
 
 // Limits allow the engine to fetch limits for a given users.
 type Limits interface {
 	MaxQuerySeries(context.Context, string) int
+	MaxQueryJoinEntries(context.Context, string) int
 	MaxQueryRange(ctx context.Context, userID string) time.Duration
 	QueryTimeout(context.Context, string) time.Duration
 	BlockedQueries(context.Context, string) []*validation.BlockedQuery
@@ -22,6 +23,7 @@ type Limits interface {
 
 type fakeLimits struct {
 	maxSeries      int
+	maxJoinEntries int
 	timeout        time.Duration
 	blockedQueries []*validation.BlockedQuery
 	rangeLimit     time.Duration
@@ -32,6 +34,10 @@ func (f fakeLimits) MaxQuerySeries(_ context.Context, _ string) int {
 	return f.maxSeries
 }
 
+func (f fakeLimits) MaxQueryJoinEntries(_ context.Context, _ string) int {
+	return f.maxJoinEntries
+}
+
 func (f fakeLimits) MaxQueryRange(_ context.Context, _ string) time.Duration {
 	return f.rangeLimit
 }
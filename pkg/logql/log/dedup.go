@@ -0,0 +1,64 @@
+package log
+
+import (
+	"strings"
+	"time"
+)
+
+// DedupCountLabel is the label holding the number of identical lines collapsed
+// into an entry by a dedup stage.
+const DedupCountLabel = "__dedup_count__"
+
+// Dedup is the stage of a log query collapsing identical lines into a single
+// entry. The stage doesn't modify the entries, they are collapsed by the engine
+// which keeps the first entry of each group of identical entries using Key and
+// Collapse.
+type Dedup struct {
+	Labels []string
+	Within time.Duration
+}
+
+func NewDedup(labels []string, within time.Duration) *Dedup {
+	return &Dedup{
+		Labels: labels,
+		Within: within,
+	}
+}
+
+func (d *Dedup) Process(_ int64, line []byte, _ *LabelsBuilder) ([]byte, bool) {
+	return line, true
+}
+
+func (d *Dedup) RequiredLabelNames() []string {
+	return d.Labels
+}
+
+// Key returns the key identifying identical entries: entries are identical
+// when they have the same line and the same values for the labels of the
+// stage. value returns the value of a label of the entry.
+func (d *Dedup) Key(line string, value func(name string) string) string {
+	if len(d.Labels) == 0 {
+		return line
+	}
+	var sb strings.Builder
+	for _, name := range d.Labels {
+		sb.WriteString(value(name))
+		sb.WriteByte(0xff)
+	}
+	sb.WriteString(line)
+	return sb.String()
+}
+
+// Collapse reports whether an entry at ts is collapsed into an identical entry
+// at first, which is the case when it is within the duration of the stage.
+// Without duration, identical entries are always collapsed.
+func (d *Dedup) Collapse(first, ts time.Time) bool {
+	if d.Within == 0 {
+		return true
+	}
+	diff := ts.Sub(first)
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= d.Within
+}
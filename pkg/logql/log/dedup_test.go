@@ -0,0 +1,26 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Dedup(t *testing.T) {
+	values := map[string]string{"pod": "a", "container": "b"}
+	value := func(name string) string { return values[name] }
+
+	d := NewDedup(nil, 0)
+	require.Empty(t, d.RequiredLabelNames())
+	require.Equal(t, "crash", d.Key("crash", value))
+	require.True(t, d.Collapse(time.Unix(0, 0), time.Unix(3600, 0)))
+
+	d = NewDedup([]string{"pod", "container"}, time.Minute)
+	require.Equal(t, []string{"pod", "container"}, d.RequiredLabelNames())
+	require.Equal(t, "a\xffb\xffcrash", d.Key("crash", value))
+	require.NotEqual(t, d.Key("crash", value), d.Key("crash", func(string) string { return "" }))
+	require.True(t, d.Collapse(time.Unix(60, 0), time.Unix(0, 0)))
+	require.True(t, d.Collapse(time.Unix(0, 0), time.Unix(60, 0)))
+	require.False(t, d.Collapse(time.Unix(0, 0), time.Unix(61, 0)))
+}
@@ -0,0 +1,332 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oschwald/geoip2-golang"
+	"go4.org/netipx"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	errGeoIPNotConfigured      = errors.New("geoip database is not configured")
+	errCIDRRangesNotConfigured = errors.New("cidr ranges directory is not configured")
+)
+
+// GeoIPDatabase resolves the geolocation of IP addresses for the geoip stage.
+type GeoIPDatabase interface {
+	// Lookup calls set with the name and value of each geolocation label of ip.
+	Lookup(ip netip.Addr, set func(name, value string)) error
+}
+
+// Enrichment holds the data used by the geoip and cidr_label stages: the
+// GeoIP database and the directory of the files of named networks, whose
+// parsed content is cached until they're modified.
+type Enrichment struct {
+	geoIP     GeoIPDatabase
+	rangesDir string
+
+	mtx    sync.RWMutex
+	ranges map[string]*cidrRanges
+}
+
+// NewEnrichment creates the enrichment data of the queries. The geoip stages
+// are rejected when geoIP is nil and the cidr_label stages when rangesDir is
+// empty.
+func NewEnrichment(geoIP GeoIPDatabase, rangesDir string) *Enrichment {
+	return &Enrichment{
+		geoIP:     geoIP,
+		rangesDir: rangesDir,
+		ranges:    map[string]*cidrRanges{},
+	}
+}
+
+// Close closes the GeoIP database.
+func (e *Enrichment) Close() error {
+	if closer, ok := e.geoIP.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// maxmindDatabase is a GeoIPDatabase backed by a MaxMind City or ASN database.
+type maxmindDatabase struct {
+	reader *geoip2.Reader
+	asn    bool
+}
+
+// OpenGeoIPDatabase opens the MaxMind City or ASN database at path.
+func OpenGeoIPDatabase(path string) (GeoIPDatabase, error) {
+	reader, err := geoip2.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	dbType := reader.Metadata().DatabaseType
+	switch {
+	case strings.Contains(dbType, "City"):
+		return &maxmindDatabase{reader: reader}, nil
+	case strings.Contains(dbType, "ASN"):
+		return &maxmindDatabase{reader: reader, asn: true}, nil
+	default:
+		_ = reader.Close()
+		return nil, fmt.Errorf("unsupported geoip database type %q, expected a City or ASN database", dbType)
+	}
+}
+
+// Close closes the MaxMind database.
+func (m *maxmindDatabase) Close() error {
+	return m.reader.Close()
+}
+
+// Lookup implements GeoIPDatabase with the labels of the promtail geoip stage.
+func (m *maxmindDatabase) Lookup(ip netip.Addr, set func(name, value string)) error {
+	addr := net.IP(ip.AsSlice())
+	if m.asn {
+		record, err := m.reader.ASN(addr)
+		if err != nil {
+			return err
+		}
+		if record.AutonomousSystemNumber != 0 {
+			set("geoip_autonomous_system_number", strconv.FormatUint(uint64(record.AutonomousSystemNumber), 10))
+		}
+		if record.AutonomousSystemOrganization != "" {
+			set("geoip_autonomous_system_organization", record.AutonomousSystemOrganization)
+		}
+		return nil
+	}
+
+	record, err := m.reader.City(addr)
+	if err != nil {
+		return err
+	}
+	setNonEmpty := func(name, value string) {
+		if value != "" {
+			set(name, value)
+		}
+	}
+	setNonEmpty("geoip_city_name", record.City.Names["en"])
+	setNonEmpty("geoip_country_name", record.Country.Names["en"])
+	setNonEmpty("geoip_continent_name", record.Continent.Names["en"])
+	setNonEmpty("geoip_continent_code", record.Continent.Code)
+	setNonEmpty("geoip_postal_code", record.Postal.Code)
+	setNonEmpty("geoip_timezone", record.Location.TimeZone)
+	if record.Location.Latitude != 0 || record.Location.Longitude != 0 {
+		set("geoip_location_latitude", strconv.FormatFloat(record.Location.Latitude, 'f', -1, 64))
+		set("geoip_location_longitude", strconv.FormatFloat(record.Location.Longitude, 'f', -1, 64))
+	}
+	if len(record.Subdivisions) > 0 {
+		// the last subdivision is the most specific one
+		subdivision := record.Subdivisions[len(record.Subdivisions)-1]
+		setNonEmpty("geoip_subdivision_name", subdivision.Names["en"])
+		setNonEmpty("geoip_subdivision_code", subdivision.IsoCode)
+	}
+	return nil
+}
+
+// GeoIP is a stage adding the geolocation labels of the IP address held by a
+// label, using the GeoIP database of the enrichment data.
+type GeoIP struct {
+	label string
+	db    GeoIPDatabase
+}
+
+func NewGeoIP(label string, enrichment *Enrichment) (*GeoIP, error) {
+	if enrichment == nil || enrichment.geoIP == nil {
+		return nil, errGeoIPNotConfigured
+	}
+	return &GeoIP{label: label, db: enrichment.geoIP}, nil
+}
+
+func (g *GeoIP) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	ip, ok := labelIP(lbs, g.label)
+	if !ok {
+		return line, true
+	}
+	// addresses missing from the database are not enriched.
+	_ = g.db.Lookup(ip, func(name, value string) {
+		setEnrichedLabel(lbs, name, value)
+	})
+	return line, true
+}
+
+func (g *GeoIP) RequiredLabelNames() []string { return []string{g.label} }
+
+// CIDRLabel is a stage adding the name of the network containing the IP
+// address held by a label as the <label>_network label.
+type CIDRLabel struct {
+	label  string
+	dst    string
+	ranges *cidrRanges
+}
+
+// NewCIDRLabel creates a cidr_label stage with the named networks of a file of
+// the ranges directory of the enrichment data.
+func NewCIDRLabel(label, file string, enrichment *Enrichment) (*CIDRLabel, error) {
+	if enrichment == nil {
+		return nil, errCIDRRangesNotConfigured
+	}
+	ranges, err := enrichment.loadCIDRRanges(file)
+	if err != nil {
+		return nil, err
+	}
+	return &CIDRLabel{label: label, dst: label + "_network", ranges: ranges}, nil
+}
+
+func (c *CIDRLabel) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	ip, ok := labelIP(lbs, c.label)
+	if !ok {
+		return line, true
+	}
+	if name, ok := c.ranges.lookup(ip); ok {
+		setEnrichedLabel(lbs, c.dst, name)
+	}
+	return line, true
+}
+
+func (c *CIDRLabel) RequiredLabelNames() []string { return []string{c.label} }
+
+func labelIP(lbs *LabelsBuilder, label string) (netip.Addr, bool) {
+	value, ok := lbs.Get(label)
+	if !ok {
+		return netip.Addr{}, false
+	}
+	ip, err := netip.ParseAddr(strings.TrimSpace(value))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap(), true
+}
+
+func setEnrichedLabel(lbs *LabelsBuilder, name, value string) {
+	if lbs.BaseHas(name) {
+		name = name + duplicateSuffix
+	}
+	lbs.Set(ParsedLabel, name, value)
+}
+
+type namedRange struct {
+	name string
+	rng  netipx.IPRange
+}
+
+// cidrRanges holds the named networks of a file, reloaded when it's modified.
+type cidrRanges struct {
+	modTime time.Time
+	ranges  []namedRange
+}
+
+// lookup returns the name of the narrowest network containing ip. When ip
+// belongs to overlapping networks which don't contain each other, the first
+// one in name order is returned.
+func (c *cidrRanges) lookup(ip netip.Addr) (string, bool) {
+	var best *namedRange
+	for i := range c.ranges {
+		r := &c.ranges[i]
+		if !r.rng.Contains(ip) {
+			continue
+		}
+		if best == nil || containsRange(best.rng, r.rng) && best.rng != r.rng {
+			best = r
+		}
+	}
+	if best == nil {
+		return "", false
+	}
+	return best.name, true
+}
+
+func containsRange(outer, inner netipx.IPRange) bool {
+	return outer.From().Compare(inner.From()) <= 0 && outer.To().Compare(inner.To()) >= 0
+}
+
+// loadCIDRRanges returns the named networks of a file of the ranges directory,
+// parsing it again only when it was modified since it was last loaded.
+func (e *Enrichment) loadCIDRRanges(file string) (*cidrRanges, error) {
+	dir := e.rangesDir
+	if dir == "" {
+		return nil, errCIDRRangesNotConfigured
+	}
+	// files are only read from the configured directory.
+	if file == "" || file != filepath.Base(file) || strings.HasPrefix(file, ".") {
+		return nil, fmt.Errorf("invalid cidr ranges file %q", file)
+	}
+	path := filepath.Join(dir, file)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("cidr ranges file %q: %w", file, err)
+	}
+	e.mtx.RLock()
+	cached := e.ranges[file]
+	e.mtx.RUnlock()
+	if cached != nil && cached.modTime.Equal(info.ModTime()) {
+		return cached, nil
+	}
+
+	ranges, err := parseCIDRRanges(path)
+	if err != nil {
+		return nil, fmt.Errorf("cidr ranges file %q: %w", file, err)
+	}
+	ranges.modTime = info.ModTime()
+
+	e.mtx.Lock()
+	e.ranges[file] = ranges
+	e.mtx.Unlock()
+	return ranges, nil
+}
+
+// parseCIDRRanges parses a YAML file mapping network names to lists of IP
+// addresses, CIDRs or IP ranges, such as:
+//
+//	office:
+//	  - 10.1.0.0/16
+//	  - 192.168.0.1-192.168.0.50
+//	vpn:
+//	  - 100.64.0.0/10
+func parseCIDRRanges(path string) (*cidrRanges, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var networks map[string][]string
+	if err := yaml.UnmarshalStrict(content, &networks); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(networks))
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	res := &cidrRanges{}
+	for _, name := range names {
+		for _, pattern := range networks[name] {
+			matcher, err := getMatcher(strings.TrimSpace(pattern))
+			if err != nil {
+				return nil, fmt.Errorf("network %q: %w", name, err)
+			}
+			var rng netipx.IPRange
+			switch m := matcher.(type) {
+			case netip.Addr:
+				rng = netipx.IPRangeFrom(m, m)
+			case netip.Prefix:
+				rng = netipx.RangeOfPrefix(m.Masked())
+			case netipx.IPRange:
+				rng = m
+			}
+			res.ranges = append(res.ranges, namedRange{name: name, rng: rng})
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,150 @@
+package log
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeGeoIPDatabase map[string][]string
+
+func (f fakeGeoIPDatabase) Lookup(ip netip.Addr, set func(name, value string)) error {
+	values := f[ip.String()]
+	for i := 0; i < len(values); i += 2 {
+		set(values[i], values[i+1])
+	}
+	return nil
+}
+
+func Test_GeoIP(t *testing.T) {
+	_, err := NewGeoIP("src_ip", nil)
+	require.ErrorIs(t, err, errGeoIPNotConfigured)
+	_, err = NewGeoIP("src_ip", NewEnrichment(nil, t.TempDir()))
+	require.ErrorIs(t, err, errGeoIPNotConfigured)
+
+	enrichment := NewEnrichment(fakeGeoIPDatabase{
+		"81.2.69.142": {"geoip_city_name", "London", "geoip_country_name", "United Kingdom"},
+	}, "")
+	g, err := NewGeoIP("src_ip", enrichment)
+	require.NoError(t, err)
+	require.Equal(t, []string{"src_ip"}, g.RequiredLabelNames())
+
+	for _, tc := range []struct {
+		name string
+		lbs  labels.Labels
+		ip   string
+		want labels.Labels
+	}{
+		{
+			"found",
+			labels.FromStrings("app", "foo"),
+			"81.2.69.142",
+			labels.FromStrings("app", "foo", "geoip_city_name", "London", "geoip_country_name", "United Kingdom", "src_ip", "81.2.69.142"),
+		},
+		{
+			"ipv4 mapped ipv6",
+			labels.FromStrings("app", "foo"),
+			"::ffff:81.2.69.142",
+			labels.FromStrings("app", "foo", "geoip_city_name", "London", "geoip_country_name", "United Kingdom", "src_ip", "::ffff:81.2.69.142"),
+		},
+		{
+			"duplicate stream label",
+			labels.FromStrings("geoip_city_name", "Paris"),
+			"81.2.69.142",
+			labels.FromStrings("geoip_city_name", "Paris", "geoip_city_name_extracted", "London", "geoip_country_name", "United Kingdom", "src_ip", "81.2.69.142"),
+		},
+		{
+			"not found",
+			labels.FromStrings("app", "foo"),
+			"10.0.0.1",
+			labels.FromStrings("app", "foo", "src_ip", "10.0.0.1"),
+		},
+		{
+			"invalid ip",
+			labels.FromStrings("app", "foo"),
+			"not-an-ip",
+			labels.FromStrings("app", "foo", "src_ip", "not-an-ip"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := NewBaseLabelsBuilder().ForLabels(tc.lbs, tc.lbs.Hash())
+			b.Set(ParsedLabel, "src_ip", tc.ip)
+			_, ok := g.Process(0, []byte("line"), b)
+			require.True(t, ok)
+			require.False(t, b.HasErr())
+			require.Equal(t, tc.want, b.LabelsResult().Labels())
+		})
+	}
+}
+
+func Test_CIDRLabel(t *testing.T) {
+	_, err := NewCIDRLabel("src_ip", "ranges.yaml", nil)
+	require.ErrorIs(t, err, errCIDRRangesNotConfigured)
+	_, err = NewCIDRLabel("src_ip", "ranges.yaml", NewEnrichment(nil, ""))
+	require.ErrorIs(t, err, errCIDRRangesNotConfigured)
+
+	dir := t.TempDir()
+	enrichment := NewEnrichment(nil, dir)
+	path := filepath.Join(dir, "ranges.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+private:
+  - 10.0.0.0/8
+office:
+  - 10.1.0.0/16
+  - 192.168.0.1-192.168.0.50
+gateway:
+  - 10.1.2.3
+  - 2001:db8::/32
+`), 0o644))
+
+	c, err := NewCIDRLabel("src_ip", "ranges.yaml", enrichment)
+	require.NoError(t, err)
+	require.Equal(t, []string{"src_ip"}, c.RequiredLabelNames())
+
+	for _, tc := range []struct {
+		ip      string
+		network string
+	}{
+		{"10.200.0.1", "private"},
+		{"10.1.0.1", "office"},
+		{"10.1.2.3", "gateway"},
+		{"192.168.0.20", "office"},
+		{"2001:db8::1", "gateway"},
+		{"192.168.0.51", ""},
+		{"not-an-ip", ""},
+	} {
+		t.Run(tc.ip, func(t *testing.T) {
+			lbs := labels.FromStrings("app", "foo")
+			b := NewBaseLabelsBuilder().ForLabels(lbs, lbs.Hash())
+			b.Set(ParsedLabel, "src_ip", tc.ip)
+			_, ok := c.Process(0, []byte("line"), b)
+			require.True(t, ok)
+			network, found := b.Get("src_ip_network")
+			require.Equal(t, tc.network != "", found)
+			require.Equal(t, tc.network, network)
+		})
+	}
+
+	// the file is parsed again once modified.
+	require.NoError(t, os.WriteFile(path, []byte("vpn: [10.0.0.0/8]\n"), 0o644))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	c, err = NewCIDRLabel("src_ip", "ranges.yaml", enrichment)
+	require.NoError(t, err)
+	name, ok := c.ranges.lookup(netip.MustParseAddr("10.1.2.3"))
+	require.True(t, ok)
+	require.Equal(t, "vpn", name)
+
+	for _, file := range []string{"../ranges.yaml", "sub/ranges.yaml", "..", "", "missing.yaml"} {
+		_, err := NewCIDRLabel("src_ip", file, enrichment)
+		require.Error(t, err, file)
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "invalid.yaml"), []byte("office: [10.1.0.0/33]\n"), 0o644))
+	_, err = NewCIDRLabel("src_ip", "invalid.yaml", enrichment)
+	require.ErrorIs(t, err, ErrIPFilterInvalidPattern)
+}
@@ -4,6 +4,10 @@ var (
 	// Possible errors thrown by a log pipeline.
 	errJSON             = "JSONParserErr"
 	errLogfmt           = "LogfmtParserErr"
+	errCSV              = "CSVParserErr"
+	errSD               = "SDParserErr"
+	errXML              = "XMLParserErr"
+	errExtract          = "ExtractParserErr"
 	errSampleExtraction = "SampleExtractionErr"
 	errLabelFilter      = "LabelFilterErr"
 	errTemplateFormat   = "TemplateFormatErr"
@@ -0,0 +1,219 @@
+package log
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+var _ Stage = &ExtractParser{}
+
+// Types of the named groups of the extract stage, named after the unwrap
+// conversions when they have one.
+const (
+	extractTypeString    = "string"
+	extractTypeInt       = "int"
+	extractTypeFloat     = ConvertFloat
+	extractTypeBytes     = ConvertBytes
+	extractTypeDuration  = ConvertDuration
+	extractTypeTimestamp = "timestamp"
+)
+
+// typedValue is the value of a label converted by the extract stage.
+type typedValue struct {
+	typ string
+	// number is the value of the label as a sample: the bytes of sizes and the
+	// seconds of durations and timestamps.
+	number float64
+	// duration is the exact value of durations.
+	duration time.Duration
+}
+
+// typedLabel is a label converted by the extract stage, with the value it was
+// converted from.
+type typedLabel struct {
+	name  string
+	value string
+	typedValue
+}
+
+type extractConversion func(string) (typedValue, error)
+
+var extractConversions = map[string]extractConversion{
+	extractTypeString: nil,
+	extractTypeInt: func(v string) (typedValue, error) {
+		i, err := strconv.ParseInt(v, 10, 64)
+		return typedValue{typ: extractTypeInt, number: float64(i)}, err
+	},
+	extractTypeFloat: func(v string) (typedValue, error) {
+		f, err := strconv.ParseFloat(v, 64)
+		return typedValue{typ: extractTypeFloat, number: f}, err
+	},
+	extractTypeBytes: func(v string) (typedValue, error) {
+		b, err := humanize.ParseBytes(v)
+		return typedValue{typ: extractTypeBytes, number: float64(b)}, err
+	},
+	extractTypeDuration: func(v string) (typedValue, error) {
+		d, err := time.ParseDuration(v)
+		return typedValue{typ: extractTypeDuration, number: d.Seconds(), duration: d}, err
+	},
+	extractTypeTimestamp: func(v string) (typedValue, error) {
+		// timestamps are either RFC3339 dates or unix epochs in seconds.
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return typedValue{typ: extractTypeTimestamp, number: float64(t.UnixNano()) / 1e9}, nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return typedValue{}, fmt.Errorf("invalid timestamp %q", v)
+		}
+		return typedValue{typ: extractTypeTimestamp, number: f}, nil
+	},
+}
+
+// ExtractParser is a regexp parser whose named groups can be typed, such as
+// (?P<took:duration>\S+). The values of typed groups are converted once by the
+// parser, and used as such by the numeric label filters and unwrap.
+type ExtractParser struct {
+	*RegexpParser
+	conversions map[int]extractConversion
+}
+
+// NewExtractParser creates a new extract stage from a regex expression with
+// at least one named group.
+func NewExtractParser(re string) (*ExtractParser, error) {
+	re, types, err := parseExtractGroups(re)
+	if err != nil {
+		return nil, err
+	}
+	parser, err := NewRegexpParser(re)
+	if err != nil {
+		return nil, err
+	}
+	conversions := map[int]extractConversion{}
+	for i, name := range parser.nameIndex {
+		if conv := extractConversions[types[name]]; conv != nil {
+			conversions[i] = conv
+		}
+	}
+	return &ExtractParser{RegexpParser: parser, conversions: conversions}, nil
+}
+
+// parseExtractGroups removes the types from the named groups of re, returning
+// the type of each group.
+func parseExtractGroups(re string) (string, map[string]string, error) {
+	var (
+		sb      strings.Builder
+		types   = map[string]string{}
+		inClass bool
+	)
+	for i := 0; i < len(re); i++ {
+		c := re[i]
+		switch {
+		case c == '\\' && i+1 < len(re):
+			sb.WriteString(re[i : i+2])
+			i++
+			continue
+		case inClass:
+			inClass = c != ']'
+		case c == '[':
+			inClass = true
+			// a closing bracket right after the opening one is part of the class.
+			if next := strings.TrimPrefix(re[i+1:], "^"); strings.HasPrefix(next, "]") {
+				sb.WriteString(re[i : len(re)-len(next)+1])
+				i = len(re) - len(next)
+				continue
+			}
+		case strings.HasPrefix(re[i:], "(?P<") || strings.HasPrefix(re[i:], "(?<"):
+			start := strings.IndexByte(re[i:], '<') + i + 1
+			end := strings.IndexByte(re[start:], '>')
+			if end < 0 {
+				// left to the regexp compilation to report.
+				break
+			}
+			name, typ, typed := strings.Cut(re[start:start+end], ":")
+			if typed {
+				if _, ok := extractConversions[typ]; !ok {
+					return "", nil, fmt.Errorf("unsupported type %q for extracted label '%s'", typ, name)
+				}
+				types[name] = typ
+			}
+			sb.WriteString("(?P<")
+			sb.WriteString(name)
+			sb.WriteByte('>')
+			i = start + end
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String(), types, nil
+}
+
+func (e *ExtractParser) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	parserHints := lbs.ParserLabelHints()
+	for i, value := range e.regex.FindSubmatch(line) {
+		if name, ok := e.nameIndex[i]; ok {
+			key, ok := e.keys.Get(unsafeGetBytes(name), func() (string, bool) {
+				sanitize := sanitizeLabelKey(name, true)
+				if len(sanitize) == 0 {
+					return "", false
+				}
+				if lbs.BaseHas(sanitize) {
+					sanitize = fmt.Sprintf("%s%s", sanitize, duplicateSuffix)
+				}
+				if !parserHints.ShouldExtract(sanitize) {
+					return "", false
+				}
+
+				return sanitize, true
+			})
+			if !ok {
+				continue
+			}
+
+			conv := e.conversions[i]
+			if conv == nil || len(value) == 0 {
+				lbs.Set(ParsedLabel, key, string(value))
+			} else if t, err := conv(string(value)); err != nil {
+				lbs.Set(ParsedLabel, key, string(value))
+				if !lbs.HasErr() {
+					lbs.SetErr(errExtract)
+					lbs.SetErrorDetails(fmt.Sprintf("label %s: %s", key, err))
+				}
+			} else {
+				lbs.setTyped(key, string(value), t)
+			}
+			if !parserHints.ShouldContinueParsingLine(key, lbs) {
+				return line, false
+			}
+		}
+	}
+	return line, true
+}
+
+// numberValue returns the number held by a label whose current value is v,
+// converted by the extract stage when possible.
+func numberValue(lbs *LabelsBuilder, name, v string) (float64, error) {
+	if t, ok := lbs.getTyped(name, v); ok {
+		return t.number, nil
+	}
+	return strconv.ParseFloat(v, 64)
+}
+
+// durationValue is numberValue for durations.
+func durationValue(lbs *LabelsBuilder, name, v string) (time.Duration, error) {
+	if t, ok := lbs.getTyped(name, v); ok && t.typ == extractTypeDuration {
+		return t.duration, nil
+	}
+	return time.ParseDuration(v)
+}
+
+// bytesValue is numberValue for sizes.
+func bytesValue(lbs *LabelsBuilder, name, v string) (uint64, error) {
+	if t, ok := lbs.getTyped(name, v); ok && t.typ == extractTypeBytes {
+		return uint64(t.number), nil
+	}
+	return humanize.ParseBytes(v)
+}
@@ -0,0 +1,130 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseExtractGroups(t *testing.T) {
+	for _, tc := range []struct {
+		in    string
+		out   string
+		types map[string]string
+		err   bool
+	}{
+		{`took=(?P<took:duration>\S+)`, `took=(?P<took>\S+)`, map[string]string{"took": "duration"}, false},
+		{`(?<size:bytes>\d+\w*) (?P<path>\S+)`, `(?P<size>\d+\w*) (?P<path>\S+)`, map[string]string{"size": "bytes"}, false},
+		{`\(?P<a:int>(?P<b:int>\d+)`, `\(?P<a:int>(?P<b>\d+)`, map[string]string{"b": "int"}, false},
+		{`[(?P<a:int>](?P<b:float>.)`, `[(?P<a:int>](?P<b>.)`, map[string]string{"b": "float"}, false},
+		{`[](?P<a:int>](?P<b:float>.)`, `[](?P<a:int>](?P<b>.)`, map[string]string{"b": "float"}, false},
+		{`(?P<a:uuid>.)`, ``, nil, true},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			out, types, err := parseExtractGroups(tc.in)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.out, out)
+			require.Equal(t, tc.types, types)
+		})
+	}
+}
+
+func Test_ExtractParser(t *testing.T) {
+	_, err := NewExtractParser(`(\d+)`)
+	require.ErrorIs(t, err, errMissingCapture)
+
+	p, err := NewExtractParser(`took=(?P<took:duration>\S+) size=(?P<size:bytes>\S+) status=(?P<status:int>\S+) ratio=(?P<ratio:float>\S+) ts=(?P<ts:timestamp>\S+) path=(?P<path>\S+)`)
+	require.NoError(t, err)
+
+	lbs := labels.FromStrings("app", "foo")
+	b := NewBaseLabelsBuilder().ForLabels(lbs, lbs.Hash())
+	b.Reset()
+	_, ok := p.Process(0, []byte(`took=1.5s size=2KB status=200 ratio=0.25 ts=2024-01-02T03:04:05.5Z path=/api`), b)
+	require.True(t, ok)
+	require.False(t, b.HasErr())
+	require.Equal(t, labels.FromStrings("app", "foo", "path", "/api", "ratio", "0.25", "size", "2KB", "status", "200", "took", "1.5s", "ts", "2024-01-02T03:04:05.5Z"), b.LabelsResult().Labels())
+
+	for _, tc := range []struct {
+		name string
+		want typedValue
+	}{
+		{"took", typedValue{typ: extractTypeDuration, number: 1.5, duration: 1500 * time.Millisecond}},
+		{"size", typedValue{typ: extractTypeBytes, number: 2000}},
+		{"status", typedValue{typ: extractTypeInt, number: 200}},
+		{"ratio", typedValue{typ: extractTypeFloat, number: 0.25}},
+		{"ts", typedValue{typ: extractTypeTimestamp, number: 1704164645.5}},
+	} {
+		v, _ := b.Get(tc.name)
+		got, ok := b.getTyped(tc.name, v)
+		require.True(t, ok, tc.name)
+		require.Equal(t, tc.want, got, tc.name)
+	}
+	_, ok = b.getTyped("path", "/api")
+	require.False(t, ok)
+
+	// the converted value is not used once the label is modified.
+	b.Set(ParsedLabel, "took", "2s")
+	_, ok = b.getTyped("took", "2s")
+	require.False(t, ok)
+
+	b.Reset()
+	_, ok = p.Process(0, []byte(`took=soon size=2KB status=200 ratio=0.25 ts=2024-01-02T03:04:05.5Z path=/api`), b)
+	require.True(t, ok)
+	require.Equal(t, errExtract, b.GetErr())
+	require.Equal(t, `label took: time: invalid duration "soon"`, b.GetErrorDetails())
+	took, _ := b.Get("took")
+	require.Equal(t, "soon", took)
+}
+
+func Test_ExtractParser_TypedValues(t *testing.T) {
+	p, err := NewExtractParser(`took=(?P<took:duration>\S+) size=(?P<size:bytes>\S+) ts=(?P<ts:timestamp>\S+)`)
+	require.NoError(t, err)
+	line := []byte(`took=250ms size=1KiB ts=1700000000`)
+
+	for _, tc := range []struct {
+		name   string
+		filter LabelFilterer
+		want   bool
+	}{
+		{"duration", NewDurationLabelFilter(LabelFilterGreaterThan, "took", 200*time.Millisecond), true},
+		{"duration as seconds", NewNumericLabelFilter(LabelFilterLesserThan, "took", 0.3), true},
+		{"bytes", NewBytesLabelFilter(LabelFilterEqual, "size", 1024), true},
+		{"timestamp as seconds", NewNumericLabelFilter(LabelFilterGreaterThanOrEqual, "ts", 1700000000), true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			lbs := labels.FromStrings("app", "foo")
+			b := NewBaseLabelsBuilder().ForLabels(lbs, lbs.Hash())
+			b.Reset()
+			_, ok := p.Process(0, line, b)
+			require.True(t, ok)
+			_, ok = tc.filter.Process(0, line, b)
+			require.Equal(t, tc.want, ok)
+			require.False(t, b.HasErr())
+		})
+	}
+
+	for _, tc := range []struct {
+		label, conversion string
+		want              float64
+	}{
+		{"took", ConvertFloat, 0.25},
+		{"took", ConvertDuration, 0.25},
+		{"size", ConvertBytes, 1024},
+		{"ts", ConvertFloat, 1700000000},
+	} {
+		t.Run("unwrap "+tc.conversion+"("+tc.label+")", func(t *testing.T) {
+			ex, err := LabelExtractorWithStages(tc.label, tc.conversion, nil, false, true, []Stage{p}, NoopStage)
+			require.NoError(t, err)
+			v, lbs, ok := singleSample(ex.ForStream(labels.FromStrings("app", "foo")).Process(0, line))
+			require.True(t, ok)
+			require.Equal(t, tc.want, v)
+			require.Empty(t, lbs.Labels())
+		})
+	}
+}
@@ -26,6 +26,10 @@ const (
 	LineMatchNotRegexp
 	LineMatchPattern
 	LineMatchNotPattern
+	LineMatchEqualFold
+	LineMatchNotEqualFold
+	LineMatchWord
+	LineMatchNotWord
 )
 
 func (t LineMatchType) String() string {
@@ -42,6 +46,14 @@ func (t LineMatchType) String() string {
 		return "|>"
 	case LineMatchNotPattern:
 		return "!>"
+	case LineMatchEqualFold:
+		return "|=~i"
+	case LineMatchNotEqualFold:
+		return "!=~i"
+	case LineMatchWord:
+		return "|=~w"
+	case LineMatchNotWord:
+		return "!=~w"
 	default:
 		return ""
 	}
@@ -437,9 +449,82 @@ func contains(line, substr []byte, caseInsensitive bool) bool {
 	if !caseInsensitive {
 		return bytes.Contains(line, substr)
 	}
+	if isASCII(substr) {
+		return indexFoldASCII(line, substr) >= 0
+	}
 	return containsLower(line, substr)
 }
 
+func isASCII(s []byte) bool {
+	for _, c := range s {
+		if c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+func lowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+func upperASCII(c byte) byte {
+	if 'a' <= c && c <= 'z' {
+		return c - ('a' - 'A')
+	}
+	return c
+}
+
+// indexFoldASCII returns the index of the first occurrence of the ASCII substr
+// in s, ignoring the case of ASCII letters, or -1 if it is not present.
+// The candidates are found with bytes.IndexByte on both cases of the first
+// byte of substr, which is vectorized on most platforms.
+func indexFoldASCII(s, substr []byte) int {
+	n := len(substr)
+	if n == 0 {
+		return 0
+	}
+	lo, up := lowerASCII(substr[0]), upperASCII(substr[0])
+	for i := 0; i+n <= len(s); i++ {
+		j := indexEitherByte(s[i:len(s)-n+1], lo, up)
+		if j < 0 {
+			return -1
+		}
+		i += j
+		if equalFoldASCII(s[i:i+n], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexEitherByte returns the index of the first occurrence of a or b in s.
+func indexEitherByte(s []byte, a, b byte) int {
+	i := bytes.IndexByte(s, a)
+	if a == b {
+		return i
+	}
+	if i >= 0 {
+		s = s[:i]
+	}
+	if j := bytes.IndexByte(s, b); j >= 0 {
+		return j
+	}
+	return i
+}
+
+func equalFoldASCII(a, b []byte) bool {
+	for i := range a {
+		if lowerASCII(a[i]) != lowerASCII(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 func containsLower(line, substr []byte) bool {
 	if len(substr) == 0 {
 		return true
@@ -511,6 +596,77 @@ func newContainsFilter(match []byte, caseInsensitive bool) MatcherFilterer {
 	}
 }
 
+// wordFilter keeps the lines containing a match surrounded by word boundaries,
+// such as the `\b` assertion of regular expressions.
+type wordFilter struct {
+	match           []byte
+	caseInsensitive bool
+}
+
+// newWordFilter creates a filter checking if a log line contains a match as a
+// whole word. Non-ASCII case-insensitive matches fall back to a regexp.
+func newWordFilter(match []byte, caseInsensitive bool) (MatcherFilterer, error) {
+	if len(match) == 0 {
+		return TrueFilter, nil
+	}
+	if caseInsensitive {
+		match = bytes.ToLower(match)
+	}
+	if caseInsensitive && !isASCII(match) {
+		re := `(?i)\b` + regexp.QuoteMeta(string(match)) + `\b`
+		return newRegexpFilter(re, re, true)
+	}
+	return &wordFilter{match: match, caseInsensitive: caseInsensitive}, nil
+}
+
+func (f *wordFilter) Filter(line []byte) bool {
+	for offset := 0; offset+len(f.match) <= len(line); offset++ {
+		var i int
+		if f.caseInsensitive {
+			i = indexFoldASCII(line[offset:], f.match)
+		} else {
+			i = bytes.Index(line[offset:], f.match)
+		}
+		if i < 0 {
+			return false
+		}
+		offset += i
+		if isWordBoundary(line, offset) && isWordBoundary(line, offset+len(f.match)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isWordBoundary reports whether there is a word boundary before line[i],
+// with the ASCII definition of word characters used by regular expressions.
+func isWordBoundary(line []byte, i int) bool {
+	before := i > 0 && isWordByte(line[i-1])
+	after := i < len(line) && isWordByte(line[i])
+	return before != after
+}
+
+func isWordByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_'
+}
+
+func (f *wordFilter) ToStage() Stage {
+	return StageFunc{
+		process: func(_ int64, line []byte, _ *LabelsBuilder) ([]byte, bool) {
+			return line, f.Filter(line)
+		},
+	}
+}
+
+// Matches implements Matcher
+func (f *wordFilter) Matches(test Checker) bool {
+	return test.Test(f.match, f.caseInsensitive, false)
+}
+
+func (f *wordFilter) String() string {
+	return string(f.match)
+}
+
 type containsAllFilter struct {
 	matches []containsFilter
 }
@@ -564,6 +720,18 @@ func NewFilter(match string, mt LineMatchType) (Filterer, error) {
 		return newPatternFilterer([]byte(match), true)
 	case LineMatchNotPattern:
 		return newPatternFilterer([]byte(match), false)
+	case LineMatchEqualFold:
+		return newContainsFilter([]byte(match), true), nil
+	case LineMatchNotEqualFold:
+		return NewNotFilter(newContainsFilter([]byte(match), true)), nil
+	case LineMatchWord:
+		return newWordFilter([]byte(match), false)
+	case LineMatchNotWord:
+		f, err := newWordFilter([]byte(match), false)
+		if err != nil {
+			return nil, err
+		}
+		return NewNotFilter(f), nil
 	default:
 		return nil, fmt.Errorf("unknown matcher: %v", match)
 	}
@@ -643,6 +811,9 @@ func (s *RegexSimplifier) Simplify(reg *syntax.Regexp, isLabel bool) (MatcherFil
 	case syntax.OpAlternate:
 		return s.simplifyAlternate(reg, isLabel)
 	case syntax.OpConcat:
+		if f, ok := simplifyWord(reg, isLabel); ok {
+			return f, true
+		}
 		return s.simplifyConcat(reg, nil)
 	case syntax.OpCapture:
 		util.ClearCapture(reg)
@@ -686,6 +857,20 @@ func (s *RegexSimplifier) simplifyAlternate(reg *syntax.Regexp, isLabel bool) (M
 	return f, true
 }
 
+// simplifyWord simplifies a literal surrounded by word boundaries such as
+// \bfoo\b into a word filter.
+func simplifyWord(reg *syntax.Regexp, isLabel bool) (MatcherFilterer, bool) {
+	if isLabel || len(reg.Sub) != 3 ||
+		reg.Sub[0].Op != syntax.OpWordBoundary || reg.Sub[1].Op != syntax.OpLiteral || reg.Sub[2].Op != syntax.OpWordBoundary {
+		return nil, false
+	}
+	f, err := newWordFilter([]byte(string(reg.Sub[1].Rune)), util.IsCaseInsensitive(reg.Sub[1]))
+	if err != nil {
+		return nil, false
+	}
+	return f, true
+}
+
 // simplifyConcat attempt to simplify concat operations.
 // Concat operations are either literal and star such as foo.* .*foo.* .*foo
 // which is a literalFilter.
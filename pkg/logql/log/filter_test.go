@@ -4,13 +4,14 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/grafana/regexp"
 	"github.com/stretchr/testify/require"
 )
 
 func Test_SimplifiedRegex(t *testing.T) {
 	fixtures := []string{
 		"foo", "foobar", "bar", "foobuzz", "buzz", "f", "  ", "fba", "foofoofoo", "b", "foob", "bfoo", "FoO",
-		"foo, 世界", allunicode(), "fooÏbar",
+		"foo, 世界", allunicode(), "fooÏbar", "a FOO.", "foo_bar",
 	}
 	for _, test := range []struct {
 		re string
@@ -83,6 +84,10 @@ func Test_SimplifiedRegex(t *testing.T) {
 		{`foo|fo\d+`, true, nil, true},
 		{`(\w\d+)`, true, nil, true},
 		{`.*f.*oo|fo{1,2}`, true, nil, true},
+		{`\bfoo\b`, true, &wordFilter{match: []byte("foo")}, true},
+		{`(?i)\bfoo\b`, true, &wordFilter{match: []byte("foo"), caseInsensitive: true}, true},
+		{`\bfoo\b`, true, NewNotFilter(&wordFilter{match: []byte("foo")}), false},
+		{`\bfoo`, false, nil, true},
 		{"f|f(?i)oo", true, nil, true},
 		{".foo+", true, nil, true},
 	} {
@@ -181,6 +186,7 @@ func Benchmark_LineFilter(b *testing.B) {
 		{"(HTTP/.*\\\"|HEAD|GET) (2..|5..)"},
 		{"\"@l\":\"(Warning|Error|Fatal)\""},
 		{"(?:)foo|fatal|exception"},
+		{"(?i)\\bbuzz\\b"},
 	} {
 		benchmarkRegex(b, test.re, logline, true)
 		benchmarkRegex(b, test.re, logline, false)
@@ -216,6 +222,62 @@ func benchmarkRegex(b *testing.B, re, line string, match bool) {
 	res = m
 }
 
+func Test_CaseInsensitiveFilter(t *testing.T) {
+	for _, tc := range []struct {
+		match, line string
+		expected    bool
+	}{
+		{"error", "level=ERROR msg=boom", true},
+		{"ErRoR", "level=error", true},
+		{"error", "level=err", false},
+		{"err", "e", false},
+		{"[", "{", false},
+		{"@", "`", false},
+		{"ïb", "fooÏBar", true},
+	} {
+		t.Run(tc.match+"/"+tc.line, func(t *testing.T) {
+			f, err := NewFilter(tc.match, LineMatchEqualFold)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, f.Filter([]byte(tc.line)))
+
+			f, err = NewFilter(tc.match, LineMatchNotEqualFold)
+			require.NoError(t, err)
+			require.Equal(t, !tc.expected, f.Filter([]byte(tc.line)))
+		})
+	}
+}
+
+func Test_WordFilter(t *testing.T) {
+	for _, tc := range []struct {
+		match, line string
+		expected    bool
+	}{
+		{"error", "error", true},
+		{"error", "an error occurred", true},
+		{"error", "errors: 1", false},
+		{"error", "no_error, error.", true},
+		{"error", "level=error", true},
+		{"error", "ERROR", false},
+		{"-v", "cmd -v", false},
+		{"-v", "cmd-v", true},
+		{"café", "un café noir", false},
+		{"café", "cafés", true},
+	} {
+		t.Run(tc.match+"/"+tc.line, func(t *testing.T) {
+			re := regexp.MustCompile(`\b` + regexp.QuoteMeta(tc.match) + `\b`)
+			require.Equal(t, re.MatchString(tc.line), tc.expected)
+
+			f, err := NewFilter(tc.match, LineMatchWord)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, f.Filter([]byte(tc.line)))
+
+			f, err = NewFilter(tc.match, LineMatchNotWord)
+			require.NoError(t, err)
+			require.Equal(t, !tc.expected, f.Filter([]byte(tc.line)))
+		})
+	}
+}
+
 func Test_rune(t *testing.T) {
 	require.True(t, newContainsFilter([]byte("foo"), true).Filter([]byte("foo")))
 }
@@ -0,0 +1,215 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/logqlmodel"
+)
+
+var _ Stage = &JSONFormatter{}
+
+// JSONField is a field of a line formatted by the json_format stage: the
+// value of the label Label is written at Key, whose dots separate the keys of
+// nested objects.
+type JSONField struct {
+	Key   string
+	Label string
+}
+
+// jsonNode is either a value, holding the label written at key, or an object.
+type jsonNode struct {
+	key    string
+	label  string
+	fields []*jsonNode
+}
+
+func (n *jsonNode) child(key string) *jsonNode {
+	for _, f := range n.fields {
+		if f.key == key {
+			return f
+		}
+	}
+	return nil
+}
+
+// JSONFormatter replaces the line with a JSON object built from labels.
+// Numbers and booleans are written as such, and labels which are missing or
+// empty are left out.
+type JSONFormatter struct {
+	// root is nil when every label is formatted.
+	root   *jsonNode
+	stream *jsoniter.Stream
+	lbs    labels.Labels
+}
+
+// NewJSONFormatter creates a json_format stage writing fields, or every label
+// when there are none.
+func NewJSONFormatter(fields []JSONField) (*JSONFormatter, error) {
+	j := &JSONFormatter{stream: jsoniter.NewStream(jsoniter.ConfigFastest, nil, 1024)}
+	if len(fields) == 0 {
+		return j, nil
+	}
+
+	j.root = &jsonNode{}
+	for _, f := range fields {
+		keys := strings.Split(f.Key, ".")
+		for _, k := range keys {
+			if k == "" {
+				return nil, fmt.Errorf("invalid json_format key %q", f.Key)
+			}
+		}
+		node := j.root
+		for i, k := range keys {
+			child := node.child(k)
+			last := i == len(keys)-1
+			if child != nil && (last || child.label != "") {
+				// a key can't hold both a value and an object.
+				return nil, fmt.Errorf("json_format key %q conflicts with another key", f.Key)
+			}
+			if child == nil {
+				child = &jsonNode{key: k}
+				if last {
+					child.label = f.Label
+				}
+				node.fields = append(node.fields, child)
+			}
+			node = child
+		}
+	}
+	return j, nil
+}
+
+// FormatsAllLabels reports whether every label is written to the line.
+func (j *JSONFormatter) FormatsAllLabels() bool {
+	return j.root == nil
+}
+
+func (j *JSONFormatter) Process(_ int64, _ []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	j.stream.SetBuffer(j.stream.Buffer()[:0])
+	if j.root == nil {
+		j.writeLabels(lbs)
+	} else {
+		j.writeObject(j.root, lbs)
+	}
+	return j.stream.Buffer(), true
+}
+
+func (j *JSONFormatter) writeLabels(lbs *LabelsBuilder) {
+	j.lbs = lbs.UnsortedLabels(j.lbs)
+	sort.Sort(j.lbs)
+
+	j.stream.WriteObjectStart()
+	more := false
+	for _, l := range j.lbs {
+		if l.Name == logqlmodel.ErrorLabel || l.Name == logqlmodel.ErrorDetailsLabel || l.Value == "" {
+			continue
+		}
+		if more {
+			j.stream.WriteMore()
+		}
+		j.stream.WriteObjectField(l.Name)
+		writeJSONValue(j.stream, l.Value)
+		more = true
+	}
+	j.stream.WriteObjectEnd()
+}
+
+// writeObject writes the fields of node, leaving out the objects without any
+// field.
+func (j *JSONFormatter) writeObject(node *jsonNode, lbs *LabelsBuilder) {
+	j.stream.WriteObjectStart()
+	more := false
+	for _, f := range node.fields {
+		start := len(j.stream.Buffer())
+		if more {
+			j.stream.WriteMore()
+		}
+		j.stream.WriteObjectField(f.key)
+		if f.label == "" {
+			empty := len(j.stream.Buffer())
+			j.writeObject(f, lbs)
+			if len(j.stream.Buffer()) == empty+2 {
+				j.stream.SetBuffer(j.stream.Buffer()[:start])
+				continue
+			}
+		} else {
+			value, ok := lbs.Get(f.label)
+			if !ok || value == "" {
+				j.stream.SetBuffer(j.stream.Buffer()[:start])
+				continue
+			}
+			writeJSONValue(j.stream, value)
+		}
+		more = true
+	}
+	j.stream.WriteObjectEnd()
+}
+
+func (j *JSONFormatter) RequiredLabelNames() []string {
+	if j.root == nil {
+		return nil
+	}
+	var names []string
+	var walk func(n *jsonNode)
+	walk = func(n *jsonNode) {
+		for _, f := range n.fields {
+			if f.label != "" {
+				names = append(names, f.label)
+				continue
+			}
+			walk(f)
+		}
+	}
+	walk(j.root)
+	return uniqueString(names)
+}
+
+func writeJSONValue(stream *jsoniter.Stream, value string) {
+	switch {
+	case value == "true" || value == "false" || isJSONNumber(value):
+		stream.WriteRaw(value)
+	default:
+		stream.WriteString(value)
+	}
+}
+
+// isJSONNumber reports whether s is a number as defined by the JSON grammar.
+func isJSONNumber(s string) bool {
+	i := 0
+	digits := func() bool {
+		start := i
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		return i > start
+	}
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	if i < len(s) && s[i] == '0' {
+		i++
+	} else if !digits() {
+		return false
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		if !digits() {
+			return false
+		}
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		if !digits() {
+			return false
+		}
+	}
+	return i == len(s)
+}
@@ -0,0 +1,92 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_JSONFormatter(t *testing.T) {
+	lbs := labels.FromStrings("app", "foo", "namespace", "prod")
+
+	for _, tc := range []struct {
+		name   string
+		fields []JSONField
+		want   string
+		names  []string
+	}{
+		{
+			"all labels",
+			nil,
+			`{"app":"foo","duration":1.5e3,"level":"error","msg":"connection \"refused\"","namespace":"prod","ok":false,"status":500,"trace_id":"0042"}`,
+			nil,
+		},
+		{
+			"nested fields",
+			[]JSONField{
+				{Key: "level", Label: "level"},
+				{Key: "http.status", Label: "status"},
+				{Key: "http.request.duration", Label: "duration"},
+				{Key: "trace.id", Label: "trace_id"},
+				{Key: "ok", Label: "ok"},
+			},
+			`{"level":"error","http":{"status":500,"request":{"duration":1.5e3}},"trace":{"id":"0042"},"ok":false}`,
+			[]string{"level", "status", "duration", "trace_id", "ok"},
+		},
+		{
+			"missing fields are left out",
+			[]JSONField{
+				{Key: "user.name", Label: "user"},
+				{Key: "user.id", Label: "user_id"},
+				{Key: "level", Label: "level"},
+				{Key: "empty", Label: "empty"},
+			},
+			`{"level":"error"}`,
+			[]string{"user", "user_id", "level", "empty"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			j, err := NewJSONFormatter(tc.fields)
+			require.NoError(t, err)
+			require.Equal(t, tc.fields == nil, j.FormatsAllLabels())
+			require.Equal(t, tc.names, j.RequiredLabelNames())
+
+			b := NewBaseLabelsBuilder().ForLabels(lbs, lbs.Hash())
+			b.Set(StructuredMetadataLabel, "trace_id", "0042")
+			b.Set(ParsedLabel, "level", "error")
+			b.Set(ParsedLabel, "status", "500")
+			b.Set(ParsedLabel, "duration", "1.5e3")
+			b.Set(ParsedLabel, "ok", "false")
+			b.Set(ParsedLabel, "msg", `connection "refused"`)
+			b.Set(ParsedLabel, "empty", "")
+			b.SetErr(errJSON)
+
+			line, ok := j.Process(0, []byte("line"), b)
+			require.True(t, ok)
+			require.Equal(t, tc.want, string(line))
+		})
+	}
+}
+
+func Test_NewJSONFormatterErrors(t *testing.T) {
+	for _, fields := range [][]JSONField{
+		{{Key: "http", Label: "a"}, {Key: "http.status", Label: "b"}},
+		{{Key: "http.status", Label: "a"}, {Key: "http", Label: "b"}},
+		{{Key: "level", Label: "a"}, {Key: "level", Label: "b"}},
+		{{Key: "http.", Label: "a"}},
+		{{Key: ".status", Label: "a"}},
+	} {
+		_, err := NewJSONFormatter(fields)
+		require.Error(t, err)
+	}
+}
+
+func Test_isJSONNumber(t *testing.T) {
+	for _, s := range []string{"0", "-1", "42", "3.14", "-0.5", "1e10", "2.5E-3", "1e+2"} {
+		require.True(t, isJSONNumber(s), s)
+	}
+	for _, s := range []string{"", "-", "01", "1.", ".5", "1e", "+1", "0x10", "NaN", "Inf", " 1", "1 ", "1_000"} {
+		require.False(t, isJSONNumber(s), s)
+	}
+}
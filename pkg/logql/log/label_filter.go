@@ -178,7 +178,7 @@ func (d *BytesLabelFilter) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]
 		// we have not found this label.
 		return line, false
 	}
-	value, err := humanize.ParseBytes(v)
+	value, err := bytesValue(lbs, d.Name, v)
 	if err != nil {
 		// Don't overwrite what might be a more useful error
 		if !lbs.HasErr() {
@@ -246,7 +246,7 @@ func (d *DurationLabelFilter) Process(_ int64, line []byte, lbs *LabelsBuilder)
 		// we have not found this label.
 		return line, false
 	}
-	value, err := time.ParseDuration(v)
+	value, err := durationValue(lbs, d.Name, v)
 	if err != nil {
 		// Don't overwrite what might be a more useful error
 		if !lbs.HasErr() {
@@ -309,7 +309,7 @@ func (n *NumericLabelFilter) Process(_ int64, line []byte, lbs *LabelsBuilder) (
 		// we have not found this label.
 		return line, false
 	}
-	value, err := strconv.ParseFloat(v, 64)
+	value, err := numberValue(lbs, n.Name, v)
 	if err != nil {
 		// Don't overwrite what might be a more useful error
 		if !lbs.HasErr() {
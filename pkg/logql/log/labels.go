@@ -133,6 +133,8 @@ type BaseLabelsBuilder struct {
 	err string
 	// nolint:structcheck
 	errDetails string
+	// typed are the values converted by the extract stage.
+	typed []typedLabel
 
 	groups                       []string
 	baseMap                      map[string]string
@@ -211,6 +213,7 @@ func (b *BaseLabelsBuilder) Reset() {
 	}
 	b.err = ""
 	b.errDetails = ""
+	b.typed = b.typed[:0]
 	b.baseMap = nil
 	b.parserKeyHints.Reset()
 }
@@ -355,6 +358,30 @@ func (b *LabelsBuilder) Set(category LabelCategory, n, v string) *LabelsBuilder
 	return b
 }
 
+// setTyped sets a parsed label, along with the value it was converted to.
+func (b *LabelsBuilder) setTyped(n, v string, t typedValue) *LabelsBuilder {
+	b.Set(ParsedLabel, n, v)
+	for i := range b.typed {
+		if b.typed[i].name == n {
+			b.typed[i] = typedLabel{name: n, value: v, typedValue: t}
+			return b
+		}
+	}
+	b.typed = append(b.typed, typedLabel{name: n, value: v, typedValue: t})
+	return b
+}
+
+// getTyped returns the converted value of a label whose current value is v,
+// as long as it wasn't modified since its conversion.
+func (b *LabelsBuilder) getTyped(n, v string) (typedValue, bool) {
+	for _, t := range b.typed {
+		if t.name == n {
+			return t.typedValue, t.value == v
+		}
+	}
+	return typedValue{}, false
+}
+
 // Add the labels to the builder. If a label with the same name
 // already exists in the base labels, a suffix is added to the name.
 func (b *LabelsBuilder) Add(category LabelCategory, labels ...labels.Label) *LabelsBuilder {
@@ -2,20 +2,31 @@ package log
 
 import (
 	"context"
+	"math"
 	"sort"
 	"strconv"
 	"time"
 
+	"github.com/cespare/xxhash/v2"
 	"github.com/pkg/errors"
 	"github.com/prometheus/prometheus/model/labels"
 
 	"github.com/dustin/go-humanize"
+
+	"github.com/grafana/loki/v3/pkg/logqlmodel"
 )
 
 const (
 	ConvertBytes    = "bytes"
 	ConvertDuration = "duration"
 	ConvertFloat    = "float"
+	// ConvertHash converts a value to the bits of its 64 bits hash, for
+	// aggregations counting distinct values.
+	ConvertHash = "hash"
+
+	// UnwrappedLabel is the label holding the name of the unwrapped label of
+	// the samples extracted from several labels.
+	UnwrappedLabel = "__unwrapped__"
 )
 
 // LineExtractor extracts a float64 from a log line.
@@ -31,12 +42,19 @@ type SampleExtractor interface {
 	ForStream(labels labels.Labels) StreamSampleExtractor
 }
 
-// StreamSampleExtractor extracts sample for a log line.
-// A StreamSampleExtractor never mutate the received line.
+// ExtractedSample is a sample extracted from a log line, with its labels.
+type ExtractedSample struct {
+	Value  float64
+	Labels LabelsResult
+}
+
+// StreamSampleExtractor extracts samples for a log line, usually a single one.
+// A StreamSampleExtractor never mutate the received line, and the returned
+// samples are only valid until the next call.
 type StreamSampleExtractor interface {
 	BaseLabels() LabelsResult
-	Process(ts int64, line []byte, structuredMetadata ...labels.Label) (float64, LabelsResult, bool)
-	ProcessString(ts int64, line string, structuredMetadata ...labels.Label) (float64, LabelsResult, bool)
+	Process(ts int64, line []byte, structuredMetadata ...labels.Label) ([]ExtractedSample, bool)
+	ProcessString(ts int64, line string, structuredMetadata ...labels.Label) ([]ExtractedSample, bool)
 	ReferencedStructuredMetadata() bool
 }
 
@@ -86,29 +104,30 @@ type streamLineSampleExtractor struct {
 	Stage
 	LineExtractor
 	builder *LabelsBuilder
+	samples [1]ExtractedSample
 }
 
 func (l *streamLineSampleExtractor) ReferencedStructuredMetadata() bool {
 	return l.builder.referencedStructuredMetadata
 }
 
-func (l *streamLineSampleExtractor) Process(ts int64, line []byte, structuredMetadata ...labels.Label) (float64, LabelsResult, bool) {
+func (l *streamLineSampleExtractor) Process(ts int64, line []byte, structuredMetadata ...labels.Label) ([]ExtractedSample, bool) {
 	l.builder.Reset()
 	l.builder.Add(StructuredMetadataLabel, structuredMetadata...)
 
 	// short circuit.
-	if l.Stage == NoopStage {
-		return l.LineExtractor(line), l.builder.GroupedLabels(), true
-	}
-
-	line, ok := l.Stage.Process(ts, line, l.builder)
-	if !ok {
-		return 0, nil, false
+	if l.Stage != NoopStage {
+		var ok bool
+		line, ok = l.Stage.Process(ts, line, l.builder)
+		if !ok {
+			return nil, false
+		}
 	}
-	return l.LineExtractor(line), l.builder.GroupedLabels(), true
+	l.samples[0] = ExtractedSample{Value: l.LineExtractor(line), Labels: l.builder.GroupedLabels()}
+	return l.samples[:], true
 }
 
-func (l *streamLineSampleExtractor) ProcessString(ts int64, line string, structuredMetadata ...labels.Label) (float64, LabelsResult, bool) {
+func (l *streamLineSampleExtractor) ProcessString(ts int64, line string, structuredMetadata ...labels.Label) ([]ExtractedSample, bool) {
 	// unsafe get bytes since we have the guarantee that the line won't be mutated.
 	return l.Process(ts, unsafeGetBytes(line), structuredMetadata...)
 }
@@ -117,11 +136,34 @@ func (l *streamLineSampleExtractor) BaseLabels() LabelsResult { return l.builder
 
 type convertionFn func(value string) (float64, error)
 
-type labelSampleExtractor struct {
-	preStage     Stage
-	postFilter   Stage
-	labelName    string
+// unwrappedLabel is a label whose value is converted to a sample.
+type unwrappedLabel struct {
+	name         string
+	conversion   string
 	conversionFn convertionFn
+}
+
+// convert converts the value of the label, using the value converted by the
+// extract stage when it has the expected type.
+func (u unwrappedLabel) convert(lbs *LabelsBuilder, value string) (float64, error) {
+	if u.conversion != ConvertHash {
+		if t, ok := lbs.getTyped(u.name, value); ok && (u.conversion == ConvertFloat || u.conversion == t.typ) {
+			return t.number, nil
+		}
+	}
+	return u.conversionFn(value)
+}
+
+type labelSampleExtractor struct {
+	preStage   Stage
+	postFilter Stage
+	labels     []unwrappedLabel
+	// multiple is true when the samples are identified by the UnwrappedLabel.
+	multiple bool
+
+	// structuredMetadataFastPath is true when samples can be read from the
+	// structured metadata of entries without running the pre stages.
+	structuredMetadataFastPath bool
 
 	baseBuilder      *BaseLabelsBuilder
 	streamExtractors map[uint64]StreamSampleExtractor
@@ -136,17 +178,11 @@ func LabelExtractorWithStages(
 	preStages []Stage,
 	postFilter Stage,
 ) (SampleExtractor, error) {
-	var convFn convertionFn
-	switch conversion {
-	case ConvertBytes:
-		convFn = convertBytes
-	case ConvertDuration:
-		convFn = convertDuration
-	case ConvertFloat:
-		convFn = convertFloat
-	default:
-		return nil, errors.Errorf("unsupported conversion operation %s", conversion)
+	convFn, err := conversionFn(conversion)
+	if err != nil {
+		return nil, err
 	}
+	fastPath := canReadStructuredMetadata(preStages, postFilter, groups, without, noLabels)
 	if len(groups) == 0 || without {
 		without = true
 		groups = append(groups, labelName)
@@ -154,19 +190,104 @@ func LabelExtractorWithStages(
 	}
 	preStage := ReduceStages(preStages)
 	hints := NewParserHint(append(preStage.RequiredLabelNames(), postFilter.RequiredLabelNames()...), groups, without, noLabels, labelName, append(preStages, postFilter))
+	return &labelSampleExtractor{
+		preStage:                   preStage,
+		labels:                     []unwrappedLabel{{name: labelName, conversion: conversion, conversionFn: convFn}},
+		postFilter:                 postFilter,
+		structuredMetadataFastPath: fastPath,
+		baseBuilder:                NewBaseLabelsBuilderWithGrouping(groups, hints, without, noLabels),
+		streamExtractors:           make(map[uint64]StreamSampleExtractor),
+	}, nil
+}
+
+// MultiLabelExtractorWithStages creates a SampleExtractor extracting a sample
+// from each of the labels of a log line, converted with the conversion of the
+// same index. The samples of each label are identified by the UnwrappedLabel,
+// which must be kept by the grouping.
+func MultiLabelExtractorWithStages(
+	labelNames, conversions []string,
+	groups []string, without, noLabels bool,
+	preStages []Stage,
+	postFilter Stage,
+) (SampleExtractor, error) {
+	if len(labelNames) != len(conversions) {
+		return nil, errors.Errorf("expected a conversion for each of the %d unwrapped labels, got %d", len(labelNames), len(conversions))
+	}
+	unwrapped := make([]unwrappedLabel, 0, len(labelNames))
+	for i, name := range labelNames {
+		convFn, err := conversionFn(conversions[i])
+		if err != nil {
+			return nil, err
+		}
+		unwrapped = append(unwrapped, unwrappedLabel{name: name, conversion: conversions[i], conversionFn: convFn})
+	}
+	if len(groups) == 0 || without {
+		without = true
+		groups = append(groups, labelNames...)
+		sort.Strings(groups)
+	}
+	preStage := ReduceStages(preStages)
+	hints := NewParserHint(append(append(preStage.RequiredLabelNames(), postFilter.RequiredLabelNames()...), labelNames...), groups, without, noLabels, "", append(preStages, postFilter))
 	return &labelSampleExtractor{
 		preStage:         preStage,
-		conversionFn:     convFn,
-		labelName:        labelName,
+		labels:           unwrapped,
+		multiple:         true,
 		postFilter:       postFilter,
 		baseBuilder:      NewBaseLabelsBuilderWithGrouping(groups, hints, without, noLabels),
 		streamExtractors: make(map[uint64]StreamSampleExtractor),
 	}, nil
 }
 
+func conversionFn(conversion string) (convertionFn, error) {
+	switch conversion {
+	case ConvertBytes:
+		return convertBytes, nil
+	case ConvertDuration:
+		return convertDuration, nil
+	case ConvertFloat:
+		return convertFloat, nil
+	case ConvertHash:
+		return convertHash, nil
+	default:
+		return nil, errors.Errorf("unsupported conversion operation %s", conversion)
+	}
+}
+
+// canReadStructuredMetadata reports whether an unwrapped label found in the
+// structured metadata of an entry can be read without running the pre stages.
+// Parsers never override structured metadata labels, so this is the case when
+// the pre stages are only parsers which never report errors, the post filter
+// only checks for errors and the samples are grouped by some labels or have
+// none, so their labels don't include all the parsed labels.
+// The other parsers report the malformed lines, which are dropped by an error
+// filter or fail the query, so they must run.
+func canReadStructuredMetadata(preStages []Stage, postFilter Stage, groups []string, without, noLabels bool) bool {
+	if !noLabels && (without || len(groups) == 0) {
+		return false
+	}
+	for _, s := range preStages {
+		switch s.(type) {
+		case *RegexpParser, *PatternParser:
+		default:
+			return false
+		}
+	}
+	for _, name := range postFilter.RequiredLabelNames() {
+		if name != logqlmodel.ErrorLabel && name != logqlmodel.ErrorDetailsLabel {
+			return false
+		}
+	}
+	return true
+}
+
 type streamLabelSampleExtractor struct {
 	*labelSampleExtractor
 	builder *LabelsBuilder
+	samples []ExtractedSample
+
+	// structuredMetadataFastPath is false when the unwrapped label is a
+	// stream label, which takes precedence over structured metadata.
+	structuredMetadataFastPath bool
 }
 
 func (l *labelSampleExtractor) ReferencedStructuredMetadata() bool {
@@ -180,51 +301,115 @@ func (l *labelSampleExtractor) ForStream(labels labels.Labels) StreamSampleExtra
 	}
 
 	res := &streamLabelSampleExtractor{
-		labelSampleExtractor: l,
-		builder:              l.baseBuilder.ForLabels(labels, hash),
+		labelSampleExtractor:       l,
+		builder:                    l.baseBuilder.ForLabels(labels, hash),
+		structuredMetadataFastPath: l.structuredMetadataFastPath && !labels.Has(l.labels[0].name),
+		samples:                    make([]ExtractedSample, 0, len(l.labels)),
 	}
 	l.streamExtractors[hash] = res
 	return res
 }
 
-func (l *streamLabelSampleExtractor) Process(ts int64, line []byte, structuredMetadata ...labels.Label) (float64, LabelsResult, bool) {
+func (l *streamLabelSampleExtractor) Process(ts int64, line []byte, structuredMetadata ...labels.Label) ([]ExtractedSample, bool) {
+	l.samples = l.samples[:0]
+	if l.structuredMetadataFastPath {
+		if v, lbs, ok := l.processStructuredMetadata(ts, line, structuredMetadata); ok {
+			l.samples = append(l.samples, ExtractedSample{Value: v, Labels: lbs})
+			return l.samples, true
+		}
+	}
+
 	// Apply the pipeline first.
 	l.builder.Reset()
 	l.builder.Add(StructuredMetadataLabel, structuredMetadata...)
 	line, ok := l.preStage.Process(ts, line, l.builder)
 	if !ok {
-		return 0, nil, false
+		return nil, false
 	}
-	// convert the label value.
-	var v float64
-	stringValue, _ := l.builder.Get(l.labelName)
+	// the errors of a sample must not leak into the samples of the next labels.
+	preErr, preErrDetails := l.builder.GetErr(), l.builder.GetErrorDetails()
+	for i, u := range l.labels {
+		if i > 0 {
+			l.builder.SetErr(preErr)
+			l.builder.SetErrorDetails(preErrDetails)
+		}
+		// convert the label value.
+		stringValue, _ := l.builder.Get(u.name)
+		if stringValue == "" {
+			// NOTE: It's totally fine for log line to not have this particular label.
+			// See Issue: https://github.com/grafana/loki/issues/6713
+			continue
+		}
+		if l.multiple {
+			l.builder.Set(ParsedLabel, UnwrappedLabel, u.name)
+		}
+
+		v, err := u.convert(l.builder, stringValue)
+		if err != nil {
+			l.builder.SetErr(errSampleExtraction)
+			l.builder.SetErrorDetails(err.Error())
+		}
+
+		// post filters
+		if _, ok = l.postFilter.Process(ts, line, l.builder); !ok {
+			continue
+		}
+		l.samples = append(l.samples, ExtractedSample{Value: v, Labels: l.builder.GroupedLabels()})
+	}
+	return l.samples, len(l.samples) > 0
+}
+
+func (l *streamLabelSampleExtractor) ProcessString(ts int64, line string, structuredMetadata ...labels.Label) ([]ExtractedSample, bool) {
+	// unsafe get bytes since we have the guarantee that the line won't be mutated.
+	return l.Process(ts, unsafeGetBytes(line), structuredMetadata...)
+}
+
+func (l *streamLabelSampleExtractor) BaseLabels() LabelsResult { return l.builder.currentResult }
+
+// processStructuredMetadata reads the sample of an entry from its structured
+// metadata without running the pre stages. It returns false when the sample
+// must be extracted by the pre stages instead: the unwrapped label or one of the
+// grouping labels is missing, or the value can't be converted.
+func (l *streamLabelSampleExtractor) processStructuredMetadata(ts int64, line []byte, structuredMetadata []labels.Label) (float64, LabelsResult, bool) {
+	stringValue := structuredMetadataValue(structuredMetadata, l.labels[0].name)
 	if stringValue == "" {
-		// NOTE: It's totally fine for log line to not have this particular label.
-		// See Issue: https://github.com/grafana/loki/issues/6713
 		return 0, nil, false
 	}
-
-	var err error
-	v, err = l.conversionFn(stringValue)
+	v, err := l.labels[0].conversionFn(stringValue)
 	if err != nil {
-		l.builder.SetErr(errSampleExtraction)
-		l.builder.SetErrorDetails(err.Error())
+		return 0, nil, false
 	}
 
-	// post filters
-	if _, ok = l.postFilter.Process(ts, line, l.builder); !ok {
+	l.builder.Reset()
+	l.builder.referencedStructuredMetadata = true
+	if !l.builder.noLabels {
+		for _, g := range l.builder.groups {
+			if l.builder.BaseHas(g) {
+				continue
+			}
+			value := structuredMetadataValue(structuredMetadata, g)
+			if value == "" {
+				return 0, nil, false
+			}
+			l.builder.Set(StructuredMetadataLabel, g, value)
+		}
+	}
+
+	if _, ok := l.postFilter.Process(ts, line, l.builder); !ok {
 		return 0, nil, false
 	}
 	return v, l.builder.GroupedLabels(), true
 }
 
-func (l *streamLabelSampleExtractor) ProcessString(ts int64, line string, structuredMetadata ...labels.Label) (float64, LabelsResult, bool) {
-	// unsafe get bytes since we have the guarantee that the line won't be mutated.
-	return l.Process(ts, unsafeGetBytes(line), structuredMetadata...)
+func structuredMetadataValue(structuredMetadata []labels.Label, name string) string {
+	for _, l := range structuredMetadata {
+		if l.Name == name {
+			return l.Value
+		}
+	}
+	return ""
 }
 
-func (l *streamLabelSampleExtractor) BaseLabels() LabelsResult { return l.builder.currentResult }
-
 // NewFilteringSampleExtractor creates a sample extractor where entries from
 // the underlying log stream are filtered by pipeline filters before being
 // passed to extract samples. Filters are always upstream of the extractor.
@@ -271,7 +456,7 @@ func (sp *filteringStreamExtractor) BaseLabels() LabelsResult {
 	return sp.extractor.BaseLabels()
 }
 
-func (sp *filteringStreamExtractor) Process(ts int64, line []byte, structuredMetadata ...labels.Label) (float64, LabelsResult, bool) {
+func (sp *filteringStreamExtractor) Process(ts int64, line []byte, structuredMetadata ...labels.Label) ([]ExtractedSample, bool) {
 	for _, filter := range sp.filters {
 		if ts < filter.start || ts > filter.end {
 			continue
@@ -279,14 +464,14 @@ func (sp *filteringStreamExtractor) Process(ts int64, line []byte, structuredMet
 
 		_, _, matches := filter.pipeline.Process(ts, line, structuredMetadata...)
 		if matches { // When the filter matches, don't run the next step
-			return 0, nil, false
+			return nil, false
 		}
 	}
 
 	return sp.extractor.Process(ts, line)
 }
 
-func (sp *filteringStreamExtractor) ProcessString(ts int64, line string, structuredMetadata ...labels.Label) (float64, LabelsResult, bool) {
+func (sp *filteringStreamExtractor) ProcessString(ts int64, line string, structuredMetadata ...labels.Label) ([]ExtractedSample, bool) {
 	for _, filter := range sp.filters {
 		if ts < filter.start || ts > filter.end {
 			continue
@@ -294,7 +479,7 @@ func (sp *filteringStreamExtractor) ProcessString(ts int64, line string, structu
 
 		_, _, matches := filter.pipeline.ProcessString(ts, line, structuredMetadata...)
 		if matches { // When the filter matches, don't run the next step
-			return 0, nil, false
+			return nil, false
 		}
 	}
 
@@ -313,6 +498,10 @@ func convertDuration(v string) (float64, error) {
 	return d.Seconds(), nil
 }
 
+func convertHash(v string) (float64, error) {
+	return math.Float64frombits(xxhash.Sum64String(v)), nil
+}
+
 func convertBytes(v string) (float64, error) {
 	b, err := humanize.ParseBytes(v)
 	if err != nil {
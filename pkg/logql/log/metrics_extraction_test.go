@@ -1,12 +1,15 @@
 package log
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/logqlmodel"
 )
 
 func Test_labelSampleExtractor_Extract(t *testing.T) {
@@ -232,15 +235,62 @@ func Test_labelSampleExtractor_Extract(t *testing.T) {
 			wantOk: true,
 			line:   "foo=not_a_number",
 		},
+		{
+			name: "structured metadata, parser skipped",
+			ex: mustSampleExtractor(LabelExtractorWithStages(
+				"foo", ConvertDuration, []string{"bar", "buzz"}, false, false, []Stage{mustStage(NewRegexpParser(`foo=(?P<foo>\S+) buzz=(?P<buzz>\S+)`))}, NoopStage,
+			)),
+			in:                 labels.FromStrings("bar", "foo"),
+			structuredMetadata: labels.FromStrings("foo", "20ms", "buzz", "blip"),
+			want:               0.02,
+			wantLbs:            labels.FromStrings("bar", "foo", "buzz", "blip"),
+			wantOk:             true,
+			line:               `foo=1s buzz=blop`,
+		},
+		{
+			name: "structured metadata, grouping on a parsed label",
+			ex: mustSampleExtractor(LabelExtractorWithStages(
+				"foo", ConvertDuration, []string{"level"}, false, false, []Stage{NewLogfmtParser(false, false)}, NoopStage,
+			)),
+			in:                 labels.FromStrings("bar", "foo"),
+			structuredMetadata: labels.FromStrings("foo", "20ms"),
+			want:               0.02,
+			wantLbs:            labels.FromStrings("level", "info"),
+			wantOk:             true,
+			line:               "foo=1s level=info",
+		},
+		{
+			name: "structured metadata, no grouping",
+			ex: mustSampleExtractor(LabelExtractorWithStages(
+				"foo", ConvertDuration, nil, false, false, []Stage{NewLogfmtParser(false, false)}, NoopStage,
+			)),
+			in:                 labels.FromStrings("bar", "foo"),
+			structuredMetadata: labels.FromStrings("foo", "20ms"),
+			want:               0.02,
+			wantLbs:            labels.FromStrings("bar", "foo", "level", "info"),
+			wantOk:             true,
+			line:               "foo=1s level=info",
+		},
+		{
+			name: "structured metadata, stream label takes precedence",
+			ex: mustSampleExtractor(LabelExtractorWithStages(
+				"foo", ConvertFloat, []string{"bar"}, false, false, nil, NoopStage,
+			)),
+			in:                 labels.FromStrings("bar", "foo", "foo", "1"),
+			structuredMetadata: labels.FromStrings("foo", "2"),
+			want:               1,
+			wantLbs:            labels.FromStrings("bar", "foo"),
+			wantOk:             true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			outval, outlbs, ok := tt.ex.ForStream(tt.in).Process(0, []byte(tt.line), tt.structuredMetadata...)
+			outval, outlbs, ok := singleSample(tt.ex.ForStream(tt.in).Process(0, []byte(tt.line), tt.structuredMetadata...))
 			require.Equal(t, tt.wantOk, ok)
 			require.Equal(t, tt.want, outval)
 			require.Equal(t, tt.wantLbs, outlbs.Labels())
 
-			outval, outlbs, ok = tt.ex.ForStream(tt.in).ProcessString(0, tt.line, tt.structuredMetadata...)
+			outval, outlbs, ok = singleSample(tt.ex.ForStream(tt.in).ProcessString(0, tt.line, tt.structuredMetadata...))
 			require.Equal(t, tt.wantOk, ok)
 			require.Equal(t, tt.want, outval)
 			require.Equal(t, tt.wantLbs, outlbs.Labels())
@@ -248,10 +298,115 @@ func Test_labelSampleExtractor_Extract(t *testing.T) {
 	}
 }
 
+func Test_canReadStructuredMetadata(t *testing.T) {
+	errorFilter := NewStringLabelFilter(labels.MustNewMatcher(labels.MatchEqual, logqlmodel.ErrorLabel, ""))
+	regexp := mustStage(NewRegexpParser(`level=(?P<level>\w+)`))
+	pattern := mustStage(NewPatternParser(`<_> level=<level>`))
+	for _, tc := range []struct {
+		name       string
+		preStages  []Stage
+		postFilter Stage
+		groups     []string
+		without    bool
+		noLabels   bool
+		expected   bool
+	}{
+		{"no stages", nil, NoopStage, []string{"pod"}, false, false, true},
+		{"parsers", []Stage{regexp, pattern}, NoopStage, []string{"pod"}, false, false, true},
+		{"parser reporting errors", []Stage{NewJSONParser()}, NoopStage, []string{"pod"}, false, false, false},
+		{"parser reporting errors and error filter", []Stage{NewLogfmtParser(false, false)}, errorFilter, []string{"pod"}, false, false, false},
+		{"error filter", []Stage{regexp}, errorFilter, []string{"pod"}, false, false, true},
+		{"no grouping", []Stage{regexp}, NoopStage, nil, false, false, false},
+		{"no labels", []Stage{regexp}, NoopStage, nil, false, true, true},
+		{"without", []Stage{regexp}, NoopStage, []string{"pod"}, true, false, false},
+		{"without and no labels", []Stage{regexp}, NoopStage, nil, true, true, true},
+		{"label filter", []Stage{regexp, NewStringLabelFilter(labels.MustNewMatcher(labels.MatchEqual, "level", "error"))}, NoopStage, []string{"pod"}, false, false, false},
+		{"post filter", nil, NewStringLabelFilter(labels.MustNewMatcher(labels.MatchEqual, "level", "error")), []string{"pod"}, false, false, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, canReadStructuredMetadata(tc.preStages, tc.postFilter, tc.groups, tc.without, tc.noLabels))
+		})
+	}
+}
+
+// Test_LabelExtractor_StructuredMetadataEquivalence checks that the samples
+// read from the structured metadata are the ones extracted by the pre stages,
+// including for the lines the parsers can't parse.
+func Test_LabelExtractor_StructuredMetadataEquivalence(t *testing.T) {
+	errorFilter := NewStringLabelFilter(labels.MustNewMatcher(labels.MatchEqual, logqlmodel.ErrorLabel, ""))
+	lbs := labels.FromStrings("app", "gateway")
+	structuredMetadata := labels.FromStrings("latency", "125ms", "pod", "gateway-0")
+	lines := []string{
+		`{"level":"info","latency":"130ms"}`,
+		`{"level":"info","latency":`,
+		`level=info latency=130ms`,
+		`level=info latency="130ms`,
+		`not a log line`,
+	}
+
+	for _, tc := range []struct {
+		name       string
+		preStages  func() []Stage
+		postFilter Stage
+	}{
+		{"json", func() []Stage { return []Stage{NewJSONParser()} }, NoopStage},
+		{"json and error filter", func() []Stage { return []Stage{NewJSONParser()} }, errorFilter},
+		{"logfmt and error filter", func() []Stage { return []Stage{NewLogfmtParser(false, false)} }, errorFilter},
+		{"regexp and error filter", func() []Stage { return []Stage{mustStage(NewRegexpParser(`level=(?P<level>\w+)`))} }, errorFilter},
+		{"pattern", func() []Stage { return []Stage{mustStage(NewPatternParser(`level=<level> <_>`))} }, NoopStage},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ex, err := LabelExtractorWithStages("latency", ConvertDuration, []string{"pod"}, false, false, tc.preStages(), tc.postFilter)
+			require.NoError(t, err)
+			expected, err := LabelExtractorWithStages("latency", ConvertDuration, []string{"pod"}, false, false, tc.preStages(), tc.postFilter)
+			require.NoError(t, err)
+			expected.(*labelSampleExtractor).structuredMetadataFastPath = false
+
+			for _, line := range lines {
+				v, l, ok := singleSample(ex.ForStream(lbs).ProcessString(0, line, structuredMetadata...))
+				expectedV, expectedL, expectedOk := singleSample(expected.ForStream(lbs).ProcessString(0, line, structuredMetadata...))
+				require.Equal(t, expectedOk, ok, line)
+				require.Equal(t, expectedV, v, line)
+				if ok {
+					require.Equal(t, expectedL.Labels(), l.Labels(), line)
+				}
+			}
+		})
+	}
+}
+
+func Benchmark_LabelExtractor_StructuredMetadata(b *testing.B) {
+	line := []byte(`{"level":"info","msg":"request served","path":"/api/v1/push","status":200,"latency":"130ms","bytes":1234}`)
+	lbs := labels.FromStrings("app", "gateway", "namespace", "prod")
+	structuredMetadata := labels.FromStrings("latency", "125ms", "pod", "gateway-0", "trace_id", "a8d7f6e5c4b3a291")
+
+	for _, fastPath := range []bool{true, false} {
+		name := "parser chain"
+		if fastPath {
+			name = "structured metadata"
+		}
+		b.Run(name, func(b *testing.B) {
+			ex, err := LabelExtractorWithStages("latency", ConvertDuration, []string{"pod"}, false, false, []Stage{NewJSONParser()}, NoopStage)
+			require.NoError(b, err)
+			ex.(*labelSampleExtractor).structuredMetadataFastPath = fastPath
+			stream := ex.ForStream(lbs)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				v, _, ok := singleSample(stream.Process(0, line, structuredMetadata...))
+				if !ok || v != 0.125 {
+					b.Fatalf("unexpected sample %v %v", v, ok)
+				}
+			}
+		})
+	}
+}
+
 func Test_Extract_ExpectedLabels(t *testing.T) {
 	ex := mustSampleExtractor(LabelExtractorWithStages("duration", ConvertDuration, []string{"foo"}, false, false, []Stage{NewJSONParser()}, NoopStage))
 
-	f, lbs, ok := ex.ForStream(labels.FromStrings("bar", "foo")).ProcessString(0, `{"duration":"20ms","foo":"json"}`)
+	f, lbs, ok := singleSample(ex.ForStream(labels.FromStrings("bar", "foo")).ProcessString(0, `{"duration":"20ms","foo":"json"}`))
 	require.True(t, ok)
 	require.Equal(t, (20 * time.Millisecond).Seconds(), f)
 	require.Equal(t, labels.FromStrings("foo", "json"), lbs.Labels())
@@ -302,7 +457,7 @@ func TestLabelExtractorWithStages(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			for _, line := range tc.checkLines {
-				v, lbs, ok := tc.extractor.ForStream(labels.FromStrings("bar", "foo")).ProcessString(0, line.logLine)
+				v, lbs, ok := singleSample(tc.extractor.ForStream(labels.FromStrings("bar", "foo")).ProcessString(0, line.logLine))
 				skipped := !ok
 				assert.Equal(t, line.skip, skipped, "line", line.logLine)
 				if !skipped {
@@ -327,6 +482,94 @@ func mustSampleExtractor(ex SampleExtractor, err error) SampleExtractor {
 	return ex
 }
 
+// singleSample returns the only sample extracted from a line.
+func singleSample(samples []ExtractedSample, ok bool) (float64, LabelsResult, bool) {
+	if !ok {
+		return 0, nil, false
+	}
+	if len(samples) != 1 {
+		panic(fmt.Sprintf("expected a single sample, got %d", len(samples)))
+	}
+	return samples[0].Value, samples[0].Labels, true
+}
+
+func TestMultiLabelExtractorWithStages(t *testing.T) {
+	type sample struct {
+		value float64
+		lbs   labels.Labels
+	}
+	for _, tc := range []struct {
+		name      string
+		extractor SampleExtractor
+		line      string
+		want      []sample
+	}{
+		{
+			name: "by",
+			extractor: mustSampleExtractor(MultiLabelExtractorWithStages(
+				[]string{"bytes_in", "bytes_out"}, []string{ConvertBytes, ConvertFloat}, []string{UnwrappedLabel, "app"}, false, false, []Stage{NewLogfmtParser(false, false)}, NoopStage,
+			)),
+			line: "bytes_in=1KB bytes_out=20 app=foo",
+			want: []sample{
+				{1000, labels.FromStrings("app", "foo", UnwrappedLabel, "bytes_in")},
+				{20, labels.FromStrings("app", "foo", UnwrappedLabel, "bytes_out")},
+			},
+		},
+		{
+			name: "without",
+			extractor: mustSampleExtractor(MultiLabelExtractorWithStages(
+				[]string{"bytes_in", "bytes_out"}, []string{ConvertFloat, ConvertFloat}, nil, false, false, []Stage{NewLogfmtParser(false, false)}, NoopStage,
+			)),
+			line: "bytes_in=1 bytes_out=2 app=foo",
+			want: []sample{
+				{1, labels.FromStrings("app", "foo", "bar", "foo", UnwrappedLabel, "bytes_in")},
+				{2, labels.FromStrings("app", "foo", "bar", "foo", UnwrappedLabel, "bytes_out")},
+			},
+		},
+		{
+			name: "missing label",
+			extractor: mustSampleExtractor(MultiLabelExtractorWithStages(
+				[]string{"bytes_in", "bytes_out"}, []string{ConvertFloat, ConvertFloat}, []string{UnwrappedLabel}, false, false, []Stage{NewLogfmtParser(false, false)}, NoopStage,
+			)),
+			line: "bytes_out=2",
+			want: []sample{
+				{2, labels.FromStrings(UnwrappedLabel, "bytes_out")},
+			},
+		},
+		{
+			name: "conversion error only applies to its sample",
+			extractor: mustSampleExtractor(MultiLabelExtractorWithStages(
+				[]string{"bytes_in", "bytes_out"}, []string{ConvertFloat, ConvertFloat}, []string{UnwrappedLabel}, false, false, []Stage{NewLogfmtParser(false, false)},
+				NewStringLabelFilter(labels.MustNewMatcher(labels.MatchEqual, logqlmodel.ErrorLabel, "")),
+			)),
+			line: "bytes_in=nope bytes_out=2",
+			want: []sample{
+				{2, labels.FromStrings(UnwrappedLabel, "bytes_out")},
+			},
+		},
+		{
+			name: "no labels",
+			extractor: mustSampleExtractor(MultiLabelExtractorWithStages(
+				[]string{"bytes_in", "bytes_out"}, []string{ConvertFloat, ConvertFloat}, []string{UnwrappedLabel}, false, false, []Stage{NewLogfmtParser(false, false)}, NoopStage,
+			)),
+			line: "app=foo",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			samples, ok := tc.extractor.ForStream(labels.FromStrings("bar", "foo")).ProcessString(0, tc.line)
+			require.Equal(t, len(tc.want) > 0, ok)
+			require.Len(t, samples, len(tc.want))
+			for i, s := range samples {
+				require.Equal(t, tc.want[i].value, s.Value)
+				require.Equal(t, tc.want[i].lbs, s.Labels.Labels())
+			}
+		})
+	}
+
+	_, err := MultiLabelExtractorWithStages([]string{"bytes_in"}, []string{ConvertFloat, ConvertFloat}, nil, false, false, nil, NoopStage)
+	require.Error(t, err)
+}
+
 func TestNewLineSampleExtractor(t *testing.T) {
 	se, err := NewLineSampleExtractor(CountExtractor, nil, nil, false, false)
 	require.NoError(t, err)
@@ -336,12 +579,12 @@ func TestNewLineSampleExtractor(t *testing.T) {
 	)
 
 	sse := se.ForStream(lbs)
-	f, l, ok := sse.Process(0, []byte(`foo`))
+	f, l, ok := singleSample(sse.Process(0, []byte(`foo`)))
 	require.True(t, ok)
 	require.Equal(t, 1., f)
 	assertLabelResult(t, lbs, l)
 
-	f, l, ok = sse.ProcessString(0, `foo`)
+	f, l, ok = singleSample(sse.ProcessString(0, `foo`))
 	require.True(t, ok)
 	require.Equal(t, 1., f)
 	assertLabelResult(t, lbs, l)
@@ -351,13 +594,13 @@ func TestNewLineSampleExtractor(t *testing.T) {
 	require.NoError(t, err)
 
 	sse = se.ForStream(lbs)
-	f, l, ok = sse.Process(0, []byte(`foo`))
+	f, l, ok = singleSample(sse.Process(0, []byte(`foo`)))
 	require.True(t, ok)
 	require.Equal(t, 3., f)
 	assertLabelResult(t, labels.FromStrings("namespace", "dev"), l)
 
 	sse = se.ForStream(lbs)
-	_, _, ok = sse.Process(0, []byte(`nope`))
+	_, _, ok = singleSample(sse.Process(0, []byte(`nope`)))
 	require.False(t, ok)
 }
 
@@ -372,12 +615,12 @@ func TestNewLineSampleExtractorWithStructuredMetadata(t *testing.T) {
 	require.NoError(t, err)
 
 	sse := se.ForStream(lbs)
-	f, l, ok := sse.Process(0, []byte(`foo`), structuredMetadata...)
+	f, l, ok := singleSample(sse.Process(0, []byte(`foo`), structuredMetadata...))
 	require.True(t, ok)
 	require.Equal(t, 1., f)
 	assertLabelResult(t, expectedLabelsResults, l)
 
-	f, l, ok = sse.ProcessString(0, `foo`, structuredMetadata...)
+	f, l, ok = singleSample(sse.ProcessString(0, `foo`, structuredMetadata...))
 	require.True(t, ok)
 	require.Equal(t, 1., f)
 	assertLabelResult(t, expectedLabelsResults, l)
@@ -387,16 +630,16 @@ func TestNewLineSampleExtractorWithStructuredMetadata(t *testing.T) {
 		Name: "foo_extracted", Value: "baz",
 	})
 	expectedLabelsResults = append(expectedLabelsResults, structuredMetadata...)
-	f, l, ok = sse.Process(0, []byte(`foo`), append(structuredMetadata, labels.Label{
+	f, l, ok = singleSample(sse.Process(0, []byte(`foo`), append(structuredMetadata, labels.Label{
 		Name: "foo", Value: "baz",
-	})...)
+	})...))
 	require.True(t, ok)
 	require.Equal(t, 1., f)
 	assertLabelResult(t, expectedLabelsResults, l)
 
-	f, l, ok = sse.ProcessString(0, `foo`, append(structuredMetadata, labels.Label{
+	f, l, ok = singleSample(sse.ProcessString(0, `foo`, append(structuredMetadata, labels.Label{
 		Name: "foo", Value: "baz",
-	})...)
+	})...))
 	require.True(t, ok)
 	require.Equal(t, 1., f)
 	assertLabelResult(t, expectedLabelsResults, l)
@@ -409,13 +652,13 @@ func TestNewLineSampleExtractorWithStructuredMetadata(t *testing.T) {
 	require.NoError(t, err)
 
 	sse = se.ForStream(lbs)
-	f, l, ok = sse.Process(0, []byte(`foo`), structuredMetadata...)
+	f, l, ok = singleSample(sse.Process(0, []byte(`foo`), structuredMetadata...))
 	require.True(t, ok)
 	require.Equal(t, 3., f)
 	assertLabelResult(t, labels.FromStrings("foo", "bar"), l)
 
 	sse = se.ForStream(lbs)
-	_, _, ok = sse.Process(0, []byte(`nope`))
+	_, _, ok = singleSample(sse.Process(0, []byte(`nope`)))
 	require.False(t, ok)
 }
 
@@ -447,10 +690,10 @@ func TestFilteringSampleExtractor(t *testing.T) {
 
 	for _, test := range tt {
 		t.Run(test.name, func(t *testing.T) {
-			_, _, ok := se.ForStream(test.labels).Process(test.ts, []byte(test.line), test.structuredMetadata...)
+			_, _, ok := singleSample(se.ForStream(test.labels).Process(test.ts, []byte(test.line), test.structuredMetadata...))
 			require.Equal(t, test.ok, ok)
 
-			_, _, ok = se.ForStream(test.labels).ProcessString(test.ts, test.line, test.structuredMetadata...)
+			_, _, ok = singleSample(se.ForStream(test.labels).ProcessString(test.ts, test.line, test.structuredMetadata...))
 			require.Equal(t, test.ok, ok)
 		})
 	}
@@ -478,12 +721,12 @@ func (p *stubStreamExtractor) BaseLabels() LabelsResult {
 	return nil
 }
 
-func (p *stubStreamExtractor) Process(_ int64, _ []byte, _ ...labels.Label) (float64, LabelsResult, bool) {
-	return 0, nil, true
+func (p *stubStreamExtractor) Process(_ int64, _ []byte, _ ...labels.Label) ([]ExtractedSample, bool) {
+	return []ExtractedSample{{}}, true
 }
 
-func (p *stubStreamExtractor) ProcessString(_ int64, _ string, _ ...labels.Label) (float64, LabelsResult, bool) {
-	return 0, nil, true
+func (p *stubStreamExtractor) ProcessString(_ int64, _ string, _ ...labels.Label) ([]ExtractedSample, bool) {
+	return []ExtractedSample{{}}, true
 }
 
 func (p *stubStreamExtractor) ReferencedStructuredMetadata() bool {
@@ -2,8 +2,12 @@ package log
 
 import (
 	"bytes"
+	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
+	"strconv"
+	"strings"
 	"unicode/utf8"
 
 	"github.com/grafana/jsonparser"
@@ -11,6 +15,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/logql/log/jsonexpr"
 	"github.com/grafana/loki/v3/pkg/logql/log/logfmt"
 	"github.com/grafana/loki/v3/pkg/logql/log/pattern"
+	"github.com/grafana/loki/v3/pkg/logql/log/sd"
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
 
 	"github.com/grafana/regexp"
@@ -32,6 +37,9 @@ var (
 	_ Stage = &JSONParser{}
 	_ Stage = &RegexpParser{}
 	_ Stage = &LogfmtParser{}
+	_ Stage = &CSVParser{}
+	_ Stage = &SDParser{}
+	_ Stage = &XMLParser{}
 
 	trueBytes = []byte("true")
 
@@ -39,6 +47,8 @@ var (
 	errMissingCapture       = errors.New("at least one named capture must be supplied")
 	errFoundAllLabels       = errors.New("found all required labels")
 	errLabelDoesNotMatch    = errors.New("found a label with a matcher that didn't match")
+	errUnterminatedQuote    = errors.New("unterminated quoted field")
+	errNoXMLElement         = errors.New("no xml element found")
 )
 
 type JSONParser struct {
@@ -420,6 +430,507 @@ func (l *PatternParser) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byt
 
 func (l *PatternParser) RequiredLabelNames() []string { return []string{} }
 
+// csvColumnPrefix is the prefix of the label names of the fields of a CSV
+// parser without columns, followed by the position of the field.
+const csvColumnPrefix = "col_"
+
+// CSVParser extracts the fields of lines separated by a delimiter, such as CSV
+// or TSV lines. A field can be quoted to contain the delimiter, a quote within a
+// quoted field is escaped by doubling it.
+type CSVParser struct {
+	columns   []string
+	positions []string
+	delimiter byte
+	quote     byte
+	buf       []byte
+}
+
+// NewCSVParser creates a parser extracting the fields of a line as the labels
+// of the given columns, in order. Fields without a column name are skipped, as
+// well as the fields after the last column. When no columns are given all the
+// fields are extracted and named after their position: col_1, col_2 and so on.
+// Fields are not quoted when quote is 0.
+func NewCSVParser(columns []string, delimiter, quote byte) (*CSVParser, error) {
+	if delimiter == quote {
+		return nil, fmt.Errorf("the delimiter and the quote must be different characters")
+	}
+	if delimiter == 0 || delimiter >= utf8.RuneSelf || quote >= utf8.RuneSelf {
+		return nil, fmt.Errorf("the delimiter and the quote must be single ASCII characters")
+	}
+	seen := make(map[string]struct{}, len(columns))
+	for _, name := range columns {
+		if name == "" {
+			continue
+		}
+		if !model.LabelName(name).IsValid() {
+			return nil, fmt.Errorf("invalid column label name '%s'", name)
+		}
+		if _, ok := seen[name]; ok {
+			return nil, fmt.Errorf("duplicate column label name '%s'", name)
+		}
+		seen[name] = struct{}{}
+	}
+	return &CSVParser{
+		columns:   columns,
+		delimiter: delimiter,
+		quote:     quote,
+	}, nil
+}
+
+func (c *CSVParser) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	parserHints := lbs.ParserLabelHints()
+	if parserHints.NoLabels() {
+		return line, true
+	}
+
+	rest, more := line, true
+	for i := 0; more && (len(c.columns) == 0 || i < len(c.columns)); i++ {
+		var (
+			value []byte
+			err   error
+		)
+		value, rest, more, err = c.next(rest)
+		if err != nil {
+			addErrLabel(errCSV, err, lbs)
+			return line, parserHints.ShouldContinueParsingLine(logqlmodel.ErrorLabel, lbs)
+		}
+
+		name := c.name(i)
+		if name == "" || len(value) == 0 {
+			continue
+		}
+		if lbs.BaseHas(name) {
+			name = name + duplicateSuffix
+		}
+		if !parserHints.ShouldExtract(name) {
+			continue
+		}
+
+		lbs.Set(ParsedLabel, name, string(value))
+		if !parserHints.ShouldContinueParsingLine(name, lbs) {
+			return line, false
+		}
+		// the remaining fields are not scanned once the required ones are extracted.
+		if parserHints.AllRequiredExtracted() {
+			break
+		}
+	}
+	return line, true
+}
+
+// next returns the value of the first field of the line, the rest of the line
+// after its delimiter and whether there is another field.
+func (c *CSVParser) next(line []byte) ([]byte, []byte, bool, error) {
+	if c.quote == 0 || len(line) == 0 || line[0] != c.quote {
+		i := bytes.IndexByte(line, c.delimiter)
+		if i < 0 {
+			return line, nil, false, nil
+		}
+		return line[:i], line[i+1:], true, nil
+	}
+
+	c.buf = c.buf[:0]
+	line = line[1:]
+	for {
+		i := bytes.IndexByte(line, c.quote)
+		if i < 0 {
+			return nil, nil, false, errUnterminatedQuote
+		}
+		c.buf = append(c.buf, line[:i]...)
+		line = line[i+1:]
+		if len(line) == 0 || line[0] != c.quote {
+			break
+		}
+		// a doubled quote is an escaped quote.
+		c.buf = append(c.buf, c.quote)
+		line = line[1:]
+	}
+	if len(line) == 0 {
+		return c.buf, nil, false, nil
+	}
+	if line[0] != c.delimiter {
+		return nil, nil, false, fmt.Errorf("unexpected character %q after quoted field", line[0])
+	}
+	return c.buf, line[1:], true, nil
+}
+
+// name returns the label name of the field at the given position.
+func (c *CSVParser) name(i int) string {
+	if len(c.columns) > 0 {
+		return c.columns[i]
+	}
+	for len(c.positions) <= i {
+		c.positions = append(c.positions, csvColumnPrefix+strconv.Itoa(len(c.positions)+1))
+	}
+	return c.positions[i]
+}
+
+func (c *CSVParser) RequiredLabelNames() []string { return []string{} }
+
+// SDParser extracts the parameters of the structured data of RFC5424 syslog
+// lines, e.g. `[exampleSDID@32473 iut="3"]`. Each parameter is extracted into
+// a label named after the element id and the parameter name, e.g.
+// exampleSDID_32473_iut, unless expressions are given in which case only the
+// parameters they match are extracted into the label of the expression.
+type SDParser struct {
+	strict      bool
+	keepEmpty   bool
+	expressions []LabelExtractionExpr
+	dec         *sd.Decoder
+	keys        internedStringSet
+	buf         []byte
+}
+
+// NewSDParser creates a parser extracting every parameter of the structured
+// data of a line.
+func NewSDParser(strict, keepEmpty bool) *SDParser {
+	return &SDParser{
+		strict:    strict,
+		keepEmpty: keepEmpty,
+		dec:       sd.NewDecoder(nil),
+		keys:      internedStringSet{},
+	}
+}
+
+// NewSDExpressionParser creates a parser extracting the parameters of the
+// structured data matched by the expressions. An expression matches the
+// parameters with a given name, e.g. `iut`, or a given element id and name
+// separated by a dot, e.g. `exampleSDID@32473.iut`.
+func NewSDExpressionParser(expressions []LabelExtractionExpr, strict, keepEmpty bool) (*SDParser, error) {
+	if len(expressions) == 0 {
+		return nil, fmt.Errorf("no sd expression provided")
+	}
+	for _, exp := range expressions {
+		if !model.LabelName(exp.Identifier).IsValid() {
+			return nil, fmt.Errorf("invalid extracted label name '%s'", exp.Identifier)
+		}
+	}
+	p := NewSDParser(strict, keepEmpty)
+	p.expressions = expressions
+	return p, nil
+}
+
+func (s *SDParser) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	parserHints := lbs.ParserLabelHints()
+	if parserHints.NoLabels() {
+		return line, true
+	}
+
+	s.dec.Reset(line)
+	for !s.dec.EOL() {
+		ok := s.dec.ScanParam()
+		if !ok {
+			// for strict parsing, do not continue on errs
+			if s.strict {
+				break
+			}
+
+			continue
+		}
+
+		val := s.dec.Value()
+		// the rune error replacement is rejected by Prometheus, so we skip it.
+		if bytes.ContainsRune(val, utf8.RuneError) {
+			val = nil
+		}
+		if !s.keepEmpty && len(val) == 0 {
+			continue
+		}
+
+		var keys []string
+		if s.expressions != nil {
+			keys = s.matches(s.dec.ID(), s.dec.Name(), lbs)
+		} else if key, ok := s.key(s.dec.ID(), s.dec.Name(), lbs); ok {
+			keys = []string{key}
+		}
+
+		for _, key := range keys {
+			lbs.Set(ParsedLabel, key, string(val))
+			if !parserHints.ShouldContinueParsingLine(key, lbs) {
+				return line, false
+			}
+		}
+
+		if len(keys) > 0 && parserHints.AllRequiredExtracted() {
+			break
+		}
+	}
+
+	if s.strict && s.dec.Err() != nil {
+		addErrLabel(errSD, s.dec.Err(), lbs)
+
+		if !parserHints.ShouldContinueParsingLine(logqlmodel.ErrorLabel, lbs) {
+			return line, false
+		}
+		return line, true
+	}
+
+	return line, true
+}
+
+// key returns the label of a parameter of the structured data.
+func (s *SDParser) key(id, name []byte, lbs *LabelsBuilder) (string, bool) {
+	s.buf = append(append(append(s.buf[:0], id...), jsonSpacer), name...)
+	return s.keys.Get(s.buf, func() (string, bool) {
+		sanitized := sanitizeLabelKey(string(s.buf), true)
+		if len(sanitized) == 0 {
+			return "", false
+		}
+
+		if lbs.BaseHas(sanitized) {
+			sanitized = fmt.Sprintf("%s%s", sanitized, duplicateSuffix)
+		}
+
+		if !lbs.ParserLabelHints().ShouldExtract(sanitized) {
+			return "", false
+		}
+		return sanitized, true
+	})
+}
+
+// matches returns the labels of the expressions matching a parameter of the
+// structured data.
+func (s *SDParser) matches(id, name []byte, lbs *LabelsBuilder) []string {
+	var keys []string
+	for _, exp := range s.expressions {
+		e := exp.Expression
+		switch {
+		case e == string(name):
+		case len(e) == len(id)+1+len(name) && e[len(id)] == '.' && strings.HasPrefix(e, string(id)) && strings.HasSuffix(e, string(name)):
+		default:
+			continue
+		}
+		key := exp.Identifier
+		if lbs.BaseHas(key) {
+			key = key + duplicateSuffix
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (s *SDParser) RequiredLabelNames() []string { return []string{} }
+
+// XMLParser extracts the text of the elements without child elements and the
+// attributes of XML lines. The label of an element is the path of elements to
+// it from the root element separated by '_', e.g. `event_status` for
+// `<event><status>500</status></event>`, and the label of an attribute is the
+// label of its element followed by the attribute name. If expressions are
+// given, only the elements and attributes matched by an expression are
+// extracted into the label of the expression.
+type XMLParser struct {
+	strict      bool
+	keepEmpty   bool
+	expressions []LabelExtractionExpr
+	keys        internedStringSet
+
+	prefix   []byte // label of the current element
+	path     []byte // path of the current element as written in expressions
+	elements []xmlElement
+	text     []byte
+}
+
+type xmlElement struct {
+	prefix   int
+	path     int
+	children bool
+}
+
+// NewXMLParser creates a parser extracting every element and attribute of an
+// XML line.
+func NewXMLParser(strict, keepEmpty bool) *XMLParser {
+	return &XMLParser{
+		strict:    strict,
+		keepEmpty: keepEmpty,
+		keys:      internedStringSet{},
+	}
+}
+
+// NewXMLExpressionParser creates a parser extracting the elements and
+// attributes matched by the expressions. An expression is the path of the
+// elements from the root element separated by dots, e.g. `event.status`,
+// followed by `.@` and the attribute name for an attribute, e.g. `event.@id`.
+func NewXMLExpressionParser(expressions []LabelExtractionExpr, strict, keepEmpty bool) (*XMLParser, error) {
+	if len(expressions) == 0 {
+		return nil, fmt.Errorf("no xml expression provided")
+	}
+	for _, exp := range expressions {
+		if !model.LabelName(exp.Identifier).IsValid() {
+			return nil, fmt.Errorf("invalid extracted label name '%s'", exp.Identifier)
+		}
+	}
+	p := NewXMLParser(strict, keepEmpty)
+	p.expressions = expressions
+	return p, nil
+}
+
+func (x *XMLParser) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	parserHints := lbs.ParserLabelHints()
+	if parserHints.NoLabels() {
+		return line, true
+	}
+
+	// reset the state.
+	x.prefix, x.path, x.elements, x.text = x.prefix[:0], x.path[:0], x.elements[:0], x.text[:0]
+
+	dec := xml.NewDecoder(bytes.NewReader(line))
+	if !x.strict {
+		dec.Strict = false
+		dec.AutoClose = xml.HTMLAutoClose
+		dec.Entity = xml.HTMLEntity
+	}
+
+	var (
+		found bool
+		err   error
+		tok   xml.Token
+	)
+	for {
+		tok, err = dec.Token()
+		if err != nil {
+			break
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			found = true
+			if n := len(x.elements); n > 0 {
+				x.elements[n-1].children = true
+			}
+			x.elements = append(x.elements, xmlElement{prefix: len(x.prefix), path: len(x.path)})
+			x.push(t.Name.Local, false)
+			x.text = x.text[:0]
+
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+					continue
+				}
+				ok, done := x.set(lbs, attr.Name.Local, []byte(attr.Value))
+				if !ok {
+					return line, false
+				}
+				if done {
+					return line, true
+				}
+			}
+		case xml.CharData:
+			x.text = append(x.text, t...)
+		case xml.EndElement:
+			n := len(x.elements)
+			if n == 0 {
+				continue
+			}
+			e := x.elements[n-1]
+			x.elements = x.elements[:n-1]
+			if !e.children {
+				ok, done := x.set(lbs, "", bytes.TrimSpace(x.text))
+				if !ok {
+					return line, false
+				}
+				if done {
+					return line, true
+				}
+			}
+			x.prefix, x.path = x.prefix[:e.prefix], x.path[:e.path]
+			x.text = x.text[:0]
+		}
+	}
+
+	if errors.Is(err, io.EOF) {
+		err = nil
+		if !found {
+			err = errNoXMLElement
+		}
+	}
+	if x.strict && err != nil {
+		addErrLabel(errXML, err, lbs)
+
+		if !parserHints.ShouldContinueParsingLine(logqlmodel.ErrorLabel, lbs) {
+			return line, false
+		}
+	}
+
+	return line, true
+}
+
+// push appends an element or attribute name to the label and path of the
+// current element.
+func (x *XMLParser) push(name string, attr bool) {
+	if len(x.prefix) > 0 {
+		x.prefix = append(x.prefix, jsonSpacer)
+	}
+	x.prefix = appendSanitized(x.prefix, []byte(name))
+	if len(x.path) > 0 {
+		x.path = append(x.path, '.')
+	}
+	if attr {
+		x.path = append(x.path, '@')
+	}
+	x.path = append(x.path, name...)
+}
+
+// set extracts the value of the current element, or of one of its attributes
+// when attr is not empty. It returns false when the line must be filtered out,
+// and done when all required labels have been extracted.
+func (x *XMLParser) set(lbs *LabelsBuilder, attr string, val []byte) (ok, done bool) {
+	if bytes.ContainsRune(val, utf8.RuneError) {
+		val = nil
+	}
+	if !x.keepEmpty && len(val) == 0 {
+		return true, false
+	}
+
+	prefix, path := len(x.prefix), len(x.path)
+	if attr != "" {
+		x.push(attr, true)
+		defer func() { x.prefix, x.path = x.prefix[:prefix], x.path[:path] }()
+	}
+
+	parserHints := lbs.ParserLabelHints()
+	var keys []string
+	if x.expressions != nil {
+		for _, exp := range x.expressions {
+			if exp.Expression != string(x.path) {
+				continue
+			}
+			key := exp.Identifier
+			if lbs.BaseHas(key) {
+				key = key + duplicateSuffix
+			}
+			keys = append(keys, key)
+		}
+	} else {
+		key, ok := x.keys.Get(x.prefix, func() (string, bool) {
+			sanitized := sanitizeLabelKey(string(x.prefix), true)
+			if len(sanitized) == 0 {
+				return "", false
+			}
+
+			if lbs.BaseHas(sanitized) {
+				sanitized = fmt.Sprintf("%s%s", sanitized, duplicateSuffix)
+			}
+
+			if !parserHints.ShouldExtract(sanitized) {
+				return "", false
+			}
+			return sanitized, true
+		})
+		if ok {
+			keys = []string{key}
+		}
+	}
+
+	for _, key := range keys {
+		lbs.Set(ParsedLabel, key, string(val))
+		if !parserHints.ShouldContinueParsingLine(key, lbs) {
+			return false, false
+		}
+	}
+	return true, len(keys) > 0 && parserHints.AllRequiredExtracted()
+}
+
+func (x *XMLParser) RequiredLabelNames() []string { return []string{} }
+
 type LogfmtExpressionParser struct {
 	expressions map[string][]interface{}
 	dec         *logfmt.Decoder
@@ -134,6 +134,21 @@ func (p *Hints) ShouldContinueParsingLine(labelName string, lbs *LabelsBuilder)
 
 // NewParserHint creates a new parser hint using the list of labels that are seen and required in a query.
 func NewParserHint(requiredLabelNames, groups []string, without, noLabels bool, metricLabelName string, stages []Stage) *Hints {
+	for _, stage := range stages {
+		switch s := stage.(type) {
+		case *Search:
+			// a full-text search over the metadata needs all the parsed labels.
+			if s.SearchesMetadata() {
+				return &Hints{}
+			}
+		case *JSONFormatter:
+			// so does a line formatted from all the labels.
+			if s.FormatsAllLabels() {
+				return &Hints{}
+			}
+		}
+	}
+
 	hints := make([]string, 0, 2*(len(requiredLabelNames)+len(groups)+1))
 	hints = appendLabelHints(hints, requiredLabelNames...)
 	hints = appendLabelHints(hints, groups...)
@@ -43,6 +43,12 @@ var (
 	}`)
 
 	logfmtLine = []byte(`ts=2021-02-02T14:35:05.983992774Z caller=spanlogger.go:79 org_id=3677 traceID=2e5c7234b8640997 Ingester.TotalReached=15 Ingester.TotalChunksMatched=0 Ingester.TotalBatches=0`)
+
+	// the last field is malformed to check that fields after the required ones are not parsed.
+	csvLine = []byte(`10.0.0.1,POST,204,"unterminated`)
+
+	sdLine  = []byte(`<165>1 2003-10-11T22:14:15.003Z host app - ID47 [exampleSDID@32473 iut="3" eventSource="Application"][meta sequenceId="1"] An application event`)
+	xmlLine = []byte(`<event id="42"><status>500</status><user><name>alice</name></user></event>`)
 )
 
 func Test_ParserHints(t *testing.T) {
@@ -91,6 +97,20 @@ func Test_ParserHints(t *testing.T) {
 			1.0,
 			`{}`,
 		},
+		{
+			`sum(rate({app="nginx"} | json | line_format "{{.remote_user}}" |* --metadata "grafana.net" [1m]))`,
+			jsonLine,
+			true,
+			1.0,
+			`{}`,
+		},
+		{
+			`sum by (remote_user) (rate({app="nginx"} | json | json_format |= "grafana.net" [1m]))`,
+			jsonLine,
+			true,
+			1.0,
+			`{remote_user="foo"}`,
+		},
 		{
 			`sum(rate({app="nginx"} | json | unwrap response_latency_seconds [1m]))`,
 			jsonLine,
@@ -217,6 +237,55 @@ func Test_ParserHints(t *testing.T) {
 			0,
 			``,
 		},
+		{
+			`sum by (status) (count_over_time({app="nginx"} | csv "ip,method,status,msg" [1m]))`,
+			csvLine,
+			true,
+			1.0,
+			`{status="204"}`,
+		},
+		{
+			`sum by (method) (count_over_time({app="nginx"} | csv "ip,method,status,msg" | status = 204 [1m]))`,
+			csvLine,
+			true,
+			1.0,
+			`{method="POST"}`,
+		},
+		{
+			`sum(count_over_time({app="nginx"} | csv "ip,method,status,msg" | __error__ != "" [1m]))`,
+			csvLine,
+			true,
+			1.0,
+			`{__error__="CSVParserErr", __error_details__="unterminated quoted field", __preserve_error__="true", app="nginx", cluster="us-central-west"}`,
+		},
+		{
+			`sum by (meta_sequenceId) (count_over_time({app="nginx"} | sd | exampleSDID_32473_iut = 3 [1m]))`,
+			sdLine,
+			true,
+			1.0,
+			`{meta_sequenceId="1"}`,
+		},
+		{
+			`sum by (source) (count_over_time({app="nginx"} | sd source="eventSource" [1m]))`,
+			sdLine,
+			true,
+			1.0,
+			`{source="Application"}`,
+		},
+		{
+			`sum by (event_user_name) (count_over_time({app="nginx"} | xml | event_status >= 500 [1m]))`,
+			xmlLine,
+			true,
+			1.0,
+			`{event_user_name="alice"}`,
+		},
+		{
+			`sum by (event_id) (count_over_time({app="nginx"} | xml | event_status < 500 [1m]))`,
+			xmlLine,
+			false,
+			0,
+			``,
+		},
 		{
 			`sum by (message_message,app)(count_over_time({app="nginx"} | json | response_status = 204 and  remote_user = "foo"[1m]))`,
 			jsonLine,
@@ -233,10 +302,12 @@ func Test_ParserHints(t *testing.T) {
 
 			ex, err := expr.Extractor()
 			require.NoError(t, err)
-			v, lbsRes, ok := ex.ForStream(lbs).Process(0, append([]byte{}, tt.line...))
+			samples, ok := ex.ForStream(lbs).Process(0, append([]byte{}, tt.line...))
+			var v float64
 			var lbsResString string
-			if lbsRes != nil {
-				lbsResString = lbsRes.String()
+			if ok {
+				require.Len(t, samples, 1)
+				v, lbsResString = samples[0].Value, samples[0].Labels.String()
 			}
 			require.Equal(t, tt.expectOk, ok)
 			require.Equal(t, tt.expectVal, v)
@@ -1371,6 +1371,334 @@ func Test_PatternParser(t *testing.T) {
 	}
 }
 
+func Test_CSVParser(t *testing.T) {
+	tests := []struct {
+		name      string
+		columns   []string
+		delimiter byte
+		quote     byte
+		line      []byte
+		lbs       labels.Labels
+		want      labels.Labels
+	}{
+		{
+			"columns",
+			[]string{"ip", "method", "path", "status"},
+			',', '"',
+			[]byte(`127.0.0.1,GET,/api/v1/push,204`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar",
+				"ip", "127.0.0.1",
+				"method", "GET",
+				"path", "/api/v1/push",
+				"status", "204",
+			),
+		},
+		{
+			"quoted fields",
+			[]string{"msg", "user", "status"},
+			',', '"',
+			[]byte(`"hello, ""world""",,"500"`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar",
+				"msg", `hello, "world"`,
+				"status", "500",
+			),
+		},
+		{
+			"skipped and missing columns",
+			[]string{"ip", "", "path", "status"},
+			',', '"',
+			[]byte(`127.0.0.1,GET,/api/v1/push`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar",
+				"ip", "127.0.0.1",
+				"path", "/api/v1/push",
+			),
+		},
+		{
+			"extra fields",
+			[]string{"ip", "method"},
+			',', '"',
+			[]byte(`127.0.0.1,GET,"/api/v1/push`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar",
+				"ip", "127.0.0.1",
+				"method", "GET",
+			),
+		},
+		{
+			"positional tsv without quotes",
+			nil,
+			'\t', 0,
+			[]byte("bar\t\"GET\"\t204"),
+			labels.FromStrings("col_1", "foo"),
+			labels.FromStrings("col_1", "foo",
+				"col_1_extracted", "bar",
+				"col_2", `"GET"`,
+				"col_3", "204",
+			),
+		},
+		{
+			"unterminated quote",
+			[]string{"ip", "msg"},
+			',', '"',
+			[]byte(`127.0.0.1,"hello`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar",
+				"ip", "127.0.0.1",
+				logqlmodel.ErrorLabel, errCSV,
+				logqlmodel.ErrorDetailsLabel, errUnterminatedQuote.Error(),
+			),
+		},
+		{
+			"unexpected character after quote",
+			[]string{"msg", "ip"},
+			',', '"',
+			[]byte(`"hello"world,127.0.0.1`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar",
+				logqlmodel.ErrorLabel, errCSV,
+				logqlmodel.ErrorDetailsLabel, `unexpected character 'w' after quoted field`,
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b := NewBaseLabelsBuilder().ForLabels(tt.lbs, tt.lbs.Hash())
+			b.Reset()
+			pp, err := NewCSVParser(tt.columns, tt.delimiter, tt.quote)
+			require.NoError(t, err)
+			_, _ = pp.Process(0, tt.line, b)
+			require.Equal(t, tt.want, b.LabelsResult().Labels())
+		})
+	}
+}
+
+func TestNewCSVParser(t *testing.T) {
+	for _, tc := range []struct {
+		columns          []string
+		delimiter, quote byte
+		err              string
+	}{
+		{[]string{"a", "b"}, ',', '"', ""},
+		{[]string{"a", "", "b"}, '\t', 0, ""},
+		{[]string{"a", "a"}, ',', '"', "duplicate column label name 'a'"},
+		{[]string{"a", "b-c"}, ',', '"', "invalid column label name 'b-c'"},
+		{nil, ',', ',', "the delimiter and the quote must be different characters"},
+		{nil, 0xc3, '"', "the delimiter and the quote must be single ASCII characters"},
+	} {
+		_, err := NewCSVParser(tc.columns, tc.delimiter, tc.quote)
+		if tc.err == "" {
+			require.NoError(t, err)
+			continue
+		}
+		require.EqualError(t, err, tc.err)
+	}
+}
+
+func Test_SDParser(t *testing.T) {
+	line := []byte(`<165>1 2003-10-11T22:14:15.003Z host app - ID47 [exampleSDID@32473 iut="3" eventSource="Application" eventID=""][meta sequenceId="1"] An application event`)
+
+	tests := []struct {
+		name   string
+		parser func() (Stage, error)
+		line   []byte
+		lbs    labels.Labels
+		want   labels.Labels
+	}{
+		{
+			"all parameters",
+			func() (Stage, error) { return NewSDParser(false, false), nil },
+			line,
+			labels.FromStrings("meta_sequenceId", "0"),
+			labels.FromStrings("meta_sequenceId", "0",
+				"exampleSDID_32473_iut", "3",
+				"exampleSDID_32473_eventSource", "Application",
+				"meta_sequenceId_extracted", "1",
+			),
+		},
+		{
+			"keep empty",
+			func() (Stage, error) { return NewSDParser(false, true), nil },
+			[]byte(`[exampleSDID@32473 iut="3" eventID=""]`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar",
+				"exampleSDID_32473_iut", "3",
+				"exampleSDID_32473_eventID", "",
+			),
+		},
+		{
+			"invalid element is skipped",
+			func() (Stage, error) { return NewSDParser(false, false), nil },
+			[]byte(`[bad a=1][meta sequenceId="1"]`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar",
+				"meta_sequenceId", "1",
+			),
+		},
+		{
+			"strict",
+			func() (Stage, error) { return NewSDParser(true, false), nil },
+			[]byte(`[meta a="1"][bad a=1][meta sequenceId="1"]`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar",
+				"meta_a", "1",
+				logqlmodel.ErrorLabel, errSD,
+				logqlmodel.ErrorDetailsLabel, `structured data syntax error at pos 20 : unexpected '1'`,
+			),
+		},
+		{
+			"expressions",
+			func() (Stage, error) {
+				return NewSDExpressionParser([]LabelExtractionExpr{
+					NewLabelExtractionExpr("source", "eventSource"),
+					NewLabelExtractionExpr("iut", "exampleSDID@32473.iut"),
+					NewLabelExtractionExpr("seq", "other.sequenceId"),
+				}, false, false)
+			},
+			line,
+			labels.FromStrings("iut", "1"),
+			labels.FromStrings("iut", "1",
+				"iut_extracted", "3",
+				"source", "Application",
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b := NewBaseLabelsBuilder().ForLabels(tt.lbs, tt.lbs.Hash())
+			b.Reset()
+			pp, err := tt.parser()
+			require.NoError(t, err)
+			_, _ = pp.Process(0, tt.line, b)
+			require.Equal(t, tt.want, b.LabelsResult().Labels())
+		})
+	}
+}
+
+func Test_XMLParser(t *testing.T) {
+	line := []byte(`<?xml version="1.0"?><event id="42" xmlns="urn:example"><status>500</status><msg>
+  boom</msg><user><name>alice</name><email/></user></event>`)
+
+	tests := []struct {
+		name   string
+		parser func() (Stage, error)
+		line   []byte
+		lbs    labels.Labels
+		want   labels.Labels
+	}{
+		{
+			"elements and attributes",
+			func() (Stage, error) { return NewXMLParser(false, false), nil },
+			line,
+			labels.FromStrings("event_status", "200"),
+			labels.FromStrings("event_status", "200",
+				"event_id", "42",
+				"event_status_extracted", "500",
+				"event_msg", "boom",
+				"event_user_name", "alice",
+			),
+		},
+		{
+			"keep empty",
+			func() (Stage, error) { return NewXMLParser(false, true), nil },
+			line,
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar",
+				"event_id", "42",
+				"event_status", "500",
+				"event_msg", "boom",
+				"event_user_name", "alice",
+				"event_user_email", "",
+			),
+		},
+		{
+			"sanitized names",
+			func() (Stage, error) { return NewXMLParser(false, false), nil },
+			[]byte(`<log:entry xmlns:log="urn:log" log:level="warn"><http-status>404</http-status></log:entry>`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar",
+				"entry_level", "warn",
+				"entry_http_status", "404",
+			),
+		},
+		{
+			"malformed",
+			func() (Stage, error) { return NewXMLParser(false, false), nil },
+			[]byte(`<event><status>500</status><msg>boom</event>`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar",
+				"event_status", "500",
+				"event_msg", "boom",
+			),
+		},
+		{
+			"strict",
+			func() (Stage, error) { return NewXMLParser(true, false), nil },
+			[]byte(`<event><status>500</status><msg>boom</event>`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar",
+				"event_status", "500",
+				logqlmodel.ErrorLabel, errXML,
+				logqlmodel.ErrorDetailsLabel, "XML syntax error on line 1: element <msg> closed by </event>",
+			),
+		},
+		{
+			"strict without element",
+			func() (Stage, error) { return NewXMLParser(true, false), nil },
+			[]byte(`level=info msg=hello`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar",
+				logqlmodel.ErrorLabel, errXML,
+				logqlmodel.ErrorDetailsLabel, errNoXMLElement.Error(),
+			),
+		},
+		{
+			"expressions",
+			func() (Stage, error) {
+				return NewXMLExpressionParser([]LabelExtractionExpr{
+					NewLabelExtractionExpr("id", "event.@id"),
+					NewLabelExtractionExpr("user", "event.user.name"),
+					NewLabelExtractionExpr("missing", "event.name"),
+				}, false, false)
+			},
+			line,
+			labels.FromStrings("id", "1"),
+			labels.FromStrings("id", "1",
+				"id_extracted", "42",
+				"user", "alice",
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b := NewBaseLabelsBuilder().ForLabels(tt.lbs, tt.lbs.Hash())
+			b.Reset()
+			pp, err := tt.parser()
+			require.NoError(t, err)
+			_, _ = pp.Process(0, tt.line, b)
+			require.Equal(t, tt.want, b.LabelsResult().Labels())
+		})
+	}
+}
+
+func TestXMLAndSDExpressionParserFailures(t *testing.T) {
+	_, err := NewSDExpressionParser(nil, false, false)
+	require.EqualError(t, err, "no sd expression provided")
+	_, err = NewXMLExpressionParser([]LabelExtractionExpr{NewLabelExtractionExpr("a-b", "a")}, false, false)
+	require.EqualError(t, err, "invalid extracted label name 'a-b'")
+}
+
 func BenchmarkJsonExpressionParser(b *testing.B) {
 	simpleJsn := []byte(`{
       "data": "Click Here",
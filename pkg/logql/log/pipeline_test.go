@@ -289,7 +289,7 @@ var (
 	resLine       []byte
 	resLineString string
 	resLbs        LabelsResult
-	resSample     float64
+	resSamples    []ExtractedSample
 )
 
 func TestDropLabelsPipeline(t *testing.T) {
@@ -572,13 +572,13 @@ func Benchmark_Pipeline(b *testing.B) {
 	b.Run("line extractor bytes", func(b *testing.B) {
 		b.ResetTimer()
 		for n := 0; n < b.N; n++ {
-			resSample, resLbs, resMatches = ex.Process(0, line)
+			resSamples, resMatches = ex.Process(0, line)
 		}
 	})
 	b.Run("line extractor string", func(b *testing.B) {
 		b.ResetTimer()
 		for n := 0; n < b.N; n++ {
-			resSample, resLbs, resMatches = ex.ProcessString(0, lineString)
+			resSamples, resMatches = ex.ProcessString(0, lineString)
 		}
 	})
 
@@ -589,13 +589,13 @@ func Benchmark_Pipeline(b *testing.B) {
 	b.Run("label extractor bytes", func(b *testing.B) {
 		b.ResetTimer()
 		for n := 0; n < b.N; n++ {
-			resSample, resLbs, resMatches = ex.Process(0, line)
+			resSamples, resMatches = ex.Process(0, line)
 		}
 	})
 	b.Run("label extractor string", func(b *testing.B) {
 		b.ResetTimer()
 		for n := 0; n < b.N; n++ {
-			resSample, resLbs, resMatches = ex.ProcessString(0, lineString)
+			resSamples, resMatches = ex.ProcessString(0, lineString)
 		}
 	})
 }
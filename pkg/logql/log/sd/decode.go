@@ -0,0 +1,263 @@
+// Package sd decodes the structured data of RFC5424 syslog messages, e.g.
+// `[exampleSDID@32473 iut="3" eventSource="Application"][meta sequenceId="1"]`.
+package sd
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const (
+	untermElement = "unterminated element"
+	untermQuote   = "unterminated quoted value"
+	missingID     = "missing element id"
+	missingName   = "missing parameter name"
+)
+
+// A Decoder reads the parameters of the structured data of a line.
+//
+// The structured data starts at the first '[' of the line and ends with the
+// last of the consecutive elements, the rest of the line is ignored.
+type Decoder struct {
+	pos       int
+	line      []byte
+	inElement bool
+	id        []byte
+	name      []byte
+	value     []byte
+	buf       []byte
+	err       error
+}
+
+// NewDecoder returns a new decoder that reads the structured data of line.
+func NewDecoder(line []byte) *Decoder {
+	dec := &Decoder{}
+	dec.Reset(line)
+	return dec
+}
+
+func (dec *Decoder) Reset(line []byte) {
+	dec.pos = bytes.IndexByte(line, '[')
+	if dec.pos < 0 {
+		dec.pos = len(line)
+	}
+	dec.line = line
+	dec.inElement = false
+	dec.id, dec.name, dec.value = nil, nil, nil
+	dec.err = nil
+}
+
+func (dec *Decoder) EOL() bool {
+	return dec.pos >= len(dec.line)
+}
+
+// ScanParam advances the Decoder to the next parameter of the structured data,
+// which can then be retrieved with the ID, Name and Value methods. It returns
+// false when decoding stops, either by reaching the end of the structured data
+// or an error. On error, the decoder skips the rest of the element so decoding
+// can continue with the next one.
+func (dec *Decoder) ScanParam() bool {
+	dec.name, dec.value = nil, nil
+
+	line := dec.line
+	for dec.pos < len(line) {
+		if !dec.inElement {
+			if line[dec.pos] != '[' {
+				// the structured data is followed by the message.
+				dec.pos = len(line)
+				return false
+			}
+			dec.pos++
+			if !dec.scanID() {
+				dec.skipElement()
+				return false
+			}
+			dec.inElement = true
+		}
+
+		for dec.pos < len(line) && line[dec.pos] == ' ' {
+			dec.pos++
+		}
+		if dec.pos >= len(line) {
+			dec.syntaxError(untermElement)
+			return false
+		}
+		if line[dec.pos] == ']' {
+			dec.pos++
+			dec.inElement = false
+			continue
+		}
+		if !dec.scanParam() {
+			dec.skipElement()
+			return false
+		}
+		return true
+	}
+	if dec.inElement {
+		dec.syntaxError(untermElement)
+	}
+	return false
+}
+
+func (dec *Decoder) scanID() bool {
+	start := dec.pos
+	for dec.pos < len(dec.line) {
+		c := dec.line[dec.pos]
+		if c == ' ' || c == ']' {
+			break
+		}
+		if !isNameByte(c) {
+			dec.unexpectedByte(c)
+			return false
+		}
+		dec.pos++
+	}
+	if dec.pos == start {
+		dec.syntaxError(missingID)
+		return false
+	}
+	dec.id = dec.line[start:dec.pos]
+	return true
+}
+
+func (dec *Decoder) scanParam() bool {
+	line := dec.line
+
+	start := dec.pos
+	for dec.pos < len(line) && line[dec.pos] != '=' {
+		if c := line[dec.pos]; !isNameByte(c) {
+			dec.unexpectedByte(c)
+			return false
+		}
+		dec.pos++
+	}
+	if dec.pos == start {
+		dec.syntaxError(missingName)
+		return false
+	}
+	name := line[start:dec.pos]
+
+	// skip the '=' and the opening quote.
+	dec.pos++
+	if dec.pos >= len(line) {
+		dec.syntaxError(untermElement)
+		return false
+	}
+	if c := line[dec.pos]; c != '"' {
+		dec.unexpectedByte(c)
+		return false
+	}
+	dec.pos++
+
+	start = dec.pos
+	escaped := false
+	for dec.pos < len(line) {
+		switch line[dec.pos] {
+		case '\\':
+			escaped = true
+			dec.pos += 2
+			continue
+		case '"':
+			value := line[start:dec.pos]
+			if escaped {
+				value = dec.unescape(value)
+			}
+			dec.pos++
+			if dec.pos < len(line) && line[dec.pos] != ' ' && line[dec.pos] != ']' {
+				dec.unexpectedByte(line[dec.pos])
+				return false
+			}
+			dec.name, dec.value = name, value
+			return true
+		}
+		dec.pos++
+	}
+	dec.syntaxError(untermQuote)
+	return false
+}
+
+// unescape returns the value with the '"', '\' and ']' characters unescaped.
+// Other backslashes are kept as they are, as required by the RFC.
+func (dec *Decoder) unescape(value []byte) []byte {
+	dec.buf = dec.buf[:0]
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			switch value[i+1] {
+			case '"', '\\', ']':
+				i++
+			}
+		}
+		dec.buf = append(dec.buf, value[i])
+	}
+	return dec.buf
+}
+
+// skipElement moves the decoder after the end of the current element.
+func (dec *Decoder) skipElement() {
+	dec.inElement = false
+	for dec.pos < len(dec.line) {
+		c := dec.line[dec.pos]
+		dec.pos++
+		if c == '\\' {
+			dec.pos++
+			continue
+		}
+		if c == ']' {
+			return
+		}
+	}
+}
+
+// isNameByte reports whether c can be part of an SD-NAME, which are printable
+// US-ASCII characters except '=', ' ', ']' and '"'.
+func isNameByte(c byte) bool {
+	return c > ' ' && c < 127 && c != '=' && c != ']' && c != '"'
+}
+
+// ID returns the id of the element of the most recent parameter found by a call
+// to ScanParam. The returned slice points to the line.
+func (dec *Decoder) ID() []byte {
+	return dec.id
+}
+
+// Name returns the name of the most recent parameter found by a call to
+// ScanParam. The returned slice points to the line.
+func (dec *Decoder) Name() []byte {
+	return dec.name
+}
+
+// Value returns the value of the most recent parameter found by a call to
+// ScanParam. The returned slice may point to internal buffers and is only
+// valid until the next call to ScanParam.
+func (dec *Decoder) Value() []byte {
+	return dec.value
+}
+
+// Err returns the first error that was encountered by the Decoder.
+func (dec *Decoder) Err() error {
+	return dec.err
+}
+
+func (dec *Decoder) syntaxError(msg string) {
+	if dec.err != nil {
+		return
+	}
+	dec.err = &SyntaxError{
+		Msg: msg,
+		Pos: dec.pos + 1,
+	}
+}
+
+func (dec *Decoder) unexpectedByte(c byte) {
+	dec.syntaxError(fmt.Sprintf("unexpected %q", c))
+}
+
+// A SyntaxError represents a syntax error in the structured data.
+type SyntaxError struct {
+	Msg string
+	Pos int
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("structured data syntax error at pos %d : %s", e.Pos, e.Msg)
+}
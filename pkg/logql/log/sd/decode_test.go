@@ -0,0 +1,84 @@
+package sd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoder(t *testing.T) {
+	type param struct {
+		id, name, value string
+	}
+
+	for _, tc := range []struct {
+		name     string
+		line     string
+		expected []param
+		err      string
+	}{
+		{
+			name: "rfc5424 message",
+			line: `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="Application" eventID="1011"][examplePriority@32473 class="high"] An application event`,
+			expected: []param{
+				{"exampleSDID@32473", "iut", "3"},
+				{"exampleSDID@32473", "eventSource", "Application"},
+				{"exampleSDID@32473", "eventID", "1011"},
+				{"examplePriority@32473", "class", "high"},
+			},
+		},
+		{
+			name: "escaped values",
+			line: `[meta path="C:\\temp\dir" quote="say \"hi\"" bracket="[a\]" empty=""]`,
+			expected: []param{
+				{"meta", "path", `C:\temp\dir`},
+				{"meta", "quote", `say "hi"`},
+				{"meta", "bracket", `[a]`},
+				{"meta", "empty", ``},
+			},
+		},
+		{
+			name:     "element without parameters",
+			line:     `[origin][meta sequenceId="1"] message [not="structured data"]`,
+			expected: []param{{"meta", "sequenceId", "1"}},
+		},
+		{
+			name: "no structured data",
+			line: `- message`,
+		},
+		{
+			name:     "invalid element is skipped",
+			line:     `[bad a=1 b="2"][meta sequenceId="1"]`,
+			expected: []param{{"meta", "sequenceId", "1"}},
+			err:      `structured data syntax error at pos 8 : unexpected '1'`,
+		},
+		{
+			name:     "unterminated value",
+			line:     `[meta a="1" b="2`,
+			expected: []param{{"meta", "a", "1"}},
+			err:      `structured data syntax error at pos 17 : unterminated quoted value`,
+		},
+		{
+			name: "missing id",
+			line: `[ a="1"]`,
+			err:  `structured data syntax error at pos 2 : missing element id`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dec := NewDecoder([]byte(tc.line))
+			var actual []param
+			for !dec.EOL() {
+				if !dec.ScanParam() {
+					continue
+				}
+				actual = append(actual, param{string(dec.ID()), string(dec.Name()), string(dec.Value())})
+			}
+			require.Equal(t, tc.expected, actual)
+			if tc.err != "" {
+				require.EqualError(t, dec.Err(), tc.err)
+				return
+			}
+			require.NoError(t, dec.Err())
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/logqlmodel"
+)
+
+// Search is a full-text filter keeping the entries containing every term of a
+// search. A term is found when it is part of the line or, when the metadata is
+// searched too, part of the value of a structured metadata or parsed label.
+type Search struct {
+	terms    [][]byte
+	metadata bool
+	buf      labels.Labels
+}
+
+// SearchTerms splits a search into its terms, which are separated by spaces.
+func SearchTerms(search string) []string {
+	return strings.Fields(search)
+}
+
+func NewSearch(search string, metadata bool) *Search {
+	terms := SearchTerms(search)
+	s := &Search{
+		terms:    make([][]byte, 0, len(terms)),
+		metadata: metadata,
+	}
+	for _, term := range terms {
+		s.terms = append(s.terms, []byte(term))
+	}
+	return s
+}
+
+// SearchesMetadata reports whether the terms are also searched in the values
+// of the structured metadata and parsed labels.
+func (s *Search) SearchesMetadata() bool {
+	return s.metadata
+}
+
+func (s *Search) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	var values labels.Labels
+	for i, term := range s.terms {
+		if bytes.Contains(line, term) {
+			continue
+		}
+		if !s.metadata {
+			return line, false
+		}
+		if values == nil {
+			s.buf = lbs.UnsortedLabels(s.buf, StructuredMetadataLabel, ParsedLabel)
+			values = s.buf
+		}
+		if !containsTerm(values, s.terms[i]) {
+			return line, false
+		}
+	}
+	return line, true
+}
+
+func containsTerm(values labels.Labels, term []byte) bool {
+	for _, l := range values {
+		if l.Name == logqlmodel.ErrorLabel || l.Name == logqlmodel.ErrorDetailsLabel {
+			continue
+		}
+		if strings.Contains(l.Value, string(term)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Search) RequiredLabelNames() []string { return nil }
@@ -0,0 +1,41 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Search(t *testing.T) {
+	lbs := labels.FromStrings("app", "foo")
+	line := []byte("connection refused by upstream")
+
+	for _, tc := range []struct {
+		name     string
+		search   string
+		metadata bool
+		match    bool
+	}{
+		{"all terms in line", "refused upstream", false, true},
+		{"missing term", "refused downstream", false, false},
+		{"stream labels are not searched", "foo", true, false},
+		{"term in structured metadata", "refused 3fa85f64", true, true},
+		{"term in parsed label", "refused gateway", true, true},
+		{"metadata not searched", "refused 3fa85f64", false, false},
+		{"error labels are not searched", "JSONParserErr", true, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := NewBaseLabelsBuilder().ForLabels(lbs, lbs.Hash())
+			b.Set(StructuredMetadataLabel, "trace_id", "3fa85f64-5717")
+			b.Set(ParsedLabel, "service", "gateway")
+			b.SetErr(errJSON)
+
+			s := NewSearch(tc.search, tc.metadata)
+			require.Equal(t, tc.metadata, s.SearchesMetadata())
+			l, ok := s.Process(0, line, b)
+			require.Equal(t, tc.match, ok)
+			require.Equal(t, line, l)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package log
+
+// SortBy is the stage of a log query ordering its entries by the value of a
+// label instead of their timestamp. The stage doesn't modify the entries, they
+// are ordered by the engine which only keeps the first entries of the query
+// limit using Less.
+type SortBy struct {
+	Label string
+	Desc  bool
+}
+
+func NewSortBy(label string, desc bool) *SortBy {
+	return &SortBy{
+		Label: label,
+		Desc:  desc,
+	}
+}
+
+func (s *SortBy) Process(_ int64, line []byte, _ *LabelsBuilder) ([]byte, bool) {
+	return line, true
+}
+
+func (s *SortBy) RequiredLabelNames() []string {
+	return []string{s.Label}
+}
+
+// SortKey is the value of the sort label of an entry.
+type SortKey struct {
+	value   string
+	number  float64
+	numeric bool
+	present bool
+}
+
+// Key returns the sort key of a label value. Values which can be converted to
+// a number, including durations and bytes, are compared as numbers. The value
+// is ignored when ok is false, i.e. the entry doesn't have the sort label.
+func (s *SortBy) Key(value string, ok bool) SortKey {
+	if !ok {
+		return SortKey{}
+	}
+	key := SortKey{value: value, present: true}
+	for _, convert := range []func(string) (float64, error){convertFloat, convertDuration, convertBytes} {
+		if n, err := convert(value); err == nil {
+			key.number, key.numeric = n, true
+			break
+		}
+	}
+	return key
+}
+
+// Less reports whether an entry with the key a is ordered before an entry with
+// the key b. Entries without the sort label are ordered last, numeric values
+// are ordered before other values regardless of the sort direction.
+func (s *SortBy) Less(a, b SortKey) bool {
+	switch {
+	case a.present != b.present:
+		return a.present
+	case a.numeric != b.numeric:
+		return a.numeric
+	case a.numeric:
+		if s.Desc {
+			return a.number > b.number
+		}
+		return a.number < b.number
+	case s.Desc:
+		return a.value > b.value
+	default:
+		return a.value < b.value
+	}
+}
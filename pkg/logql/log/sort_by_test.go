@@ -0,0 +1,32 @@
+package log
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SortBy(t *testing.T) {
+	values := []string{"b", "2s", "", "10", "a", "500ms", "1KB"}
+
+	for _, tc := range []struct {
+		name     string
+		desc     bool
+		expected []string
+	}{
+		{"asc", false, []string{"500ms", "2s", "10", "1KB", "a", "b", ""}},
+		{"desc", true, []string{"1KB", "10", "2s", "500ms", "b", "a", ""}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewSortBy("duration", tc.desc)
+			require.Equal(t, []string{"duration"}, s.RequiredLabelNames())
+
+			actual := append([]string(nil), values...)
+			sort.SliceStable(actual, func(i, j int) bool {
+				return s.Less(s.Key(actual[i], actual[i] != ""), s.Key(actual[j], actual[j] != ""))
+			})
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
@@ -8,7 +8,7 @@ func optimizeSampleExpr(expr syntax.SampleExpr) (syntax.SampleExpr, error) {
 	// we skip sharding AST for now, it's not easy to clone them since they are not part of the language.
 	expr.Walk(func(e syntax.Expr) {
 		switch e.(type) {
-		case *ConcatSampleExpr, DownstreamSampleExpr, *QuantileSketchEvalExpr, *QuantileSketchMergeExpr, *MergeFirstOverTimeExpr, *MergeLastOverTimeExpr:
+		case *ConcatSampleExpr, DownstreamSampleExpr, *QuantileSketchEvalExpr, *QuantileSketchMergeExpr, *CountDistinctSketchEvalExpr, *CountDistinctSketchMergeExpr, *MergeFirstOverTimeExpr, *MergeLastOverTimeExpr:
 			skip = true
 			return
 		}
@@ -63,6 +63,10 @@ func removeLineformat(expr syntax.SampleExpr) {
 					found = true
 					break
 				}
+				if _, ok := pipelineExpr.MultiStages[j].(*syntax.SearchExpr); ok {
+					found = true
+					break
+				}
 				if _, ok := pipelineExpr.MultiStages[j].(*syntax.JSONExpressionParser); ok {
 					found = true
 					break
@@ -71,6 +75,18 @@ func removeLineformat(expr syntax.SampleExpr) {
 					found = true
 					break
 				}
+				if _, ok := pipelineExpr.MultiStages[j].(*syntax.CSVParserExpr); ok {
+					found = true
+					break
+				}
+				if _, ok := pipelineExpr.MultiStages[j].(*syntax.SDParserExpr); ok {
+					found = true
+					break
+				}
+				if _, ok := pipelineExpr.MultiStages[j].(*syntax.XMLParserExpr); ok {
+					found = true
+					break
+				}
 			}
 			if found {
 				// we cannot remove safely the linefmtExpr.
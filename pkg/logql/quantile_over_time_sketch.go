@@ -62,6 +62,10 @@ func (q ProbabilisticQuantileVector) QuantileSketchVec() ProbabilisticQuantileVe
 	return q
 }
 
+func (ProbabilisticQuantileVector) CountDistinctSketchVec() ProbabilisticCountDistinctVector {
+	return ProbabilisticCountDistinctVector{}
+}
+
 func (q ProbabilisticQuantileVector) ToProto() *logproto.QuantileSketchVector {
 	samples := make([]*logproto.QuantileSketchSample, len(q))
 	for i, sample := range q {
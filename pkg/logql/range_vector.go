@@ -12,6 +12,7 @@ import (
 	promql_parser "github.com/prometheus/prometheus/promql/parser"
 
 	"github.com/grafana/loki/v3/pkg/iter"
+	"github.com/grafana/loki/v3/pkg/logql/sketch"
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	"github.com/grafana/loki/v3/pkg/logql/vector"
 )
@@ -250,6 +251,8 @@ func aggregator(r *syntax.RangeAggregationExpr) (BatchRangeVectorAggregator, err
 		return last, nil
 	case syntax.OpRangeTypeAbsent:
 		return one, nil
+	case syntax.OpRangeTypeCountDistinct:
+		return countDistinctOverTime, nil
 	default:
 		return nil, fmt.Errorf(syntax.UnsupportedErr, r.Operation)
 	}
@@ -504,6 +507,16 @@ func one(_ []promql.FPoint) float64 {
 	return 1.0
 }
 
+// countDistinctOverTime estimates the number of distinct values of the
+// samples, whose values are the hashes of the values extracted from log lines.
+func countDistinctOverTime(samples []promql.FPoint) float64 {
+	s := sketch.NewDistinctSketch()
+	for _, v := range samples {
+		s.AddHash(math.Float64bits(v.F))
+	}
+	return float64(s.Count())
+}
+
 // streaming range agg
 type streamRangeVectorIterator struct {
 	iter                                 iter.PeekingSampleIterator
@@ -627,6 +640,8 @@ func streamingAggregator(r *syntax.RangeAggregationExpr) (RangeStreamingAgg, err
 		return &LastOverTime{}, nil
 	case syntax.OpRangeTypeAbsent:
 		return &OneOverTime{}, nil
+	case syntax.OpRangeTypeCountDistinct:
+		return &CountDistinctOverTime{sketch: sketch.NewDistinctSketch()}, nil
 	default:
 		return nil, fmt.Errorf(syntax.UnsupportedErr, r.Operation)
 	}
@@ -851,3 +866,15 @@ func (a *OneOverTime) agg(_ promql.FPoint) {
 func (a *OneOverTime) at() float64 {
 	return 1.0
 }
+
+type CountDistinctOverTime struct {
+	sketch *sketch.DistinctSketch
+}
+
+func (a *CountDistinctOverTime) agg(sample promql.FPoint) {
+	a.sketch.AddHash(math.Float64bits(sample.F))
+}
+
+func (a *CountDistinctOverTime) at() float64 {
+	return float64(a.sketch.Count())
+}
@@ -383,6 +383,108 @@ func Test_RangeVectorIteratorBadLabels(t *testing.T) {
 	}
 }
 
+func Test_HistogramIterator(t *testing.T) {
+	histogramSamples := []logproto.Sample{
+		{Timestamp: time.Unix(2, 0).UnixNano(), Hash: 1, Value: 0.05},
+		{Timestamp: time.Unix(5, 0).UnixNano(), Hash: 2, Value: 0.5},
+		{Timestamp: time.Unix(6, 0).UnixNano(), Hash: 3, Value: 0.7},
+		{Timestamp: time.Unix(8, 0).UnixNano(), Hash: 4, Value: 3},
+		{Timestamp: time.Unix(12, 0).UnixNano(), Hash: 5, Value: 0.2},
+	}
+	it := newHistogramIterator(newfakePeekingSampleIterator(histogramSamples), []float64{0.1, 0.5, 1},
+		(10 * time.Second).Nanoseconds(), (10 * time.Second).Nanoseconds(),
+		time.Unix(10, 0).UnixNano(), time.Unix(20, 0).UnixNano(), 0)
+
+	bucket := func(lbs labels.Labels, le string) labels.Labels {
+		return labels.NewBuilder(lbs).Set(labels.BucketLabel, le).Labels()
+	}
+	expected := []promql.Vector{
+		{
+			newSample(time.Unix(10, 0), 1, bucket(labelBar, "0.1")),
+			newSample(time.Unix(10, 0), 2, bucket(labelBar, "0.5")),
+			newSample(time.Unix(10, 0), 3, bucket(labelBar, "1")),
+			newSample(time.Unix(10, 0), 4, bucket(labelBar, "+Inf")),
+			newSample(time.Unix(10, 0), 1, bucket(labelFoo, "0.1")),
+			newSample(time.Unix(10, 0), 2, bucket(labelFoo, "0.5")),
+			newSample(time.Unix(10, 0), 3, bucket(labelFoo, "1")),
+			newSample(time.Unix(10, 0), 4, bucket(labelFoo, "+Inf")),
+		},
+		{
+			newSample(time.Unix(20, 0), 0, bucket(labelBar, "0.1")),
+			newSample(time.Unix(20, 0), 1, bucket(labelBar, "0.5")),
+			newSample(time.Unix(20, 0), 1, bucket(labelBar, "1")),
+			newSample(time.Unix(20, 0), 1, bucket(labelBar, "+Inf")),
+			newSample(time.Unix(20, 0), 0, bucket(labelFoo, "0.1")),
+			newSample(time.Unix(20, 0), 1, bucket(labelFoo, "0.5")),
+			newSample(time.Unix(20, 0), 1, bucket(labelFoo, "1")),
+			newSample(time.Unix(20, 0), 1, bucket(labelFoo, "+Inf")),
+		},
+	}
+
+	i := 0
+	for it.Next() {
+		ts, res := it.At()
+		vec := res.SampleVector()
+		sort.SliceStable(vec, func(i, j int) bool { return vec[i].Metric.Get("app") < vec[j].Metric.Get("app") })
+		require.Equal(t, expected[i], vec)
+		require.Equal(t, expected[i][0].T, ts)
+		i++
+	}
+	require.Equal(t, len(expected), i)
+	require.NoError(t, it.Error())
+}
+
+func Test_TrendIterator(t *testing.T) {
+	// the values grow by 2 per second.
+	trendSamples := []logproto.Sample{
+		{Timestamp: time.Unix(2, 0).UnixNano(), Hash: 1, Value: 4},
+		{Timestamp: time.Unix(4, 0).UnixNano(), Hash: 2, Value: 8},
+		{Timestamp: time.Unix(6, 0).UnixNano(), Hash: 3, Value: 12},
+		{Timestamp: time.Unix(8, 0).UnixNano(), Hash: 4, Value: 16},
+		{Timestamp: time.Unix(12, 0).UnixNano(), Hash: 5, Value: 24},
+	}
+	rangeExpr := func(op string, args ...float64) *syntax.RangeAggregationExpr {
+		return &syntax.RangeAggregationExpr{
+			Left:      &syntax.LogRange{Interval: 10 * time.Second},
+			Operation: op,
+			Args:      args,
+		}
+	}
+
+	for _, tt := range []struct {
+		expr     *syntax.RangeAggregationExpr
+		expected float64
+	}{
+		{rangeExpr(syntax.OpRangeTypeDeriv), 2},
+		{rangeExpr(syntax.OpRangeTypePredictLinear, 10), 40},
+		{rangeExpr(syntax.OpRangeTypeHoltWinters, 0.5, 0.5), 16},
+	} {
+		t.Run(tt.expr.Operation, func(t *testing.T) {
+			it := newTrendIterator(newfakePeekingSampleIterator(trendSamples), tt.expr,
+				(10 * time.Second).Nanoseconds(), (10 * time.Second).Nanoseconds(),
+				time.Unix(10, 0).UnixNano(), time.Unix(20, 0).UnixNano(), 0)
+
+			require.True(t, it.Next())
+			ts, res := it.At()
+			vec := res.SampleVector()
+			sort.SliceStable(vec, func(i, j int) bool { return vec[i].Metric.Get("app") < vec[j].Metric.Get("app") })
+			require.Equal(t, promql.Vector{
+				newSample(time.Unix(10, 0), tt.expected, labelBar),
+				newSample(time.Unix(10, 0), tt.expected, labelFoo),
+			}, vec)
+			require.Equal(t, time.Unix(10, 0).UnixMilli(), ts)
+
+			// series with a single sample in the window are dropped.
+			require.True(t, it.Next())
+			_, res = it.At()
+			require.Empty(t, res.SampleVector())
+
+			require.False(t, it.Next())
+			require.NoError(t, it.Error())
+		})
+	}
+}
+
 func Test_InstantQueryRangeVectorAggregations(t *testing.T) {
 	tests := []struct {
 		name          string
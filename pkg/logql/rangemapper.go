@@ -24,6 +24,10 @@ var splittableVectorOp = map[string]struct{}{
 	syntax.OpTypeSortDesc: {},
 }
 
+// splittableRangeVectorOp lists the range aggregations that can be merged
+// from the results of smaller ranges. Trend functions such as deriv,
+// predict_linear and holt_winters need all the samples of the range at once and
+// are never split.
 var splittableRangeVectorOp = map[string]struct{}{
 	syntax.OpRangeTypeRate:      {},
 	syntax.OpRangeTypeBytesRate: {},
@@ -32,6 +36,7 @@ var splittableRangeVectorOp = map[string]struct{}{
 	syntax.OpRangeTypeSum:       {},
 	syntax.OpRangeTypeMax:       {},
 	syntax.OpRangeTypeMin:       {},
+	syntax.OpRangeTypeHistogram: {},
 }
 
 // RangeMapper is used to rewrite LogQL sample expressions into multiple
@@ -175,10 +180,29 @@ func (m RangeMapper) Map(expr syntax.SampleExpr, vectorAggrPushdown *syntax.Vect
 		}
 		e.Left = lhsMapped
 		return e, nil
+	case *syntax.LabelFunctionExpr:
+		lhsMapped, err := m.Map(e.Left, vectorAggrPushdown, recorder)
+		if err != nil {
+			return nil, err
+		}
+		e.Left = lhsMapped
+		return e, nil
 	case *syntax.LiteralExpr:
 		return e, nil
 	case *syntax.VectorExpr:
 		return e, nil
+	case *syntax.SubqueryExpr:
+		// subqueries are evaluated over the whole range of the inner query
+		// and are never split.
+		return e, nil
+	case *syntax.ChangesVsExpr:
+		// both legs run the same split query over their own time range.
+		lhsMapped, err := m.Map(e.Left, vectorAggrPushdown, recorder)
+		if err != nil {
+			return nil, err
+		}
+		e.Left = lhsMapped
+		return e, nil
 	default:
 		// ConcatSampleExpr and DownstreamSampleExpr are not supported input expression types
 		return nil, errors.Errorf("unexpected expr type (%T) for ASTMapper type (%T) ", expr, m)
@@ -208,6 +232,20 @@ func hasLabelExtractionStage(expr syntax.SampleExpr) bool {
 		switch concrete := e.(type) {
 		case *syntax.LogfmtParserExpr:
 			found = true
+		case *syntax.CSVParserExpr:
+			// `csv` without columns extracts every field of the lines.
+			if len(concrete.Columns) == 0 {
+				found = true
+			}
+		case *syntax.SDParserExpr:
+			// Like `json` and `logfmt`, `sd` and `xml` without expressions extract every label.
+			if len(concrete.Expressions) == 0 {
+				found = true
+			}
+		case *syntax.XMLParserExpr:
+			if len(concrete.Expressions) == 0 {
+				found = true
+			}
 		case *syntax.LabelParserExpr:
 			// It will **not** return true for `regexp`, `unpack` and `pattern`, since these label extraction
 			// stages can control how many labels, and therefore the resulting amount of series, are extracted.
@@ -517,6 +555,18 @@ func (m RangeMapper) mapRangeAggregationExpr(expr *syntax.RangeAggregationExpr,
 		return m.vectorAggrWithRangeDownstreams(expr, vectorAggrPushdown, syntax.OpTypeMax, rangeInterval, recorder)
 	case syntax.OpRangeTypeMin:
 		return m.vectorAggrWithRangeDownstreams(expr, vectorAggrPushdown, syntax.OpTypeMin, rangeInterval, recorder)
+	case syntax.OpRangeTypeHistogram:
+		// The bucket counts of the smaller ranges are summed per series,
+		// keeping the `le` label of the downstream results.
+		var downstream syntax.SampleExpr = expr
+		if vectorAggrPushdown != nil {
+			downstream = vectorAggrPushdown
+		}
+		return &syntax.VectorAggregationExpr{
+			Left:      m.mapConcatSampleExpr(downstream, rangeInterval, recorder),
+			Grouping:  &syntax.Grouping{Without: true, Groups: []string{}},
+			Operation: syntax.OpTypeSum,
+		}
 	case syntax.OpRangeTypeRate:
 		if labelExtractor && vectorAggrPushdown.Operation != syntax.OpTypeSum {
 			return expr
@@ -570,7 +620,11 @@ func isSplittableByRange(expr syntax.SampleExpr) bool {
 		return isSplittableByRange(e.SampleExpr) || literalLHS && isSplittableByRange(e.RHS) || literalRHS
 	case *syntax.LabelReplaceExpr:
 		return isSplittableByRange(e.Left)
-	case *syntax.VectorExpr:
+	case *syntax.LabelFunctionExpr:
+		return isSplittableByRange(e.Left)
+	case *syntax.ChangesVsExpr:
+		return isSplittableByRange(e.Left)
+	case *syntax.VectorExpr, *syntax.SubqueryExpr:
 		return false
 	default:
 		return false
@@ -232,6 +232,35 @@ func Test_SplitRangeVectorMapping(t *testing.T) {
 			)`,
 			3,
 		},
+		{
+			`histogram_over_time({app="foo"} | unwrap bar [3m], 0.5, 1)`,
+			`sum without () (
+				downstream<histogram_over_time({app="foo"} | unwrap bar [1m] offset 2m0s, 0.5, 1), shard=<nil>>
+				++ downstream<histogram_over_time({app="foo"} | unwrap bar [1m] offset 1m0s, 0.5, 1), shard=<nil>>
+				++ downstream<histogram_over_time({app="foo"} | unwrap bar [1m], 0.5, 1), shard=<nil>>
+			)`,
+			3,
+		},
+		{
+			`histogram_over_time({app="foo"} | unwrap bar [3m], 1) by (baz)`,
+			`sum without () (
+				downstream<histogram_over_time({app="foo"} | unwrap bar [1m] offset 2m0s, 1) by (baz), shard=<nil>>
+				++ downstream<histogram_over_time({app="foo"} | unwrap bar [1m] offset 1m0s, 1) by (baz), shard=<nil>>
+				++ downstream<histogram_over_time({app="foo"} | unwrap bar [1m], 1) by (baz), shard=<nil>>
+			)`,
+			3,
+		},
+		{
+			`sum by (le) (histogram_over_time({app="foo"} | unwrap bar [3m], 1))`,
+			`sum by (le) (
+				sum without () (
+					downstream<sum by (le) (histogram_over_time({app="foo"} | unwrap bar [1m] offset 2m0s, 1)), shard=<nil>>
+					++ downstream<sum by (le) (histogram_over_time({app="foo"} | unwrap bar [1m] offset 1m0s, 1)), shard=<nil>>
+					++ downstream<sum by (le) (histogram_over_time({app="foo"} | unwrap bar [1m], 1)), shard=<nil>>
+				)
+			)`,
+			3,
+		},
 		{
 			`min_over_time({app="foo"} | unwrap bar [3m])`,
 			`min without () (
@@ -1844,6 +1873,22 @@ func Test_SplitRangeVectorMapping_Noop(t *testing.T) {
 			`sum(avg_over_time({app="foo"} | unwrap bar[3m]))`,
 		},
 
+		// trend functions need the whole range and are never split
+		{
+			`predict_linear({app="foo"} | unwrap bar [3m], 3600)`,
+			`predict_linear({app="foo"} | unwrap bar [3m], 3600)`,
+		},
+		{
+			`sum(rate({app="foo"}[3m])) + sum(deriv({app="foo"} | unwrap bar [3m]))`,
+			`(sum(rate({app="foo"}[3m])) + sum(deriv({app="foo"} | unwrap bar [3m])))`,
+		},
+
+		// subqueries are never split
+		{
+			`max_over_time(sum(count_over_time({app="foo"}[3m]))[10m:1m])`,
+			`max_over_time(sum(count_over_time({app="foo"}[3m]))[10m:1m])`,
+		},
+
 		// should be noop if range interval is lower or equal to split interval (1m)
 		{
 			`bytes_over_time({app="foo"}[1m])`,
@@ -1860,6 +1905,18 @@ func Test_SplitRangeVectorMapping_Noop(t *testing.T) {
 			`count_over_time({app="foo"} | json [3m])`,
 			`count_over_time({app="foo"} | json [3m])`,
 		},
+		{
+			`count_over_time({app="foo"} | sd [3m])`,
+			`count_over_time({app="foo"} | sd [3m])`,
+		},
+		{
+			`count_over_time({app="foo"} | xml [3m])`,
+			`count_over_time({app="foo"} | xml [3m])`,
+		},
+		{
+			`count_over_time({app="foo"} | csv [3m])`,
+			`count_over_time({app="foo"} | csv [3m])`,
+		},
 		{
 			`sum_over_time({app="foo"} | logfmt | unwrap bar [3m])`,
 			`sum_over_time({app="foo"} | logfmt | unwrap bar [3m])`,
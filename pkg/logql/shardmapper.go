@@ -6,6 +6,7 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
 
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/tsdb/index"
@@ -13,23 +14,26 @@ import (
 )
 
 const (
-	ShardLastOverTime     = "last_over_time"
-	ShardFirstOverTime    = "first_over_time"
-	ShardQuantileOverTime = "quantile_over_time"
+	ShardLastOverTime          = "last_over_time"
+	ShardFirstOverTime         = "first_over_time"
+	ShardQuantileOverTime      = "quantile_over_time"
+	ShardCountDistinctOverTime = "count_distinct_over_time"
 )
 
 type ShardMapper struct {
-	shards                   ShardingStrategy
-	metrics                  *MapperMetrics
-	quantileOverTimeSharding bool
-	lastOverTimeSharding     bool
-	firstOverTimeSharding    bool
+	shards                        ShardingStrategy
+	metrics                       *MapperMetrics
+	quantileOverTimeSharding      bool
+	lastOverTimeSharding          bool
+	firstOverTimeSharding         bool
+	countDistinctOverTimeSharding bool
 }
 
 func NewShardMapper(strategy ShardingStrategy, metrics *MapperMetrics, shardAggregation []string) ShardMapper {
 	quantileOverTimeSharding := false
 	lastOverTimeSharding := false
 	firstOverTimeSharding := false
+	countDistinctOverTimeSharding := false
 	for _, a := range shardAggregation {
 		switch a {
 		case ShardQuantileOverTime:
@@ -38,14 +42,17 @@ func NewShardMapper(strategy ShardingStrategy, metrics *MapperMetrics, shardAggr
 			lastOverTimeSharding = true
 		case ShardFirstOverTime:
 			firstOverTimeSharding = true
+		case ShardCountDistinctOverTime:
+			countDistinctOverTimeSharding = true
 		}
 	}
 	return ShardMapper{
-		shards:                   strategy,
-		metrics:                  metrics,
-		quantileOverTimeSharding: quantileOverTimeSharding,
-		firstOverTimeSharding:    firstOverTimeSharding,
-		lastOverTimeSharding:     lastOverTimeSharding,
+		shards:                        strategy,
+		metrics:                       metrics,
+		quantileOverTimeSharding:      quantileOverTimeSharding,
+		firstOverTimeSharding:         firstOverTimeSharding,
+		lastOverTimeSharding:          lastOverTimeSharding,
+		countDistinctOverTimeSharding: countDistinctOverTimeSharding,
 	}
 }
 
@@ -88,12 +95,21 @@ func (m ShardMapper) Map(expr syntax.Expr, r *downstreamRecorder, topLevel bool)
 		return e, 0, nil
 	case *syntax.MatchersExpr, *syntax.PipelineExpr:
 		return m.mapLogSelectorExpr(e.(syntax.LogSelectorExpr), r)
+	case *syntax.JoinExpr:
+		// both sides of a join need to be evaluated together.
+		return noOp(e, m.shards.Resolver())
 	case *syntax.VectorAggregationExpr:
 		return m.mapVectorAggregationExpr(e, r, topLevel)
 	case *syntax.LabelReplaceExpr:
 		return m.mapLabelReplaceExpr(e, r, topLevel)
+	case *syntax.LabelFunctionExpr:
+		return m.mapLabelFunctionExpr(e, r, topLevel)
 	case *syntax.RangeAggregationExpr:
 		return m.mapRangeAggregationExpr(e, r, topLevel)
+	case *syntax.SubqueryExpr:
+		return m.mapSubqueryExpr(e, r)
+	case *syntax.ChangesVsExpr:
+		return m.mapChangesVsExpr(e, r, topLevel)
 	case *syntax.BinOpExpr:
 		return m.mapBinOpExpr(e, r, topLevel)
 	default:
@@ -333,6 +349,47 @@ func (m ShardMapper) mapLabelReplaceExpr(expr *syntax.LabelReplaceExpr, r *downs
 	return &cpy, bytesPerShard, nil
 }
 
+func (m ShardMapper) mapLabelFunctionExpr(expr *syntax.LabelFunctionExpr, r *downstreamRecorder, topLevel bool) (syntax.SampleExpr, uint64, error) {
+	subMapped, bytesPerShard, err := m.Map(expr.Left, r, topLevel)
+	if err != nil {
+		return nil, 0, err
+	}
+	cpy := *expr
+	cpy.Left = subMapped.(syntax.SampleExpr)
+	return &cpy, bytesPerShard, nil
+}
+
+// mapSubqueryExpr shards the inner query of a subquery. The aggregation over
+// time is always evaluated on the merged results of the inner query.
+func (m ShardMapper) mapSubqueryExpr(expr *syntax.SubqueryExpr, r *downstreamRecorder) (syntax.SampleExpr, uint64, error) {
+	// the inner query is never the top level aggregation.
+	subMapped, bytesPerShard, err := m.Map(expr.Left, r, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	if isNoOp(expr.Left, subMapped) {
+		return noOp(expr, m.shards.Resolver())
+	}
+	cpy := *expr
+	cpy.Left = subMapped.(syntax.SampleExpr)
+	return &cpy, bytesPerShard, nil
+}
+
+// mapChangesVsExpr shards the compared query of a changes_vs expression. Both
+// legs run the mapped query, the shifted one over the shifted time range.
+func (m ShardMapper) mapChangesVsExpr(expr *syntax.ChangesVsExpr, r *downstreamRecorder, topLevel bool) (syntax.SampleExpr, uint64, error) {
+	subMapped, bytesPerShard, err := m.Map(expr.Left, r, topLevel)
+	if err != nil {
+		return nil, 0, err
+	}
+	if isNoOp(expr.Left, subMapped) {
+		return noOp(expr, m.shards.Resolver())
+	}
+	cpy := *expr
+	cpy.Left = subMapped.(syntax.SampleExpr)
+	return &cpy, bytesPerShard, nil
+}
+
 // These functions require a different merge strategy than the default
 // concatenation.
 // This is because the same label sets may exist on multiple shards when label-reducing parsing is applied or when
@@ -345,12 +402,29 @@ var rangeMergeMap = map[string]string{
 	syntax.OpRangeTypeBytes:     syntax.OpTypeSum,
 	syntax.OpRangeTypeBytesRate: syntax.OpTypeSum,
 	syntax.OpRangeTypeSum:       syntax.OpTypeSum,
+	// histogram buckets are summed per `le` label
+	syntax.OpRangeTypeHistogram: syntax.OpTypeSum,
 
 	// min & max require taking the min|max of the shards
 	syntax.OpRangeTypeMin: syntax.OpTypeMin,
 	syntax.OpRangeTypeMax: syntax.OpTypeMax,
 }
 
+// histogramMergeGrouping returns the grouping used to merge the bucket series
+// of a histogram_over_time. The `le` label must always be retained.
+func histogramMergeGrouping(grouping *syntax.Grouping) *syntax.Grouping {
+	if grouping == nil {
+		return &syntax.Grouping{Without: true}
+	}
+	if grouping.Without {
+		return grouping
+	}
+	groups := make([]string, 0, len(grouping.Groups)+1)
+	groups = append(groups, grouping.Groups...)
+	groups = append(groups, labels.BucketLabel)
+	return &syntax.Grouping{Groups: groups}
+}
+
 func (m ShardMapper) mapRangeAggregationExpr(expr *syntax.RangeAggregationExpr, r *downstreamRecorder, topLevel bool) (syntax.SampleExpr, uint64, error) {
 	if !expr.Shardable(topLevel) {
 		return noOp(expr, m.shards.Resolver())
@@ -391,12 +465,32 @@ func (m ShardMapper) mapRangeAggregationExpr(expr *syntax.RangeAggregationExpr,
 			Operation: merger,
 		}, bytes, err
 
+	case syntax.OpRangeTypeHistogram:
+		potentialConflict := syntax.ReducesLabels(expr)
+		if !potentialConflict && (expr.Grouping == nil || expr.Grouping.Noop()) {
+			return m.mapSampleExpr(expr, r)
+		}
+
+		// histogram_over_time(_, 1, 2) by (foo) -> sum by (foo, le) (histogram_over_time(_, 1, 2) by (foo) ++ ...)
+		mapped, bytes, err := m.mapSampleExpr(expr, r)
+		return &syntax.VectorAggregationExpr{
+			Left:      mapped,
+			Grouping:  histogramMergeGrouping(expr.Grouping),
+			Operation: syntax.OpTypeSum,
+		}, bytes, err
+
 	case syntax.OpRangeTypeAvg:
 		potentialConflict := syntax.ReducesLabels(expr)
 		if !potentialConflict && (expr.Grouping == nil || expr.Grouping.Noop()) {
 			return m.mapSampleExpr(expr, r)
 		}
 
+		// the lines counted without the unwrap can't be matched with the
+		// series of each unwrapped label.
+		if expr.Left.Unwrap != nil && expr.Left.Unwrap.IsMultiple() {
+			return noOp(expr, m.shards.Resolver())
+		}
+
 		// avg_over_time() by (foo) -> sum by (foo) (sum_over_time()) / sum by (foo) (count_over_time())
 		lhs, lhsBytesPerShard, err := m.mapVectorAggregationExpr(&syntax.VectorAggregationExpr{
 			Left: &syntax.RangeAggregationExpr{
@@ -484,6 +578,49 @@ func (m ShardMapper) mapRangeAggregationExpr(expr *syntax.RangeAggregationExpr,
 			quantile: expr.Params,
 		}, bytesPerShard, nil
 
+	case syntax.OpRangeTypeCountDistinct:
+		if !m.countDistinctOverTimeSharding {
+			return noOp(expr, m.shards.Resolver())
+		}
+
+		potentialConflict := syntax.ReducesLabels(expr)
+		if !potentialConflict && (expr.Grouping == nil || expr.Grouping.Noop()) {
+			return m.mapSampleExpr(expr, r)
+		}
+
+		shards, bytesPerShard, err := m.shards.Resolver().Shards(expr)
+		if err != nil {
+			return nil, 0, err
+		}
+		if shards == 0 {
+			return noOp(expr, m.shards.Resolver())
+		}
+
+		// count_distinct_over_time() by (foo) ->
+		// count_distinct_sketch_eval(count_distinct_merge by (foo)
+		// (__count_distinct_sketch_over_time__() by (foo)))
+
+		downstreams := make([]DownstreamSampleExpr, 0, shards)
+		expr.Operation = syntax.OpRangeTypeCountDistinctSketch
+		for shard := shards - 1; shard >= 0; shard-- {
+			s := NewPowerOfTwoShard(index.ShardAnnotation{
+				Shard: uint32(shard),
+				Of:    uint32(shards),
+			})
+			downstreams = append(downstreams, DownstreamSampleExpr{
+				shard: &ShardWithChunkRefs{
+					Shard: s,
+				},
+				SampleExpr: expr,
+			})
+		}
+
+		return &CountDistinctSketchEvalExpr{
+			countDistinctMergeExpr: &CountDistinctSketchMergeExpr{
+				downstreams: downstreams,
+			},
+		}, bytesPerShard, nil
+
 	case syntax.OpRangeTypeFirst:
 		if !m.firstOverTimeSharding {
 			return noOp(expr, m.shards.Resolver())
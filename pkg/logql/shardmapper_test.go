@@ -356,6 +356,46 @@ func TestMappingStrings(t *testing.T) {
 				downstream<max_over_time({foo="ugh"}|unwrapbaz[1m])by(),shard=1_of_2>
 			)`,
 		},
+		{
+			in: `histogram_over_time({foo="ugh"} | unwrap baz [1m], 0.5, 1)`,
+			out: `downstream<histogram_over_time({foo="ugh"}|unwrapbaz[1m],0.5,1),shard=0_of_2>
+				++
+				downstream<histogram_over_time({foo="ugh"}|unwrapbaz[1m],0.5,1),shard=1_of_2>`,
+		},
+		{
+			in: `histogram_over_time({foo="ugh"} | unwrap baz [1m], 0.5, 1) by (bar)`,
+			out: `sum by (bar, le) (
+				downstream<histogram_over_time({foo="ugh"}|unwrapbaz[1m],0.5,1)by(bar),shard=0_of_2>
+				++
+				downstream<histogram_over_time({foo="ugh"}|unwrapbaz[1m],0.5,1)by(bar),shard=1_of_2>
+			)`,
+		},
+		{
+			in: `histogram_over_time({foo="ugh"} | unwrap baz [1m], 0.5, 1) without (bar)`,
+			out: `sum without (bar) (
+				downstream<histogram_over_time({foo="ugh"}|unwrapbaz[1m],0.5,1)without(bar),shard=0_of_2>
+				++
+				downstream<histogram_over_time({foo="ugh"}|unwrapbaz[1m],0.5,1)without(bar),shard=1_of_2>
+			)`,
+		},
+		{
+			in: `max_over_time(sum(rate({foo="bar"}[1m]))[1h:1m])`,
+			out: `max_over_time(
+				sum(
+					downstream<sum(rate({foo="bar"}[1m])),shard=0_of_2>
+					++
+					downstream<sum(rate({foo="bar"}[1m])),shard=1_of_2>
+				)[1h:1m]
+			)`,
+		},
+		{
+			in:  `{foo="bar"} | json | join on(trace_id) within 5m {app="backend"}`,
+			out: `{foo="bar"} | json | join on(trace_id) within 5m {app="backend"}`,
+		},
+		{
+			in:  `max_over_time(absent_over_time({foo="bar"}[1m])[1h:1m])`,
+			out: `max_over_time(absent_over_time({foo="bar"}[1m])[1h:1m])`,
+		},
 		{
 			in: `avg(avg_over_time({job=~"myapps.*"} |= "stats" | json busy="utilization" | unwrap busy [5m]))`,
 			out: `(
@@ -392,6 +432,10 @@ func TestMappingStrings(t *testing.T) {
 				)
 			)`,
 		},
+		{
+			in:  `avg_over_time({job=~"myapps.*"} | logfmt | unwrap (busy, idle) [5m]) by (cluster, __unwrapped__)`,
+			out: `avg_over_time({job=~"myapps.*"} | logfmt | unwrap (busy, idle) [5m]) by (cluster, __unwrapped__)`,
+		},
 		// should be noop if VectorExpr
 		{
 			in:  `vector(0)`,
@@ -423,6 +467,11 @@ func TestMappingStrings(t *testing.T) {
 			in:  `sum(count_over_time({a=~".+"}[1s]) * ignoring () count_over_time({a=~".+"}[1s]))`,
 			out: `sum(downstream<sum((count_over_time({a=~".+"}[1s])*count_over_time({a=~".+"}[1s]))),shard=0_of_2>++downstream<sum((count_over_time({a=~".+"}[1s])*count_over_time({a=~".+"}[1s]))),shard=1_of_2>)`,
 		},
+		{
+			// both legs of changes_vs share the sharded compared expression
+			in:  `changes_vs(sum(rate({foo="bar"}[1m])), 1d)`,
+			out: `changes_vs(sum(downstream<sum(rate({foo="bar"}[1m])),shard=0_of_2>++downstream<sum(rate({foo="bar"}[1m])),shard=1_of_2>),1d)`,
+		},
 		{
 			// shard the count since there is no label reduction in children
 			in:  `count by (foo) (rate({job="bar"}[1m]))`,
@@ -1720,3 +1769,41 @@ func TestShardTopk(t *testing.T) {
 )`
 	require.Equal(t, expected, mappedExpr.Pretty(0))
 }
+
+func TestShardCountDistinctOverTime(t *testing.T) {
+	for _, tc := range []struct {
+		in          string
+		aggregation []string
+		out         string
+	}{
+		{
+			in:          `count_distinct_over_time({a="foo"} | json | unwrap user [1m]) by (b)`,
+			aggregation: []string{ShardCountDistinctOverTime},
+			out: `countDistinctSketchEval<countDistinctSketchMerge<
+				downstream<__count_distinct_sketch_over_time__({a="foo"}|json|unwrapuser[1m])by(b),shard=1_of_2>
+				++ downstream<__count_distinct_sketch_over_time__({a="foo"}|json|unwrapuser[1m])by(b),shard=0_of_2>>>`,
+		},
+		{
+			in:          `count_distinct_over_time({a="foo"} | json | unwrap user [1m])`,
+			aggregation: []string{ShardCountDistinctOverTime},
+			out: `downstream<count_distinct_over_time({a="foo"}|json|unwrapuser[1m]),shard=0_of_2>
+				++ downstream<count_distinct_over_time({a="foo"}|json|unwrapuser[1m]),shard=1_of_2>`,
+		},
+		{
+			in:          `max(count_distinct_over_time({a="foo"} | json | unwrap user [1m]) by (b))`,
+			aggregation: []string{ShardCountDistinctOverTime},
+			out:         `max(count_distinct_over_time({a="foo"}|json|unwrapuser[1m])by(b))`,
+		},
+		{
+			in:  `count_distinct_over_time({a="foo"} | json | unwrap user [1m]) by (b)`,
+			out: `count_distinct_over_time({a="foo"}|json|unwrapuser[1m])by(b)`,
+		},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			m := NewShardMapper(NewPowerOfTwoStrategy(ConstantShards(2)), nilShardMetrics, tc.aggregation)
+			_, _, mapped, err := m.Parse(syntax.MustParseExpr(tc.in))
+			require.NoError(t, err)
+			require.Equal(t, removeWhiteSpace(tc.out), removeWhiteSpace(mapped.String()))
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package sketch
+
+import (
+	"github.com/axiomhq/hyperloglog"
+)
+
+// DistinctSketch estimates the number of distinct values added to it with a
+// HyperLogLog sketch. The values are added as 64 bits hashes, so the caller
+// decides how values are hashed.
+type DistinctSketch struct {
+	hll *hyperloglog.Sketch
+}
+
+func NewDistinctSketch() *DistinctSketch {
+	return &DistinctSketch{hll: hyperloglog.New()}
+}
+
+// AddHash adds the hash of a value to the sketch.
+func (d *DistinctSketch) AddHash(h uint64) {
+	d.hll.InsertHash(h)
+}
+
+// Count returns the estimated number of distinct values added to the sketch.
+func (d *DistinctSketch) Count() uint64 {
+	return d.hll.Estimate()
+}
+
+func (d *DistinctSketch) Merge(other *DistinctSketch) error {
+	return d.hll.Merge(other.hll)
+}
+
+func (d *DistinctSketch) ToProto() ([]byte, error) {
+	return d.hll.MarshalBinary()
+}
+
+func DistinctSketchFromProto(buf []byte) (*DistinctSketch, error) {
+	d := &DistinctSketch{hll: hyperloglog.New()}
+	if err := d.hll.UnmarshalBinary(buf); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
@@ -0,0 +1,249 @@
+package logql
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"golang.org/x/exp/maps"
+
+	"github.com/grafana/loki/v3/pkg/iter"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/log"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/logqlmodel"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/metadata"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase/definitions"
+)
+
+// SortAndLimit returns the sort_by stage of a log query, if any, and the
+// maximum number of entries it returns which is the smallest of the limit of
+// the request and the limit stage.
+func SortAndLimit(expr syntax.LogSelectorExpr, limit uint32) (*log.SortBy, uint32) {
+	sortBy, limitExpr := syntax.SortStages(expr)
+	if limitExpr != nil && limitExpr.Limit < limit {
+		limit = limitExpr.Limit
+	}
+	if sortBy == nil {
+		return nil, limit
+	}
+	return log.NewSortBy(sortBy.Label, sortBy.Desc), limit
+}
+
+// readSortedStreams reads the first size entries of the iterator in the order
+// of the sort_by stage. Entries with the same sort key are ordered by the
+// direction of the query.
+func readSortedStreams(i iter.EntryIterator, sortBy *log.SortBy, size uint32, dir logproto.Direction) (logqlmodel.Streams, error) {
+	top := newTopEntries(sortBy, size, dir)
+	for i.Next() {
+		top.add(i.Labels(), i.Entry())
+	}
+	return top.streams(), i.Error()
+}
+
+type sortedEntry struct {
+	labels string
+	entry  logproto.Entry
+	key    log.SortKey
+}
+
+// topEntries keeps the first limit entries in the order of a sort_by stage.
+// It's a heap holding the last of those entries on top, so it can be replaced
+// when a better entry is added.
+// It implements container/heap.Interface solely to use heap.Interface as a
+// library, the heap pkg functions are not intended to otherwise call it.
+type topEntries struct {
+	sortBy  *log.SortBy
+	limit   int
+	forward bool
+	entries []sortedEntry
+
+	metrics map[string]labels.Labels
+}
+
+func newTopEntries(sortBy *log.SortBy, limit uint32, dir logproto.Direction) *topEntries {
+	return &topEntries{
+		sortBy:  sortBy,
+		limit:   int(limit),
+		forward: dir == logproto.FORWARD,
+		metrics: map[string]labels.Labels{},
+	}
+}
+
+func (t *topEntries) add(lbs string, entry logproto.Entry) {
+	if t.limit == 0 {
+		return
+	}
+	e := sortedEntry{labels: lbs, entry: entry, key: t.key(lbs, entry)}
+	if len(t.entries) < t.limit {
+		heap.Push(t, e)
+		return
+	}
+	if t.before(&e, &t.entries[0]) {
+		t.entries[0] = e
+		heap.Fix(t, 0)
+	}
+}
+
+// key returns the sort key of an entry. The sort label is looked up in the
+// labels of the entry, then in its parsed labels and structured metadata when
+// labels are categorized.
+func (t *topEntries) key(lbs string, entry logproto.Entry) log.SortKey {
+	metric, ok := t.metrics[lbs]
+	if !ok {
+		var err error
+		metric, err = syntax.ParseLabels(lbs)
+		if err != nil {
+			metric = labels.EmptyLabels()
+		}
+		t.metrics[lbs] = metric
+	}
+	if v := metric.Get(t.sortBy.Label); v != "" {
+		return t.sortBy.Key(v, true)
+	}
+	for _, adapters := range [][]logproto.LabelAdapter{entry.Parsed, entry.StructuredMetadata} {
+		for _, l := range adapters {
+			if l.Name == t.sortBy.Label {
+				return t.sortBy.Key(l.Value, true)
+			}
+		}
+	}
+	return t.sortBy.Key("", false)
+}
+
+// before reports whether a is ordered before b.
+func (t *topEntries) before(a, b *sortedEntry) bool {
+	if t.sortBy.Less(a.key, b.key) {
+		return true
+	}
+	if t.sortBy.Less(b.key, a.key) {
+		return false
+	}
+	if !a.entry.Timestamp.Equal(b.entry.Timestamp) {
+		return a.entry.Timestamp.Before(b.entry.Timestamp) == t.forward
+	}
+	return a.labels < b.labels
+}
+
+func (t *topEntries) Len() int           { return len(t.entries) }
+func (t *topEntries) Less(i, j int) bool { return t.before(&t.entries[j], &t.entries[i]) }
+func (t *topEntries) Swap(i, j int)      { t.entries[i], t.entries[j] = t.entries[j], t.entries[i] }
+func (t *topEntries) Push(x any)         { t.entries = append(t.entries, x.(sortedEntry)) }
+
+func (t *topEntries) Pop() any {
+	n := len(t.entries)
+	e := t.entries[n-1]
+	t.entries = t.entries[:n-1]
+	return e
+}
+
+// streams returns the entries grouped by stream. Streams are ordered by labels
+// and their entries by timestamp in the direction of the query, like the
+// streams of any other log query.
+func (t *topEntries) streams() logqlmodel.Streams {
+	byLabels := map[string]*logproto.Stream{}
+	for _, e := range t.entries {
+		s, ok := byLabels[e.labels]
+		if !ok {
+			s = &logproto.Stream{Labels: e.labels}
+			byLabels[e.labels] = s
+		}
+		s.Entries = append(s.Entries, e.entry)
+	}
+
+	result := make(logqlmodel.Streams, 0, len(byLabels))
+	for _, s := range byLabels {
+		sort.SliceStable(s.Entries, func(i, j int) bool {
+			if t.forward {
+				return s.Entries[i].Timestamp.Before(s.Entries[j].Timestamp)
+			}
+			return s.Entries[j].Timestamp.Before(s.Entries[i].Timestamp)
+		})
+		result = append(result, *s)
+	}
+	sort.Sort(result)
+	return result
+}
+
+// SortedStreamsAccumulator is the accumulator of log queries using a sort_by
+// stage. Each downstream query returns its first entries in the order of the
+// stage, the accumulator only keeps the first entries across all of them.
+type SortedStreamsAccumulator struct {
+	top *topEntries
+
+	stats    stats.Result        // for accumulating statistics from downstream requests
+	headers  map[string][]string // for accumulating headers from downstream requests
+	warnings map[string]struct{} // for accumulating warnings from downstream requests
+}
+
+func NewSortedStreamsAccumulator(sortBy *log.SortBy, limit uint32, dir logproto.Direction) *SortedStreamsAccumulator {
+	return &SortedStreamsAccumulator{
+		top:      newTopEntries(sortBy, limit, dir),
+		headers:  make(map[string][]string),
+		warnings: make(map[string]struct{}),
+	}
+}
+
+// newLogAccumulator returns the accumulator of a downstream log query.
+func newLogAccumulator(expr syntax.LogSelectorExpr, params Params) Accumulator {
+	sortBy, limit := SortAndLimit(expr, params.Limit())
+	if sortBy != nil {
+		return NewSortedStreamsAccumulator(sortBy, limit, params.Direction())
+	}
+	if dedup := DedupStage(expr); dedup != nil {
+		return NewDedupStreamsAccumulator(dedup, limit, params.Direction())
+	}
+	return NewStreamAccumulator(params)
+}
+
+func (acc *SortedStreamsAccumulator) Accumulate(_ context.Context, x logqlmodel.Result, _ int) error {
+	// See AccumulatedStreams.Accumulate for setting the shard count here.
+	if x.Statistics.Summary.Shards == 0 {
+		x.Statistics.Summary.Shards = 1
+	}
+	acc.stats.Merge(x.Statistics)
+	metadata.ExtendHeaders(acc.headers, x.Headers)
+
+	for _, w := range x.Warnings {
+		acc.warnings[w] = struct{}{}
+	}
+
+	got, ok := x.Data.(logqlmodel.Streams)
+	if !ok {
+		return fmt.Errorf("unexpected response type during response result accumulation. Got (%T), wanted %s", x.Data, logqlmodel.ValueTypeStreams)
+	}
+	for _, s := range got {
+		for _, e := range s.Entries {
+			acc.top.add(s.Labels, e)
+		}
+	}
+	return nil
+}
+
+func (acc *SortedStreamsAccumulator) Result() []logqlmodel.Result {
+	res := logqlmodel.Result{
+		// stats & headers are already aggregated in the context
+		Data:       acc.top.streams(),
+		Statistics: acc.stats,
+		Headers:    make([]*definitions.PrometheusResponseHeader, 0, len(acc.headers)),
+	}
+
+	for name, vals := range acc.headers {
+		res.Headers = append(
+			res.Headers,
+			&definitions.PrometheusResponseHeader{
+				Name:   name,
+				Values: vals,
+			},
+		)
+	}
+
+	warnings := maps.Keys(acc.warnings)
+	sort.Strings(warnings)
+	res.Warnings = warnings
+
+	return []logqlmodel.Result{res}
+}
@@ -0,0 +1,78 @@
+package logql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/log"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/logqlmodel"
+)
+
+func Test_SortAndLimit(t *testing.T) {
+	for _, tc := range []struct {
+		query         string
+		expectedSort  *log.SortBy
+		expectedLimit uint32
+	}{
+		{`{app="foo"}`, nil, 100},
+		{`{app="foo"} | limit 10`, nil, 10},
+		{`{app="foo"} | limit 1000`, nil, 100},
+		{`{app="foo"} | sort_by(duration desc)`, log.NewSortBy("duration", true), 100},
+		{`{app="foo"} | logfmt | sort_by(duration) | limit 50`, log.NewSortBy("duration", false), 50},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := syntax.ParseLogSelector(tc.query, true)
+			require.NoError(t, err)
+			sortBy, limit := SortAndLimit(expr, 100)
+			require.Equal(t, tc.expectedSort, sortBy)
+			require.Equal(t, tc.expectedLimit, limit)
+		})
+	}
+}
+
+func TestSortedStreamsAccumulator(t *testing.T) {
+	entry := func(ts int64, parsed ...logproto.LabelAdapter) logproto.Entry {
+		return logproto.Entry{Timestamp: time.Unix(ts, 0), Line: "line", Parsed: parsed}
+	}
+
+	for _, direction := range []logproto.Direction{logproto.FORWARD, logproto.BACKWARD} {
+		t.Run(direction.String(), func(t *testing.T) {
+			acc := NewSortedStreamsAccumulator(log.NewSortBy("duration", true), 3, direction)
+
+			// entries of the same stream can be returned by different shards
+			// when their sort label is extracted at query time.
+			require.NoError(t, acc.Accumulate(context.Background(), logqlmodel.Result{
+				Data: logqlmodel.Streams{
+					{Labels: `{app="foo", duration="1s"}`, Entries: []logproto.Entry{entry(1), entry(2)}},
+					{Labels: `{app="foo"}`, Entries: []logproto.Entry{entry(3, logproto.LabelAdapter{Name: "duration", Value: "5s"})}},
+				},
+			}, 0))
+			require.NoError(t, acc.Accumulate(context.Background(), logqlmodel.Result{
+				Data: logqlmodel.Streams{
+					{Labels: `{app="foo", duration="2s"}`, Entries: []logproto.Entry{entry(4)}},
+					{Labels: `{app="bar"}`, Entries: []logproto.Entry{entry(5)}},
+				},
+			}, 1))
+
+			res := acc.Result()[0]
+			require.Equal(t, int64(2), res.Statistics.Summary.Shards)
+
+			// the entry of the first stream with the same sort key kept
+			// depends on the direction of the query.
+			kept := entry(1)
+			if direction == logproto.BACKWARD {
+				kept = entry(2)
+			}
+			require.Equal(t, logqlmodel.Streams{
+				{Labels: `{app="foo", duration="1s"}`, Entries: []logproto.Entry{kept}},
+				{Labels: `{app="foo", duration="2s"}`, Entries: []logproto.Entry{entry(4)}},
+				{Labels: `{app="foo"}`, Entries: []logproto.Entry{entry(3, logproto.LabelAdapter{Name: "duration", Value: "5s"})}},
+			}, res.Data)
+		})
+	}
+}
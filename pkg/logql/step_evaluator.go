@@ -7,6 +7,7 @@ import (
 type StepResult interface {
 	SampleVector() promql.Vector
 	QuantileSketchVec() ProbabilisticQuantileVector
+	CountDistinctSketchVec() ProbabilisticCountDistinctVector
 }
 
 type SampleVector promql.Vector
@@ -21,6 +22,10 @@ func (p SampleVector) QuantileSketchVec() ProbabilisticQuantileVector {
 	return ProbabilisticQuantileVector{}
 }
 
+func (p SampleVector) CountDistinctSketchVec() ProbabilisticCountDistinctVector {
+	return ProbabilisticCountDistinctVector{}
+}
+
 // StepEvaluator evaluate a single step of a query.
 type StepEvaluator interface {
 	// while Next returns a promql.Value, the only acceptable types are Scalar and Vector.
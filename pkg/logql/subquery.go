@@ -0,0 +1,132 @@
+package logql
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/prometheus/promql"
+
+	"github.com/grafana/loki/v3/pkg/iter"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+)
+
+// subqueryParams overrides the time range and step of the outer query so the
+// inner query of a subquery is evaluated at the subquery resolution.
+type subqueryParams struct {
+	Params
+	start, end time.Time
+	step       time.Duration
+}
+
+func (p subqueryParams) Start() time.Time    { return p.start }
+func (p subqueryParams) End() time.Time      { return p.end }
+func (p subqueryParams) Step() time.Duration { return p.step }
+
+// newSubqueryParams returns the params of the inner query of a subquery.
+// The inner query is evaluated from the start of the first outer window up to
+// the end of the last one, with steps aligned to the epoch like Prometheus does.
+func newSubqueryParams(e *syntax.SubqueryExpr, q Params) subqueryParams {
+	step := e.Step.Nanoseconds()
+	start := q.Start().Add(-e.Range).Add(-e.Offset).UnixNano()
+	aligned := start - start%step
+	if aligned < start {
+		aligned += step
+	}
+	return subqueryParams{
+		Params: q,
+		start:  time.Unix(0, aligned),
+		end:    q.End().Add(-e.Offset),
+		step:   e.Step,
+	}
+}
+
+// SubqueryEvaluator evaluates a range aggregation over the samples produced by
+// the inner query of a subquery.
+type SubqueryEvaluator struct {
+	StepEvaluator
+	inner StepEvaluator
+}
+
+func newSubqueryEvaluator(
+	ctx context.Context,
+	evFactory SampleEvaluatorFactory,
+	expr *syntax.SubqueryExpr,
+	q Params,
+) (*SubqueryEvaluator, error) {
+	inner, err := evFactory.NewStepEvaluator(ctx, evFactory, expr.Left, newSubqueryParams(expr, q))
+	if err != nil {
+		return nil, err
+	}
+	rangeExpr := &syntax.RangeAggregationExpr{
+		Left: &syntax.LogRange{
+			Interval: expr.Range,
+			Offset:   expr.Offset,
+		},
+		Operation: expr.Operation,
+		Params:    expr.Params,
+		Args:      expr.Args,
+	}
+	it := iter.NewPeekingSampleIterator(newStepEvaluatorSampleIterator(inner))
+	ev, err := newRangeAggEvaluator(it, rangeExpr, q, expr.Offset)
+	if err != nil {
+		return nil, err
+	}
+	return &SubqueryEvaluator{
+		StepEvaluator: ev,
+		inner:         inner,
+	}, nil
+}
+
+func (e *SubqueryEvaluator) Explain(parent Node) {
+	b := parent.Child("Subquery")
+	e.inner.Explain(b)
+}
+
+// stepEvaluatorSampleIterator flattens the vectors of a StepEvaluator into a
+// sample iterator ordered by timestamp.
+type stepEvaluatorSampleIterator struct {
+	ev StepEvaluator
+
+	vec  promql.Vector
+	idx  int
+	curr promql.Sample
+}
+
+func newStepEvaluatorSampleIterator(ev StepEvaluator) iter.SampleIterator {
+	return &stepEvaluatorSampleIterator{ev: ev}
+}
+
+func (it *stepEvaluatorSampleIterator) Next() bool {
+	for it.idx >= len(it.vec) {
+		next, _, r := it.ev.Next()
+		if !next {
+			return false
+		}
+		it.vec = r.SampleVector()
+		it.idx = 0
+	}
+	it.curr = it.vec[it.idx]
+	it.idx++
+	return true
+}
+
+func (it *stepEvaluatorSampleIterator) Labels() string {
+	return it.curr.Metric.String()
+}
+
+func (it *stepEvaluatorSampleIterator) StreamHash() uint64 {
+	return it.curr.Metric.Hash()
+}
+
+func (it *stepEvaluatorSampleIterator) Sample() logproto.Sample {
+	return logproto.Sample{
+		Timestamp: it.curr.T * int64(time.Millisecond),
+		Value:     it.curr.F,
+		Hash:      it.curr.Metric.Hash(),
+	}
+}
+
+func (it *stepEvaluatorSampleIterator) Error() error { return it.ev.Error() }
+
+func (it *stepEvaluatorSampleIterator) Close() error { return it.ev.Close() }
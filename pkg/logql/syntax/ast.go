@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -142,10 +143,10 @@ func (m MultiStageExpr) reorderStages() []StageExpr {
 		switch f := s.(type) {
 		case *LineFilterExpr:
 			filters = append(filters, f)
-		case *LineFmtExpr:
-			// line_format modifies the contents of the line so any line filter
-			// originally after a line_format must still be after the same
-			// line_format.
+		case *LineFmtExpr, *JSONFmtExpr:
+			// line_format and json_format modify the contents of the line so
+			// any line filter originally after them must still be after the
+			// same stage.
 
 			rest = append(rest, f)
 
@@ -319,7 +320,7 @@ func (e *PipelineExpr) Pipeline() (log.Pipeline, error) {
 func (e *PipelineExpr) HasFilter() bool {
 	for _, p := range e.MultiStages {
 		switch p.(type) {
-		case *LineFilterExpr, *LabelFilterExpr:
+		case *LineFilterExpr, *LabelFilterExpr, *SearchExpr:
 			return true
 		default:
 			continue
@@ -328,6 +329,88 @@ func (e *PipelineExpr) HasFilter() bool {
 	return false
 }
 
+// JoinExpr correlates the entries of two log queries on the values of a set of
+// labels, e.g. {app="gateway"} | json | join on(trace_id) within 5m {app="backend"} | json.
+// Entries of the left query are kept when an entry of the right query with the
+// same label values exists within the given duration, and are returned with the
+// labels of both sides.
+type JoinExpr struct {
+	Left   LogSelectorExpr
+	Right  LogSelectorExpr
+	On     []string
+	Within time.Duration
+	err    error
+	implicit
+}
+
+func newJoinExpr(left LogSelectorExpr, on []string, within time.Duration, right LogSelectorExpr) *JoinExpr {
+	e := &JoinExpr{
+		Left:   left,
+		Right:  right,
+		On:     on,
+		Within: within,
+	}
+	if err := e.validate(); err != nil {
+		e.err = logqlmodel.NewParseError(err.Error(), 0, 0)
+	}
+	return e
+}
+
+func (e *JoinExpr) validate() error {
+	if len(e.On) == 0 {
+		return fmt.Errorf("%s requires at least one label", OpJoin)
+	}
+	if e.Within <= 0 {
+		return fmt.Errorf("%s duration must be positive", OpWithin)
+	}
+	return nil
+}
+
+func (e *JoinExpr) isLogSelectorExpr() {}
+
+// Shardable returns false: entries of both sides need to be correlated
+// regardless of the shard they belong to.
+func (e *JoinExpr) Shardable(_ bool) bool { return false }
+
+func (e *JoinExpr) Walk(f WalkFn) {
+	f(e)
+	walkAll(f, e.Left, e.Right)
+}
+
+func (e *JoinExpr) Accept(v RootVisitor) { v.VisitJoin(e) }
+
+// Matchers returns the matchers of the left side of the join which selects
+// the returned streams.
+func (e *JoinExpr) Matchers() []*labels.Matcher {
+	return e.Left.Matchers()
+}
+
+// Pipeline returns an error as a join is evaluated on the results of two
+// distinct queries and can't be expressed as a single pipeline.
+func (e *JoinExpr) Pipeline() (log.Pipeline, error) {
+	return nil, fmt.Errorf("%s cannot be evaluated as a single pipeline", OpJoin)
+}
+
+// HasFilter returns true as a join drops the entries without a match.
+func (e *JoinExpr) HasFilter() bool {
+	return true
+}
+
+func (e *JoinExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(e.Left.String())
+	sb.WriteString(" ")
+	sb.WriteString(e.joinString())
+	sb.WriteString(" ")
+	sb.WriteString(e.Right.String())
+	return sb.String()
+}
+
+// joinString returns the join stage without its sides, e.g. | join on(trace_id) within 5m.
+func (e *JoinExpr) joinString() string {
+	return fmt.Sprintf("%s %s %s(%s) %s %s", OpPipe, OpJoin, OpOn, strings.Join(e.On, ","), OpWithin, model.Duration(e.Within))
+}
+
 type LineFilter struct {
 	Ty    log.LineMatchType
 	Match string
@@ -363,10 +446,20 @@ func newLineFilterExpr(ty log.LineMatchType, op, match string) *LineFilterExpr {
 	}
 }
 
+// isPositiveLineMatch returns whether the line filter type keeps the matching
+// lines, as opposed to the negated types.
+func isPositiveLineMatch(ty log.LineMatchType) bool {
+	switch ty {
+	case log.LineMatchEqual, log.LineMatchRegexp, log.LineMatchPattern, log.LineMatchEqualFold, log.LineMatchWord:
+		return true
+	}
+	return false
+}
+
 func newOrLineFilter(left, right *LineFilterExpr) *LineFilterExpr {
 	right.Ty = left.Ty
 
-	if left.Ty == log.LineMatchEqual || left.Ty == log.LineMatchRegexp || left.Ty == log.LineMatchPattern {
+	if isPositiveLineMatch(left.Ty) {
 		left.Or = right
 		right.IsOrChild = true
 		return left
@@ -391,7 +484,7 @@ func newOrLineFilter(left, right *LineFilterExpr) *LineFilterExpr {
 func newNestedLineFilterExpr(left *LineFilterExpr, right *LineFilterExpr) *LineFilterExpr {
 	// NOTE: When parsing "or" chains in linefilter, particularly variations of NOT filters (!= or !~), we need to transform
 	// say (!= "foo" or "bar "baz") => (!="foo" != "bar" != "baz")
-	if right.Or != nil && !(right.Ty == log.LineMatchEqual || right.Ty == log.LineMatchRegexp || right.Ty == log.LineMatchPattern) {
+	if right.Or != nil && !isPositiveLineMatch(right.Ty) {
 		right.Or.IsOrChild = false
 		tmp := right.Or
 		right.Or = nil
@@ -630,6 +723,12 @@ func newLabelParserExpr(op, param string) *LabelParserExpr {
 			panic(logqlmodel.NewParseError(fmt.Sprintf("invalid regexp parser: %s", err.Error()), 0, 0))
 		}
 	}
+	if op == OpParserTypeExtract {
+		_, err := log.NewExtractParser(param)
+		if err != nil {
+			panic(logqlmodel.NewParseError(fmt.Sprintf("invalid extract parser: %s", err.Error()), 0, 0))
+		}
+	}
 	if op == OpParserTypePattern {
 		_, err := log.NewPatternParser(param)
 		if err != nil {
@@ -657,6 +756,8 @@ func (e *LabelParserExpr) Stage() (log.Stage, error) {
 		return log.NewJSONParser(), nil
 	case OpParserTypeRegexp:
 		return log.NewRegexpParser(e.Param)
+	case OpParserTypeExtract:
+		return log.NewExtractParser(e.Param)
 	case OpParserTypeUnpack:
 		return log.NewUnpackParser(), nil
 	case OpParserTypePattern:
@@ -675,7 +776,7 @@ func (e *LabelParserExpr) String() string {
 		sb.WriteString(" ")
 		sb.WriteString(strconv.Quote(e.Param))
 	}
-	if (e.Op == OpParserTypeRegexp || e.Op == OpParserTypePattern) && e.Param == "" {
+	if (e.Op == OpParserTypeRegexp || e.Op == OpParserTypeExtract || e.Op == OpParserTypePattern) && e.Param == "" {
 		sb.WriteString(" \"\"")
 	}
 	return sb.String()
@@ -833,6 +934,299 @@ func (e *KeepLabelsExpr) Walk(f WalkFn) { f(e) }
 
 func (e *KeepLabelsExpr) Accept(v RootVisitor) { v.VisitKeepLabel(e) }
 
+// SortByExpr orders the entries of a log query by the value of a label.
+type SortByExpr struct {
+	Label string
+	Desc  bool
+	implicit
+}
+
+func newSortByExpr(label, order string) *SortByExpr {
+	switch strings.ToLower(order) {
+	case "", OpSortAsc:
+		return &SortByExpr{Label: label}
+	case OpSortDesc:
+		return &SortByExpr{Label: label, Desc: true}
+	default:
+		panic(logqlmodel.NewParseError(fmt.Sprintf("invalid %s order %q, expected %s or %s", OpSortBy, order, OpSortAsc, OpSortDesc), 0, 0))
+	}
+}
+
+func (*SortByExpr) isStageExpr() {}
+
+func (e *SortByExpr) Shardable(_ bool) bool { return true }
+
+func (e *SortByExpr) Stage() (log.Stage, error) {
+	return log.NewSortBy(e.Label, e.Desc), nil
+}
+
+func (e *SortByExpr) String() string {
+	order := OpSortAsc
+	if e.Desc {
+		order = OpSortDesc
+	}
+	return fmt.Sprintf("%s %s(%s %s)", OpPipe, OpSortBy, e.Label, order)
+}
+
+func (e *SortByExpr) Walk(f WalkFn) { f(e) }
+
+func (e *SortByExpr) Accept(v RootVisitor) { v.VisitSortBy(e) }
+
+// LimitExpr limits the number of entries returned by a log query.
+type LimitExpr struct {
+	Limit uint32
+	implicit
+}
+
+func newLimitExpr(limit string) *LimitExpr {
+	n, err := strconv.ParseUint(limit, 10, 32)
+	if err != nil || n == 0 {
+		panic(logqlmodel.NewParseError(fmt.Sprintf("invalid %s %q, expected a positive integer", OpLimit, limit), 0, 0))
+	}
+	return &LimitExpr{Limit: uint32(n)}
+}
+
+func (*LimitExpr) isStageExpr() {}
+
+func (e *LimitExpr) Shardable(_ bool) bool { return true }
+
+func (e *LimitExpr) Stage() (log.Stage, error) {
+	return log.NoopStage, nil
+}
+
+func (e *LimitExpr) String() string {
+	return fmt.Sprintf("%s %s %d", OpPipe, OpLimit, e.Limit)
+}
+
+func (e *LimitExpr) Walk(f WalkFn) { f(e) }
+
+func (e *LimitExpr) Accept(v RootVisitor) { v.VisitLimit(e) }
+
+// DedupExpr collapses identical lines of a log query into a single entry, e.g.
+// `| dedup by (pod) within 1m`.
+type DedupExpr struct {
+	Labels []string
+	Within time.Duration
+	implicit
+}
+
+func newDedupExpr(labels []string, within time.Duration) *DedupExpr {
+	if within < 0 {
+		panic(logqlmodel.NewParseError(fmt.Sprintf("%s %s duration must be positive", OpDedup, OpWithin), 0, 0))
+	}
+	return &DedupExpr{Labels: labels, Within: within}
+}
+
+func (*DedupExpr) isStageExpr() {}
+
+func (e *DedupExpr) Shardable(_ bool) bool { return true }
+
+func (e *DedupExpr) Stage() (log.Stage, error) {
+	return log.NewDedup(e.Labels, e.Within), nil
+}
+
+func (e *DedupExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s", OpPipe, OpDedup))
+	if len(e.Labels) > 0 {
+		sb.WriteString(fmt.Sprintf(" by (%s)", strings.Join(e.Labels, ",")))
+	}
+	if e.Within > 0 {
+		sb.WriteString(fmt.Sprintf(" %s %s", OpWithin, model.Duration(e.Within)))
+	}
+	return sb.String()
+}
+
+func (e *DedupExpr) Walk(f WalkFn) { f(e) }
+
+func (e *DedupExpr) Accept(v RootVisitor) { v.VisitDedup(e) }
+
+// GeoIPExpr adds the geolocation labels of the IP address held by a label,
+// e.g. `| geoip(src_ip)`.
+type GeoIPExpr struct {
+	Label string
+	implicit
+
+	enrichment *log.Enrichment
+}
+
+func newGeoIPExpr(label string) *GeoIPExpr {
+	return &GeoIPExpr{Label: label}
+}
+
+func (*GeoIPExpr) isStageExpr() {}
+
+func (e *GeoIPExpr) Shardable(_ bool) bool { return true }
+
+func (e *GeoIPExpr) Stage() (log.Stage, error) {
+	return log.NewGeoIP(e.Label, e.enrichment)
+}
+
+func (e *GeoIPExpr) String() string {
+	return fmt.Sprintf("%s %s(%s)", OpPipe, OpGeoIP, e.Label)
+}
+
+func (e *GeoIPExpr) Walk(f WalkFn) { f(e) }
+
+func (e *GeoIPExpr) Accept(v RootVisitor) { v.VisitGeoIP(e) }
+
+// CIDRLabelExpr adds the name of the network of a ranges file containing the
+// IP address held by a label, e.g. `| cidr_label(src_ip, "ranges.yaml")`.
+type CIDRLabelExpr struct {
+	Label string
+	File  string
+	implicit
+
+	enrichment *log.Enrichment
+}
+
+func newCIDRLabelExpr(label, file string) *CIDRLabelExpr {
+	return &CIDRLabelExpr{Label: label, File: file}
+}
+
+func (*CIDRLabelExpr) isStageExpr() {}
+
+func (e *CIDRLabelExpr) Shardable(_ bool) bool { return true }
+
+func (e *CIDRLabelExpr) Stage() (log.Stage, error) {
+	return log.NewCIDRLabel(e.Label, e.File, e.enrichment)
+}
+
+func (e *CIDRLabelExpr) String() string {
+	return fmt.Sprintf("%s %s(%s, %s)", OpPipe, OpCIDRLabel, e.Label, strconv.Quote(e.File))
+}
+
+func (e *CIDRLabelExpr) Walk(f WalkFn) { f(e) }
+
+func (e *CIDRLabelExpr) Accept(v RootVisitor) { v.VisitCIDRLabel(e) }
+
+// WithEnrichment returns a copy of expr whose geoip and cidr_label stages use
+// the given enrichment data. expr is returned as is when it has no such stage,
+// the expressions being shared by concurrent queries.
+func WithEnrichment[T Expr](expr T, enrichment *log.Enrichment) T {
+	var found bool
+	expr.Walk(func(e Expr) {
+		switch e.(type) {
+		case *GeoIPExpr, *CIDRLabelExpr:
+			found = true
+		}
+	})
+	if !found {
+		return expr
+	}
+
+	copied := MustClone[T](expr)
+	copied.Walk(func(e Expr) {
+		switch stage := e.(type) {
+		case *GeoIPExpr:
+			stage.enrichment = enrichment
+		case *CIDRLabelExpr:
+			stage.enrichment = enrichment
+		}
+	})
+	return copied
+}
+
+// SearchExpr is a full-text filter keeping the entries containing all the
+// terms of a search, e.g. `|* "connection refused"`. The terms are searched in
+// the line and, with the --metadata flag, in the values of the structured
+// metadata and parsed labels.
+type SearchExpr struct {
+	Search   string
+	Metadata bool
+	implicit
+}
+
+func newSearchExpr(flags []string, search string) *SearchExpr {
+	e := &SearchExpr{Search: search}
+	for _, f := range flags {
+		if f != OpSearchMetadata {
+			panic(logqlmodel.NewParseError(fmt.Sprintf("invalid flag %s for full-text filter, expected %s", f, OpSearchMetadata), 0, 0))
+		}
+		e.Metadata = true
+	}
+	if len(log.SearchTerms(search)) == 0 {
+		panic(logqlmodel.NewParseError("full-text filter requires at least one term", 0, 0))
+	}
+	return e
+}
+
+func (*SearchExpr) isStageExpr() {}
+
+func (e *SearchExpr) Shardable(_ bool) bool { return true }
+
+func (e *SearchExpr) Stage() (log.Stage, error) {
+	return log.NewSearch(e.Search, e.Metadata), nil
+}
+
+func (e *SearchExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(OpSearch)
+	sb.WriteString(" ")
+	if e.Metadata {
+		sb.WriteString(OpSearchMetadata)
+		sb.WriteString(" ")
+	}
+	sb.WriteString(strconv.Quote(e.Search))
+	return sb.String()
+}
+
+func (e *SearchExpr) Walk(f WalkFn) { f(e) }
+
+func (e *SearchExpr) Accept(v RootVisitor) { v.VisitSearch(e) }
+
+// DedupStage returns the dedup stage of a log query, if any.
+func DedupStage(expr LogSelectorExpr) *DedupExpr {
+	var dedup *DedupExpr
+	expr.Walk(func(e Expr) {
+		if d, ok := e.(*DedupExpr); ok {
+			dedup = d
+		}
+	})
+	return dedup
+}
+
+// SortStages returns the sort_by and limit stages of a log query, if any.
+func SortStages(expr LogSelectorExpr) (sortBy *SortByExpr, limit *LimitExpr) {
+	expr.Walk(func(e Expr) {
+		switch s := e.(type) {
+		case *SortByExpr:
+			sortBy = s
+		case *LimitExpr:
+			limit = s
+		}
+	})
+	return sortBy, limit
+}
+
+// MacroExpr is the invocation of a named macro of the tenant, e.g. `| @parse_nginx()`.
+// Macros are replaced by the stages of their body by ExpandMacros before the
+// query is executed.
+type MacroExpr struct {
+	Name string
+	implicit
+}
+
+func newMacroExpr(name string) *MacroExpr {
+	return &MacroExpr{Name: name}
+}
+
+func (*MacroExpr) isStageExpr() {}
+
+func (e *MacroExpr) Shardable(_ bool) bool { return false }
+
+func (e *MacroExpr) Stage() (log.Stage, error) {
+	return nil, fmt.Errorf("macro %s%s() has not been expanded: macros are only supported by the queries sent to the query frontend", OpMacro, e.Name)
+}
+
+func (e *MacroExpr) String() string {
+	return fmt.Sprintf("%s %s%s()", OpPipe, OpMacro, e.Name)
+}
+
+func (e *MacroExpr) Walk(f WalkFn) { f(e) }
+
+func (e *MacroExpr) Accept(v RootVisitor) { v.VisitMacro(e) }
+
 func (*LineFmtExpr) isStageExpr() {}
 
 func (e *LineFmtExpr) Shardable(_ bool) bool { return true }
@@ -849,6 +1243,54 @@ func (e *LineFmtExpr) String() string {
 	return fmt.Sprintf("%s %s %s", OpPipe, OpFmtLine, strconv.Quote(e.Value))
 }
 
+// JSONFmtExpr replaces the line with a JSON object built from labels, e.g.
+// `| json_format level, "http.status"=status`.
+type JSONFmtExpr struct {
+	Fields []log.JSONField
+	implicit
+}
+
+func newJSONFmtExpr(fields []string) *JSONFmtExpr {
+	e := &JSONFmtExpr{}
+	for i := 0; i < len(fields); i += 2 {
+		e.Fields = append(e.Fields, log.JSONField{Key: fields[i], Label: fields[i+1]})
+	}
+	if _, err := log.NewJSONFormatter(e.Fields); err != nil {
+		panic(logqlmodel.NewParseError(err.Error(), 0, 0))
+	}
+	return e
+}
+
+func (*JSONFmtExpr) isStageExpr() {}
+
+func (e *JSONFmtExpr) Shardable(_ bool) bool { return true }
+
+func (e *JSONFmtExpr) Walk(f WalkFn) { f(e) }
+
+func (e *JSONFmtExpr) Accept(v RootVisitor) { v.VisitJSONFmt(e) }
+
+func (e *JSONFmtExpr) Stage() (log.Stage, error) {
+	return log.NewJSONFormatter(e.Fields)
+}
+
+func (e *JSONFmtExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s", OpPipe, OpFmtJSON))
+	for i, f := range e.Fields {
+		if i == 0 {
+			sb.WriteString(" ")
+		} else {
+			sb.WriteString(", ")
+		}
+		if f.Key != f.Label {
+			sb.WriteString(strconv.Quote(f.Key))
+			sb.WriteString("=")
+		}
+		sb.WriteString(f.Label)
+	}
+	return sb.String()
+}
+
 type LabelFmtExpr struct {
 	Formats []log.LabelFmt
 	implicit
@@ -935,79 +1377,270 @@ func (j *JSONExpressionParser) String() string {
 	return sb.String()
 }
 
-type internedStringSet map[string]struct {
-	s  string
-	ok bool
-}
-
-type LogfmtExpressionParser struct {
-	Expressions       []log.LabelExtractionExpr
-	Strict, KeepEmpty bool
+// CSVParserExpr extracts the fields of delimiter-separated lines, e.g.
+// `| csv "ip,method,status" delimiter="\t", quote=""`.
+type CSVParserExpr struct {
+	Columns   []string
+	Delimiter byte
+	Quote     byte
 
 	implicit
 }
 
-func newLogfmtExpressionParser(expressions []log.LabelExtractionExpr, flags []string) *LogfmtExpressionParser {
-	e := LogfmtExpressionParser{
-		Expressions: expressions,
+func newCSVParserExpr(columns string, options []log.LabelExtractionExpr) *CSVParserExpr {
+	e := &CSVParserExpr{Delimiter: ',', Quote: '"'}
+	if columns != "" {
+		for _, c := range strings.Split(columns, ",") {
+			e.Columns = append(e.Columns, strings.TrimSpace(c))
+		}
 	}
-
-	for _, flag := range flags {
-		switch flag {
-		case OpStrict:
-			e.Strict = true
-		case OpKeepEmpty:
-			e.KeepEmpty = true
+	for _, o := range options {
+		if len(o.Expression) > 1 || o.Identifier == o.Expression {
+			panic(logqlmodel.NewParseError(fmt.Sprintf("invalid csv parser option %s, expected a single character", o.Identifier), 0, 0))
+		}
+		var c byte
+		if len(o.Expression) == 1 {
+			c = o.Expression[0]
+		}
+		switch o.Identifier {
+		case OpCSVDelimiter:
+			e.Delimiter = c
+		case OpCSVQuote:
+			e.Quote = c
+		default:
+			panic(logqlmodel.NewParseError(fmt.Sprintf("unknown csv parser option %s", o.Identifier), 0, 0))
 		}
 	}
-
-	return &e
+	if _, err := e.Stage(); err != nil {
+		panic(logqlmodel.NewParseError(fmt.Sprintf("invalid csv parser: %s", err.Error()), 0, 0))
+	}
+	return e
 }
 
-func (*LogfmtExpressionParser) isStageExpr() {}
+func (*CSVParserExpr) isStageExpr() {}
 
-func (l *LogfmtExpressionParser) Shardable(_ bool) bool { return true }
+func (e *CSVParserExpr) Shardable(_ bool) bool { return true }
 
-func (l *LogfmtExpressionParser) Walk(f WalkFn) { f(l) }
+func (e *CSVParserExpr) Walk(f WalkFn) { f(e) }
 
-func (l *LogfmtExpressionParser) Accept(v RootVisitor) { v.VisitLogfmtExpressionParser(l) }
+func (e *CSVParserExpr) Accept(v RootVisitor) { v.VisitCSVParser(e) }
 
-func (l *LogfmtExpressionParser) Stage() (log.Stage, error) {
-	return log.NewLogfmtExpressionParser(l.Expressions, l.Strict)
+func (e *CSVParserExpr) Stage() (log.Stage, error) {
+	return log.NewCSVParser(e.Columns, e.Delimiter, e.Quote)
 }
 
-func (l *LogfmtExpressionParser) String() string {
+func (e *CSVParserExpr) String() string {
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("%s %s ", OpPipe, OpParserTypeLogfmt))
-	if l.Strict {
-		sb.WriteString(OpStrict)
+	sb.WriteString(fmt.Sprintf("%s %s", OpPipe, OpParserTypeCSV))
+	if len(e.Columns) > 0 {
 		sb.WriteString(" ")
+		sb.WriteString(strconv.Quote(strings.Join(e.Columns, ",")))
 	}
 
-	if l.KeepEmpty {
-		sb.WriteString(OpKeepEmpty)
-		sb.WriteString(" ")
+	var options []string
+	if e.Delimiter != ',' {
+		options = append(options, OpCSVDelimiter+"="+strconv.Quote(string(e.Delimiter)))
 	}
-
-	for i, exp := range l.Expressions {
-		sb.WriteString(exp.Identifier)
-		sb.WriteString("=")
-		sb.WriteString(strconv.Quote(exp.Expression))
-
-		if i+1 != len(l.Expressions) {
-			sb.WriteString(",")
+	if e.Quote != '"' {
+		quote := ""
+		if e.Quote != 0 {
+			quote = string(e.Quote)
 		}
+		options = append(options, OpCSVQuote+"="+strconv.Quote(quote))
+	}
+	if len(options) > 0 {
+		sb.WriteString(" ")
+		sb.WriteString(strings.Join(options, ","))
 	}
 	return sb.String()
 }
 
-func mustNewMatcher(t labels.MatchType, n, v string) *labels.Matcher {
-	m, err := labels.NewMatcher(t, n, v)
-	if err != nil {
-		panic(logqlmodel.NewParseError(err.Error(), 0, 0))
-	}
-	return m
-}
+// SDParserExpr extracts the parameters of the structured data of RFC5424 syslog
+// lines, e.g. `| sd --strict iut="exampleSDID@32473.iut"`.
+type SDParserExpr struct {
+	Expressions       []log.LabelExtractionExpr
+	Strict, KeepEmpty bool
+
+	implicit
+}
+
+func newSDParserExpr(flags []string, expressions []log.LabelExtractionExpr) *SDParserExpr {
+	e := &SDParserExpr{Expressions: expressions}
+	e.Strict, e.KeepEmpty = parseParserFlags(flags)
+	if _, err := e.Stage(); err != nil {
+		panic(logqlmodel.NewParseError(fmt.Sprintf("invalid sd parser: %s", err.Error()), 0, 0))
+	}
+	return e
+}
+
+func (*SDParserExpr) isStageExpr() {}
+
+func (e *SDParserExpr) Shardable(_ bool) bool { return true }
+
+func (e *SDParserExpr) Walk(f WalkFn) { f(e) }
+
+func (e *SDParserExpr) Accept(v RootVisitor) { v.VisitSDParser(e) }
+
+func (e *SDParserExpr) Stage() (log.Stage, error) {
+	if len(e.Expressions) > 0 {
+		return log.NewSDExpressionParser(e.Expressions, e.Strict, e.KeepEmpty)
+	}
+	return log.NewSDParser(e.Strict, e.KeepEmpty), nil
+}
+
+func (e *SDParserExpr) String() string {
+	return parserString(OpParserTypeSD, e.Strict, e.KeepEmpty, e.Expressions)
+}
+
+// XMLParserExpr extracts the elements and attributes of XML lines, e.g.
+// `| xml status="event.status", id="event.@id"`.
+type XMLParserExpr struct {
+	Expressions       []log.LabelExtractionExpr
+	Strict, KeepEmpty bool
+
+	implicit
+}
+
+func newXMLParserExpr(flags []string, expressions []log.LabelExtractionExpr) *XMLParserExpr {
+	e := &XMLParserExpr{Expressions: expressions}
+	e.Strict, e.KeepEmpty = parseParserFlags(flags)
+	if _, err := e.Stage(); err != nil {
+		panic(logqlmodel.NewParseError(fmt.Sprintf("invalid xml parser: %s", err.Error()), 0, 0))
+	}
+	return e
+}
+
+func (*XMLParserExpr) isStageExpr() {}
+
+func (e *XMLParserExpr) Shardable(_ bool) bool { return true }
+
+func (e *XMLParserExpr) Walk(f WalkFn) { f(e) }
+
+func (e *XMLParserExpr) Accept(v RootVisitor) { v.VisitXMLParser(e) }
+
+func (e *XMLParserExpr) Stage() (log.Stage, error) {
+	if len(e.Expressions) > 0 {
+		return log.NewXMLExpressionParser(e.Expressions, e.Strict, e.KeepEmpty)
+	}
+	return log.NewXMLParser(e.Strict, e.KeepEmpty), nil
+}
+
+func (e *XMLParserExpr) String() string {
+	return parserString(OpParserTypeXML, e.Strict, e.KeepEmpty, e.Expressions)
+}
+
+func parseParserFlags(flags []string) (strict, keepEmpty bool) {
+	for _, f := range flags {
+		switch f {
+		case OpStrict:
+			strict = true
+		case OpKeepEmpty:
+			keepEmpty = true
+		}
+	}
+	return strict, keepEmpty
+}
+
+// parserString returns the string of a parser stage accepting flags and label
+// extraction expressions, e.g. `| xml --strict status="event.status"`.
+func parserString(op string, strict, keepEmpty bool, expressions []log.LabelExtractionExpr) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s", OpPipe, op))
+	if strict {
+		sb.WriteString(" ")
+		sb.WriteString(OpStrict)
+	}
+	if keepEmpty {
+		sb.WriteString(" ")
+		sb.WriteString(OpKeepEmpty)
+	}
+
+	for i, exp := range expressions {
+		if i == 0 {
+			sb.WriteString(" ")
+		} else {
+			sb.WriteString(",")
+		}
+		sb.WriteString(exp.Identifier)
+		sb.WriteString("=")
+		sb.WriteString(strconv.Quote(exp.Expression))
+	}
+	return sb.String()
+}
+
+type internedStringSet map[string]struct {
+	s  string
+	ok bool
+}
+
+type LogfmtExpressionParser struct {
+	Expressions       []log.LabelExtractionExpr
+	Strict, KeepEmpty bool
+
+	implicit
+}
+
+func newLogfmtExpressionParser(expressions []log.LabelExtractionExpr, flags []string) *LogfmtExpressionParser {
+	e := LogfmtExpressionParser{
+		Expressions: expressions,
+	}
+
+	for _, flag := range flags {
+		switch flag {
+		case OpStrict:
+			e.Strict = true
+		case OpKeepEmpty:
+			e.KeepEmpty = true
+		}
+	}
+
+	return &e
+}
+
+func (*LogfmtExpressionParser) isStageExpr() {}
+
+func (l *LogfmtExpressionParser) Shardable(_ bool) bool { return true }
+
+func (l *LogfmtExpressionParser) Walk(f WalkFn) { f(l) }
+
+func (l *LogfmtExpressionParser) Accept(v RootVisitor) { v.VisitLogfmtExpressionParser(l) }
+
+func (l *LogfmtExpressionParser) Stage() (log.Stage, error) {
+	return log.NewLogfmtExpressionParser(l.Expressions, l.Strict)
+}
+
+func (l *LogfmtExpressionParser) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s ", OpPipe, OpParserTypeLogfmt))
+	if l.Strict {
+		sb.WriteString(OpStrict)
+		sb.WriteString(" ")
+	}
+
+	if l.KeepEmpty {
+		sb.WriteString(OpKeepEmpty)
+		sb.WriteString(" ")
+	}
+
+	for i, exp := range l.Expressions {
+		sb.WriteString(exp.Identifier)
+		sb.WriteString("=")
+		sb.WriteString(strconv.Quote(exp.Expression))
+
+		if i+1 != len(l.Expressions) {
+			sb.WriteString(",")
+		}
+	}
+	return sb.String()
+}
+
+func mustNewMatcher(t labels.MatchType, n, v string) *labels.Matcher {
+	m, err := labels.NewMatcher(t, n, v)
+	if err != nil {
+		panic(logqlmodel.NewParseError(err.Error(), 0, 0))
+	}
+	return m
+}
 
 // simplify will return an equals matcher if there is a regex matching a literal
 func simplify(typ labels.MatchType, name string, reg *syntax.Regexp) (*labels.Matcher, bool) {
@@ -1037,16 +1670,47 @@ type UnwrapExpr struct {
 	Identifier string
 	Operation  string
 
+	// Labels are set when several labels are unwrapped, each one producing its
+	// own series identified by the __unwrapped__ label. Identifier and
+	// Operation then hold the first one.
+	Labels []UnwrapLabel
+
 	PostFilters []log.LabelFilterer
 }
 
+// UnwrapLabel is a label unwrapped by an UnwrapExpr, with its conversion function.
+type UnwrapLabel struct {
+	Identifier string
+	Operation  string
+}
+
+func (l UnwrapLabel) String() string {
+	if l.Operation != "" {
+		return fmt.Sprintf("%s(%s)", l.Operation, l.Identifier)
+	}
+	return l.Identifier
+}
+
+// IsMultiple returns true when several labels are unwrapped.
+func (u UnwrapExpr) IsMultiple() bool {
+	return len(u.Labels) > 1
+}
+
+// labels returns the unwrapped labels, as written after the unwrap keyword.
+func (u UnwrapExpr) labels() string {
+	if !u.IsMultiple() {
+		return UnwrapLabel{Identifier: u.Identifier, Operation: u.Operation}.String()
+	}
+	labels := make([]string, 0, len(u.Labels))
+	for _, l := range u.Labels {
+		labels = append(labels, l.String())
+	}
+	return "(" + strings.Join(labels, ", ") + ")"
+}
+
 func (u UnwrapExpr) String() string {
 	var sb strings.Builder
-	if u.Operation != "" {
-		sb.WriteString(fmt.Sprintf(" %s %s %s(%s)", OpPipe, OpUnwrap, u.Operation, u.Identifier))
-	} else {
-		sb.WriteString(fmt.Sprintf(" %s %s %s", OpPipe, OpUnwrap, u.Identifier))
-	}
+	sb.WriteString(fmt.Sprintf(" %s %s %s", OpPipe, OpUnwrap, u.labels()))
 	for _, f := range u.PostFilters {
 		sb.WriteString(fmt.Sprintf(" %s %s", OpPipe, f))
 	}
@@ -1062,6 +1726,24 @@ func newUnwrapExpr(id string, operation string) *UnwrapExpr {
 	return &UnwrapExpr{Identifier: id, Operation: operation}
 }
 
+// newMultiUnwrapExpr creates an UnwrapExpr from pairs of conversion function
+// and label.
+func newMultiUnwrapExpr(pairs []string) *UnwrapExpr {
+	if len(pairs) == 2 {
+		return newUnwrapExpr(pairs[1], pairs[0])
+	}
+	u := &UnwrapExpr{Identifier: pairs[1], Operation: pairs[0]}
+	for i := 0; i < len(pairs); i += 2 {
+		for _, l := range u.Labels {
+			if l.Identifier == pairs[i+1] {
+				panic(logqlmodel.NewParseError(fmt.Sprintf("label %s unwrapped more than once", l.Identifier), 0, 0))
+			}
+		}
+		u.Labels = append(u.Labels, UnwrapLabel{Identifier: pairs[i+1], Operation: pairs[i]})
+	}
+	return u
+}
+
 type LogRange struct {
 	Left     LogSelectorExpr
 	Interval time.Duration
@@ -1158,25 +1840,35 @@ const (
 	OpTypeSortDesc = "sort_desc"
 
 	// range vector ops
-	OpRangeTypeCount       = "count_over_time"
-	OpRangeTypeRate        = "rate"
-	OpRangeTypeRateCounter = "rate_counter"
-	OpRangeTypeBytes       = "bytes_over_time"
-	OpRangeTypeBytesRate   = "bytes_rate"
-	OpRangeTypeAvg         = "avg_over_time"
-	OpRangeTypeSum         = "sum_over_time"
-	OpRangeTypeMin         = "min_over_time"
-	OpRangeTypeMax         = "max_over_time"
-	OpRangeTypeStdvar      = "stdvar_over_time"
-	OpRangeTypeStddev      = "stddev_over_time"
-	OpRangeTypeQuantile    = "quantile_over_time"
-	OpRangeTypeFirst       = "first_over_time"
-	OpRangeTypeLast        = "last_over_time"
-	OpRangeTypeAbsent      = "absent_over_time"
+	OpRangeTypeCount         = "count_over_time"
+	OpRangeTypeRate          = "rate"
+	OpRangeTypeRateCounter   = "rate_counter"
+	OpRangeTypeBytes         = "bytes_over_time"
+	OpRangeTypeBytesRate     = "bytes_rate"
+	OpRangeTypeAvg           = "avg_over_time"
+	OpRangeTypeSum           = "sum_over_time"
+	OpRangeTypeMin           = "min_over_time"
+	OpRangeTypeMax           = "max_over_time"
+	OpRangeTypeStdvar        = "stdvar_over_time"
+	OpRangeTypeStddev        = "stddev_over_time"
+	OpRangeTypeQuantile      = "quantile_over_time"
+	OpRangeTypeFirst         = "first_over_time"
+	OpRangeTypeLast          = "last_over_time"
+	OpRangeTypeAbsent        = "absent_over_time"
+	OpRangeTypeHistogram     = "histogram_over_time"
+	OpRangeTypeCountDistinct = "count_distinct_over_time"
+	OpRangeTypeDeriv         = "deriv"
+	OpRangeTypePredictLinear = "predict_linear"
+	OpRangeTypeHoltWinters   = "holt_winters"
 
 	//vector
 	OpTypeVector = "vector"
 
+	// time-shifted comparisons
+	OpTypeChangesVs    = "changes_vs"
+	OpTypeDayOverDay   = "day_over_day"
+	OpTypeWeekOverWeek = "week_over_week"
+
 	// binops - logical/set
 	OpTypeOr     = "or"
 	OpTypeAnd    = "and"
@@ -1202,11 +1894,20 @@ const (
 	OpParserTypeJSON    = "json"
 	OpParserTypeLogfmt  = "logfmt"
 	OpParserTypeRegexp  = "regexp"
+	OpParserTypeExtract = "extract"
 	OpParserTypeUnpack  = "unpack"
 	OpParserTypePattern = "pattern"
+	OpParserTypeCSV     = "csv"
+	OpParserTypeSD      = "sd"
+	OpParserTypeXML     = "xml"
+
+	// csv parser options
+	OpCSVDelimiter = "delimiter"
+	OpCSVQuote     = "quote"
 
 	OpFmtLine    = "line_format"
 	OpFmtLabel   = "label_format"
+	OpFmtJSON    = "json_format"
 	OpDecolorize = "decolorize"
 
 	OpPipe   = "|"
@@ -1224,7 +1925,12 @@ const (
 	OpConvDuration        = "duration"
 	OpConvDurationSeconds = "duration_seconds"
 
-	OpLabelReplace = "label_replace"
+	OpLabelReplace  = "label_replace"
+	OpLabelJoin     = "label_join"
+	OpLabelMap      = "label_map"
+	OpLabelLower    = "label_lower"
+	OpLabelUpper    = "label_upper"
+	OpLabelTruncate = "label_truncate"
 
 	// function filters
 	OpFilterIP = "ip"
@@ -1235,16 +1941,41 @@ const (
 	// keep labels
 	OpKeep = "keep"
 
+	// join
+	OpJoin   = "join"
+	OpWithin = "within"
+
+	// sort
+	OpSortBy   = "sort_by"
+	OpSortAsc  = "asc"
+	OpSortDesc = "desc"
+	OpLimit    = "limit"
+
+	// dedup
+	OpDedup = "dedup"
+
+	// enrichment
+	OpGeoIP     = "geoip"
+	OpCIDRLabel = "cidr_label"
+
+	// macros
+	OpMacro = "@"
+
 	// parser flags
 	OpStrict    = "--strict"
 	OpKeepEmpty = "--keep-empty"
 
+	// full-text filter
+	OpSearch         = "|*"
+	OpSearchMetadata = "--metadata"
+
 	// internal expressions not represented in LogQL. These are used to
 	// evaluate expressions differently resulting in intermediate formats
 	// that are not consumable by LogQL clients but are used for sharding.
-	OpRangeTypeQuantileSketch     = "__quantile_sketch_over_time__"
-	OpRangeTypeFirstWithTimestamp = "__first_over_time_ts__"
-	OpRangeTypeLastWithTimestamp  = "__last_over_time_ts__"
+	OpRangeTypeQuantileSketch      = "__quantile_sketch_over_time__"
+	OpRangeTypeFirstWithTimestamp  = "__first_over_time_ts__"
+	OpRangeTypeLastWithTimestamp   = "__last_over_time_ts__"
+	OpRangeTypeCountDistinctSketch = "__count_distinct_sketch_over_time__"
 )
 
 func IsComparisonOperator(op string) bool {
@@ -1278,108 +2009,422 @@ type SampleExpr interface {
 	isSampleExpr()
 }
 
-// RangeAggregationExpr not all range vector aggregation expressions support grouping by/without label(s),
-// therefore the Grouping struct can be nil.
-type RangeAggregationExpr struct {
-	Left      *LogRange
+// RangeAggregationExpr not all range vector aggregation expressions support grouping by/without label(s),
+// therefore the Grouping struct can be nil.
+type RangeAggregationExpr struct {
+	Left      *LogRange
+	Operation string
+
+	Params   *float64
+	Buckets  []float64
+	Args     []float64
+	Grouping *Grouping
+	err      error
+	implicit
+}
+
+func newRangeAggregationExpr(left *LogRange, operation string, gr *Grouping, stringParams *string) SampleExpr {
+	var params *float64
+	if stringParams != nil {
+		if operation != OpRangeTypeQuantile && operation != OpRangeTypeQuantileSketch {
+			return &RangeAggregationExpr{err: logqlmodel.NewParseError(fmt.Sprintf("parameter %s not supported for operation %s", *stringParams, operation), 0, 0)}
+		}
+		var err error
+		params = new(float64)
+		*params, err = strconv.ParseFloat(*stringParams, 64)
+		if err != nil {
+			return &RangeAggregationExpr{err: logqlmodel.NewParseError(fmt.Sprintf("invalid parameter for operation %s: %s", operation, err), 0, 0)}
+		}
+
+	} else {
+		if operation == OpRangeTypeQuantile {
+			return &RangeAggregationExpr{err: logqlmodel.NewParseError(fmt.Sprintf("parameter required for operation %s", operation), 0, 0)}
+		}
+	}
+	e := &RangeAggregationExpr{
+		Left:      left,
+		Operation: operation,
+		Grouping:  gr,
+		Params:    params,
+	}
+	if err := e.validate(); err != nil {
+		return &RangeAggregationExpr{err: logqlmodel.NewParseError(err.Error(), 0, 0)}
+	}
+	return e
+}
+
+// newHistogramRangeAggregationExpr creates a histogram_over_time range aggregation
+// counting the unwrapped values into the given upper bounds.
+func newHistogramRangeAggregationExpr(left *LogRange, gr *Grouping, stringBuckets []string) SampleExpr {
+	buckets := make([]float64, 0, len(stringBuckets))
+	for _, b := range stringBuckets {
+		upper, err := strconv.ParseFloat(b, 64)
+		if err != nil {
+			return &RangeAggregationExpr{err: logqlmodel.NewParseError(fmt.Sprintf("invalid bucket for operation %s: %s", OpRangeTypeHistogram, err), 0, 0)}
+		}
+		buckets = append(buckets, upper)
+	}
+	e := &RangeAggregationExpr{
+		Left:      left,
+		Operation: OpRangeTypeHistogram,
+		Grouping:  gr,
+		Buckets:   buckets,
+	}
+	if err := e.validate(); err != nil {
+		return &RangeAggregationExpr{err: logqlmodel.NewParseError(err.Error(), 0, 0)}
+	}
+	return e
+}
+
+// newRangeAggregationExprWithArgs creates a range aggregation whose parameters
+// follow the range, e.g. predict_linear({app="foo"} | unwrap bytes [1h], 3600).
+func newRangeAggregationExprWithArgs(left *LogRange, operation string, stringArgs []string) SampleExpr {
+	args, err := parseRangeArgs(operation, stringArgs)
+	if err != nil {
+		return &RangeAggregationExpr{err: logqlmodel.NewParseError(err.Error(), 0, 0)}
+	}
+	e := &RangeAggregationExpr{
+		Left:      left,
+		Operation: operation,
+		Args:      args,
+	}
+	if err := e.validate(); err != nil {
+		return &RangeAggregationExpr{err: logqlmodel.NewParseError(err.Error(), 0, 0)}
+	}
+	return e
+}
+
+func parseRangeArgs(operation string, stringArgs []string) ([]float64, error) {
+	args := make([]float64, 0, len(stringArgs))
+	for _, a := range stringArgs {
+		f, err := strconv.ParseFloat(a, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameter for operation %s: %s", operation, err)
+		}
+		args = append(args, f)
+	}
+	return args, nil
+}
+
+func (e *RangeAggregationExpr) isSampleExpr() {}
+
+func (e *RangeAggregationExpr) Selector() (LogSelectorExpr, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.Left.Left, nil
+}
+
+func (e *RangeAggregationExpr) MatcherGroups() ([]MatcherRange, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	xs := e.Left.Left.Matchers()
+	if len(xs) > 0 {
+		return []MatcherRange{
+			{
+				Matchers: xs,
+				Interval: e.Left.Interval,
+				Offset:   e.Left.Offset,
+			},
+		}, nil
+	}
+	return nil, nil
+}
+
+func (e RangeAggregationExpr) validate() error {
+	if e.Operation == OpRangeTypeHistogram {
+		if err := validateHistogramBuckets(e.Buckets); err != nil {
+			return err
+		}
+	}
+	if err := validateRangeArgs(e.Operation, e.Args); err != nil {
+		return err
+	}
+	if e.Grouping != nil {
+		switch e.Operation {
+		case OpRangeTypeAvg, OpRangeTypeStddev, OpRangeTypeStdvar, OpRangeTypeQuantile,
+			OpRangeTypeQuantileSketch, OpRangeTypeMax, OpRangeTypeMin, OpRangeTypeFirst,
+			OpRangeTypeLast, OpRangeTypeFirstWithTimestamp, OpRangeTypeLastWithTimestamp,
+			OpRangeTypeHistogram, OpRangeTypeCountDistinct, OpRangeTypeCountDistinctSketch:
+		default:
+			return fmt.Errorf("grouping not allowed for %s aggregation", e.Operation)
+		}
+	}
+	if e.Left.Unwrap != nil {
+		if e.Left.Unwrap.IsMultiple() && !keepsUnwrappedLabel(e.Grouping) {
+			return fmt.Errorf("grouping of %s aggregation must keep the %s label of the unwrapped labels", e.Operation, log.UnwrappedLabel)
+		}
+		switch e.Operation {
+		case OpRangeTypeCountDistinct, OpRangeTypeCountDistinctSketch:
+			// distinct values are counted as strings.
+			for _, l := range e.Left.Unwrap.Labels {
+				if l.Operation != "" {
+					return fmt.Errorf("conversion function %s not allowed for %s aggregation", l.Operation, e.Operation)
+				}
+			}
+			if e.Left.Unwrap.Operation != "" {
+				return fmt.Errorf("conversion function %s not allowed for %s aggregation", e.Left.Unwrap.Operation, e.Operation)
+			}
+			return nil
+		case OpRangeTypeAvg, OpRangeTypeSum, OpRangeTypeMax, OpRangeTypeMin, OpRangeTypeStddev,
+			OpRangeTypeStdvar, OpRangeTypeQuantile, OpRangeTypeRate, OpRangeTypeRateCounter,
+			OpRangeTypeAbsent, OpRangeTypeFirst, OpRangeTypeLast, OpRangeTypeQuantileSketch,
+			OpRangeTypeFirstWithTimestamp, OpRangeTypeLastWithTimestamp, OpRangeTypeHistogram,
+			OpRangeTypeDeriv, OpRangeTypePredictLinear, OpRangeTypeHoltWinters:
+			return nil
+		default:
+			return fmt.Errorf("invalid aggregation %s with unwrap", e.Operation)
+		}
+	}
+	switch e.Operation {
+	case OpRangeTypeBytes, OpRangeTypeBytesRate, OpRangeTypeCount, OpRangeTypeRate, OpRangeTypeAbsent:
+		return nil
+	default:
+		return fmt.Errorf("invalid aggregation %s without unwrap", e.Operation)
+	}
+}
+
+func (e RangeAggregationExpr) Validate() error {
+	return e.validate()
+}
+
+// validateHistogramBuckets ensures histogram buckets are present and sorted
+// in strictly increasing order. The +Inf bucket is always implied.
+func validateHistogramBuckets(buckets []float64) error {
+	if len(buckets) == 0 {
+		return fmt.Errorf("at least one bucket is required for operation %s", OpRangeTypeHistogram)
+	}
+	for i, b := range buckets {
+		if math.IsNaN(b) {
+			return fmt.Errorf("invalid bucket NaN for operation %s", OpRangeTypeHistogram)
+		}
+		if i > 0 && b <= buckets[i-1] {
+			return fmt.Errorf("buckets for operation %s must be in increasing order", OpRangeTypeHistogram)
+		}
+	}
+	return nil
+}
+
+// validateRangeArgs ensures the parameters following the range match the
+// operation: predict_linear(<range>, t) and holt_winters(<range>, sf, tf).
+func validateRangeArgs(operation string, args []float64) error {
+	switch operation {
+	case OpRangeTypePredictLinear:
+		if len(args) != 1 {
+			return fmt.Errorf("operation %s requires a duration in seconds", operation)
+		}
+	case OpRangeTypeHoltWinters:
+		if len(args) != 2 {
+			return fmt.Errorf("operation %s requires a smoothing factor and a trend factor", operation)
+		}
+		for _, f := range args {
+			if math.IsNaN(f) || f <= 0 || f >= 1 {
+				return fmt.Errorf("invalid factor %s for operation %s: must be between 0 and 1", strconv.FormatFloat(f, 'f', -1, 64), operation)
+			}
+		}
+	default:
+		if len(args) > 0 {
+			return fmt.Errorf("parameters not supported for operation %s", operation)
+		}
+	}
+	return nil
+}
+
+// impls Stringer
+func (e *RangeAggregationExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(e.Operation)
+	sb.WriteString("(")
+	if e.Params != nil {
+		sb.WriteString(strconv.FormatFloat(*e.Params, 'f', -1, 64))
+		sb.WriteString(",")
+	}
+	sb.WriteString(e.Left.String())
+	for _, b := range e.Buckets {
+		sb.WriteString(",")
+		sb.WriteString(strconv.FormatFloat(b, 'f', -1, 64))
+	}
+	for _, a := range e.Args {
+		sb.WriteString(",")
+		sb.WriteString(strconv.FormatFloat(a, 'f', -1, 64))
+	}
+	sb.WriteString(")")
+	if e.Grouping != nil {
+		sb.WriteString(e.Grouping.String())
+	}
+	return sb.String()
+}
+
+// impl SampleExpr
+func (e *RangeAggregationExpr) Shardable(topLevel bool) bool {
+	// Here we are blocking sharding of quantile operations if they are not
+	// the top level aggregation in a query, such as max(quantile_over_time(...)).
+	// The sharding here will be blocked even if the feature flag in the shardmapper
+	// to enable sharding of quantile queries is enabled.
+	// The same applies to count_distinct_over_time.
+	if (e.Operation == OpRangeTypeQuantile || e.Operation == OpRangeTypeCountDistinct) && !topLevel {
+		return false
+	}
+	return shardableOps[e.Operation] && e.Left.Shardable(topLevel)
+}
+
+func (e *RangeAggregationExpr) Walk(f WalkFn) {
+	f(e)
+	if e.Left == nil {
+		return
+	}
+	e.Left.Walk(f)
+}
+
+func (e *RangeAggregationExpr) Accept(v RootVisitor) { v.VisitRangeAggregation(e) }
+
+// SubqueryExpr is a range aggregation over the results of a metric query
+// evaluated at a fixed resolution, e.g. max_over_time(rate({app="foo"}[1m])[1h:1m]).
+type SubqueryExpr struct {
+	Left      SampleExpr
 	Operation string
+	Params    *float64
+	Args      []float64
 
-	Params   *float64
-	Grouping *Grouping
-	err      error
+	Range  time.Duration
+	Step   time.Duration
+	Offset time.Duration
+
+	err error
 	implicit
 }
 
-func newRangeAggregationExpr(left *LogRange, operation string, gr *Grouping, stringParams *string) SampleExpr {
+// subqueryRange holds the range and resolution of a subquery, e.g. [1h:1m].
+type subqueryRange struct {
+	Range time.Duration
+	Step  time.Duration
+}
+
+func newSubqueryExpr(left SampleExpr, operation string, rng subqueryRange, o *OffsetExpr, stringParams *string) SampleExpr {
 	var params *float64
 	if stringParams != nil {
-		if operation != OpRangeTypeQuantile && operation != OpRangeTypeQuantileSketch {
-			return &RangeAggregationExpr{err: logqlmodel.NewParseError(fmt.Sprintf("parameter %s not supported for operation %s", *stringParams, operation), 0, 0)}
+		if operation != OpRangeTypeQuantile {
+			return &SubqueryExpr{err: logqlmodel.NewParseError(fmt.Sprintf("parameter %s not supported for operation %s", *stringParams, operation), 0, 0)}
 		}
 		var err error
 		params = new(float64)
 		*params, err = strconv.ParseFloat(*stringParams, 64)
 		if err != nil {
-			return &RangeAggregationExpr{err: logqlmodel.NewParseError(fmt.Sprintf("invalid parameter for operation %s: %s", operation, err), 0, 0)}
-		}
-
-	} else {
-		if operation == OpRangeTypeQuantile {
-			return &RangeAggregationExpr{err: logqlmodel.NewParseError(fmt.Sprintf("parameter required for operation %s", operation), 0, 0)}
+			return &SubqueryExpr{err: logqlmodel.NewParseError(fmt.Sprintf("invalid parameter for operation %s: %s", operation, err), 0, 0)}
 		}
+	} else if operation == OpRangeTypeQuantile {
+		return &SubqueryExpr{err: logqlmodel.NewParseError(fmt.Sprintf("parameter required for operation %s", operation), 0, 0)}
 	}
-	e := &RangeAggregationExpr{
+
+	e := &SubqueryExpr{
 		Left:      left,
 		Operation: operation,
-		Grouping:  gr,
 		Params:    params,
+		Range:     rng.Range,
+		Step:      rng.Step,
+	}
+	if o != nil {
+		e.Offset = o.Offset
 	}
 	if err := e.validate(); err != nil {
-		return &RangeAggregationExpr{err: logqlmodel.NewParseError(err.Error(), 0, 0)}
+		return &SubqueryExpr{err: logqlmodel.NewParseError(err.Error(), 0, 0)}
 	}
 	return e
 }
-func (e *RangeAggregationExpr) isSampleExpr() {}
 
-func (e *RangeAggregationExpr) Selector() (LogSelectorExpr, error) {
+// newSubqueryExprWithArgs creates a subquery whose parameters follow the range,
+// e.g. predict_linear(sum(rate({app="foo"}[1m]))[1h:1m], 3600).
+func newSubqueryExprWithArgs(left SampleExpr, operation string, rng subqueryRange, o *OffsetExpr, stringArgs []string) SampleExpr {
+	args, err := parseRangeArgs(operation, stringArgs)
+	if err != nil {
+		return &SubqueryExpr{err: logqlmodel.NewParseError(err.Error(), 0, 0)}
+	}
+	e := &SubqueryExpr{
+		Left:      left,
+		Operation: operation,
+		Args:      args,
+		Range:     rng.Range,
+		Step:      rng.Step,
+	}
+	if o != nil {
+		e.Offset = o.Offset
+	}
+	if err := e.validate(); err != nil {
+		return &SubqueryExpr{err: logqlmodel.NewParseError(err.Error(), 0, 0)}
+	}
+	return e
+}
+
+func (e *SubqueryExpr) validate() error {
+	switch e.Operation {
+	case OpRangeTypeAvg, OpRangeTypeSum, OpRangeTypeMax, OpRangeTypeMin, OpRangeTypeCount,
+		OpRangeTypeStddev, OpRangeTypeStdvar, OpRangeTypeQuantile, OpRangeTypeFirst, OpRangeTypeLast,
+		OpRangeTypeDeriv, OpRangeTypePredictLinear, OpRangeTypeHoltWinters:
+	default:
+		return fmt.Errorf("invalid aggregation %s with subquery", e.Operation)
+	}
+	if err := validateRangeArgs(e.Operation, e.Args); err != nil {
+		return err
+	}
+	if e.Range <= 0 {
+		return fmt.Errorf("subquery range must be positive")
+	}
+	if e.Step <= 0 {
+		return fmt.Errorf("subquery resolution must be positive")
+	}
+	return nil
+}
+
+func (e *SubqueryExpr) isSampleExpr() {}
+
+func (e *SubqueryExpr) Selector() (LogSelectorExpr, error) {
 	if e.err != nil {
 		return nil, e.err
 	}
-	return e.Left.Left, nil
+	return e.Left.Selector()
 }
 
-func (e *RangeAggregationExpr) MatcherGroups() ([]MatcherRange, error) {
+func (e *SubqueryExpr) Extractor() (log.SampleExtractor, error) {
 	if e.err != nil {
 		return nil, e.err
 	}
-	xs := e.Left.Left.Matchers()
-	if len(xs) > 0 {
-		return []MatcherRange{
-			{
-				Matchers: xs,
-				Interval: e.Left.Interval,
-				Offset:   e.Left.Offset,
-			},
-		}, nil
-	}
-	return nil, nil
+	return e.Left.Extractor()
 }
 
-func (e RangeAggregationExpr) validate() error {
-	if e.Grouping != nil {
-		switch e.Operation {
-		case OpRangeTypeAvg, OpRangeTypeStddev, OpRangeTypeStdvar, OpRangeTypeQuantile,
-			OpRangeTypeQuantileSketch, OpRangeTypeMax, OpRangeTypeMin, OpRangeTypeFirst,
-			OpRangeTypeLast, OpRangeTypeFirstWithTimestamp, OpRangeTypeLastWithTimestamp:
-		default:
-			return fmt.Errorf("grouping not allowed for %s aggregation", e.Operation)
-		}
+// MatcherGroups returns the matcher groups of the inner query, with their
+// range widened by the range of the subquery.
+func (e *SubqueryExpr) MatcherGroups() ([]MatcherRange, error) {
+	if e.err != nil {
+		return nil, e.err
 	}
-	if e.Left.Unwrap != nil {
-		switch e.Operation {
-		case OpRangeTypeAvg, OpRangeTypeSum, OpRangeTypeMax, OpRangeTypeMin, OpRangeTypeStddev,
-			OpRangeTypeStdvar, OpRangeTypeQuantile, OpRangeTypeRate, OpRangeTypeRateCounter,
-			OpRangeTypeAbsent, OpRangeTypeFirst, OpRangeTypeLast, OpRangeTypeQuantileSketch,
-			OpRangeTypeFirstWithTimestamp, OpRangeTypeLastWithTimestamp:
-			return nil
-		default:
-			return fmt.Errorf("invalid aggregation %s with unwrap", e.Operation)
-		}
+	groups, err := e.Left.MatcherGroups()
+	if err != nil {
+		return nil, err
 	}
-	switch e.Operation {
-	case OpRangeTypeBytes, OpRangeTypeBytesRate, OpRangeTypeCount, OpRangeTypeRate, OpRangeTypeAbsent:
-		return nil
-	default:
-		return fmt.Errorf("invalid aggregation %s without unwrap", e.Operation)
+	for i := range groups {
+		groups[i].Interval += e.Range
+		groups[i].Offset += e.Offset
 	}
+	return groups, nil
 }
 
-func (e RangeAggregationExpr) Validate() error {
-	return e.validate()
+// Shardable returns false: the aggregation over time is evaluated on the
+// results of the inner query, which may be sharded on its own.
+func (e *SubqueryExpr) Shardable(_ bool) bool { return false }
+
+func (e *SubqueryExpr) Walk(f WalkFn) {
+	f(e)
+	if e.Left == nil {
+		return
+	}
+	e.Left.Walk(f)
 }
 
+func (e *SubqueryExpr) Accept(v RootVisitor) { v.VisitSubquery(e) }
+
 // impls Stringer
-func (e *RangeAggregationExpr) String() string {
+func (e *SubqueryExpr) String() string {
 	var sb strings.Builder
 	sb.WriteString(e.Operation)
 	sb.WriteString("(")
@@ -1388,26 +2433,88 @@ func (e *RangeAggregationExpr) String() string {
 		sb.WriteString(",")
 	}
 	sb.WriteString(e.Left.String())
-	sb.WriteString(")")
-	if e.Grouping != nil {
-		sb.WriteString(e.Grouping.String())
+	sb.WriteString(e.rangeString())
+	for _, a := range e.Args {
+		sb.WriteString(",")
+		sb.WriteString(strconv.FormatFloat(a, 'f', -1, 64))
 	}
+	sb.WriteString(")")
 	return sb.String()
 }
 
-// impl SampleExpr
-func (e *RangeAggregationExpr) Shardable(topLevel bool) bool {
-	// Here we are blocking sharding of quantile operations if they are not
-	// the top level aggregation in a query, such as max(quantile_over_time(...)).
-	// The sharding here will be blocked even if the feature flag in the shardmapper
-	// to enable sharding of quantile queries is enabled.
-	if e.Operation == OpRangeTypeQuantile && !topLevel {
-		return false
+// rangeString returns the range, resolution and offset of the subquery, e.g. [1h:1m] offset 5m.
+func (e *SubqueryExpr) rangeString() string {
+	s := fmt.Sprintf("[%v:%v]", model.Duration(e.Range), model.Duration(e.Step))
+	if e.Offset != 0 {
+		offsetExpr := OffsetExpr{Offset: e.Offset}
+		s += offsetExpr.String()
 	}
-	return shardableOps[e.Operation] && e.Left.Shardable(topLevel)
+	return s
 }
 
-func (e *RangeAggregationExpr) Walk(f WalkFn) {
+// ChangesVsExpr compares a metric query with its own results a fixed duration
+// earlier, e.g. changes_vs(sum(rate({app="foo"}[5m])), 1w). The query is parsed
+// once and evaluated for both legs, and the result is the difference between
+// the current and the shifted leg for each series present in both.
+// day_over_day(<expr>) and week_over_week(<expr>) are shorthands for a shift
+// of 1d and 1w.
+type ChangesVsExpr struct {
+	Left  SampleExpr
+	Shift time.Duration
+
+	err error
+	implicit
+}
+
+func newChangesVsExpr(left SampleExpr, shift time.Duration) SampleExpr {
+	if shift <= 0 {
+		return &ChangesVsExpr{err: logqlmodel.NewParseError(fmt.Sprintf("invalid shift for operation %s: must be positive", OpTypeChangesVs), 0, 0)}
+	}
+	return &ChangesVsExpr{
+		Left:  left,
+		Shift: shift,
+	}
+}
+
+func (e *ChangesVsExpr) isSampleExpr() {}
+
+func (e *ChangesVsExpr) Selector() (LogSelectorExpr, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.Left.Selector()
+}
+
+func (e *ChangesVsExpr) Extractor() (log.SampleExtractor, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.Left.Extractor()
+}
+
+// MatcherGroups returns the matcher groups of the compared query for both
+// legs, the ones of the shifted leg having their offset increased by the shift.
+func (e *ChangesVsExpr) MatcherGroups() ([]MatcherRange, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	groups, err := e.Left.MatcherGroups()
+	if err != nil {
+		return nil, err
+	}
+	shifted := make([]MatcherRange, 0, len(groups))
+	for _, g := range groups {
+		g.Offset += e.Shift
+		shifted = append(shifted, g)
+	}
+	return append(groups, shifted...), nil
+}
+
+// Shardable returns false: the comparison is evaluated on the results of the
+// compared query, which may be sharded on its own.
+func (e *ChangesVsExpr) Shardable(_ bool) bool { return false }
+
+func (e *ChangesVsExpr) Walk(f WalkFn) {
 	f(e)
 	if e.Left == nil {
 		return
@@ -1415,7 +2522,19 @@ func (e *RangeAggregationExpr) Walk(f WalkFn) {
 	e.Left.Walk(f)
 }
 
-func (e *RangeAggregationExpr) Accept(v RootVisitor) { v.VisitRangeAggregation(e) }
+func (e *ChangesVsExpr) Accept(v RootVisitor) { v.VisitChangesVs(e) }
+
+// impls Stringer
+func (e *ChangesVsExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(OpTypeChangesVs)
+	sb.WriteString("(")
+	sb.WriteString(e.Left.String())
+	sb.WriteString(", ")
+	sb.WriteString(model.Duration(e.Shift).String())
+	sb.WriteString(")")
+	return sb.String()
+}
 
 // Grouping struct represents the grouping by/without label(s) for vector aggregators and range vector aggregators.
 // The representation is as follows:
@@ -1493,6 +2612,14 @@ func mustNewVectorAggregationExpr(left SampleExpr, operation string, gr *Groupin
 	if gr == nil {
 		gr = &Grouping{}
 	}
+	switch operation {
+	case OpTypeBottomK, OpTypeTopK, OpTypeSort, OpTypeSortDesc:
+	default:
+		// the series of each unwrapped label must not be aggregated together.
+		if !keepsUnwrappedLabel(gr) && hasMultipleUnwrap(left) {
+			return &VectorAggregationExpr{err: logqlmodel.NewParseError(fmt.Sprintf("grouping of %s aggregation must keep the %s label of the unwrapped labels", operation, log.UnwrappedLabel), 0, 0)}
+		}
+	}
 	return &VectorAggregationExpr{
 		Left:      left,
 		Operation: operation,
@@ -1501,6 +2628,33 @@ func mustNewVectorAggregationExpr(left SampleExpr, operation string, gr *Groupin
 	}
 }
 
+// hasMultipleUnwrap returns true when the expression aggregates the samples
+// of multiple unwrapped labels, whose series are told apart by their label set
+// by the unwrap.
+func hasMultipleUnwrap(e SampleExpr) bool {
+	var found bool
+	e.Walk(func(e Expr) {
+		if r, ok := e.(*RangeAggregationExpr); ok && r.Left != nil && r.Left.Unwrap != nil && r.Left.Unwrap.IsMultiple() {
+			found = true
+		}
+	})
+	return found
+}
+
+// keepsUnwrappedLabel returns true when the series aggregated with the
+// grouping keep the label set by multiple unwraps.
+func keepsUnwrappedLabel(g *Grouping) bool {
+	if g == nil {
+		return true
+	}
+	for _, name := range g.Groups {
+		if name == log.UnwrappedLabel {
+			return !g.Without
+		}
+	}
+	return g.Without
+}
+
 func (e *VectorAggregationExpr) isSampleExpr() {}
 
 func (e *VectorAggregationExpr) MatcherGroups() ([]MatcherRange, error) {
@@ -2190,6 +3344,160 @@ func (e *LabelReplaceExpr) String() string {
 	return sb.String()
 }
 
+// LabelFunctionExpr sets the label Dst of each series of Left to a value
+// computed from its Src labels. The label is removed when the value is empty.
+//
+//   - label_join joins the values of Src with Separator.
+//   - label_map looks the value of Src up in Mapping and leaves the series
+//     unchanged when it isn't found.
+//   - label_lower and label_upper change the case of the value of Src.
+//   - label_truncate keeps the first Length characters of the value of Src.
+type LabelFunctionExpr struct {
+	Left      SampleExpr
+	Operation string
+	Dst       string
+	Src       []string
+	Separator string
+	Mapping   map[string]string
+	Length    int
+	err       error
+
+	implicit
+}
+
+func newLabelFunctionExpr(left SampleExpr, op, dst string, src []string, separator string, mapping map[string]string, length int) *LabelFunctionExpr {
+	if !model.LabelName(dst).IsValid() {
+		return &LabelFunctionExpr{
+			err: logqlmodel.NewParseError(fmt.Sprintf("invalid destination label name in %s: %s", op, dst), 0, 0),
+		}
+	}
+	if op == OpLabelTruncate && length <= 0 {
+		return &LabelFunctionExpr{
+			err: logqlmodel.NewParseError(fmt.Sprintf("invalid length %d in %s: must be greater than 0", length, op), 0, 0),
+		}
+	}
+	return &LabelFunctionExpr{
+		Left:      left,
+		Operation: op,
+		Dst:       dst,
+		Src:       src,
+		Separator: separator,
+		Mapping:   mapping,
+		Length:    length,
+	}
+}
+
+// newLabelMapExpr builds a label_map expression from the flattened key value
+// pairs of its mapping.
+func newLabelMapExpr(left SampleExpr, dst, src string, pairs []string) *LabelFunctionExpr {
+	mapping := make(map[string]string, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if _, ok := mapping[pairs[i]]; ok {
+			return &LabelFunctionExpr{
+				err: logqlmodel.NewParseError(fmt.Sprintf("duplicate key %q in %s", pairs[i], OpLabelMap), 0, 0),
+			}
+		}
+		mapping[pairs[i]] = pairs[i+1]
+	}
+	return newLabelFunctionExpr(left, OpLabelMap, dst, []string{src}, "", mapping, 0)
+}
+
+func newLabelTruncateExpr(left SampleExpr, dst, src, length string) *LabelFunctionExpr {
+	n, err := strconv.Atoi(length)
+	if err != nil {
+		return &LabelFunctionExpr{
+			err: logqlmodel.NewParseError(fmt.Sprintf("invalid length %s in %s: must be an integer", length, OpLabelTruncate), 0, 0),
+		}
+	}
+	return newLabelFunctionExpr(left, OpLabelTruncate, dst, []string{src}, "", nil, n)
+}
+
+func (e *LabelFunctionExpr) isSampleExpr() {}
+
+func (e *LabelFunctionExpr) Selector() (LogSelectorExpr, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.Left.Selector()
+}
+
+func (e *LabelFunctionExpr) MatcherGroups() ([]MatcherRange, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.Left.MatcherGroups()
+}
+
+func (e *LabelFunctionExpr) Extractor() (SampleExtractor, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.Left.Extractor()
+}
+
+func (e *LabelFunctionExpr) Shardable(_ bool) bool {
+	return false
+}
+
+func (e *LabelFunctionExpr) Walk(f WalkFn) {
+	f(e)
+	if e.Left == nil {
+		return
+	}
+	e.Left.Walk(f)
+}
+
+func (e *LabelFunctionExpr) Accept(v RootVisitor) { v.VisitLabelFunction(e) }
+
+// params returns the arguments following the inner expression.
+func (e *LabelFunctionExpr) params() []string {
+	params := []string{strconv.Quote(e.Dst)}
+	switch e.Operation {
+	case OpLabelJoin:
+		params = append(params, strconv.Quote(e.Separator))
+		for _, src := range e.Src {
+			params = append(params, strconv.Quote(src))
+		}
+	case OpLabelMap:
+		keys := make([]string, 0, len(e.Mapping))
+		for k := range e.Mapping {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var sb strings.Builder
+		sb.WriteString("{")
+		for i, k := range keys {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(strconv.Quote(k))
+			sb.WriteString("=")
+			sb.WriteString(strconv.Quote(e.Mapping[k]))
+		}
+		sb.WriteString("}")
+		params = append(params, strconv.Quote(e.Src[0]), sb.String())
+	case OpLabelTruncate:
+		params = append(params, strconv.Quote(e.Src[0]), strconv.Itoa(e.Length))
+	default:
+		params = append(params, strconv.Quote(e.Src[0]))
+	}
+	return params
+}
+
+func (e *LabelFunctionExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(e.Operation)
+	sb.WriteString("(")
+	sb.WriteString(e.Left.String())
+	for _, p := range e.params() {
+		sb.WriteString(",")
+		sb.WriteString(p)
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
 // shardableOps lists the operations which may be sharded, but are not
 // guaranteed to be. See the `Shardable()` implementations
 // on the respective expr types for more details.
@@ -2219,17 +3527,19 @@ var shardableOps = map[string]bool{
 	OpTypeMin:   true,
 
 	// range vector ops
-	OpRangeTypeAvg:       true,
-	OpRangeTypeCount:     true,
-	OpRangeTypeFirst:     true,
-	OpRangeTypeLast:      true,
-	OpRangeTypeRate:      true,
-	OpRangeTypeBytes:     true,
-	OpRangeTypeBytesRate: true,
-	OpRangeTypeSum:       true,
-	OpRangeTypeMax:       true,
-	OpRangeTypeMin:       true,
-	OpRangeTypeQuantile:  true,
+	OpRangeTypeAvg:           true,
+	OpRangeTypeCount:         true,
+	OpRangeTypeFirst:         true,
+	OpRangeTypeLast:          true,
+	OpRangeTypeRate:          true,
+	OpRangeTypeBytes:         true,
+	OpRangeTypeBytesRate:     true,
+	OpRangeTypeSum:           true,
+	OpRangeTypeMax:           true,
+	OpRangeTypeMin:           true,
+	OpRangeTypeQuantile:      true,
+	OpRangeTypeHistogram:     true,
+	OpRangeTypeCountDistinct: true,
 
 	// binops - arith
 	OpTypeAdd: true,
@@ -2245,6 +3555,16 @@ func MatcherGroups(expr Expr) ([]MatcherRange, error) {
 	switch e := expr.(type) {
 	case SampleExpr:
 		return e.MatcherGroups()
+	case *JoinExpr:
+		left, err := MatcherGroups(e.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := MatcherGroups(e.Right)
+		if err != nil {
+			return nil, err
+		}
+		return append(left, right...), nil
 	case LogSelectorExpr:
 		if xs := e.Matchers(); len(xs) > 0 {
 			return []MatcherRange{
@@ -2,6 +2,8 @@ package syntax
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -101,6 +103,9 @@ func Test_SampleExpr_String(t *testing.T) {
 		`avg( rate( ( {job="nginx"} |= "GET" ) [10s] ) ) by (region)`,
 		`avg(min_over_time({job="nginx"} |= "GET" | unwrap foo[10s])) by (region)`,
 		`avg(min_over_time({job="nginx"} |= "GET" | unwrap foo[10s] offset 10m)) by (region)`,
+		`sum by (__unwrapped__) (sum_over_time({job="nginx"} | logfmt | unwrap (bytes_in, bytes(bytes_out)) | __error__="" [5m]))`,
+		`sum_over_time({job="nginx"} | extract "took=(?P<took:duration>\\S+) size=(?P<size:bytes>\\S+)" | size > 1KB | unwrap took [5m])`,
+		`topk(2, max_over_time({job="nginx"} | logfmt | unwrap (duration(latency), bytes_out) [5m]) without (pod))`,
 		`sum by (cluster) (count_over_time({job="mysql"}[5m]))`,
 		`sum by (cluster) (count_over_time({job="mysql"}[5m] offset 10m))`,
 		`sum by (cluster) (count_over_time({job="mysql"}[5m])) / sum by (cluster) (count_over_time({job="postgres"}[5m])) `,
@@ -188,6 +193,31 @@ func Test_SampleExpr_String(t *testing.T) {
 				or on ()
 				((sum by(typename,pool,commandname,colo) (sum_over_time({_namespace_="appspace", _schema_="appspace-1h", pool=~"r1testlvs", colo=~"slc|lvs|rno", env!~"(pre-production|sandbox)"} | logfmt | status!="0" | ( ( type=~"(?i)^(Error|Exception|Fatal|ERRPAGE|ValidationError)$" or typename=~"(?i)^(Error|Exception|Fatal|ERRPAGE|ValidationError)$" ) or status=~"(?i)^(Error|Exception|Fatal|ERRPAGE|ValidationError)$" ) | commandname=~"(?i).*|UNSET" | unwrap sumcount[5m])) / 60) / 60))`,
 		`{app="foo"} | logfmt code="response.code", IPAddress="host"`,
+		`histogram_over_time({app="foo"} | json | unwrap latency [5m], 0.05, 0.5, 1, 2.5) by (namespace)`,
+		`predict_linear({app="foo"} | json | unwrap bytes [1h], 3600)`,
+		`holt_winters(sum(rate({app="foo"}[1m]))[1h:1m] offset 5m, 0.5, 0.1)`,
+		`changes_vs(sum by (app) (rate({app="foo"}[5m])), 1w)`,
+		`changes_vs(sum(count_over_time({app="foo"}[1h])), 1d) / 2`,
+		`label_join(sum by (cluster, namespace) (rate({app="foo"}[5m])), "dst", "/", "cluster", "namespace")`,
+		`label_map(rate({app="foo"}[5m]), "tier", "namespace", {"dev"="bronze", "prod"="gold"})`,
+		`label_truncate(label_lower(rate({app="foo"}[5m]), "path", "path"), "path", "path", 20)`,
+		`max_over_time(rate({app="foo"}[5m])[1h:1m])`,
+		`quantile_over_time(0.99, (sum by (app) (rate({app="foo"}[5m])) / 2)[1h:30s] offset 5m)`,
+		`{app="gateway"} | json | join on(trace_id) within 5m {app="backend"} | logfmt | level="error"`,
+		`{app="foo"} | logfmt | sort_by(duration desc) | limit 50`,
+		`{app="foo"} | logfmt | dedup by (pod,level) within 1m | limit 50`,
+		`{app="foo"} | dedup`,
+		`{app="foo"} | logfmt | json_format level, "http.status"=status | line_format "{{.level}}"`,
+		`sum by (level) (count_over_time({app="foo"} | logfmt | json_format [5m]))`,
+		`{app="foo"} | json | geoip(src_ip) | cidr_label(src_ip, "ranges.yaml") | geoip_country_name="France"`,
+		`{app="foo"} |* "connection refused"`,
+		`{app="foo"} |=~i "error" or "fatal" !=~w "timeout"`,
+		`{app="foo"} | json |* --metadata "3fa85f64" |= "error"`,
+		`{app="foo"} | csv "ip,,status" delimiter="\t", quote="" | status >= 500`,
+		`sum by (col_2) (rate({app="foo"} | csv [5m]))`,
+		`{app="foo"} | sd --strict iut="exampleSDID@32473.iut",eventSource="eventSource" | iut > 1`,
+		`sum by (event_status) (rate({app="foo"} | xml --keep-empty [5m]))`,
+		`{app="foo"} | @parse_nginx() | status >= 500`,
 	} {
 		t.Run(tc, func(t *testing.T) {
 			expr, err := ParseExpr(tc)
@@ -228,6 +258,32 @@ func Test_SampleExpr_Sort_Fail(t *testing.T) {
 	}
 }
 
+func Test_WithEnrichment(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ranges.yaml"), []byte("office: [10.1.0.0/16]\n"), 0o644))
+	enrichment := log.NewEnrichment(nil, dir)
+
+	expr, err := ParseSampleExpr(`sum(count_over_time({app="foo"} | logfmt | cidr_label(src_ip, "ranges.yaml") [5m]))`)
+	require.NoError(t, err)
+	bound := WithEnrichment(expr, enrichment)
+	require.Equal(t, expr.String(), bound.String())
+	_, err = bound.Extractor()
+	require.NoError(t, err)
+	// the parsed expression is left untouched.
+	_, err = expr.Extractor()
+	require.Error(t, err)
+
+	// geoip stages are rejected without a GeoIP database.
+	selector, err := ParseLogSelector(`{app="foo"} | logfmt | geoip(src_ip)`, true)
+	require.NoError(t, err)
+	_, err = WithEnrichment(selector, enrichment).Pipeline()
+	require.Error(t, err)
+
+	plain, err := ParseExpr(`{app="foo"} | logfmt`)
+	require.NoError(t, err)
+	require.Same(t, plain, WithEnrichment(plain, enrichment))
+}
+
 func TestMatcherGroups(t *testing.T) {
 	for i, tc := range []struct {
 		query string
@@ -988,6 +1044,16 @@ func TestFilterReodering(t *testing.T) {
 		require.Len(t, stages, 5)
 		require.Equal(t, `|= "06497595" | unpack != "message" | json | line_format "new log: {{.foo}}"`, MultiStageExpr(stages).String())
 	})
+
+	t.Run("json_format test", func(t *testing.T) {
+		logExpr := `{container_name="app"} | logfmt |= "foo" | json_format level |= "error"`
+		l, err := ParseExpr(logExpr)
+		require.NoError(t, err)
+
+		stages := l.(*PipelineExpr).MultiStages.reorderStages()
+		require.Len(t, stages, 4)
+		require.Equal(t, `|= "foo" | logfmt | json_format level |= "error"`, MultiStageExpr(stages).String())
+	})
 }
 
 var result bool
@@ -79,14 +79,70 @@ func (v *cloneVisitor) VisitRangeAggregation(e *RangeAggregationExpr) {
 		copied.Params = &tmp
 	}
 
+	if e.Buckets != nil {
+		copied.Buckets = make([]float64, len(e.Buckets))
+		copy(copied.Buckets, e.Buckets)
+	}
+
+	if e.Args != nil {
+		copied.Args = make([]float64, len(e.Args))
+		copy(copied.Args, e.Args)
+	}
+
+	v.cloned = copied
+}
+
+func (v *cloneVisitor) VisitSubquery(e *SubqueryExpr) {
+	copied := &SubqueryExpr{
+		Left:      MustClone[SampleExpr](e.Left),
+		Operation: e.Operation,
+		Range:     e.Range,
+		Step:      e.Step,
+		Offset:    e.Offset,
+	}
+
+	if e.Params != nil {
+		tmp := *e.Params
+		copied.Params = &tmp
+	}
+
+	if e.Args != nil {
+		copied.Args = make([]float64, len(e.Args))
+		copy(copied.Args, e.Args)
+	}
+
 	v.cloned = copied
 }
 
+func (v *cloneVisitor) VisitChangesVs(e *ChangesVsExpr) {
+	v.cloned = &ChangesVsExpr{
+		Left:  MustClone[SampleExpr](e.Left),
+		Shift: e.Shift,
+	}
+}
+
 func (v *cloneVisitor) VisitLabelReplace(e *LabelReplaceExpr) {
 	left := MustClone[SampleExpr](e.Left)
 	v.cloned = mustNewLabelReplaceExpr(left, e.Dst, e.Replacement, e.Src, e.Regex)
 }
 
+func (v *cloneVisitor) VisitLabelFunction(e *LabelFunctionExpr) {
+	left := MustClone[SampleExpr](e.Left)
+	var src []string
+	if e.Src != nil {
+		src = make([]string, len(e.Src))
+		copy(src, e.Src)
+	}
+	var mapping map[string]string
+	if e.Mapping != nil {
+		mapping = make(map[string]string, len(e.Mapping))
+		for k, val := range e.Mapping {
+			mapping[k] = val
+		}
+	}
+	v.cloned = newLabelFunctionExpr(left, e.Operation, e.Dst, src, e.Separator, mapping, e.Length)
+}
+
 func (v *cloneVisitor) VisitLiteral(e *LiteralExpr) {
 	v.cloned = &LiteralExpr{Val: e.Val}
 }
@@ -106,6 +162,10 @@ func (v *cloneVisitor) VisitLogRange(e *LogRange) {
 			Identifier: e.Unwrap.Identifier,
 			Operation:  e.Unwrap.Operation,
 		}
+		if e.Unwrap.Labels != nil {
+			copied.Unwrap.Labels = make([]UnwrapLabel, len(e.Unwrap.Labels))
+			copy(copied.Unwrap.Labels, e.Unwrap.Labels)
+		}
 		if e.Unwrap.PostFilters != nil {
 			copied.Unwrap.PostFilters = make([]log.LabelFilterer, len(e.Unwrap.PostFilters))
 			for i, f := range e.Unwrap.PostFilters {
@@ -140,6 +200,30 @@ func (v *cloneVisitor) VisitPipeline(e *PipelineExpr) {
 	v.cloned = copied
 }
 
+func (v *cloneVisitor) VisitJoin(e *JoinExpr) {
+	copied := &JoinExpr{
+		Left:   MustClone[LogSelectorExpr](e.Left),
+		Right:  MustClone[LogSelectorExpr](e.Right),
+		On:     make([]string, len(e.On)),
+		Within: e.Within,
+	}
+	copy(copied.On, e.On)
+
+	v.cloned = copied
+}
+
+func (v *cloneVisitor) VisitCSVParser(e *CSVParserExpr) {
+	copied := &CSVParserExpr{
+		Delimiter: e.Delimiter,
+		Quote:     e.Quote,
+	}
+	if e.Columns != nil {
+		copied.Columns = make([]string, len(e.Columns))
+		copy(copied.Columns, e.Columns)
+	}
+	v.cloned = copied
+}
+
 func (v *cloneVisitor) VisitDecolorize(*DecolorizeExpr) {
 	v.cloned = &DecolorizeExpr{}
 }
@@ -184,6 +268,63 @@ func (v *cloneVisitor) VisitKeepLabel(e *KeepLabelsExpr) {
 	v.cloned = copied
 }
 
+func (v *cloneVisitor) VisitSortBy(e *SortByExpr) {
+	v.cloned = &SortByExpr{Label: e.Label, Desc: e.Desc}
+}
+
+func (v *cloneVisitor) VisitLimit(e *LimitExpr) {
+	v.cloned = &LimitExpr{Limit: e.Limit}
+}
+
+func (v *cloneVisitor) VisitDedup(e *DedupExpr) {
+	copied := &DedupExpr{Within: e.Within}
+	if e.Labels != nil {
+		copied.Labels = make([]string, len(e.Labels))
+		copy(copied.Labels, e.Labels)
+	}
+	v.cloned = copied
+}
+
+func (v *cloneVisitor) VisitGeoIP(e *GeoIPExpr) {
+	v.cloned = &GeoIPExpr{Label: e.Label, enrichment: e.enrichment}
+}
+
+func (v *cloneVisitor) VisitCIDRLabel(e *CIDRLabelExpr) {
+	v.cloned = &CIDRLabelExpr{Label: e.Label, File: e.File, enrichment: e.enrichment}
+}
+
+func (v *cloneVisitor) VisitSearch(e *SearchExpr) {
+	v.cloned = &SearchExpr{Search: e.Search, Metadata: e.Metadata}
+}
+
+func (v *cloneVisitor) VisitMacro(e *MacroExpr) {
+	v.cloned = &MacroExpr{Name: e.Name}
+}
+
+func (v *cloneVisitor) VisitSDParser(e *SDParserExpr) {
+	copied := &SDParserExpr{
+		Strict:    e.Strict,
+		KeepEmpty: e.KeepEmpty,
+	}
+	if e.Expressions != nil {
+		copied.Expressions = make([]log.LabelExtractionExpr, len(e.Expressions))
+		copy(copied.Expressions, e.Expressions)
+	}
+	v.cloned = copied
+}
+
+func (v *cloneVisitor) VisitXMLParser(e *XMLParserExpr) {
+	copied := &XMLParserExpr{
+		Strict:    e.Strict,
+		KeepEmpty: e.KeepEmpty,
+	}
+	if e.Expressions != nil {
+		copied.Expressions = make([]log.LabelExtractionExpr, len(e.Expressions))
+		copy(copied.Expressions, e.Expressions)
+	}
+	v.cloned = copied
+}
+
 func (v *cloneVisitor) VisitLabelFilter(e *LabelFilterExpr) {
 	v.cloned = &LabelFilterExpr{
 		LabelFilterer: cloneLabelFilterer(e.LabelFilterer),
@@ -283,6 +424,15 @@ func (v *cloneVisitor) VisitLineFmt(e *LineFmtExpr) {
 	v.cloned = &LineFmtExpr{Value: e.Value}
 }
 
+func (v *cloneVisitor) VisitJSONFmt(e *JSONFmtExpr) {
+	copied := &JSONFmtExpr{}
+	if e.Fields != nil {
+		copied.Fields = make([]log.JSONField, len(e.Fields))
+		copy(copied.Fields, e.Fields)
+	}
+	v.cloned = copied
+}
+
 func (v *cloneVisitor) VisitLogfmtExpressionParser(e *LogfmtExpressionParser) {
 	copied := &LogfmtExpressionParser{
 		Expressions: make([]log.LabelExtractionExpr, len(e.Expressions)),
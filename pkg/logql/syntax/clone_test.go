@@ -35,6 +35,9 @@ func TestClone(t *testing.T) {
 		"drop label": {
 			query: `{app="foo"} |= "bar" | json | drop latency, status_code="200"`,
 		},
+		"label functions": {
+			query: `label_map(label_join(vector(0.000000),"dst","-","a","b"),"tier","namespace",{"prod"="gold"})`,
+		},
 		"keep label": {
 			query: `{app="foo"} |= "bar" | json | keep latency, status_code="200"`,
 		},
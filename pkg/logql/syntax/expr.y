@@ -19,7 +19,9 @@ import (
   Matcher                 *labels.Matcher
   Matchers                []*labels.Matcher
   RangeAggregationExpr    SampleExpr
+  SubqueryExpr            SampleExpr
   RangeOp                 string
+  Numbers                 []string
   ConvOp                  string
   Selector                []*labels.Matcher
   VectorAggregationExpr   SampleExpr
@@ -34,6 +36,7 @@ import (
   bytes                   uint64
   str                     string
   duration                time.Duration
+  subqueryRange           subqueryRange
   LiteralExpr             *LiteralExpr
   BinOpModifier           *BinOpOptions
   BoolModifier            *BinOpOptions
@@ -71,6 +74,8 @@ import (
   KeepLabel               log.KeepLabel
   KeepLabels              []log.KeepLabel
   KeepLabelsExpr          *KeepLabelsExpr
+  SortByExpr              *SortByExpr
+  LimitExpr               *LimitExpr
 }
 
 %start root
@@ -78,14 +83,17 @@ import (
 %type <Expr>                  expr
 %type <Filter>                filter
 %type <Grouping>              grouping
-%type <Labels>                labels
+%type <Labels>                labels strings labelMap jsonFormatFields jsonFormatField unwrapLabels unwrapLabel
 %type <LogExpr>               logExpr
-%type <MetricExpr>            metricExpr
+%type <LogExpr>               joinExpr
+%type <MetricExpr>            metricExpr changesVsExpr
 %type <LogRangeExpr>          logRangeExpr
 %type <Matcher>               matcher
 %type <Matchers>              matchers
 %type <RangeAggregationExpr>  rangeAggregationExpr
+%type <SubqueryExpr>          subqueryExpr
 %type <RangeOp>               rangeOp
+%type <Numbers>               numbers
 %type <ConvOp>                convOp
 %type <Selector>              selector
 %type <VectorAggregationExpr> vectorAggregationExpr
@@ -95,7 +103,7 @@ import (
 %type <FilterOp>              filterOp
 %type <BinOpExpr>             binOpExpr
 %type <LiteralExpr>           literalExpr
-%type <LabelReplaceExpr>      labelReplaceExpr
+%type <LabelReplaceExpr>      labelReplaceExpr labelFunctionExpr
 %type <BinOpModifier>         binOpModifier
 %type <BoolModifier>          boolModifier
 %type <OnOrIgnoringModifier>  onOrIgnoringModifier
@@ -112,6 +120,7 @@ import (
 %type <OrFilter>              orFilter
 %type <ParserFlags>           parserFlags
 %type <LineFormatExpr>        lineFormatExpr
+%type <PipelineStage>         jsonFormatExpr
 %type <DecolorizeExpr>        decolorizeExpr
 %type <DropLabelsExpr>        dropLabelsExpr
 %type <DropLabels>            dropLabels
@@ -119,6 +128,14 @@ import (
 %type <KeepLabelsExpr>        keepLabelsExpr
 %type <KeepLabels>            keepLabels
 %type <KeepLabel>             keepLabel
+%type <SortByExpr>            sortByExpr
+%type <LimitExpr>             limitExpr
+%type <PipelineStage>         macroExpr
+%type <PipelineStage>         csvParser
+%type <PipelineStage>         sdParser
+%type <PipelineStage>         xmlParser
+%type <PipelineStage>         dedupExpr
+%type <PipelineStage>         enrichmentExpr
 %type <LabelFormatExpr>       labelFormatExpr
 %type <LabelFormat>           labelFormat
 %type <LabelsFormat>          labelsFormat
@@ -132,14 +149,16 @@ import (
 %type <OffsetExpr>            offsetExpr
 
 %token <bytes> BYTES
-%token <str>      IDENTIFIER STRING NUMBER PARSER_FLAG
+%token <str>      IDENTIFIER STRING NUMBER PARSER_FLAG MACRO
 %token <duration> DURATION RANGE
-%token <val>      MATCHERS LABELS EQ RE NRE NPA OPEN_BRACE CLOSE_BRACE OPEN_BRACKET CLOSE_BRACKET COMMA DOT PIPE_MATCH PIPE_EXACT PIPE_PATTERN
-                  OPEN_PARENTHESIS CLOSE_PARENTHESIS BY WITHOUT COUNT_OVER_TIME RATE RATE_COUNTER SUM SORT SORT_DESC AVG MAX MIN COUNT STDDEV STDVAR BOTTOMK TOPK
-                  BYTES_OVER_TIME BYTES_RATE BOOL JSON REGEXP LOGFMT PIPE LINE_FMT LABEL_FMT UNWRAP AVG_OVER_TIME SUM_OVER_TIME MIN_OVER_TIME
+%token <subqueryRange> SUBQUERY_RANGE
+%token <val>      MATCHERS LABELS EQ RE NRE NPA OPEN_BRACE CLOSE_BRACE OPEN_BRACKET CLOSE_BRACKET COMMA DOT PIPE_MATCH PIPE_EXACT PIPE_PATTERN PIPE_SEARCH
+                  PIPE_EXACT_FOLD NEQ_FOLD PIPE_WORD NEQ_WORD
+                  OPEN_PARENTHESIS OPEN_SUBQUERY CLOSE_PARENTHESIS BY WITHOUT COUNT_OVER_TIME RATE RATE_COUNTER SUM SORT SORT_DESC AVG MAX MIN COUNT STDDEV STDVAR BOTTOMK TOPK
+                  BYTES_OVER_TIME BYTES_RATE BOOL JSON REGEXP EXTRACT LOGFMT PIPE LINE_FMT LABEL_FMT JSON_FMT UNWRAP AVG_OVER_TIME SUM_OVER_TIME MIN_OVER_TIME
                   MAX_OVER_TIME STDVAR_OVER_TIME STDDEV_OVER_TIME QUANTILE_OVER_TIME BYTES_CONV DURATION_CONV DURATION_SECONDS_CONV
-                  FIRST_OVER_TIME LAST_OVER_TIME ABSENT_OVER_TIME VECTOR LABEL_REPLACE UNPACK OFFSET PATTERN IP ON IGNORING GROUP_LEFT GROUP_RIGHT
-                  DECOLORIZE DROP KEEP
+                  FIRST_OVER_TIME LAST_OVER_TIME ABSENT_OVER_TIME HISTOGRAM_OVER_TIME COUNT_DISTINCT_OVER_TIME DERIV PREDICT_LINEAR HOLT_WINTERS CHANGES_VS DAY_OVER_DAY WEEK_OVER_WEEK VECTOR LABEL_REPLACE LABEL_JOIN LABEL_MAP LABEL_LOWER LABEL_UPPER LABEL_TRUNCATE UNPACK OFFSET PATTERN IP ON IGNORING GROUP_LEFT GROUP_RIGHT
+                  DECOLORIZE DROP KEEP JOIN WITHIN SORT_BY LIMIT CSV SD XML DEDUP GEOIP CIDR_LABEL
 
 // Operators are listed with increasing precedence.
 %left <binOp> OR
@@ -160,10 +179,13 @@ expr:
 
 metricExpr:
       rangeAggregationExpr                          { $$ = $1 }
+    | subqueryExpr                                  { $$ = $1 }
+    | changesVsExpr                                 { $$ = $1 }
     | vectorAggregationExpr                         { $$ = $1 }
     | binOpExpr                                     { $$ = $1 }
     | literalExpr                                   { $$ = $1 }
     | labelReplaceExpr                              { $$ = $1 }
+    | labelFunctionExpr                             { $$ = $1 }
     | vectorExpr                                    { $$ = $1 }
     | OPEN_PARENTHESIS metricExpr CLOSE_PARENTHESIS { $$ = $2 }
     ;
@@ -171,9 +193,15 @@ metricExpr:
 logExpr:
       selector                                    { $$ = newMatcherExpr($1)}
     | selector pipelineExpr                       { $$ = newPipelineExpr(newMatcherExpr($1), $2)}
+    | joinExpr                                    { $$ = $1 }
     | OPEN_PARENTHESIS logExpr CLOSE_PARENTHESIS  { $$ = $2 }
     ;
 
+joinExpr:
+      selector PIPE JOIN ON OPEN_PARENTHESIS labels CLOSE_PARENTHESIS WITHIN DURATION logExpr                { $$ = newJoinExpr(newMatcherExpr($1), $6, $9, $10) }
+    | selector pipelineExpr PIPE JOIN ON OPEN_PARENTHESIS labels CLOSE_PARENTHESIS WITHIN DURATION logExpr   { $$ = newJoinExpr(newPipelineExpr(newMatcherExpr($1), $2), $7, $10, $11) }
+    ;
+
 logRangeExpr:
       selector RANGE                                                                        { $$ = newLogRange(newMatcherExpr($1), $2, nil, nil ) }
     | selector RANGE offsetExpr                                                             { $$ = newLogRange(newMatcherExpr($1), $2, nil, $3 ) }
@@ -206,9 +234,20 @@ logRangeExpr:
 unwrapExpr:
     PIPE UNWRAP IDENTIFIER                                                   { $$ = newUnwrapExpr($3, "")}
   | PIPE UNWRAP convOp OPEN_PARENTHESIS IDENTIFIER CLOSE_PARENTHESIS         { $$ = newUnwrapExpr($5, $3)}
+  | PIPE UNWRAP OPEN_PARENTHESIS unwrapLabels CLOSE_PARENTHESIS              { $$ = newMultiUnwrapExpr($4)}
   | unwrapExpr PIPE labelFilter                                              { $$ = $1.addPostFilter($3) }
   ;
 
+unwrapLabels:
+    unwrapLabel                          { $$ = $1 }
+  | unwrapLabels COMMA unwrapLabel       { $$ = append($1, $3...) }
+  ;
+
+unwrapLabel:
+    IDENTIFIER                                              { $$ = []string{"", $1} }
+  | convOp OPEN_PARENTHESIS IDENTIFIER CLOSE_PARENTHESIS    { $$ = []string{$1, $3} }
+  ;
+
 convOp:
     BYTES_CONV              { $$ = OpConvBytes }
   | DURATION_CONV           { $$ = OpConvDuration }
@@ -220,6 +259,29 @@ rangeAggregationExpr:
     | rangeOp OPEN_PARENTHESIS NUMBER COMMA logRangeExpr CLOSE_PARENTHESIS           { $$ = newRangeAggregationExpr($5, $1, nil, &$3) }
     | rangeOp OPEN_PARENTHESIS logRangeExpr CLOSE_PARENTHESIS grouping               { $$ = newRangeAggregationExpr($3, $1, $5, nil) }
     | rangeOp OPEN_PARENTHESIS NUMBER COMMA logRangeExpr CLOSE_PARENTHESIS grouping  { $$ = newRangeAggregationExpr($5, $1, $7, &$3) }
+    | rangeOp OPEN_PARENTHESIS logRangeExpr COMMA numbers CLOSE_PARENTHESIS                   { $$ = newRangeAggregationExprWithArgs($3, $1, $5) }
+    | HISTOGRAM_OVER_TIME OPEN_PARENTHESIS logRangeExpr COMMA numbers CLOSE_PARENTHESIS           { $$ = newHistogramRangeAggregationExpr($3, nil, $5) }
+    | HISTOGRAM_OVER_TIME OPEN_PARENTHESIS logRangeExpr COMMA numbers CLOSE_PARENTHESIS grouping  { $$ = newHistogramRangeAggregationExpr($3, $7, $5) }
+    ;
+
+numbers:
+      NUMBER               { $$ = []string{ $1 } }
+    | numbers COMMA NUMBER { $$ = append($1, $3) }
+    ;
+
+subqueryExpr:
+      rangeOp OPEN_SUBQUERY metricExpr SUBQUERY_RANGE CLOSE_PARENTHESIS                               { $$ = newSubqueryExpr($3, $1, $4, nil, nil) }
+    | rangeOp OPEN_SUBQUERY metricExpr SUBQUERY_RANGE offsetExpr CLOSE_PARENTHESIS                    { $$ = newSubqueryExpr($3, $1, $4, $5, nil) }
+    | rangeOp OPEN_SUBQUERY NUMBER COMMA metricExpr SUBQUERY_RANGE CLOSE_PARENTHESIS                  { $$ = newSubqueryExpr($5, $1, $6, nil, &$3) }
+    | rangeOp OPEN_SUBQUERY NUMBER COMMA metricExpr SUBQUERY_RANGE offsetExpr CLOSE_PARENTHESIS       { $$ = newSubqueryExpr($5, $1, $6, $7, &$3) }
+    | rangeOp OPEN_SUBQUERY metricExpr SUBQUERY_RANGE COMMA numbers CLOSE_PARENTHESIS                 { $$ = newSubqueryExprWithArgs($3, $1, $4, nil, $6) }
+    | rangeOp OPEN_SUBQUERY metricExpr SUBQUERY_RANGE offsetExpr COMMA numbers CLOSE_PARENTHESIS      { $$ = newSubqueryExprWithArgs($3, $1, $4, $5, $7) }
+    ;
+
+changesVsExpr:
+      CHANGES_VS OPEN_PARENTHESIS metricExpr COMMA DURATION CLOSE_PARENTHESIS  { $$ = newChangesVsExpr($3, $5) }
+    | DAY_OVER_DAY OPEN_PARENTHESIS metricExpr CLOSE_PARENTHESIS              { $$ = newChangesVsExpr($3, 24 * time.Hour) }
+    | WEEK_OVER_WEEK OPEN_PARENTHESIS metricExpr CLOSE_PARENTHESIS            { $$ = newChangesVsExpr($3, 7 * 24 * time.Hour) }
     ;
 
 vectorAggregationExpr:
@@ -238,6 +300,32 @@ labelReplaceExpr:
       { $$ = mustNewLabelReplaceExpr($3, $5, $7, $9, $11)}
     ;
 
+labelFunctionExpr:
+      LABEL_JOIN OPEN_PARENTHESIS metricExpr COMMA STRING COMMA STRING CLOSE_PARENTHESIS
+      { $$ = newLabelFunctionExpr($3, OpLabelJoin, $5, nil, $7, nil, 0) }
+    | LABEL_JOIN OPEN_PARENTHESIS metricExpr COMMA STRING COMMA STRING COMMA strings CLOSE_PARENTHESIS
+      { $$ = newLabelFunctionExpr($3, OpLabelJoin, $5, $9, $7, nil, 0) }
+    | LABEL_MAP OPEN_PARENTHESIS metricExpr COMMA STRING COMMA STRING COMMA OPEN_BRACE labelMap CLOSE_BRACE CLOSE_PARENTHESIS
+      { $$ = newLabelMapExpr($3, $5, $7, $10) }
+    | LABEL_LOWER OPEN_PARENTHESIS metricExpr COMMA STRING COMMA STRING CLOSE_PARENTHESIS
+      { $$ = newLabelFunctionExpr($3, OpLabelLower, $5, []string{$7}, "", nil, 0) }
+    | LABEL_UPPER OPEN_PARENTHESIS metricExpr COMMA STRING COMMA STRING CLOSE_PARENTHESIS
+      { $$ = newLabelFunctionExpr($3, OpLabelUpper, $5, []string{$7}, "", nil, 0) }
+    | LABEL_TRUNCATE OPEN_PARENTHESIS metricExpr COMMA STRING COMMA STRING COMMA NUMBER CLOSE_PARENTHESIS
+      { $$ = newLabelTruncateExpr($3, $5, $7, $9) }
+    ;
+
+strings:
+      STRING                 { $$ = []string{$1} }
+    | strings COMMA STRING   { $$ = append($1, $3) }
+    ;
+
+// labelMap holds the key value pairs of a label_map mapping one after the other.
+labelMap:
+      STRING EQ STRING                  { $$ = []string{$1, $3} }
+    | labelMap COMMA STRING EQ STRING   { $$ = append($1, $3, $5) }
+    ;
+
 filter:
       PIPE_MATCH                       { $$ = log.LineMatchRegexp }
     | PIPE_EXACT                       { $$ = log.LineMatchEqual }
@@ -245,6 +333,10 @@ filter:
     | NRE                              { $$ = log.LineMatchNotRegexp }
     | NEQ                              { $$ = log.LineMatchNotEqual }
     | NPA                              { $$ = log.LineMatchNotPattern }
+    | PIPE_EXACT_FOLD                  { $$ = log.LineMatchEqualFold }
+    | NEQ_FOLD                         { $$ = log.LineMatchNotEqualFold }
+    | PIPE_WORD                        { $$ = log.LineMatchWord }
+    | NEQ_WORD                         { $$ = log.LineMatchNotWord }
     ;
 
 selector:
@@ -272,16 +364,27 @@ pipelineExpr:
 
 pipelineStage:
    lineFilters                   { $$ = $1 }
+  | PIPE_SEARCH STRING             { $$ = newSearchExpr(nil, $2) }
+  | PIPE_SEARCH parserFlags STRING { $$ = newSearchExpr($2, $3) }
   | PIPE logfmtParser            { $$ = $2 }
   | PIPE labelParser             { $$ = $2 }
   | PIPE jsonExpressionParser    { $$ = $2 }
   | PIPE logfmtExpressionParser  { $$ = $2 }
+  | PIPE csvParser               { $$ = $2 }
+  | PIPE sdParser                { $$ = $2 }
+  | PIPE xmlParser               { $$ = $2 }
   | PIPE labelFilter             { $$ = &LabelFilterExpr{LabelFilterer: $2 }}
   | PIPE lineFormatExpr          { $$ = $2 }
+  | PIPE jsonFormatExpr          { $$ = $2 }
   | PIPE decolorizeExpr          { $$ = $2 }
   | PIPE labelFormatExpr         { $$ = $2 }
   | PIPE dropLabelsExpr          { $$ = $2 }
   | PIPE keepLabelsExpr          { $$ = $2 }
+  | PIPE sortByExpr              { $$ = $2 }
+  | PIPE limitExpr               { $$ = $2 }
+  | PIPE dedupExpr               { $$ = $2 }
+  | PIPE enrichmentExpr          { $$ = $2 }
+  | PIPE macroExpr               { $$ = $2 }
   ;
 
 filterOp:
@@ -319,10 +422,32 @@ logfmtParser:
 labelParser:
     JSON                { $$ = newLabelParserExpr(OpParserTypeJSON, "") }
   | REGEXP STRING       { $$ = newLabelParserExpr(OpParserTypeRegexp, $2) }
+  | EXTRACT STRING      { $$ = newLabelParserExpr(OpParserTypeExtract, $2) }
   | UNPACK              { $$ = newLabelParserExpr(OpParserTypeUnpack, "") }
   | PATTERN STRING      { $$ = newLabelParserExpr(OpParserTypePattern, $2) }
   ;
 
+csvParser:
+    CSV                                       { $$ = newCSVParserExpr("", nil) }
+  | CSV STRING                                { $$ = newCSVParserExpr($2, nil) }
+  | CSV labelExtractionExpressionList         { $$ = newCSVParserExpr("", $2) }
+  | CSV STRING labelExtractionExpressionList  { $$ = newCSVParserExpr($2, $3) }
+  ;
+
+sdParser:
+    SD                                               { $$ = newSDParserExpr(nil, nil) }
+  | SD parserFlags                                   { $$ = newSDParserExpr($2, nil) }
+  | SD labelExtractionExpressionList                 { $$ = newSDParserExpr(nil, $2) }
+  | SD parserFlags labelExtractionExpressionList     { $$ = newSDParserExpr($2, $3) }
+  ;
+
+xmlParser:
+    XML                                              { $$ = newXMLParserExpr(nil, nil) }
+  | XML parserFlags                                  { $$ = newXMLParserExpr($2, nil) }
+  | XML labelExtractionExpressionList                { $$ = newXMLParserExpr(nil, $2) }
+  | XML parserFlags labelExtractionExpressionList    { $$ = newXMLParserExpr($2, $3) }
+  ;
+
 jsonExpressionParser:
     JSON labelExtractionExpressionList { $$ = newJSONExpressionParser($2) }
 
@@ -333,6 +458,23 @@ logfmtExpressionParser:
 
 lineFormatExpr: LINE_FMT STRING { $$ = newLineFmtExpr($2) };
 
+jsonFormatExpr:
+      JSON_FMT                    { $$ = newJSONFmtExpr(nil) }
+    | JSON_FMT jsonFormatFields   { $$ = newJSONFmtExpr($2) }
+    ;
+
+// jsonFormatFields holds the key and label of each field one after the other.
+jsonFormatFields:
+      jsonFormatField                          { $$ = $1 }
+    | jsonFormatFields COMMA jsonFormatField   { $$ = append($1, $3...) }
+    ;
+
+jsonFormatField:
+      IDENTIFIER                  { $$ = []string{$1, $1} }
+    | IDENTIFIER EQ IDENTIFIER    { $$ = []string{$1, $3} }
+    | STRING EQ IDENTIFIER        { $$ = []string{$1, $3} }
+    ;
+
 decolorizeExpr: DECOLORIZE { $$ = newDecolorizeExpr() };
 
 labelFormat:
@@ -431,6 +573,27 @@ keepLabels:
 
 keepLabelsExpr: KEEP keepLabels { $$ = newKeepLabelsExpr($2) }
 
+sortByExpr:
+      SORT_BY OPEN_PARENTHESIS IDENTIFIER CLOSE_PARENTHESIS            { $$ = newSortByExpr($3, "") }
+    | SORT_BY OPEN_PARENTHESIS IDENTIFIER IDENTIFIER CLOSE_PARENTHESIS { $$ = newSortByExpr($3, $4) }
+    ;
+
+limitExpr: LIMIT NUMBER { $$ = newLimitExpr($2) }
+
+dedupExpr:
+      DEDUP                                                                { $$ = newDedupExpr(nil, 0) }
+    | DEDUP BY OPEN_PARENTHESIS labels CLOSE_PARENTHESIS                   { $$ = newDedupExpr($4, 0) }
+    | DEDUP WITHIN DURATION                                                { $$ = newDedupExpr(nil, $3) }
+    | DEDUP BY OPEN_PARENTHESIS labels CLOSE_PARENTHESIS WITHIN DURATION   { $$ = newDedupExpr($4, $7) }
+    ;
+
+enrichmentExpr:
+      GEOIP OPEN_PARENTHESIS IDENTIFIER CLOSE_PARENTHESIS                        { $$ = newGeoIPExpr($3) }
+    | CIDR_LABEL OPEN_PARENTHESIS IDENTIFIER COMMA STRING CLOSE_PARENTHESIS     { $$ = newCIDRLabelExpr($3, $5) }
+    ;
+
+macroExpr: MACRO OPEN_PARENTHESIS CLOSE_PARENTHESIS { $$ = newMacroExpr($1) }
+
 // Operator precedence only works if each of these is listed separately.
 binOpExpr:
          expr OR binOpModifier expr          { $$ = mustNewBinOpExpr("or", $3, $1, $4) }
@@ -563,6 +726,10 @@ rangeOp:
     | FIRST_OVER_TIME    { $$ = OpRangeTypeFirst }
     | LAST_OVER_TIME     { $$ = OpRangeTypeLast }
     | ABSENT_OVER_TIME   { $$ = OpRangeTypeAbsent }
+    | COUNT_DISTINCT_OVER_TIME { $$ = OpRangeTypeCountDistinct }
+    | DERIV              { $$ = OpRangeTypeDeriv }
+    | PREDICT_LINEAR     { $$ = OpRangeTypePredictLinear }
+    | HOLT_WINTERS       { $$ = OpRangeTypeHoltWinters }
     ;
 
 offsetExpr:
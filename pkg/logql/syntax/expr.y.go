@@ -25,7 +25,9 @@ type exprSymType struct {
 	Matcher               *labels.Matcher
 	Matchers              []*labels.Matcher
 	RangeAggregationExpr  SampleExpr
+	SubqueryExpr          SampleExpr
 	RangeOp               string
+	Numbers               []string
 	ConvOp                string
 	Selector              []*labels.Matcher
 	VectorAggregationExpr SampleExpr
@@ -40,6 +42,7 @@ type exprSymType struct {
 	bytes                 uint64
 	str                   string
 	duration              time.Duration
+	subqueryRange         subqueryRange
 	LiteralExpr           *LiteralExpr
 	BinOpModifier         *BinOpOptions
 	BoolModifier          *BinOpOptions
@@ -77,6 +80,8 @@ type exprSymType struct {
 	KeepLabel      log.KeepLabel
 	KeepLabels     []log.KeepLabel
 	KeepLabelsExpr *KeepLabelsExpr
+	SortByExpr     *SortByExpr
+	LimitExpr      *LimitExpr
 }
 
 const BYTES = 57346
@@ -84,92 +89,125 @@ const IDENTIFIER = 57347
 const STRING = 57348
 const NUMBER = 57349
 const PARSER_FLAG = 57350
-const DURATION = 57351
-const RANGE = 57352
-const MATCHERS = 57353
-const LABELS = 57354
-const EQ = 57355
-const RE = 57356
-const NRE = 57357
-const NPA = 57358
-const OPEN_BRACE = 57359
-const CLOSE_BRACE = 57360
-const OPEN_BRACKET = 57361
-const CLOSE_BRACKET = 57362
-const COMMA = 57363
-const DOT = 57364
-const PIPE_MATCH = 57365
-const PIPE_EXACT = 57366
-const PIPE_PATTERN = 57367
-const OPEN_PARENTHESIS = 57368
-const CLOSE_PARENTHESIS = 57369
-const BY = 57370
-const WITHOUT = 57371
-const COUNT_OVER_TIME = 57372
-const RATE = 57373
-const RATE_COUNTER = 57374
-const SUM = 57375
-const SORT = 57376
-const SORT_DESC = 57377
-const AVG = 57378
-const MAX = 57379
-const MIN = 57380
-const COUNT = 57381
-const STDDEV = 57382
-const STDVAR = 57383
-const BOTTOMK = 57384
-const TOPK = 57385
-const BYTES_OVER_TIME = 57386
-const BYTES_RATE = 57387
-const BOOL = 57388
-const JSON = 57389
-const REGEXP = 57390
-const LOGFMT = 57391
-const PIPE = 57392
-const LINE_FMT = 57393
-const LABEL_FMT = 57394
-const UNWRAP = 57395
-const AVG_OVER_TIME = 57396
-const SUM_OVER_TIME = 57397
-const MIN_OVER_TIME = 57398
-const MAX_OVER_TIME = 57399
-const STDVAR_OVER_TIME = 57400
-const STDDEV_OVER_TIME = 57401
-const QUANTILE_OVER_TIME = 57402
-const BYTES_CONV = 57403
-const DURATION_CONV = 57404
-const DURATION_SECONDS_CONV = 57405
-const FIRST_OVER_TIME = 57406
-const LAST_OVER_TIME = 57407
-const ABSENT_OVER_TIME = 57408
-const VECTOR = 57409
-const LABEL_REPLACE = 57410
-const UNPACK = 57411
-const OFFSET = 57412
-const PATTERN = 57413
-const IP = 57414
-const ON = 57415
-const IGNORING = 57416
-const GROUP_LEFT = 57417
-const GROUP_RIGHT = 57418
-const DECOLORIZE = 57419
-const DROP = 57420
-const KEEP = 57421
-const OR = 57422
-const AND = 57423
-const UNLESS = 57424
-const CMP_EQ = 57425
-const NEQ = 57426
-const LT = 57427
-const LTE = 57428
-const GT = 57429
-const GTE = 57430
-const ADD = 57431
-const SUB = 57432
-const MUL = 57433
-const DIV = 57434
-const MOD = 57435
-const POW = 57436
+const MACRO = 57351
+const DURATION = 57352
+const RANGE = 57353
+const SUBQUERY_RANGE = 57354
+const MATCHERS = 57355
+const LABELS = 57356
+const EQ = 57357
+const RE = 57358
+const NRE = 57359
+const NPA = 57360
+const OPEN_BRACE = 57361
+const CLOSE_BRACE = 57362
+const OPEN_BRACKET = 57363
+const CLOSE_BRACKET = 57364
+const COMMA = 57365
+const DOT = 57366
+const PIPE_MATCH = 57367
+const PIPE_EXACT = 57368
+const PIPE_PATTERN = 57369
+const PIPE_SEARCH = 57370
+const PIPE_EXACT_FOLD = 57371
+const NEQ_FOLD = 57372
+const PIPE_WORD = 57373
+const NEQ_WORD = 57374
+const OPEN_PARENTHESIS = 57375
+const OPEN_SUBQUERY = 57376
+const CLOSE_PARENTHESIS = 57377
+const BY = 57378
+const WITHOUT = 57379
+const COUNT_OVER_TIME = 57380
+const RATE = 57381
+const RATE_COUNTER = 57382
+const SUM = 57383
+const SORT = 57384
+const SORT_DESC = 57385
+const AVG = 57386
+const MAX = 57387
+const MIN = 57388
+const COUNT = 57389
+const STDDEV = 57390
+const STDVAR = 57391
+const BOTTOMK = 57392
+const TOPK = 57393
+const BYTES_OVER_TIME = 57394
+const BYTES_RATE = 57395
+const BOOL = 57396
+const JSON = 57397
+const REGEXP = 57398
+const EXTRACT = 57399
+const LOGFMT = 57400
+const PIPE = 57401
+const LINE_FMT = 57402
+const LABEL_FMT = 57403
+const JSON_FMT = 57404
+const UNWRAP = 57405
+const AVG_OVER_TIME = 57406
+const SUM_OVER_TIME = 57407
+const MIN_OVER_TIME = 57408
+const MAX_OVER_TIME = 57409
+const STDVAR_OVER_TIME = 57410
+const STDDEV_OVER_TIME = 57411
+const QUANTILE_OVER_TIME = 57412
+const BYTES_CONV = 57413
+const DURATION_CONV = 57414
+const DURATION_SECONDS_CONV = 57415
+const FIRST_OVER_TIME = 57416
+const LAST_OVER_TIME = 57417
+const ABSENT_OVER_TIME = 57418
+const HISTOGRAM_OVER_TIME = 57419
+const COUNT_DISTINCT_OVER_TIME = 57420
+const DERIV = 57421
+const PREDICT_LINEAR = 57422
+const HOLT_WINTERS = 57423
+const CHANGES_VS = 57424
+const DAY_OVER_DAY = 57425
+const WEEK_OVER_WEEK = 57426
+const VECTOR = 57427
+const LABEL_REPLACE = 57428
+const LABEL_JOIN = 57429
+const LABEL_MAP = 57430
+const LABEL_LOWER = 57431
+const LABEL_UPPER = 57432
+const LABEL_TRUNCATE = 57433
+const UNPACK = 57434
+const OFFSET = 57435
+const PATTERN = 57436
+const IP = 57437
+const ON = 57438
+const IGNORING = 57439
+const GROUP_LEFT = 57440
+const GROUP_RIGHT = 57441
+const DECOLORIZE = 57442
+const DROP = 57443
+const KEEP = 57444
+const JOIN = 57445
+const WITHIN = 57446
+const SORT_BY = 57447
+const LIMIT = 57448
+const CSV = 57449
+const SD = 57450
+const XML = 57451
+const DEDUP = 57452
+const GEOIP = 57453
+const CIDR_LABEL = 57454
+const OR = 57455
+const AND = 57456
+const UNLESS = 57457
+const CMP_EQ = 57458
+const NEQ = 57459
+const LT = 57460
+const LTE = 57461
+const GT = 57462
+const GTE = 57463
+const ADD = 57464
+const SUB = 57465
+const MUL = 57466
+const DIV = 57467
+const MOD = 57468
+const POW = 57469
 
 var exprToknames = [...]string{
 	"$end",
@@ -180,8 +218,10 @@ var exprToknames = [...]string{
 	"STRING",
 	"NUMBER",
 	"PARSER_FLAG",
+	"MACRO",
 	"DURATION",
 	"RANGE",
+	"SUBQUERY_RANGE",
 	"MATCHERS",
 	"LABELS",
 	"EQ",
@@ -197,7 +237,13 @@ var exprToknames = [...]string{
 	"PIPE_MATCH",
 	"PIPE_EXACT",
 	"PIPE_PATTERN",
+	"PIPE_SEARCH",
+	"PIPE_EXACT_FOLD",
+	"NEQ_FOLD",
+	"PIPE_WORD",
+	"NEQ_WORD",
 	"OPEN_PARENTHESIS",
+	"OPEN_SUBQUERY",
 	"CLOSE_PARENTHESIS",
 	"BY",
 	"WITHOUT",
@@ -220,10 +266,12 @@ var exprToknames = [...]string{
 	"BOOL",
 	"JSON",
 	"REGEXP",
+	"EXTRACT",
 	"LOGFMT",
 	"PIPE",
 	"LINE_FMT",
 	"LABEL_FMT",
+	"JSON_FMT",
 	"UNWRAP",
 	"AVG_OVER_TIME",
 	"SUM_OVER_TIME",
@@ -238,8 +286,21 @@ var exprToknames = [...]string{
 	"FIRST_OVER_TIME",
 	"LAST_OVER_TIME",
 	"ABSENT_OVER_TIME",
+	"HISTOGRAM_OVER_TIME",
+	"COUNT_DISTINCT_OVER_TIME",
+	"DERIV",
+	"PREDICT_LINEAR",
+	"HOLT_WINTERS",
+	"CHANGES_VS",
+	"DAY_OVER_DAY",
+	"WEEK_OVER_WEEK",
 	"VECTOR",
 	"LABEL_REPLACE",
+	"LABEL_JOIN",
+	"LABEL_MAP",
+	"LABEL_LOWER",
+	"LABEL_UPPER",
+	"LABEL_TRUNCATE",
 	"UNPACK",
 	"OFFSET",
 	"PATTERN",
@@ -251,6 +312,16 @@ var exprToknames = [...]string{
 	"DECOLORIZE",
 	"DROP",
 	"KEEP",
+	"JOIN",
+	"WITHIN",
+	"SORT_BY",
+	"LIMIT",
+	"CSV",
+	"SD",
+	"XML",
+	"DEDUP",
+	"GEOIP",
+	"CIDR_LABEL",
 	"OR",
 	"AND",
 	"UNLESS",
@@ -274,7 +345,7 @@ const exprEofCode = 1
 const exprErrCode = 2
 const exprInitialStackSize = 16
 
-//line expr.y:582
+//line expr.y:749
 
 //line yacctab:1
 var exprExca = [...]int8{
@@ -285,260 +356,388 @@ var exprExca = [...]int8{
 
 const exprPrivate = 57344
 
-const exprLast = 639
+const exprLast = 1056
 
 var exprAct = [...]int16{
-	289, 228, 84, 4, 214, 64, 182, 126, 204, 189,
-	75, 200, 197, 63, 237, 5, 152, 187, 77, 2,
-	56, 80, 48, 49, 50, 57, 58, 61, 62, 59,
-	60, 51, 52, 53, 54, 55, 56, 283, 10, 49,
-	50, 57, 58, 61, 62, 59, 60, 51, 52, 53,
-	54, 55, 56, 57, 58, 61, 62, 59, 60, 51,
-	52, 53, 54, 55, 56, 53, 54, 55, 56, 109,
-	217, 139, 215, 115, 51, 52, 53, 54, 55, 56,
-	266, 140, 221, 16, 216, 265, 292, 156, 136, 166,
-	167, 297, 262, 161, 220, 16, 294, 261, 154, 148,
-	150, 151, 366, 281, 184, 339, 16, 67, 280, 130,
-	163, 207, 150, 151, 168, 169, 170, 171, 172, 173,
-	174, 175, 176, 177, 178, 179, 180, 181, 278, 164,
-	165, 16, 366, 277, 239, 94, 293, 194, 142, 386,
-	191, 275, 202, 206, 16, 294, 274, 142, 264, 340,
-	85, 86, 292, 141, 272, 219, 316, 16, 381, 271,
-	260, 306, 235, 185, 183, 17, 18, 356, 229, 224,
-	149, 231, 232, 110, 306, 240, 294, 17, 18, 136,
-	355, 213, 208, 211, 212, 209, 210, 374, 17, 18,
-	248, 249, 250, 269, 331, 184, 16, 306, 268, 373,
-	130, 255, 306, 354, 252, 342, 343, 344, 353, 136,
-	239, 72, 74, 17, 18, 371, 363, 339, 306, 69,
-	70, 71, 285, 384, 308, 184, 17, 18, 287, 290,
-	130, 296, 314, 299, 369, 109, 302, 115, 303, 17,
-	18, 291, 154, 288, 359, 300, 263, 267, 270, 273,
-	276, 279, 282, 349, 185, 183, 330, 294, 293, 310,
-	312, 315, 317, 318, 72, 74, 202, 206, 325, 320,
-	324, 295, 69, 70, 71, 346, 72, 74, 17, 18,
-	73, 306, 239, 239, 69, 70, 71, 307, 328, 136,
-	239, 332, 304, 334, 336, 224, 338, 109, 294, 230,
-	243, 337, 348, 333, 313, 311, 109, 239, 295, 350,
-	130, 230, 241, 72, 74, 83, 224, 85, 86, 292,
-	301, 69, 70, 71, 233, 347, 144, 13, 136, 238,
-	143, 327, 153, 73, 360, 361, 155, 326, 284, 109,
-	362, 225, 13, 247, 184, 73, 364, 365, 230, 130,
-	246, 155, 370, 245, 244, 218, 160, 159, 158, 227,
-	16, 90, 89, 82, 72, 74, 376, 380, 377, 378,
-	13, 352, 69, 70, 71, 253, 298, 305, 259, 6,
-	382, 258, 73, 21, 22, 23, 36, 45, 46, 37,
-	39, 40, 38, 41, 42, 43, 44, 24, 25, 230,
-	256, 242, 234, 226, 183, 257, 254, 26, 27, 28,
-	29, 30, 31, 32, 81, 379, 368, 33, 34, 35,
-	47, 19, 236, 227, 367, 162, 345, 79, 72, 74,
-	335, 190, 13, 73, 251, 88, 69, 70, 71, 322,
-	323, 6, 17, 18, 87, 21, 22, 23, 36, 45,
-	46, 37, 39, 40, 38, 41, 42, 43, 44, 24,
-	25, 190, 375, 230, 188, 385, 383, 372, 358, 26,
-	27, 28, 29, 30, 31, 32, 146, 357, 3, 33,
-	34, 35, 47, 19, 157, 76, 329, 319, 309, 286,
-	72, 74, 145, 321, 13, 147, 198, 73, 69, 70,
-	71, 223, 222, 6, 17, 18, 136, 21, 22, 23,
-	36, 45, 46, 37, 39, 40, 38, 41, 42, 43,
-	44, 24, 25, 221, 220, 230, 195, 130, 193, 192,
-	351, 26, 27, 28, 29, 30, 31, 32, 205, 136,
-	201, 33, 34, 35, 47, 19, 190, 81, 122, 123,
-	121, 198, 131, 133, 297, 72, 74, 127, 128, 73,
-	130, 113, 114, 69, 70, 71, 17, 18, 196, 118,
-	124, 203, 125, 120, 199, 91, 119, 117, 132, 134,
-	135, 122, 123, 121, 116, 131, 133, 186, 65, 137,
-	66, 129, 138, 111, 112, 93, 92, 11, 9, 20,
-	12, 15, 8, 124, 341, 125, 14, 7, 78, 68,
-	1, 132, 134, 135, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 73, 95, 96, 97, 98, 99,
-	100, 101, 102, 103, 104, 105, 106, 107, 108,
+	3, 405, 522, 111, 402, 333, 318, 524, 98, 82,
+	4, 252, 303, 172, 284, 273, 280, 258, 97, 277,
+	257, 80, 194, 70, 71, 72, 73, 209, 5, 73,
+	397, 102, 99, 2, 65, 66, 67, 74, 75, 78,
+	79, 76, 77, 68, 69, 70, 71, 72, 73, 74,
+	75, 78, 79, 76, 77, 68, 69, 70, 71, 72,
+	73, 306, 13, 66, 67, 74, 75, 78, 79, 76,
+	77, 68, 69, 70, 71, 72, 73, 68, 69, 70,
+	71, 72, 73, 196, 567, 546, 289, 205, 207, 208,
+	142, 395, 545, 151, 24, 304, 380, 394, 310, 24,
+	349, 392, 379, 389, 24, 386, 24, 391, 24, 388,
+	527, 385, 234, 235, 232, 233, 213, 251, 216, 217,
+	218, 219, 408, 197, 85, 533, 305, 224, 225, 226,
+	227, 228, 229, 211, 215, 211, 420, 376, 413, 309,
+	24, 410, 527, 375, 408, 126, 459, 189, 418, 597,
+	596, 188, 383, 151, 290, 24, 231, 201, 382, 523,
+	236, 237, 238, 239, 240, 241, 242, 243, 244, 245,
+	246, 247, 248, 249, 588, 176, 458, 294, 207, 208,
+	112, 113, 478, 408, 199, 260, 256, 378, 270, 206,
+	265, 267, 269, 266, 268, 282, 286, 164, 165, 166,
+	163, 335, 177, 180, 178, 413, 408, 189, 192, 25,
+	26, 188, 199, 198, 25, 26, 335, 582, 308, 25,
+	26, 25, 26, 25, 26, 482, 483, 484, 374, 336,
+	410, 443, 331, 319, 167, 176, 168, 583, 409, 570,
+	322, 321, 179, 181, 182, 478, 441, 183, 184, 169,
+	170, 171, 185, 186, 187, 25, 26, 164, 165, 166,
+	163, 335, 177, 180, 178, 351, 352, 353, 335, 530,
+	25, 26, 110, 409, 553, 112, 113, 354, 300, 295,
+	298, 299, 296, 297, 565, 357, 410, 358, 189, 359,
+	313, 440, 564, 410, 167, 559, 168, 486, 438, 189,
+	549, 313, 179, 181, 182, 250, 254, 183, 184, 169,
+	170, 171, 185, 186, 187, 548, 176, 254, 401, 399,
+	189, 410, 315, 470, 406, 419, 412, 176, 415, 142,
+	422, 547, 151, 424, 314, 421, 479, 585, 254, 407,
+	335, 425, 537, 416, 404, 211, 335, 515, 176, 584,
+	360, 437, 439, 442, 444, 562, 446, 377, 381, 384,
+	387, 390, 393, 396, 481, 313, 566, 561, 510, 495,
+	337, 494, 411, 447, 467, 460, 334, 449, 90, 92,
+	282, 286, 457, 456, 452, 461, 87, 88, 89, 84,
+	93, 94, 95, 96, 469, 426, 487, 253, 417, 373,
+	344, 329, 482, 483, 484, 328, 558, 255, 253, 327,
+	465, 475, 471, 477, 473, 189, 200, 142, 552, 188,
+	320, 480, 476, 488, 428, 491, 142, 472, 255, 253,
+	551, 17, 469, 104, 105, 520, 544, 428, 496, 464,
+	317, 428, 577, 176, 532, 581, 90, 92, 189, 511,
+	428, 508, 463, 509, 87, 88, 89, 84, 93, 94,
+	95, 96, 507, 428, 414, 164, 165, 166, 163, 428,
+	177, 180, 178, 428, 517, 506, 176, 469, 91, 518,
+	519, 505, 142, 563, 490, 504, 469, 525, 320, 493,
+	445, 428, 526, 428, 534, 531, 489, 535, 468, 536,
+	398, 560, 167, 430, 168, 429, 189, 369, 350, 17,
+	179, 181, 182, 143, 210, 183, 184, 169, 170, 171,
+	185, 186, 187, 212, 254, 348, 17, 347, 346, 345,
+	555, 556, 554, 307, 176, 293, 292, 291, 287, 223,
+	212, 222, 221, 122, 121, 120, 91, 119, 90, 92,
+	118, 117, 24, 116, 109, 571, 87, 88, 89, 573,
+	93, 94, 95, 96, 17, 108, 107, 106, 593, 580,
+	503, 594, 502, 501, 500, 499, 498, 462, 7, 313,
+	589, 203, 590, 34, 35, 36, 53, 62, 63, 54,
+	56, 57, 55, 58, 59, 60, 61, 37, 38, 202,
+	325, 355, 204, 427, 367, 366, 364, 361, 343, 39,
+	40, 41, 42, 43, 44, 45, 342, 341, 340, 46,
+	47, 48, 19, 49, 50, 51, 52, 20, 21, 22,
+	64, 27, 28, 29, 30, 31, 32, 411, 332, 339,
+	338, 330, 326, 90, 92, 324, 316, 103, 91, 600,
+	17, 87, 88, 89, 84, 93, 94, 95, 96, 595,
+	365, 363, 101, 362, 7, 356, 492, 25, 26, 34,
+	35, 36, 53, 62, 63, 54, 56, 57, 55, 58,
+	59, 60, 61, 37, 38, 320, 323, 557, 529, 528,
+	485, 579, 569, 568, 474, 39, 40, 41, 42, 43,
+	44, 45, 423, 370, 578, 46, 47, 48, 19, 49,
+	50, 51, 52, 20, 21, 22, 64, 27, 28, 29,
+	30, 31, 32, 259, 220, 301, 302, 302, 259, 403,
+	193, 195, 195, 516, 454, 455, 17, 274, 275, 572,
+	288, 259, 264, 91, 230, 115, 114, 601, 599, 598,
+	7, 592, 591, 25, 26, 34, 35, 36, 53, 62,
+	63, 54, 56, 57, 55, 58, 59, 60, 61, 37,
+	38, 587, 576, 574, 543, 542, 541, 540, 539, 538,
+	514, 39, 40, 41, 42, 43, 44, 45, 513, 512,
+	466, 46, 47, 48, 19, 49, 50, 51, 52, 20,
+	21, 22, 64, 27, 28, 29, 30, 31, 32, 453,
+	214, 448, 278, 173, 436, 435, 434, 433, 432, 90,
+	92, 431, 17, 400, 312, 311, 310, 87, 88, 89,
+	84, 93, 94, 95, 96, 309, 7, 271, 263, 25,
+	26, 34, 35, 36, 53, 62, 63, 54, 56, 57,
+	55, 58, 59, 60, 61, 37, 38, 262, 261, 550,
+	335, 320, 497, 285, 281, 451, 450, 39, 40, 41,
+	42, 43, 44, 45, 259, 372, 371, 46, 47, 48,
+	19, 49, 50, 51, 52, 20, 21, 22, 64, 27,
+	28, 29, 30, 31, 32, 408, 317, 368, 103, 278,
+	174, 146, 90, 92, 147, 276, 155, 161, 160, 150,
+	87, 88, 89, 84, 93, 94, 95, 96, 149, 91,
+	90, 92, 148, 162, 159, 25, 26, 158, 87, 88,
+	89, 84, 93, 94, 95, 96, 123, 90, 92, 283,
+	157, 279, 156, 154, 320, 87, 88, 89, 84, 93,
+	94, 95, 96, 153, 152, 90, 92, 83, 190, 175,
+	191, 144, 320, 87, 88, 89, 84, 93, 94, 95,
+	96, 145, 125, 124, 15, 14, 12, 33, 16, 141,
+	23, 11, 18, 9, 8, 100, 10, 6, 521, 272,
+	586, 575, 86, 1, 0, 0, 0, 81, 0, 0,
+	0, 0, 91, 127, 128, 129, 130, 131, 132, 133,
+	134, 135, 136, 137, 138, 139, 140, 0, 0, 0,
+	91, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 91, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 91,
 }
 
 var exprPact = [...]int16{
-	353, -1000, -58, -1000, -1000, 540, 353, -1000, -1000, -1000,
-	-1000, -1000, -1000, 409, 337, 289, -1000, 437, 428, 336,
-	335, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	545, -1000, -79, -1000, -1000, 938, -1000, 545, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, 642, 400, 534,
+	533, 532, 521, 239, -1000, 739, 738, 520, 518, 517,
+	514, 512, 511, 510, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, 89, 89,
-	89, 89, 89, 89, 89, 89, 89, 89, 89, 89,
-	89, 89, 89, 540, -1000, 196, 534, -9, 75, -1000,
-	-1000, -1000, -1000, -1000, -1000, 303, 299, -58, 474, -1000,
-	-1000, 86, 325, 477, 332, 331, 330, -1000, -1000, 353,
-	418, 353, 56, 14, -1000, 353, 353, 353, 353, 353,
-	353, 353, 353, 353, 353, 353, 353, 353, 353, -1000,
-	-1000, -1000, -1000, -1000, -1000, 83, -1000, -1000, -1000, -1000,
-	-1000, 456, 541, 523, -1000, 522, -1000, -1000, -1000, -1000,
-	284, 520, -1000, 546, 535, 533, 98, -1000, -1000, 66,
-	-10, 329, -1000, -1000, -1000, -1000, -1000, 542, 518, 517,
-	496, 495, 314, 382, 413, 310, 297, 381, 415, 302,
-	285, 380, 273, -42, 328, 327, 324, 317, -30, -30,
-	-26, -26, -74, -74, -74, -74, -15, -15, -15, -15,
-	-15, -15, 83, 284, 284, 284, 426, 354, -1000, -1000,
-	393, 354, -1000, -1000, 174, -1000, 379, -1000, 392, 360,
-	-1000, 86, -1000, 357, -1000, 86, -1000, 88, 76, 189,
-	150, 137, 124, 99, -1000, -43, 312, 66, 483, -1000,
-	-1000, -1000, -1000, -1000, -1000, 122, 310, 249, 126, 261,
-	501, 349, 293, 122, 353, 265, 356, 260, -1000, -1000,
-	197, -1000, 482, -1000, 278, 277, 205, 129, 204, 83,
-	323, -1000, 354, 541, 481, -1000, 491, 434, 535, 533,
-	311, -1000, -1000, -1000, 305, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, -1000, 66, 480, -1000, 229, -1000, 167, 475,
-	46, 475, 421, 16, 284, 16, 95, 144, 416, 248,
-	298, -1000, -1000, 226, -1000, 353, 525, -1000, -1000, 350,
-	181, -1000, 176, -1000, -1000, 153, -1000, 140, -1000, -1000,
-	-1000, -1000, -1000, -1000, -1000, -1000, 471, 462, -1000, 217,
-	-1000, 122, 46, 475, 46, -1000, -1000, 83, -1000, 16,
-	-1000, 190, -1000, -1000, -1000, 82, 414, 406, 207, 122,
-	188, -1000, 461, -1000, -1000, -1000, -1000, 172, 160, -1000,
-	-1000, 46, -1000, 457, 52, 46, 38, 16, 16, 405,
-	-1000, -1000, 346, -1000, -1000, 131, 46, -1000, -1000, 16,
-	460, -1000, -1000, 202, 459, 112, -1000,
+	-1000, -1000, -1000, -1000, -1000, 91, 91, 91, 91, 91,
+	91, 91, 91, 91, 91, 91, 91, 91, 91, 91,
+	920, 410, -1000, 531, 724, -30, 117, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, 381, 122, -79,
+	579, -1000, -1000, 72, 507, 803, 490, 545, 545, 545,
+	717, 509, 508, 506, -1000, -1000, 545, 545, 545, 545,
+	545, 545, 737, 545, 18, 14, -1000, 545, 545, 545,
+	545, 545, 545, 545, 545, 545, 545, 545, 545, 545,
+	545, 202, -1000, 21, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, 294, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, 723, 869, 852, 851, -1000, 832, 736,
+	723, 723, -1000, -1000, -1000, -1000, 443, 831, 732, -1000,
+	894, 859, 858, 505, 733, 50, 504, 503, 502, 162,
+	-1000, -1000, -1000, -1000, 719, -1000, 89, -52, 500, -1000,
+	-1000, -1000, -1000, -1000, 893, 829, 820, 819, 818, 299,
+	623, 885, 490, 674, 622, 577, 619, 374, 370, 366,
+	618, 631, 341, 335, 617, 616, 595, 594, 593, 585,
+	365, -51, 496, 495, 494, 492, -67, -67, -101, -101,
+	-98, -98, -98, -98, -45, -45, -45, -45, -45, -45,
+	4, 475, 294, 443, 443, 443, 718, 578, -1000, 650,
+	578, -1000, -1000, -1000, 869, 578, 718, 578, 718, 578,
+	315, -1000, 584, -1000, 648, 646, 583, -1000, 645, 582,
+	-1000, 72, -1000, 581, -1000, 72, -1000, 892, -1000, 474,
+	693, 871, 870, 364, 133, 92, 148, 101, 99, 97,
+	87, -1000, -1000, -1000, -83, 467, 89, 817, -1000, -1000,
+	-1000, -1000, -1000, -1000, 144, 722, 490, 802, 227, 626,
+	142, 429, 363, 113, 545, 722, 692, -1000, -1000, 144,
+	545, 360, 580, 470, -1000, -1000, 468, -1000, 815, 812,
+	811, 810, 809, 808, -1000, 263, 256, 211, 196, 457,
+	855, 501, 294, 283, 578, 869, 805, 578, 578, 578,
+	-1000, 732, 861, 860, 807, 729, 859, 858, 141, 855,
+	-1000, 350, 554, -1000, 419, -1000, -1000, -1000, 406, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, 89, 784, -1000,
+	339, -1000, 463, -1000, 288, 903, 82, 903, 684, 29,
+	443, 29, 171, 331, 679, 262, 361, -1000, -1000, 461,
+	722, 654, 454, 336, -1000, 334, -1000, 545, 857, -1000,
+	-1000, 553, 552, 551, 550, 549, 547, 450, -1000, 446,
+	-1000, -1000, 440, -1000, 427, 855, 418, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, 333,
+	414, -1000, 783, 782, 774, -1000, 312, -1000, -1000, 726,
+	144, 82, 903, 82, -1000, -1000, 294, -1000, 29, -1000,
+	402, 154, -1000, -1000, -1000, 51, 678, 677, 234, -1000,
+	722, 409, 90, 144, -1000, 144, 307, -1000, 773, 772,
+	771, 770, 769, 768, -1000, -1000, -1000, -1000, 401, -12,
+	-1000, -19, 296, 280, 265, -1000, -1000, -1000, 82, -1000,
+	854, 395, -1000, -1000, 241, 83, 82, 75, 29, 29,
+	676, 371, -1000, -1000, 260, -1000, -1000, -1000, 478, 332,
+	460, 257, 249, 343, -20, 683, 682, -1000, -1000, -1000,
+	204, -1000, 154, 734, 82, -1000, -1000, 29, -1000, -1000,
+	767, -1000, 766, 423, -1000, -1000, 697, 681, 412, -1000,
+	-1000, -1000, 182, -1000, 214, 314, -1000, 765, 139, 412,
+	-1000, 412, -1000, 746, -1000, 745, 548, 644, -1000, -1000,
+	122, 115, -1000, 114, 743, 742, -1000, -1000, 634, -1000,
+	741, -1000,
 }
 
 var exprPgo = [...]int16{
-	0, 610, 18, 609, 2, 14, 478, 3, 16, 7,
-	608, 607, 606, 604, 15, 602, 601, 600, 599, 84,
-	598, 38, 597, 575, 596, 595, 594, 593, 13, 5,
-	592, 591, 589, 6, 588, 107, 4, 587, 584, 577,
-	576, 574, 11, 573, 571, 8, 569, 12, 568, 9,
-	17, 562, 561, 1, 558, 557, 0,
+	0, 993, 32, 992, 3, 5, 991, 990, 989, 15,
+	988, 2, 0, 987, 10, 986, 27, 13, 985, 984,
+	983, 982, 4, 7, 28, 981, 980, 978, 977, 126,
+	976, 62, 975, 974, 936, 973, 972, 971, 961, 21,
+	9, 960, 959, 958, 11, 957, 124, 12, 22, 954,
+	953, 943, 942, 941, 16, 940, 939, 14, 927, 924,
+	923, 922, 918, 909, 908, 907, 906, 19, 905, 17,
+	20, 904, 901, 6, 900, 813, 1,
 }
 
 var exprR1 = [...]int8{
-	0, 1, 2, 2, 7, 7, 7, 7, 7, 7,
-	7, 6, 6, 6, 8, 8, 8, 8, 8, 8,
-	8, 8, 8, 8, 8, 8, 8, 8, 8, 8,
-	8, 8, 8, 8, 8, 8, 8, 8, 8, 8,
-	53, 53, 53, 13, 13, 13, 11, 11, 11, 11,
-	15, 15, 15, 15, 15, 15, 22, 3, 3, 3,
-	3, 3, 3, 14, 14, 14, 10, 10, 9, 9,
-	9, 9, 28, 28, 29, 29, 29, 29, 29, 29,
-	29, 29, 29, 29, 29, 19, 36, 36, 36, 35,
-	35, 35, 34, 34, 34, 37, 37, 27, 27, 26,
-	26, 26, 26, 52, 51, 51, 38, 39, 47, 47,
-	48, 48, 48, 46, 33, 33, 33, 33, 33, 33,
-	33, 33, 33, 49, 49, 50, 50, 55, 55, 54,
-	54, 32, 32, 32, 32, 32, 32, 32, 30, 30,
-	30, 30, 30, 30, 30, 31, 31, 31, 31, 31,
-	31, 31, 42, 42, 41, 41, 40, 45, 45, 44,
-	44, 43, 20, 20, 20, 20, 20, 20, 20, 20,
-	20, 20, 20, 20, 20, 20, 20, 24, 24, 25,
-	25, 25, 25, 23, 23, 23, 23, 23, 23, 23,
-	23, 21, 21, 21, 17, 18, 16, 16, 16, 16,
-	16, 16, 16, 16, 16, 16, 16, 12, 12, 12,
-	12, 12, 12, 12, 12, 12, 12, 12, 12, 12,
-	12, 12, 56, 5, 5, 4, 4, 4, 4,
+	0, 1, 2, 2, 14, 14, 14, 14, 14, 14,
+	14, 14, 14, 14, 12, 12, 12, 12, 13, 13,
+	16, 16, 16, 16, 16, 16, 16, 16, 16, 16,
+	16, 16, 16, 16, 16, 16, 16, 16, 16, 16,
+	16, 16, 16, 16, 16, 16, 73, 73, 73, 73,
+	10, 10, 11, 11, 23, 23, 23, 19, 19, 19,
+	19, 19, 19, 19, 22, 22, 20, 20, 20, 20,
+	20, 20, 15, 15, 15, 25, 25, 25, 25, 25,
+	25, 32, 33, 33, 33, 33, 33, 33, 6, 6,
+	7, 7, 3, 3, 3, 3, 3, 3, 3, 3,
+	3, 3, 24, 24, 24, 18, 18, 17, 17, 17,
+	17, 39, 39, 40, 40, 40, 40, 40, 40, 40,
+	40, 40, 40, 40, 40, 40, 40, 40, 40, 40,
+	40, 40, 40, 40, 40, 29, 47, 47, 47, 46,
+	46, 46, 45, 45, 45, 48, 48, 38, 38, 37,
+	37, 37, 37, 37, 61, 61, 61, 61, 62, 62,
+	62, 62, 63, 63, 63, 63, 72, 71, 71, 49,
+	50, 50, 8, 8, 9, 9, 9, 51, 67, 67,
+	68, 68, 68, 66, 44, 44, 44, 44, 44, 44,
+	44, 44, 44, 69, 69, 70, 70, 75, 75, 74,
+	74, 43, 43, 43, 43, 43, 43, 43, 41, 41,
+	41, 41, 41, 41, 41, 42, 42, 42, 42, 42,
+	42, 42, 54, 54, 53, 53, 52, 57, 57, 56,
+	56, 55, 58, 58, 59, 64, 64, 64, 64, 65,
+	65, 60, 30, 30, 30, 30, 30, 30, 30, 30,
+	30, 30, 30, 30, 30, 30, 30, 35, 35, 36,
+	36, 36, 36, 34, 34, 34, 34, 34, 34, 34,
+	34, 31, 31, 31, 27, 28, 26, 26, 26, 26,
+	26, 26, 26, 26, 26, 26, 26, 21, 21, 21,
+	21, 21, 21, 21, 21, 21, 21, 21, 21, 21,
+	21, 21, 21, 21, 21, 21, 76, 5, 5, 4,
+	4, 4, 4,
 }
 
 var exprR2 = [...]int8{
 	0, 1, 1, 1, 1, 1, 1, 1, 1, 1,
-	3, 1, 2, 3, 2, 3, 4, 5, 3, 4,
-	5, 6, 3, 4, 5, 6, 3, 4, 5, 6,
-	4, 5, 6, 7, 3, 4, 4, 5, 3, 2,
-	3, 6, 3, 1, 1, 1, 4, 6, 5, 7,
-	4, 5, 5, 6, 7, 7, 12, 1, 1, 1,
-	1, 1, 1, 3, 3, 2, 1, 3, 3, 3,
-	3, 3, 1, 2, 1, 2, 2, 2, 2, 2,
+	1, 1, 1, 3, 1, 2, 1, 3, 10, 11,
+	2, 3, 4, 5, 3, 4, 5, 6, 3, 4,
+	5, 6, 3, 4, 5, 6, 4, 5, 6, 7,
+	3, 4, 4, 5, 3, 2, 3, 6, 5, 3,
+	1, 3, 1, 4, 1, 1, 1, 4, 6, 5,
+	7, 6, 6, 7, 1, 3, 5, 6, 7, 8,
+	7, 8, 6, 4, 4, 4, 5, 5, 6, 7,
+	7, 12, 8, 10, 12, 8, 8, 10, 1, 3,
+	3, 5, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 3, 3, 2, 1, 3, 3, 3, 3,
+	3, 1, 2, 1, 2, 3, 2, 2, 2, 2,
+	2, 2, 2, 2, 2, 2, 2, 2, 2, 2,
 	2, 2, 2, 2, 2, 1, 1, 4, 3, 2,
 	5, 4, 1, 3, 2, 1, 2, 1, 2, 1,
-	2, 1, 2, 2, 3, 2, 2, 1, 3, 3,
+	2, 2, 1, 2, 1, 2, 2, 3, 1, 2,
+	2, 3, 1, 2, 2, 3, 2, 3, 2, 2,
+	1, 2, 1, 3, 1, 3, 3, 1, 3, 3,
 	1, 3, 3, 2, 1, 1, 1, 1, 3, 2,
 	3, 3, 3, 3, 1, 1, 3, 6, 6, 1,
 	1, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 1, 1, 1, 3, 2, 1, 1, 1,
-	3, 2, 4, 4, 4, 4, 4, 4, 4, 4,
+	3, 2, 4, 5, 2, 1, 5, 3, 7, 4,
+	6, 3, 4, 4, 4, 4, 4, 4, 4, 4,
 	4, 4, 4, 4, 4, 4, 4, 0, 1, 5,
 	4, 5, 4, 1, 1, 2, 4, 5, 2, 4,
 	5, 1, 2, 2, 4, 1, 1, 1, 1, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
-	1, 1, 2, 1, 3, 4, 4, 3, 3,
+	1, 1, 1, 1, 1, 1, 2, 1, 3, 4,
+	4, 3, 3,
 }
 
 var exprChk = [...]int16{
-	-1000, -1, -2, -6, -7, -14, 26, -11, -15, -20,
-	-21, -22, -17, 17, -12, -16, 7, 89, 90, 68,
-	-18, 30, 31, 32, 44, 45, 54, 55, 56, 57,
-	58, 59, 60, 64, 65, 66, 33, 36, 39, 37,
-	38, 40, 41, 42, 43, 34, 35, 67, 80, 81,
-	82, 89, 90, 91, 92, 93, 94, 83, 84, 87,
-	88, 85, 86, -28, -29, -34, 50, -35, -3, 23,
-	24, 25, 15, 84, 16, -7, -6, -2, -10, 18,
-	-9, 5, 26, 26, -4, 28, 29, 7, 7, 26,
-	26, -23, -24, -25, 46, -23, -23, -23, -23, -23,
-	-23, -23, -23, -23, -23, -23, -23, -23, -23, -29,
-	-35, -27, -26, -52, -51, -33, -38, -39, -46, -40,
-	-43, 49, 47, 48, 69, 71, -9, -55, -54, -31,
-	26, 51, 77, 52, 78, 79, 5, -32, -30, 80,
-	6, -19, 72, 27, 27, 18, 2, 21, 13, 84,
-	14, 15, -8, 7, -14, 26, -7, 7, 26, 26,
-	26, -7, 7, -2, 73, 74, 75, 76, -2, -2,
+	-1000, -1, -2, -12, -14, -24, -13, 33, -19, -20,
+	-15, -25, -30, -31, -32, -33, -27, 19, -21, 77,
+	82, 83, 84, -26, 7, 122, 123, 86, 87, 88,
+	89, 90, 91, -28, 38, 39, 40, 52, 53, 64,
+	65, 66, 67, 68, 69, 70, 74, 75, 76, 78,
+	79, 80, 81, 41, 44, 47, 45, 46, 48, 49,
+	50, 51, 42, 43, 85, 113, 114, 115, 122, 123,
+	124, 125, 126, 127, 116, 117, 120, 121, 118, 119,
+	-39, 59, -40, -45, 28, -46, -3, 25, 26, 27,
+	17, 117, 18, 29, 30, 31, 32, -14, -12, -2,
+	-18, 20, -17, 5, 33, 34, 33, 33, 33, 33,
+	33, -4, 36, 37, 7, 7, 33, 33, 33, 33,
+	33, 33, 33, -34, -35, -36, 54, -34, -34, -34,
+	-34, -34, -34, -34, -34, -34, -34, -34, -34, -34,
+	-34, 59, -40, 103, -38, -37, -72, -71, -61, -62,
+	-63, -44, -49, -50, -51, -66, -52, -55, -58, -59,
+	-64, -65, -60, 58, 55, 56, 57, 92, 94, 107,
+	108, 109, -17, -75, -74, -42, 33, 60, 62, 100,
+	61, 101, 102, 105, 106, 110, 111, 112, 9, 5,
+	-43, -41, -46, 6, -48, 8, 113, 6, -29, 95,
+	35, 35, 20, 2, 23, 15, 117, 16, 17, -16,
+	7, -24, 33, -14, 7, -16, -14, -14, -14, -14,
+	7, 33, 33, 33, -14, -14, -14, -14, -14, -14,
+	7, -2, 96, 97, 98, 99, -2, -2, -2, -2,
 	-2, -2, -2, -2, -2, -2, -2, -2, -2, -2,
-	-2, -2, -33, 81, 21, 80, -37, -50, 8, -49,
-	5, -50, 6, 6, -33, 6, -48, -47, 5, -41,
-	-42, 5, -9, -44, -45, 5, -9, 13, 84, 87,
-	88, 85, 86, 83, -36, 6, -19, 80, 26, -9,
-	6, 6, 6, 6, 2, 27, 21, 10, -53, -28,
-	50, -14, -8, 27, 21, -7, 7, -5, 27, 5,
-	-5, 27, 21, 27, 26, 26, 26, 26, -33, -33,
-	-33, 8, -50, 21, 13, 27, 21, 13, 21, 21,
-	72, 9, 4, -21, 72, 9, 4, -21, 9, 4,
-	-21, 9, 4, -21, 9, 4, -21, 9, 4, -21,
-	9, 4, -21, 80, 26, -36, 6, -4, -8, -56,
-	-53, -28, 70, 10, 50, 10, -53, 53, 27, -53,
-	-28, 27, -4, -7, 27, 21, 21, 27, 27, 6,
-	-5, 27, -5, 27, 27, -5, 27, -5, -49, 6,
-	-47, 2, 5, 6, -42, -45, 26, 26, -36, 6,
-	27, 27, -53, -28, -53, 9, -56, -33, -56, 10,
-	5, -13, 61, 62, 63, 10, 27, 27, -53, 27,
-	-7, 5, 21, 27, 27, 27, 27, 6, 6, 27,
-	-4, -53, -56, 26, -56, -53, 50, 10, 10, 27,
-	-4, 27, 6, 27, 27, 5, -53, -56, -56, 10,
-	21, 27, -56, 6, 21, 6, 27,
+	103, 96, -44, 114, 23, 113, -48, -70, -69, 5,
+	-70, 6, 6, 6, 6, -70, -48, -70, -48, -70,
+	-44, 6, -8, -9, 5, 6, -68, -67, 5, -53,
+	-54, 5, -17, -56, -57, 5, -17, 33, 7, 36,
+	104, 33, 33, 33, 15, 117, 120, 121, 118, 119,
+	116, 6, 8, -47, 6, -29, 113, 33, -17, 6,
+	6, 6, 6, 2, 35, 23, 23, 11, -73, -39,
+	59, -24, -16, 12, 23, 23, 23, 35, 35, 35,
+	23, -14, 7, -5, 35, 5, -5, 35, 23, 23,
+	23, 23, 23, 23, 35, 33, 33, 33, 33, 96,
+	33, -44, -44, -44, -70, 23, 15, -70, -70, -70,
+	35, 23, 15, 15, 23, 15, 23, 23, 5, 33,
+	10, 5, 5, 35, 95, 10, 4, -31, 95, 10,
+	4, -31, 10, 4, -31, 10, 4, -31, 10, 4,
+	-31, 10, 4, -31, 10, 4, -31, 113, 33, -47,
+	6, -4, -22, 7, -16, -76, -73, -39, 93, 11,
+	59, 11, -73, 63, 35, -73, -39, 35, 35, -76,
+	23, -14, -22, 10, -4, -14, 35, 23, 23, 35,
+	35, 6, 6, 6, 6, 6, 6, -5, 35, -5,
+	35, 35, -5, 35, -5, 33, -5, -69, 6, -9,
+	5, 5, -67, 2, 5, 6, -54, -57, 35, 5,
+	-5, 35, 23, 33, 33, -47, 6, 35, 35, 23,
+	35, -73, -39, -73, 10, -76, -44, -76, 11, 5,
+	-23, 33, 71, 72, 73, 11, 35, 35, -73, 35,
+	23, -22, 12, 35, 35, 35, -14, 5, 23, 23,
+	23, 23, 23, 23, 35, 35, 35, 35, -5, 35,
+	35, 35, 6, 6, 6, 35, 7, -4, -73, -76,
+	33, -10, -11, 5, -23, -76, -73, 59, 11, 11,
+	35, -22, 35, 35, -76, -4, -4, 35, 6, 6,
+	6, 6, 6, 6, 35, 104, 104, 35, 35, 35,
+	5, 35, 23, 33, -73, -76, -76, 11, 35, 35,
+	23, 35, 23, 23, 35, 35, 23, 104, 10, 10,
+	35, -11, 5, -76, 6, -6, 6, 19, 7, 10,
+	-12, 33, 35, 23, 35, 23, -7, 6, 35, -12,
+	-12, 6, 6, 20, 23, 15, 35, 35, 6, 6,
+	15, 6,
 }
 
 var exprDef = [...]int16{
-	0, -2, 1, 2, 3, 11, 0, 4, 5, 6,
-	7, 8, 9, 0, 0, 0, 191, 0, 0, 0,
-	0, 207, 208, 209, 210, 211, 212, 213, 214, 215,
-	216, 217, 218, 219, 220, 221, 196, 197, 198, 199,
-	200, 201, 202, 203, 204, 205, 206, 195, 177, 177,
-	177, 177, 177, 177, 177, 177, 177, 177, 177, 177,
-	177, 177, 177, 12, 72, 74, 0, 92, 0, 57,
-	58, 59, 60, 61, 62, 3, 2, 0, 0, 65,
-	66, 0, 0, 0, 0, 0, 0, 192, 193, 0,
-	0, 0, 183, 184, 178, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 73,
-	94, 75, 76, 77, 78, 79, 80, 81, 82, 83,
-	84, 97, 99, 0, 101, 0, 114, 115, 116, 117,
-	0, 0, 107, 0, 0, 0, 0, 129, 130, 0,
-	89, 0, 85, 10, 13, 63, 64, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 3, 191, 0, 0,
-	0, 3, 0, 162, 0, 0, 185, 188, 163, 164,
-	165, 166, 167, 168, 169, 170, 171, 172, 173, 174,
-	175, 176, 119, 0, 0, 0, 98, 105, 95, 125,
-	124, 103, 100, 102, 0, 106, 113, 110, 0, 156,
-	154, 152, 153, 161, 159, 157, 158, 0, 0, 0,
-	0, 0, 0, 0, 93, 86, 0, 0, 0, 67,
-	68, 69, 70, 71, 39, 46, 0, 14, 0, 0,
-	0, 0, 0, 50, 0, 3, 191, 0, 227, 223,
-	0, 228, 0, 194, 0, 0, 0, 0, 120, 121,
-	122, 96, 104, 0, 0, 118, 0, 0, 0, 0,
-	0, 136, 143, 150, 0, 135, 142, 149, 131, 138,
-	145, 132, 139, 146, 133, 140, 147, 134, 141, 148,
-	137, 144, 151, 0, 0, 91, 0, 48, 0, 15,
-	18, 34, 0, 22, 0, 26, 0, 0, 0, 0,
-	0, 38, 52, 3, 51, 0, 0, 225, 226, 0,
-	0, 180, 0, 182, 186, 0, 189, 0, 126, 123,
-	111, 112, 108, 109, 155, 160, 0, 0, 88, 0,
-	90, 47, 19, 35, 36, 222, 23, 42, 27, 30,
-	40, 0, 43, 44, 45, 16, 0, 0, 0, 53,
-	3, 224, 0, 179, 181, 187, 190, 0, 0, 87,
-	49, 37, 31, 0, 17, 20, 0, 24, 28, 0,
-	54, 55, 0, 127, 128, 0, 21, 25, 29, 32,
-	0, 41, 33, 0, 0, 0, 56,
+	0, -2, 1, 2, 3, 14, 16, 0, 4, 5,
+	6, 7, 8, 9, 10, 11, 12, 0, 0, 0,
+	0, 0, 0, 0, 271, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 287, 288, 289, 290, 291, 292,
+	293, 294, 295, 296, 297, 298, 299, 300, 301, 302,
+	303, 304, 305, 276, 277, 278, 279, 280, 281, 282,
+	283, 284, 285, 286, 275, 257, 257, 257, 257, 257,
+	257, 257, 257, 257, 257, 257, 257, 257, 257, 257,
+	15, 0, 111, 113, 0, 142, 0, 92, 93, 94,
+	95, 96, 97, 98, 99, 100, 101, 3, 2, 0,
+	0, 104, 105, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 272, 273, 0, 0, 0, 0,
+	0, 0, 0, 0, 263, 264, 258, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 112, 0, 116, 117, 118, 119, 120, 121,
+	122, 123, 124, 125, 126, 127, 128, 129, 130, 131,
+	132, 133, 134, 147, 149, 0, 0, 152, 0, 154,
+	158, 162, 184, 185, 186, 187, 0, 0, 170, 177,
+	0, 0, 0, 0, 0, 235, 0, 0, 0, 0,
+	199, 200, 144, 114, 0, 145, 0, 139, 0, 135,
+	13, 17, 102, 103, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 3, 271, 0, 3, 3, 3, 3,
+	271, 0, 0, 0, 3, 3, 3, 3, 3, 3,
+	0, 242, 0, 0, 265, 268, 243, 244, 245, 246,
+	247, 248, 249, 250, 251, 252, 253, 254, 255, 256,
+	0, 0, 189, 0, 0, 0, 148, 168, 195, 194,
+	166, 150, 151, 153, 155, 156, 159, 160, 163, 164,
+	0, 169, 171, 172, 174, 0, 183, 180, 0, 226,
+	224, 222, 223, 231, 229, 227, 228, 0, 234, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 115, 146, 143, 136, 0, 0, 0, 106, 107,
+	108, 109, 110, 45, 57, 0, 0, 20, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 73, 74, 75,
+	0, 3, 271, 0, 311, 307, 0, 312, 0, 0,
+	0, 0, 0, 0, 274, 0, 0, 0, 0, 0,
+	0, 190, 191, 192, 167, 0, 0, 157, 161, 165,
+	188, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	237, 0, 0, 241, 0, 206, 213, 220, 0, 205,
+	212, 219, 201, 208, 215, 202, 209, 216, 203, 210,
+	217, 204, 211, 218, 207, 214, 221, 0, 0, 141,
+	0, 59, 0, 64, 0, 21, 24, 40, 0, 28,
+	0, 32, 0, 0, 0, 0, 0, 44, 66, 0,
+	0, 3, 0, 0, 77, 3, 76, 0, 0, 309,
+	310, 0, 0, 0, 0, 0, 0, 0, 260, 0,
+	262, 266, 0, 269, 0, 0, 0, 196, 193, 173,
+	175, 176, 181, 182, 178, 179, 225, 230, 232, 0,
+	0, 239, 0, 0, 0, 138, 0, 140, 61, 0,
+	58, 25, 41, 42, 306, 29, 49, 33, 36, 46,
+	0, 0, 54, 55, 56, 22, 0, 0, 0, 67,
+	0, 0, 0, 62, 72, 78, 3, 308, 0, 0,
+	0, 0, 0, 0, 259, 261, 267, 270, 0, 0,
+	233, 236, 0, 0, 0, 137, 65, 60, 43, 37,
+	0, 0, 50, 52, 0, 23, 26, 0, 30, 34,
+	0, 0, 70, 68, 0, 63, 79, 80, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 240, 197, 198,
+	0, 48, 0, 0, 27, 31, 35, 38, 71, 69,
+	0, 82, 0, 0, 85, 86, 0, 0, 0, 238,
+	47, 51, 0, 39, 0, 0, 88, 0, 0, 0,
+	18, 0, 53, 0, 83, 0, 0, 0, 87, 19,
+	0, 0, 89, 0, 0, 0, 81, 84, 0, 90,
+	0, 91,
 }
 
 var exprTok1 = [...]int8{
@@ -555,7 +754,10 @@ var exprTok2 = [...]int8{
 	62, 63, 64, 65, 66, 67, 68, 69, 70, 71,
 	72, 73, 74, 75, 76, 77, 78, 79, 80, 81,
 	82, 83, 84, 85, 86, 87, 88, 89, 90, 91,
-	92, 93, 94,
+	92, 93, 94, 95, 96, 97, 98, 99, 100, 101,
+	102, 103, 104, 105, 106, 107, 108, 109, 110, 111,
+	112, 113, 114, 115, 116, 117, 118, 119, 120, 121,
+	122, 123, 124, 125, 126, 127,
 }
 
 var exprTok3 = [...]int8{
@@ -901,1368 +1103,1872 @@ exprdefault:
 
 	case 1:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:154
+//line expr.y:173
 		{
 			exprlex.(*parser).expr = exprDollar[1].Expr
 		}
 	case 2:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:157
+//line expr.y:176
 		{
 			exprVAL.Expr = exprDollar[1].LogExpr
 		}
 	case 3:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:158
+//line expr.y:177
 		{
 			exprVAL.Expr = exprDollar[1].MetricExpr
 		}
 	case 4:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:162
+//line expr.y:181
 		{
 			exprVAL.MetricExpr = exprDollar[1].RangeAggregationExpr
 		}
 	case 5:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:163
+//line expr.y:182
 		{
-			exprVAL.MetricExpr = exprDollar[1].VectorAggregationExpr
+			exprVAL.MetricExpr = exprDollar[1].SubqueryExpr
 		}
 	case 6:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:164
+//line expr.y:183
 		{
-			exprVAL.MetricExpr = exprDollar[1].BinOpExpr
+			exprVAL.MetricExpr = exprDollar[1].MetricExpr
 		}
 	case 7:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:165
+//line expr.y:184
 		{
-			exprVAL.MetricExpr = exprDollar[1].LiteralExpr
+			exprVAL.MetricExpr = exprDollar[1].VectorAggregationExpr
 		}
 	case 8:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:166
+//line expr.y:185
 		{
-			exprVAL.MetricExpr = exprDollar[1].LabelReplaceExpr
+			exprVAL.MetricExpr = exprDollar[1].BinOpExpr
 		}
 	case 9:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:167
+//line expr.y:186
 		{
-			exprVAL.MetricExpr = exprDollar[1].VectorExpr
+			exprVAL.MetricExpr = exprDollar[1].LiteralExpr
 		}
 	case 10:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:187
+		{
+			exprVAL.MetricExpr = exprDollar[1].LabelReplaceExpr
+		}
+	case 11:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:188
+		{
+			exprVAL.MetricExpr = exprDollar[1].LabelReplaceExpr
+		}
+	case 12:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:189
+		{
+			exprVAL.MetricExpr = exprDollar[1].VectorExpr
+		}
+	case 13:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:168
+//line expr.y:190
 		{
 			exprVAL.MetricExpr = exprDollar[2].MetricExpr
 		}
-	case 11:
+	case 14:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:172
+//line expr.y:194
 		{
 			exprVAL.LogExpr = newMatcherExpr(exprDollar[1].Selector)
 		}
-	case 12:
+	case 15:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:173
+//line expr.y:195
 		{
 			exprVAL.LogExpr = newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].PipelineExpr)
 		}
-	case 13:
+	case 16:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:196
+		{
+			exprVAL.LogExpr = exprDollar[1].LogExpr
+		}
+	case 17:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:174
+//line expr.y:197
 		{
 			exprVAL.LogExpr = exprDollar[2].LogExpr
 		}
-	case 14:
+	case 18:
+		exprDollar = exprS[exprpt-10 : exprpt+1]
+//line expr.y:201
+		{
+			exprVAL.LogExpr = newJoinExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[6].Labels, exprDollar[9].duration, exprDollar[10].LogExpr)
+		}
+	case 19:
+		exprDollar = exprS[exprpt-11 : exprpt+1]
+//line expr.y:202
+		{
+			exprVAL.LogExpr = newJoinExpr(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].PipelineExpr), exprDollar[7].Labels, exprDollar[10].duration, exprDollar[11].LogExpr)
+		}
+	case 20:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:178
+//line expr.y:206
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].duration, nil, nil)
 		}
-	case 15:
+	case 21:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:179
+//line expr.y:207
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].duration, nil, exprDollar[3].OffsetExpr)
 		}
-	case 16:
+	case 22:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:180
+//line expr.y:208
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[2].Selector), exprDollar[4].duration, nil, nil)
 		}
-	case 17:
+	case 23:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:181
+//line expr.y:209
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[2].Selector), exprDollar[4].duration, nil, exprDollar[5].OffsetExpr)
 		}
-	case 18:
+	case 24:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:182
+//line expr.y:210
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].duration, exprDollar[3].UnwrapExpr, nil)
 		}
-	case 19:
+	case 25:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:183
+//line expr.y:211
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].duration, exprDollar[4].UnwrapExpr, exprDollar[3].OffsetExpr)
 		}
-	case 20:
+	case 26:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:184
+//line expr.y:212
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[2].Selector), exprDollar[4].duration, exprDollar[5].UnwrapExpr, nil)
 		}
-	case 21:
+	case 27:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
-//line expr.y:185
+//line expr.y:213
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[2].Selector), exprDollar[4].duration, exprDollar[6].UnwrapExpr, exprDollar[5].OffsetExpr)
 		}
-	case 22:
+	case 28:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:186
+//line expr.y:214
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[1].Selector), exprDollar[3].duration, exprDollar[2].UnwrapExpr, nil)
 		}
-	case 23:
+	case 29:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:187
+//line expr.y:215
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[1].Selector), exprDollar[3].duration, exprDollar[2].UnwrapExpr, exprDollar[4].OffsetExpr)
 		}
-	case 24:
+	case 30:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:188
+//line expr.y:216
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[2].Selector), exprDollar[5].duration, exprDollar[3].UnwrapExpr, nil)
 		}
-	case 25:
+	case 31:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
-//line expr.y:189
+//line expr.y:217
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[2].Selector), exprDollar[5].duration, exprDollar[3].UnwrapExpr, exprDollar[6].OffsetExpr)
 		}
-	case 26:
+	case 32:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:190
+//line expr.y:218
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].PipelineExpr), exprDollar[3].duration, nil, nil)
 		}
-	case 27:
+	case 33:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:191
+//line expr.y:219
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].PipelineExpr), exprDollar[3].duration, nil, exprDollar[4].OffsetExpr)
 		}
-	case 28:
+	case 34:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:192
+//line expr.y:220
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[2].Selector), exprDollar[3].PipelineExpr), exprDollar[5].duration, nil, nil)
 		}
-	case 29:
+	case 35:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
-//line expr.y:193
+//line expr.y:221
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[2].Selector), exprDollar[3].PipelineExpr), exprDollar[5].duration, nil, exprDollar[6].OffsetExpr)
 		}
-	case 30:
+	case 36:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:194
+//line expr.y:222
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].PipelineExpr), exprDollar[4].duration, exprDollar[3].UnwrapExpr, nil)
 		}
-	case 31:
+	case 37:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:195
+//line expr.y:223
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].PipelineExpr), exprDollar[4].duration, exprDollar[3].UnwrapExpr, exprDollar[5].OffsetExpr)
 		}
-	case 32:
+	case 38:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
-//line expr.y:196
+//line expr.y:224
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[2].Selector), exprDollar[3].PipelineExpr), exprDollar[6].duration, exprDollar[4].UnwrapExpr, nil)
 		}
-	case 33:
+	case 39:
 		exprDollar = exprS[exprpt-7 : exprpt+1]
-//line expr.y:197
+//line expr.y:225
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[2].Selector), exprDollar[3].PipelineExpr), exprDollar[6].duration, exprDollar[4].UnwrapExpr, exprDollar[7].OffsetExpr)
 		}
-	case 34:
+	case 40:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:198
+//line expr.y:226
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[3].PipelineExpr), exprDollar[2].duration, nil, nil)
 		}
-	case 35:
+	case 41:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:199
+//line expr.y:227
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[4].PipelineExpr), exprDollar[2].duration, nil, exprDollar[3].OffsetExpr)
 		}
-	case 36:
+	case 42:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:200
+//line expr.y:228
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[3].PipelineExpr), exprDollar[2].duration, exprDollar[4].UnwrapExpr, nil)
 		}
-	case 37:
+	case 43:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:201
+//line expr.y:229
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[4].PipelineExpr), exprDollar[2].duration, exprDollar[5].UnwrapExpr, exprDollar[3].OffsetExpr)
 		}
-	case 38:
+	case 44:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:202
+//line expr.y:230
 		{
 			exprVAL.LogRangeExpr = exprDollar[2].LogRangeExpr
 		}
-	case 40:
+	case 46:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:207
+//line expr.y:235
 		{
 			exprVAL.UnwrapExpr = newUnwrapExpr(exprDollar[3].str, "")
 		}
-	case 41:
-		exprDollar = exprS[exprpt-6 : exprpt+1]
-//line expr.y:208
-		{
-			exprVAL.UnwrapExpr = newUnwrapExpr(exprDollar[5].str, exprDollar[3].ConvOp)
-		}
-	case 42:
-		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:209
-		{
-			exprVAL.UnwrapExpr = exprDollar[1].UnwrapExpr.addPostFilter(exprDollar[3].LabelFilter)
-		}
-	case 43:
-		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:213
-		{
-			exprVAL.ConvOp = OpConvBytes
-		}
-	case 44:
-		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:214
-		{
-			exprVAL.ConvOp = OpConvDuration
-		}
-	case 45:
-		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:215
-		{
-			exprVAL.ConvOp = OpConvDurationSeconds
-		}
-	case 46:
-		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:219
-		{
-			exprVAL.RangeAggregationExpr = newRangeAggregationExpr(exprDollar[3].LogRangeExpr, exprDollar[1].RangeOp, nil, nil)
-		}
 	case 47:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
-//line expr.y:220
+//line expr.y:236
 		{
-			exprVAL.RangeAggregationExpr = newRangeAggregationExpr(exprDollar[5].LogRangeExpr, exprDollar[1].RangeOp, nil, &exprDollar[3].str)
+			exprVAL.UnwrapExpr = newUnwrapExpr(exprDollar[5].str, exprDollar[3].ConvOp)
 		}
 	case 48:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:221
+//line expr.y:237
 		{
-			exprVAL.RangeAggregationExpr = newRangeAggregationExpr(exprDollar[3].LogRangeExpr, exprDollar[1].RangeOp, exprDollar[5].Grouping, nil)
+			exprVAL.UnwrapExpr = newMultiUnwrapExpr(exprDollar[4].Labels)
 		}
 	case 49:
-		exprDollar = exprS[exprpt-7 : exprpt+1]
-//line expr.y:222
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:238
 		{
-			exprVAL.RangeAggregationExpr = newRangeAggregationExpr(exprDollar[5].LogRangeExpr, exprDollar[1].RangeOp, exprDollar[7].Grouping, &exprDollar[3].str)
+			exprVAL.UnwrapExpr = exprDollar[1].UnwrapExpr.addPostFilter(exprDollar[3].LabelFilter)
 		}
 	case 50:
-		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:227
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:242
 		{
-			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[3].MetricExpr, exprDollar[1].VectorOp, nil, nil)
+			exprVAL.Labels = exprDollar[1].Labels
 		}
 	case 51:
-		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:228
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:243
 		{
-			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[4].MetricExpr, exprDollar[1].VectorOp, exprDollar[2].Grouping, nil)
+			exprVAL.Labels = append(exprDollar[1].Labels, exprDollar[3].Labels...)
 		}
 	case 52:
-		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:229
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:247
 		{
-			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[3].MetricExpr, exprDollar[1].VectorOp, exprDollar[5].Grouping, nil)
+			exprVAL.Labels = []string{"", exprDollar[1].str}
 		}
 	case 53:
-		exprDollar = exprS[exprpt-6 : exprpt+1]
-//line expr.y:231
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+//line expr.y:248
 		{
-			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[5].MetricExpr, exprDollar[1].VectorOp, nil, &exprDollar[3].str)
+			exprVAL.Labels = []string{exprDollar[1].ConvOp, exprDollar[3].str}
 		}
 	case 54:
-		exprDollar = exprS[exprpt-7 : exprpt+1]
-//line expr.y:232
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:252
 		{
-			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[5].MetricExpr, exprDollar[1].VectorOp, exprDollar[7].Grouping, &exprDollar[3].str)
+			exprVAL.ConvOp = OpConvBytes
 		}
 	case 55:
-		exprDollar = exprS[exprpt-7 : exprpt+1]
-//line expr.y:233
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:253
 		{
-			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[6].MetricExpr, exprDollar[1].VectorOp, exprDollar[2].Grouping, &exprDollar[4].str)
+			exprVAL.ConvOp = OpConvDuration
 		}
 	case 56:
-		exprDollar = exprS[exprpt-12 : exprpt+1]
-//line expr.y:238
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:254
 		{
-			exprVAL.LabelReplaceExpr = mustNewLabelReplaceExpr(exprDollar[3].MetricExpr, exprDollar[5].str, exprDollar[7].str, exprDollar[9].str, exprDollar[11].str)
+			exprVAL.ConvOp = OpConvDurationSeconds
 		}
 	case 57:
-		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:242
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+//line expr.y:258
 		{
-			exprVAL.Filter = log.LineMatchRegexp
+			exprVAL.RangeAggregationExpr = newRangeAggregationExpr(exprDollar[3].LogRangeExpr, exprDollar[1].RangeOp, nil, nil)
 		}
 	case 58:
-		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:243
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+//line expr.y:259
 		{
-			exprVAL.Filter = log.LineMatchEqual
+			exprVAL.RangeAggregationExpr = newRangeAggregationExpr(exprDollar[5].LogRangeExpr, exprDollar[1].RangeOp, nil, &exprDollar[3].str)
 		}
 	case 59:
-		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:244
+		exprDollar = exprS[exprpt-5 : exprpt+1]
+//line expr.y:260
 		{
-			exprVAL.Filter = log.LineMatchPattern
+			exprVAL.RangeAggregationExpr = newRangeAggregationExpr(exprDollar[3].LogRangeExpr, exprDollar[1].RangeOp, exprDollar[5].Grouping, nil)
 		}
 	case 60:
-		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:245
+		exprDollar = exprS[exprpt-7 : exprpt+1]
+//line expr.y:261
 		{
-			exprVAL.Filter = log.LineMatchNotRegexp
+			exprVAL.RangeAggregationExpr = newRangeAggregationExpr(exprDollar[5].LogRangeExpr, exprDollar[1].RangeOp, exprDollar[7].Grouping, &exprDollar[3].str)
 		}
 	case 61:
-		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:246
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+//line expr.y:262
 		{
-			exprVAL.Filter = log.LineMatchNotEqual
+			exprVAL.RangeAggregationExpr = newRangeAggregationExprWithArgs(exprDollar[3].LogRangeExpr, exprDollar[1].RangeOp, exprDollar[5].Numbers)
 		}
 	case 62:
-		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:247
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+//line expr.y:263
 		{
-			exprVAL.Filter = log.LineMatchNotPattern
+			exprVAL.RangeAggregationExpr = newHistogramRangeAggregationExpr(exprDollar[3].LogRangeExpr, nil, exprDollar[5].Numbers)
 		}
 	case 63:
-		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:251
+		exprDollar = exprS[exprpt-7 : exprpt+1]
+//line expr.y:264
 		{
-			exprVAL.Selector = exprDollar[2].Matchers
+			exprVAL.RangeAggregationExpr = newHistogramRangeAggregationExpr(exprDollar[3].LogRangeExpr, exprDollar[7].Grouping, exprDollar[5].Numbers)
 		}
 	case 64:
-		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:252
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:268
 		{
-			exprVAL.Selector = exprDollar[2].Matchers
+			exprVAL.Numbers = []string{exprDollar[1].str}
 		}
 	case 65:
-		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:253
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:269
 		{
+			exprVAL.Numbers = append(exprDollar[1].Numbers, exprDollar[3].str)
 		}
 	case 66:
+		exprDollar = exprS[exprpt-5 : exprpt+1]
+//line expr.y:273
+		{
+			exprVAL.SubqueryExpr = newSubqueryExpr(exprDollar[3].MetricExpr, exprDollar[1].RangeOp, exprDollar[4].subqueryRange, nil, nil)
+		}
+	case 67:
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+//line expr.y:274
+		{
+			exprVAL.SubqueryExpr = newSubqueryExpr(exprDollar[3].MetricExpr, exprDollar[1].RangeOp, exprDollar[4].subqueryRange, exprDollar[5].OffsetExpr, nil)
+		}
+	case 68:
+		exprDollar = exprS[exprpt-7 : exprpt+1]
+//line expr.y:275
+		{
+			exprVAL.SubqueryExpr = newSubqueryExpr(exprDollar[5].MetricExpr, exprDollar[1].RangeOp, exprDollar[6].subqueryRange, nil, &exprDollar[3].str)
+		}
+	case 69:
+		exprDollar = exprS[exprpt-8 : exprpt+1]
+//line expr.y:276
+		{
+			exprVAL.SubqueryExpr = newSubqueryExpr(exprDollar[5].MetricExpr, exprDollar[1].RangeOp, exprDollar[6].subqueryRange, exprDollar[7].OffsetExpr, &exprDollar[3].str)
+		}
+	case 70:
+		exprDollar = exprS[exprpt-7 : exprpt+1]
+//line expr.y:277
+		{
+			exprVAL.SubqueryExpr = newSubqueryExprWithArgs(exprDollar[3].MetricExpr, exprDollar[1].RangeOp, exprDollar[4].subqueryRange, nil, exprDollar[6].Numbers)
+		}
+	case 71:
+		exprDollar = exprS[exprpt-8 : exprpt+1]
+//line expr.y:278
+		{
+			exprVAL.SubqueryExpr = newSubqueryExprWithArgs(exprDollar[3].MetricExpr, exprDollar[1].RangeOp, exprDollar[4].subqueryRange, exprDollar[5].OffsetExpr, exprDollar[7].Numbers)
+		}
+	case 72:
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+//line expr.y:282
+		{
+			exprVAL.MetricExpr = newChangesVsExpr(exprDollar[3].MetricExpr, exprDollar[5].duration)
+		}
+	case 73:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+//line expr.y:283
+		{
+			exprVAL.MetricExpr = newChangesVsExpr(exprDollar[3].MetricExpr, 24*time.Hour)
+		}
+	case 74:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+//line expr.y:284
+		{
+			exprVAL.MetricExpr = newChangesVsExpr(exprDollar[3].MetricExpr, 7*24*time.Hour)
+		}
+	case 75:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+//line expr.y:289
+		{
+			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[3].MetricExpr, exprDollar[1].VectorOp, nil, nil)
+		}
+	case 76:
+		exprDollar = exprS[exprpt-5 : exprpt+1]
+//line expr.y:290
+		{
+			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[4].MetricExpr, exprDollar[1].VectorOp, exprDollar[2].Grouping, nil)
+		}
+	case 77:
+		exprDollar = exprS[exprpt-5 : exprpt+1]
+//line expr.y:291
+		{
+			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[3].MetricExpr, exprDollar[1].VectorOp, exprDollar[5].Grouping, nil)
+		}
+	case 78:
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+//line expr.y:293
+		{
+			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[5].MetricExpr, exprDollar[1].VectorOp, nil, &exprDollar[3].str)
+		}
+	case 79:
+		exprDollar = exprS[exprpt-7 : exprpt+1]
+//line expr.y:294
+		{
+			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[5].MetricExpr, exprDollar[1].VectorOp, exprDollar[7].Grouping, &exprDollar[3].str)
+		}
+	case 80:
+		exprDollar = exprS[exprpt-7 : exprpt+1]
+//line expr.y:295
+		{
+			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[6].MetricExpr, exprDollar[1].VectorOp, exprDollar[2].Grouping, &exprDollar[4].str)
+		}
+	case 81:
+		exprDollar = exprS[exprpt-12 : exprpt+1]
+//line expr.y:300
+		{
+			exprVAL.LabelReplaceExpr = mustNewLabelReplaceExpr(exprDollar[3].MetricExpr, exprDollar[5].str, exprDollar[7].str, exprDollar[9].str, exprDollar[11].str)
+		}
+	case 82:
+		exprDollar = exprS[exprpt-8 : exprpt+1]
+//line expr.y:305
+		{
+			exprVAL.LabelReplaceExpr = newLabelFunctionExpr(exprDollar[3].MetricExpr, OpLabelJoin, exprDollar[5].str, nil, exprDollar[7].str, nil, 0)
+		}
+	case 83:
+		exprDollar = exprS[exprpt-10 : exprpt+1]
+//line expr.y:307
+		{
+			exprVAL.LabelReplaceExpr = newLabelFunctionExpr(exprDollar[3].MetricExpr, OpLabelJoin, exprDollar[5].str, exprDollar[9].Labels, exprDollar[7].str, nil, 0)
+		}
+	case 84:
+		exprDollar = exprS[exprpt-12 : exprpt+1]
+//line expr.y:309
+		{
+			exprVAL.LabelReplaceExpr = newLabelMapExpr(exprDollar[3].MetricExpr, exprDollar[5].str, exprDollar[7].str, exprDollar[10].Labels)
+		}
+	case 85:
+		exprDollar = exprS[exprpt-8 : exprpt+1]
+//line expr.y:311
+		{
+			exprVAL.LabelReplaceExpr = newLabelFunctionExpr(exprDollar[3].MetricExpr, OpLabelLower, exprDollar[5].str, []string{exprDollar[7].str}, "", nil, 0)
+		}
+	case 86:
+		exprDollar = exprS[exprpt-8 : exprpt+1]
+//line expr.y:313
+		{
+			exprVAL.LabelReplaceExpr = newLabelFunctionExpr(exprDollar[3].MetricExpr, OpLabelUpper, exprDollar[5].str, []string{exprDollar[7].str}, "", nil, 0)
+		}
+	case 87:
+		exprDollar = exprS[exprpt-10 : exprpt+1]
+//line expr.y:315
+		{
+			exprVAL.LabelReplaceExpr = newLabelTruncateExpr(exprDollar[3].MetricExpr, exprDollar[5].str, exprDollar[7].str, exprDollar[9].str)
+		}
+	case 88:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:257
+//line expr.y:319
+		{
+			exprVAL.Labels = []string{exprDollar[1].str}
+		}
+	case 89:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:320
+		{
+			exprVAL.Labels = append(exprDollar[1].Labels, exprDollar[3].str)
+		}
+	case 90:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:325
+		{
+			exprVAL.Labels = []string{exprDollar[1].str, exprDollar[3].str}
+		}
+	case 91:
+		exprDollar = exprS[exprpt-5 : exprpt+1]
+//line expr.y:326
+		{
+			exprVAL.Labels = append(exprDollar[1].Labels, exprDollar[3].str, exprDollar[5].str)
+		}
+	case 92:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:330
+		{
+			exprVAL.Filter = log.LineMatchRegexp
+		}
+	case 93:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:331
+		{
+			exprVAL.Filter = log.LineMatchEqual
+		}
+	case 94:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:332
+		{
+			exprVAL.Filter = log.LineMatchPattern
+		}
+	case 95:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:333
+		{
+			exprVAL.Filter = log.LineMatchNotRegexp
+		}
+	case 96:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:334
+		{
+			exprVAL.Filter = log.LineMatchNotEqual
+		}
+	case 97:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:335
+		{
+			exprVAL.Filter = log.LineMatchNotPattern
+		}
+	case 98:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:336
+		{
+			exprVAL.Filter = log.LineMatchEqualFold
+		}
+	case 99:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:337
+		{
+			exprVAL.Filter = log.LineMatchNotEqualFold
+		}
+	case 100:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:338
+		{
+			exprVAL.Filter = log.LineMatchWord
+		}
+	case 101:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:339
+		{
+			exprVAL.Filter = log.LineMatchNotWord
+		}
+	case 102:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:343
+		{
+			exprVAL.Selector = exprDollar[2].Matchers
+		}
+	case 103:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:344
+		{
+			exprVAL.Selector = exprDollar[2].Matchers
+		}
+	case 104:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:345
+		{
+		}
+	case 105:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:349
 		{
 			exprVAL.Matchers = []*labels.Matcher{exprDollar[1].Matcher}
 		}
-	case 67:
+	case 106:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:258
+//line expr.y:350
 		{
 			exprVAL.Matchers = append(exprDollar[1].Matchers, exprDollar[3].Matcher)
 		}
-	case 68:
+	case 107:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:262
+//line expr.y:354
 		{
 			exprVAL.Matcher = mustNewMatcher(labels.MatchEqual, exprDollar[1].str, exprDollar[3].str)
 		}
-	case 69:
+	case 108:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:263
+//line expr.y:355
 		{
 			exprVAL.Matcher = mustNewMatcher(labels.MatchNotEqual, exprDollar[1].str, exprDollar[3].str)
 		}
-	case 70:
+	case 109:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:264
+//line expr.y:356
 		{
 			exprVAL.Matcher = mustNewMatcher(labels.MatchRegexp, exprDollar[1].str, exprDollar[3].str)
 		}
-	case 71:
+	case 110:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:265
+//line expr.y:357
 		{
 			exprVAL.Matcher = mustNewMatcher(labels.MatchNotRegexp, exprDollar[1].str, exprDollar[3].str)
 		}
-	case 72:
+	case 111:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:269
+//line expr.y:361
 		{
 			exprVAL.PipelineExpr = MultiStageExpr{exprDollar[1].PipelineStage}
 		}
-	case 73:
+	case 112:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:270
+//line expr.y:362
 		{
 			exprVAL.PipelineExpr = append(exprDollar[1].PipelineExpr, exprDollar[2].PipelineStage)
 		}
-	case 74:
+	case 113:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:274
+//line expr.y:366
 		{
 			exprVAL.PipelineStage = exprDollar[1].LineFilters
 		}
-	case 75:
+	case 114:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:275
+//line expr.y:367
+		{
+			exprVAL.PipelineStage = newSearchExpr(nil, exprDollar[2].str)
+		}
+	case 115:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:368
+		{
+			exprVAL.PipelineStage = newSearchExpr(exprDollar[2].ParserFlags, exprDollar[3].str)
+		}
+	case 116:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:369
 		{
 			exprVAL.PipelineStage = exprDollar[2].LogfmtParser
 		}
-	case 76:
+	case 117:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:276
+//line expr.y:370
 		{
 			exprVAL.PipelineStage = exprDollar[2].LabelParser
 		}
-	case 77:
+	case 118:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:277
+//line expr.y:371
 		{
 			exprVAL.PipelineStage = exprDollar[2].JSONExpressionParser
 		}
-	case 78:
+	case 119:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:278
+//line expr.y:372
 		{
 			exprVAL.PipelineStage = exprDollar[2].LogfmtExpressionParser
 		}
-	case 79:
+	case 120:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:279
+//line expr.y:373
+		{
+			exprVAL.PipelineStage = exprDollar[2].PipelineStage
+		}
+	case 121:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:374
+		{
+			exprVAL.PipelineStage = exprDollar[2].PipelineStage
+		}
+	case 122:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:375
+		{
+			exprVAL.PipelineStage = exprDollar[2].PipelineStage
+		}
+	case 123:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:376
 		{
 			exprVAL.PipelineStage = &LabelFilterExpr{LabelFilterer: exprDollar[2].LabelFilter}
 		}
-	case 80:
+	case 124:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:280
+//line expr.y:377
 		{
 			exprVAL.PipelineStage = exprDollar[2].LineFormatExpr
 		}
-	case 81:
+	case 125:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:378
+		{
+			exprVAL.PipelineStage = exprDollar[2].PipelineStage
+		}
+	case 126:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:281
+//line expr.y:379
 		{
 			exprVAL.PipelineStage = exprDollar[2].DecolorizeExpr
 		}
-	case 82:
+	case 127:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:282
+//line expr.y:380
 		{
 			exprVAL.PipelineStage = exprDollar[2].LabelFormatExpr
 		}
-	case 83:
+	case 128:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:283
+//line expr.y:381
 		{
 			exprVAL.PipelineStage = exprDollar[2].DropLabelsExpr
 		}
-	case 84:
+	case 129:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:284
+//line expr.y:382
 		{
 			exprVAL.PipelineStage = exprDollar[2].KeepLabelsExpr
 		}
-	case 85:
+	case 130:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:383
+		{
+			exprVAL.PipelineStage = exprDollar[2].SortByExpr
+		}
+	case 131:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:384
+		{
+			exprVAL.PipelineStage = exprDollar[2].LimitExpr
+		}
+	case 132:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:385
+		{
+			exprVAL.PipelineStage = exprDollar[2].PipelineStage
+		}
+	case 133:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:386
+		{
+			exprVAL.PipelineStage = exprDollar[2].PipelineStage
+		}
+	case 134:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:387
+		{
+			exprVAL.PipelineStage = exprDollar[2].PipelineStage
+		}
+	case 135:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:288
+//line expr.y:391
 		{
 			exprVAL.FilterOp = OpFilterIP
 		}
-	case 86:
+	case 136:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:292
+//line expr.y:395
 		{
 			exprVAL.OrFilter = newLineFilterExpr(log.LineMatchEqual, "", exprDollar[1].str)
 		}
-	case 87:
+	case 137:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:293
+//line expr.y:396
 		{
 			exprVAL.OrFilter = newLineFilterExpr(log.LineMatchEqual, exprDollar[1].FilterOp, exprDollar[3].str)
 		}
-	case 88:
+	case 138:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:294
+//line expr.y:397
 		{
 			exprVAL.OrFilter = newOrLineFilter(newLineFilterExpr(log.LineMatchEqual, "", exprDollar[1].str), exprDollar[3].OrFilter)
 		}
-	case 89:
+	case 139:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:298
+//line expr.y:401
 		{
 			exprVAL.LineFilter = newLineFilterExpr(exprDollar[1].Filter, "", exprDollar[2].str)
 		}
-	case 90:
+	case 140:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:299
+//line expr.y:402
 		{
 			exprVAL.LineFilter = newLineFilterExpr(exprDollar[1].Filter, exprDollar[2].FilterOp, exprDollar[4].str)
 		}
-	case 91:
+	case 141:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:300
+//line expr.y:403
 		{
 			exprVAL.LineFilter = newOrLineFilter(newLineFilterExpr(exprDollar[1].Filter, "", exprDollar[2].str), exprDollar[4].OrFilter)
 		}
-	case 92:
+	case 142:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:304
+//line expr.y:407
 		{
 			exprVAL.LineFilters = exprDollar[1].LineFilter
 		}
-	case 93:
+	case 143:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:305
+//line expr.y:408
 		{
 			exprVAL.LineFilters = newOrLineFilter(exprDollar[1].LineFilter, exprDollar[3].OrFilter)
 		}
-	case 94:
+	case 144:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:306
+//line expr.y:409
 		{
 			exprVAL.LineFilters = newNestedLineFilterExpr(exprDollar[1].LineFilters, exprDollar[2].LineFilter)
 		}
-	case 95:
+	case 145:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:310
+//line expr.y:413
 		{
 			exprVAL.ParserFlags = []string{exprDollar[1].str}
 		}
-	case 96:
+	case 146:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:311
+//line expr.y:414
 		{
 			exprVAL.ParserFlags = append(exprDollar[1].ParserFlags, exprDollar[2].str)
 		}
-	case 97:
+	case 147:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:315
+//line expr.y:418
 		{
 			exprVAL.LogfmtParser = newLogfmtParserExpr(nil)
 		}
-	case 98:
+	case 148:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:316
+//line expr.y:419
 		{
 			exprVAL.LogfmtParser = newLogfmtParserExpr(exprDollar[2].ParserFlags)
 		}
-	case 99:
+	case 149:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:320
+//line expr.y:423
 		{
 			exprVAL.LabelParser = newLabelParserExpr(OpParserTypeJSON, "")
 		}
-	case 100:
+	case 150:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:321
+//line expr.y:424
 		{
 			exprVAL.LabelParser = newLabelParserExpr(OpParserTypeRegexp, exprDollar[2].str)
 		}
-	case 101:
+	case 151:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:425
+		{
+			exprVAL.LabelParser = newLabelParserExpr(OpParserTypeExtract, exprDollar[2].str)
+		}
+	case 152:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:322
+//line expr.y:426
 		{
 			exprVAL.LabelParser = newLabelParserExpr(OpParserTypeUnpack, "")
 		}
-	case 102:
+	case 153:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:323
+//line expr.y:427
 		{
 			exprVAL.LabelParser = newLabelParserExpr(OpParserTypePattern, exprDollar[2].str)
 		}
-	case 103:
+	case 154:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:431
+		{
+			exprVAL.PipelineStage = newCSVParserExpr("", nil)
+		}
+	case 155:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:432
+		{
+			exprVAL.PipelineStage = newCSVParserExpr(exprDollar[2].str, nil)
+		}
+	case 156:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:433
+		{
+			exprVAL.PipelineStage = newCSVParserExpr("", exprDollar[2].LabelExtractionExpressionList)
+		}
+	case 157:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:434
+		{
+			exprVAL.PipelineStage = newCSVParserExpr(exprDollar[2].str, exprDollar[3].LabelExtractionExpressionList)
+		}
+	case 158:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:438
+		{
+			exprVAL.PipelineStage = newSDParserExpr(nil, nil)
+		}
+	case 159:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:439
+		{
+			exprVAL.PipelineStage = newSDParserExpr(exprDollar[2].ParserFlags, nil)
+		}
+	case 160:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:440
+		{
+			exprVAL.PipelineStage = newSDParserExpr(nil, exprDollar[2].LabelExtractionExpressionList)
+		}
+	case 161:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:441
+		{
+			exprVAL.PipelineStage = newSDParserExpr(exprDollar[2].ParserFlags, exprDollar[3].LabelExtractionExpressionList)
+		}
+	case 162:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:445
+		{
+			exprVAL.PipelineStage = newXMLParserExpr(nil, nil)
+		}
+	case 163:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:327
+//line expr.y:446
+		{
+			exprVAL.PipelineStage = newXMLParserExpr(exprDollar[2].ParserFlags, nil)
+		}
+	case 164:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:447
+		{
+			exprVAL.PipelineStage = newXMLParserExpr(nil, exprDollar[2].LabelExtractionExpressionList)
+		}
+	case 165:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:448
+		{
+			exprVAL.PipelineStage = newXMLParserExpr(exprDollar[2].ParserFlags, exprDollar[3].LabelExtractionExpressionList)
+		}
+	case 166:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:452
 		{
 			exprVAL.JSONExpressionParser = newJSONExpressionParser(exprDollar[2].LabelExtractionExpressionList)
 		}
-	case 104:
+	case 167:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:330
+//line expr.y:455
 		{
 			exprVAL.LogfmtExpressionParser = newLogfmtExpressionParser(exprDollar[3].LabelExtractionExpressionList, exprDollar[2].ParserFlags)
 		}
-	case 105:
+	case 168:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:331
+//line expr.y:456
 		{
 			exprVAL.LogfmtExpressionParser = newLogfmtExpressionParser(exprDollar[2].LabelExtractionExpressionList, nil)
 		}
-	case 106:
+	case 169:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:334
+//line expr.y:459
 		{
 			exprVAL.LineFormatExpr = newLineFmtExpr(exprDollar[2].str)
 		}
-	case 107:
+	case 170:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:336
+//line expr.y:462
+		{
+			exprVAL.PipelineStage = newJSONFmtExpr(nil)
+		}
+	case 171:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:463
+		{
+			exprVAL.PipelineStage = newJSONFmtExpr(exprDollar[2].Labels)
+		}
+	case 172:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:468
+		{
+			exprVAL.Labels = exprDollar[1].Labels
+		}
+	case 173:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:469
+		{
+			exprVAL.Labels = append(exprDollar[1].Labels, exprDollar[3].Labels...)
+		}
+	case 174:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:473
+		{
+			exprVAL.Labels = []string{exprDollar[1].str, exprDollar[1].str}
+		}
+	case 175:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:474
+		{
+			exprVAL.Labels = []string{exprDollar[1].str, exprDollar[3].str}
+		}
+	case 176:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:475
+		{
+			exprVAL.Labels = []string{exprDollar[1].str, exprDollar[3].str}
+		}
+	case 177:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:478
 		{
 			exprVAL.DecolorizeExpr = newDecolorizeExpr()
 		}
-	case 108:
+	case 178:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:339
+//line expr.y:481
 		{
 			exprVAL.LabelFormat = log.NewRenameLabelFmt(exprDollar[1].str, exprDollar[3].str)
 		}
-	case 109:
+	case 179:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:340
+//line expr.y:482
 		{
 			exprVAL.LabelFormat = log.NewTemplateLabelFmt(exprDollar[1].str, exprDollar[3].str)
 		}
-	case 110:
+	case 180:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:344
+//line expr.y:486
 		{
 			exprVAL.LabelsFormat = []log.LabelFmt{exprDollar[1].LabelFormat}
 		}
-	case 111:
+	case 181:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:345
+//line expr.y:487
 		{
 			exprVAL.LabelsFormat = append(exprDollar[1].LabelsFormat, exprDollar[3].LabelFormat)
 		}
-	case 113:
+	case 183:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:350
+//line expr.y:492
 		{
 			exprVAL.LabelFormatExpr = newLabelFmtExpr(exprDollar[2].LabelsFormat)
 		}
-	case 114:
+	case 184:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:353
+//line expr.y:495
 		{
 			exprVAL.LabelFilter = log.NewStringLabelFilter(exprDollar[1].Matcher)
 		}
-	case 115:
+	case 185:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:354
+//line expr.y:496
 		{
 			exprVAL.LabelFilter = exprDollar[1].IPLabelFilter
 		}
-	case 116:
+	case 186:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:355
+//line expr.y:497
 		{
 			exprVAL.LabelFilter = exprDollar[1].UnitFilter
 		}
-	case 117:
+	case 187:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:356
+//line expr.y:498
 		{
 			exprVAL.LabelFilter = exprDollar[1].NumberFilter
 		}
-	case 118:
+	case 188:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:357
+//line expr.y:499
 		{
 			exprVAL.LabelFilter = exprDollar[2].LabelFilter
 		}
-	case 119:
+	case 189:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:358
+//line expr.y:500
 		{
 			exprVAL.LabelFilter = log.NewAndLabelFilter(exprDollar[1].LabelFilter, exprDollar[2].LabelFilter)
 		}
-	case 120:
+	case 190:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:359
+//line expr.y:501
 		{
 			exprVAL.LabelFilter = log.NewAndLabelFilter(exprDollar[1].LabelFilter, exprDollar[3].LabelFilter)
 		}
-	case 121:
+	case 191:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:360
+//line expr.y:502
 		{
 			exprVAL.LabelFilter = log.NewAndLabelFilter(exprDollar[1].LabelFilter, exprDollar[3].LabelFilter)
 		}
-	case 122:
+	case 192:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:361
+//line expr.y:503
 		{
 			exprVAL.LabelFilter = log.NewOrLabelFilter(exprDollar[1].LabelFilter, exprDollar[3].LabelFilter)
 		}
-	case 123:
+	case 193:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:365
+//line expr.y:507
 		{
 			exprVAL.LabelExtractionExpression = log.NewLabelExtractionExpr(exprDollar[1].str, exprDollar[3].str)
 		}
-	case 124:
+	case 194:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:366
+//line expr.y:508
 		{
 			exprVAL.LabelExtractionExpression = log.NewLabelExtractionExpr(exprDollar[1].str, exprDollar[1].str)
 		}
-	case 125:
+	case 195:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:369
+//line expr.y:511
 		{
 			exprVAL.LabelExtractionExpressionList = []log.LabelExtractionExpr{exprDollar[1].LabelExtractionExpression}
 		}
-	case 126:
+	case 196:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:370
+//line expr.y:512
 		{
 			exprVAL.LabelExtractionExpressionList = append(exprDollar[1].LabelExtractionExpressionList, exprDollar[3].LabelExtractionExpression)
 		}
-	case 127:
+	case 197:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
-//line expr.y:374
+//line expr.y:516
 		{
 			exprVAL.IPLabelFilter = log.NewIPLabelFilter(exprDollar[5].str, exprDollar[1].str, log.LabelFilterEqual)
 		}
-	case 128:
+	case 198:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
-//line expr.y:375
+//line expr.y:517
 		{
 			exprVAL.IPLabelFilter = log.NewIPLabelFilter(exprDollar[5].str, exprDollar[1].str, log.LabelFilterNotEqual)
 		}
-	case 129:
+	case 199:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:379
+//line expr.y:521
 		{
 			exprVAL.UnitFilter = exprDollar[1].DurationFilter
 		}
-	case 130:
+	case 200:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:380
+//line expr.y:522
 		{
 			exprVAL.UnitFilter = exprDollar[1].BytesFilter
 		}
-	case 131:
+	case 201:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:383
+//line expr.y:525
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterGreaterThan, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 132:
+	case 202:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:384
+//line expr.y:526
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterGreaterThanOrEqual, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 133:
+	case 203:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:385
+//line expr.y:527
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterLesserThan, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 134:
+	case 204:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:386
+//line expr.y:528
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterLesserThanOrEqual, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 135:
+	case 205:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:387
+//line expr.y:529
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterNotEqual, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 136:
+	case 206:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:388
+//line expr.y:530
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterEqual, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 137:
+	case 207:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:389
+//line expr.y:531
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterEqual, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 138:
+	case 208:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:393
+//line expr.y:535
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterGreaterThan, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 139:
+	case 209:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:394
+//line expr.y:536
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterGreaterThanOrEqual, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 140:
+	case 210:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:395
+//line expr.y:537
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterLesserThan, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 141:
+	case 211:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:396
+//line expr.y:538
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterLesserThanOrEqual, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 142:
+	case 212:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:397
+//line expr.y:539
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterNotEqual, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 143:
+	case 213:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:398
+//line expr.y:540
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterEqual, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 144:
+	case 214:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:399
+//line expr.y:541
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterEqual, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 145:
+	case 215:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:403
+//line expr.y:545
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterGreaterThan, exprDollar[1].str, exprDollar[3].LiteralExpr.Val)
 		}
-	case 146:
+	case 216:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:404
+//line expr.y:546
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterGreaterThanOrEqual, exprDollar[1].str, exprDollar[3].LiteralExpr.Val)
 		}
-	case 147:
+	case 217:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:405
+//line expr.y:547
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterLesserThan, exprDollar[1].str, exprDollar[3].LiteralExpr.Val)
 		}
-	case 148:
+	case 218:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:406
+//line expr.y:548
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterLesserThanOrEqual, exprDollar[1].str, exprDollar[3].LiteralExpr.Val)
 		}
-	case 149:
+	case 219:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:407
+//line expr.y:549
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterNotEqual, exprDollar[1].str, exprDollar[3].LiteralExpr.Val)
 		}
-	case 150:
+	case 220:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:408
+//line expr.y:550
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterEqual, exprDollar[1].str, exprDollar[3].LiteralExpr.Val)
 		}
-	case 151:
+	case 221:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:409
+//line expr.y:551
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterEqual, exprDollar[1].str, exprDollar[3].LiteralExpr.Val)
 		}
-	case 152:
+	case 222:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:413
+//line expr.y:555
 		{
 			exprVAL.DropLabel = log.NewDropLabel(nil, exprDollar[1].str)
 		}
-	case 153:
+	case 223:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:414
+//line expr.y:556
 		{
 			exprVAL.DropLabel = log.NewDropLabel(exprDollar[1].Matcher, "")
 		}
-	case 154:
+	case 224:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:417
+//line expr.y:559
 		{
 			exprVAL.DropLabels = []log.DropLabel{exprDollar[1].DropLabel}
 		}
-	case 155:
+	case 225:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:418
+//line expr.y:560
 		{
 			exprVAL.DropLabels = append(exprDollar[1].DropLabels, exprDollar[3].DropLabel)
 		}
-	case 156:
+	case 226:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:421
+//line expr.y:563
 		{
 			exprVAL.DropLabelsExpr = newDropLabelsExpr(exprDollar[2].DropLabels)
 		}
-	case 157:
+	case 227:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:424
+//line expr.y:566
 		{
 			exprVAL.KeepLabel = log.NewKeepLabel(nil, exprDollar[1].str)
 		}
-	case 158:
+	case 228:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:425
+//line expr.y:567
 		{
 			exprVAL.KeepLabel = log.NewKeepLabel(exprDollar[1].Matcher, "")
 		}
-	case 159:
+	case 229:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:428
+//line expr.y:570
 		{
 			exprVAL.KeepLabels = []log.KeepLabel{exprDollar[1].KeepLabel}
 		}
-	case 160:
+	case 230:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:429
+//line expr.y:571
 		{
 			exprVAL.KeepLabels = append(exprDollar[1].KeepLabels, exprDollar[3].KeepLabel)
 		}
-	case 161:
+	case 231:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:432
+//line expr.y:574
 		{
 			exprVAL.KeepLabelsExpr = newKeepLabelsExpr(exprDollar[2].KeepLabels)
 		}
-	case 162:
+	case 232:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+//line expr.y:577
+		{
+			exprVAL.SortByExpr = newSortByExpr(exprDollar[3].str, "")
+		}
+	case 233:
+		exprDollar = exprS[exprpt-5 : exprpt+1]
+//line expr.y:578
+		{
+			exprVAL.SortByExpr = newSortByExpr(exprDollar[3].str, exprDollar[4].str)
+		}
+	case 234:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:581
+		{
+			exprVAL.LimitExpr = newLimitExpr(exprDollar[2].str)
+		}
+	case 235:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:584
+		{
+			exprVAL.PipelineStage = newDedupExpr(nil, 0)
+		}
+	case 236:
+		exprDollar = exprS[exprpt-5 : exprpt+1]
+//line expr.y:585
+		{
+			exprVAL.PipelineStage = newDedupExpr(exprDollar[4].Labels, 0)
+		}
+	case 237:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:586
+		{
+			exprVAL.PipelineStage = newDedupExpr(nil, exprDollar[3].duration)
+		}
+	case 238:
+		exprDollar = exprS[exprpt-7 : exprpt+1]
+//line expr.y:587
+		{
+			exprVAL.PipelineStage = newDedupExpr(exprDollar[4].Labels, exprDollar[7].duration)
+		}
+	case 239:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+//line expr.y:591
+		{
+			exprVAL.PipelineStage = newGeoIPExpr(exprDollar[3].str)
+		}
+	case 240:
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+//line expr.y:592
+		{
+			exprVAL.PipelineStage = newCIDRLabelExpr(exprDollar[3].str, exprDollar[5].str)
+		}
+	case 241:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:595
+		{
+			exprVAL.PipelineStage = newMacroExpr(exprDollar[1].str)
+		}
+	case 242:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:436
+//line expr.y:599
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("or", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 163:
+	case 243:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:437
+//line expr.y:600
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("and", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 164:
+	case 244:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:438
+//line expr.y:601
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("unless", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 165:
+	case 245:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:439
+//line expr.y:602
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("+", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 166:
+	case 246:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:440
+//line expr.y:603
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("-", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 167:
+	case 247:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:441
+//line expr.y:604
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("*", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 168:
+	case 248:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:442
+//line expr.y:605
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("/", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 169:
+	case 249:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:443
+//line expr.y:606
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("%", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 170:
+	case 250:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:444
+//line expr.y:607
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("^", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 171:
+	case 251:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:445
+//line expr.y:608
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("==", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 172:
+	case 252:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:446
+//line expr.y:609
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("!=", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 173:
+	case 253:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:447
+//line expr.y:610
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr(">", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 174:
+	case 254:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:448
+//line expr.y:611
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr(">=", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 175:
+	case 255:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:449
+//line expr.y:612
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("<", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 176:
+	case 256:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:450
+//line expr.y:613
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("<=", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 177:
+	case 257:
 		exprDollar = exprS[exprpt-0 : exprpt+1]
-//line expr.y:454
+//line expr.y:617
 		{
 			exprVAL.BoolModifier = &BinOpOptions{VectorMatching: &VectorMatching{Card: CardOneToOne}}
 		}
-	case 178:
+	case 258:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:458
+//line expr.y:621
 		{
 			exprVAL.BoolModifier = &BinOpOptions{VectorMatching: &VectorMatching{Card: CardOneToOne}, ReturnBool: true}
 		}
-	case 179:
+	case 259:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:465
+//line expr.y:628
 		{
 			exprVAL.OnOrIgnoringModifier = exprDollar[1].BoolModifier
 			exprVAL.OnOrIgnoringModifier.VectorMatching.On = true
 			exprVAL.OnOrIgnoringModifier.VectorMatching.MatchingLabels = exprDollar[4].Labels
 		}
-	case 180:
+	case 260:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:471
+//line expr.y:634
 		{
 			exprVAL.OnOrIgnoringModifier = exprDollar[1].BoolModifier
 			exprVAL.OnOrIgnoringModifier.VectorMatching.On = true
 		}
-	case 181:
+	case 261:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:476
+//line expr.y:639
 		{
 			exprVAL.OnOrIgnoringModifier = exprDollar[1].BoolModifier
 			exprVAL.OnOrIgnoringModifier.VectorMatching.MatchingLabels = exprDollar[4].Labels
 		}
-	case 182:
+	case 262:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:481
+//line expr.y:644
 		{
 			exprVAL.OnOrIgnoringModifier = exprDollar[1].BoolModifier
 		}
-	case 183:
+	case 263:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:487
+//line expr.y:650
 		{
 			exprVAL.BinOpModifier = exprDollar[1].BoolModifier
 		}
-	case 184:
+	case 264:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:488
+//line expr.y:651
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 		}
-	case 185:
+	case 265:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:490
+//line expr.y:653
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardManyToOne
 		}
-	case 186:
+	case 266:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:495
+//line expr.y:658
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardManyToOne
 		}
-	case 187:
+	case 267:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:500
+//line expr.y:663
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardManyToOne
 			exprVAL.BinOpModifier.VectorMatching.Include = exprDollar[4].Labels
 		}
-	case 188:
+	case 268:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:506
+//line expr.y:669
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardOneToMany
 		}
-	case 189:
+	case 269:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:511
+//line expr.y:674
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardOneToMany
 		}
-	case 190:
+	case 270:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:516
+//line expr.y:679
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardOneToMany
 			exprVAL.BinOpModifier.VectorMatching.Include = exprDollar[4].Labels
 		}
-	case 191:
+	case 271:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:524
+//line expr.y:687
 		{
 			exprVAL.LiteralExpr = mustNewLiteralExpr(exprDollar[1].str, false)
 		}
-	case 192:
+	case 272:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:525
+//line expr.y:688
 		{
 			exprVAL.LiteralExpr = mustNewLiteralExpr(exprDollar[2].str, false)
 		}
-	case 193:
+	case 273:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:526
+//line expr.y:689
 		{
 			exprVAL.LiteralExpr = mustNewLiteralExpr(exprDollar[2].str, true)
 		}
-	case 194:
+	case 274:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:530
+//line expr.y:693
 		{
 			exprVAL.VectorExpr = NewVectorExpr(exprDollar[3].str)
 		}
-	case 195:
+	case 275:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:533
+//line expr.y:696
 		{
 			exprVAL.Vector = OpTypeVector
 		}
-	case 196:
+	case 276:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:537
+//line expr.y:700
 		{
 			exprVAL.VectorOp = OpTypeSum
 		}
-	case 197:
+	case 277:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:538
+//line expr.y:701
 		{
 			exprVAL.VectorOp = OpTypeAvg
 		}
-	case 198:
+	case 278:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:539
+//line expr.y:702
 		{
 			exprVAL.VectorOp = OpTypeCount
 		}
-	case 199:
+	case 279:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:540
+//line expr.y:703
 		{
 			exprVAL.VectorOp = OpTypeMax
 		}
-	case 200:
+	case 280:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:541
+//line expr.y:704
 		{
 			exprVAL.VectorOp = OpTypeMin
 		}
-	case 201:
+	case 281:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:542
+//line expr.y:705
 		{
 			exprVAL.VectorOp = OpTypeStddev
 		}
-	case 202:
+	case 282:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:543
+//line expr.y:706
 		{
 			exprVAL.VectorOp = OpTypeStdvar
 		}
-	case 203:
+	case 283:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:544
+//line expr.y:707
 		{
 			exprVAL.VectorOp = OpTypeBottomK
 		}
-	case 204:
+	case 284:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:545
+//line expr.y:708
 		{
 			exprVAL.VectorOp = OpTypeTopK
 		}
-	case 205:
+	case 285:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:546
+//line expr.y:709
 		{
 			exprVAL.VectorOp = OpTypeSort
 		}
-	case 206:
+	case 286:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:547
+//line expr.y:710
 		{
 			exprVAL.VectorOp = OpTypeSortDesc
 		}
-	case 207:
+	case 287:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:551
+//line expr.y:714
 		{
 			exprVAL.RangeOp = OpRangeTypeCount
 		}
-	case 208:
+	case 288:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:552
+//line expr.y:715
 		{
 			exprVAL.RangeOp = OpRangeTypeRate
 		}
-	case 209:
+	case 289:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:553
+//line expr.y:716
 		{
 			exprVAL.RangeOp = OpRangeTypeRateCounter
 		}
-	case 210:
+	case 290:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:554
+//line expr.y:717
 		{
 			exprVAL.RangeOp = OpRangeTypeBytes
 		}
-	case 211:
+	case 291:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:555
+//line expr.y:718
 		{
 			exprVAL.RangeOp = OpRangeTypeBytesRate
 		}
-	case 212:
+	case 292:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:556
+//line expr.y:719
 		{
 			exprVAL.RangeOp = OpRangeTypeAvg
 		}
-	case 213:
+	case 293:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:557
+//line expr.y:720
 		{
 			exprVAL.RangeOp = OpRangeTypeSum
 		}
-	case 214:
+	case 294:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:558
+//line expr.y:721
 		{
 			exprVAL.RangeOp = OpRangeTypeMin
 		}
-	case 215:
+	case 295:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:559
+//line expr.y:722
 		{
 			exprVAL.RangeOp = OpRangeTypeMax
 		}
-	case 216:
+	case 296:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:560
+//line expr.y:723
 		{
 			exprVAL.RangeOp = OpRangeTypeStdvar
 		}
-	case 217:
+	case 297:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:561
+//line expr.y:724
 		{
 			exprVAL.RangeOp = OpRangeTypeStddev
 		}
-	case 218:
+	case 298:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:562
+//line expr.y:725
 		{
 			exprVAL.RangeOp = OpRangeTypeQuantile
 		}
-	case 219:
+	case 299:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:563
+//line expr.y:726
 		{
 			exprVAL.RangeOp = OpRangeTypeFirst
 		}
-	case 220:
+	case 300:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:564
+//line expr.y:727
 		{
 			exprVAL.RangeOp = OpRangeTypeLast
 		}
-	case 221:
+	case 301:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:565
+//line expr.y:728
 		{
 			exprVAL.RangeOp = OpRangeTypeAbsent
 		}
-	case 222:
+	case 302:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:729
+		{
+			exprVAL.RangeOp = OpRangeTypeCountDistinct
+		}
+	case 303:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:730
+		{
+			exprVAL.RangeOp = OpRangeTypeDeriv
+		}
+	case 304:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:731
+		{
+			exprVAL.RangeOp = OpRangeTypePredictLinear
+		}
+	case 305:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:732
+		{
+			exprVAL.RangeOp = OpRangeTypeHoltWinters
+		}
+	case 306:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:569
+//line expr.y:736
 		{
 			exprVAL.OffsetExpr = newOffsetExpr(exprDollar[2].duration)
 		}
-	case 223:
+	case 307:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:572
+//line expr.y:739
 		{
 			exprVAL.Labels = []string{exprDollar[1].str}
 		}
-	case 224:
+	case 308:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:573
+//line expr.y:740
 		{
 			exprVAL.Labels = append(exprDollar[1].Labels, exprDollar[3].str)
 		}
-	case 225:
+	case 309:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:577
+//line expr.y:744
 		{
 			exprVAL.Grouping = &Grouping{Without: false, Groups: exprDollar[3].Labels}
 		}
-	case 226:
+	case 310:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:578
+//line expr.y:745
 		{
 			exprVAL.Grouping = &Grouping{Without: true, Groups: exprDollar[3].Labels}
 		}
-	case 227:
+	case 311:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:579
+//line expr.y:746
 		{
 			exprVAL.Grouping = &Grouping{Without: false, Groups: nil}
 		}
-	case 228:
+	case 312:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:580
+//line expr.y:747
 		{
 			exprVAL.Grouping = &Grouping{Without: true, Groups: nil}
 		}
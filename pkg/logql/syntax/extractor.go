@@ -56,19 +56,37 @@ func (r RangeAggregationExpr) extractor(override *Grouping) (log.SampleExtractor
 	}
 	// unwrap...means we want to extract metrics from labels.
 	if r.Left.Unwrap != nil {
-		var convOp string
-		switch r.Left.Unwrap.Operation {
-		case OpConvBytes:
-			convOp = log.ConvertBytes
-		case OpConvDuration, OpConvDurationSeconds:
-			convOp = log.ConvertDuration
-		default:
-			convOp = log.ConvertFloat
+		convOp := func(operation string) string {
+			// distinct values are counted by the hash of their string value.
+			if r.Operation == OpRangeTypeCountDistinct || r.Operation == OpRangeTypeCountDistinctSketch {
+				return log.ConvertHash
+			}
+			switch operation {
+			case OpConvBytes:
+				return log.ConvertBytes
+			case OpConvDuration, OpConvDurationSeconds:
+				return log.ConvertDuration
+			default:
+				return log.ConvertFloat
+			}
+		}
+
+		if r.Left.Unwrap.IsMultiple() {
+			labelNames := make([]string, 0, len(r.Left.Unwrap.Labels))
+			conversions := make([]string, 0, len(r.Left.Unwrap.Labels))
+			for _, l := range r.Left.Unwrap.Labels {
+				labelNames = append(labelNames, l.Identifier)
+				conversions = append(conversions, convOp(l.Operation))
+			}
+			return log.MultiLabelExtractorWithStages(
+				labelNames, conversions, groups, without, noLabels, stages,
+				log.ReduceAndLabelFilter(r.Left.Unwrap.PostFilters),
+			)
 		}
 
 		return log.LabelExtractorWithStages(
 			r.Left.Unwrap.Identifier,
-			convOp, groups, without, noLabels, stages,
+			convOp(r.Left.Unwrap.Operation), groups, without, noLabels, stages,
 			log.ReduceAndLabelFilter(r.Left.Unwrap.PostFilters),
 		)
 	}
@@ -27,6 +27,7 @@ var tokens = map[string]int{
 	"|=":           PIPE_EXACT,
 	"|~":           PIPE_MATCH,
 	"|>":           PIPE_PATTERN,
+	OpSearch:       PIPE_SEARCH,
 	OpPipe:         PIPE,
 	OpUnwrap:       UNWRAP,
 	"(":            OPEN_PARENTHESIS,
@@ -70,6 +71,7 @@ var tokens = map[string]int{
 	// fmt
 	OpFmtLabel: LABEL_FMT,
 	OpFmtLine:  LINE_FMT,
+	OpFmtJSON:  JSON_FMT,
 
 	// filter functions
 	OpFilterIP:   IP,
@@ -82,30 +84,58 @@ var tokens = map[string]int{
 	OpKeep: KEEP,
 }
 
+// stageTokens are the keywords of stages which are only lexed as such right
+// after a pipe, so they can still be used as label names.
+var stageTokens = map[string]int{
+	OpJoin:              JOIN,
+	OpParserTypeCSV:     CSV,
+	OpParserTypeSD:      SD,
+	OpParserTypeXML:     XML,
+	OpDedup:             DEDUP,
+	OpParserTypeExtract: EXTRACT,
+}
+
+// filterModifiers maps the |= and != tokens to their variants by modifier.
+var filterModifiers = map[int]map[rune]int{
+	PIPE_EXACT: {'i': PIPE_EXACT_FOLD, 'w': PIPE_WORD},
+	NEQ:        {'i': NEQ_FOLD, 'w': NEQ_WORD},
+}
+
 var parserFlags = map[string]struct{}{
-	OpStrict:    {},
-	OpKeepEmpty: {},
+	OpStrict:         {},
+	OpKeepEmpty:      {},
+	OpSearchMetadata: {},
 }
 
 // functionTokens are tokens that needs to be suffixes with parenthesis
 var functionTokens = map[string]int{
 	// range vec ops
-	OpRangeTypeRate:        RATE,
-	OpRangeTypeRateCounter: RATE_COUNTER,
-	OpRangeTypeCount:       COUNT_OVER_TIME,
-	OpRangeTypeBytesRate:   BYTES_RATE,
-	OpRangeTypeBytes:       BYTES_OVER_TIME,
-	OpRangeTypeAvg:         AVG_OVER_TIME,
-	OpRangeTypeSum:         SUM_OVER_TIME,
-	OpRangeTypeMin:         MIN_OVER_TIME,
-	OpRangeTypeMax:         MAX_OVER_TIME,
-	OpRangeTypeStdvar:      STDVAR_OVER_TIME,
-	OpRangeTypeStddev:      STDDEV_OVER_TIME,
-	OpRangeTypeQuantile:    QUANTILE_OVER_TIME,
-	OpRangeTypeFirst:       FIRST_OVER_TIME,
-	OpRangeTypeLast:        LAST_OVER_TIME,
-	OpRangeTypeAbsent:      ABSENT_OVER_TIME,
-	OpTypeVector:           VECTOR,
+	OpRangeTypeRate:          RATE,
+	OpRangeTypeRateCounter:   RATE_COUNTER,
+	OpRangeTypeCount:         COUNT_OVER_TIME,
+	OpRangeTypeBytesRate:     BYTES_RATE,
+	OpRangeTypeBytes:         BYTES_OVER_TIME,
+	OpRangeTypeAvg:           AVG_OVER_TIME,
+	OpRangeTypeSum:           SUM_OVER_TIME,
+	OpRangeTypeMin:           MIN_OVER_TIME,
+	OpRangeTypeMax:           MAX_OVER_TIME,
+	OpRangeTypeStdvar:        STDVAR_OVER_TIME,
+	OpRangeTypeStddev:        STDDEV_OVER_TIME,
+	OpRangeTypeQuantile:      QUANTILE_OVER_TIME,
+	OpRangeTypeFirst:         FIRST_OVER_TIME,
+	OpRangeTypeLast:          LAST_OVER_TIME,
+	OpRangeTypeAbsent:        ABSENT_OVER_TIME,
+	OpRangeTypeHistogram:     HISTOGRAM_OVER_TIME,
+	OpRangeTypeCountDistinct: COUNT_DISTINCT_OVER_TIME,
+	OpRangeTypeDeriv:         DERIV,
+	OpRangeTypePredictLinear: PREDICT_LINEAR,
+	OpRangeTypeHoltWinters:   HOLT_WINTERS,
+	OpTypeVector:             VECTOR,
+
+	// time-shifted comparisons
+	OpTypeChangesVs:    CHANGES_VS,
+	OpTypeDayOverDay:   DAY_OVER_DAY,
+	OpTypeWeekOverWeek: WEEK_OVER_WEEK,
 
 	// vec ops
 	OpTypeSum:      SUM,
@@ -121,6 +151,13 @@ var functionTokens = map[string]int{
 	OpTypeSortDesc: SORT_DESC,
 	OpLabelReplace: LABEL_REPLACE,
 
+	// label functions
+	OpLabelJoin:     LABEL_JOIN,
+	OpLabelMap:      LABEL_MAP,
+	OpLabelLower:    LABEL_LOWER,
+	OpLabelUpper:    LABEL_UPPER,
+	OpLabelTruncate: LABEL_TRUNCATE,
+
 	// conversion Op
 	OpConvBytes:           BYTES_CONV,
 	OpConvDuration:        DURATION_CONV,
@@ -128,15 +165,54 @@ var functionTokens = map[string]int{
 
 	// filterOp
 	OpFilterIP: IP,
+
+	// sort
+	OpSortBy: SORT_BY,
+
+	// enrichment
+	OpGeoIP:     GEOIP,
+	OpCIDRLabel: CIDR_LABEL,
+}
+
+// rangeOpTokens are the tokens of the range aggregations, which can be applied
+// to a subquery.
+var rangeOpTokens = map[int]struct{}{
+	COUNT_OVER_TIME:          {},
+	RATE:                     {},
+	RATE_COUNTER:             {},
+	BYTES_OVER_TIME:          {},
+	BYTES_RATE:               {},
+	AVG_OVER_TIME:            {},
+	SUM_OVER_TIME:            {},
+	MIN_OVER_TIME:            {},
+	MAX_OVER_TIME:            {},
+	STDVAR_OVER_TIME:         {},
+	STDDEV_OVER_TIME:         {},
+	QUANTILE_OVER_TIME:       {},
+	FIRST_OVER_TIME:          {},
+	LAST_OVER_TIME:           {},
+	ABSENT_OVER_TIME:         {},
+	COUNT_DISTINCT_OVER_TIME: {},
+	DERIV:                    {},
+	PREDICT_LINEAR:           {},
+	HOLT_WINTERS:             {},
 }
 
 type lexer struct {
 	Scanner
 	errs    []logqlmodel.ParseError
 	builder strings.Builder
+	// last is the last token lexed.
+	last int
 }
 
 func (l *lexer) Lex(lval *exprSymType) int {
+	tok := l.lex(lval)
+	l.last = tok
+	return tok
+}
+
+func (l *lexer) lex(lval *exprSymType) int {
 	r := l.Scan()
 
 	switch r {
@@ -146,7 +222,7 @@ func (l *lexer) Lex(lval *exprSymType) int {
 		for next := l.Peek(); !(next == '\n' || next == scanner.EOF); next = l.Next() {
 		}
 
-		return l.Lex(lval)
+		return l.lex(lval)
 
 	case scanner.EOF:
 		return 0
@@ -182,6 +258,14 @@ func (l *lexer) Lex(lval *exprSymType) int {
 			return DURATION
 		}
 
+	case '@': // macro invocations, the name must directly follow the '@'
+		if next := l.Peek(); next != '_' && !unicode.IsLetter(next) || l.Scan() != scanner.Ident {
+			l.Error("expected a macro name after '@'")
+			return 0
+		}
+		lval.str = l.TokenText()
+		return MACRO
+
 	case scanner.String, scanner.RawString:
 		var err error
 		tokenText := l.TokenText()
@@ -202,6 +286,9 @@ func (l *lexer) Lex(lval *exprSymType) int {
 		l.builder.Reset()
 		for r := l.Next(); r != scanner.EOF; r = l.Next() {
 			if r == ']' {
+				if rng, step, ok := strings.Cut(l.builder.String(), ":"); ok {
+					return l.lexSubqueryRange(lval, rng, step)
+				}
 				i, err := model.ParseDuration(l.builder.String())
 				if err != nil {
 					l.Error(err.Error())
@@ -238,8 +325,31 @@ func (l *lexer) Lex(lval *exprSymType) int {
 		return tok
 	}
 
+	// The parenthesis of a range aggregation opens a subquery when its
+	// argument is followed by a [range:resolution]. It gets its own token so
+	// that the parser can still tell which tokens a range aggregation expects.
+	if _, ok := rangeOpTokens[l.last]; ok && r == '(' && isSubquery(l.Scanner) {
+		return OPEN_SUBQUERY
+	}
+
+	// limit and within are only keywords when followed by a number so they
+	// can still be used as label names.
+	if tokenTextLower == OpLimit && isNumber(l.Scanner) {
+		return LIMIT
+	}
+	if tokenTextLower == OpWithin && isNumber(l.Scanner) {
+		return WITHIN
+	}
+
+	if tok, ok := stageTokens[tokenTextLower]; ok && l.last == PIPE && isStage(l.Scanner) {
+		return tok
+	}
+
 	if tok, ok := tokens[tokenNext]; ok {
 		l.Next()
+		if tok == PIPE_EXACT || tok == NEQ {
+			return l.lexFilterModifier(tok)
+		}
 		return tok
 	}
 
@@ -251,6 +361,44 @@ func (l *lexer) Lex(lval *exprSymType) int {
 	return IDENTIFIER
 }
 
+// lexFilterModifier lexes the modifier of the |= and != line filters, ~i for
+// case-insensitive and ~w for whole-word matching, e.g. |=~i.
+func (l *lexer) lexFilterModifier(tok int) int {
+	if l.Peek() != '~' {
+		return tok
+	}
+	sc := l.Scanner
+	sc.Next()
+	modifiers := filterModifiers[tok]
+	modified, ok := modifiers[sc.Peek()]
+	if !ok {
+		return tok
+	}
+	l.Next()
+	l.Next()
+	return modified
+}
+
+// lexSubqueryRange parses the range and resolution of a subquery, e.g. [1h:1m].
+func (l *lexer) lexSubqueryRange(lval *exprSymType, rng, step string) int {
+	r, err := model.ParseDuration(rng)
+	if err != nil {
+		l.Error(err.Error())
+		return 0
+	}
+	if step == "" {
+		l.Error("missing subquery resolution")
+		return 0
+	}
+	s, err := model.ParseDuration(step)
+	if err != nil {
+		l.Error(err.Error())
+		return 0
+	}
+	lval.subqueryRange = subqueryRange{Range: time.Duration(r), Step: time.Duration(s)}
+	return SUBQUERY_RANGE
+}
+
 func (l *lexer) Error(msg string) {
 	l.errs = append(l.errs, logqlmodel.NewParseError(msg, l.Line, l.Column))
 }
@@ -405,6 +553,55 @@ func isFunction(sc Scanner) bool {
 	return false
 }
 
+// isSubquery returns whether the parenthesis just lexed encloses a
+// [range:resolution], outside of any nested parenthesis.
+func isSubquery(sc Scanner) bool {
+	depth := 1
+	for r := sc.Next(); r != scanner.EOF; r = sc.Next() {
+		switch r {
+		case '"', '`':
+			for next := sc.Next(); next != r && next != scanner.EOF; next = sc.Next() {
+				if next == '\\' && r == '"' {
+					sc.Next()
+				}
+			}
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return false
+			}
+		case '[':
+			if depth > 1 {
+				continue
+			}
+			for next := sc.Next(); next != ']' && next != scanner.EOF; next = sc.Next() {
+				if next == ':' {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// isStage returns whether the stage keyword just lexed starts a stage rather
+// than a label filter on a label with the same name, e.g. | csv="1".
+func isStage(sc Scanner) bool {
+	sc = trimSpace(sc)
+	switch sc.Peek() {
+	case '=', '!', '<', '>':
+		return false
+	}
+	return true
+}
+
+func isNumber(sc Scanner) bool {
+	sc = trimSpace(sc)
+	return unicode.IsDigit(sc.Peek())
+}
+
 func trimSpace(l Scanner) Scanner {
 	for n := l.Peek(); n != scanner.EOF; n = l.Peek() {
 		if unicode.IsSpace(n) {
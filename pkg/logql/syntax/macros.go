@@ -0,0 +1,122 @@
+package syntax
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/loki/v3/pkg/logqlmodel"
+)
+
+// macroSelector is the selector used to parse the body of a macro as the
+// pipeline of a log query.
+const macroSelector = `{__macro__=""} `
+
+var macroNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// HasMacros reports whether the expression invokes a macro.
+func HasMacros(expr Expr) bool {
+	var found bool
+	expr.Walk(func(e Expr) {
+		if _, ok := e.(*MacroExpr); ok {
+			found = true
+		}
+	})
+	return found
+}
+
+// ParseMacro parses the body of a macro, which is a pipeline of stages as they
+// are written in a log query, e.g. `| json | status >= 500`.
+func ParseMacro(body string) (MultiStageExpr, error) {
+	expr, err := ParseLogSelector(macroSelector+body, false)
+	if err != nil {
+		return nil, err
+	}
+	pipeline, ok := expr.(*PipelineExpr)
+	if !ok {
+		return nil, logqlmodel.NewParseError("the body of a macro must be a pipeline of stages", 0, 0)
+	}
+	return pipeline.MultiStages, nil
+}
+
+// ValidateMacro checks the name and body of a macro, and that the macros it
+// invokes are defined and don't invoke it back once it is added to macros.
+func ValidateMacro(name, body string, macros map[string]string) error {
+	if !macroNameRegexp.MatchString(name) {
+		return logqlmodel.NewParseError(fmt.Sprintf("invalid macro name %q", name), 0, 0)
+	}
+	if _, err := ParseMacro(body); err != nil {
+		return err
+	}
+	defined := make(map[string]string, len(macros)+1)
+	for n, b := range macros {
+		defined[n] = b
+	}
+	defined[name] = body
+
+	x := macroExpander{macros: defined}
+	_, err := x.expand(MultiStageExpr{newMacroExpr(name)}, nil)
+	return err
+}
+
+// ExpandMacros replaces the macros invoked by the expression with the stages
+// of their body. Macros can invoke other macros but not recursively. The
+// expanded expression is validated as any other query since the stages of a
+// macro may not be allowed where it is invoked.
+func ExpandMacros(expr Expr, macros map[string]string) (Expr, error) {
+	if !HasMacros(expr) {
+		return expr, nil
+	}
+	expanded, err := Clone(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	x := macroExpander{macros: macros}
+	expanded.Walk(func(e Expr) {
+		if p, ok := e.(*PipelineExpr); ok && err == nil {
+			p.MultiStages, err = x.expand(p.MultiStages, nil)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ParseExpr(expanded.String())
+}
+
+type macroExpander struct {
+	macros map[string]string
+}
+
+// expand returns the stages with the macros replaced by the stages of their
+// body. path holds the macros being expanded to detect recursion.
+func (x macroExpander) expand(stages MultiStageExpr, path []string) (MultiStageExpr, error) {
+	result := make(MultiStageExpr, 0, len(stages))
+	for _, s := range stages {
+		m, ok := s.(*MacroExpr)
+		if !ok {
+			result = append(result, s)
+			continue
+		}
+		invoked := append(path[:len(path):len(path)], m.Name)
+		for _, name := range path {
+			if name == m.Name {
+				return nil, logqlmodel.NewParseError(fmt.Sprintf("macro %s%s() is recursive: %s", OpMacro, m.Name, strings.Join(invoked, " -> ")), 0, 0)
+			}
+		}
+		body, ok := x.macros[m.Name]
+		if !ok {
+			return nil, logqlmodel.NewParseError(fmt.Sprintf("macro %s%s() is not defined", OpMacro, m.Name), 0, 0)
+		}
+		parsed, err := ParseMacro(body)
+		if err != nil {
+			return nil, logqlmodel.NewParseError(fmt.Sprintf("invalid macro %s%s(): %s", OpMacro, m.Name, err), 0, 0)
+		}
+		parsed, err = x.expand(parsed, invoked)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, parsed...)
+	}
+	return result, nil
+}
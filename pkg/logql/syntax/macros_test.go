@@ -0,0 +1,122 @@
+package syntax
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/logql/log"
+)
+
+func TestParseMacroInvocation(t *testing.T) {
+	expr, err := ParseExpr(`{app="nginx"} |= "GET" | @parse_nginx() | status >= 500`)
+	require.NoError(t, err)
+	require.Equal(t, &PipelineExpr{
+		Left: newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "nginx")}),
+		MultiStages: MultiStageExpr{
+			newLineFilterExpr(log.LineMatchEqual, "", "GET"),
+			newMacroExpr("parse_nginx"),
+			&LabelFilterExpr{LabelFilterer: log.NewNumericLabelFilter(log.LabelFilterGreaterThanOrEqual, "status", 500)},
+		},
+	}, expr)
+	require.Equal(t, `{app="nginx"} |= "GET" | @parse_nginx() | status>=500`, expr.String())
+	require.True(t, HasMacros(expr))
+
+	for _, query := range []string{
+		`{app="nginx"} | @ parse_nginx()`,
+		`{app="nginx"} | @parse_nginx`,
+		`{app="nginx"} | @()`,
+	} {
+		_, err := ParseExpr(query)
+		require.Error(t, err, query)
+	}
+}
+
+func TestExpandMacros(t *testing.T) {
+	macros := map[string]string{
+		"parse_nginx": `| pattern "<ip> - - <_> \"<method> <path> <_>\" <status> <size>"`,
+		"errors":      `| @parse_nginx() | status >= 500`,
+		"loop_a":      `| @loop_b()`,
+		"loop_b":      `| json | @loop_a()`,
+		"sorted":      `| sort_by(status desc)`,
+	}
+
+	for _, tc := range []struct {
+		query    string
+		expected string
+		err      string
+	}{
+		{
+			query:    `{app="nginx"} | logfmt`,
+			expected: `{app="nginx"} | logfmt`,
+		},
+		{
+			query:    `{app="nginx"} | @errors() | line_format "{{.path}}"`,
+			expected: `{app="nginx"} | pattern "<ip> - - <_> \"<method> <path> <_>\" <status> <size>" | status>=500 | line_format "{{.path}}"`,
+		},
+		{
+			query:    `sum by (path) (count_over_time({app="nginx"} | @errors() [5m]))`,
+			expected: `sum by (path)(count_over_time({app="nginx"} | pattern "<ip> - - <_> \"<method> <path> <_>\" <status> <size>" | status>=500[5m]))`,
+		},
+		{
+			query: `{app="nginx"} | @loop_a()`,
+			err:   "macro @loop_a() is recursive: loop_a -> loop_b -> loop_a",
+		},
+		{
+			query: `{app="nginx"} | @unknown()`,
+			err:   "macro @unknown() is not defined",
+		},
+		{
+			query: `{app="nginx"} | @sorted() | json`,
+			err:   "sort_by and limit must be the last stages of a log query",
+		},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := ParseExpr(tc.query)
+			require.NoError(t, err)
+
+			expanded, err := ExpandMacros(expr, macros)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, expanded.String())
+			require.False(t, HasMacros(expanded))
+			// the original expression is left untouched.
+			require.Equal(t, expr.String(), MustParseExpr(tc.query).String())
+		})
+	}
+}
+
+func TestValidateMacro(t *testing.T) {
+	macros := map[string]string{
+		"parse": `| json`,
+		"outer": `| @inner()`,
+	}
+
+	for _, tc := range []struct {
+		name, body string
+		err        string
+	}{
+		{name: "errors", body: `| @parse() | level="error"`},
+		{name: "parse", body: `| logfmt`},
+		{name: "inner", body: `| json`},
+		{name: "1st", body: `| json`, err: `invalid macro name "1st"`},
+		{name: "errors", body: `json`, err: "parse error"},
+		{name: "errors", body: `| json | join on(a) within 1m {app="b"}`, err: "the body of a macro must be a pipeline of stages"},
+		{name: "errors", body: `| @missing()`, err: "macro @missing() is not defined"},
+		{name: "self", body: `| json | @self()`, err: "macro @self() is recursive: self -> self"},
+		{name: "inner", body: `| @outer()`, err: "macro @inner() is recursive: inner -> outer -> inner"},
+	} {
+		t.Run(tc.name+" "+tc.body, func(t *testing.T) {
+			err := ValidateMacro(tc.name, tc.body, macros)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
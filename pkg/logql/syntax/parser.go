@@ -47,6 +47,9 @@ func init() {
 	for str, tok := range tokens {
 		exprToknames[tok-exprPrivate+1] = str
 	}
+	for str, tok := range stageTokens {
+		exprToknames[tok-exprPrivate+1] = str
+	}
 }
 
 type parser struct {
@@ -195,6 +198,16 @@ func validateSampleExpr(expr SampleExpr) error {
 			return e.err
 		}
 		return nil
+	case *SubqueryExpr:
+		if e.err != nil {
+			return e.err
+		}
+		return validateSampleExpr(e.Left)
+	case *ChangesVsExpr:
+		if e.err != nil {
+			return e.err
+		}
+		return validateSampleExpr(e.Left)
 	case *VectorAggregationExpr:
 		if e.err != nil {
 			return e.err
@@ -210,6 +223,9 @@ func validateSampleExpr(expr SampleExpr) error {
 		if err != nil {
 			return err
 		}
+		if err := validateNoSortStages(selector); err != nil {
+			return err
+		}
 		return validateLogSelectorExpression(selector)
 	}
 }
@@ -218,11 +234,82 @@ func validateLogSelectorExpression(expr LogSelectorExpr) error {
 	switch e := expr.(type) {
 	case *VectorExpr:
 		return nil
+	case *JoinExpr:
+		if e.err != nil {
+			return e.err
+		}
+		if err := validateNoSortStages(e.Left); err != nil {
+			return err
+		}
+		if err := validateLogSelectorExpression(e.Left); err != nil {
+			return err
+		}
+		// the sort_by, limit and dedup stages ending the right side apply to
+		// the joined entries.
+		return validateLogSelectorExpression(e.Right)
+	case *PipelineExpr:
+		if err := validateSortStages(e.MultiStages); err != nil {
+			return err
+		}
+		return validateMatchers(e.Matchers())
 	default:
 		return validateMatchers(e.Matchers())
 	}
 }
 
+// validateSortStages ensures sort_by, limit and dedup are used at most once and
+// are the last stages of a pipeline, sort_by or dedup coming first. dedup and
+// sort_by can't be used together.
+func validateSortStages(stages MultiStageExpr) error {
+	var sortBy, limit, dedup bool
+	for _, s := range stages {
+		switch s.(type) {
+		case *SortByExpr:
+			if dedup {
+				return logqlmodel.NewParseError(fmt.Sprintf("%s and %s can't be used together", OpDedup, OpSortBy), 0, 0)
+			}
+			if sortBy || limit {
+				return logqlmodel.NewParseError(fmt.Sprintf("%s must be used once and before %s", OpSortBy, OpLimit), 0, 0)
+			}
+			sortBy = true
+		case *DedupExpr:
+			if sortBy {
+				return logqlmodel.NewParseError(fmt.Sprintf("%s and %s can't be used together", OpDedup, OpSortBy), 0, 0)
+			}
+			if dedup || limit {
+				return logqlmodel.NewParseError(fmt.Sprintf("%s must be used once and before %s", OpDedup, OpLimit), 0, 0)
+			}
+			dedup = true
+		case *LimitExpr:
+			if limit {
+				return logqlmodel.NewParseError(fmt.Sprintf("%s must be used once", OpLimit), 0, 0)
+			}
+			limit = true
+		default:
+			if sortBy || limit {
+				return logqlmodel.NewParseError(fmt.Sprintf("%s and %s must be the last stages of a log query", OpSortBy, OpLimit), 0, 0)
+			}
+			if dedup {
+				return logqlmodel.NewParseError(fmt.Sprintf("%s must be the last stage of a log query, only followed by %s", OpDedup, OpLimit), 0, 0)
+			}
+		}
+	}
+	return nil
+}
+
+// validateNoSortStages prevents sort_by, limit and dedup stages in metric
+// queries and joins since they only apply to the entries returned by a log
+// query.
+func validateNoSortStages(expr LogSelectorExpr) error {
+	if sortBy, limit := SortStages(expr); sortBy != nil || limit != nil {
+		return logqlmodel.NewParseError(fmt.Sprintf("%s and %s are only allowed at the end of a log query", OpSortBy, OpLimit), 0, 0)
+	}
+	if DedupStage(expr) != nil {
+		return logqlmodel.NewParseError(fmt.Sprintf("%s is only allowed at the end of a log query", OpDedup), 0, 0)
+	}
+	return nil
+}
+
 // validateSortGrouping prevent by|without groupings on sort operations.
 // This will keep compatibility with promql and allowing sort by (foo) doesn't make much sense anyway when sort orders by value instead of labels.
 func validateSortGrouping(grouping *Grouping) error {
@@ -365,6 +365,680 @@ var ParseTestCases = []struct {
 		in:  `absent_over_time({ foo = "bar" }[5h]) by (foo)`,
 		err: logqlmodel.NewParseError("grouping not allowed for absent_over_time aggregation", 0, 0),
 	},
+	{
+		in: `histogram_over_time({ foo = "bar" } | unwrap latency [5m], 0.1, 0.5, 1)`,
+		exp: &RangeAggregationExpr{
+			Left:      newLogRange(newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}), 5*time.Minute, newUnwrapExpr("latency", ""), nil),
+			Operation: OpRangeTypeHistogram,
+			Buckets:   []float64{0.1, 0.5, 1},
+		},
+	},
+	{
+		in: `histogram_over_time({ foo = "bar" } | unwrap latency [5m], 1) by (namespace)`,
+		exp: &RangeAggregationExpr{
+			Left:      newLogRange(newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}), 5*time.Minute, newUnwrapExpr("latency", ""), nil),
+			Operation: OpRangeTypeHistogram,
+			Grouping:  &Grouping{Groups: []string{"namespace"}},
+			Buckets:   []float64{1},
+		},
+	},
+	{
+		in: `max_over_time(rate({ foo = "bar" }[5m])[1h:1m])`,
+		exp: newSubqueryExpr(
+			newRangeAggregationExpr(newLogRange(newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}), 5*time.Minute, nil, nil), OpRangeTypeRate, nil, nil),
+			OpRangeTypeMax, subqueryRange{Range: time.Hour, Step: time.Minute}, nil, nil,
+		),
+	},
+	{
+		in: `quantile_over_time(0.99, sum by (app) (rate({ foo = "bar" }[5m]))[1h:1m] offset 10m)`,
+		exp: newSubqueryExpr(
+			mustNewVectorAggregationExpr(
+				newRangeAggregationExpr(newLogRange(newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}), 5*time.Minute, nil, nil), OpRangeTypeRate, nil, nil),
+				OpTypeSum, &Grouping{Groups: []string{"app"}}, nil,
+			),
+			OpRangeTypeQuantile, subqueryRange{Range: time.Hour, Step: time.Minute}, newOffsetExpr(10*time.Minute), NewStringLabelFilter("0.99"),
+		),
+	},
+	{
+		in:  `rate(rate({ foo = "bar" }[5m])[1h:1m])`,
+		err: logqlmodel.NewParseError("invalid aggregation rate with subquery", 0, 0),
+	},
+	{
+		in:  `max_over_time(rate({ foo = "bar" }[5m])[1h:])`,
+		err: logqlmodel.NewParseError("missing subquery resolution", 0, 40),
+	},
+	{
+		in: `{ foo = "bar" } | json | join on(trace_id) within 5m { app = "backend" } | json`,
+		exp: newJoinExpr(
+			newPipelineExpr(
+				newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}),
+				MultiStageExpr{newLabelParserExpr(OpParserTypeJSON, "")},
+			),
+			[]string{"trace_id"}, 5*time.Minute,
+			newPipelineExpr(
+				newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "backend")}),
+				MultiStageExpr{newLabelParserExpr(OpParserTypeJSON, "")},
+			),
+		),
+	},
+	{
+		in: `{ foo = "bar" } | join on(trace_id, span_id) within 1m ({ app = "backend" })`,
+		exp: newJoinExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}),
+			[]string{"trace_id", "span_id"}, time.Minute,
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "backend")}),
+		),
+	},
+	{
+		in:  `{ foo = "bar" } | join on(trace_id) within 0s { app = "backend" }`,
+		err: logqlmodel.NewParseError("within duration must be positive", 0, 0),
+	},
+	{
+		in:  `{ foo = "bar" } | join on(trace_id) within 5m`,
+		err: logqlmodel.NewParseError("syntax error: unexpected $end, expecting { or (", 1, 46),
+	},
+	{
+		in: `{ foo = "bar" } | join on(trace_id) within 5m { app = "backend" } | json | limit 5`,
+		exp: newJoinExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}),
+			[]string{"trace_id"}, 5*time.Minute,
+			newPipelineExpr(
+				newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "backend")}),
+				MultiStageExpr{newLabelParserExpr(OpParserTypeJSON, ""), &LimitExpr{Limit: 5}},
+			),
+		),
+	},
+	{
+		in:  `{ foo = "bar" } | limit 5 | join on(trace_id) within 5m { app = "backend" }`,
+		err: logqlmodel.NewParseError("sort_by and limit are only allowed at the end of a log query", 0, 0),
+	},
+	{
+		in: `{ join = "a", within = "b" } | logfmt | within > 5 | join = "c"`,
+		exp: newPipelineExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "join", "a"), mustNewMatcher(labels.MatchEqual, "within", "b")}),
+			MultiStageExpr{
+				newLogfmtParserExpr(nil),
+				&LabelFilterExpr{LabelFilterer: log.NewNumericLabelFilter(log.LabelFilterGreaterThan, "within", 5)},
+				newLabelFilterExpr(log.NewStringLabelFilter(mustNewMatcher(labels.MatchEqual, "join", "c"))),
+			},
+		),
+	},
+	{
+		in: `sum by (join, within) (count_over_time({ foo = "bar" }[5m]))`,
+		exp: mustNewVectorAggregationExpr(
+			newRangeAggregationExpr(
+				&LogRange{Left: newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}), Interval: 5 * time.Minute},
+				OpRangeTypeCount, nil, nil,
+			),
+			OpTypeSum, &Grouping{Groups: []string{"join", "within"}}, nil,
+		),
+	},
+	{
+		in: `{ foo = "bar" } | csv "ip, method,,status" | status >= 500`,
+		exp: newPipelineExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}),
+			MultiStageExpr{
+				&CSVParserExpr{Columns: []string{"ip", "method", "", "status"}, Delimiter: ',', Quote: '"'},
+				&LabelFilterExpr{LabelFilterer: log.NewNumericLabelFilter(log.LabelFilterGreaterThanOrEqual, "status", 500)},
+			},
+		),
+	},
+	{
+		in: `{ foo = "bar" } | csv delimiter="\t", quote=""`,
+		exp: newPipelineExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}),
+			MultiStageExpr{
+				&CSVParserExpr{Delimiter: '\t'},
+			},
+		),
+	},
+	{
+		in: `sum by (status) (count_over_time({ foo = "bar" } | csv "ip,status" quote="'" [5m]))`,
+		exp: mustNewVectorAggregationExpr(
+			newRangeAggregationExpr(
+				newLogRange(newPipelineExpr(
+					newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}),
+					MultiStageExpr{
+						&CSVParserExpr{Columns: []string{"ip", "status"}, Delimiter: ',', Quote: '\''},
+					},
+				), 5*time.Minute, nil, nil),
+				OpRangeTypeCount, nil, nil,
+			),
+			OpTypeSum, &Grouping{Groups: []string{"status"}}, nil,
+		),
+	},
+	{
+		in: `{ csv = "bar" } | csv | csv = "a" | csv | csv != "b"`,
+		exp: newPipelineExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "csv", "bar")}),
+			MultiStageExpr{
+				&CSVParserExpr{Delimiter: ',', Quote: '"'},
+				&LabelFilterExpr{LabelFilterer: log.NewStringLabelFilter(mustNewMatcher(labels.MatchEqual, "csv", "a"))},
+				&CSVParserExpr{Delimiter: ',', Quote: '"'},
+				&LabelFilterExpr{LabelFilterer: log.NewStringLabelFilter(mustNewMatcher(labels.MatchNotEqual, "csv", "b"))},
+			},
+		),
+	},
+	{
+		in: `sum by (csv) (count_over_time({ foo = "bar" } | csv "csv" [5m]))`,
+		exp: &VectorAggregationExpr{
+			Left: &RangeAggregationExpr{
+				Left: &LogRange{
+					Left: newPipelineExpr(
+						newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}),
+						MultiStageExpr{&CSVParserExpr{Columns: []string{"csv"}, Delimiter: ',', Quote: '"'}},
+					),
+					Interval: 5 * time.Minute,
+				},
+				Operation: OpRangeTypeCount,
+			},
+			Grouping:  &Grouping{Groups: []string{"csv"}},
+			Params:    0,
+			Operation: OpTypeSum,
+		},
+	},
+	{
+		in:  `{ foo = "bar" } | csv "ip,ip"`,
+		err: logqlmodel.NewParseError("invalid csv parser: duplicate column label name 'ip'", 0, 0),
+	},
+	{
+		in:  `{ foo = "bar" } | csv separator=";"`,
+		err: logqlmodel.NewParseError("unknown csv parser option separator", 0, 0),
+	},
+	{
+		in:  `{ foo = "bar" } | csv delimiter="::"`,
+		err: logqlmodel.NewParseError("invalid csv parser option delimiter, expected a single character", 0, 0),
+	},
+	{
+		in: `{ sd = "a", xml = "b" } | sd | sd = "c" | xml | xml =~ "d.*"`,
+		exp: newPipelineExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "sd", "a"), mustNewMatcher(labels.MatchEqual, "xml", "b")}),
+			MultiStageExpr{
+				&SDParserExpr{},
+				&LabelFilterExpr{LabelFilterer: log.NewStringLabelFilter(mustNewMatcher(labels.MatchEqual, "sd", "c"))},
+				&XMLParserExpr{},
+				&LabelFilterExpr{LabelFilterer: log.NewStringLabelFilter(mustNewMatcher(labels.MatchRegexp, "xml", "d.*"))},
+			},
+		),
+	},
+	{
+		in: `sum by (sd, xml) (count_over_time({ foo = "bar" } | sd sd="a" | xml xml="b" [5m]))`,
+		exp: &VectorAggregationExpr{
+			Left: &RangeAggregationExpr{
+				Left: &LogRange{
+					Left: newPipelineExpr(
+						newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}),
+						MultiStageExpr{
+							&SDParserExpr{Expressions: []log.LabelExtractionExpr{log.NewLabelExtractionExpr("sd", "a")}},
+							&XMLParserExpr{Expressions: []log.LabelExtractionExpr{log.NewLabelExtractionExpr("xml", "b")}},
+						},
+					),
+					Interval: 5 * time.Minute,
+				},
+				Operation: OpRangeTypeCount,
+			},
+			Grouping:  &Grouping{Groups: []string{"sd", "xml"}},
+			Params:    0,
+			Operation: OpTypeSum,
+		},
+	},
+	{
+		in: `{ foo = "bar" } | sd | exampleSDID_32473_iut = "3"`,
+		exp: newPipelineExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}),
+			MultiStageExpr{
+				&SDParserExpr{},
+				&LabelFilterExpr{LabelFilterer: log.NewStringLabelFilter(mustNewMatcher(labels.MatchEqual, "exampleSDID_32473_iut", "3"))},
+			},
+		),
+	},
+	{
+		in: `{ foo = "bar" } | sd --strict --keep-empty iut="exampleSDID@32473.iut", eventSource`,
+		exp: newPipelineExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}),
+			MultiStageExpr{
+				&SDParserExpr{
+					Expressions: []log.LabelExtractionExpr{
+						log.NewLabelExtractionExpr("iut", "exampleSDID@32473.iut"),
+						log.NewLabelExtractionExpr("eventSource", "eventSource"),
+					},
+					Strict:    true,
+					KeepEmpty: true,
+				},
+			},
+		),
+	},
+	{
+		in: `{ foo = "bar" } | xml --strict`,
+		exp: newPipelineExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}),
+			MultiStageExpr{
+				&XMLParserExpr{Strict: true},
+			},
+		),
+	},
+	{
+		in: `sum by (status) (count_over_time({ foo = "bar" } | xml status="event.status", id="event.@id" [5m]))`,
+		exp: mustNewVectorAggregationExpr(
+			newRangeAggregationExpr(
+				newLogRange(newPipelineExpr(
+					newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}),
+					MultiStageExpr{
+						&XMLParserExpr{
+							Expressions: []log.LabelExtractionExpr{
+								log.NewLabelExtractionExpr("status", "event.status"),
+								log.NewLabelExtractionExpr("id", "event.@id"),
+							},
+						},
+					},
+				), 5*time.Minute, nil, nil),
+				OpRangeTypeCount, nil, nil,
+			),
+			OpTypeSum, &Grouping{Groups: []string{"status"}}, nil,
+		),
+	},
+	{
+		in:  `{ foo = "bar" } | xml status-code="event.status"`,
+		err: logqlmodel.NewParseError("syntax error: unexpected IDENTIFIER", 1, 30),
+	},
+	{
+		in: `{ foo = "bar" } | logfmt | sort_by(duration desc) | limit 50`,
+		exp: newPipelineExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}),
+			MultiStageExpr{
+				newLogfmtParserExpr(nil),
+				&SortByExpr{Label: "duration", Desc: true},
+				&LimitExpr{Limit: 50},
+			},
+		),
+	},
+	{
+		in: `{ limit = "bar" } | logfmt | limit > 5 | sort_by(duration)`,
+		exp: newPipelineExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "limit", "bar")}),
+			MultiStageExpr{
+				newLogfmtParserExpr(nil),
+				&LabelFilterExpr{LabelFilterer: log.NewNumericLabelFilter(log.LabelFilterGreaterThan, "limit", 5)},
+				&SortByExpr{Label: "duration"},
+			},
+		),
+	},
+	{
+		in:  `{ foo = "bar" } | sort_by(duration up)`,
+		err: logqlmodel.NewParseError(`invalid sort_by order "up", expected asc or desc`, 0, 0),
+	},
+	{
+		in:  `{ foo = "bar" } | limit 0`,
+		err: logqlmodel.NewParseError(`invalid limit "0", expected a positive integer`, 0, 0),
+	},
+	{
+		in:  `{ foo = "bar" } | sort_by(duration) | logfmt`,
+		err: logqlmodel.NewParseError("sort_by and limit must be the last stages of a log query", 0, 0),
+	},
+	{
+		in:  `{ foo = "bar" } | limit 10 | sort_by(duration)`,
+		err: logqlmodel.NewParseError("sort_by must be used once and before limit", 0, 0),
+	},
+	{
+		in:  `count_over_time({ foo = "bar" } | sort_by(duration) [5m])`,
+		err: logqlmodel.NewParseError("sort_by and limit are only allowed at the end of a log query", 0, 0),
+	},
+	{
+		in: `{ foo = "bar" } | json | dedup by (pod, level) within 1m | limit 50`,
+		exp: newPipelineExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}),
+			MultiStageExpr{
+				newLabelParserExpr(OpParserTypeJSON, ""),
+				&DedupExpr{Labels: []string{"pod", "level"}, Within: time.Minute},
+				&LimitExpr{Limit: 50},
+			},
+		),
+	},
+	{
+		in: `{ foo = "bar" } | dedup`,
+		exp: newPipelineExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}),
+			MultiStageExpr{&DedupExpr{}},
+		),
+	},
+	{
+		in: `{ foo = "bar" } | dedup within 30s`,
+		exp: newPipelineExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}),
+			MultiStageExpr{&DedupExpr{Within: 30 * time.Second}},
+		),
+	},
+	{
+		in: `{ dedup = "a" } | logfmt | dedup != "b" | dedup by (dedup)`,
+		exp: newPipelineExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "dedup", "a")}),
+			MultiStageExpr{
+				newLogfmtParserExpr(nil),
+				&LabelFilterExpr{LabelFilterer: log.NewStringLabelFilter(mustNewMatcher(labels.MatchNotEqual, "dedup", "b"))},
+				&DedupExpr{Labels: []string{"dedup"}},
+			},
+		),
+	},
+	{
+		in: `sum by (dedup) (count_over_time({ foo = "bar" } | logfmt | dedup = "a" [5m]))`,
+		exp: &VectorAggregationExpr{
+			Left: &RangeAggregationExpr{
+				Left: &LogRange{
+					Left: newPipelineExpr(
+						newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}),
+						MultiStageExpr{
+							newLogfmtParserExpr(nil),
+							&LabelFilterExpr{LabelFilterer: log.NewStringLabelFilter(mustNewMatcher(labels.MatchEqual, "dedup", "a"))},
+						},
+					),
+					Interval: 5 * time.Minute,
+				},
+				Operation: OpRangeTypeCount,
+			},
+			Grouping:  &Grouping{Groups: []string{"dedup"}},
+			Params:    0,
+			Operation: OpTypeSum,
+		},
+	},
+	{
+		in:  `{ foo = "bar" } | dedup | logfmt`,
+		err: logqlmodel.NewParseError("dedup must be the last stage of a log query, only followed by limit", 0, 0),
+	},
+	{
+		in:  `{ foo = "bar" } | dedup | sort_by(duration)`,
+		err: logqlmodel.NewParseError("dedup and sort_by can't be used together", 0, 0),
+	},
+	{
+		in:  `{ foo = "bar" } | limit 10 | dedup`,
+		err: logqlmodel.NewParseError("dedup must be used once and before limit", 0, 0),
+	},
+	{
+		in:  `count_over_time({ foo = "bar" } | dedup [5m])`,
+		err: logqlmodel.NewParseError("dedup is only allowed at the end of a log query", 0, 0),
+	},
+	{
+		in: `{ foo = "bar" } | logfmt | json_format level, "http.status"=status, "http.path"=path | json_format`,
+		exp: newPipelineExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}),
+			MultiStageExpr{
+				newLogfmtParserExpr(nil),
+				&JSONFmtExpr{Fields: []log.JSONField{
+					{Key: "level", Label: "level"},
+					{Key: "http.status", Label: "status"},
+					{Key: "http.path", Label: "path"},
+				}},
+				&JSONFmtExpr{},
+			},
+		),
+	},
+	{
+		in:  `{ foo = "bar" } | json_format "http"=method, "http.status"=status`,
+		err: logqlmodel.NewParseError(`json_format key "http.status" conflicts with another key`, 0, 0),
+	},
+	{
+		in:  `{ foo = "bar" } | json_format "http..status"=status`,
+		err: logqlmodel.NewParseError(`invalid json_format key "http..status"`, 0, 0),
+	},
+	{
+		in: `{ foo = "bar" } | json | geoip(src_ip) | cidr_label(src_ip, "ranges.yaml") | geoip_country_name != "France"`,
+		exp: newPipelineExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}),
+			MultiStageExpr{
+				newLabelParserExpr(OpParserTypeJSON, ""),
+				&GeoIPExpr{Label: "src_ip"},
+				&CIDRLabelExpr{Label: "src_ip", File: "ranges.yaml"},
+				&LabelFilterExpr{
+					LabelFilterer: log.NewStringLabelFilter(mustNewMatcher(labels.MatchNotEqual, "geoip_country_name", "France")),
+				},
+			},
+		),
+	},
+	{
+		in:  `{ foo = "bar" } | cidr_label(src_ip)`,
+		err: logqlmodel.NewParseError("syntax error: unexpected ), expecting ,", 1, 36),
+	},
+	{
+		in: `{ foo = "bar" } |* "connection refused"`,
+		exp: newPipelineExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}),
+			MultiStageExpr{&SearchExpr{Search: "connection refused"}},
+		),
+	},
+	{
+		in: `{ foo = "bar" } | json |* --metadata "3fa85f64" |= "error"`,
+		exp: newPipelineExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}),
+			MultiStageExpr{
+				newLabelParserExpr(OpParserTypeJSON, ""),
+				&SearchExpr{Search: "3fa85f64", Metadata: true},
+				newLineFilterExpr(log.LineMatchEqual, "", "error"),
+			},
+		),
+	},
+	{
+		in:  `{ foo = "bar" } |* --strict "foo"`,
+		err: logqlmodel.NewParseError("invalid flag --strict for full-text filter, expected --metadata", 0, 0),
+	},
+	{
+		in:  `{ foo = "bar" } |* " "`,
+		err: logqlmodel.NewParseError("full-text filter requires at least one term", 0, 0),
+	},
+	{
+		in: `count_distinct_over_time({ foo = "bar" } | unwrap user [5m]) by (namespace)`,
+		exp: &RangeAggregationExpr{
+			Left:      newLogRange(newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}), 5*time.Minute, newUnwrapExpr("user", ""), nil),
+			Operation: OpRangeTypeCountDistinct,
+			Grouping:  &Grouping{Groups: []string{"namespace"}},
+		},
+	},
+	{
+		in:  `count_distinct_over_time({ foo = "bar" }[5m])`,
+		err: logqlmodel.NewParseError("invalid aggregation count_distinct_over_time without unwrap", 0, 0),
+	},
+	{
+		in:  `count_distinct_over_time({ foo = "bar" } | unwrap duration(latency) [5m])`,
+		err: logqlmodel.NewParseError("conversion function duration not allowed for count_distinct_over_time aggregation", 0, 0),
+	},
+	{
+		in: `sum by (__unwrapped__) (sum_over_time({ foo = "bar" } | logfmt | unwrap (bytes(bytes_in), bytes_out) | __error__="" [5m]))`,
+		exp: mustNewVectorAggregationExpr(
+			newRangeAggregationExpr(
+				newLogRange(
+					newPipelineExpr(newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}), MultiStageExpr{newLogfmtParserExpr(nil)}),
+					5*time.Minute,
+					newMultiUnwrapExpr([]string{OpConvBytes, "bytes_in", "", "bytes_out"}).addPostFilter(log.NewStringLabelFilter(mustNewMatcher(labels.MatchEqual, logqlmodel.ErrorLabel, ""))),
+					nil,
+				),
+				OpRangeTypeSum, nil, nil,
+			),
+			OpTypeSum, &Grouping{Groups: []string{"__unwrapped__"}}, nil,
+		),
+	},
+	{
+		in: `max_over_time({ foo = "bar" } | unwrap (latency) [5m])`,
+		exp: &RangeAggregationExpr{
+			Left:      newLogRange(newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}), 5*time.Minute, newUnwrapExpr("latency", ""), nil),
+			Operation: OpRangeTypeMax,
+		},
+	},
+	{
+		in:  `max_over_time({ foo = "bar" } | unwrap (bytes_in, bytes_out) [5m]) by (namespace)`,
+		err: logqlmodel.NewParseError("grouping of max_over_time aggregation must keep the __unwrapped__ label of the unwrapped labels", 0, 0),
+	},
+	{
+		in:  `sum(sum_over_time({ foo = "bar" } | unwrap (bytes_in, bytes_out) [5m]))`,
+		err: logqlmodel.NewParseError("grouping of sum aggregation must keep the __unwrapped__ label of the unwrapped labels", 0, 0),
+	},
+	{
+		in:  `sum without (__unwrapped__) (sum_over_time({ foo = "bar" } | unwrap (bytes_in, bytes_out) [5m]))`,
+		err: logqlmodel.NewParseError("grouping of sum aggregation must keep the __unwrapped__ label of the unwrapped labels", 0, 0),
+	},
+	{
+		in:  `sum by (app) (rate({ foo = "bar" } | unwrap (bytes_in, bytes_out) [1m]) * 8)`,
+		err: logqlmodel.NewParseError("grouping of sum aggregation must keep the __unwrapped__ label of the unwrapped labels", 0, 0),
+	},
+	{
+		in:  `sum by (app) (8 * rate({ foo = "bar" } | unwrap (bytes_in, bytes_out) [1m]))`,
+		err: logqlmodel.NewParseError("grouping of sum aggregation must keep the __unwrapped__ label of the unwrapped labels", 0, 0),
+	},
+	{
+		in:  `sum by (app) (label_replace(rate({ foo = "bar" } | unwrap (bytes_in, bytes_out) [1m]) * 8, "dst", "$1", "app", "(.*)"))`,
+		err: logqlmodel.NewParseError("grouping of sum aggregation must keep the __unwrapped__ label of the unwrapped labels", 0, 0),
+	},
+	{
+		in:  `max by (app) (sum by (app, __unwrapped__) (rate({ foo = "bar" } | unwrap (bytes_in, bytes_out) [1m])))`,
+		err: logqlmodel.NewParseError("grouping of max aggregation must keep the __unwrapped__ label of the unwrapped labels", 0, 0),
+	},
+	{
+		in:  `sum by (app) (max_over_time(rate({ foo = "bar" } | unwrap (bytes_in, bytes_out) [1m])[1h:1m]))`,
+		err: logqlmodel.NewParseError("grouping of sum aggregation must keep the __unwrapped__ label of the unwrapped labels", 0, 0),
+	},
+	{
+		in: `sum by (app, __unwrapped__) (rate({ foo = "bar" } | unwrap (bytes_in, bytes_out) [1m]) * 8)`,
+		exp: mustNewVectorAggregationExpr(
+			mustNewBinOpExpr(OpTypeMul, &BinOpOptions{VectorMatching: &VectorMatching{Card: CardOneToOne}},
+				newRangeAggregationExpr(
+					newLogRange(newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}), time.Minute, newMultiUnwrapExpr([]string{"", "bytes_in", "", "bytes_out"}), nil),
+					OpRangeTypeRate, nil, nil,
+				),
+				mustNewLiteralExpr("8", false),
+			),
+			OpTypeSum, &Grouping{Groups: []string{"app", "__unwrapped__"}}, nil,
+		),
+	},
+	{
+		in: `{app="foo"} | extract "took=(?P<took:duration>\\S+)" | took > 1s`,
+		exp: newPipelineExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}),
+			MultiStageExpr{
+				newLabelParserExpr(OpParserTypeExtract, `took=(?P<took:duration>\S+)`),
+				newLabelFilterExpr(log.NewDurationLabelFilter(log.LabelFilterGreaterThan, "took", time.Second)),
+			},
+		),
+	},
+	{
+		in: `{extract="foo"} | extract "(?P<extract>\\S+)" | extract != "bar"`,
+		exp: newPipelineExpr(
+			newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "extract", "foo")}),
+			MultiStageExpr{
+				newLabelParserExpr(OpParserTypeExtract, `(?P<extract>\S+)`),
+				newLabelFilterExpr(log.NewStringLabelFilter(mustNewMatcher(labels.MatchNotEqual, "extract", "bar"))),
+			},
+		),
+	},
+	{
+		in: `sum by (extract) (count_over_time({app="foo"} | logfmt | extract = "bar" [5m]))`,
+		exp: &VectorAggregationExpr{
+			Left: &RangeAggregationExpr{
+				Left: &LogRange{
+					Left: newPipelineExpr(
+						newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}),
+						MultiStageExpr{
+							newLogfmtParserExpr(nil),
+							newLabelFilterExpr(log.NewStringLabelFilter(mustNewMatcher(labels.MatchEqual, "extract", "bar"))),
+						},
+					),
+					Interval: 5 * time.Minute,
+				},
+				Operation: OpRangeTypeCount,
+			},
+			Grouping:  &Grouping{Groups: []string{"extract"}},
+			Params:    0,
+			Operation: OpTypeSum,
+		},
+	},
+	{
+		in:  `{app="foo"} | extract "(?P<id:uuid>\\S+)"`,
+		err: logqlmodel.NewParseError(`invalid extract parser: unsupported type "uuid" for extracted label 'id'`, 0, 0),
+	},
+	{
+		in:  `sum_over_time({ foo = "bar" } | unwrap (bytes_in, bytes_in) [5m])`,
+		err: logqlmodel.NewParseError("label bytes_in unwrapped more than once", 0, 0),
+	},
+	{
+		in:  `count_distinct_over_time({ foo = "bar" } | unwrap (user, bytes(size)) [5m])`,
+		err: logqlmodel.NewParseError("conversion function bytes not allowed for count_distinct_over_time aggregation", 0, 0),
+	},
+	{
+		in: `deriv({ foo = "bar" } | unwrap latency [5m])`,
+		exp: &RangeAggregationExpr{
+			Left:      newLogRange(newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}), 5*time.Minute, newUnwrapExpr("latency", ""), nil),
+			Operation: OpRangeTypeDeriv,
+		},
+	},
+	{
+		in: `predict_linear({ foo = "bar" } | unwrap latency [1h], 3600)`,
+		exp: &RangeAggregationExpr{
+			Left:      newLogRange(newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}), time.Hour, newUnwrapExpr("latency", ""), nil),
+			Operation: OpRangeTypePredictLinear,
+			Args:      []float64{3600},
+		},
+	},
+	{
+		in: `holt_winters(sum(count_over_time({ foo = "bar" }[1m]))[1h:1m], 0.5, 0.1)`,
+		exp: &SubqueryExpr{
+			Left: mustNewVectorAggregationExpr(&RangeAggregationExpr{
+				Left:      newLogRange(newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}), time.Minute, nil, nil),
+				Operation: OpRangeTypeCount,
+			}, OpTypeSum, nil, nil),
+			Operation: OpRangeTypeHoltWinters,
+			Args:      []float64{0.5, 0.1},
+			Range:     time.Hour,
+			Step:      time.Minute,
+		},
+	},
+	{
+		in:  `deriv({ foo = "bar" }[5m])`,
+		err: logqlmodel.NewParseError("invalid aggregation deriv without unwrap", 0, 0),
+	},
+	{
+		in:  `predict_linear({ foo = "bar" } | unwrap latency [1h])`,
+		err: logqlmodel.NewParseError("operation predict_linear requires a duration in seconds", 0, 0),
+	},
+	{
+		in:  `holt_winters({ foo = "bar" } | unwrap latency [1h], 0.5, 1)`,
+		err: logqlmodel.NewParseError("invalid factor 1 for operation holt_winters: must be between 0 and 1", 0, 0),
+	},
+	{
+		in:  `rate({ foo = "bar" }[5m], 10)`,
+		err: logqlmodel.NewParseError("parameters not supported for operation rate", 0, 0),
+	},
+	{
+		in: `changes_vs(sum(count_over_time({ foo = "bar" }[5m])), 1h)`,
+		exp: &ChangesVsExpr{
+			Left: mustNewVectorAggregationExpr(&RangeAggregationExpr{
+				Left:      newLogRange(newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}), 5*time.Minute, nil, nil),
+				Operation: OpRangeTypeCount,
+			}, OpTypeSum, nil, nil),
+			Shift: time.Hour,
+		},
+	},
+	{
+		in: `week_over_week(count_over_time({ foo = "bar" }[5m]))`,
+		exp: &ChangesVsExpr{
+			Left: &RangeAggregationExpr{
+				Left:      newLogRange(newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "foo", "bar")}), 5*time.Minute, nil, nil),
+				Operation: OpRangeTypeCount,
+			},
+			Shift: 7 * 24 * time.Hour,
+		},
+	},
+	{
+		in:  `changes_vs(count_over_time({ foo = "bar" }[5m]), 0s)`,
+		err: logqlmodel.NewParseError("invalid shift for operation changes_vs: must be positive", 0, 0),
+	},
+	{
+		in:  `histogram_over_time({ foo = "bar" }[5m], 1)`,
+		err: logqlmodel.NewParseError("invalid aggregation histogram_over_time without unwrap", 0, 0),
+	},
+	{
+		in:  `histogram_over_time({ foo = "bar" } | unwrap latency [5m], 1, 0.5)`,
+		err: logqlmodel.NewParseError("buckets for operation histogram_over_time must be in increasing order", 0, 0),
+	},
+	{
+		in:  `histogram_over_time({ foo = "bar" } | unwrap latency [5m])`,
+		err: logqlmodel.NewParseError("syntax error: unexpected ), expecting ,", 1, 58),
+	},
 	{
 		in:  `rate({ foo = "bar" }[5minutes])`,
 		err: logqlmodel.NewParseError(`unknown unit "minutes" in duration "5minutes"`, 0, 21),
@@ -2904,6 +3578,42 @@ var ParseTestCases = []struct {
 		in:  `label_replace(vector(0), "foo", "bar", "", "")`,
 		exp: mustNewLabelReplaceExpr(&VectorExpr{Val: 0, err: nil}, "foo", "bar", "", ""),
 	},
+	{
+		in:  `label_join(vector(0), "foo", "-", "a", "b")`,
+		exp: newLabelFunctionExpr(&VectorExpr{Val: 0, err: nil}, OpLabelJoin, "foo", []string{"a", "b"}, "-", nil, 0),
+	},
+	{
+		in:  `label_join(vector(0), "foo", "-")`,
+		exp: newLabelFunctionExpr(&VectorExpr{Val: 0, err: nil}, OpLabelJoin, "foo", nil, "-", nil, 0),
+	},
+	{
+		in:  `label_map(vector(0), "tier", "namespace", {"prod"="gold", "dev"="bronze"})`,
+		exp: newLabelFunctionExpr(&VectorExpr{Val: 0, err: nil}, OpLabelMap, "tier", []string{"namespace"}, "", map[string]string{"prod": "gold", "dev": "bronze"}, 0),
+	},
+	{
+		in:  `label_upper(vector(0), "level", "level")`,
+		exp: newLabelFunctionExpr(&VectorExpr{Val: 0, err: nil}, OpLabelUpper, "level", []string{"level"}, "", nil, 0),
+	},
+	{
+		in:  `label_truncate(vector(0), "short", "path", 10)`,
+		exp: newLabelFunctionExpr(&VectorExpr{Val: 0, err: nil}, OpLabelTruncate, "short", []string{"path"}, "", nil, 10),
+	},
+	{
+		in:  `label_join(vector(0), "foo-bar", "-", "a")`,
+		err: logqlmodel.NewParseError("invalid destination label name in label_join: foo-bar", 0, 0),
+	},
+	{
+		in:  `label_map(vector(0), "tier", "namespace", {"prod"="gold", "prod"="silver"})`,
+		err: logqlmodel.NewParseError(`duplicate key "prod" in label_map`, 0, 0),
+	},
+	{
+		in:  `label_truncate(vector(0), "short", "path", 0)`,
+		err: logqlmodel.NewParseError("invalid length 0 in label_truncate: must be greater than 0", 0, 0),
+	},
+	{
+		in:  `label_truncate(vector(0), "short", "path", 1.5)`,
+		err: logqlmodel.NewParseError("invalid length 1.5 in label_truncate: must be an integer", 0, 0),
+	},
 	{
 		in: `sum(vector(0))`,
 		exp: &VectorAggregationExpr{
@@ -3173,6 +3883,45 @@ var ParseTestCases = []struct {
 			},
 		},
 	},
+	{
+		in: `{app="foo"} |=~i "error" or "fatal" !=~w "timeout"`,
+		exp: &PipelineExpr{
+			Left: newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}),
+			MultiStages: MultiStageExpr{
+				&LineFilterExpr{
+					Left: &LineFilterExpr{
+						LineFilter: LineFilter{
+							Ty:    log.LineMatchEqualFold,
+							Match: "error",
+						},
+						Or: &LineFilterExpr{
+							LineFilter: LineFilter{
+								Ty:    log.LineMatchEqualFold,
+								Match: "fatal",
+							},
+							IsOrChild: true,
+						},
+					},
+					LineFilter: LineFilter{
+						Ty:    log.LineMatchNotWord,
+						Match: "timeout",
+					},
+				},
+			},
+		},
+	},
+	{
+		in: `{app="foo"} !=~i "debug" |=~w "error"`,
+		exp: &PipelineExpr{
+			Left: newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}),
+			MultiStages: MultiStageExpr{
+				newNestedLineFilterExpr(
+					newLineFilterExpr(log.LineMatchNotEqualFold, "", "debug"),
+					newLineFilterExpr(log.LineMatchWord, "", "error"),
+				),
+			},
+		},
+	},
 }
 
 func TestParse(t *testing.T) {
@@ -3323,23 +4072,23 @@ func Benchmark_MetricPipelineCombined(b *testing.B) {
 	require.Nil(b, err)
 	sp := p.ForStream(labels.EmptyLabels())
 	var (
-		v       float64
-		lbs     log.LabelsResult
+		samples []log.ExtractedSample
 		matches bool
 	)
 	in := []byte(`level=debug ts=2020-10-02T10:10:42.092268913Z caller=logging.go:66 traceID=a9d4d8a928d8db1 msg="POST /api/prom/api/v1/query_range (200) 1.5s"`)
 	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		v, lbs, matches = sp.Process(0, in)
+		samples, matches = sp.Process(0, in)
 	}
 	require.True(b, matches)
+	require.Len(b, samples, 1)
 	require.Equal(
 		b,
 		labels.FromStrings("caller", "logging.go:66", "duration", "1.5s", "level", "debug", "method", "POST", "msg", "POST /api/prom/api/v1/query_range (200) 1.5s", "path", "/api/prom/api/v1/query_range", "status", "200", "traceID", "a9d4d8a928d8db1", "ts", "2020-10-02T10:10:42.092268913Z"),
-		lbs.Labels(),
+		samples[0].Labels.Labels(),
 	)
-	require.Equal(b, 1.0, v)
+	require.Equal(b, 1.0, samples[0].Value)
 }
 
 var c []*labels.Matcher
@@ -70,6 +70,21 @@ func (e *PipelineExpr) Pretty(level int) string {
 	return s
 }
 
+// e.g:
+// {app="gateway"} | json
+// | join on(trace_id) within 5m
+// {app="backend"} | json
+func (e *JoinExpr) Pretty(level int) string {
+	if !NeedSplit(e) {
+		return Indent(level) + e.String()
+	}
+
+	s := fmt.Sprintf("%s\n", e.Left.Pretty(level))
+	s += fmt.Sprintf("%s%s\n", Indent(level+1), e.joinString())
+	s += e.Right.Pretty(level)
+	return s
+}
+
 // e.g: `|= "error" != "memcache" |= ip("192.168.0.1")`
 // NOTE: here `ip` is Op in this expression.
 func (e *LineFilterExpr) Pretty(level int) string {
@@ -121,6 +136,56 @@ func (e *KeepLabelsExpr) Pretty(level int) string {
 	return commonPrefixIndent(level, e)
 }
 
+// e.g: | sort_by(duration desc)
+func (e *SortByExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
+// e.g: | limit 50
+func (e *LimitExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
+// e.g: | dedup by (pod) within 1m
+func (e *DedupExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
+// e.g: | geoip(src_ip)
+func (e *GeoIPExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
+// e.g: | cidr_label(src_ip, "ranges.yaml")
+func (e *CIDRLabelExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
+// e.g: |* --metadata "connection refused"
+func (e *SearchExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
+// e.g: | csv "ip,method,status"
+func (e *CSVParserExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
+// e.g: | sd --strict iut="exampleSDID@32473.iut"
+func (e *SDParserExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
+// e.g: | xml status="event.status"
+func (e *XMLParserExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
+// e.g: | @parse_nginx()
+func (e *MacroExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
 // e.g: | level!="error"
 func (e *LabelFilterExpr) Pretty(level int) string {
 	return commonPrefixIndent(level, e)
@@ -131,6 +196,11 @@ func (e *LineFmtExpr) Pretty(level int) string {
 	return commonPrefixIndent(level, e)
 }
 
+// e.g: | json_format level, "http.status"=status
+func (e *JSONFmtExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
 // e.g: | decolorize
 func (e *DecolorizeExpr) Pretty(_ int) string {
 	return e.String()
@@ -155,11 +225,7 @@ func (e *LogfmtExpressionParser) Pretty(level int) string {
 func (e *UnwrapExpr) Pretty(level int) string {
 	s := Indent(level)
 
-	if e.Operation != "" {
-		s += fmt.Sprintf("%s %s %s(%s)", OpPipe, OpUnwrap, e.Operation, e.Identifier)
-	} else {
-		s += fmt.Sprintf("%s %s %s", OpPipe, OpUnwrap, e.Identifier)
-	}
+	s += fmt.Sprintf("%s %s %s", OpPipe, OpUnwrap, e.labels())
 	for _, f := range e.PostFilters {
 		s += fmt.Sprintf("\n%s%s %s", Indent(level), OpPipe, f)
 	}
@@ -217,6 +283,14 @@ func (e *RangeAggregationExpr) Pretty(level int) string {
 
 	s += e.Left.Pretty(level + 1)
 
+	for _, b := range e.Buckets {
+		s = fmt.Sprintf("%s,\n%s%s", s, Indent(level+1), fmt.Sprint(b))
+	}
+
+	for _, a := range e.Args {
+		s = fmt.Sprintf("%s,\n%s%s", s, Indent(level+1), fmt.Sprint(a))
+	}
+
 	s += "\n" + Indent(level) + ")"
 
 	if e.Grouping != nil {
@@ -226,6 +300,47 @@ func (e *RangeAggregationExpr) Pretty(level int) string {
 	return s
 }
 
+// e.g: max_over_time(rate({foo="bar"}[5m])[1h:1m])
+func (e *SubqueryExpr) Pretty(level int) string {
+	s := Indent(level)
+	if !NeedSplit(e) {
+		return s + e.String()
+	}
+
+	s += e.Operation + "(\n"
+
+	// print args to the function.
+	if e.Params != nil {
+		s = fmt.Sprintf("%s%s%s,", s, Indent(level+1), fmt.Sprint(*e.Params))
+		s += "\n"
+	}
+
+	s += e.Left.Pretty(level+1) + e.rangeString()
+
+	for _, a := range e.Args {
+		s = fmt.Sprintf("%s,\n%s%s", s, Indent(level+1), fmt.Sprint(a))
+	}
+
+	s += "\n" + Indent(level) + ")"
+
+	return s
+}
+
+// e.g: changes_vs(sum(rate({foo="bar"}[5m])), 1w)
+func (e *ChangesVsExpr) Pretty(level int) string {
+	s := Indent(level)
+	if !NeedSplit(e) {
+		return s + e.String()
+	}
+
+	s += OpTypeChangesVs + "(\n"
+	s += e.Left.Pretty(level+1) + ","
+	s += "\n" + Indent(level+1) + model.Duration(e.Shift).String()
+	s += "\n" + Indent(level) + ")"
+
+	return s
+}
+
 // e.g:
 // sum(count_over_time({foo="bar"}[5m])) by (container)
 // topk(10, count_over_time({foo="bar"}[5m])) by (container)
@@ -338,6 +453,37 @@ func (e *LabelReplaceExpr) Pretty(level int) string {
 	return s
 }
 
+// e.g: label_join(rate({job="api-server"}[5m]), "foo", ",", "cluster", "namespace")
+func (e *LabelFunctionExpr) Pretty(level int) string {
+	s := Indent(level)
+
+	if !NeedSplit(e) {
+		return s + e.String()
+	}
+
+	s += e.Operation
+
+	s += "(\n"
+
+	params := []string{e.Left.Pretty(level + 1)}
+	for _, p := range e.params() {
+		params = append(params, Indent(level+1)+p)
+	}
+
+	for i, v := range params {
+		s += v
+		// LogQL doesn't allow `,` at the end of last argument.
+		if i < len(params)-1 {
+			s += ","
+		}
+		s += "\n"
+	}
+
+	s += Indent(level) + ")"
+
+	return s
+}
+
 // e.g: vector(5)
 func (e *VectorExpr) Pretty(level int) string {
 	return commonPrefixIndent(level, e)
@@ -186,6 +186,18 @@ func TestFormat_LabelReplace(t *testing.T) {
   "$1",
   "service",
   "(.*):.*"
+)`,
+		},
+		{
+			name: "label_map",
+			in:   `label_map(rate({job="api-server"}[5m]), "tier", "namespace", {"prod"="gold", "dev"="bronze"})`,
+			exp: `label_map(
+  rate(
+    {job="api-server"} [5m]
+  ),
+  "tier",
+  "namespace",
+  {"dev"="bronze","prod"="gold"}
 )`,
 		},
 	}
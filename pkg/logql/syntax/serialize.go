@@ -3,6 +3,7 @@ package syntax
 import (
 	"fmt"
 	"io"
+	"sort"
 	"time"
 
 	jsoniter "github.com/json-iterator/go"
@@ -33,9 +34,12 @@ func EncodeJSON(e Expr, w io.Writer) error {
 const (
 	Bin                 = "bin"
 	Binary              = "binary"
+	Buckets             = "buckets"
 	Bytes               = "bytes"
 	And                 = "and"
+	Args                = "args"
 	Card                = "cardinality"
+	ChangesVs           = "changes_vs"
 	Dst                 = "dst"
 	Duration            = "duration"
 	Groups              = "groups"
@@ -46,10 +50,14 @@ const (
 	IntervalNanos       = "interval_nanos"
 	IPField             = "ip"
 	Label               = "label"
+	LabelFunction       = "label_function"
 	LabelReplace        = "label_replace"
+	LabelsField         = "labels"
+	Length              = "length"
 	LHS                 = "lhs"
 	Literal             = "literal"
 	LogSelector         = "log_selector"
+	Mapping             = "mapping"
 	Name                = "name"
 	Numeric             = "numeric"
 	MatchingLabels      = "matching_labels"
@@ -67,8 +75,12 @@ const (
 	Replacement         = "replacement"
 	ReturnBool          = "return_bool"
 	RHS                 = "rhs"
+	Separator           = "separator"
+	ShiftNanos          = "shift_nanos"
 	Src                 = "src"
+	StepNanos           = "step_nanos"
 	StringField         = "string"
+	Subquery            = "subquery"
 	NoopField           = "noop"
 	Type                = "type"
 	Unwrap              = "unwrap"
@@ -90,12 +102,18 @@ func DecodeJSON(raw string) (Expr, error) {
 		return decodeVectorAgg(iter)
 	case RangeAgg:
 		return decodeRangeAgg(iter)
+	case Subquery:
+		return decodeSubquery(iter)
+	case ChangesVs:
+		return decodeChangesVs(iter)
 	case Literal:
 		return decodeLiteral(iter)
 	case Vector:
 		return decodeVector(iter)
 	case LabelReplace:
 		return decodeLabelReplace(iter)
+	case LabelFunction:
+		return decodeLabelFunction(iter)
 	case LogSelector:
 		return decodeLogSelector(iter)
 	default:
@@ -195,6 +213,18 @@ func (v *JSONSerializer) VisitRangeAggregation(e *RangeAggregationExpr) {
 		v.WriteFloat64(*e.Params)
 	}
 
+	if len(e.Buckets) > 0 {
+		v.WriteMore()
+		v.WriteObjectField(Buckets)
+		encodeFloats(v.Stream, e.Buckets)
+	}
+
+	if len(e.Args) > 0 {
+		v.WriteMore()
+		v.WriteObjectField(Args)
+		encodeFloats(v.Stream, e.Args)
+	}
+
 	v.WriteMore()
 	v.WriteObjectField(Range)
 	v.VisitLogRange(e.Left)
@@ -204,6 +234,64 @@ func (v *JSONSerializer) VisitRangeAggregation(e *RangeAggregationExpr) {
 	v.Flush()
 }
 
+func (v *JSONSerializer) VisitSubquery(e *SubqueryExpr) {
+	v.WriteObjectStart()
+
+	v.WriteObjectField(Subquery)
+	v.WriteObjectStart()
+
+	v.WriteObjectField(Op)
+	v.WriteString(e.Operation)
+
+	if e.Params != nil {
+		v.WriteMore()
+		v.WriteObjectField(Params)
+		v.WriteFloat64(*e.Params)
+	}
+
+	if len(e.Args) > 0 {
+		v.WriteMore()
+		v.WriteObjectField(Args)
+		encodeFloats(v.Stream, e.Args)
+	}
+
+	v.WriteMore()
+	v.WriteObjectField(IntervalNanos)
+	v.WriteInt64(int64(e.Range))
+	v.WriteMore()
+	v.WriteObjectField(StepNanos)
+	v.WriteInt64(int64(e.Step))
+	v.WriteMore()
+	v.WriteObjectField(OffsetNanos)
+	v.WriteInt64(int64(e.Offset))
+
+	v.WriteMore()
+	v.WriteObjectField(Inner)
+	e.Left.Accept(v)
+
+	v.WriteObjectEnd()
+	v.WriteObjectEnd()
+	v.Flush()
+}
+
+func (v *JSONSerializer) VisitChangesVs(e *ChangesVsExpr) {
+	v.WriteObjectStart()
+
+	v.WriteObjectField(ChangesVs)
+	v.WriteObjectStart()
+
+	v.WriteObjectField(ShiftNanos)
+	v.WriteInt64(int64(e.Shift))
+
+	v.WriteMore()
+	v.WriteObjectField(Inner)
+	e.Left.Accept(v)
+
+	v.WriteObjectEnd()
+	v.WriteObjectEnd()
+	v.Flush()
+}
+
 func (v *JSONSerializer) VisitLogRange(e *LogRange) {
 	v.WriteObjectStart()
 
@@ -258,6 +346,66 @@ func (v *JSONSerializer) VisitLabelReplace(e *LabelReplaceExpr) {
 	v.Flush()
 }
 
+func (v *JSONSerializer) VisitLabelFunction(e *LabelFunctionExpr) {
+	v.WriteObjectStart()
+
+	v.WriteObjectField(LabelFunction)
+	v.WriteObjectStart()
+
+	v.WriteObjectField(Op)
+	v.WriteString(e.Operation)
+
+	v.WriteMore()
+	v.WriteObjectField(Inner)
+	e.Left.Accept(v)
+
+	v.WriteMore()
+	v.WriteObjectField(Dst)
+	v.WriteString(e.Dst)
+
+	v.WriteMore()
+	v.WriteObjectField(Src)
+	v.WriteArrayStart()
+	for i, src := range e.Src {
+		if i > 0 {
+			v.WriteMore()
+		}
+		v.WriteString(src)
+	}
+	v.WriteArrayEnd()
+
+	v.WriteMore()
+	v.WriteObjectField(Separator)
+	v.WriteString(e.Separator)
+
+	if e.Mapping != nil {
+		v.WriteMore()
+		v.WriteObjectField(Mapping)
+		v.WriteObjectStart()
+		keys := make([]string, 0, len(e.Mapping))
+		for k := range e.Mapping {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			if i > 0 {
+				v.WriteMore()
+			}
+			v.WriteObjectField(k)
+			v.WriteString(e.Mapping[k])
+		}
+		v.WriteObjectEnd()
+	}
+
+	v.WriteMore()
+	v.WriteObjectField(Length)
+	v.WriteInt(e.Length)
+
+	v.WriteObjectEnd()
+	v.WriteObjectEnd()
+	v.Flush()
+}
+
 func (v *JSONSerializer) VisitLiteral(e *LiteralExpr) {
 	v.WriteObjectStart()
 
@@ -304,8 +452,18 @@ func (v *JSONSerializer) VisitPipeline(e *PipelineExpr) {
 	v.Flush()
 }
 
+func (v *JSONSerializer) VisitJoin(e *JoinExpr) {
+	v.WriteObjectStart()
+
+	v.WriteObjectField(LogSelector)
+	encodeLogSelector(v.Stream, e)
+	v.WriteObjectEnd()
+	v.Flush()
+}
+
 // Below are StageExpr visitors that we are skipping since a pipeline is
 // serialized as a string.
+func (*JSONSerializer) VisitCSVParser(*CSVParserExpr)                       {}
 func (*JSONSerializer) VisitDecolorize(*DecolorizeExpr)                     {}
 func (*JSONSerializer) VisitDropLabels(*DropLabelsExpr)                     {}
 func (*JSONSerializer) VisitJSONExpressionParser(*JSONExpressionParser)     {}
@@ -315,8 +473,18 @@ func (*JSONSerializer) VisitLabelFmt(*LabelFmtExpr)                         {}
 func (*JSONSerializer) VisitLabelParser(*LabelParserExpr)                   {}
 func (*JSONSerializer) VisitLineFilter(*LineFilterExpr)                     {}
 func (*JSONSerializer) VisitLineFmt(*LineFmtExpr)                           {}
+func (*JSONSerializer) VisitJSONFmt(*JSONFmtExpr)                           {}
 func (*JSONSerializer) VisitLogfmtExpressionParser(*LogfmtExpressionParser) {}
 func (*JSONSerializer) VisitLogfmtParser(*LogfmtParserExpr)                 {}
+func (*JSONSerializer) VisitSortBy(*SortByExpr)                             {}
+func (*JSONSerializer) VisitLimit(*LimitExpr)                               {}
+func (*JSONSerializer) VisitMacro(*MacroExpr)                               {}
+func (*JSONSerializer) VisitSDParser(*SDParserExpr)                         {}
+func (*JSONSerializer) VisitXMLParser(*XMLParserExpr)                       {}
+func (*JSONSerializer) VisitDedup(*DedupExpr)                               {}
+func (*JSONSerializer) VisitSearch(*SearchExpr)                             {}
+func (*JSONSerializer) VisitGeoIP(*GeoIPExpr)                               {}
+func (*JSONSerializer) VisitCIDRLabel(*CIDRLabelExpr)                       {}
 
 func encodeGrouping(s *jsoniter.Stream, g *Grouping) {
 	s.WriteObjectStart()
@@ -362,6 +530,25 @@ func encodeUnwrap(s *jsoniter.Stream, u *UnwrapExpr) {
 	s.WriteObjectField(Op)
 	s.WriteString(u.Operation)
 
+	if u.IsMultiple() {
+		s.WriteMore()
+		s.WriteObjectField(LabelsField)
+		s.WriteArrayStart()
+		for i, l := range u.Labels {
+			if i > 0 {
+				s.WriteMore()
+			}
+			s.WriteObjectStart()
+			s.WriteObjectField(Identifier)
+			s.WriteString(l.Identifier)
+			s.WriteMore()
+			s.WriteObjectField(Op)
+			s.WriteString(l.Operation)
+			s.WriteObjectEnd()
+		}
+		s.WriteArrayEnd()
+	}
+
 	s.WriteMore()
 	s.WriteObjectField(PostFilterers)
 	s.WriteArrayStart()
@@ -384,6 +571,20 @@ func decodeUnwrap(iter *jsoniter.Iterator) *UnwrapExpr {
 			e.Identifier = iter.ReadString()
 		case Op:
 			e.Operation = iter.ReadString()
+		case LabelsField:
+			iter.ReadArrayCB(func(i *jsoniter.Iterator) bool {
+				var l UnwrapLabel
+				for f := i.ReadObject(); f != ""; f = i.ReadObject() {
+					switch f {
+					case Identifier:
+						l.Identifier = i.ReadString()
+					case Op:
+						l.Operation = i.ReadString()
+					}
+				}
+				e.Labels = append(e.Labels, l)
+				return true
+			})
 		case PostFilterers:
 			iter.ReadArrayCB(func(i *jsoniter.Iterator) bool {
 				e.PostFilters = append(e.PostFilters, decodeLabelFilter(i))
@@ -711,12 +912,18 @@ func decodeSample(iter *jsoniter.Iterator) (SampleExpr, error) {
 			expr, err = decodeVectorAgg(iter)
 		case RangeAgg:
 			expr, err = decodeRangeAgg(iter)
+		case Subquery:
+			expr, err = decodeSubquery(iter)
+		case ChangesVs:
+			expr, err = decodeChangesVs(iter)
 		case Literal:
 			expr, err = decodeLiteral(iter)
 		case Vector:
 			expr, err = decodeVector(iter)
 		case LabelReplace:
 			expr, err = decodeLabelReplace(iter)
+		case LabelFunction:
+			expr, err = decodeLabelFunction(iter)
 		default:
 			return nil, fmt.Errorf("unknown sample expression type: %s", key)
 		}
@@ -848,6 +1055,10 @@ func decodeRangeAgg(iter *jsoniter.Iterator) (*RangeAggregationExpr, error) {
 		case Params:
 			tmp := iter.ReadFloat64()
 			expr.Params = &tmp
+		case Buckets:
+			expr.Buckets = decodeFloats(iter)
+		case Args:
+			expr.Args = decodeFloats(iter)
 		case Range:
 			expr.Left, err = decodeLogRange(iter)
 		case GroupingField:
@@ -858,6 +1069,69 @@ func decodeRangeAgg(iter *jsoniter.Iterator) (*RangeAggregationExpr, error) {
 	return expr, err
 }
 
+func encodeFloats(s *jsoniter.Stream, xs []float64) {
+	s.WriteArrayStart()
+	for i, x := range xs {
+		if i > 0 {
+			s.WriteMore()
+		}
+		s.WriteFloat64(x)
+	}
+	s.WriteArrayEnd()
+}
+
+func decodeFloats(iter *jsoniter.Iterator) []float64 {
+	var xs []float64
+	iter.ReadArrayCB(func(iter *jsoniter.Iterator) bool {
+		xs = append(xs, iter.ReadFloat64())
+		return true
+	})
+	return xs
+}
+
+func decodeSubquery(iter *jsoniter.Iterator) (*SubqueryExpr, error) {
+	expr := &SubqueryExpr{}
+	var err error
+
+	for f := iter.ReadObject(); f != ""; f = iter.ReadObject() {
+		switch f {
+		case Op:
+			expr.Operation = iter.ReadString()
+		case Params:
+			tmp := iter.ReadFloat64()
+			expr.Params = &tmp
+		case Args:
+			expr.Args = decodeFloats(iter)
+		case IntervalNanos:
+			expr.Range = time.Duration(iter.ReadInt64())
+		case StepNanos:
+			expr.Step = time.Duration(iter.ReadInt64())
+		case OffsetNanos:
+			expr.Offset = time.Duration(iter.ReadInt64())
+		case Inner:
+			expr.Left, err = decodeSample(iter)
+		}
+	}
+
+	return expr, err
+}
+
+func decodeChangesVs(iter *jsoniter.Iterator) (*ChangesVsExpr, error) {
+	expr := &ChangesVsExpr{}
+	var err error
+
+	for f := iter.ReadObject(); f != ""; f = iter.ReadObject() {
+		switch f {
+		case ShiftNanos:
+			expr.Shift = time.Duration(iter.ReadInt64())
+		case Inner:
+			expr.Left, err = decodeSample(iter)
+		}
+	}
+
+	return expr, err
+}
+
 func decodeLogRange(iter *jsoniter.Iterator) (*LogRange, error) {
 	expr := &LogRange{}
 	var err error
@@ -878,6 +1152,50 @@ func decodeLogRange(iter *jsoniter.Iterator) (*LogRange, error) {
 	return expr, err
 }
 
+func decodeLabelFunction(iter *jsoniter.Iterator) (*LabelFunctionExpr, error) {
+	var err error
+	var left SampleExpr
+	var op, dst, separator string
+	var src []string
+	var mapping map[string]string
+	var length int
+
+	for f := iter.ReadObject(); f != ""; f = iter.ReadObject() {
+		switch f {
+		case Op:
+			op = iter.ReadString()
+		case Inner:
+			left, err = decodeSample(iter)
+			if err != nil {
+				return nil, err
+			}
+		case Dst:
+			dst = iter.ReadString()
+		case Src:
+			iter.ReadArrayCB(func(iter *jsoniter.Iterator) bool {
+				src = append(src, iter.ReadString())
+				return true
+			})
+		case Separator:
+			separator = iter.ReadString()
+		case Mapping:
+			mapping = map[string]string{}
+			iter.ReadMapCB(func(iter *jsoniter.Iterator, k string) bool {
+				mapping[k] = iter.ReadString()
+				return true
+			})
+		case Length:
+			length = iter.ReadInt()
+		}
+	}
+
+	expr := newLabelFunctionExpr(left, op, dst, src, separator, mapping, length)
+	if expr.err != nil {
+		return nil, expr.err
+	}
+	return expr, nil
+}
+
 func decodeLabelReplace(iter *jsoniter.Iterator) (*LabelReplaceExpr, error) {
 	var err error
 	var left SampleExpr
@@ -50,12 +50,45 @@ func TestJSONSerializationRoundTrip(t *testing.T) {
 				| line_format "blip{{ .foo }}blop {{.status_code}}" | label_format foo=bar,status_code="buzz{{.bar}}" | unwrap foo
 				| __error__ !~".+"[5m]) by (namespace,instance)`,
 		},
+		"histogram over time": {
+			query: `histogram_over_time({app="foo"} | json | unwrap latency [5m], 0.1, 0.5, 1) by (namespace)`,
+		},
+		"subquery": {
+			query: `quantile_over_time(0.99, sum by (app) (rate({app="foo"}[5m]))[1h:1m] offset 10m)`,
+		},
+		"predict linear": {
+			query: `predict_linear({app="foo"} | json | unwrap bytes [1h], 3600)`,
+		},
+		"holt winters subquery": {
+			query: `holt_winters(sum(rate({app="foo"}[1m]))[1h:1m], 0.5, 0.1)`,
+		},
+		"label join": {
+			query: `label_join(sum by (cluster, namespace) (rate({app="foo"}[5m])), "dst", "/", "cluster", "namespace")`,
+		},
+		"label map": {
+			query: `label_map(rate({app="foo"}[5m]), "tier", "namespace", {"prod"="gold", "dev"="bronze"})`,
+		},
+		"label truncate": {
+			query: `label_truncate(label_upper(rate({app="foo"}[5m]), "level", "level"), "path", "path", 20)`,
+		},
+		"changes vs": {
+			query: `changes_vs(sum by (app) (rate({app="foo"}[5m])), 1w)`,
+		},
+		"day over day": {
+			query: `day_over_day(sum(count_over_time({app="foo"}[1h])))`,
+		},
 		"multiple post filters": {
 			query: `rate({app="foo"} | json | unwrap foo | latency >= 250ms or bytes > 42B or ( status_code < 500 and status_code > 200) or source = ip("") and user = "me" [1m])`,
 		},
 		"multiple post filters where one is a noop": {
 			query: `rate({app="foo"} | json | unwrap foo | latency >= 250ms or bytes=~".*" [1m])`,
 		},
+		"join": {
+			query: `{app="gateway"} | json | join on(trace_id) within 5m {app="backend"} | json`,
+		},
+		"sort_by": {
+			query: `{app="foo"} | logfmt | sort_by(duration desc) | limit 50`,
+		},
 		"empty label filter string": {
 			query: `rate({app="foo"} |= "bar" | json | unwrap latency | path!="" [5m])`,
 		},
@@ -16,7 +16,10 @@ type SampleExprVisitor interface {
 	VisitBinOp(*BinOpExpr)
 	VisitVectorAggregation(*VectorAggregationExpr)
 	VisitRangeAggregation(*RangeAggregationExpr)
+	VisitSubquery(*SubqueryExpr)
+	VisitChangesVs(*ChangesVsExpr)
 	VisitLabelReplace(*LabelReplaceExpr)
+	VisitLabelFunction(*LabelFunctionExpr)
 	VisitLiteral(*LiteralExpr)
 	VisitVector(*VectorExpr)
 }
@@ -24,11 +27,13 @@ type SampleExprVisitor interface {
 type LogSelectorExprVisitor interface {
 	VisitMatchers(*MatchersExpr)
 	VisitPipeline(*PipelineExpr)
+	VisitJoin(*JoinExpr)
 	VisitLiteral(*LiteralExpr)
 	VisitVector(*VectorExpr)
 }
 
 type StageExprVisitor interface {
+	VisitCSVParser(*CSVParserExpr)
 	VisitDecolorize(*DecolorizeExpr)
 	VisitDropLabels(*DropLabelsExpr)
 	VisitJSONExpressionParser(*JSONExpressionParser)
@@ -38,33 +43,58 @@ type StageExprVisitor interface {
 	VisitLabelParser(*LabelParserExpr)
 	VisitLineFilter(*LineFilterExpr)
 	VisitLineFmt(*LineFmtExpr)
+	VisitJSONFmt(*JSONFmtExpr)
 	VisitLogfmtExpressionParser(*LogfmtExpressionParser)
 	VisitLogfmtParser(*LogfmtParserExpr)
+	VisitSortBy(*SortByExpr)
+	VisitLimit(*LimitExpr)
+	VisitMacro(*MacroExpr)
+	VisitSDParser(*SDParserExpr)
+	VisitXMLParser(*XMLParserExpr)
+	VisitDedup(*DedupExpr)
+	VisitSearch(*SearchExpr)
+	VisitGeoIP(*GeoIPExpr)
+	VisitCIDRLabel(*CIDRLabelExpr)
 }
 
 var _ RootVisitor = &DepthFirstTraversal{}
 
 type DepthFirstTraversal struct {
 	VisitBinOpFn                  func(v RootVisitor, e *BinOpExpr)
+	VisitChangesVsFn              func(v RootVisitor, e *ChangesVsExpr)
+	VisitCIDRLabelFn              func(v RootVisitor, e *CIDRLabelExpr)
+	VisitCSVParserFn              func(v RootVisitor, e *CSVParserExpr)
 	VisitDecolorizeFn             func(v RootVisitor, e *DecolorizeExpr)
+	VisitDedupFn                  func(v RootVisitor, e *DedupExpr)
 	VisitDropLabelsFn             func(v RootVisitor, e *DropLabelsExpr)
+	VisitGeoIPFn                  func(v RootVisitor, e *GeoIPExpr)
 	VisitJSONExpressionParserFn   func(v RootVisitor, e *JSONExpressionParser)
+	VisitJSONFmtFn                func(v RootVisitor, e *JSONFmtExpr)
+	VisitJoinFn                   func(v RootVisitor, e *JoinExpr)
 	VisitKeepLabelFn              func(v RootVisitor, e *KeepLabelsExpr)
 	VisitLabelFilterFn            func(v RootVisitor, e *LabelFilterExpr)
 	VisitLabelFmtFn               func(v RootVisitor, e *LabelFmtExpr)
+	VisitLabelFunctionFn          func(v RootVisitor, e *LabelFunctionExpr)
 	VisitLabelParserFn            func(v RootVisitor, e *LabelParserExpr)
 	VisitLabelReplaceFn           func(v RootVisitor, e *LabelReplaceExpr)
+	VisitLimitFn                  func(v RootVisitor, e *LimitExpr)
 	VisitLineFilterFn             func(v RootVisitor, e *LineFilterExpr)
 	VisitLineFmtFn                func(v RootVisitor, e *LineFmtExpr)
 	VisitLiteralFn                func(v RootVisitor, e *LiteralExpr)
 	VisitLogRangeFn               func(v RootVisitor, e *LogRange)
 	VisitLogfmtExpressionParserFn func(v RootVisitor, e *LogfmtExpressionParser)
 	VisitLogfmtParserFn           func(v RootVisitor, e *LogfmtParserExpr)
+	VisitMacroFn                  func(v RootVisitor, e *MacroExpr)
 	VisitMatchersFn               func(v RootVisitor, e *MatchersExpr)
 	VisitPipelineFn               func(v RootVisitor, e *PipelineExpr)
 	VisitRangeAggregationFn       func(v RootVisitor, e *RangeAggregationExpr)
+	VisitSDParserFn               func(v RootVisitor, e *SDParserExpr)
+	VisitSearchFn                 func(v RootVisitor, e *SearchExpr)
+	VisitSortByFn                 func(v RootVisitor, e *SortByExpr)
+	VisitSubqueryFn               func(v RootVisitor, e *SubqueryExpr)
 	VisitVectorFn                 func(v RootVisitor, e *VectorExpr)
 	VisitVectorAggregationFn      func(v RootVisitor, e *VectorAggregationExpr)
+	VisitXMLParserFn              func(v RootVisitor, e *XMLParserExpr)
 }
 
 // VisitBinOp implements RootVisitor.
@@ -80,6 +110,16 @@ func (v *DepthFirstTraversal) VisitBinOp(e *BinOpExpr) {
 	}
 }
 
+// VisitCSVParser implements RootVisitor.
+func (v *DepthFirstTraversal) VisitCSVParser(e *CSVParserExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitCSVParserFn != nil {
+		v.VisitCSVParserFn(v, e)
+	}
+}
+
 // VisitDecolorize implements RootVisitor.
 func (v *DepthFirstTraversal) VisitDecolorize(e *DecolorizeExpr) {
 	if e == nil {
@@ -90,6 +130,26 @@ func (v *DepthFirstTraversal) VisitDecolorize(e *DecolorizeExpr) {
 	}
 }
 
+// VisitDedup implements RootVisitor.
+func (v *DepthFirstTraversal) VisitDedup(e *DedupExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitDedupFn != nil {
+		v.VisitDedupFn(v, e)
+	}
+}
+
+// VisitCIDRLabel implements RootVisitor.
+func (v *DepthFirstTraversal) VisitCIDRLabel(e *CIDRLabelExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitCIDRLabelFn != nil {
+		v.VisitCIDRLabelFn(v, e)
+	}
+}
+
 // VisitDropLabels implements RootVisitor.
 func (v *DepthFirstTraversal) VisitDropLabels(e *DropLabelsExpr) {
 	if e == nil {
@@ -110,6 +170,26 @@ func (v *DepthFirstTraversal) VisitJSONExpressionParser(e *JSONExpressionParser)
 	}
 }
 
+// VisitGeoIP implements RootVisitor.
+func (v *DepthFirstTraversal) VisitGeoIP(e *GeoIPExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitGeoIPFn != nil {
+		v.VisitGeoIPFn(v, e)
+	}
+}
+
+// VisitJSONFmt implements RootVisitor.
+func (v *DepthFirstTraversal) VisitJSONFmt(e *JSONFmtExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitJSONFmtFn != nil {
+		v.VisitJSONFmtFn(v, e)
+	}
+}
+
 // VisitKeepLabel implements RootVisitor.
 func (v *DepthFirstTraversal) VisitKeepLabel(e *KeepLabelsExpr) {
 	if e == nil {
@@ -150,6 +230,18 @@ func (v *DepthFirstTraversal) VisitLabelParser(e *LabelParserExpr) {
 	}
 }
 
+// VisitLabelFunction implements RootVisitor.
+func (v *DepthFirstTraversal) VisitLabelFunction(e *LabelFunctionExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitLabelFunctionFn != nil {
+		v.VisitLabelFunctionFn(v, e)
+	} else {
+		e.Left.Accept(v)
+	}
+}
+
 // VisitLabelReplace implements RootVisitor.
 func (v *DepthFirstTraversal) VisitLabelReplace(e *LabelReplaceExpr) {
 	if e == nil {
@@ -160,6 +252,16 @@ func (v *DepthFirstTraversal) VisitLabelReplace(e *LabelReplaceExpr) {
 	}
 }
 
+// VisitLimit implements RootVisitor.
+func (v *DepthFirstTraversal) VisitLimit(e *LimitExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitLimitFn != nil {
+		v.VisitLimitFn(v, e)
+	}
+}
+
 // VisitLineFilter implements RootVisitor.
 func (v *DepthFirstTraversal) VisitLineFilter(e *LineFilterExpr) {
 	if e == nil {
@@ -229,6 +331,16 @@ func (v *DepthFirstTraversal) VisitLogfmtParser(e *LogfmtParserExpr) {
 	}
 }
 
+// VisitMacro implements RootVisitor.
+func (v *DepthFirstTraversal) VisitMacro(e *MacroExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitMacroFn != nil {
+		v.VisitMacroFn(v, e)
+	}
+}
+
 // VisitMatchers implements RootVisitor.
 func (v *DepthFirstTraversal) VisitMatchers(e *MatchersExpr) {
 	if e == nil {
@@ -254,6 +366,19 @@ func (v *DepthFirstTraversal) VisitPipeline(e *PipelineExpr) {
 	}
 }
 
+// VisitJoin implements RootVisitor.
+func (v *DepthFirstTraversal) VisitJoin(e *JoinExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitJoinFn != nil {
+		v.VisitJoinFn(v, e)
+	} else {
+		e.Left.Accept(v)
+		e.Right.Accept(v)
+	}
+}
+
 // VisitRangeAggregation implements RootVisitor.
 func (v *DepthFirstTraversal) VisitRangeAggregation(e *RangeAggregationExpr) {
 	if e == nil {
@@ -266,6 +391,60 @@ func (v *DepthFirstTraversal) VisitRangeAggregation(e *RangeAggregationExpr) {
 	}
 }
 
+// VisitSDParser implements RootVisitor.
+func (v *DepthFirstTraversal) VisitSDParser(e *SDParserExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitSDParserFn != nil {
+		v.VisitSDParserFn(v, e)
+	}
+}
+
+// VisitSearch implements RootVisitor.
+func (v *DepthFirstTraversal) VisitSearch(e *SearchExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitSearchFn != nil {
+		v.VisitSearchFn(v, e)
+	}
+}
+
+// VisitSortBy implements RootVisitor.
+func (v *DepthFirstTraversal) VisitSortBy(e *SortByExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitSortByFn != nil {
+		v.VisitSortByFn(v, e)
+	}
+}
+
+// VisitSubquery implements RootVisitor.
+func (v *DepthFirstTraversal) VisitSubquery(e *SubqueryExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitSubqueryFn != nil {
+		v.VisitSubqueryFn(v, e)
+	} else {
+		e.Left.Accept(v)
+	}
+}
+
+// VisitChangesVs implements RootVisitor.
+func (v *DepthFirstTraversal) VisitChangesVs(e *ChangesVsExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitChangesVsFn != nil {
+		v.VisitChangesVsFn(v, e)
+	} else {
+		e.Left.Accept(v)
+	}
+}
+
 // VisitVector implements RootVisitor.
 func (v *DepthFirstTraversal) VisitVector(e *VectorExpr) {
 	if e == nil {
@@ -287,3 +466,13 @@ func (v *DepthFirstTraversal) VisitVectorAggregation(e *VectorAggregationExpr) {
 		e.Left.Accept(v)
 	}
 }
+
+// VisitXMLParser implements RootVisitor.
+func (v *DepthFirstTraversal) VisitXMLParser(e *XMLParserExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitXMLParserFn != nil {
+		v.VisitXMLParserFn(v, e)
+	}
+}
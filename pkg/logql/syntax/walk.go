@@ -4,6 +4,9 @@ type WalkFn = func(e Expr)
 
 func walkAll(f WalkFn, xs ...Walkable) {
 	for _, x := range xs {
+		if x == nil {
+			continue
+		}
 		x.Walk(f)
 	}
 }
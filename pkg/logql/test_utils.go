@@ -143,17 +143,21 @@ func processSeries(in []logproto.Stream, ex log.SampleExtractor) []logproto.Seri
 	for _, stream := range in {
 		for _, e := range stream.Entries {
 			exs := ex.ForStream(mustParseLabels(stream.Labels))
-			if f, lbs, ok := exs.Process(e.Timestamp.UnixNano(), []byte(e.Line)); ok {
+			samples, ok := exs.Process(e.Timestamp.UnixNano(), []byte(e.Line))
+			if !ok {
+				continue
+			}
+			for _, sample := range samples {
 				var s *logproto.Series
 				var found bool
-				s, found = resBySeries[lbs.String()]
+				s, found = resBySeries[sample.Labels.String()]
 				if !found {
-					s = &logproto.Series{Labels: lbs.String(), StreamHash: exs.BaseLabels().Hash()}
-					resBySeries[lbs.String()] = s
+					s = &logproto.Series{Labels: sample.Labels.String(), StreamHash: exs.BaseLabels().Hash()}
+					resBySeries[sample.Labels.String()] = s
 				}
 				s.Samples = append(s.Samples, logproto.Sample{
 					Timestamp: e.Timestamp.UnixNano(),
-					Value:     f,
+					Value:     sample.Value,
 					Hash:      xxhash.Sum64([]byte(e.Line)),
 				})
 			}
@@ -247,6 +251,15 @@ func (m MockDownstreamer) Downstream(ctx context.Context, queries []DownstreamQu
 		}
 		return []logqlmodel.Result{{Data: matrix}}, nil
 	}
+	if matrix, ok := results[0].Data.(ProbabilisticCountDistinctMatrix); ok {
+		if len(results) == 1 {
+			return results, nil
+		}
+		for _, m := range results[1:] {
+			matrix, _ = matrix.Merge(m.Data.(ProbabilisticCountDistinctMatrix))
+		}
+		return []logqlmodel.Result{{Data: matrix}}, nil
+	}
 	return results, nil
 }
 
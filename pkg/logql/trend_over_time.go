@@ -0,0 +1,178 @@
+package logql
+
+import (
+	"math"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+
+	"github.com/grafana/loki/v3/pkg/iter"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+)
+
+// trendAggregator computes the trend of the samples of a window, with ts the
+// end of the window in nanoseconds. It returns false when the window has not
+// enough samples to compute a trend.
+type trendAggregator func(ts int64, samples []promql.FPoint) (float64, bool)
+
+// newTrendIterator returns an iterator computing deriv, predict_linear or
+// holt_winters over each window. Series with less than two samples in a window
+// are dropped like Prometheus does.
+func newTrendIterator(
+	it iter.PeekingSampleIterator,
+	expr *syntax.RangeAggregationExpr,
+	selRange, step, start, end, offset int64) RangeVectorIterator {
+	inner := &batchRangeVectorIterator{
+		iter:     it,
+		step:     step,
+		end:      end,
+		selRange: selRange,
+		metrics:  map[string]labels.Labels{},
+		window:   map[string]*promql.Series{},
+		agg:      nil,
+		current:  start - step, // first loop iteration will set it to start
+		offset:   offset,
+	}
+	var agg trendAggregator
+	switch expr.Operation {
+	case syntax.OpRangeTypeDeriv:
+		agg = deriv
+	case syntax.OpRangeTypePredictLinear:
+		agg = predictLinear(expr.Args[0])
+	case syntax.OpRangeTypeHoltWinters:
+		agg = holtWinters(expr.Args[0], expr.Args[1])
+	}
+	return &trendBatchRangeVectorIterator{
+		batchRangeVectorIterator: inner,
+		agg:                      agg,
+	}
+}
+
+type trendBatchRangeVectorIterator struct {
+	*batchRangeVectorIterator
+	agg trendAggregator
+	at  []promql.Sample
+}
+
+// At aggregates the underlying window by computing the trend of each series.
+func (r *trendBatchRangeVectorIterator) At() (int64, StepResult) {
+	if r.at == nil {
+		r.at = make([]promql.Sample, 0, len(r.window))
+	}
+	r.at = r.at[:0]
+	// convert ts from nano to milli seconds as the iterator work with nanoseconds
+	ts := r.current/1e+6 + r.offset/1e+6
+	for _, series := range r.window {
+		v, ok := r.agg(r.current, series.Floats)
+		if !ok {
+			continue
+		}
+		r.at = append(r.at, promql.Sample{
+			F:      v,
+			T:      ts,
+			Metric: series.Metric,
+		})
+	}
+	return ts, SampleVector(r.at)
+}
+
+// deriv calculates the per-second derivative of the samples using a simple
+// linear regression.
+func deriv(_ int64, samples []promql.FPoint) (float64, bool) {
+	if len(samples) < 2 {
+		return 0, false
+	}
+	slope, _ := linearRegression(samples, samples[0].T)
+	return slope, true
+}
+
+// predictLinear predicts the value of the samples the given number of seconds
+// after the end of the window, using a simple linear regression.
+func predictLinear(seconds float64) trendAggregator {
+	return func(ts int64, samples []promql.FPoint) (float64, bool) {
+		if len(samples) < 2 {
+			return 0, false
+		}
+		slope, intercept := linearRegression(samples, ts)
+		return slope*seconds + intercept, true
+	}
+}
+
+// linearRegression is taken from prometheus code promql/functions.go.
+// It returns the slope in units per second and the intercept at the given
+// timestamp in nanoseconds.
+func linearRegression(samples []promql.FPoint, interceptTime int64) (slope, intercept float64) {
+	var (
+		n            float64
+		sumX, sumY   float64
+		sumXY, sumX2 float64
+		initY        = samples[0].F
+		constY       = true
+	)
+	for i, sample := range samples {
+		// Set constY to false if any new y values are encountered.
+		if constY && i > 0 && sample.F != initY {
+			constY = false
+		}
+		n += 1.0
+		x := float64(sample.T-interceptTime) / 1e9
+		sumX += x
+		sumY += sample.F
+		sumXY += x * sample.F
+		sumX2 += x * x
+	}
+	if constY {
+		if math.IsInf(initY, 0) {
+			return math.NaN(), math.NaN()
+		}
+		return 0, initY
+	}
+	covXY := sumXY - sumX*sumY/n
+	varX := sumX2 - sumX*sumX/n
+
+	slope = covXY / varX
+	intercept = sumY/n - slope*sumX/n
+	return slope, intercept
+}
+
+// holtWinters is taken from prometheus code promql/functions.go.
+// It returns the smoothed value of the samples using double exponential
+// smoothing, with sf the smoothing factor and tf the trend factor.
+func holtWinters(sf, tf float64) trendAggregator {
+	return func(_ int64, samples []promql.FPoint) (float64, bool) {
+		l := len(samples)
+		if l < 2 {
+			return 0, false
+		}
+		var s0, s1, b float64
+		// Set initial values.
+		s1 = samples[0].F
+		b = samples[1].F - samples[0].F
+
+		// Run the smoothing operation.
+		for i := 1; i < l; i++ {
+			// Scale the raw value against the smoothing factor.
+			x := sf * samples[i].F
+			// Scale the last smoothed value with the trend at this point.
+			b = calcTrendValue(i-1, tf, s0, s1, b)
+			y := (1 - sf) * (s1 + b)
+			s0, s1 = s1, x+y
+		}
+		return s1, true
+	}
+}
+
+// calcTrendValue calculates the trend value at the given index i in raw data d.
+// This is somewhat analogous to the slope of the trend at the given index.
+// The argument "tf" is the trend factor.
+// The argument "s0" is the computed smoothed value.
+// The argument "s1" is the computed trend factor.
+// The argument "b" is the raw input value.
+func calcTrendValue(i int, tf, s0, s1, b float64) float64 {
+	if i == 0 {
+		return b
+	}
+	x := tf * (s1 - s0)
+	y := (1 - tf) * b
+	return x + y
+}
@@ -91,6 +91,12 @@ func NewSeriesLimitError(limit int) *LimitError {
 	}
 }
 
+func NewJoinLimitError(limit int) *LimitError {
+	return &LimitError{
+		error: fmt.Errorf("maximum of join entries (%d) reached for a single query", limit),
+	}
+}
+
 // Is allows to use errors.Is(err,ErrLimit) on this error.
 func (e LimitError) Is(target error) bool {
 	return target == ErrLimit
@@ -52,12 +52,14 @@ import (
 	"github.com/grafana/loki/v3/pkg/ingester"
 	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/logql"
+	logql_log "github.com/grafana/loki/v3/pkg/logql/log"
 	"github.com/grafana/loki/v3/pkg/lokifrontend/frontend"
 	"github.com/grafana/loki/v3/pkg/lokifrontend/frontend/transport"
 	"github.com/grafana/loki/v3/pkg/lokifrontend/frontend/v1/frontendv1pb"
 	"github.com/grafana/loki/v3/pkg/lokifrontend/frontend/v2/frontendv2pb"
 	"github.com/grafana/loki/v3/pkg/pattern"
 	"github.com/grafana/loki/v3/pkg/querier"
+	"github.com/grafana/loki/v3/pkg/querier/macros"
 	"github.com/grafana/loki/v3/pkg/querier/queryrange"
 	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
 	"github.com/grafana/loki/v3/pkg/ruler"
@@ -66,6 +68,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/scheduler"
 	"github.com/grafana/loki/v3/pkg/scheduler/schedulerpb"
 	"github.com/grafana/loki/v3/pkg/storage"
+	"github.com/grafana/loki/v3/pkg/storage/bucket"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/cache"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
 	chunk_util "github.com/grafana/loki/v3/pkg/storage/chunk/client/util"
@@ -96,6 +99,7 @@ const (
 	Overrides                string = "overrides"
 	OverridesExporter        string = "overrides-exporter"
 	TenantConfigs            string = "tenant-configs"
+	QueryEnrichment          string = "query-enrichment"
 	Server                   string = "server"
 	InternalServer           string = "internal-server"
 	Distributor              string = "distributor"
@@ -320,6 +324,25 @@ func (t *Loki) initTenantConfigs() (_ services.Service, err error) {
 	return nil, err
 }
 
+func (t *Loki) initQueryEnrichment() (services.Service, error) {
+	var geoIP logql_log.GeoIPDatabase
+	if path := t.Cfg.Querier.GeoIPDatabase; path != "" {
+		db, err := logql_log.OpenGeoIPDatabase(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open geoip database: %w", err)
+		}
+		geoIP = db
+	}
+	enrichment := logql_log.NewEnrichment(geoIP, t.Cfg.Querier.CIDRRangesDirectory)
+	t.Cfg.Querier.Engine.Enrichment = enrichment
+	t.Cfg.Ingester.Enrichment = enrichment
+
+	// the GeoIP database is closed once the modules depending on it are stopped.
+	return services.NewIdleService(nil, func(_ error) error {
+		return enrichment.Close()
+	}), nil
+}
+
 func (t *Loki) initDistributor() (services.Service, error) {
 	if t.Cfg.Pattern.Enabled {
 		patternTee, err := pattern.NewTee(t.Cfg.Pattern, t.PatternRingClient, t.Cfg.MetricsNamespace, prometheus.DefaultRegisterer, util_log.Logger)
@@ -948,7 +971,7 @@ func (i ingesterQueryOptions) QueryIngestersWithin() time.Duration {
 func (t *Loki) initQueryFrontendMiddleware() (_ services.Service, err error) {
 	level.Debug(util_log.Logger).Log("msg", "initializing query frontend tripperware")
 
-	middleware, stopper, err := queryrange.NewMiddleware(
+	middleware, resultsCache, stopper, err := queryrange.NewMiddleware(
 		t.Cfg.QueryRange,
 		t.Cfg.Querier.Engine,
 		ingesterQueryOptions{t.Cfg.Querier},
@@ -963,6 +986,20 @@ func (t *Loki) initQueryFrontendMiddleware() (_ services.Service, err error) {
 		return
 	}
 	t.stopper = stopper
+	t.resultsCache = resultsCache
+
+	// The cost of the queries is the cost of their requests to the queriers.
+	t.costTracker = queryrange.NewCostTracker(t.Overrides, util_log.Logger, prometheus.DefaultRegisterer, t.Cfg.MetricsNamespace)
+	middleware = queryrangebase.MergeMiddlewares(queryrange.NewCostBudgetMiddleware(t.costTracker), middleware, t.costTracker)
+
+	if t.Cfg.QueryRange.Macros.Enabled {
+		bucketClient, err := bucket.NewClient(context.Background(), t.Cfg.QueryRange.Macros.Storage, "query-macros", util_log.Logger, prometheus.DefaultRegisterer)
+		if err != nil {
+			return nil, err
+		}
+		t.macroStore = macros.NewCachedStore(macros.NewBucketStore(bucketClient), t.Cfg.QueryRange.Macros.CacheTTL)
+		middleware = queryrangebase.MergeMiddlewares(queryrange.NewMacrosMiddleware(t.macroStore), middleware)
+	}
 	t.QueryFrontEndMiddleware = middleware
 
 	return services.NewIdleService(nil, nil), nil
@@ -1056,7 +1093,8 @@ func (t *Loki) initQueryFrontend() (_ services.Service, err error) {
 		level.Debug(util_log.Logger).Log("msg", "no query frontend configured")
 	}
 
-	roundTripper := queryrange.NewSerializeRoundTripper(t.QueryFrontEndMiddleware.Wrap(frontendTripper), queryrange.DefaultCodec)
+	frontendRoundTripper := t.QueryFrontEndMiddleware.Wrap(frontendTripper)
+	roundTripper := queryrange.NewSerializeRoundTripper(frontendRoundTripper, queryrange.DefaultCodec)
 
 	frontendHandler := transport.NewHandler(t.Cfg.Frontend.Handler, roundTripper, util_log.Logger, prometheus.DefaultRegisterer, t.Cfg.MetricsNamespace)
 	if t.Cfg.Frontend.CompressResponses {
@@ -1131,6 +1169,44 @@ func (t *Loki) initQueryFrontend() (_ services.Service, err error) {
 	t.Server.HTTP.Path("/api/prom/label/{name}/values").Methods("GET", "POST").Handler(frontendHandler)
 	t.Server.HTTP.Path("/api/prom/series").Methods("GET", "POST").Handler(frontendHandler)
 
+	// The explain handler sends the index stats and shards requests of the
+	// explained queries through the frontend to make use of its caches, and
+	// looks up the splits of the queries in its results cache.
+	explainHandler := queryrange.NewExplainHandler(
+		t.Cfg.QueryRange,
+		t.Cfg.Querier.Engine,
+		ingesterQueryOptions{t.Cfg.Querier},
+		t.Overrides,
+		t.Cfg.SchemaConfig,
+		t.macroStore,
+		t.resultsCache,
+		t.cacheGenerationLoader,
+		t.Cfg.CompactorConfig.RetentionEnabled,
+		frontendRoundTripper,
+		util_log.Logger,
+	)
+	t.Server.HTTP.Path("/loki/api/v1/explain").Methods("GET", "POST").Handler(middleware.Merge(
+		httpreq.ExtractQueryTagsMiddleware(),
+		serverutil.RecoveryHTTPMiddleware,
+		t.HTTPAuthMiddleware,
+	).Wrap(explainHandler))
+
+	t.Server.HTTP.Path("/loki/api/v1/cost").Methods("GET").Handler(middleware.Merge(
+		serverutil.RecoveryHTTPMiddleware,
+		t.HTTPAuthMiddleware,
+	).Wrap(http.HandlerFunc(t.costTracker.Handler)))
+
+	if t.macroStore != nil {
+		macrosHandler := macros.NewHandler(t.macroStore, util_log.Logger)
+		httpMiddleware := middleware.Merge(
+			serverutil.RecoveryHTTPMiddleware,
+			t.HTTPAuthMiddleware,
+		)
+		t.Server.HTTP.Path("/loki/api/v1/macros").Methods("GET").Handler(httpMiddleware.Wrap(http.HandlerFunc(macrosHandler.ListHandler)))
+		t.Server.HTTP.Path("/loki/api/v1/macros/{name}").Methods("PUT", "POST").Handler(httpMiddleware.Wrap(http.HandlerFunc(macrosHandler.CreateHandler)))
+		t.Server.HTTP.Path("/loki/api/v1/macros/{name}").Methods("DELETE").Handler(httpMiddleware.Wrap(http.HandlerFunc(macrosHandler.DeleteHandler)))
+	}
+
 	// Only register tailing requests if this process does not act as a Querier
 	// If this process is also a Querier the Querier will register the tail endpoints.
 	if !t.isModuleActive(Querier) {
@@ -34,6 +34,8 @@ const (
 	// StatusClientClosedRequest is the status code for when a client request cancellation of an http request
 	StatusClientClosedRequest = 499
 	ServiceTimingHeaderName   = "Server-Timing"
+
+	eventStreamType = "text/event-stream"
 )
 
 var (
@@ -141,6 +143,9 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		server.WriteError(err, w)
 		return
 	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
 
 	hs := w.Header()
 	for h, vs := range resp.Header {
@@ -153,7 +158,14 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(resp.StatusCode)
 	// we don't check for copy error as there is no much we can do at this point
-	_, _ = io.Copy(w, resp.Body)
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), eventStreamType) {
+		// events are sent as soon as they are written, and the query lasts
+		// until the last one.
+		_, _ = io.Copy(&flushWriter{w: w, rc: http.NewResponseController(w)}, resp.Body)
+		queryResponseTime = time.Since(startTime)
+	} else {
+		_, _ = io.Copy(w, resp.Body)
+	}
 
 	// Check whether we should parse the query string.
 	shouldReportSlowQuery := f.cfg.LogQueriesLongerThan > 0 && queryResponseTime > f.cfg.LogQueriesLongerThan
@@ -169,6 +181,22 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// flushWriter flushes the response after each write.
+type flushWriter struct {
+	w  io.Writer
+	rc *http.ResponseController
+}
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	// not every response can be flushed, in which case it is sent at once.
+	_ = f.rc.Flush()
+	return n, nil
+}
+
 // reportSlowQuery reports slow queries.
 func (f *Handler) reportSlowQuery(r *http.Request, queryString url.Values, queryResponseTime time.Duration) {
 	logMessage := append([]interface{}{
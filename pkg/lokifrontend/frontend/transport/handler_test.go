@@ -1,9 +1,13 @@
 package transport
 
 import (
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/go-kit/log"
 	"github.com/stretchr/testify/require"
 )
 
@@ -21,3 +25,26 @@ func TestFormatRequestHeaders(t *testing.T) {
 
 	require.Equal(t, expected, fields)
 }
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestHandlerFlushesEventStreams(t *testing.T) {
+	body := "event: result\ndata: {}\n\n"
+	h := NewHandler(HandlerConfig{MaxBodySize: 1024}, roundTripperFunc(func(_ *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{eventStreamType}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil
+	}), log.NewNopLogger(), nil, "")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/loki/api/v1/query_range", nil))
+	require.Equal(t, body, w.Body.String())
+	require.Equal(t, eventStreamType, w.Header().Get("Content-Type"))
+	require.True(t, w.Flushed)
+}
@@ -0,0 +1,82 @@
+package macros
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CachedStore is a Store caching the macros of each tenant for a TTL, so the
+// queries invoking macros don't list them from the underlying store each time.
+// The macros of a tenant changed through the CachedStore are invalidated right
+// away, other instances see the changes once the TTL elapsed.
+type CachedStore struct {
+	Store
+	ttl time.Duration
+	now func() time.Time
+
+	mtx     sync.Mutex
+	tenants map[string]cachedMacros
+}
+
+type cachedMacros struct {
+	macros  map[string]string
+	expires time.Time
+}
+
+// NewCachedStore returns a CachedStore wrapping store, or store itself when the
+// TTL is 0.
+func NewCachedStore(store Store, ttl time.Duration) Store {
+	if ttl <= 0 {
+		return store
+	}
+	return &CachedStore{
+		Store:   store,
+		ttl:     ttl,
+		now:     time.Now,
+		tenants: map[string]cachedMacros{},
+	}
+}
+
+// List returns the cached macros of the tenant. They must not be modified.
+func (s *CachedStore) List(ctx context.Context, tenant string) (map[string]string, error) {
+	now := s.now()
+	s.mtx.Lock()
+	cached, ok := s.tenants[tenant]
+	s.mtx.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.macros, nil
+	}
+
+	macros, err := s.Store.List(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	// drop the expired tenants, so the ones which stopped using macros don't
+	// stay in the cache.
+	for name, c := range s.tenants {
+		if !now.Before(c.expires) {
+			delete(s.tenants, name)
+		}
+	}
+	s.tenants[tenant] = cachedMacros{macros: macros, expires: now.Add(s.ttl)}
+	return macros, nil
+}
+
+func (s *CachedStore) Set(ctx context.Context, tenant, name, body string) error {
+	defer s.invalidate(tenant)
+	return s.Store.Set(ctx, tenant, name, body)
+}
+
+func (s *CachedStore) Delete(ctx context.Context, tenant, name string) error {
+	defer s.invalidate(tenant)
+	return s.Store.Delete(ctx, tenant, name)
+}
+
+func (s *CachedStore) invalidate(tenant string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.tenants, tenant)
+}
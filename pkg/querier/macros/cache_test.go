@@ -0,0 +1,65 @@
+package macros
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+type countingStore struct {
+	Store
+	lists int
+}
+
+func (s *countingStore) List(ctx context.Context, tenant string) (map[string]string, error) {
+	s.lists++
+	return s.Store.List(ctx, tenant)
+}
+
+func TestCachedStore(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	counting := &countingStore{Store: NewBucketStore(bkt)}
+	store := NewCachedStore(counting, time.Minute).(*CachedStore)
+	now := time.Unix(0, 0)
+	store.now = func() time.Time { return now }
+
+	require.NoError(t, store.Set(ctx, "tenant-a", "parse", "| json"))
+	for i := 0; i < 3; i++ {
+		macros, err := store.List(ctx, "tenant-a")
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"parse": "| json"}, macros)
+	}
+	require.Equal(t, 1, counting.lists)
+
+	// the changes made through the cache invalidate it.
+	require.NoError(t, store.Set(ctx, "tenant-a", "parse", "| logfmt"))
+	macros, err := store.List(ctx, "tenant-a")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"parse": "| logfmt"}, macros)
+	require.Equal(t, 2, counting.lists)
+
+	// the other changes are listed once the TTL elapsed.
+	require.NoError(t, NewBucketStore(bkt).Delete(ctx, "tenant-a", "parse"))
+	macros, err = store.List(ctx, "tenant-a")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"parse": "| logfmt"}, macros)
+	now = now.Add(time.Minute)
+	macros, err = store.List(ctx, "tenant-a")
+	require.NoError(t, err)
+	require.Empty(t, macros)
+	require.Equal(t, 3, counting.lists)
+
+	// the expired tenants are dropped.
+	_, err = store.List(ctx, "tenant-b")
+	require.NoError(t, err)
+	now = now.Add(time.Minute)
+	_, err = store.List(ctx, "tenant-c")
+	require.NoError(t, err)
+	require.Len(t, store.tenants, 1)
+
+	require.Equal(t, counting, NewCachedStore(counting, 0))
+}
@@ -0,0 +1,138 @@
+package macros
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/tenant"
+
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+)
+
+// maxBodySize is the maximum size of the body of a macro.
+const maxBodySize = 64 << 10
+
+// Macro is a macro of a tenant as returned by the API.
+type Macro struct {
+	Name string `json:"name"`
+	Body string `json:"body"`
+}
+
+// Handler provides the handlers of the macros API.
+type Handler struct {
+	store  Store
+	logger log.Logger
+}
+
+// NewHandler creates a Handler
+func NewHandler(store Store, logger log.Logger) *Handler {
+	return &Handler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// ListHandler returns the macros of the tenant ordered by name.
+func (h *Handler) ListHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	macros, err := h.store.List(ctx, userID)
+	if err != nil {
+		level.Error(h.logger).Log("msg", "error listing macros from the store", "user", userID, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	result := make([]Macro, 0, len(macros))
+	for name, body := range macros {
+		result = append(result, Macro{Name: name, Body: body})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		level.Error(h.logger).Log("msg", "error marshalling response", "err", err)
+		http.Error(w, fmt.Sprintf("Error marshalling response: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// CreateHandler creates or replaces the macro named in the path with the body
+// of the request. The macro is rejected if its body can't be parsed or if its
+// expansion is recursive.
+func (h *Handler) CreateHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxBodySize {
+		http.Error(w, fmt.Sprintf("macro body is larger than %d bytes", maxBodySize), http.StatusBadRequest)
+		return
+	}
+
+	macros, err := h.store.List(ctx, userID)
+	if err != nil {
+		level.Error(h.logger).Log("msg", "error listing macros from the store", "user", userID, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	if err := syntax.ValidateMacro(name, string(body), macros); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.Set(ctx, userID, name, string(body)); err != nil {
+		level.Error(h.logger).Log("msg", "error storing macro", "user", userID, "macro", name, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	level.Info(h.logger).Log("msg", "macro created", "user", userID, "macro", name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteHandler deletes the macro named in the path.
+func (h *Handler) DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	if err := h.store.Delete(ctx, userID, name); err != nil {
+		if errors.Is(err, ErrMacroNotFound) {
+			http.Error(w, "could not find macro with given name", http.StatusNotFound)
+			return
+		}
+
+		level.Error(h.logger).Log("msg", "error deleting macro", "user", userID, "macro", name, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	level.Info(h.logger).Log("msg", "macro deleted", "user", userID, "macro", name)
+	w.WriteHeader(http.StatusNoContent)
+}
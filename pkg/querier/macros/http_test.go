@@ -0,0 +1,52 @@
+package macros
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/user"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestHandler(t *testing.T) {
+	store := NewBucketStore(objstore.NewInMemBucket())
+	h := NewHandler(store, log.NewNopLogger())
+
+	router := mux.NewRouter()
+	router.Path("/loki/api/v1/macros").Methods("GET").HandlerFunc(h.ListHandler)
+	router.Path("/loki/api/v1/macros/{name}").Methods("POST").HandlerFunc(h.CreateHandler)
+	router.Path("/loki/api/v1/macros/{name}").Methods("DELETE").HandlerFunc(h.DeleteHandler)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req = req.WithContext(user.InjectOrgID(context.Background(), "tenant"))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, tc := range []struct {
+		method, path, body string
+		status             int
+		response           string
+	}{
+		{"POST", "/loki/api/v1/macros/parse", `| json`, http.StatusNoContent, ""},
+		{"POST", "/loki/api/v1/macros/errors", `| @parse() | level="error"`, http.StatusNoContent, ""},
+		{"POST", "/loki/api/v1/macros/parse", `| @errors()`, http.StatusBadRequest, "parse error : macro @parse() is recursive: parse -> errors -> parse\n"},
+		{"POST", "/loki/api/v1/macros/bad-name", `| json`, http.StatusBadRequest, "parse error : invalid macro name \"bad-name\"\n"},
+		{"GET", "/loki/api/v1/macros", "", http.StatusOK, `[{"name":"errors","body":"| @parse() | level=\"error\""},{"name":"parse","body":"| json"}]` + "\n"},
+		{"DELETE", "/loki/api/v1/macros/errors", "", http.StatusNoContent, ""},
+		{"DELETE", "/loki/api/v1/macros/errors", "", http.StatusNotFound, "could not find macro with given name\n"},
+		{"GET", "/loki/api/v1/macros", "", http.StatusOK, `[{"name":"parse","body":"| json"}]` + "\n"},
+	} {
+		w := do(tc.method, tc.path, tc.body)
+		require.Equal(t, tc.status, w.Code, "%s %s", tc.method, tc.path)
+		require.Equal(t, tc.response, w.Body.String(), "%s %s", tc.method, tc.path)
+	}
+}
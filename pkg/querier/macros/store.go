@@ -0,0 +1,117 @@
+package macros
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/loki/v3/pkg/storage/bucket"
+)
+
+// The bucket prefix under which all tenants macros are stored.
+const macrosPrefix = "macros"
+
+// ErrMacroNotFound is returned when deleting a macro which doesn't exist.
+var ErrMacroNotFound = errors.New("macro not found")
+
+// Config configures the storage of the query macros.
+type Config struct {
+	Enabled  bool          `yaml:"enabled"`
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+	Storage  bucket.Config `yaml:"storage"`
+}
+
+// RegisterFlags registers the flags of the macros config.
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "querier.macros.enabled", false, "Enable the per-tenant query macros and their API. Macros are stored in the configured object storage and expanded by the query frontend.")
+	f.DurationVar(&cfg.CacheTTL, "querier.macros.cache-ttl", time.Minute, "How long the macros of a tenant are cached by the query frontend. The macros changed through the API of another instance are used once this duration elapsed. 0 disables the cache.")
+	cfg.Storage.RegisterFlagsWithPrefix("querier.macros.storage.", f)
+}
+
+// Validate validates the config.
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	return cfg.Storage.Validate()
+}
+
+// Store stores the macros of the tenants.
+type Store interface {
+	// List returns the bodies of the macros of a tenant by name.
+	List(ctx context.Context, tenant string) (map[string]string, error)
+	// Set creates or replaces the macro of a tenant.
+	Set(ctx context.Context, tenant, name, body string) error
+	// Delete deletes the macro of a tenant, or returns ErrMacroNotFound.
+	Delete(ctx context.Context, tenant, name string) error
+}
+
+// BucketStore is a Store backed by an object storage bucket, each macro is an
+// object named after the tenant and the macro.
+type BucketStore struct {
+	bucket objstore.Bucket
+}
+
+func NewBucketStore(bkt objstore.Bucket) *BucketStore {
+	return &BucketStore{
+		bucket: bucket.NewPrefixedBucketClient(bkt, macrosPrefix),
+	}
+}
+
+func (s *BucketStore) List(ctx context.Context, tenant string) (map[string]string, error) {
+	prefix := tenant + objstore.DirDelim
+	macros := map[string]string{}
+	err := s.bucket.Iter(ctx, prefix, func(key string) error {
+		name := strings.TrimPrefix(key, prefix)
+		body, err := s.get(ctx, key)
+		if s.bucket.IsObjNotFoundErr(err) {
+			// deleted while listing.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		macros[name] = body
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the macros of tenant %s: %w", tenant, err)
+	}
+	return macros, nil
+}
+
+func (s *BucketStore) get(ctx context.Context, key string) (string, error) {
+	reader, err := s.bucket.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = reader.Close() }()
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read macro %s", key)
+	}
+	return string(body), nil
+}
+
+func (s *BucketStore) Set(ctx context.Context, tenant, name, body string) error {
+	return s.bucket.Upload(ctx, objectKey(tenant, name), strings.NewReader(body))
+}
+
+func (s *BucketStore) Delete(ctx context.Context, tenant, name string) error {
+	err := s.bucket.Delete(ctx, objectKey(tenant, name))
+	if s.bucket.IsObjNotFoundErr(err) {
+		return ErrMacroNotFound
+	}
+	return err
+}
+
+func objectKey(tenant, name string) string {
+	return tenant + objstore.DirDelim + name
+}
@@ -0,0 +1,36 @@
+package macros
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestBucketStore(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	store := NewBucketStore(bkt)
+
+	require.NoError(t, store.Set(ctx, "tenant-a", "parse", "| json"))
+	require.NoError(t, store.Set(ctx, "tenant-a", "errors", `| @parse() | level="error"`))
+	require.NoError(t, store.Set(ctx, "tenant-b", "parse", "| logfmt"))
+	require.Contains(t, bkt.Objects(), "macros/tenant-a/parse")
+
+	macros, err := store.List(ctx, "tenant-a")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"parse": "| json", "errors": `| @parse() | level="error"`}, macros)
+
+	require.NoError(t, store.Set(ctx, "tenant-a", "parse", "| logfmt"))
+	require.NoError(t, store.Delete(ctx, "tenant-a", "errors"))
+	require.ErrorIs(t, store.Delete(ctx, "tenant-a", "errors"), ErrMacroNotFound)
+
+	macros, err = store.List(ctx, "tenant-a")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"parse": "| logfmt"}, macros)
+
+	macros, err = store.List(ctx, "tenant-c")
+	require.NoError(t, err)
+	require.Empty(t, macros)
+}
@@ -72,6 +72,8 @@ type Config struct {
 	QueryIngesterOnly             bool             `yaml:"query_ingester_only"`
 	MultiTenantQueriesEnabled     bool             `yaml:"multi_tenant_queries_enabled"`
 	PerRequestLimitsEnabled       bool             `yaml:"per_request_limits_enabled"`
+	GeoIPDatabase                 string           `yaml:"geoip_database"`
+	CIDRRangesDirectory           string           `yaml:"cidr_ranges_directory"`
 }
 
 // RegisterFlags register flags.
@@ -85,6 +87,8 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.QueryIngesterOnly, "querier.query-ingester-only", false, "When true, queriers only query the ingesters, and not stored data. This is useful when the object store is unavailable.")
 	f.BoolVar(&cfg.MultiTenantQueriesEnabled, "querier.multi-tenant-queries-enabled", false, "When true, allow queries to span multiple tenants.")
 	f.BoolVar(&cfg.PerRequestLimitsEnabled, "querier.per-request-limits-enabled", false, "When true, querier limits sent via a header are enforced.")
+	f.StringVar(&cfg.GeoIPDatabase, "querier.geoip-database", "", "Path of the MaxMind City or ASN database used by the geoip stage of LogQL queries. The database must be available on queriers, ingesters and rulers. When empty, queries using the geoip stage are rejected.")
+	f.StringVar(&cfg.CIDRRangesDirectory, "querier.cidr-ranges-directory", "", "Directory holding the YAML files of named networks used by the cidr_label stage of LogQL queries. The directory must be available on queriers, ingesters and rulers. When empty, queries using the cidr_label stage are rejected.")
 }
 
 // Validate validates the config.
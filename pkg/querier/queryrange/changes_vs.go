@@ -0,0 +1,135 @@
+package queryrange
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/querier/plan"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
+)
+
+type changesVsMiddleware struct {
+	next queryrangebase.Handler
+}
+
+// NewChangesVsMiddleware creates a new Middleware running the two legs of a
+// top level changes_vs query as two range queries of the compared expression,
+// the second one over the time range shifted back by the shift. Each leg is
+// split and cached on its own, so the shifted leg reuses the extents cached
+// for the compared expression when it was queried over the earlier time range.
+// Queries whose step doesn't divide the shift are not split into legs, as the
+// legs are aligned to their step on their own and their samples wouldn't be
+// the shift apart.
+func NewChangesVsMiddleware() queryrangebase.Middleware {
+	return queryrangebase.MiddlewareFunc(func(next queryrangebase.Handler) queryrangebase.Handler {
+		return changesVsMiddleware{
+			next: next,
+		}
+	})
+}
+
+func (m changesVsMiddleware) Do(ctx context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+	req, ok := r.(*LokiRequest)
+	if !ok || req.Plan == nil {
+		return m.next.Do(ctx, r)
+	}
+	expr, ok := req.Plan.AST.(*syntax.ChangesVsExpr)
+	if !ok || req.Step <= 0 || expr.Shift%(time.Duration(req.Step)*time.Millisecond) != 0 {
+		return m.next.Do(ctx, r)
+	}
+
+	current := req.WithQuery(expr.Left.String()).(*LokiRequest)
+	current.Plan = &plan.QueryPlan{AST: expr.Left}
+	shifted := current.WithStartEnd(req.StartTs.Add(-expr.Shift), req.EndTs.Add(-expr.Shift))
+
+	var currentResp, shiftedResp queryrangebase.Response
+	// the parts of either leg are not parts of the result.
+	g, gctx := errgroup.WithContext(withoutPartialResults(ctx))
+	g.Go(func() error {
+		var err error
+		currentResp, err = m.next.Do(gctx, current)
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		shiftedResp, err = m.next.Do(gctx, shifted)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	lhs, ok := currentResp.(*LokiPromResponse)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response type %T for the current leg of %s", currentResp, syntax.OpTypeChangesVs)
+	}
+	rhs, ok := shiftedResp.(*LokiPromResponse)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response type %T for the shifted leg of %s", shiftedResp, syntax.OpTypeChangesVs)
+	}
+	return subtractShifted(lhs, rhs, expr.Shift), nil
+}
+
+// subtractShifted subtracts the samples of the shifted leg from the samples of
+// the current leg, matching series with identical labels and samples whose
+// timestamps are the shift apart. Series and samples without a match are
+// dropped, like the engine does when evaluating changes_vs.
+func subtractShifted(lhs, rhs *LokiPromResponse, shift time.Duration) *LokiPromResponse {
+	shiftMs := shift.Milliseconds()
+
+	shiftedSeries := make(map[string]map[int64]float64, len(rhs.Response.Data.Result))
+	for _, s := range rhs.Response.Data.Result {
+		samples := make(map[int64]float64, len(s.Samples))
+		for _, sample := range s.Samples {
+			samples[sample.TimestampMs+shiftMs] = sample.Value
+		}
+		shiftedSeries[logproto.FromLabelAdaptersToLabels(s.Labels).String()] = samples
+	}
+
+	result := make([]queryrangebase.SampleStream, 0, len(lhs.Response.Data.Result))
+	for _, s := range lhs.Response.Data.Result {
+		shifted, ok := shiftedSeries[logproto.FromLabelAdaptersToLabels(s.Labels).String()]
+		if !ok {
+			continue
+		}
+		samples := make([]logproto.LegacySample, 0, len(s.Samples))
+		for _, sample := range s.Samples {
+			v, ok := shifted[sample.TimestampMs]
+			if !ok {
+				continue
+			}
+			samples = append(samples, logproto.LegacySample{
+				TimestampMs: sample.TimestampMs,
+				Value:       sample.Value - v,
+			})
+		}
+		if len(samples) == 0 {
+			continue
+		}
+		result = append(result, queryrangebase.SampleStream{
+			Labels:  s.Labels,
+			Samples: samples,
+		})
+	}
+
+	statistics := lhs.Statistics
+	statistics.Merge(rhs.Statistics)
+
+	return &LokiPromResponse{
+		Response: &queryrangebase.PrometheusResponse{
+			Status: lhs.Response.Status,
+			Data: queryrangebase.PrometheusData{
+				ResultType: lhs.Response.Data.ResultType,
+				Result:     result,
+			},
+			Headers:  lhs.Response.Headers,
+			Warnings: append(lhs.Response.Warnings, rhs.Response.Warnings...),
+		},
+		Statistics: statistics,
+	}
+}
@@ -0,0 +1,123 @@
+package queryrange
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/v3/pkg/querier/plan"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
+)
+
+func Test_ChangesVsMiddleware(t *testing.T) {
+	const query = `changes_vs(sum by (app) (rate({app="foo"}[5m])), 1h)`
+	start := time.Unix(7200, 0)
+	end := time.Unix(7260, 0)
+
+	series := func(app string, samples ...logproto.LegacySample) queryrangebase.SampleStream {
+		return queryrangebase.SampleStream{
+			Labels:  []logproto.LabelAdapter{{Name: "app", Value: app}},
+			Samples: samples,
+		}
+	}
+	response := func(result ...queryrangebase.SampleStream) *LokiPromResponse {
+		return &LokiPromResponse{
+			Response: &queryrangebase.PrometheusResponse{
+				Status: "success",
+				Data: queryrangebase.PrometheusData{
+					ResultType: "matrix",
+					Result:     result,
+				},
+			},
+			Statistics: stats.Result{Summary: stats.Summary{Splits: 1}},
+		}
+	}
+
+	var (
+		mtx  sync.Mutex
+		legs []*LokiRequest
+	)
+	handler := NewChangesVsMiddleware().Wrap(queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+		req := r.(*LokiRequest)
+		mtx.Lock()
+		legs = append(legs, req)
+		mtx.Unlock()
+
+		if req.StartTs.Equal(start) {
+			return response(
+				series("foo", logproto.LegacySample{TimestampMs: 7200000, Value: 10}, logproto.LegacySample{TimestampMs: 7260000, Value: 12}),
+				series("bar", logproto.LegacySample{TimestampMs: 7200000, Value: 1}),
+			), nil
+		}
+		return response(
+			series("foo", logproto.LegacySample{TimestampMs: 3600000, Value: 4}),
+		), nil
+	}))
+
+	resp, err := handler.Do(context.Background(), &LokiRequest{
+		Query:   query,
+		StartTs: start,
+		EndTs:   end,
+		Step:    60000,
+		Plan:    &plan.QueryPlan{AST: syntax.MustParseExpr(query)},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, legs, 2)
+	for _, leg := range legs {
+		require.Equal(t, `sum by (app)(rate({app="foo"}[5m]))`, leg.GetQuery())
+		require.Equal(t, leg.GetQuery(), leg.Plan.AST.String())
+		require.Equal(t, int64(60000), leg.Step)
+	}
+	ranges := [][2]time.Time{{legs[0].StartTs, legs[0].EndTs}, {legs[1].StartTs, legs[1].EndTs}}
+	require.ElementsMatch(t, [][2]time.Time{{start, end}, {start.Add(-time.Hour), end.Add(-time.Hour)}}, ranges)
+
+	require.Equal(t, response(
+		series("foo", logproto.LegacySample{TimestampMs: 7200000, Value: 6}),
+	).Response.Data, resp.(*LokiPromResponse).Response.Data)
+	require.Equal(t, int64(2), resp.(*LokiPromResponse).Statistics.Summary.Splits)
+}
+
+func Test_ChangesVsMiddleware_Passthrough(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		query string
+		step  int64
+	}{
+		{
+			name:  "nested changes_vs",
+			query: `sum(changes_vs(rate({app="foo"}[5m]), 1h))`,
+			step:  60000,
+		},
+		{
+			name:  "step not dividing the shift",
+			query: `day_over_day(sum by (app) (rate({app="foo"}[5m])))`,
+			step:  (7 * time.Minute).Milliseconds(),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []queryrangebase.Request
+			handler := NewChangesVsMiddleware().Wrap(queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+				got = append(got, r)
+				return &LokiPromResponse{}, nil
+			}))
+
+			req := &LokiRequest{
+				Query:   tc.query,
+				StartTs: time.Unix(7200, 0),
+				EndTs:   time.Unix(10800, 0),
+				Step:    tc.step,
+				Plan:    &plan.QueryPlan{AST: syntax.MustParseExpr(tc.query)},
+			}
+			_, err := handler.Do(context.Background(), req)
+			require.NoError(t, err)
+			require.Equal(t, []queryrangebase.Request{req}, got)
+		})
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/loghttp"
 	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/logql"
+	"github.com/grafana/loki/v3/pkg/logql/log"
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
@@ -1257,6 +1258,8 @@ func decodeResponseProtobuf(r *http.Response, req queryrangebase.Request) (query
 			return concrete.TopkSketches.WithHeaders(headers), nil
 		case *QueryResponse_QuantileSketches:
 			return concrete.QuantileSketches.WithHeaders(headers), nil
+		case *QueryResponse_CountDistinctSketches:
+			return concrete.CountDistinctSketches.WithHeaders(headers), nil
 		default:
 			return nil, httpgrpc.Errorf(http.StatusInternalServerError, "unsupported response type, got (%T)", resp.Response)
 		}
@@ -2152,6 +2155,47 @@ func NewEmptyResponse(r queryrangebase.Request) (queryrangebase.Response, error)
 }
 
 func mergeLokiResponse(responses ...queryrangebase.Response) *LokiResponse {
+	return mergeLokiResponseStreams(mergeOrderedNonOverlappingStreams, responses...)
+}
+
+// mergeLimitedLokiResponse merges the responses of a log query ending with a
+// limit stage, keeping the first limit entries of all responses.
+func mergeLimitedLokiResponse(limit uint32, responses ...queryrangebase.Response) *LokiResponse {
+	return mergeLokiResponseStreams(func(resps []*LokiResponse, _ uint32, direction logproto.Direction) []logproto.Stream {
+		return mergeOrderedNonOverlappingStreams(resps, limit, direction)
+	}, responses...)
+}
+
+// mergeSortedLokiResponse merges the responses of a log query using a sort_by
+// stage, keeping the first limit entries of all responses in the order of the stage.
+func mergeSortedLokiResponse(sortBy *log.SortBy, limit uint32, responses ...queryrangebase.Response) *LokiResponse {
+	return mergeLokiResponseStreams(func(resps []*LokiResponse, _ uint32, direction logproto.Direction) []logproto.Stream {
+		acc := logql.NewSortedStreamsAccumulator(sortBy, limit, direction)
+		for _, res := range resps {
+			// accumulating streams never fails
+			_ = acc.Accumulate(context.Background(), logqlmodel.Result{Data: logqlmodel.Streams(res.Data.Result)}, 0)
+		}
+		return acc.Result()[0].Data.(logqlmodel.Streams)
+	}, responses...)
+}
+
+// mergeDedupLokiResponse merges the responses of a log query using a dedup
+// stage, collapsing the identical entries of all responses.
+func mergeDedupLokiResponse(dedup *log.Dedup, limit uint32, responses ...queryrangebase.Response) *LokiResponse {
+	return mergeLokiResponseStreams(func(resps []*LokiResponse, _ uint32, direction logproto.Direction) []logproto.Stream {
+		acc := logql.NewDedupStreamsAccumulator(dedup, limit, direction)
+		for _, res := range resps {
+			// accumulating streams never fails
+			_ = acc.Accumulate(context.Background(), logqlmodel.Result{Data: logqlmodel.Streams(res.Data.Result)}, 0)
+		}
+		return acc.Result()[0].Data.(logqlmodel.Streams)
+	}, responses...)
+}
+
+func mergeLokiResponseStreams(
+	merge func(resps []*LokiResponse, limit uint32, direction logproto.Direction) []logproto.Stream,
+	responses ...queryrangebase.Response,
+) *LokiResponse {
 	if len(responses) == 0 {
 		return nil
 	}
@@ -2192,7 +2236,7 @@ func mergeLokiResponse(responses ...queryrangebase.Response) *LokiResponse {
 		Warnings:   warnings,
 		Data: LokiData{
 			ResultType: loghttp.ResultTypeStream,
-			Result:     mergeOrderedNonOverlappingStreams(lokiResponses, lokiRes.Limit, lokiRes.Direction),
+			Result:     merge(lokiResponses, lokiRes.Limit, lokiRes.Direction),
 		},
 	}
 }
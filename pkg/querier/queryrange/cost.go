@@ -0,0 +1,304 @@
+package queryrange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/httpgrpc"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+	queryrange_limits "github.com/grafana/loki/v3/pkg/querier/queryrange/limits"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/v3/pkg/util/spanlogger"
+	"github.com/grafana/loki/v3/pkg/validation"
+)
+
+const (
+	// costBucketDuration is the granularity of the sliding window of the
+	// query costs.
+	costBucketDuration = time.Minute
+
+	limErrQueryCostBudgetExhaustedTmpl = "the query cost budget of tenant %s is exhausted (cost: %s, budget: %s over %s); retry later or contact your Loki operator"
+)
+
+// CostBudgetLimits are the limits of the query cost budgets.
+type CostBudgetLimits queryrange_limits.CostBudgetLimits
+
+// QueryCost is the cost of queries to the queriers.
+type QueryCost struct {
+	// Bytes are the bytes of log lines processed.
+	Bytes int64 `json:"bytes"`
+	// Chunks are the chunks fetched from the store.
+	Chunks int64 `json:"chunks"`
+	// ExecTime is the time spent executing the queries, in seconds.
+	ExecTime float64 `json:"execTime"`
+}
+
+func queryCostFromStats(s stats.Result) QueryCost {
+	return QueryCost{
+		Bytes:    s.Summary.TotalBytesProcessed,
+		Chunks:   s.TotalChunksDownloaded(),
+		ExecTime: s.Summary.ExecTime,
+	}
+}
+
+func (c *QueryCost) add(o QueryCost) {
+	c.Bytes += o.Bytes
+	c.Chunks += o.Chunks
+	c.ExecTime += o.ExecTime
+}
+
+// exceeds returns whether the cost reaches any of the non zero parts of the
+// budget.
+func (c QueryCost) exceeds(budget QueryCost) bool {
+	return (budget.Bytes > 0 && c.Bytes >= budget.Bytes) ||
+		(budget.Chunks > 0 && c.Chunks >= budget.Chunks) ||
+		(budget.ExecTime > 0 && c.ExecTime >= budget.ExecTime)
+}
+
+func (c QueryCost) String() string {
+	return fmt.Sprintf("%d bytes, %d chunks, %s", c.Bytes, c.Chunks, time.Duration(c.ExecTime*float64(time.Second)))
+}
+
+type costBucket struct {
+	start time.Time
+	cost  QueryCost
+}
+
+// CostTracker accumulates the cost of the queries of each tenant over the
+// sliding window of its query cost budget. The cost of a query is the cost of
+// its requests to the queriers: the parts of queries served from the caches
+// are free.
+type CostTracker struct {
+	limits CostBudgetLimits
+	logger log.Logger
+	now    func() time.Time
+
+	mtx     sync.Mutex
+	tenants map[string][]costBucket
+
+	bytes     *prometheus.CounterVec
+	chunks    *prometheus.CounterVec
+	execTime  *prometheus.CounterVec
+	exhausted *prometheus.CounterVec
+}
+
+// NewCostTracker creates a CostTracker.
+func NewCostTracker(limits CostBudgetLimits, logger log.Logger, registerer prometheus.Registerer, metricsNamespace string) *CostTracker {
+	return &CostTracker{
+		limits:  limits,
+		logger:  logger,
+		now:     time.Now,
+		tenants: map[string][]costBucket{},
+		bytes: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "query_frontend_tenant_query_bytes_processed_total",
+			Help:      "Total bytes processed by the queriers for the queries of a tenant.",
+		}, []string{"tenant"}),
+		chunks: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "query_frontend_tenant_query_chunks_fetched_total",
+			Help:      "Total chunks fetched by the queriers for the queries of a tenant.",
+		}, []string{"tenant"}),
+		execTime: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "query_frontend_tenant_query_exec_seconds_total",
+			Help:      "Total time spent by the queriers executing the queries of a tenant.",
+		}, []string{"tenant"}),
+		exhausted: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "query_frontend_tenant_query_cost_budget_exhausted_total",
+			Help:      "Total queries of a tenant received once its query cost budget was exhausted, by action taken.",
+		}, []string{"tenant", "action"}),
+	}
+}
+
+// Add adds the cost of a query to the cost of a tenant.
+func (t *CostTracker) Add(tenantID string, cost QueryCost) {
+	t.bytes.WithLabelValues(tenantID).Add(float64(cost.Bytes))
+	t.chunks.WithLabelValues(tenantID).Add(float64(cost.Chunks))
+	t.execTime.WithLabelValues(tenantID).Add(cost.ExecTime)
+
+	start := t.now().Truncate(costBucketDuration)
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	buckets := t.prune(tenantID)
+	if n := len(buckets); n > 0 && buckets[n-1].start.Equal(start) {
+		buckets[n-1].cost.add(cost)
+		return
+	}
+	t.tenants[tenantID] = append(buckets, costBucket{start: start, cost: cost})
+}
+
+// Cost returns the cost of the queries of a tenant within its window.
+func (t *CostTracker) Cost(tenantID string) QueryCost {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	var cost QueryCost
+	for _, b := range t.prune(tenantID) {
+		cost.add(b.cost)
+	}
+	return cost
+}
+
+// prune removes the buckets of a tenant which left its window, and returns
+// the remaining ones. It must be called with the lock held.
+func (t *CostTracker) prune(tenantID string) []costBucket {
+	buckets := t.tenants[tenantID]
+	from := t.now().Add(-t.limits.QueryCostBudgetWindow(tenantID))
+	i := 0
+	for i < len(buckets) && !buckets[i].start.Add(costBucketDuration).After(from) {
+		i++
+	}
+	if i == len(buckets) {
+		delete(t.tenants, tenantID)
+		return nil
+	}
+	buckets = buckets[i:]
+	t.tenants[tenantID] = buckets
+	return buckets
+}
+
+// Budget returns the query cost budget of a tenant. Zero parts are unlimited.
+func (t *CostTracker) Budget(tenantID string) QueryCost {
+	return QueryCost{
+		Bytes:    int64(t.limits.QueryCostBudgetBytes(tenantID)),
+		Chunks:   int64(t.limits.QueryCostBudgetChunks(tenantID)),
+		ExecTime: t.limits.QueryCostBudgetExecTime(tenantID).Seconds(),
+	}
+}
+
+// Wrap records the cost of the requests to the queriers. It must wrap the
+// handler sending the requests to the queriers.
+func (t *CostTracker) Wrap(next queryrangebase.Handler) queryrangebase.Handler {
+	return queryrangebase.HandlerFunc(func(ctx context.Context, req queryrangebase.Request) (queryrangebase.Response, error) {
+		resp, err := next.Do(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+		withStats, ok := resp.(interface{ GetStatistics() stats.Result })
+		if !ok {
+			return resp, nil
+		}
+		tenantIDs, err := tenant.TenantIDs(ctx)
+		if err != nil {
+			return resp, nil
+		}
+
+		// the cost of the queries of several tenants is shared between them.
+		cost := queryCostFromStats(withStats.GetStatistics())
+		n := int64(len(tenantIDs))
+		share := QueryCost{
+			Bytes:    cost.Bytes / n,
+			Chunks:   cost.Chunks / n,
+			ExecTime: cost.ExecTime / float64(n),
+		}
+		for _, id := range tenantIDs {
+			t.Add(id, share)
+		}
+		return resp, nil
+	})
+}
+
+// Handler returns the cost of the queries of the tenant within its window,
+// with its budget.
+func (t *CostTracker) Handler(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cost, budget := t.Cost(userID), t.Budget(userID)
+	resp := struct {
+		Window    model.Duration `json:"window"`
+		Cost      QueryCost      `json:"cost"`
+		Budget    QueryCost      `json:"budget"`
+		Exhausted bool           `json:"exhausted"`
+	}{
+		Window:    model.Duration(t.limits.QueryCostBudgetWindow(userID)),
+		Cost:      cost,
+		Budget:    budget,
+		Exhausted: cost.exceeds(budget),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		level.Error(t.logger).Log("msg", "error marshalling response", "err", err)
+		http.Error(w, fmt.Sprintf("Error marshalling response: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// NewCostBudgetMiddleware rejects or deprioritizes the queries of the tenants
+// which exhausted their query cost budget, depending on their budget action.
+func NewCostBudgetMiddleware(t *CostTracker) queryrangebase.Middleware {
+	return queryrangebase.MiddlewareFunc(func(next queryrangebase.Handler) queryrangebase.Handler {
+		return queryrangebase.HandlerFunc(func(ctx context.Context, req queryrangebase.Request) (queryrangebase.Response, error) {
+			tenantIDs, err := tenant.TenantIDs(ctx)
+			if err != nil {
+				return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+			}
+
+			for _, id := range tenantIDs {
+				cost, budget := t.Cost(id), t.Budget(id)
+				if !cost.exceeds(budget) {
+					continue
+				}
+
+				action := t.limits.QueryCostBudgetAction(id)
+				t.exhausted.WithLabelValues(id, action).Inc()
+				level.Warn(spanlogger.FromContext(ctx)).Log("msg", "query cost budget exhausted", "tenant", id, "action", action, "cost", cost, "budget", budget)
+				if action == validation.QueryCostBudgetDeprioritize {
+					ctx = withDeprioritized(ctx)
+					continue
+				}
+				window := model.Duration(t.limits.QueryCostBudgetWindow(id))
+				return nil, httpgrpc.Errorf(http.StatusTooManyRequests, limErrQueryCostBudgetExhaustedTmpl, id, cost, budget, window)
+			}
+			return next.Do(ctx, req)
+		})
+	})
+}
+
+type deprioritizedKey struct{}
+
+func withDeprioritized(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deprioritizedKey{}, true)
+}
+
+func isDeprioritized(ctx context.Context) bool {
+	deprioritized, _ := ctx.Value(deprioritizedKey{}).(bool)
+	return deprioritized
+}
+
+// deprioritizedLimits limits the parallelism of the deprioritized queries to 1.
+type deprioritizedLimits struct {
+	Limits
+}
+
+func (l deprioritizedLimits) MaxQueryParallelism(ctx context.Context, user string) int {
+	if isDeprioritized(ctx) {
+		return min(l.Limits.MaxQueryParallelism(ctx, user), 1)
+	}
+	return l.Limits.MaxQueryParallelism(ctx, user)
+}
+
+func (l deprioritizedLimits) TSDBMaxQueryParallelism(ctx context.Context, user string) int {
+	if isDeprioritized(ctx) {
+		return min(l.Limits.TSDBMaxQueryParallelism(ctx, user), 1)
+	}
+	return l.Limits.TSDBMaxQueryParallelism(ctx, user)
+}
@@ -0,0 +1,139 @@
+package queryrange
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/httpgrpc"
+	"github.com/grafana/dskit/user"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/v3/pkg/validation"
+)
+
+type fakeCostLimits struct {
+	window   time.Duration
+	bytes    int
+	chunks   int
+	execTime time.Duration
+	action   string
+}
+
+func (f fakeCostLimits) QueryCostBudgetWindow(string) time.Duration   { return f.window }
+func (f fakeCostLimits) QueryCostBudgetBytes(string) int              { return f.bytes }
+func (f fakeCostLimits) QueryCostBudgetChunks(string) int             { return f.chunks }
+func (f fakeCostLimits) QueryCostBudgetExecTime(string) time.Duration { return f.execTime }
+func (f fakeCostLimits) QueryCostBudgetAction(string) string          { return f.action }
+
+func newTestCostTracker(limits fakeCostLimits, now *time.Time) *CostTracker {
+	t := NewCostTracker(limits, log.NewNopLogger(), nil, "loki")
+	t.now = func() time.Time { return *now }
+	return t
+}
+
+func TestCostTracker_Window(t *testing.T) {
+	now := time.Unix(3600, 0)
+	tracker := newTestCostTracker(fakeCostLimits{window: 10 * time.Minute}, &now)
+
+	tracker.Add("foo", QueryCost{Bytes: 10, Chunks: 1, ExecTime: 0.5})
+	now = now.Add(5 * time.Minute)
+	tracker.Add("foo", QueryCost{Bytes: 20, Chunks: 2, ExecTime: 1})
+	tracker.Add("bar", QueryCost{Bytes: 1})
+	require.Equal(t, QueryCost{Bytes: 30, Chunks: 3, ExecTime: 1.5}, tracker.Cost("foo"))
+	require.Equal(t, QueryCost{Bytes: 1}, tracker.Cost("bar"))
+
+	// the first cost left the window.
+	now = now.Add(6 * time.Minute)
+	require.Equal(t, QueryCost{Bytes: 20, Chunks: 2, ExecTime: 1}, tracker.Cost("foo"))
+
+	now = now.Add(10 * time.Minute)
+	require.Equal(t, QueryCost{}, tracker.Cost("foo"))
+	require.Empty(t, tracker.tenants["foo"])
+}
+
+func TestCostTracker_Wrap(t *testing.T) {
+	now := time.Unix(3600, 0)
+	tracker := newTestCostTracker(fakeCostLimits{window: time.Hour}, &now)
+
+	var statistics stats.Result
+	statistics.Querier.Store.TotalChunksDownloaded = 4
+	statistics.Querier.Store.Chunk.DecompressedBytes = 100
+	statistics.ComputeSummary(2*time.Second, 0, 0)
+	next := queryrangebase.HandlerFunc(func(_ context.Context, _ queryrangebase.Request) (queryrangebase.Response, error) {
+		return &LokiResponse{Status: "success", Statistics: statistics}, nil
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "foo|bar")
+	_, err := tracker.Wrap(next).Do(ctx, &LokiRequest{})
+	require.NoError(t, err)
+
+	// the cost of multi tenant queries is shared between the tenants.
+	require.Equal(t, QueryCost{Bytes: 50, Chunks: 2, ExecTime: 1}, tracker.Cost("foo"))
+	require.Equal(t, QueryCost{Bytes: 50, Chunks: 2, ExecTime: 1}, tracker.Cost("bar"))
+}
+
+func TestCostBudgetMiddleware(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "foo")
+	limits := deprioritizedLimits{Limits: fakeLimits{maxQueryParallelism: 32, tsdbMaxQueryParallelism: 32}}
+
+	for _, tc := range []struct {
+		name        string
+		action      string
+		cost        QueryCost
+		err         bool
+		parallelism int
+	}{
+		{"within budget", validation.QueryCostBudgetReject, QueryCost{Bytes: 99, Chunks: 9}, false, 32},
+		{"rejected", validation.QueryCostBudgetReject, QueryCost{Bytes: 100}, true, 0},
+		{"deprioritized", validation.QueryCostBudgetDeprioritize, QueryCost{Chunks: 10}, false, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			now := time.Unix(3600, 0)
+			tracker := newTestCostTracker(fakeCostLimits{window: time.Hour, bytes: 100, chunks: 10, action: tc.action}, &now)
+			tracker.Add("foo", tc.cost)
+
+			var parallelism, tsdbParallelism int
+			next := queryrangebase.HandlerFunc(func(ctx context.Context, _ queryrangebase.Request) (queryrangebase.Response, error) {
+				parallelism = limits.MaxQueryParallelism(ctx, "foo")
+				tsdbParallelism = limits.TSDBMaxQueryParallelism(ctx, "foo")
+				return &LokiResponse{}, nil
+			})
+
+			_, err := NewCostBudgetMiddleware(tracker).Wrap(next).Do(ctx, &LokiRequest{})
+			if tc.err {
+				resp, ok := httpgrpc.HTTPResponseFromError(err)
+				require.True(t, ok)
+				require.Equal(t, int32(http.StatusTooManyRequests), resp.Code)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.parallelism, parallelism)
+			require.Equal(t, tc.parallelism, tsdbParallelism)
+		})
+	}
+}
+
+func TestCostTracker_Handler(t *testing.T) {
+	now := time.Unix(3600, 0)
+	tracker := newTestCostTracker(fakeCostLimits{window: time.Hour, bytes: 100, execTime: time.Minute}, &now)
+	tracker.Add("foo", QueryCost{Bytes: 100, Chunks: 3, ExecTime: 1.5})
+
+	req := httptest.NewRequest(http.MethodGet, "/loki/api/v1/cost", nil)
+	req = req.WithContext(user.InjectOrgID(req.Context(), "foo"))
+	w := httptest.NewRecorder()
+	tracker.Handler(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{
+		"window": "1h",
+		"cost": {"bytes": 100, "chunks": 3, "execTime": 1.5},
+		"budget": {"bytes": 100, "chunks": 0, "execTime": 60},
+		"exhausted": true
+	}`, w.Body.String())
+}
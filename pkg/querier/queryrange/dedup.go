@@ -0,0 +1,196 @@
+package queryrange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/grafana/loki/v3/pkg/querier/plan"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/v3/pkg/util/httpreq"
+	"github.com/grafana/loki/v3/pkg/util/querylimits"
+)
+
+// Levels of the deduplication of the requests.
+const (
+	dedupLevelQuery      = "query"
+	dedupLevelDownstream = "downstream"
+)
+
+type DedupMetrics struct {
+	requests  *prometheus.CounterVec
+	coalesced *prometheus.CounterVec
+}
+
+func NewDedupMetrics(registerer prometheus.Registerer, metricsNamespace string) *DedupMetrics {
+	return &DedupMetrics{
+		requests: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "query_frontend_dedup_requests_total",
+			Help:      "Total number of requests which could be deduplicated, by level: the queries or their requests to the queriers.",
+		}, []string{"level"}),
+		coalesced: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "query_frontend_dedup_coalesced_requests_total",
+			Help:      "Total number of requests which shared the execution of an identical in-flight request, by level.",
+		}, []string{"level"}),
+	}
+}
+
+type dedupMiddleware struct {
+	next      queryrangebase.Handler
+	level     string
+	group     singleflight.Group
+	requests  prometheus.Counter
+	coalesced prometheus.Counter
+}
+
+// dedupResult is the result of a shared execution.
+type dedupResult struct {
+	resp queryrangebase.Response
+	// data is the query data recorded by the execution for the stats of the
+	// query.
+	data *queryData
+}
+
+// NewDedupMiddleware makes the concurrent identical requests of a tenant share
+// a single execution of the request, along with its response and its stats.
+func NewDedupMiddleware(level string, metrics *DedupMetrics) queryrangebase.Middleware {
+	return queryrangebase.MiddlewareFunc(func(next queryrangebase.Handler) queryrangebase.Handler {
+		return &dedupMiddleware{
+			next:      next,
+			level:     level,
+			requests:  metrics.requests.WithLabelValues(level),
+			coalesced: metrics.coalesced.WithLabelValues(level),
+		}
+	})
+}
+
+func (d *dedupMiddleware) Do(ctx context.Context, req queryrangebase.Request) (queryrangebase.Response, error) {
+	// the partial results are sent by the execution of the query itself.
+	if partialResultsFromContext(ctx) != nil {
+		return d.next.Do(ctx, req)
+	}
+	key, ok := dedupKey(ctx, req)
+	if !ok {
+		return d.next.Do(ctx, req)
+	}
+	var executed bool
+	ch := d.group.DoChan(key, func() (interface{}, error) {
+		executed = true
+		// the execution records the data of the query on its own, to share
+		// it with all the requests.
+		data := &queryData{}
+		resp, err := d.next.Do(context.WithValue(ctx, ctxKey, data), req)
+		return dedupResult{resp: resp, data: data}, err
+	})
+	d.requests.Inc()
+
+	var res singleflight.Result
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res = <-ch:
+	}
+	if !executed {
+		d.coalesced.Inc()
+		if errors.Is(res.Err, context.Canceled) && ctx.Err() == nil {
+			// the request which executed the shared request was canceled.
+			return d.next.Do(ctx, req)
+		}
+	}
+	if res.Err != nil {
+		return nil, res.Err
+	}
+
+	shared := res.Val.(dedupResult)
+	if data, ok := ctx.Value(ctxKey).(*queryData); ok && shared.data.recorded {
+		*data = *shared.data
+		if shared.data.statistics != nil {
+			statistics := *shared.data.statistics
+			data.statistics = &statistics
+		}
+	}
+	if !res.Shared {
+		return shared.resp, nil
+	}
+	// the requests sharing a response can modify it.
+	return cloneResponse(shared.resp)
+}
+
+// dedupKey returns the key of the request, or false when the request isn't
+// deduplicated.
+func dedupKey(ctx context.Context, req queryrangebase.Request) (string, bool) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return "", false
+	}
+
+	var sb strings.Builder
+	sb.WriteString(tenant.JoinTenantIDs(tenantIDs))
+	switch r := req.(type) {
+	case *LokiRequest:
+		if r.StoreChunks != nil {
+			return "", false
+		}
+		fmt.Fprintf(&sb, ":range:%s:%d:%d:%d:%d:%d:%s:%s:", r.Path, r.StartTs.UnixNano(), r.EndTs.UnixNano(), r.Step, r.Interval, r.Limit, r.Direction, strings.Join(r.Shards, ","))
+		sb.WriteString(dedupQuery(r.Query, r.Plan))
+	case *LokiInstantRequest:
+		if r.StoreChunks != nil {
+			return "", false
+		}
+		fmt.Fprintf(&sb, ":instant:%s:%d:%d:%d:%s:", r.Path, r.TimeTs.UnixNano(), r.Limit, r.Direction, strings.Join(r.Shards, ","))
+		sb.WriteString(dedupQuery(r.Query, r.Plan))
+	default:
+		return "", false
+	}
+
+	// the encoding flags and the limits of the request change its response.
+	flags := httpreq.ExtractEncodingFlagsFromCtx(ctx)
+	fmt.Fprintf(&sb, ":%s", flags.String())
+	if limits := querylimits.ExtractQueryLimitsContext(ctx); limits != nil {
+		b, err := querylimits.MarshalQueryLimits(limits)
+		if err != nil {
+			return "", false
+		}
+		fmt.Fprintf(&sb, ":%s", b)
+	}
+	return sb.String(), true
+}
+
+// dedupQuery returns the normalized query of the plan when possible, so that
+// equivalent queries are deduplicated.
+func dedupQuery(query string, p *plan.QueryPlan) string {
+	if p == nil || p.AST == nil {
+		return query
+	}
+	normalized, err := normalizeExpr(p.AST)
+	if err != nil {
+		return query
+	}
+	return normalized
+}
+
+// cloneResponse returns a deep copy of a response, through its protobuf
+// encoding.
+func cloneResponse(resp queryrangebase.Response) (queryrangebase.Response, error) {
+	wrapped, err := QueryResponseWrap(resp)
+	if err != nil {
+		return nil, err
+	}
+	b, err := wrapped.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	var cloned QueryResponse
+	if err := cloned.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	return QueryResponseUnwrap(&cloned)
+}
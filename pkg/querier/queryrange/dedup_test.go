@@ -0,0 +1,183 @@
+package queryrange
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/v3/pkg/querier/plan"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
+)
+
+func newDedupRequest(query string, start, end time.Time, step int64) *LokiRequest {
+	return &LokiRequest{
+		Query:     query,
+		StartTs:   start,
+		EndTs:     end,
+		Step:      step,
+		Limit:     100,
+		Direction: logproto.BACKWARD,
+		Path:      "/loki/api/v1/query_range",
+		Plan:      &plan.QueryPlan{AST: syntax.MustParseExpr(query)},
+	}
+}
+
+func Test_DedupMiddleware(t *testing.T) {
+	metrics := NewDedupMetrics(prometheus.NewRegistry(), "loki")
+
+	var statistics stats.Result
+	statistics.Summary.TotalBytesProcessed = 10
+	executions := atomic.NewInt32(0)
+	release := make(chan struct{})
+	next := queryrangebase.HandlerFunc(func(ctx context.Context, _ queryrangebase.Request) (queryrangebase.Response, error) {
+		executions.Inc()
+		<-release
+		if data, ok := ctx.Value(ctxKey).(*queryData); ok {
+			data.recorded = true
+			data.statistics = &statistics
+		}
+		return &LokiResponse{
+			Status:     "success",
+			Statistics: statistics,
+			Data: LokiData{
+				ResultType: "streams",
+				Result:     []logproto.Stream{{Labels: `{app="foo"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(1, 0), Line: "foo"}}}},
+			},
+		}, nil
+	})
+	h := NewDedupMiddleware(dedupLevelQuery, metrics).Wrap(next)
+
+	// the same query, with its matchers in another order.
+	queries := []string{`{app="foo", env="prod"} |= "foo"`, `{env="prod", app="foo"} |= "foo"`}
+	const n = 10
+	var (
+		wg    sync.WaitGroup
+		resps = make([]queryrangebase.Response, n)
+		datas = make([]*queryData, n)
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			datas[i] = &queryData{}
+			ctx := context.WithValue(user.InjectOrgID(context.Background(), "1"), ctxKey, datas[i])
+			req := newDedupRequest(queries[i%2], time.Unix(0, 0), time.Unix(3600, 0), 0)
+			resp, err := h.Do(ctx, req)
+			require.NoError(t, err)
+			resps[i] = resp
+		}(i)
+	}
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.requests.WithLabelValues(dedupLevelQuery)) == n
+	}, 5*time.Second, 10*time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, int32(1), executions.Load())
+	require.Equal(t, float64(n-1), testutil.ToFloat64(metrics.coalesced.WithLabelValues(dedupLevelQuery)))
+	for i := 0; i < n; i++ {
+		require.Equal(t, resps[0], resps[i])
+		require.True(t, datas[i].recorded)
+		require.Equal(t, int64(10), datas[i].statistics.Summary.TotalBytesProcessed)
+	}
+
+	// the responses are not shared.
+	resps[0].(*LokiResponse).Data.Result[0].Entries[0].Line = "bar"
+	require.Equal(t, "foo", resps[1].(*LokiResponse).Data.Result[0].Entries[0].Line)
+	datas[0].statistics.Summary.TotalBytesProcessed = 20
+	require.Equal(t, int64(10), datas[1].statistics.Summary.TotalBytesProcessed)
+}
+
+func Test_DedupMiddleware_CanceledExecution(t *testing.T) {
+	metrics := NewDedupMetrics(nil, "loki")
+
+	started := make(chan struct{})
+	executions := atomic.NewInt32(0)
+	next := queryrangebase.HandlerFunc(func(ctx context.Context, _ queryrangebase.Request) (queryrangebase.Response, error) {
+		if executions.Inc() == 1 {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return &LokiResponse{Status: "success"}, nil
+	})
+	h := NewDedupMiddleware(dedupLevelDownstream, metrics).Wrap(next)
+	req := newDedupRequest(`{app="foo"}`, time.Unix(0, 0), time.Unix(3600, 0), 0)
+
+	ctx, cancel := context.WithCancel(user.InjectOrgID(context.Background(), "1"))
+	errs := make(chan error)
+	go func() {
+		_, err := h.Do(ctx, req)
+		errs <- err
+	}()
+	<-started
+
+	resps := make(chan queryrangebase.Response)
+	go func() {
+		resp, err := h.Do(user.InjectOrgID(context.Background(), "1"), req)
+		require.NoError(t, err)
+		resps <- resp
+	}()
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.requests.WithLabelValues(dedupLevelDownstream)) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the request sharing the canceled execution executes the request itself.
+	cancel()
+	require.ErrorIs(t, <-errs, context.Canceled)
+	require.Equal(t, &LokiResponse{Status: "success"}, <-resps)
+	require.Equal(t, int32(2), executions.Load())
+}
+
+func Test_dedupKey(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "1")
+	start, end := time.Unix(0, 0), time.Unix(3600, 0)
+	req := newDedupRequest(`sum by (b, a) (rate({app="foo", env="prod"}[5m]))`, start, end, 60000)
+	key, ok := dedupKey(ctx, req)
+	require.True(t, ok)
+
+	equivalent, ok := dedupKey(ctx, newDedupRequest(`sum by (a, b) (rate({env="prod", app="foo"}[5m]))`, start, end, 60000))
+	require.True(t, ok)
+	require.Equal(t, key, equivalent)
+
+	sharded := *req
+	sharded.Shards = []string{"0_of_2"}
+	storeChunks := *req
+	storeChunks.StoreChunks = &logproto.ChunkRefGroup{}
+	for _, tc := range []struct {
+		name string
+		ctx  context.Context
+		req  queryrangebase.Request
+	}{
+		{"other tenant", user.InjectOrgID(context.Background(), "2"), req},
+		{"multiple tenants", user.InjectOrgID(context.Background(), "1|2"), req},
+		{"other query", ctx, newDedupRequest(`sum by (a) (rate({app="foo", env="prod"}[5m]))`, start, end, 60000)},
+		{"other range", ctx, newDedupRequest(req.Query, start, end.Add(time.Minute), 60000)},
+		{"other step", ctx, newDedupRequest(req.Query, start, end, 30000)},
+		{"other shard", ctx, &sharded},
+		{"instant", ctx, &LokiInstantRequest{Query: req.Query, TimeTs: end, Limit: 100, Direction: logproto.BACKWARD, Path: req.Path, Plan: req.Plan}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			other, ok := dedupKey(tc.ctx, tc.req)
+			require.True(t, ok)
+			require.NotEqual(t, key, other)
+		})
+	}
+
+	_, ok = dedupKey(ctx, &storeChunks)
+	require.False(t, ok)
+	_, ok = dedupKey(context.Background(), req)
+	require.False(t, ok)
+	_, ok = dedupKey(ctx, &LokiSeriesRequest{})
+	require.False(t, ok)
+}
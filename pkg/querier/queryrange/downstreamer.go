@@ -195,10 +195,12 @@ func (in instance) For(
 		close(ch)
 	}()
 
+	partials := newShardPartials(ctx, queries)
 	for resp := range ch {
 		if resp.Err != nil {
 			return nil, resp.Err
 		}
+		partials.add(resp.I, resp.Res)
 		if err := acc.Accumulate(ctx, resp.Res, resp.I); err != nil {
 			return nil, err
 		}
@@ -330,6 +330,50 @@ func TestInstanceFor(t *testing.T) {
 	ensureParallelism(t, in, in.parallelism)
 }
 
+func TestInstanceForPartialResults(t *testing.T) {
+	in := DownstreamHandler{
+		limits: fakeLimits{},
+		next:   nil,
+	}.Downstreamer(context.Background()).(*instance)
+
+	var queries []logql.DownstreamQuery
+	for i := 0; i < 3; i++ {
+		params, err := logql.NewLiteralParams(`{app="foo"}`, time.Unix(0, 0), time.Unix(10, 0), 0, 0, logproto.BACKWARD, 1000, nil, nil)
+		require.NoError(t, err)
+		queries = append(queries, logql.DownstreamQuery{
+			Params: logql.ParamsWithShardsOverride{
+				Params: params,
+				ShardsOverride: logql.Shards{
+					logql.NewPowerOfTwoShard(index.ShardAnnotation{Shard: uint32(i), Of: 4}),
+				}.Encode(),
+			},
+		})
+	}
+
+	var lines []string
+	ctx := WithPartialResults(context.Background(), func(p PartialResult) {
+		require.False(t, p.Final)
+		for _, s := range p.Response.(*LokiResponse).Data.Result {
+			lines = append(lines, s.Entries[0].Line)
+		}
+	})
+	_, err := in.For(ctx, queries, logql.NewBufferedAccumulator(len(queries)), func(qry logql.DownstreamQuery) (logqlmodel.Result, error) {
+		shard, err := strconv.Atoi(strings.Split(qry.Params.Shards()[0], "_")[0])
+		if err != nil {
+			return logqlmodel.Result{}, err
+		}
+		// the first shards complete last.
+		time.Sleep(time.Duration(len(queries)-shard) * 10 * time.Millisecond)
+		return logqlmodel.Result{
+			Data: logqlmodel.Streams{
+				{Labels: `{app="foo"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(int64(shard), 0), Line: strconv.Itoa(shard)}}},
+			},
+		}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"0", "1", "2"}, lines)
+}
+
 func TestParamsToLokiRequest(t *testing.T) {
 	// Usually, queryrangebase.Request converted into Params and passed to downstream engine
 	// And converted back to queryrangebase.Request from the params before executing those queries.
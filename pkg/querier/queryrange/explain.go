@@ -0,0 +1,586 @@
+package queryrange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/grafana/dskit/httpgrpc"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/grafana/loki/v3/pkg/logql"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	logqlstats "github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/v3/pkg/querier/macros"
+	"github.com/grafana/loki/v3/pkg/querier/plan"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
+	v1 "github.com/grafana/loki/v3/pkg/storage/bloom/v1"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/cache"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/cache/resultscache"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	"github.com/grafana/loki/v3/pkg/storage/stores/index/stats"
+	"github.com/grafana/loki/v3/pkg/util"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+	serverutil "github.com/grafana/loki/v3/pkg/util/server"
+	"github.com/grafana/loki/v3/pkg/util/validation"
+)
+
+// maxConcurrentExplainIndexReq is the number of index stats requests sent
+// concurrently for the matcher groups of an explained query.
+const maxConcurrentExplainIndexReq = 10
+
+// ExplainResponse is the response of the explain API.
+type ExplainResponse struct {
+	Status string      `json:"status"`
+	Data   Explanation `json:"data"`
+}
+
+// Explanation describes how the query frontend would execute a range query,
+// with the estimates of the index, without running it.
+type Explanation struct {
+	Query string `json:"query"`
+	// Plan is the query after it is mapped for sharding.
+	Plan          string                `json:"plan"`
+	SplitInterval model.Duration        `json:"splitInterval"`
+	Splits        int                   `json:"splits"`
+	Sharded       bool                  `json:"sharded"`
+	BytesPerShard uint64                `json:"bytesPerShard"`
+	Shards        []ExplainedShards     `json:"shards"`
+	IndexStats    []ExplainedIndexStats `json:"indexStats"`
+	Total         stats.Stats           `json:"total"`
+	Caches        []ExplainedCache      `json:"caches"`
+	// Rejected is the error the query would be rejected with, if any.
+	Rejected string `json:"rejected,omitempty"`
+}
+
+// ExplainedIndexStats are the index stats of a group of matchers of a query.
+type ExplainedIndexStats struct {
+	Matchers string      `json:"matchers"`
+	Stats    stats.Stats `json:"stats"`
+}
+
+// ExplainedShards are the shards of an expression mapped for sharding.
+type ExplainedShards struct {
+	Expr          string           `json:"expr"`
+	BytesPerShard uint64           `json:"bytesPerShard"`
+	Shards        []ExplainedShard `json:"shards"`
+}
+
+// ExplainedShard is a shard of an expression. The index stats of a shard are
+// only known for bounded shards.
+type ExplainedShard struct {
+	Shard string       `json:"shard"`
+	Stats *stats.Stats `json:"stats,omitempty"`
+}
+
+// ExplainedCache reports whether a cache of the query frontend is applicable
+// to the query, that is whether the query goes through it, and what the query
+// would find in it. The results caches are looked up for the splits of the
+// query with the keys the query would use, the index stats cache is queried
+// while explaining the query.
+type ExplainedCache struct {
+	Name             string `json:"name"`
+	Enabled          bool   `json:"enabled"`
+	Applicable       bool   `json:"applicable"`
+	EntriesFound     int32  `json:"entriesFound,omitempty"`
+	EntriesRequested int32  `json:"entriesRequested,omitempty"`
+	// MaxEntrySize is the maximum size of the cached results with entries of
+	// the log queries. Only their empty results are cached when it's 0.
+	MaxEntrySize int `json:"maxEntrySize,omitempty"`
+	// Splits are the lookups of the splits of the query in a results cache.
+	Splits []ExplainedCachedSplit `json:"splits,omitempty"`
+}
+
+// ExplainedCachedSplit is the lookup of a split of a query in a results cache.
+type ExplainedCachedSplit struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Fresh reports whether the split is too recent to be looked up, being
+	// within the max cache freshness.
+	Fresh bool `json:"fresh,omitempty"`
+	// Extents are the time ranges of the split with cached results.
+	Extents []ExplainedExtent `json:"extents,omitempty"`
+}
+
+// ExplainedExtent is a time range with cached results.
+type ExplainedExtent struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// ExplainHandler serves the explain API of the query frontend.
+type ExplainHandler struct {
+	cfg        Config
+	engineOpts logql.EngineOpts
+	iqo        util.IngesterQueryOptions
+	limits     Limits
+	schema     config.SchemaConfig
+	macros     macros.Store
+	next       queryrangebase.Handler
+	logger     log.Logger
+
+	resultsCache      cache.Cache
+	cacheGenNumLoader queryrangebase.CacheGenNumberLoader
+	retentionEnabled  bool
+}
+
+// NewExplainHandler creates an ExplainHandler. next handles the index stats and
+// shards requests of the explained queries, store expands the macros of the
+// queries when it isn't nil. resultsCache is the cache of the results of the
+// metric and log queries of the frontend, looked up for the splits of the
+// explained queries when it isn't nil.
+func NewExplainHandler(
+	cfg Config,
+	engineOpts logql.EngineOpts,
+	iqo util.IngesterQueryOptions,
+	limits Limits,
+	schema config.SchemaConfig,
+	store macros.Store,
+	resultsCache cache.Cache,
+	cacheGenNumLoader queryrangebase.CacheGenNumberLoader,
+	retentionEnabled bool,
+	next queryrangebase.Handler,
+	logger log.Logger,
+) *ExplainHandler {
+	if resultsCache != nil && cacheGenNumLoader != nil {
+		resultsCache = cache.NewCacheGenNumMiddleware(resultsCache)
+	}
+	return &ExplainHandler{
+		cfg:               cfg,
+		engineOpts:        engineOpts,
+		iqo:               iqo,
+		limits:            limits,
+		schema:            schema,
+		macros:            store,
+		next:              next,
+		logger:            logger,
+		resultsCache:      resultsCache,
+		cacheGenNumLoader: cacheGenNumLoader,
+		retentionEnabled:  retentionEnabled,
+	}
+}
+
+// ServeHTTP explains the range query of the request, which takes the same
+// parameters as a query_range request.
+func (h *ExplainHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		serverutil.WriteError(httpgrpc.Errorf(http.StatusBadRequest, err.Error()), w)
+		return
+	}
+	req, err := parseRangeQuery(r)
+	if err != nil {
+		serverutil.WriteError(httpgrpc.Errorf(http.StatusBadRequest, err.Error()), w)
+		return
+	}
+
+	explanation, err := h.Explain(r.Context(), req)
+	if err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ExplainResponse{Status: loghttp.QueryStatusSuccess, Data: *explanation}); err != nil {
+		level.Error(h.logger).Log("msg", "error marshalling response", "err", err)
+		http.Error(w, fmt.Sprintf("Error marshalling response: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// Explain explains how the query of req would be split, sharded and cached,
+// querying the index for the estimates of the bytes, chunks and streams read.
+func (h *ExplainHandler) Explain(ctx context.Context, req *LokiRequest) (*Explanation, error) {
+	logger := util_log.WithContext(ctx, h.logger)
+
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+
+	expr := req.Plan.AST
+	if h.macros != nil && syntax.HasMacros(expr) {
+		if expr, err = expandMacros(ctx, h.macros, expr); err != nil {
+			return nil, err
+		}
+		req = req.WithQuery(expr.String()).(*LokiRequest)
+		req.Plan = &plan.QueryPlan{AST: expr}
+	}
+
+	// the stats of the index stats cache are collected while explaining.
+	statsCtx, ctx := logqlstats.NewContext(ctx)
+
+	explanation := &Explanation{
+		Query: expr.String(),
+		Plan:  syntax.Prettify(expr),
+	}
+
+	if err := h.explainIndexStats(ctx, logger, tenantIDs, req, explanation); err != nil {
+		return nil, err
+	}
+	splits, err := h.explainSplits(tenantIDs, req, explanation)
+	if err != nil {
+		return nil, err
+	}
+	if h.shardable(expr) {
+		if err := h.explainShards(ctx, logger, tenantIDs, req, explanation); err != nil {
+			return nil, err
+		}
+	}
+	h.explainCaches(ctx, logger, tenantIDs, expr, splits, statsCtx.Caches(), explanation)
+
+	return explanation, nil
+}
+
+func (h *ExplainHandler) explainIndexStats(ctx context.Context, logger log.Logger, tenantIDs []string, req *LokiRequest, explanation *Explanation) error {
+	matcherGroups, err := syntax.MatcherGroups(req.Plan.AST)
+	if err != nil {
+		return httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+
+	results, err := getStatsForMatchers(ctx, logger, h.next, model.Time(req.GetStart().UnixMilli()), model.Time(req.GetEnd().UnixMilli()), matcherGroups, maxConcurrentExplainIndexReq, h.engineOpts.MaxLookBackPeriod)
+	if err != nil {
+		return err
+	}
+
+	explanation.IndexStats = make([]ExplainedIndexStats, 0, len(results))
+	for i, res := range results {
+		explanation.IndexStats = append(explanation.IndexStats, ExplainedIndexStats{
+			Matchers: syntax.MatchersString(matcherGroups[i].Matchers),
+			Stats:    *res,
+		})
+	}
+	explanation.Total = stats.MergeStats(results...)
+
+	maxQueryBytesRead := func(id string) int { return h.limits.MaxQueryBytesRead(ctx, id) }
+	if maxBytesRead := validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, maxQueryBytesRead); maxBytesRead > 0 && explanation.Total.Bytes > uint64(maxBytesRead) {
+		explanation.Rejected = fmt.Sprintf(limErrQueryTooManyBytesTmpl, humanize.IBytes(explanation.Total.Bytes), humanize.IBytes(uint64(maxBytesRead)))
+	}
+	return nil
+}
+
+// explainSplits splits the query as the split by interval middleware would,
+// returning the splits.
+func (h *ExplainHandler) explainSplits(tenantIDs []string, req *LokiRequest, explanation *Explanation) ([]queryrangebase.Request, error) {
+	interval := validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, h.limits.QuerySplitDuration)
+	explanation.SplitInterval = model.Duration(interval)
+	explanation.Splits = 1
+
+	var (
+		r queryrangebase.Request = req
+		s splitter               = newDefaultSplitter(h.limits, h.iqo)
+	)
+	if _, ok := req.Plan.AST.(syntax.SampleExpr); ok {
+		if h.cfg.AlignQueriesWithStep {
+			start := (req.GetStart().UnixMilli() / req.GetStep()) * req.GetStep()
+			end := (req.GetEnd().UnixMilli() / req.GetStep()) * req.GetStep()
+			r = req.WithStartEnd(time.UnixMilli(start), time.UnixMilli(end))
+		}
+		s = newMetricQuerySplitter(h.limits, h.iqo)
+	}
+	if interval == 0 {
+		return []queryrangebase.Request{r}, nil
+	}
+
+	splits, err := s.split(time.Now().UTC(), tenantIDs, r, interval)
+	if err != nil {
+		return nil, err
+	}
+	if len(splits) == 0 {
+		return []queryrangebase.Request{r}, nil
+	}
+	explanation.Splits = len(splits)
+	return splits, nil
+}
+
+// shardable reports whether the query goes through the sharding middleware:
+// log queries are only sharded when they have a filter.
+func (h *ExplainHandler) shardable(expr syntax.Expr) bool {
+	if !h.cfg.ShardedQueries || !hasShards(h.schema.Configs) {
+		return false
+	}
+	if e, ok := expr.(syntax.LogSelectorExpr); ok {
+		return e.HasFilter()
+	}
+	return true
+}
+
+// explainShards maps the query for sharding as the sharding middleware would,
+// recording the shards of the mapped expressions.
+func (h *ExplainHandler) explainShards(ctx context.Context, logger log.Logger, tenantIDs []string, req *LokiRequest, explanation *Explanation) error {
+	maxRVDuration, maxOffset, err := maxRangeVectorAndOffsetDuration(req.Plan.AST)
+	if err != nil {
+		level.Warn(logger).Log("msg", "failed to get range-vector and offset duration so skipped explaining shards", "err", err)
+		return nil
+	}
+
+	conf, err := ShardingConfigs(h.schema.Configs).GetConf(int64(model.Time(req.GetStart().UnixMilli()).Add(-maxRVDuration).Add(-maxOffset)), int64(model.Time(req.GetEnd().UnixMilli()).Add(-maxOffset)))
+	if err != nil {
+		// cannot shard with this timerange
+		return nil
+	}
+
+	resolver, ok := shardResolverForConf(
+		ctx,
+		conf,
+		h.engineOpts.MaxLookBackPeriod,
+		h.logger,
+		MinWeightedParallelism(ctx, tenantIDs, h.schema.Configs, h.limits, model.Time(req.GetStart().UnixMilli()), model.Time(req.GetEnd().UnixMilli())),
+		0, // 0 is unlimited shards
+		req,
+		h.next,
+		h.next,
+		h.limits,
+	)
+	if !ok {
+		return nil
+	}
+
+	version, err := logql.ParseShardVersion(h.limits.TSDBShardingStrategy(tenantIDs[0]))
+	if err != nil {
+		level.Warn(logger).Log("msg", "failed to parse shard version", "fallback", version.String(), "err", err.Error())
+	}
+	strategy := &explainStrategy{
+		ShardingStrategy: version.Strategy(resolver, uint64(h.limits.TSDBMaxBytesPerShard(tenantIDs[0]))),
+	}
+
+	noop, bytesPerShard, parsed, err := logql.NewShardMapper(strategy, logql.NewShardMapperMetrics(nil), h.cfg.ShardAggregations).Parse(req.Plan.AST)
+	if err != nil {
+		return httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+
+	explanation.Sharded = !noop
+	explanation.BytesPerShard = bytesPerShard
+	explanation.Shards = strategy.shards
+	if !noop {
+		explanation.Plan = syntax.Prettify(parsed)
+	}
+
+	// Note, even if noop, bytesPerShard contains the bytes that'd be read for the whole expr without sharding
+	maxQuerierBytesRead := func(id string) int { return h.limits.MaxQuerierBytesRead(ctx, id) }
+	if maxBytesRead := validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, maxQuerierBytesRead); maxBytesRead > 0 && bytesPerShard > uint64(maxBytesRead) && explanation.Rejected == "" {
+		errorTmpl := limErrQuerierTooManyBytesShardableTmpl
+		if noop {
+			errorTmpl = limErrQuerierTooManyBytesUnshardableTmpl
+		}
+		explanation.Rejected = fmt.Sprintf(errorTmpl, humanize.IBytes(bytesPerShard), humanize.IBytes(uint64(maxBytesRead)))
+	}
+	return nil
+}
+
+func (h *ExplainHandler) explainCaches(ctx context.Context, logger log.Logger, tenantIDs []string, expr syntax.Expr, splits []queryrangebase.Request, caches logqlstats.Caches, explanation *Explanation) {
+	var metric, filtered bool
+	switch e := expr.(type) {
+	case syntax.SampleExpr:
+		metric = true
+	case syntax.LogSelectorExpr:
+		filtered = e.HasFilter()
+	}
+
+	results := ExplainedCache{
+		Name:       "results",
+		Enabled:    h.cfg.CacheResults,
+		Applicable: h.cfg.CacheResults && metric,
+	}
+	logResults := ExplainedCache{
+		Name:         "log_results",
+		Enabled:      h.cfg.CacheResults,
+		Applicable:   h.cfg.CacheResults && filtered,
+		MaxEntrySize: h.cfg.logResultCacheMaxEntriesSize(),
+	}
+
+	if h.cacheGenNumLoader != nil && h.retentionEnabled {
+		ctx = cache.InjectCacheGenNumber(ctx, h.cacheGenNumLoader.GetResultsCacheGenNumber(tenantIDs))
+	}
+	cacheFreshnessCapture := func(id string) time.Duration { return h.limits.MaxCacheFreshness(ctx, id) }
+	maxCacheTime := time.Now().Add(-validation.MaxDurationPerTenant(tenantIDs, cacheFreshnessCapture))
+
+	if results.Applicable && h.resultsCache != nil {
+		keyGen := cacheKeyLimits{h.limits, h.cfg.Transformer, h.iqo}
+		for _, split := range splits {
+			lookup := ExplainedCachedSplit{Start: split.GetStart(), End: split.GetEnd()}
+			if split.GetStart().After(maxCacheTime) {
+				lookup.Fresh = true
+			} else {
+				lookup.Extents = h.cachedExtents(ctx, logger, keyGen.GenerateCacheKey(ctx, tenant.JoinTenantIDs(tenantIDs), split.(*LokiRequest)), split)
+			}
+			results.addSplit(lookup)
+		}
+	}
+
+	// log queries without a split interval or a limit aren't cached.
+	interval := validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, h.limits.QuerySplitDuration)
+	if logResults.Applicable && (interval == 0 || len(splits) == 0 || splits[0].(*LokiRequest).Limit == 0) {
+		logResults.Applicable = false
+	}
+	if logResults.Applicable && h.resultsCache != nil {
+		transformedTenantIDs := transformTenantIDs(ctx, h.cfg.Transformer, tenantIDs)
+		for _, split := range splits {
+			lookup := ExplainedCachedSplit{Start: split.GetStart(), End: split.GetEnd()}
+			if split.GetEnd().After(maxCacheTime) {
+				lookup.Fresh = true
+			} else {
+				key := logResultCacheKey(transformedTenantIDs, split.GetQuery(), interval, split.GetStart())
+				lookup.Extents = h.cachedLogExtents(ctx, logger, key, split.(*LokiRequest))
+			}
+			logResults.addSplit(lookup)
+		}
+	}
+
+	explanation.Caches = []ExplainedCache{
+		results,
+		logResults,
+		{
+			Name:             "index_stats",
+			Enabled:          h.cfg.CacheIndexStatsResults,
+			Applicable:       h.cfg.CacheIndexStatsResults,
+			EntriesFound:     caches.StatsResult.EntriesFound,
+			EntriesRequested: caches.StatsResult.EntriesRequested,
+		},
+	}
+}
+
+// addSplit adds the lookup of a split, which is found when it has cached
+// results.
+func (c *ExplainedCache) addSplit(lookup ExplainedCachedSplit) {
+	c.Splits = append(c.Splits, lookup)
+	if lookup.Fresh {
+		return
+	}
+	c.EntriesRequested++
+	if len(lookup.Extents) > 0 {
+		c.EntriesFound++
+	}
+}
+
+// cachedExtents returns the extents of the results cache overlapping the split
+// of a metric query.
+func (h *ExplainHandler) cachedExtents(ctx context.Context, logger log.Logger, key string, split queryrangebase.Request) []ExplainedExtent {
+	found, bufs, _, err := h.resultsCache.Fetch(ctx, []string{cache.HashKey(key)})
+	if err != nil {
+		level.Warn(logger).Log("msg", "error fetching cache", "err", err, "cacheKey", key)
+		return nil
+	}
+	if len(found) != 1 {
+		return nil
+	}
+
+	var cached resultscache.CachedResponse
+	if err := proto.Unmarshal(bufs[0], &cached); err != nil {
+		level.Warn(logger).Log("msg", "error unmarshalling cached value", "err", err)
+		return nil
+	}
+	if cached.Key != key {
+		return nil
+	}
+
+	var extents []ExplainedExtent
+	start, end := split.GetStart().UnixMilli(), split.GetEnd().UnixMilli()
+	for _, extent := range cached.Extents {
+		if extent.GetEnd() < start || extent.GetStart() > end {
+			continue
+		}
+		extents = append(extents, ExplainedExtent{
+			Start: time.UnixMilli(max(extent.GetStart(), start)).UTC(),
+			End:   time.UnixMilli(min(extent.GetEnd(), end)).UTC(),
+		})
+	}
+	return extents
+}
+
+// cachedLogExtents returns the extent of the log results cache overlapping the
+// split of a log query, which is either cached as empty or with its entries.
+func (h *ExplainHandler) cachedLogExtents(ctx context.Context, logger log.Logger, key string, split *LokiRequest) []ExplainedExtent {
+	_, bufs, _, err := h.resultsCache.Fetch(ctx, []string{cache.HashKey(key)})
+	if err != nil {
+		level.Warn(logger).Log("msg", "error fetching cache", "err", err, "cacheKey", key)
+		return nil
+	}
+
+	var start, end time.Time
+	switch {
+	case len(bufs) == 1:
+		var cached LokiRequest
+		if err := proto.Unmarshal(bufs[0], &cached); err != nil {
+			level.Warn(logger).Log("msg", "error unmarshalling request from cache", "err", err)
+			return nil
+		}
+		start, end = cached.StartTs, cached.EndTs
+	case h.cfg.logResultCacheMaxEntriesSize() > 0:
+		entriesKey := entriesCacheKey(key, split)
+		_, bufs, _, err := h.resultsCache.Fetch(ctx, []string{cache.HashKey(entriesKey)})
+		if err != nil {
+			level.Warn(logger).Log("msg", "error fetching cache", "err", err, "cacheKey", entriesKey)
+			return nil
+		}
+		if len(bufs) != 1 {
+			return nil
+		}
+		var (
+			extent resultscache.Extent
+			cached LokiResponse
+		)
+		if err := proto.Unmarshal(bufs[0], &extent); err != nil {
+			level.Warn(logger).Log("msg", "error unmarshalling entries from cache", "err", err)
+			return nil
+		}
+		if err := types.UnmarshalAny(extent.Response, &cached); err != nil {
+			level.Warn(logger).Log("msg", "error unmarshalling entries from cache", "err", err)
+			return nil
+		}
+		start, end = time.Unix(0, extent.Start), time.Unix(0, extent.End)
+		// truncated entries are only used for their own time range.
+		if !isComplete(&cached) && (!start.Equal(split.StartTs) || !end.Equal(split.EndTs)) {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	if !overlap(split.StartTs, split.EndTs, start, end) {
+		return nil
+	}
+	return []ExplainedExtent{{
+		Start: maxTime(split.StartTs, start).UTC(),
+		End:   minTime(split.EndTs, end).UTC(),
+	}}
+}
+
+// explainStrategy is a sharding strategy recording the shards of the
+// expressions it shards.
+type explainStrategy struct {
+	logql.ShardingStrategy
+	shards []ExplainedShards
+}
+
+func (s *explainStrategy) Shards(expr syntax.Expr) ([]logql.ShardWithChunkRefs, uint64, error) {
+	shards, bytesPerShard, err := s.ShardingStrategy.Shards(expr)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	explained := ExplainedShards{
+		Expr:          expr.String(),
+		BytesPerShard: bytesPerShard,
+		Shards:        make([]ExplainedShard, 0, len(shards)),
+	}
+	for _, shard := range shards {
+		if shard.Bounded != nil {
+			explained.Shards = append(explained.Shards, ExplainedShard{
+				Shard: v1.BoundsFromProto(shard.Bounded.Bounds).String(),
+				Stats: shard.Bounded.Stats,
+			})
+			continue
+		}
+		explained.Shards = append(explained.Shards, ExplainedShard{Shard: shard.String()})
+	}
+	s.shards = append(s.shards, explained)
+	return shards, bytesPerShard, nil
+}
@@ -0,0 +1,223 @@
+package queryrange
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql"
+	"github.com/grafana/loki/v3/pkg/querier/macros"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/cache"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	valid "github.com/grafana/loki/v3/pkg/validation"
+)
+
+func TestExplainHandler(t *testing.T) {
+	store := macros.NewBucketStore(objstore.NewInMemBucket())
+	require.NoError(t, store.Set(context.Background(), "1", "errors", `| logfmt | level="error"`))
+
+	// every group of matchers reads 4 times the max bytes of a shard.
+	var statsRequests int
+	next := queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+		_, ok := r.(*logproto.IndexStatsRequest)
+		require.True(t, ok, "unexpected request %T", r)
+		statsRequests++
+		return &IndexStatsResponse{Response: &logproto.IndexStatsResponse{
+			Streams: 2,
+			Chunks:  10,
+			Bytes:   4 * valid.DefaultTSDBMaxBytesPerShard,
+			Entries: 100,
+		}}, nil
+	})
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := Config{Config: queryrangebase.Config{ShardedQueries: true, CacheResults: true}}
+	resultsCache := cache.NewMockCache()
+	explain := func(limits Limits, query string) *Explanation {
+		h := NewExplainHandler(
+			cfg,
+			logql.EngineOpts{},
+			nil,
+			limits,
+			config.SchemaConfig{Configs: testSchemasTSDB},
+			store,
+			resultsCache,
+			nil,
+			false,
+			next,
+			log.NewNopLogger(),
+		)
+
+		params := url.Values{
+			"query": []string{query},
+			"start": []string{start.Format(time.RFC3339)},
+			"end":   []string{start.Add(2 * time.Hour).Format(time.RFC3339)},
+			"step":  []string{"60"},
+		}
+		req := httptest.NewRequest(http.MethodGet, "/loki/api/v1/explain?"+params.Encode(), nil)
+		req = req.WithContext(user.InjectOrgID(context.Background(), "1"))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp ExplainResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Equal(t, "success", resp.Status)
+		return &resp.Data
+	}
+
+	indexStats := logproto.IndexStatsResponse{Streams: 2, Chunks: 10, Bytes: 4 * valid.DefaultTSDBMaxBytesPerShard, Entries: 100}
+
+	t.Run("sharded metric query", func(t *testing.T) {
+		statsRequests = 0
+		limits := fakeLimits{splitDuration: map[string]time.Duration{"1": time.Hour}, maxQueryParallelism: 1, tsdbMaxQueryParallelism: 1}
+		res := explain(limits, `sum by (app) (count_over_time({app="foo"} | @errors() [5m]))`)
+
+		require.Equal(t, `sum by (app)(count_over_time({app="foo"} | logfmt | level="error"[5m]))`, res.Query)
+		require.Equal(t, model.Duration(time.Hour), res.SplitInterval)
+		require.Equal(t, 2, res.Splits)
+		require.True(t, res.Sharded)
+		require.Equal(t, uint64(valid.DefaultTSDBMaxBytesPerShard), res.BytesPerShard)
+		require.Contains(t, res.Plan, "shard=0_of_4")
+		require.Equal(t, []ExplainedIndexStats{{Matchers: `{app="foo"}`, Stats: indexStats}}, res.IndexStats)
+		require.Equal(t, indexStats, res.Total)
+		require.Equal(t, []ExplainedShards{{
+			Expr:          `sum by (app)(count_over_time({app="foo"} | logfmt | level="error"[5m]))`,
+			BytesPerShard: valid.DefaultTSDBMaxBytesPerShard,
+			Shards:        []ExplainedShard{{Shard: "0_of_4"}, {Shard: "1_of_4"}, {Shard: "2_of_4"}, {Shard: "3_of_4"}},
+		}}, res.Shards)
+		require.Equal(t, []ExplainedCache{
+			{Name: "results", Enabled: true, Applicable: true, EntriesRequested: 2, Splits: []ExplainedCachedSplit{
+				{Start: start, End: start.Add(time.Hour - time.Minute)},
+				{Start: start.Add(time.Hour), End: start.Add(2 * time.Hour)},
+			}},
+			{Name: "log_results", Enabled: true},
+			{Name: "index_stats"},
+		}, res.Caches)
+		require.Empty(t, res.Rejected)
+		require.Equal(t, 2, statsRequests)
+	})
+
+	t.Run("cached metric query", func(t *testing.T) {
+		limits := fakeLimits{splitDuration: map[string]time.Duration{"1": time.Hour}, maxQueryParallelism: 1, tsdbMaxQueryParallelism: 1}
+		query := `sum by (app) (count_over_time({app="foo"} |= "cached" [5m]))`
+		res := explain(limits, query)
+		require.Equal(t, int32(0), res.Caches[0].EntriesFound)
+
+		// the first split of the query is cached by the results cache.
+		mw, err := queryrangebase.NewResultsCacheMiddleware(
+			log.NewNopLogger(),
+			resultsCache,
+			cacheKeyLimits{limits, nil, nil},
+			limits,
+			DefaultCodec,
+			PrometheusExtractor{},
+			nil,
+			nil,
+			func(context.Context, []string, queryrangebase.Request) int { return 1 },
+			false,
+			false,
+			nil,
+		)
+		require.NoError(t, err)
+		split := res.Caches[0].Splits[0]
+		_, err = mw.Wrap(queryrangebase.HandlerFunc(func(context.Context, queryrangebase.Request) (queryrangebase.Response, error) {
+			return &LokiPromResponse{Response: &queryrangebase.PrometheusResponse{
+				Status: "success",
+				Data:   queryrangebase.PrometheusData{ResultType: "matrix"},
+			}}, nil
+		})).Do(user.InjectOrgID(context.Background(), "1"), &LokiRequest{
+			Query:   query,
+			Step:    time.Minute.Milliseconds(),
+			StartTs: split.Start,
+			EndTs:   split.End,
+		})
+		require.NoError(t, err)
+
+		res = explain(limits, query)
+		require.Equal(t, int32(1), res.Caches[0].EntriesFound)
+		require.Equal(t, int32(2), res.Caches[0].EntriesRequested)
+		require.Equal(t, []ExplainedExtent{{Start: split.Start, End: split.End}}, res.Caches[0].Splits[0].Extents)
+		require.Empty(t, res.Caches[0].Splits[1].Extents)
+	})
+
+	t.Run("cached log query", func(t *testing.T) {
+		limits := fakeLimits{splitDuration: map[string]time.Duration{"1": time.Hour}, maxQueryParallelism: 1, tsdbMaxQueryParallelism: 1}
+		query := `{app="foo"} |= "cached"`
+		res := explain(limits, query)
+		require.True(t, res.Caches[1].Applicable)
+		require.Equal(t, int32(0), res.Caches[1].EntriesFound)
+
+		// the second split of the query is cached as empty by the log results cache.
+		split := res.Caches[1].Splits[1]
+		req := &LokiRequest{Query: query, Limit: 100, StartTs: split.Start, EndTs: split.End}
+		_, err := NewLogResultCache(log.NewNopLogger(), limits, resultsCache, nil, nil, nil, false, 0, nil).
+			Wrap(queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+				return emptyResponse(r.(*LokiRequest)), nil
+			})).Do(user.InjectOrgID(context.Background(), "1"), req)
+		require.NoError(t, err)
+
+		res = explain(limits, query)
+		require.Equal(t, int32(1), res.Caches[1].EntriesFound)
+		require.Equal(t, int32(2), res.Caches[1].EntriesRequested)
+		require.Empty(t, res.Caches[1].Splits[0].Extents)
+		require.Equal(t, []ExplainedExtent{{Start: split.Start, End: split.End}}, res.Caches[1].Splits[1].Extents)
+	})
+
+	t.Run("log query without filter", func(t *testing.T) {
+		statsRequests = 0
+		res := explain(fakeLimits{maxQueryParallelism: 1, tsdbMaxQueryParallelism: 1}, `{app="foo"} | logfmt`)
+
+		require.Equal(t, `{app="foo"} | logfmt`, res.Plan)
+		require.Equal(t, model.Duration(0), res.SplitInterval)
+		require.Equal(t, 1, res.Splits)
+		require.False(t, res.Sharded)
+		require.Empty(t, res.Shards)
+		require.Equal(t, []ExplainedCache{
+			{Name: "results", Enabled: true},
+			{Name: "log_results", Enabled: true},
+			{Name: "index_stats"},
+		}, res.Caches)
+		require.Equal(t, 1, statsRequests)
+	})
+
+	t.Run("log query with cached entries", func(t *testing.T) {
+		cfg.CacheLogResultEntries = true
+		cfg.LogResultCacheMaxEntrySize = 1 << 20
+		defer func() { cfg.CacheLogResultEntries = false }()
+
+		limits := fakeLimits{splitDuration: map[string]time.Duration{"1": 2 * time.Hour}, maxQueryParallelism: 1, tsdbMaxQueryParallelism: 1}
+		res := explain(limits, `{app="foo"} |= "error"`)
+		require.Equal(t, ExplainedCache{
+			Name:             "log_results",
+			Enabled:          true,
+			Applicable:       true,
+			EntriesRequested: 1,
+			MaxEntrySize:     1 << 20,
+			Splits:           []ExplainedCachedSplit{{Start: start, End: start.Add(2 * time.Hour)}},
+		}, res.Caches[1])
+	})
+
+	t.Run("rejected query", func(t *testing.T) {
+		limits := fakeLimits{maxQueryParallelism: 1, tsdbMaxQueryParallelism: 1, maxQuerierBytesRead: valid.DefaultTSDBMaxBytesPerShard / 2}
+		res := explain(limits, `{app="foo"} |= "error"`)
+		require.True(t, res.Sharded)
+		require.Equal(t, "shard query is too large to execute on a single querier: (query: 600 MiB, limit: 300 MiB); consider adding more specific stream selectors or reduce the time range of the query", res.Rejected)
+
+		limits.maxQueryBytesRead = valid.DefaultTSDBMaxBytesPerShard
+		res = explain(limits, `{app="foo"} |= "error"`)
+		require.Equal(t, "the query would read too many bytes (query: 2.3 GiB, limit: 600 MiB); consider adding more specific stream selectors or reduce the time range of the query", res.Rejected)
+	})
+}
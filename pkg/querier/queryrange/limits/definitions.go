@@ -37,3 +37,12 @@ type Limits interface {
 	MaxMetadataCacheFreshness(context.Context, string) time.Duration
 	VolumeEnabled(string) bool
 }
+
+// CostBudgetLimits are the limits of the query cost budgets of the tenants.
+type CostBudgetLimits interface {
+	QueryCostBudgetWindow(string) time.Duration
+	QueryCostBudgetBytes(string) int
+	QueryCostBudgetChunks(string) int
+	QueryCostBudgetExecTime(string) time.Duration
+	QueryCostBudgetAction(string) string
+}
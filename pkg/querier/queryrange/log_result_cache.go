@@ -9,6 +9,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
 	"github.com/grafana/dskit/httpgrpc"
 	"github.com/grafana/dskit/tenant"
 	"github.com/opentracing/opentracing-go"
@@ -22,6 +23,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/cache"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/cache/resultscache"
 	"github.com/grafana/loki/v3/pkg/util/constants"
 	"github.com/grafana/loki/v3/pkg/util/validation"
 )
@@ -47,34 +49,47 @@ func NewLogResultCacheMetrics(registerer prometheus.Registerer) *LogResultCacheM
 }
 
 // NewLogResultCache creates a new log result cache middleware.
-// Currently it only caches empty filter queries, this is because those are usually easily and freely cacheable.
-// Log hits are difficult to handle because of the limit query parameter and the size of the response.
-// In the future it could be extended to cache non-empty query results.
+// It caches empty filter queries, this is because those are usually easily and freely cacheable.
+// Log hits are difficult to handle because of the limit query parameter and the size of the response,
+// so they are only cached when maxEntriesSize is positive, keyed by the direction and limit of the query,
+// and when their encoded size is at most maxEntriesSize bytes.
+// Cached results are invalidated by delete requests through the results cache generation number of the
+// tenant, when retention is enabled.
 // see https://docs.google.com/document/d/1_mACOpxdWZ5K0cIedaja5gzMbv-m0lUVazqZd2O4mEU/edit
-func NewLogResultCache(logger log.Logger, limits Limits, cache cache.Cache, shouldCache queryrangebase.ShouldCacheFn,
-	transformer UserIDTransformer, metrics *LogResultCacheMetrics) queryrangebase.Middleware {
+func NewLogResultCache(logger log.Logger, limits Limits, c cache.Cache, shouldCache queryrangebase.ShouldCacheFn,
+	transformer UserIDTransformer, cacheGenNumberLoader queryrangebase.CacheGenNumberLoader, retentionEnabled bool,
+	maxEntriesSize int, metrics *LogResultCacheMetrics) queryrangebase.Middleware {
 	if metrics == nil {
 		metrics = NewLogResultCacheMetrics(nil)
 	}
+	if cacheGenNumberLoader != nil {
+		c = cache.NewCacheGenNumMiddleware(c)
+	}
 	return queryrangebase.MiddlewareFunc(func(next queryrangebase.Handler) queryrangebase.Handler {
 		return &logResultCache{
-			next:        next,
-			limits:      limits,
-			cache:       cache,
-			logger:      logger,
-			shouldCache: shouldCache,
-			transformer: transformer,
-			metrics:     metrics,
+			next:                 next,
+			limits:               limits,
+			cache:                c,
+			logger:               logger,
+			shouldCache:          shouldCache,
+			transformer:          transformer,
+			cacheGenNumberLoader: cacheGenNumberLoader,
+			retentionEnabled:     retentionEnabled,
+			maxEntriesSize:       maxEntriesSize,
+			metrics:              metrics,
 		}
 	})
 }
 
 type logResultCache struct {
-	next        queryrangebase.Handler
-	limits      Limits
-	cache       cache.Cache
-	shouldCache queryrangebase.ShouldCacheFn
-	transformer UserIDTransformer
+	next                 queryrangebase.Handler
+	limits               Limits
+	cache                cache.Cache
+	shouldCache          queryrangebase.ShouldCacheFn
+	transformer          UserIDTransformer
+	cacheGenNumberLoader queryrangebase.CacheGenNumberLoader
+	retentionEnabled     bool
+	maxEntriesSize       int
 
 	metrics *LogResultCacheMetrics
 	logger  log.Logger
@@ -92,6 +107,10 @@ func (l *logResultCache) Do(ctx context.Context, req queryrangebase.Request) (qu
 		return l.next.Do(ctx, req)
 	}
 
+	if l.cacheGenNumberLoader != nil && l.retentionEnabled {
+		ctx = cache.InjectCacheGenNumber(ctx, l.cacheGenNumberLoader.GetResultsCacheGenNumber(tenantIDs))
+	}
+
 	cacheFreshnessCapture := func(id string) time.Duration { return l.limits.MaxCacheFreshness(ctx, id) }
 	maxCacheFreshness := validation.MaxDurationPerTenant(tenantIDs, cacheFreshnessCapture)
 	maxCacheTime := int64(model.Now().Add(-maxCacheFreshness))
@@ -110,20 +129,8 @@ func (l *logResultCache) Do(ctx context.Context, req queryrangebase.Request) (qu
 	if interval == 0 || lokiReq.Limit == 0 {
 		return l.next.Do(ctx, req)
 	}
-	// The first subquery might not be aligned.
-	alignedStart := time.Unix(0, lokiReq.GetStartTs().UnixNano()-(lokiReq.GetStartTs().UnixNano()%interval.Nanoseconds()))
 	// generate the cache key based on query, tenant and start time.
-
-	transformedTenantIDs := tenantIDs
-	if l.transformer != nil {
-		transformedTenantIDs = make([]string, 0, len(tenantIDs))
-
-		for _, tenantID := range tenantIDs {
-			transformedTenantIDs = append(transformedTenantIDs, l.transformer(ctx, tenantID))
-		}
-	}
-
-	cacheKey := fmt.Sprintf("log:%s:%s:%d:%d", tenant.JoinTenantIDs(transformedTenantIDs), req.GetQuery(), interval.Nanoseconds(), alignedStart.UnixNano()/(interval.Nanoseconds()))
+	cacheKey := logResultCacheKey(transformTenantIDs(ctx, l.transformer, tenantIDs), req.GetQuery(), interval, lokiReq.GetStartTs())
 
 	_, buff, _, err := l.cache.Fetch(ctx, []string{cache.HashKey(cacheKey)})
 	if err != nil {
@@ -138,6 +145,9 @@ func (l *logResultCache) Do(ctx context.Context, req queryrangebase.Request) (qu
 
 	if len(buff) == 0 {
 		// cache miss
+		if l.maxEntriesSize > 0 {
+			return l.handleEntries(ctx, cacheKey, lokiReq)
+		}
 		return l.handleMiss(ctx, cacheKey, lokiReq)
 	}
 
@@ -151,6 +161,26 @@ func (l *logResultCache) Do(ctx context.Context, req queryrangebase.Request) (qu
 	return l.handleHit(ctx, cacheKey, &cachedRequest, lokiReq)
 }
 
+// transformTenantIDs returns the tenant IDs transformed for the cache keys.
+func transformTenantIDs(ctx context.Context, transformer UserIDTransformer, tenantIDs []string) []string {
+	if transformer == nil {
+		return tenantIDs
+	}
+	transformed := make([]string, 0, len(tenantIDs))
+	for _, tenantID := range tenantIDs {
+		transformed = append(transformed, transformer(ctx, tenantID))
+	}
+	return transformed
+}
+
+// logResultCacheKey returns the key of the cached result of the split of a log
+// query starting at start.
+func logResultCacheKey(tenantIDs []string, query string, interval time.Duration, start time.Time) string {
+	// The first subquery might not be aligned.
+	alignedStart := time.Unix(0, start.UnixNano()-(start.UnixNano()%interval.Nanoseconds()))
+	return fmt.Sprintf("log:%s:%s:%d:%d", tenant.JoinTenantIDs(tenantIDs), query, interval.Nanoseconds(), alignedStart.UnixNano()/(interval.Nanoseconds()))
+}
+
 func (l *logResultCache) handleMiss(ctx context.Context, cacheKey string, req *LokiRequest) (queryrangebase.Response, error) {
 	l.metrics.CacheMiss.Inc()
 	level.Debug(l.logger).Log("msg", "cache miss", "key", cacheKey)
@@ -162,8 +192,9 @@ func (l *logResultCache) handleMiss(ctx context.Context, cacheKey string, req *L
 	if !ok {
 		return nil, fmt.Errorf("unexpected response type %T", resp)
 	}
-	// At the moment we only cache empty results
+	// Non-empty results are cached with their entries.
 	if !isEmpty(lokiRes) {
+		l.storeEntries(ctx, cacheKey, req, lokiRes)
 		return resp, nil
 	}
 	data, err := proto.Marshal(req)
@@ -296,6 +327,142 @@ func (l *logResultCache) handleHit(ctx context.Context, cacheKey string, cachedR
 	return result, nil
 }
 
+// entriesCacheKey returns the key of the cached entries of a split, which
+// depend on the direction and limit of the query.
+func entriesCacheKey(cacheKey string, req *LokiRequest) string {
+	return fmt.Sprintf("%s:entries:%s:%d", cacheKey, req.Direction, req.Limit)
+}
+
+// isComplete returns whether a response holds every entry of its time range,
+// not having been truncated to its limit.
+func isComplete(lokiRes *LokiResponse) bool {
+	return lokiRes.Count() < int64(lokiRes.Limit)
+}
+
+// handleEntries handles a request whose result isn't cached as empty, using
+// the cached entries of its split when they cover it.
+func (l *logResultCache) handleEntries(ctx context.Context, cacheKey string, req *LokiRequest) (queryrangebase.Response, error) {
+	key := entriesCacheKey(cacheKey, req)
+	_, buff, _, err := l.cache.Fetch(ctx, []string{cache.HashKey(key)})
+	if err != nil {
+		level.Warn(l.logger).Log("msg", "error fetching cache", "err", err, "cacheKey", key)
+		return l.next.Do(ctx, req)
+	}
+	if len(buff) != 1 {
+		return l.handleMiss(ctx, cacheKey, req)
+	}
+
+	var extent resultscache.Extent
+	cached := &LokiResponse{}
+	if err := proto.Unmarshal(buff[0], &extent); err != nil {
+		level.Warn(l.logger).Log("msg", "error unmarshalling entries from cache", "err", err)
+		return l.next.Do(ctx, req)
+	}
+	if err := types.UnmarshalAny(extent.Response, cached); err != nil {
+		level.Warn(l.logger).Log("msg", "error unmarshalling entries from cache", "err", err)
+		return l.next.Do(ctx, req)
+	}
+	cachedStart, cachedEnd := time.Unix(0, extent.Start), time.Unix(0, extent.End)
+	// the statistics of the cached result are those of another query.
+	cached.Statistics = stats.Result{}
+
+	switch {
+	case cachedStart.Equal(req.StartTs) && cachedEnd.Equal(req.EndTs):
+		l.metrics.CacheHit.Inc()
+		return cached, nil
+	case !isComplete(cached) || !overlap(req.StartTs, req.EndTs, cachedStart, cachedEnd):
+		// truncated entries are only those of their time range.
+		return l.handleMiss(ctx, cacheKey, req)
+	}
+	l.metrics.CacheHit.Inc()
+
+	// the cached entries are all the entries of their time range, so only
+	// what is missing at the start and the end is fetched.
+	var (
+		startResp, endResp *LokiResponse
+		g, gctx            = errgroup.WithContext(ctx)
+	)
+	if req.StartTs.Before(cachedStart) {
+		g.Go(func() error {
+			var err error
+			startResp, err = l.doLokiRequest(gctx, req.WithStartEnd(req.StartTs, cachedStart))
+			return err
+		})
+	}
+	if req.EndTs.After(cachedEnd) {
+		g.Go(func() error {
+			var err error
+			endResp, err = l.doLokiRequest(gctx, req.WithStartEnd(cachedEnd, req.EndTs))
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	responses := []queryrangebase.Response{extractLokiResponse(maxTime(req.StartTs, cachedStart), minTime(req.EndTs, cachedEnd), cached)}
+	extended := []queryrangebase.Response{cached}
+	for _, resp := range []*LokiResponse{startResp, endResp} {
+		if resp == nil {
+			continue
+		}
+		if resp.Status != loghttp.QueryStatusSuccess {
+			return resp, nil
+		}
+		responses = append(responses, resp)
+		extended = append(extended, resp)
+	}
+	result := mergeLokiResponse(responses...)
+
+	// the cached entries are extended with the fetched ones, as long as they
+	// are all the entries of the extended time range.
+	if merged := mergeLokiResponse(extended...); len(extended) > 1 && isComplete(merged) {
+		l.storeEntries(ctx, cacheKey, req.WithStartEnd(minTime(req.StartTs, cachedStart), maxTime(req.EndTs, cachedEnd)).(*LokiRequest), merged)
+	}
+	return result, nil
+}
+
+func (l *logResultCache) doLokiRequest(ctx context.Context, req queryrangebase.Request) (*LokiResponse, error) {
+	resp, err := l.next.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	lokiRes, ok := resp.(*LokiResponse)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response type %T", resp)
+	}
+	return lokiRes, nil
+}
+
+// storeEntries caches the entries of a successful response, unless they are
+// larger than the limit.
+func (l *logResultCache) storeEntries(ctx context.Context, cacheKey string, req *LokiRequest, lokiRes *LokiResponse) {
+	if l.maxEntriesSize <= 0 || lokiRes.Status != loghttp.QueryStatusSuccess {
+		return
+	}
+	anyResp, err := types.MarshalAny(lokiRes)
+	if err != nil {
+		level.Warn(l.logger).Log("msg", "error marshalling entries", "err", err)
+		return
+	}
+	data, err := proto.Marshal(&resultscache.Extent{
+		Start:    req.StartTs.UnixNano(),
+		End:      req.EndTs.UnixNano(),
+		Response: anyResp,
+	})
+	if err != nil {
+		level.Warn(l.logger).Log("msg", "error marshalling entries", "err", err)
+		return
+	}
+	if len(data) > l.maxEntriesSize {
+		return
+	}
+	key := entriesCacheKey(cacheKey, req)
+	if err := l.cache.Store(ctx, []string{cache.HashKey(key)}, [][]byte{data}); err != nil {
+		level.Warn(l.logger).Log("msg", "error storing cache", "err", err)
+	}
+}
+
 // extractLokiResponse extracts response with interval [start, end)
 func extractLokiResponse(start, end time.Time, r *LokiResponse) *LokiResponse {
 	extractedResp := LokiResponse{
@@ -312,7 +479,14 @@ func extractLokiResponse(start, end time.Time, r *LokiResponse) *LokiResponse {
 		},
 	}
 	for _, stream := range r.Data.Result {
-		if stream.Entries[0].Timestamp.After(end) || stream.Entries[len(stream.Entries)-1].Timestamp.Before(start) {
+		if len(stream.Entries) == 0 {
+			continue
+		}
+		oldest, newest := stream.Entries[0].Timestamp, stream.Entries[len(stream.Entries)-1].Timestamp
+		if r.Direction == logproto.BACKWARD {
+			oldest, newest = newest, oldest
+		}
+		if oldest.After(end) || newest.Before(start) {
 			continue
 		}
 
@@ -328,6 +502,9 @@ func extractLokiResponse(start, end time.Time, r *LokiResponse) *LokiResponse {
 
 			extractedStream.Entries = append(extractedStream.Entries, entry)
 		}
+		if len(extractedStream.Entries) == 0 {
+			continue
+		}
 
 		extractedResp.Data.Result = append(extractedResp.Data.Result, extractedStream)
 	}
@@ -360,3 +537,17 @@ func overlap(aFrom, aThrough, bFrom, bThrough time.Time) bool {
 
 	return true
 }
+
+func minTime(t1, t2 time.Time) time.Time {
+	if t1.Before(t2) {
+		return t1
+	}
+	return t2
+}
+
+func maxTime(t1, t2 time.Time) time.Time {
+	if t1.After(t2) {
+		return t1
+	}
+	return t2
+}
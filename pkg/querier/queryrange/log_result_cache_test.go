@@ -38,6 +38,9 @@ func Test_LogResultCacheSameRange(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			false,
+			0,
+			nil,
 		)
 	)
 
@@ -80,6 +83,9 @@ func Test_LogResultCacheSameRangeNonEmpty(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			false,
+			0,
+			nil,
 		)
 	)
 
@@ -128,6 +134,9 @@ func Test_LogResultCacheSmallerRange(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			false,
+			0,
+			nil,
 		)
 	)
 
@@ -178,6 +187,9 @@ func Test_LogResultCacheDifferentRange(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			false,
+			0,
+			nil,
 		)
 	)
 
@@ -254,6 +266,9 @@ func Test_LogResultCacheDifferentRangeNonEmpty(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			false,
+			0,
+			nil,
 		)
 	)
 
@@ -341,6 +356,9 @@ func Test_LogResultCacheDifferentRangeNonEmptyAndEmpty(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			false,
+			0,
+			nil,
 		)
 	)
 
@@ -450,6 +468,9 @@ func Test_LogResultNonOverlappingCache(t *testing.T) {
 			mockCache,
 			nil,
 			nil,
+			nil,
+			false,
+			0,
 			metrics,
 		)
 	)
@@ -592,6 +613,9 @@ func Test_LogResultCacheDifferentLimit(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			false,
+			0,
+			nil,
 		)
 	)
 
@@ -628,6 +652,175 @@ func Test_LogResultCacheDifferentLimit(t *testing.T) {
 	fake.AssertExpectations(t)
 }
 
+type fakeCacheGenNumberLoader string
+
+func (f *fakeCacheGenNumberLoader) GetResultsCacheGenNumber(_ []string) string {
+	return string(*f)
+}
+
+func (f *fakeCacheGenNumberLoader) Stop() {}
+
+func entryLines(resp queryrangebase.Response) []string {
+	var lines []string
+	for _, s := range resp.(*LokiResponse).Data.Result {
+		for _, e := range s.Entries {
+			lines = append(lines, e.Line)
+		}
+	}
+	return lines
+}
+
+func newEntriesLogResultCache(maxEntriesSize int, genNumber *fakeCacheGenNumberLoader) queryrangebase.Middleware {
+	var loader queryrangebase.CacheGenNumberLoader
+	if genNumber != nil {
+		loader = genNumber
+	}
+	return NewLogResultCache(
+		log.NewNopLogger(),
+		fakeLimits{
+			splitDuration: map[string]time.Duration{"foo": time.Minute},
+		},
+		cache.NewMockCache(),
+		nil,
+		nil,
+		loader,
+		true,
+		maxEntriesSize,
+		nil,
+	)
+}
+
+func Test_LogResultCacheEntries(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "foo")
+	req := &LokiRequest{
+		StartTs:   time.Unix(0, time.Minute.Nanoseconds()),
+		EndTs:     time.Unix(0, 2*time.Minute.Nanoseconds()),
+		Limit:     entriesLimit,
+		Direction: logproto.BACKWARD,
+	}
+	resp := nonEmptyResponse(req, time.Unix(61, 0), time.Unix(62, 0), lblFooBar)
+
+	fake := newFakeResponse([]mockResponse{
+		{RequestResponse: queryrangebase.RequestResponse{Request: req, Response: resp}},
+	})
+	h := newEntriesLogResultCache(1<<20, nil).Wrap(fake)
+
+	for i := 0; i < 2; i++ {
+		res, err := h.Do(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, []string{"61", "62"}, entryLines(res))
+	}
+	fake.AssertExpectations(t)
+}
+
+func Test_LogResultCacheEntriesExtended(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "foo")
+	req1 := &LokiRequest{
+		StartTs: time.Unix(0, time.Minute.Nanoseconds()),
+		EndTs:   time.Unix(0, time.Minute.Nanoseconds()+30*time.Second.Nanoseconds()),
+		Limit:   entriesLimit,
+	}
+	req2 := &LokiRequest{
+		StartTs: time.Unix(0, time.Minute.Nanoseconds()+10*time.Second.Nanoseconds()),
+		EndTs:   time.Unix(0, time.Minute.Nanoseconds()+40*time.Second.Nanoseconds()),
+		Limit:   entriesLimit,
+	}
+	// only the entries after the cached ones are fetched.
+	missing := req2.WithStartEnd(req1.EndTs, req2.EndTs)
+	req3 := req1.WithStartEnd(req1.StartTs, req2.EndTs)
+
+	fake := newFakeResponse([]mockResponse{
+		{RequestResponse: queryrangebase.RequestResponse{Request: req1, Response: nonEmptyResponse(req1, time.Unix(75, 0), time.Unix(76, 0), lblFooBar)}},
+		{RequestResponse: queryrangebase.RequestResponse{Request: missing, Response: nonEmptyResponse(req2, time.Unix(95, 0), time.Unix(95, 0), lblFizzBuzz)}},
+	})
+	h := newEntriesLogResultCache(1<<20, nil).Wrap(fake)
+
+	res, err := h.Do(ctx, req1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"75", "76"}, entryLines(res))
+
+	res, err = h.Do(ctx, req2)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"75", "76", "95"}, entryLines(res))
+
+	// the cached entries were extended with the fetched ones.
+	res, err = h.Do(ctx, req3)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"75", "76", "95"}, entryLines(res))
+
+	fake.AssertExpectations(t)
+}
+
+func Test_LogResultCacheEntriesTruncated(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "foo")
+	req1 := &LokiRequest{
+		StartTs:   time.Unix(0, time.Minute.Nanoseconds()),
+		EndTs:     time.Unix(0, time.Minute.Nanoseconds()+30*time.Second.Nanoseconds()),
+		Limit:     2,
+		Direction: logproto.BACKWARD,
+	}
+	req2 := req1.WithStartEnd(req1.StartTs, time.Unix(0, time.Minute.Nanoseconds()+20*time.Second.Nanoseconds()))
+
+	// the entries of req1 were truncated to its limit, so the ones of req2
+	// can't be extracted from them.
+	fake := newFakeResponse([]mockResponse{
+		{RequestResponse: queryrangebase.RequestResponse{Request: req1, Response: nonEmptyResponse(req1, time.Unix(85, 0), time.Unix(86, 0), lblFooBar)}},
+		{RequestResponse: queryrangebase.RequestResponse{Request: req2, Response: nonEmptyResponse(req1, time.Unix(65, 0), time.Unix(66, 0), lblFooBar)}},
+	})
+	h := newEntriesLogResultCache(1<<20, nil).Wrap(fake)
+
+	for _, req := range []queryrangebase.Request{req1, req1, req2} {
+		_, err := h.Do(ctx, req)
+		require.NoError(t, err)
+	}
+	fake.AssertExpectations(t)
+}
+
+func Test_LogResultCacheEntriesNotCached(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "foo")
+	req := &LokiRequest{
+		StartTs: time.Unix(0, time.Minute.Nanoseconds()),
+		EndTs:   time.Unix(0, 2*time.Minute.Nanoseconds()),
+		Limit:   entriesLimit,
+	}
+	resp := nonEmptyResponse(req, time.Unix(61, 0), time.Unix(62, 0), lblFooBar)
+
+	for _, tc := range []struct {
+		name           string
+		maxEntriesSize int
+		do             func(h queryrangebase.Handler, genNumber *fakeCacheGenNumberLoader)
+	}{
+		{
+			name:           "too large",
+			maxEntriesSize: 10,
+			do: func(h queryrangebase.Handler, _ *fakeCacheGenNumberLoader) {
+				_, _ = h.Do(ctx, req)
+				_, _ = h.Do(ctx, req)
+			},
+		},
+		{
+			name:           "deleted",
+			maxEntriesSize: 1 << 20,
+			do: func(h queryrangebase.Handler, genNumber *fakeCacheGenNumberLoader) {
+				_, _ = h.Do(ctx, req)
+				// a delete request changes the cache generation number.
+				*genNumber = "2"
+				_, _ = h.Do(ctx, req)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeResponse([]mockResponse{
+				{RequestResponse: queryrangebase.RequestResponse{Request: req, Response: resp}},
+				{RequestResponse: queryrangebase.RequestResponse{Request: req, Response: resp}},
+			})
+			genNumber := fakeCacheGenNumberLoader("1")
+			tc.do(newEntriesLogResultCache(tc.maxEntriesSize, &genNumber).Wrap(fake), &genNumber)
+			fake.AssertExpectations(t)
+		})
+	}
+}
+
 func TestExtractLokiResponse(t *testing.T) {
 	for _, tc := range []struct {
 		name           string
@@ -0,0 +1,78 @@
+package queryrange
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/grafana/dskit/httpgrpc"
+	"github.com/grafana/dskit/tenant"
+
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/querier/macros"
+	"github.com/grafana/loki/v3/pkg/querier/plan"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
+)
+
+type macrosMiddleware struct {
+	store macros.Store
+	next  queryrangebase.Handler
+}
+
+// NewMacrosMiddleware creates a new Middleware expanding the macros of the
+// tenant invoked by log and metric queries, before they are split, sharded
+// and sent to the queriers.
+func NewMacrosMiddleware(store macros.Store) queryrangebase.Middleware {
+	return queryrangebase.MiddlewareFunc(func(next queryrangebase.Handler) queryrangebase.Handler {
+		return macrosMiddleware{
+			store: store,
+			next:  next,
+		}
+	})
+}
+
+func (m macrosMiddleware) Do(ctx context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+	var queryPlan *plan.QueryPlan
+	switch req := r.(type) {
+	case *LokiRequest:
+		queryPlan = req.Plan
+	case *LokiInstantRequest:
+		queryPlan = req.Plan
+	}
+	if queryPlan == nil || !syntax.HasMacros(queryPlan.AST) {
+		return m.next.Do(ctx, r)
+	}
+
+	expanded, err := expandMacros(ctx, m.store, queryPlan.AST)
+	if err != nil {
+		return nil, err
+	}
+
+	expandedPlan := &plan.QueryPlan{AST: expanded}
+	switch req := r.WithQuery(expanded.String()).(type) {
+	case *LokiRequest:
+		req.Plan = expandedPlan
+		r = req
+	case *LokiInstantRequest:
+		req.Plan = expandedPlan
+		r = req
+	}
+	return m.next.Do(ctx, r)
+}
+
+// expandMacros expands the macros of the tenant invoked by expr.
+func expandMacros(ctx context.Context, store macros.Store, expr syntax.Expr) (syntax.Expr, error) {
+	// macros are defined per tenant so they can't be used across tenants.
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, "macros can only be used in single tenant queries: %s", err)
+	}
+	defined, err := store.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	expanded, err := syntax.ExpandMacros(expr, defined)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	return expanded, nil
+}
@@ -0,0 +1,64 @@
+package queryrange
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/dskit/httpgrpc"
+	"github.com/grafana/dskit/user"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/querier/macros"
+	"github.com/grafana/loki/v3/pkg/querier/plan"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
+)
+
+func Test_MacrosMiddleware(t *testing.T) {
+	store := macros.NewBucketStore(objstore.NewInMemBucket())
+	require.NoError(t, store.Set(context.Background(), "1", "errors", `| logfmt | level="error"`))
+
+	var got queryrangebase.Request
+	handler := NewMacrosMiddleware(store).Wrap(queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+		got = r
+		return &LokiResponse{}, nil
+	}))
+
+	newRequest := func(query string) queryrangebase.Request {
+		return &LokiRequest{
+			Query: query,
+			Plan:  &plan.QueryPlan{AST: syntax.MustParseExpr(query)},
+		}
+	}
+
+	for _, tc := range []struct {
+		tenant   string
+		query    string
+		expected string
+		status   int32
+	}{
+		{tenant: "1", query: `{app="foo"} | json`, expected: `{app="foo"} | json`},
+		{tenant: "1", query: `{app="foo"} | @errors()`, expected: `{app="foo"} | logfmt | level="error"`},
+		{tenant: "1", query: `rate({app="foo"} | @errors() [1m])`, expected: `rate({app="foo"} | logfmt | level="error"[1m])`},
+		{tenant: "2", query: `{app="foo"} | @errors()`, status: http.StatusBadRequest},
+		{tenant: "1|2", query: `{app="foo"} | @errors()`, status: http.StatusBadRequest},
+	} {
+		t.Run(tc.tenant+" "+tc.query, func(t *testing.T) {
+			got = nil
+			ctx := user.InjectOrgID(context.Background(), tc.tenant)
+			_, err := handler.Do(ctx, newRequest(tc.query))
+			if tc.status != 0 {
+				resp, ok := httpgrpc.HTTPResponseFromError(err)
+				require.True(t, ok)
+				require.Equal(t, tc.status, resp.Code)
+				require.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, got.GetQuery())
+			require.Equal(t, tc.expected, got.(*LokiRequest).Plan.AST.String())
+		})
+	}
+}
@@ -134,6 +134,12 @@ func ResultToResponse(result logqlmodel.Result, params logql.Params) (queryrange
 			Response: r,
 			Warnings: result.Warnings,
 		}, nil
+	case logql.ProbabilisticCountDistinctMatrix:
+		r, err := data.ToProto()
+		return &CountDistinctSketchResponse{
+			Response: r,
+			Warnings: result.Warnings,
+		}, err
 	}
 
 	return nil, fmt.Errorf("unsupported data type: %T", result.Data)
@@ -198,6 +204,16 @@ func ResponseToResult(resp queryrangebase.Response) (logqlmodel.Result, error) {
 			Headers:  resp.GetHeaders(),
 			Warnings: r.Warnings,
 		}, nil
+	case *CountDistinctSketchResponse:
+		matrix, err := logql.ProbabilisticCountDistinctMatrixFromProto(r.Response)
+		if err != nil {
+			return logqlmodel.Result{}, fmt.Errorf("cannot decode count distinct sketch: %w", err)
+		}
+		return logqlmodel.Result{
+			Data:     matrix,
+			Headers:  resp.GetHeaders(),
+			Warnings: r.Warnings,
+		}, nil
 	default:
 		return logqlmodel.Result{}, fmt.Errorf("cannot decode (%T)", resp)
 	}
@@ -227,6 +243,8 @@ func QueryResponseUnwrap(res *QueryResponse) (queryrangebase.Response, error) {
 		return concrete.TopkSketches, nil
 	case *QueryResponse_QuantileSketches:
 		return concrete.QuantileSketches, nil
+	case *QueryResponse_CountDistinctSketches:
+		return concrete.CountDistinctSketches, nil
 	case *QueryResponse_PatternsResponse:
 		return concrete.PatternsResponse, nil
 	case *QueryResponse_DetectedLabels:
@@ -266,6 +284,8 @@ func QueryResponseWrap(res queryrangebase.Response) (*QueryResponse, error) {
 		p.Response = &QueryResponse_TopkSketches{response}
 	case *QuantileSketchResponse:
 		p.Response = &QueryResponse_QuantileSketches{response}
+	case *CountDistinctSketchResponse:
+		p.Response = &QueryResponse_CountDistinctSketches{response}
 	case *ShardsResponse:
 		p.Response = &QueryResponse_ShardsResponse{response}
 	case *QueryPatternsResponse:
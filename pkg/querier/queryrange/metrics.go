@@ -19,6 +19,7 @@ type Metrics struct {
 	*LogResultCacheMetrics
 	*QueryMetrics
 	*queryrangebase.ResultsCacheMetrics
+	*DedupMetrics
 }
 
 type MiddlewareMapperMetrics struct {
@@ -42,6 +43,7 @@ func NewMetrics(registerer prometheus.Registerer, metricsNamespace string) *Metr
 		LogResultCacheMetrics:       NewLogResultCacheMetrics(registerer),
 		QueryMetrics:                NewMiddlewareQueryMetrics(registerer, metricsNamespace),
 		ResultsCacheMetrics:         queryrangebase.NewResultsCacheMetrics(registerer),
+		DedupMetrics:                NewDedupMetrics(registerer, metricsNamespace),
 	}
 }
 
@@ -0,0 +1,195 @@
+package queryrange
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql"
+	"github.com/grafana/loki/v3/pkg/logqlmodel"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/v3/pkg/util/httpreq"
+)
+
+const (
+	EventStreamType = `text/event-stream`
+
+	// Events of the streamed responses of range queries.
+	EventPartial  = "partial"
+	EventProgress = "progress"
+	EventResult   = "result"
+	EventError    = "error"
+)
+
+// PartialResult is a part of the result of a query, sent while the query is
+// still running.
+type PartialResult struct {
+	Response queryrangebase.Response
+	// Final is true when the part is in the result as is: the parts of split
+	// queries are sent in the order of the result, and log parts are truncated
+	// to the limit. The parts of sharded queries are merged into the result,
+	// and only give a preview of it.
+	Final bool
+}
+
+type partialResultsKey struct{}
+
+// WithPartialResults returns a context whose range queries send the parts of
+// their result to fn as they complete, ahead of the result itself.
+func WithPartialResults(ctx context.Context, fn func(PartialResult)) context.Context {
+	return context.WithValue(ctx, partialResultsKey{}, fn)
+}
+
+func partialResultsFromContext(ctx context.Context) func(PartialResult) {
+	fn, _ := ctx.Value(partialResultsKey{}).(func(PartialResult))
+	return fn
+}
+
+// withoutPartialResults returns a context whose queries don't send partial
+// results, for the sub-queries of a query which either sends their parts
+// itself or can't send meaningful parts.
+func withoutPartialResults(ctx context.Context) context.Context {
+	if partialResultsFromContext(ctx) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, partialResultsKey{}, (func(PartialResult))(nil))
+}
+
+// splitPartialResult returns the part of the result held by the response of
+// a split, truncating log responses to the remaining entries of the limit.
+func splitPartialResult(resp queryrangebase.Response, remaining int64, unlimited bool) (PartialResult, bool) {
+	switch res := resp.(type) {
+	case *LokiResponse:
+		if !unlimited && res.Count() > remaining {
+			truncated := *res
+			truncated.Limit = uint32(remaining)
+			resp = mergeLokiResponse(&truncated)
+		}
+	case *LokiPromResponse:
+	default:
+		return PartialResult{}, false
+	}
+	return PartialResult{Response: resp, Final: true}, true
+}
+
+// shardPartials sends the results of the shards of a log query as partial
+// results, in the order of the shards whatever the order they complete in.
+type shardPartials struct {
+	send    func(PartialResult)
+	queries []logql.DownstreamQuery
+	next    int
+	pending map[int]logqlmodel.Result
+}
+
+// newShardPartials returns nil when the query doesn't send partial results.
+func newShardPartials(ctx context.Context, queries []logql.DownstreamQuery) *shardPartials {
+	send := partialResultsFromContext(ctx)
+	if send == nil {
+		return nil
+	}
+	return &shardPartials{
+		send:    send,
+		queries: queries,
+		pending: map[int]logqlmodel.Result{},
+	}
+}
+
+// add must be called before the result is accumulated, which can modify its
+// streams.
+func (s *shardPartials) add(i int, res logqlmodel.Result) {
+	if s == nil {
+		return
+	}
+	streams, ok := res.Data.(logqlmodel.Streams)
+	if !ok {
+		// only the shards of log queries make sense on their own.
+		return
+	}
+	cloned := make(logqlmodel.Streams, len(streams))
+	for j, stream := range streams {
+		cloned[j] = logproto.Stream{
+			Labels:  stream.Labels,
+			Entries: append([]logproto.Entry(nil), stream.Entries...),
+			Hash:    stream.Hash,
+		}
+	}
+	res.Data = cloned
+	s.pending[i] = res
+
+	for {
+		res, ok := s.pending[s.next]
+		if !ok {
+			return
+		}
+		delete(s.pending, s.next)
+		resp, err := ResultToResponse(res, s.queries[s.next].Params)
+		s.next++
+		if err == nil {
+			s.send(PartialResult{Response: resp})
+		}
+	}
+}
+
+// AcceptsEventStream returns whether the response of a request can be
+// streamed as server-sent events.
+func AcceptsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), EventStreamType)
+}
+
+// eventStreamWriter writes the partial results and the result of a query as
+// server-sent events.
+type eventStreamWriter struct {
+	mtx         sync.Mutex
+	w           io.Writer
+	version     loghttp.Version
+	encodeFlags httpreq.EncodingFlags
+	err         error
+}
+
+func (e *eventStreamWriter) writePartial(p PartialResult) {
+	event := EventProgress
+	if p.Final {
+		event = EventPartial
+	}
+	e.writeResponse(event, p.Response)
+}
+
+func (e *eventStreamWriter) writeResponse(event string, resp queryrangebase.Response) {
+	var buf bytes.Buffer
+	if err := encodeResponseJSONTo(e.version, resp, &buf, e.encodeFlags); err != nil {
+		e.writeError(err)
+		return
+	}
+	e.write(event, buf.Bytes())
+}
+
+func (e *eventStreamWriter) writeError(err error) {
+	e.write(EventError, []byte(err.Error()))
+}
+
+// write writes an event at once, with a data line per line of data.
+func (e *eventStreamWriter) write(event string, data []byte) {
+	var buf bytes.Buffer
+	buf.WriteString("event: ")
+	buf.WriteString(event)
+	buf.WriteByte('\n')
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	if e.err != nil {
+		// the client is gone.
+		return
+	}
+	_, e.err = e.w.Write(buf.Bytes())
+}
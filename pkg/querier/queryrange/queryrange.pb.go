@@ -892,6 +892,51 @@ func (m *QuantileSketchResponse) GetWarnings() []string {
 	return nil
 }
 
+type CountDistinctSketchResponse struct {
+	Response *github_com_grafana_loki_v3_pkg_logproto.CountDistinctSketchMatrix                                      `protobuf:"bytes,1,opt,name=response,proto3,customtype=github.com/grafana/loki/v3/pkg/logproto.CountDistinctSketchMatrix" json:"response,omitempty"`
+	Headers  []github_com_grafana_loki_v3_pkg_querier_queryrange_queryrangebase_definitions.PrometheusResponseHeader `protobuf:"bytes,2,rep,name=Headers,proto3,customtype=github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase/definitions.PrometheusResponseHeader" json:"-"`
+	Warnings []string                                                                                                `protobuf:"bytes,3,rep,name=warnings,proto3" json:"warnings,omitempty"`
+}
+
+func (m *CountDistinctSketchResponse) Reset()      { *m = CountDistinctSketchResponse{} }
+func (*CountDistinctSketchResponse) ProtoMessage() {}
+func (*CountDistinctSketchResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_51b9d53b40d11902, []int{13}
+}
+func (m *CountDistinctSketchResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *CountDistinctSketchResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_CountDistinctSketchResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *CountDistinctSketchResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CountDistinctSketchResponse.Merge(m, src)
+}
+func (m *CountDistinctSketchResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *CountDistinctSketchResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CountDistinctSketchResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CountDistinctSketchResponse proto.InternalMessageInfo
+
+func (m *CountDistinctSketchResponse) GetWarnings() []string {
+	if m != nil {
+		return m.Warnings
+	}
+	return nil
+}
+
 type ShardsResponse struct {
 	Response *github_com_grafana_loki_v3_pkg_logproto.ShardsResponse                                                 `protobuf:"bytes,1,opt,name=response,proto3,customtype=github.com/grafana/loki/v3/pkg/logproto.ShardsResponse" json:"response,omitempty"`
 	Headers  []github_com_grafana_loki_v3_pkg_querier_queryrange_queryrangebase_definitions.PrometheusResponseHeader `protobuf:"bytes,2,rep,name=Headers,proto3,customtype=github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase/definitions.PrometheusResponseHeader" json:"-"`
@@ -900,7 +945,7 @@ type ShardsResponse struct {
 func (m *ShardsResponse) Reset()      { *m = ShardsResponse{} }
 func (*ShardsResponse) ProtoMessage() {}
 func (*ShardsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_51b9d53b40d11902, []int{13}
+	return fileDescriptor_51b9d53b40d11902, []int{14}
 }
 func (m *ShardsResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -937,7 +982,7 @@ type DetectedFieldsResponse struct {
 func (m *DetectedFieldsResponse) Reset()      { *m = DetectedFieldsResponse{} }
 func (*DetectedFieldsResponse) ProtoMessage() {}
 func (*DetectedFieldsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_51b9d53b40d11902, []int{14}
+	return fileDescriptor_51b9d53b40d11902, []int{15}
 }
 func (m *DetectedFieldsResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -974,7 +1019,7 @@ type QueryPatternsResponse struct {
 func (m *QueryPatternsResponse) Reset()      { *m = QueryPatternsResponse{} }
 func (*QueryPatternsResponse) ProtoMessage() {}
 func (*QueryPatternsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_51b9d53b40d11902, []int{15}
+	return fileDescriptor_51b9d53b40d11902, []int{16}
 }
 func (m *QueryPatternsResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1011,7 +1056,7 @@ type DetectedLabelsResponse struct {
 func (m *DetectedLabelsResponse) Reset()      { *m = DetectedLabelsResponse{} }
 func (*DetectedLabelsResponse) ProtoMessage() {}
 func (*DetectedLabelsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_51b9d53b40d11902, []int{16}
+	return fileDescriptor_51b9d53b40d11902, []int{17}
 }
 func (m *DetectedLabelsResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1055,13 +1100,14 @@ type QueryResponse struct {
 	//	*QueryResponse_DetectedFields
 	//	*QueryResponse_PatternsResponse
 	//	*QueryResponse_DetectedLabels
+	//	*QueryResponse_CountDistinctSketches
 	Response isQueryResponse_Response `protobuf_oneof:"response"`
 }
 
 func (m *QueryResponse) Reset()      { *m = QueryResponse{} }
 func (*QueryResponse) ProtoMessage() {}
 func (*QueryResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_51b9d53b40d11902, []int{17}
+	return fileDescriptor_51b9d53b40d11902, []int{18}
 }
 func (m *QueryResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1133,19 +1179,23 @@ type QueryResponse_PatternsResponse struct {
 type QueryResponse_DetectedLabels struct {
 	DetectedLabels *DetectedLabelsResponse `protobuf:"bytes,13,opt,name=detectedLabels,proto3,oneof"`
 }
+type QueryResponse_CountDistinctSketches struct {
+	CountDistinctSketches *CountDistinctSketchResponse `protobuf:"bytes,14,opt,name=countDistinctSketches,proto3,oneof"`
+}
 
-func (*QueryResponse_Series) isQueryResponse_Response()           {}
-func (*QueryResponse_Labels) isQueryResponse_Response()           {}
-func (*QueryResponse_Stats) isQueryResponse_Response()            {}
-func (*QueryResponse_Prom) isQueryResponse_Response()             {}
-func (*QueryResponse_Streams) isQueryResponse_Response()          {}
-func (*QueryResponse_Volume) isQueryResponse_Response()           {}
-func (*QueryResponse_TopkSketches) isQueryResponse_Response()     {}
-func (*QueryResponse_QuantileSketches) isQueryResponse_Response() {}
-func (*QueryResponse_ShardsResponse) isQueryResponse_Response()   {}
-func (*QueryResponse_DetectedFields) isQueryResponse_Response()   {}
-func (*QueryResponse_PatternsResponse) isQueryResponse_Response() {}
-func (*QueryResponse_DetectedLabels) isQueryResponse_Response()   {}
+func (*QueryResponse_Series) isQueryResponse_Response()                {}
+func (*QueryResponse_Labels) isQueryResponse_Response()                {}
+func (*QueryResponse_Stats) isQueryResponse_Response()                 {}
+func (*QueryResponse_Prom) isQueryResponse_Response()                  {}
+func (*QueryResponse_Streams) isQueryResponse_Response()               {}
+func (*QueryResponse_Volume) isQueryResponse_Response()                {}
+func (*QueryResponse_TopkSketches) isQueryResponse_Response()          {}
+func (*QueryResponse_QuantileSketches) isQueryResponse_Response()      {}
+func (*QueryResponse_ShardsResponse) isQueryResponse_Response()        {}
+func (*QueryResponse_DetectedFields) isQueryResponse_Response()        {}
+func (*QueryResponse_PatternsResponse) isQueryResponse_Response()      {}
+func (*QueryResponse_DetectedLabels) isQueryResponse_Response()        {}
+func (*QueryResponse_CountDistinctSketches) isQueryResponse_Response() {}
 
 func (m *QueryResponse) GetResponse() isQueryResponse_Response {
 	if m != nil {
@@ -1245,6 +1295,13 @@ func (m *QueryResponse) GetDetectedLabels() *DetectedLabelsResponse {
 	return nil
 }
 
+func (m *QueryResponse) GetCountDistinctSketches() *CountDistinctSketchResponse {
+	if x, ok := m.GetResponse().(*QueryResponse_CountDistinctSketches); ok {
+		return x.CountDistinctSketches
+	}
+	return nil
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*QueryResponse) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
@@ -1260,6 +1317,7 @@ func (*QueryResponse) XXX_OneofWrappers() []interface{} {
 		(*QueryResponse_DetectedFields)(nil),
 		(*QueryResponse_PatternsResponse)(nil),
 		(*QueryResponse_DetectedLabels)(nil),
+		(*QueryResponse_CountDistinctSketches)(nil),
 	}
 }
 
@@ -1282,7 +1340,7 @@ type QueryRequest struct {
 func (m *QueryRequest) Reset()      { *m = QueryRequest{} }
 func (*QueryRequest) ProtoMessage() {}
 func (*QueryRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_51b9d53b40d11902, []int{18}
+	return fileDescriptor_51b9d53b40d11902, []int{19}
 }
 func (m *QueryRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1474,6 +1532,7 @@ func init() {
 	proto.RegisterType((*VolumeResponse)(nil), "queryrange.VolumeResponse")
 	proto.RegisterType((*TopKSketchesResponse)(nil), "queryrange.TopKSketchesResponse")
 	proto.RegisterType((*QuantileSketchResponse)(nil), "queryrange.QuantileSketchResponse")
+	proto.RegisterType((*CountDistinctSketchResponse)(nil), "queryrange.CountDistinctSketchResponse")
 	proto.RegisterType((*ShardsResponse)(nil), "queryrange.ShardsResponse")
 	proto.RegisterType((*DetectedFieldsResponse)(nil), "queryrange.DetectedFieldsResponse")
 	proto.RegisterType((*QueryPatternsResponse)(nil), "queryrange.QueryPatternsResponse")
@@ -1488,128 +1547,132 @@ func init() {
 }
 
 var fileDescriptor_51b9d53b40d11902 = []byte{
-	// 1935 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xec, 0x59, 0xcb, 0x6f, 0x23, 0x49,
-	0x19, 0x77, 0xfb, 0x19, 0x7f, 0x79, 0x4c, 0xa8, 0x09, 0xd9, 0x26, 0x3b, 0xeb, 0x36, 0x96, 0xd8,
-	0x0d, 0x08, 0xec, 0x1d, 0x67, 0x77, 0xd8, 0x0d, 0xc3, 0x68, 0xa7, 0x27, 0x33, 0x64, 0x86, 0x59,
-	0x76, 0xb6, 0x13, 0x71, 0xe0, 0x82, 0x2a, 0x76, 0xc5, 0x6e, 0x62, 0x77, 0xf7, 0x74, 0x97, 0x33,
-	0x13, 0x09, 0xa1, 0xfd, 0x07, 0x56, 0xec, 0x5f, 0x81, 0xb8, 0x71, 0xe1, 0xc4, 0x89, 0xe3, 0xee,
-	0x01, 0x69, 0x8e, 0x2b, 0x4b, 0x34, 0x8c, 0x07, 0x21, 0x94, 0xd3, 0x4a, 0x5c, 0x39, 0xa0, 0x7a,
-	0x74, 0xbb, 0xca, 0x76, 0x18, 0x27, 0x20, 0xa4, 0xc0, 0x5e, 0x92, 0x7a, 0x7c, 0xbf, 0xea, 0xaa,
-	0xdf, 0xf7, 0xfb, 0xbe, 0x7a, 0x18, 0xde, 0x08, 0x8e, 0x3a, 0x8d, 0xc7, 0x03, 0x12, 0xba, 0x24,
-	0xe4, 0xff, 0x4f, 0x42, 0xec, 0x75, 0x88, 0x52, 0xac, 0x07, 0xa1, 0x4f, 0x7d, 0x04, 0xe3, 0x96,
-	0x8d, 0x66, 0xc7, 0xa5, 0xdd, 0xc1, 0x41, 0xbd, 0xe5, 0xf7, 0x1b, 0x1d, 0xbf, 0xe3, 0x37, 0x3a,
-	0xbe, 0xdf, 0xe9, 0x11, 0x1c, 0xb8, 0x91, 0x2c, 0x36, 0xc2, 0xa0, 0xd5, 0x88, 0x28, 0xa6, 0x83,
-	0x48, 0xe0, 0x37, 0xd6, 0x98, 0x21, 0x2f, 0x72, 0x88, 0x6c, 0xb5, 0xa4, 0x39, 0xaf, 0x1d, 0x0c,
-	0x0e, 0x1b, 0xd4, 0xed, 0x93, 0x88, 0xe2, 0x7e, 0x90, 0x18, 0xb0, 0xf9, 0xf5, 0xfc, 0x8e, 0x40,
-	0xba, 0x5e, 0x9b, 0x3c, 0xed, 0x60, 0x4a, 0x9e, 0xe0, 0x13, 0x69, 0xf0, 0xaa, 0x66, 0x90, 0x14,
-	0x64, 0xe7, 0x86, 0xd6, 0x19, 0x60, 0x4a, 0x49, 0xe8, 0xc9, 0xbe, 0xaf, 0x69, 0x7d, 0xd1, 0x11,
-	0xa1, 0xad, 0xae, 0xec, 0xaa, 0xca, 0xae, 0xc7, 0xbd, 0xbe, 0xdf, 0x26, 0x3d, 0xbe, 0x90, 0x48,
-	0xfc, 0x95, 0x16, 0x57, 0x99, 0x45, 0x30, 0x88, 0xba, 0xfc, 0x8f, 0x6c, 0xbc, 0xf3, 0x52, 0x2e,
-	0x0f, 0x70, 0x44, 0x1a, 0x6d, 0x72, 0xe8, 0x7a, 0x2e, 0x75, 0x7d, 0x2f, 0x52, 0xcb, 0x72, 0x90,
-	0x1b, 0xf3, 0x0d, 0x32, 0xe9, 0x9f, 0x8d, 0x37, 0x19, 0x2e, 0xa2, 0x7e, 0x88, 0x3b, 0xa4, 0xd1,
-	0xea, 0x0e, 0xbc, 0xa3, 0x46, 0x0b, 0xb7, 0xba, 0xa4, 0x11, 0x92, 0x68, 0xd0, 0xa3, 0x91, 0xa8,
-	0xd0, 0x93, 0x80, 0xc8, 0x2f, 0xd5, 0x3e, 0xcb, 0xc3, 0xe2, 0x43, 0xff, 0xc8, 0x75, 0xc8, 0xe3,
-	0x01, 0x89, 0x28, 0x5a, 0x83, 0x02, 0x1f, 0xd5, 0x34, 0xaa, 0xc6, 0x66, 0xd9, 0x11, 0x15, 0xd6,
-	0xda, 0x73, 0xfb, 0x2e, 0x35, 0xb3, 0x55, 0x63, 0x73, 0xd9, 0x11, 0x15, 0x84, 0x20, 0x1f, 0x51,
-	0x12, 0x98, 0xb9, 0xaa, 0xb1, 0x99, 0x73, 0x78, 0x19, 0x6d, 0xc0, 0x82, 0xeb, 0x51, 0x12, 0x1e,
-	0xe3, 0x9e, 0x59, 0xe6, 0xed, 0x69, 0x1d, 0xdd, 0x82, 0x52, 0x44, 0x71, 0x48, 0xf7, 0x23, 0x33,
-	0x5f, 0x35, 0x36, 0x17, 0x9b, 0x1b, 0x75, 0xe1, 0xf9, 0x7a, 0xe2, 0xf9, 0xfa, 0x7e, 0xe2, 0x79,
-	0x7b, 0xe1, 0xd3, 0xd8, 0xca, 0x7c, 0xf2, 0x27, 0xcb, 0x70, 0x12, 0x10, 0xda, 0x86, 0x02, 0xf1,
-	0xda, 0xfb, 0x91, 0x59, 0x38, 0x07, 0x5a, 0x40, 0xd0, 0x75, 0x28, 0xb7, 0xdd, 0x90, 0xb4, 0x18,
-	0xcb, 0x66, 0xb1, 0x6a, 0x6c, 0xae, 0x34, 0xaf, 0xd6, 0x53, 0xa1, 0xec, 0x24, 0x5d, 0xce, 0xd8,
-	0x8a, 0x2d, 0x2f, 0xc0, 0xb4, 0x6b, 0x96, 0x38, 0x13, 0xbc, 0x8c, 0x6a, 0x50, 0x8c, 0xba, 0x38,
-	0x6c, 0x47, 0xe6, 0x42, 0x35, 0xb7, 0x59, 0xb6, 0xe1, 0x34, 0xb6, 0x64, 0x8b, 0x23, 0xff, 0xa3,
-	0x9f, 0x42, 0x3e, 0xe8, 0x61, 0xcf, 0x04, 0x3e, 0xcb, 0xd5, 0xba, 0xe2, 0xa5, 0x47, 0x3d, 0xec,
-	0xd9, 0xef, 0x0e, 0x63, 0xeb, 0x6d, 0x35, 0x78, 0x42, 0x7c, 0x88, 0x3d, 0xdc, 0xe8, 0xf9, 0x47,
-	0x6e, 0xe3, 0x78, 0xab, 0xa1, 0xfa, 0x9e, 0x0d, 0x54, 0xff, 0x90, 0x0d, 0xc0, 0xa0, 0x0e, 0x1f,
-	0x18, 0x3d, 0x80, 0x45, 0xe6, 0x63, 0x72, 0x87, 0x39, 0x38, 0x32, 0x17, 0xf9, 0x77, 0x5e, 0x19,
-	0xaf, 0x86, 0xb7, 0x3b, 0xe4, 0xf0, 0x07, 0xa1, 0x3f, 0x08, 0xec, 0x2b, 0xa7, 0xb1, 0xa5, 0xda,
-	0x3b, 0x6a, 0x05, 0x3d, 0x80, 0x15, 0x26, 0x0a, 0xd7, 0xeb, 0x7c, 0x10, 0x70, 0x05, 0x9a, 0x4b,
-	0x7c, 0xb8, 0x6b, 0x75, 0x55, 0x32, 0xf5, 0x3b, 0x9a, 0x8d, 0x9d, 0x67, 0xf4, 0x3a, 0x13, 0xc8,
-	0xda, 0x28, 0x07, 0x88, 0x69, 0xe9, 0xbe, 0x17, 0x51, 0xec, 0xd1, 0x8b, 0x48, 0xea, 0x26, 0x14,
-	0x59, 0xf0, 0xef, 0x47, 0x5c, 0x54, 0xf3, 0xfa, 0x58, 0x62, 0x74, 0x27, 0xe7, 0xcf, 0xe5, 0xe4,
-	0xc2, 0x4c, 0x27, 0x17, 0x5f, 0xea, 0xe4, 0xd2, 0x7f, 0xc9, 0xc9, 0x0b, 0xff, 0x59, 0x27, 0x97,
-	0x2f, 0xec, 0x64, 0x13, 0xf2, 0x6c, 0x96, 0x68, 0x15, 0x72, 0x21, 0x7e, 0xc2, 0x7d, 0xba, 0xe4,
-	0xb0, 0x62, 0x6d, 0x94, 0x87, 0x25, 0x91, 0x4a, 0xa2, 0xc0, 0xf7, 0x22, 0xc2, 0x78, 0xdc, 0xe3,
-	0xd9, 0x5f, 0x78, 0x5e, 0xf2, 0xc8, 0x5b, 0x1c, 0xd9, 0x83, 0xde, 0x83, 0xfc, 0x0e, 0xa6, 0x98,
-	0xab, 0x60, 0xb1, 0xb9, 0xa6, 0xf2, 0xc8, 0xc6, 0x62, 0x7d, 0xf6, 0x3a, 0x9b, 0xc8, 0x69, 0x6c,
-	0xad, 0xb4, 0x31, 0xc5, 0xdf, 0xf6, 0xfb, 0x2e, 0x25, 0xfd, 0x80, 0x9e, 0x38, 0x1c, 0x89, 0xde,
-	0x86, 0xf2, 0xdd, 0x30, 0xf4, 0xc3, 0xfd, 0x93, 0x80, 0x70, 0xd5, 0x94, 0xed, 0x57, 0x4e, 0x63,
-	0xeb, 0x2a, 0x49, 0x1a, 0x15, 0xc4, 0xd8, 0x12, 0x7d, 0x13, 0x0a, 0xbc, 0xc2, 0x75, 0x52, 0xb6,
-	0xaf, 0x9e, 0xc6, 0xd6, 0x15, 0x0e, 0x51, 0xcc, 0x85, 0x85, 0x2e, 0xab, 0xc2, 0x5c, 0xb2, 0x4a,
-	0xd5, 0x5d, 0x54, 0xd5, 0x6d, 0x42, 0xe9, 0x98, 0x84, 0x11, 0x1b, 0xa6, 0xc4, 0xdb, 0x93, 0x2a,
-	0xba, 0x0d, 0xc0, 0x88, 0x71, 0x23, 0xea, 0xb6, 0x12, 0x67, 0x2f, 0xd7, 0xc5, 0x66, 0xe3, 0x70,
-	0x1f, 0xd9, 0x48, 0xb2, 0xa0, 0x18, 0x3a, 0x4a, 0x19, 0xfd, 0xc6, 0x80, 0xd2, 0x2e, 0xc1, 0x6d,
-	0x12, 0x32, 0xf7, 0xe6, 0x36, 0x17, 0x9b, 0xdf, 0xa8, 0xab, 0x3b, 0xcb, 0xa3, 0xd0, 0xef, 0x13,
-	0xda, 0x25, 0x83, 0x28, 0x71, 0x90, 0xb0, 0xb6, 0xbd, 0x61, 0x6c, 0x91, 0x39, 0xa5, 0x3a, 0xd7,
-	0x86, 0x76, 0xe6, 0xa7, 0x4e, 0x63, 0xcb, 0xf8, 0x8e, 0x93, 0xcc, 0x12, 0x35, 0x61, 0xe1, 0x09,
-	0x0e, 0x3d, 0xd7, 0xeb, 0x44, 0x26, 0xf0, 0x48, 0x5b, 0x3f, 0x8d, 0x2d, 0x94, 0xb4, 0x29, 0x8e,
-	0x48, 0xed, 0x6a, 0x7f, 0x34, 0xe0, 0x2b, 0x4c, 0x18, 0x7b, 0x6c, 0x3e, 0x91, 0x92, 0x62, 0xfa,
-	0x98, 0xb6, 0xba, 0xa6, 0xc1, 0x86, 0x71, 0x44, 0x45, 0xdd, 0x6f, 0xb2, 0xff, 0xd6, 0x7e, 0x93,
-	0x3b, 0xff, 0x7e, 0x93, 0xe4, 0x95, 0xfc, 0xcc, 0xbc, 0x52, 0x38, 0x2b, 0xaf, 0xd4, 0x7e, 0x29,
-	0x73, 0x68, 0xb2, 0xbe, 0x73, 0x84, 0xd2, 0xbd, 0x34, 0x94, 0x72, 0x7c, 0xb6, 0xa9, 0x42, 0xc5,
-	0x58, 0xf7, 0xdb, 0xc4, 0xa3, 0xee, 0xa1, 0x4b, 0xc2, 0x97, 0x04, 0x94, 0xa2, 0xd2, 0x9c, 0xae,
-	0x52, 0x55, 0x62, 0xf9, 0x4b, 0x21, 0x31, 0x3d, 0xae, 0x0a, 0x17, 0x88, 0xab, 0xda, 0xdf, 0xb3,
-	0xb0, 0xce, 0x3c, 0xf2, 0x10, 0x1f, 0x90, 0xde, 0x8f, 0x70, 0xff, 0x9c, 0x5e, 0x79, 0x5d, 0xf1,
-	0x4a, 0xd9, 0x46, 0x5f, 0xb2, 0x3e, 0x1f, 0xeb, 0xbf, 0x32, 0x60, 0x21, 0xd9, 0x00, 0x50, 0x1d,
-	0x40, 0xc0, 0x78, 0x8e, 0x17, 0x5c, 0xaf, 0x30, 0x70, 0x98, 0xb6, 0x3a, 0x8a, 0x05, 0xfa, 0x19,
-	0x14, 0x45, 0x4d, 0xc6, 0x82, 0xb2, 0x6d, 0xee, 0xd1, 0x90, 0xe0, 0xfe, 0xed, 0x36, 0x0e, 0x28,
-	0x09, 0xed, 0x77, 0xd9, 0x2c, 0x86, 0xb1, 0xf5, 0xc6, 0x59, 0x2c, 0x25, 0x27, 0x7c, 0x89, 0x63,
-	0xfe, 0x15, 0xdf, 0x74, 0xe4, 0x17, 0x6a, 0x1f, 0x1b, 0xb0, 0xca, 0x26, 0xca, 0xa8, 0x49, 0x85,
-	0xb1, 0x03, 0x0b, 0xa1, 0x2c, 0xf3, 0xe9, 0x2e, 0x36, 0x6b, 0x75, 0x9d, 0xd6, 0x19, 0x54, 0xf2,
-	0x0d, 0xd7, 0x70, 0x52, 0x24, 0xda, 0xd2, 0x68, 0xcc, 0xce, 0xa2, 0x51, 0xec, 0xd1, 0x2a, 0x71,
-	0xbf, 0xcf, 0x02, 0xba, 0xcf, 0x6e, 0x48, 0x4c, 0x7f, 0x63, 0xa9, 0x3e, 0x9d, 0x9a, 0xd1, 0xb5,
-	0x31, 0x29, 0xd3, 0xf6, 0xf6, 0xad, 0x61, 0x6c, 0x6d, 0xbf, 0x44, 0x3b, 0xff, 0x02, 0xaf, 0xac,
-	0x42, 0x95, 0x6f, 0xf6, 0x32, 0xc8, 0xb7, 0xf6, 0xdb, 0x2c, 0xac, 0xfc, 0xd8, 0xef, 0x0d, 0xfa,
-	0x24, 0xa5, 0x2f, 0x98, 0xa2, 0xcf, 0x1c, 0xd3, 0xa7, 0xdb, 0xda, 0xdb, 0xc3, 0xd8, 0xba, 0x31,
-	0x2f, 0x75, 0x3a, 0xf6, 0x52, 0xd3, 0xf6, 0xd7, 0x2c, 0xac, 0xed, 0xfb, 0xc1, 0x0f, 0xf7, 0xf8,
-	0x2d, 0x5a, 0x49, 0x93, 0xdd, 0x29, 0xf2, 0xd6, 0xc6, 0xe4, 0x31, 0xc4, 0xfb, 0x98, 0x86, 0xee,
-	0x53, 0xfb, 0xc6, 0x30, 0xb6, 0x9a, 0xf3, 0x12, 0x37, 0xc6, 0x5d, 0x66, 0xd2, 0xb4, 0x33, 0x50,
-	0x6e, 0xce, 0x33, 0xd0, 0x3f, 0xb2, 0xb0, 0xfe, 0xe1, 0x00, 0x7b, 0xd4, 0xed, 0x11, 0x41, 0x76,
-	0x4a, 0xf5, 0xcf, 0xa7, 0xa8, 0xae, 0x8c, 0xa9, 0xd6, 0x31, 0x92, 0xf4, 0xf7, 0x86, 0xb1, 0x75,
-	0x73, 0x5e, 0xd2, 0x67, 0x8d, 0xf0, 0x7f, 0x47, 0xff, 0xef, 0xb2, 0xb0, 0xb2, 0x27, 0x4e, 0x6d,
-	0xc9, 0xc2, 0x8f, 0x67, 0xd0, 0xae, 0x3e, 0x53, 0x05, 0x07, 0x75, 0x1d, 0x71, 0xbe, 0x24, 0xa1,
-	0x63, 0x2f, 0x75, 0x92, 0xf8, 0x43, 0x16, 0xd6, 0x77, 0x08, 0x25, 0x2d, 0x4a, 0xda, 0xf7, 0x5c,
-	0xd2, 0x53, 0x48, 0xfc, 0xc8, 0x98, 0x62, 0xb1, 0xaa, 0x5c, 0xb3, 0x66, 0x82, 0x6c, 0x7b, 0x18,
-	0x5b, 0xb7, 0xe6, 0xe5, 0x71, 0xf6, 0x18, 0x97, 0x9a, 0xcf, 0xcf, 0xb2, 0xf0, 0x55, 0xf1, 0x74,
-	0x20, 0xde, 0x35, 0xc7, 0x74, 0xfe, 0x62, 0x8a, 0x4d, 0x4b, 0x4d, 0x05, 0x33, 0x20, 0xf6, 0xed,
-	0x61, 0x6c, 0x7d, 0x7f, 0xfe, 0x5c, 0x30, 0x63, 0x88, 0xff, 0x19, 0x6d, 0xf2, 0xd3, 0xfe, 0x79,
-	0xb5, 0xa9, 0x83, 0x2e, 0xa6, 0x4d, 0x7d, 0x8c, 0x4b, 0xcd, 0xe7, 0x5f, 0x8a, 0xb0, 0xcc, 0x55,
-	0x92, 0xd2, 0xf8, 0x2d, 0x90, 0xd7, 0x23, 0xc9, 0x21, 0x4a, 0xae, 0xd4, 0x61, 0xd0, 0xaa, 0xef,
-	0xc9, 0x8b, 0x93, 0xb0, 0x40, 0xef, 0x40, 0x31, 0xe2, 0x17, 0x57, 0x79, 0xf2, 0xad, 0x4c, 0xbe,
-	0x0d, 0xe9, 0x57, 0xe4, 0xdd, 0x8c, 0x23, 0xed, 0xd1, 0x4d, 0x28, 0xf6, 0x38, 0x8b, 0xf2, 0xe2,
-	0x5e, 0x9b, 0x44, 0x4e, 0x5f, 0xe5, 0x18, 0x5a, 0x60, 0xd0, 0x0d, 0x28, 0xf0, 0x23, 0xb6, 0x7c,
-	0xa3, 0xd6, 0x3e, 0x3b, 0x7d, 0xd0, 0xdd, 0xcd, 0x38, 0xc2, 0x1c, 0x35, 0x21, 0x1f, 0x84, 0x7e,
-	0x5f, 0x5e, 0x77, 0xae, 0x4d, 0x7e, 0x53, 0xbd, 0x1f, 0xec, 0x66, 0x1c, 0x6e, 0x8b, 0xde, 0x82,
-	0x52, 0xc4, 0x2f, 0x16, 0x11, 0x7f, 0x28, 0x62, 0xa7, 0xca, 0x09, 0x98, 0x02, 0x49, 0x4c, 0xd1,
-	0x5b, 0x50, 0x3c, 0xe6, 0xc7, 0x46, 0xf9, 0xfa, 0xb8, 0xa1, 0x82, 0xf4, 0x03, 0x25, 0x5b, 0x97,
-	0xb0, 0x45, 0xf7, 0x60, 0x89, 0xfa, 0xc1, 0x51, 0x72, 0x3a, 0x93, 0x8f, 0x4c, 0x55, 0x15, 0x3b,
-	0xeb, 0xf4, 0xb6, 0x9b, 0x71, 0x34, 0x1c, 0x7a, 0x04, 0xab, 0x8f, 0xb5, 0x63, 0x00, 0x49, 0x9e,
-	0x13, 0x35, 0x9e, 0x67, 0x1f, 0x50, 0x76, 0x33, 0xce, 0x14, 0x1a, 0xed, 0xc0, 0x4a, 0xa4, 0xed,
-	0x70, 0xf2, 0xe9, 0x5c, 0x5b, 0x97, 0xbe, 0x07, 0xee, 0x66, 0x9c, 0x09, 0x0c, 0x7a, 0x08, 0x2b,
-	0x6d, 0x2d, 0xbf, 0xcb, 0x87, 0x71, 0x6d, 0x56, 0xb3, 0x77, 0x00, 0x36, 0x9a, 0x8e, 0x45, 0x1f,
-	0xc0, 0x6a, 0x30, 0x91, 0xdb, 0xe4, 0xcb, 0xf8, 0xd7, 0xf5, 0x55, 0xce, 0x48, 0x82, 0x6c, 0x91,
-	0x93, 0x60, 0x75, 0x7a, 0x22, 0xc4, 0xcd, 0xe5, 0xb3, 0xa7, 0xa7, 0x27, 0x01, 0x75, 0x7a, 0xa2,
-	0xc7, 0x86, 0x71, 0x3a, 0xaa, 0x7d, 0x5c, 0x84, 0x25, 0x19, 0x66, 0xe2, 0x35, 0xec, 0xbb, 0x69,
-	0xe4, 0x88, 0x28, 0x7b, 0xed, 0xac, 0xc8, 0xe1, 0xe6, 0x4a, 0xe0, 0xbc, 0x99, 0x06, 0x8e, 0x08,
-	0xb9, 0xf5, 0x71, 0x8a, 0xe3, 0xdf, 0x55, 0x10, 0x32, 0x58, 0xb6, 0x92, 0x60, 0x11, 0x91, 0xf6,
-	0xea, 0xec, 0x3b, 0x65, 0x82, 0x92, 0x91, 0xb2, 0x0d, 0x25, 0x57, 0xfc, 0x44, 0x30, 0x2b, 0xc6,
-	0xa6, 0x7f, 0x41, 0x60, 0xda, 0x97, 0x00, 0xb4, 0x35, 0x8e, 0x98, 0x82, 0x7c, 0x12, 0x9f, 0x8a,
-	0x98, 0x14, 0x94, 0x04, 0xcc, 0xf5, 0x34, 0x60, 0x8a, 0x93, 0xcf, 0xe8, 0x49, 0xb8, 0xa4, 0x0b,
-	0x93, 0xd1, 0x72, 0x17, 0x96, 0x13, 0x7d, 0xf1, 0x2e, 0x19, 0x2e, 0xaf, 0x9d, 0x75, 0xac, 0x4b,
-	0xf0, 0x3a, 0x0a, 0xdd, 0x9f, 0x12, 0x65, 0x79, 0x72, 0x2b, 0x9e, 0x94, 0x64, 0x32, 0xd2, 0xa4,
-	0x22, 0x1f, 0xc0, 0x95, 0xb1, 0xa8, 0xc4, 0x9c, 0x60, 0xfa, 0x84, 0xaf, 0xc9, 0x31, 0x19, 0x6a,
-	0x12, 0xa8, 0x4e, 0x4b, 0x8a, 0x71, 0xf1, 0xac, 0x69, 0x25, 0x52, 0x9c, 0x9a, 0x96, 0xe8, 0x40,
-	0xbb, 0xb0, 0xd0, 0x27, 0x14, 0xb7, 0x31, 0xc5, 0x66, 0x89, 0x6f, 0x4b, 0xaf, 0x4f, 0x05, 0x88,
-	0x44, 0xd7, 0xdf, 0x97, 0x86, 0x77, 0x3d, 0x1a, 0x9e, 0xc8, 0xb7, 0x8b, 0x14, 0xbd, 0xf1, 0x3d,
-	0x58, 0xd6, 0x0c, 0xd0, 0x2a, 0xe4, 0x8e, 0x48, 0xf2, 0xb3, 0x11, 0x2b, 0xa2, 0x35, 0x28, 0x1c,
-	0xe3, 0xde, 0x80, 0x70, 0x7d, 0x96, 0x1d, 0x51, 0xd9, 0xce, 0xbe, 0x63, 0xd8, 0x65, 0x28, 0x85,
-	0xe2, 0x2b, 0x76, 0xe7, 0xd9, 0xf3, 0x4a, 0xe6, 0xf3, 0xe7, 0x95, 0xcc, 0x17, 0xcf, 0x2b, 0xc6,
-	0x47, 0xa3, 0x8a, 0xf1, 0xeb, 0x51, 0xc5, 0xf8, 0x74, 0x54, 0x31, 0x9e, 0x8d, 0x2a, 0xc6, 0x9f,
-	0x47, 0x15, 0xe3, 0x6f, 0xa3, 0x4a, 0xe6, 0x8b, 0x51, 0xc5, 0xf8, 0xe4, 0x45, 0x25, 0xf3, 0xec,
-	0x45, 0x25, 0xf3, 0xf9, 0x8b, 0x4a, 0xe6, 0x27, 0xd7, 0xcf, 0xbd, 0x43, 0x1e, 0x14, 0x39, 0x53,
-	0x5b, 0xff, 0x0c, 0x00, 0x00, 0xff, 0xff, 0xd4, 0x35, 0x32, 0x52, 0x3f, 0x1f, 0x00, 0x00,
+	// 1996 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xec, 0x59, 0xcd, 0x8f, 0x23, 0x47,
+	0x15, 0x77, 0xfb, 0x73, 0x5c, 0x9e, 0xf1, 0x0e, 0xb5, 0x93, 0x49, 0x33, 0xbb, 0x71, 0x1b, 0x23,
+	0xb2, 0x03, 0x82, 0x76, 0xd6, 0x93, 0x2c, 0xc9, 0x10, 0x56, 0xd9, 0xde, 0xd9, 0xc5, 0xbb, 0x6c,
+	0xc8, 0xa6, 0x67, 0xc4, 0x81, 0x4b, 0x54, 0x63, 0xd7, 0xd8, 0xcd, 0xd8, 0xdd, 0xbd, 0xdd, 0xe5,
+	0xd9, 0x1d, 0x09, 0xa1, 0x9c, 0x38, 0x11, 0x91, 0xbf, 0x02, 0x71, 0xe3, 0xc2, 0x89, 0x13, 0x17,
+	0xa4, 0xe4, 0x80, 0xb4, 0xc7, 0xc8, 0x12, 0x0d, 0xeb, 0x95, 0x10, 0x9a, 0x53, 0x24, 0xae, 0x1c,
+	0x50, 0x7d, 0x74, 0xbb, 0xda, 0xdd, 0xce, 0xda, 0x03, 0x42, 0x1a, 0xc2, 0xc5, 0xee, 0xaa, 0x7a,
+	0xbf, 0xea, 0x57, 0xbf, 0xf7, 0x7b, 0xf5, 0xd5, 0xe0, 0x9a, 0x7b, 0xdc, 0x6b, 0x3e, 0x1a, 0x61,
+	0xcf, 0xc2, 0x1e, 0xfb, 0x3f, 0xf5, 0x90, 0xdd, 0xc3, 0xd2, 0xa3, 0xee, 0x7a, 0x0e, 0x71, 0x20,
+	0x98, 0xd6, 0x6c, 0xb5, 0x7a, 0x16, 0xe9, 0x8f, 0x0e, 0xf5, 0x8e, 0x33, 0x6c, 0xf6, 0x9c, 0x9e,
+	0xd3, 0xec, 0x39, 0x4e, 0x6f, 0x80, 0x91, 0x6b, 0xf9, 0xe2, 0xb1, 0xe9, 0xb9, 0x9d, 0xa6, 0x4f,
+	0x10, 0x19, 0xf9, 0x1c, 0xbf, 0xb5, 0x41, 0x0d, 0xd9, 0x23, 0x83, 0x88, 0x5a, 0x4d, 0x98, 0xb3,
+	0xd2, 0xe1, 0xe8, 0xa8, 0x49, 0xac, 0x21, 0xf6, 0x09, 0x1a, 0xba, 0xa1, 0x01, 0xf5, 0x6f, 0xe0,
+	0xf4, 0x38, 0xd2, 0xb2, 0xbb, 0xf8, 0x49, 0x0f, 0x11, 0xfc, 0x18, 0x9d, 0x0a, 0x83, 0x2b, 0x31,
+	0x83, 0xf0, 0x41, 0x34, 0x6e, 0xc5, 0x1a, 0x5d, 0x44, 0x08, 0xf6, 0x6c, 0xd1, 0xf6, 0xd5, 0x58,
+	0x9b, 0x7f, 0x8c, 0x49, 0xa7, 0x2f, 0x9a, 0xea, 0xa2, 0xe9, 0xd1, 0x60, 0xe8, 0x74, 0xf1, 0x80,
+	0x0d, 0xc4, 0xe7, 0xbf, 0xc2, 0xe2, 0x32, 0xb5, 0x70, 0x47, 0x7e, 0x9f, 0xfd, 0x88, 0xca, 0xdb,
+	0x2f, 0xe4, 0xf2, 0x10, 0xf9, 0xb8, 0xd9, 0xc5, 0x47, 0x96, 0x6d, 0x11, 0xcb, 0xb1, 0x7d, 0xf9,
+	0x59, 0x74, 0x72, 0x63, 0xb1, 0x4e, 0x66, 0xe3, 0xb3, 0xf5, 0x1a, 0xc5, 0xf9, 0xc4, 0xf1, 0x50,
+	0x0f, 0x37, 0x3b, 0xfd, 0x91, 0x7d, 0xdc, 0xec, 0xa0, 0x4e, 0x1f, 0x37, 0x3d, 0xec, 0x8f, 0x06,
+	0xc4, 0xe7, 0x05, 0x72, 0xea, 0x62, 0xf1, 0xa6, 0xc6, 0xa7, 0x79, 0x50, 0x79, 0xe0, 0x1c, 0x5b,
+	0x26, 0x7e, 0x34, 0xc2, 0x3e, 0x81, 0x1b, 0xa0, 0xc0, 0x7a, 0x55, 0x95, 0xba, 0xb2, 0x5d, 0x36,
+	0x79, 0x81, 0xd6, 0x0e, 0xac, 0xa1, 0x45, 0xd4, 0x6c, 0x5d, 0xd9, 0x5e, 0x33, 0x79, 0x01, 0x42,
+	0x90, 0xf7, 0x09, 0x76, 0xd5, 0x5c, 0x5d, 0xd9, 0xce, 0x99, 0xec, 0x19, 0x6e, 0x81, 0x15, 0xcb,
+	0x26, 0xd8, 0x3b, 0x41, 0x03, 0xb5, 0xcc, 0xea, 0xa3, 0x32, 0xbc, 0x09, 0x4a, 0x3e, 0x41, 0x1e,
+	0x39, 0xf0, 0xd5, 0x7c, 0x5d, 0xd9, 0xae, 0xb4, 0xb6, 0x74, 0x1e, 0x79, 0x3d, 0x8c, 0xbc, 0x7e,
+	0x10, 0x46, 0xde, 0x58, 0xf9, 0x24, 0xd0, 0x32, 0x1f, 0xff, 0x45, 0x53, 0xcc, 0x10, 0x04, 0x77,
+	0x41, 0x01, 0xdb, 0xdd, 0x03, 0x5f, 0x2d, 0x2c, 0x81, 0xe6, 0x10, 0x78, 0x1d, 0x94, 0xbb, 0x96,
+	0x87, 0x3b, 0x94, 0x65, 0xb5, 0x58, 0x57, 0xb6, 0xab, 0xad, 0xcb, 0x7a, 0x24, 0x94, 0xbd, 0xb0,
+	0xc9, 0x9c, 0x5a, 0xd1, 0xe1, 0xb9, 0x88, 0xf4, 0xd5, 0x12, 0x63, 0x82, 0x3d, 0xc3, 0x06, 0x28,
+	0xfa, 0x7d, 0xe4, 0x75, 0x7d, 0x75, 0xa5, 0x9e, 0xdb, 0x2e, 0x1b, 0xe0, 0x2c, 0xd0, 0x44, 0x8d,
+	0x29, 0xfe, 0xe1, 0x07, 0x20, 0xef, 0x0e, 0x90, 0xad, 0x02, 0xe6, 0xe5, 0xba, 0x2e, 0x45, 0xe9,
+	0xe1, 0x00, 0xd9, 0xc6, 0x5b, 0xe3, 0x40, 0x7b, 0x43, 0x4e, 0x1e, 0x0f, 0x1d, 0x21, 0x1b, 0x35,
+	0x07, 0xce, 0xb1, 0xd5, 0x3c, 0xd9, 0x69, 0xca, 0xb1, 0xa7, 0x1d, 0xe9, 0xef, 0xd3, 0x0e, 0x28,
+	0xd4, 0x64, 0x1d, 0xc3, 0xfb, 0xa0, 0x42, 0x63, 0x8c, 0x6f, 0xd3, 0x00, 0xfb, 0x6a, 0x85, 0xbd,
+	0xe7, 0xe5, 0xe9, 0x68, 0x58, 0xbd, 0x89, 0x8f, 0x7e, 0xe0, 0x39, 0x23, 0xd7, 0xb8, 0x74, 0x16,
+	0x68, 0xb2, 0xbd, 0x29, 0x17, 0xe0, 0x7d, 0x50, 0xa5, 0xa2, 0xb0, 0xec, 0xde, 0x7b, 0x2e, 0x53,
+	0xa0, 0xba, 0xca, 0xba, 0xbb, 0xaa, 0xcb, 0x92, 0xd1, 0x6f, 0xc7, 0x6c, 0x8c, 0x3c, 0xa5, 0xd7,
+	0x9c, 0x41, 0x36, 0x26, 0x39, 0x00, 0xa9, 0x96, 0xee, 0xd9, 0x3e, 0x41, 0x36, 0x39, 0x8f, 0xa4,
+	0xde, 0x06, 0x45, 0x9a, 0xfc, 0x07, 0xbe, 0x9a, 0x5b, 0x22, 0xc6, 0x02, 0x13, 0x0f, 0x72, 0x7e,
+	0xa9, 0x20, 0x17, 0x52, 0x83, 0x5c, 0x7c, 0x61, 0x90, 0x4b, 0xff, 0xa5, 0x20, 0xaf, 0xfc, 0x67,
+	0x83, 0x5c, 0x3e, 0x77, 0x90, 0x55, 0x90, 0xa7, 0x5e, 0xc2, 0x75, 0x90, 0xf3, 0xd0, 0x63, 0x16,
+	0xd3, 0x55, 0x93, 0x3e, 0x36, 0x26, 0x79, 0xb0, 0xca, 0xa7, 0x12, 0xdf, 0x75, 0x6c, 0x1f, 0x53,
+	0x1e, 0xf7, 0xd9, 0xec, 0xcf, 0x23, 0x2f, 0x78, 0x64, 0x35, 0xa6, 0x68, 0x81, 0xef, 0x80, 0xfc,
+	0x1e, 0x22, 0x88, 0xa9, 0xa0, 0xd2, 0xda, 0x90, 0x79, 0xa4, 0x7d, 0xd1, 0x36, 0x63, 0x93, 0x3a,
+	0x72, 0x16, 0x68, 0xd5, 0x2e, 0x22, 0xe8, 0xdb, 0xce, 0xd0, 0x22, 0x78, 0xe8, 0x92, 0x53, 0x93,
+	0x21, 0xe1, 0x1b, 0xa0, 0x7c, 0xc7, 0xf3, 0x1c, 0xef, 0xe0, 0xd4, 0xc5, 0x4c, 0x35, 0x65, 0xe3,
+	0xe5, 0xb3, 0x40, 0xbb, 0x8c, 0xc3, 0x4a, 0x09, 0x31, 0xb5, 0x84, 0xdf, 0x04, 0x05, 0x56, 0x60,
+	0x3a, 0x29, 0x1b, 0x97, 0xcf, 0x02, 0xed, 0x12, 0x83, 0x48, 0xe6, 0xdc, 0x22, 0x2e, 0xab, 0xc2,
+	0x42, 0xb2, 0x8a, 0xd4, 0x5d, 0x94, 0xd5, 0xad, 0x82, 0xd2, 0x09, 0xf6, 0x7c, 0xcb, 0xe1, 0xba,
+	0x59, 0x33, 0xc3, 0x22, 0xbc, 0x05, 0x00, 0x25, 0xc6, 0xf2, 0x89, 0xd5, 0x09, 0x83, 0xbd, 0xa6,
+	0xf3, 0xc5, 0xc6, 0x64, 0x31, 0x32, 0xa0, 0x60, 0x41, 0x32, 0x34, 0xa5, 0x67, 0xf8, 0x5b, 0x05,
+	0x94, 0xda, 0x18, 0x75, 0xb1, 0x47, 0xc3, 0x9b, 0xdb, 0xae, 0xb4, 0xbe, 0xa1, 0xcb, 0x2b, 0xcb,
+	0x43, 0xcf, 0x19, 0x62, 0xd2, 0xc7, 0x23, 0x3f, 0x0c, 0x10, 0xb7, 0x36, 0xec, 0x71, 0xa0, 0xe1,
+	0x05, 0xa5, 0xba, 0xd0, 0x82, 0x36, 0xf7, 0x55, 0x67, 0x81, 0xa6, 0x7c, 0xc7, 0x0c, 0xbd, 0x84,
+	0x2d, 0xb0, 0xf2, 0x18, 0x79, 0xb6, 0x65, 0xf7, 0x7c, 0x15, 0xb0, 0x4c, 0xdb, 0x3c, 0x0b, 0x34,
+	0x18, 0xd6, 0x49, 0x81, 0x88, 0xec, 0x1a, 0x7f, 0x56, 0xc0, 0x57, 0xa8, 0x30, 0xf6, 0xa9, 0x3f,
+	0xbe, 0x34, 0xc5, 0x0c, 0x11, 0xe9, 0xf4, 0x55, 0x85, 0x76, 0x63, 0xf2, 0x82, 0xbc, 0xde, 0x64,
+	0xff, 0xad, 0xf5, 0x26, 0xb7, 0xfc, 0x7a, 0x13, 0xce, 0x2b, 0xf9, 0xd4, 0x79, 0xa5, 0x30, 0x6f,
+	0x5e, 0x69, 0xfc, 0x4a, 0xcc, 0xa1, 0xe1, 0xf8, 0x96, 0x48, 0xa5, 0xbb, 0x51, 0x2a, 0xe5, 0x98,
+	0xb7, 0x91, 0x42, 0x79, 0x5f, 0xf7, 0xba, 0xd8, 0x26, 0xd6, 0x91, 0x85, 0xbd, 0x17, 0x24, 0x94,
+	0xa4, 0xd2, 0x5c, 0x5c, 0xa5, 0xb2, 0xc4, 0xf2, 0x17, 0x42, 0x62, 0xf1, 0xbc, 0x2a, 0x9c, 0x23,
+	0xaf, 0x1a, 0xff, 0xc8, 0x82, 0x4d, 0x1a, 0x91, 0x07, 0xe8, 0x10, 0x0f, 0x7e, 0x84, 0x86, 0x4b,
+	0x46, 0xe5, 0x55, 0x29, 0x2a, 0x65, 0x03, 0xfe, 0x9f, 0xf5, 0xc5, 0x58, 0xff, 0xb5, 0x02, 0x56,
+	0xc2, 0x05, 0x00, 0xea, 0x00, 0x70, 0x18, 0x9b, 0xe3, 0x39, 0xd7, 0x55, 0x0a, 0xf6, 0xa2, 0x5a,
+	0x53, 0xb2, 0x80, 0x3f, 0x05, 0x45, 0x5e, 0x12, 0xb9, 0x20, 0x2d, 0x9b, 0xfb, 0xc4, 0xc3, 0x68,
+	0x78, 0xab, 0x8b, 0x5c, 0x82, 0x3d, 0xe3, 0x2d, 0xea, 0xc5, 0x38, 0xd0, 0xae, 0xcd, 0x63, 0x29,
+	0xdc, 0xe1, 0x0b, 0x1c, 0x8d, 0x2f, 0x7f, 0xa7, 0x29, 0xde, 0xd0, 0xf8, 0x48, 0x01, 0xeb, 0xd4,
+	0x51, 0x4a, 0x4d, 0x24, 0x8c, 0x3d, 0xb0, 0xe2, 0x89, 0x67, 0xe6, 0x6e, 0xa5, 0xd5, 0xd0, 0xe3,
+	0xb4, 0xa6, 0x50, 0xc9, 0x16, 0x5c, 0xc5, 0x8c, 0x90, 0x70, 0x27, 0x46, 0x63, 0x36, 0x8d, 0x46,
+	0xbe, 0x46, 0xcb, 0xc4, 0xfd, 0x21, 0x0b, 0xe0, 0x3d, 0x7a, 0x42, 0xa2, 0xfa, 0x9b, 0x4a, 0xf5,
+	0x49, 0xc2, 0xa3, 0xab, 0x53, 0x52, 0x92, 0xf6, 0xc6, 0xcd, 0x71, 0xa0, 0xed, 0xbe, 0x40, 0x3b,
+	0x5f, 0x80, 0x97, 0x46, 0x21, 0xcb, 0x37, 0x7b, 0x11, 0xe4, 0xdb, 0xf8, 0x5d, 0x16, 0x54, 0x7f,
+	0xec, 0x0c, 0x46, 0x43, 0x1c, 0xd1, 0xe7, 0x26, 0xe8, 0x53, 0xa7, 0xf4, 0xc5, 0x6d, 0x8d, 0xdd,
+	0x71, 0xa0, 0xdd, 0x58, 0x94, 0xba, 0x38, 0xf6, 0x42, 0xd3, 0xf6, 0xb7, 0x2c, 0xd8, 0x38, 0x70,
+	0xdc, 0x1f, 0xee, 0xb3, 0x53, 0xb4, 0x34, 0x4d, 0xf6, 0x13, 0xe4, 0x6d, 0x4c, 0xc9, 0xa3, 0x88,
+	0x77, 0x11, 0xf1, 0xac, 0x27, 0xc6, 0x8d, 0x71, 0xa0, 0xb5, 0x16, 0x25, 0x6e, 0x8a, 0xbb, 0xc8,
+	0xa4, 0xc5, 0xf6, 0x40, 0xb9, 0x05, 0xf7, 0x40, 0xff, 0xcc, 0x82, 0xcd, 0xf7, 0x47, 0xc8, 0x26,
+	0xd6, 0x00, 0x73, 0xb2, 0x23, 0xaa, 0x7f, 0x96, 0xa0, 0xba, 0x36, 0xa5, 0x3a, 0x8e, 0x11, 0xa4,
+	0xbf, 0x33, 0x0e, 0xb4, 0xb7, 0x17, 0x25, 0x3d, 0xad, 0x87, 0x2f, 0x1d, 0xfd, 0xbf, 0xcc, 0x81,
+	0x2b, 0xb7, 0x9d, 0x91, 0x4d, 0xf6, 0xe8, 0x94, 0x6b, 0x77, 0xc8, 0x4c, 0x0c, 0x7e, 0xa1, 0x24,
+	0x82, 0xf0, 0x75, 0xe9, 0xdc, 0x96, 0x44, 0x8a, 0x48, 0xdc, 0x19, 0x07, 0xda, 0xad, 0x45, 0x23,
+	0x31, 0xb7, 0x9b, 0x2f, 0x5d, 0x38, 0x7e, 0x9f, 0x05, 0xd5, 0x7d, 0xbe, 0x89, 0x0e, 0x07, 0x7e,
+	0x92, 0x92, 0x05, 0xf2, 0xad, 0xa1, 0x7b, 0xa8, 0xc7, 0x11, 0xcb, 0xcd, 0xd9, 0x71, 0xec, 0x85,
+	0x9e, 0xb3, 0xff, 0x94, 0x05, 0x9b, 0x7b, 0x98, 0xe0, 0x0e, 0xc1, 0xdd, 0xbb, 0x16, 0x1e, 0x48,
+	0x24, 0x7e, 0x98, 0x94, 0x71, 0x5d, 0x3a, 0xf5, 0xa6, 0x82, 0x0c, 0x63, 0x1c, 0x68, 0x37, 0x17,
+	0xe5, 0x31, 0xbd, 0x8f, 0x0b, 0xcd, 0xe7, 0xa7, 0x59, 0xf0, 0x12, 0xbf, 0xc9, 0xe1, 0xd7, 0xcc,
+	0x53, 0x3a, 0x7f, 0x9e, 0x60, 0x53, 0x93, 0x67, 0xe6, 0x14, 0x88, 0x71, 0x6b, 0x1c, 0x68, 0xdf,
+	0x5f, 0x7c, 0x6a, 0x4e, 0xe9, 0xe2, 0x7f, 0x46, 0x9b, 0xec, 0xf0, 0xb5, 0xac, 0x36, 0xe3, 0xa0,
+	0xf3, 0x69, 0x33, 0xde, 0xc7, 0x85, 0xe6, 0xf3, 0x8f, 0x25, 0xb0, 0xc6, 0x54, 0x12, 0xd1, 0xf8,
+	0x2d, 0x20, 0x4e, 0xab, 0x82, 0x43, 0x18, 0xde, 0x70, 0x78, 0x6e, 0x47, 0xdf, 0x17, 0xe7, 0x58,
+	0x6e, 0x01, 0xdf, 0x04, 0x45, 0x9f, 0x3a, 0x15, 0x1e, 0x44, 0x6a, 0xb3, 0x57, 0x75, 0xf1, 0x1b,
+	0x8b, 0x76, 0xc6, 0x14, 0xf6, 0xf4, 0x4e, 0x77, 0xc0, 0x58, 0x54, 0x73, 0x89, 0xa3, 0x90, 0x9e,
+	0x7e, 0xb2, 0xa6, 0x68, 0x8e, 0x81, 0x37, 0x40, 0x81, 0x7a, 0x10, 0x7e, 0x32, 0x88, 0xbd, 0x36,
+	0x79, 0xee, 0x68, 0x67, 0x4c, 0x6e, 0x0e, 0x5b, 0x20, 0xef, 0x7a, 0xce, 0x50, 0x9c, 0x3e, 0xaf,
+	0xce, 0xbe, 0x53, 0x3e, 0xae, 0xb5, 0x33, 0x26, 0xb3, 0x85, 0xaf, 0xd3, 0x0b, 0x23, 0x7a, 0xce,
+	0xf3, 0xd5, 0xa2, 0xd8, 0xe4, 0xcf, 0xc0, 0x24, 0x48, 0x68, 0x0a, 0x5f, 0x07, 0xc5, 0x13, 0xb6,
+	0x8b, 0x17, 0x97, 0xc1, 0x5b, 0x32, 0x28, 0xbe, 0xbf, 0xa7, 0xe3, 0xe2, 0xb6, 0xf0, 0x2e, 0x58,
+	0x25, 0x8e, 0x7b, 0x1c, 0x6e, 0x96, 0xc5, 0x9d, 0x5f, 0x5d, 0xc6, 0xa6, 0x6d, 0xa6, 0xdb, 0x19,
+	0x33, 0x86, 0x83, 0x0f, 0xc1, 0xfa, 0xa3, 0xd8, 0xae, 0x0c, 0x87, 0xb7, 0xbb, 0x31, 0x9e, 0xd3,
+	0xf7, 0x8b, 0xed, 0x8c, 0x99, 0x40, 0xc3, 0x3d, 0x50, 0xf5, 0x63, 0x2b, 0x9c, 0x0a, 0x92, 0xe3,
+	0x8a, 0xaf, 0x81, 0xed, 0x8c, 0x39, 0x83, 0x81, 0x0f, 0x40, 0xb5, 0x1b, 0x9b, 0xdf, 0xd5, 0x4a,
+	0xd2, 0xab, 0xf4, 0x15, 0x80, 0xf6, 0x16, 0xc7, 0xc2, 0xf7, 0xc0, 0xba, 0x3b, 0x33, 0xb7, 0x89,
+	0x0f, 0x15, 0x5f, 0x8b, 0x8f, 0x32, 0x65, 0x12, 0xa4, 0x83, 0x9c, 0x05, 0xcb, 0xee, 0xf1, 0x14,
+	0x57, 0xd7, 0xe6, 0xbb, 0x17, 0x9f, 0x04, 0x64, 0xf7, 0x78, 0x0b, 0xfc, 0x00, 0xbc, 0xd4, 0x49,
+	0x6e, 0xc8, 0xb0, 0xaf, 0x56, 0x59, 0xa7, 0xd7, 0xe4, 0x4e, 0xbf, 0x60, 0xeb, 0xd8, 0xce, 0x98,
+	0xe9, 0xfd, 0x18, 0x60, 0x3a, 0xdf, 0x35, 0x3e, 0x2a, 0x82, 0x55, 0x91, 0xc7, 0xfc, 0xf6, 0xf3,
+	0xbb, 0x51, 0x6a, 0xf2, 0x34, 0x7e, 0x65, 0x5e, 0x6a, 0x32, 0x73, 0x29, 0x33, 0x5f, 0x8b, 0x32,
+	0x93, 0xe7, 0xf4, 0xe6, 0x74, 0x0e, 0x65, 0x03, 0x93, 0x10, 0x22, 0x1b, 0x77, 0xc2, 0x6c, 0xe4,
+	0xa9, 0x7c, 0x25, 0xfd, 0x0e, 0x21, 0x44, 0x89, 0x54, 0xdc, 0x05, 0x25, 0x8b, 0x7f, 0x12, 0x4a,
+	0x4b, 0xe2, 0xe4, 0x17, 0x23, 0x9a, 0x5c, 0x02, 0x00, 0x77, 0xa6, 0x29, 0x59, 0x10, 0x9f, 0x40,
+	0x12, 0x29, 0x19, 0x81, 0xc2, 0x8c, 0xbc, 0x1e, 0x65, 0x64, 0x71, 0xf6, 0xb3, 0x49, 0x98, 0x8f,
+	0xd1, 0xc0, 0x44, 0x3a, 0xde, 0x01, 0x6b, 0xa1, 0x80, 0x59, 0x93, 0xc8, 0xc7, 0x57, 0xe6, 0xed,
+	0x1b, 0x43, 0x7c, 0x1c, 0x05, 0xef, 0x25, 0x54, 0x5f, 0x9e, 0x5d, 0xeb, 0x67, 0x35, 0x1f, 0xf6,
+	0x34, 0x2b, 0xf9, 0xfb, 0xe0, 0xd2, 0x54, 0xb5, 0xdc, 0x27, 0x90, 0x3c, 0xd1, 0xc5, 0xf4, 0x1e,
+	0x76, 0x35, 0x0b, 0x94, 0xdd, 0x12, 0x6a, 0xaf, 0xcc, 0x73, 0x2b, 0xd4, 0x7a, 0xc2, 0x2d, 0x21,
+	0xf5, 0x36, 0x58, 0x19, 0x62, 0x82, 0xe8, 0x1d, 0xa6, 0x5a, 0x62, 0xeb, 0xde, 0xab, 0x89, 0x0c,
+	0x14, 0x68, 0xfd, 0x5d, 0x61, 0x78, 0xc7, 0x26, 0xde, 0xa9, 0xb8, 0xab, 0x8a, 0xd0, 0x5b, 0xdf,
+	0x03, 0x6b, 0x31, 0x03, 0xfa, 0x49, 0xe9, 0x18, 0x87, 0x9f, 0x09, 0xe9, 0x23, 0xbd, 0xd7, 0x3f,
+	0x41, 0x83, 0x11, 0x66, 0xfa, 0x2c, 0x9b, 0xbc, 0xb0, 0x9b, 0x7d, 0x53, 0x31, 0xca, 0xa0, 0xe4,
+	0xf1, 0xb7, 0x18, 0xbd, 0xa7, 0xcf, 0x6a, 0x99, 0xcf, 0x9e, 0xd5, 0x32, 0x9f, 0x3f, 0xab, 0x29,
+	0x1f, 0x4e, 0x6a, 0xca, 0x6f, 0x26, 0x35, 0xe5, 0x93, 0x49, 0x4d, 0x79, 0x3a, 0xa9, 0x29, 0x7f,
+	0x9d, 0xd4, 0x94, 0xbf, 0x4f, 0x6a, 0x99, 0xcf, 0x27, 0x35, 0xe5, 0xe3, 0xe7, 0xb5, 0xcc, 0xd3,
+	0xe7, 0xb5, 0xcc, 0x67, 0xcf, 0x6b, 0x99, 0x9f, 0x5c, 0x5f, 0x7a, 0x09, 0x3e, 0x2c, 0x32, 0xa6,
+	0x76, 0xfe, 0x35, 0x00, 0x49, 0x5e, 0x04, 0x41, 0x2f, 0x21, 0x00, 0x00,
 }
 
 func (this *LokiRequest) Equal(that interface{}) bool {
@@ -2177,6 +2240,50 @@ func (this *QuantileSketchResponse) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *CountDistinctSketchResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*CountDistinctSketchResponse)
+	if !ok {
+		that2, ok := that.(CountDistinctSketchResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if that1.Response == nil {
+		if this.Response != nil {
+			return false
+		}
+	} else if !this.Response.Equal(*that1.Response) {
+		return false
+	}
+	if len(this.Headers) != len(that1.Headers) {
+		return false
+	}
+	for i := range this.Headers {
+		if !this.Headers[i].Equal(that1.Headers[i]) {
+			return false
+		}
+	}
+	if len(this.Warnings) != len(that1.Warnings) {
+		return false
+	}
+	for i := range this.Warnings {
+		if this.Warnings[i] != that1.Warnings[i] {
+			return false
+		}
+	}
+	return true
+}
 func (this *ShardsResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
@@ -2642,6 +2749,30 @@ func (this *QueryResponse_DetectedLabels) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *QueryResponse_CountDistinctSketches) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*QueryResponse_CountDistinctSketches)
+	if !ok {
+		that2, ok := that.(QueryResponse_CountDistinctSketches)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !this.CountDistinctSketches.Equal(that1.CountDistinctSketches) {
+		return false
+	}
+	return true
+}
 func (this *QueryRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
@@ -3110,6 +3241,18 @@ func (this *QuantileSketchResponse) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *CountDistinctSketchResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&queryrange.CountDistinctSketchResponse{")
+	s = append(s, "Response: "+fmt.Sprintf("%#v", this.Response)+",\n")
+	s = append(s, "Headers: "+fmt.Sprintf("%#v", this.Headers)+",\n")
+	s = append(s, "Warnings: "+fmt.Sprintf("%#v", this.Warnings)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func (this *ShardsResponse) GoString() string {
 	if this == nil {
 		return "nil"
@@ -3158,7 +3301,7 @@ func (this *QueryResponse) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 17)
+	s := make([]string, 0, 18)
 	s = append(s, "&queryrange.QueryResponse{")
 	if this.Status != nil {
 		s = append(s, "Status: "+fmt.Sprintf("%#v", this.Status)+",\n")
@@ -3265,6 +3408,14 @@ func (this *QueryResponse_DetectedLabels) GoString() string {
 		`DetectedLabels:` + fmt.Sprintf("%#v", this.DetectedLabels) + `}`}, ", ")
 	return s
 }
+func (this *QueryResponse_CountDistinctSketches) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&queryrange.QueryResponse_CountDistinctSketches{` +
+		`CountDistinctSketches:` + fmt.Sprintf("%#v", this.CountDistinctSketches) + `}`}, ", ")
+	return s
+}
 func (this *QueryRequest) GoString() string {
 	if this == nil {
 		return "nil"
@@ -4232,6 +4383,64 @@ func (m *QuantileSketchResponse) MarshalToSizedBuffer(dAtA []byte) (int, error)
 	return len(dAtA) - i, nil
 }
 
+func (m *CountDistinctSketchResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CountDistinctSketchResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CountDistinctSketchResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Warnings) > 0 {
+		for iNdEx := len(m.Warnings) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Warnings[iNdEx])
+			copy(dAtA[i:], m.Warnings[iNdEx])
+			i = encodeVarintQueryrange(dAtA, i, uint64(len(m.Warnings[iNdEx])))
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if len(m.Headers) > 0 {
+		for iNdEx := len(m.Headers) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size := m.Headers[iNdEx].Size()
+				i -= size
+				if _, err := m.Headers[iNdEx].MarshalTo(dAtA[i:]); err != nil {
+					return 0, err
+				}
+				i = encodeVarintQueryrange(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.Response != nil {
+		{
+			size := m.Response.Size()
+			i -= size
+			if _, err := m.Response.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+			i = encodeVarintQueryrange(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *ShardsResponse) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -4712,6 +4921,27 @@ func (m *QueryResponse_DetectedLabels) MarshalToSizedBuffer(dAtA []byte) (int, e
 	}
 	return len(dAtA) - i, nil
 }
+func (m *QueryResponse_CountDistinctSketches) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QueryResponse_CountDistinctSketches) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.CountDistinctSketches != nil {
+		{
+			size, err := m.CountDistinctSketches.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintQueryrange(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x72
+	}
+	return len(dAtA) - i, nil
+}
 func (m *QueryRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -5334,6 +5564,31 @@ func (m *QuantileSketchResponse) Size() (n int) {
 	return n
 }
 
+func (m *CountDistinctSketchResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Response != nil {
+		l = m.Response.Size()
+		n += 1 + l + sovQueryrange(uint64(l))
+	}
+	if len(m.Headers) > 0 {
+		for _, e := range m.Headers {
+			l = e.Size()
+			n += 1 + l + sovQueryrange(uint64(l))
+		}
+	}
+	if len(m.Warnings) > 0 {
+		for _, s := range m.Warnings {
+			l = len(s)
+			n += 1 + l + sovQueryrange(uint64(l))
+		}
+	}
+	return n
+}
+
 func (m *ShardsResponse) Size() (n int) {
 	if m == nil {
 		return 0
@@ -5570,6 +5825,18 @@ func (m *QueryResponse_DetectedLabels) Size() (n int) {
 	}
 	return n
 }
+func (m *QueryResponse_CountDistinctSketches) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.CountDistinctSketches != nil {
+		l = m.CountDistinctSketches.Size()
+		n += 1 + l + sovQueryrange(uint64(l))
+	}
+	return n
+}
 func (m *QueryRequest) Size() (n int) {
 	if m == nil {
 		return 0
@@ -5900,6 +6167,18 @@ func (this *QuantileSketchResponse) String() string {
 	}, "")
 	return s
 }
+func (this *CountDistinctSketchResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&CountDistinctSketchResponse{`,
+		`Response:` + fmt.Sprintf("%v", this.Response) + `,`,
+		`Headers:` + fmt.Sprintf("%v", this.Headers) + `,`,
+		`Warnings:` + fmt.Sprintf("%v", this.Warnings) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func (this *ShardsResponse) String() string {
 	if this == nil {
 		return "nil"
@@ -6075,6 +6354,16 @@ func (this *QueryResponse_DetectedLabels) String() string {
 	}, "")
 	return s
 }
+func (this *QueryResponse_CountDistinctSketches) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&QueryResponse_CountDistinctSketches{`,
+		`CountDistinctSketches:` + strings.Replace(fmt.Sprintf("%v", this.CountDistinctSketches), "CountDistinctSketchResponse", "CountDistinctSketchResponse", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func (this *QueryRequest) String() string {
 	if this == nil {
 		return "nil"
@@ -8770,6 +9059,161 @@ func (m *QuantileSketchResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *CountDistinctSketchResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowQueryrange
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CountDistinctSketchResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CountDistinctSketchResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Response", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQueryrange
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthQueryrange
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthQueryrange
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Response == nil {
+				m.Response = &github_com_grafana_loki_v3_pkg_logproto.CountDistinctSketchMatrix{}
+			}
+			if err := m.Response.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Headers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQueryrange
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthQueryrange
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthQueryrange
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Headers = append(m.Headers, github_com_grafana_loki_v3_pkg_querier_queryrange_queryrangebase_definitions.PrometheusResponseHeader{})
+			if err := m.Headers[len(m.Headers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Warnings", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQueryrange
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthQueryrange
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthQueryrange
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Warnings = append(m.Warnings, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipQueryrange(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthQueryrange
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthQueryrange
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *ShardsResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
@@ -9747,6 +10191,41 @@ func (m *QueryResponse) Unmarshal(dAtA []byte) error {
 			}
 			m.Response = &QueryResponse_DetectedLabels{v}
 			iNdEx = postIndex
+		case 14:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CountDistinctSketches", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQueryrange
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthQueryrange
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthQueryrange
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &CountDistinctSketchResponse{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Response = &QueryResponse_CountDistinctSketches{v}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipQueryrange(dAtA[iNdEx:])
@@ -0,0 +1,398 @@
+package queryrange
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/tenant"
+	"github.com/grafana/dskit/user"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/v3/pkg/util/spanlogger"
+)
+
+// RecordingRulesConfig configures the rewriting of metric queries matching
+// recording rules to queries of the series recorded by the rules.
+type RecordingRulesConfig struct {
+	Enabled       bool             `yaml:"enabled"`
+	SourceURL     flagext.URLValue `yaml:"source_url"`
+	SourceTimeout time.Duration    `yaml:"source_timeout"`
+	MaxFreshness  time.Duration    `yaml:"max_freshness"`
+	Rules         []RecordingRule  `yaml:"rules" doc:"description=The recording rules whose series answer the queries matching their expression.\nExample:\n rules:\n - record: app:rate5m\n expr: sum by (app) (rate({env=\"prod\"}[5m]))\n labels:\n source: loki\n interval: 1m\n tenants: [tenant-a]\nThe queries are matched regardless of the order of their label matchers and grouping labels. The labels of the rule are removed from the recorded series. The series are only used for queries with a step of at least the interval of the rule."`
+}
+
+// RecordingRule is a recording rule whose series are queried from the source
+// instead of running the queries matching its expression.
+type RecordingRule struct {
+	// Record is the name of the series recorded by the rule.
+	Record string `yaml:"record"`
+	Expr   string `yaml:"expr"`
+	// Labels are the labels added by the rule to its series.
+	Labels map[string]string `yaml:"labels"`
+	// Interval is the evaluation interval of the rule: only the queries with a
+	// step of at least the interval are rewritten.
+	Interval model.Duration `yaml:"interval"`
+	// Tenants are the tenants whose queries are rewritten, all of them if empty.
+	Tenants []string `yaml:"tenants"`
+}
+
+// RegisterFlags registers the flags of the recording rules config.
+func (cfg *RecordingRulesConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "querier.recording-rules.enabled", false, "Answer the metric range queries matching the configured recording rules with the series recorded by the rules.")
+	f.Var(&cfg.SourceURL, "querier.recording-rules.source-url", "URL of the Prometheus-compatible API the series of the recording rules are remote-written to. The queries are sent to its /api/v1/query_range endpoint with the tenant in the X-Scope-OrgID header.")
+	f.DurationVar(&cfg.SourceTimeout, "querier.recording-rules.source-timeout", 30*time.Second, "Timeout of the queries to the source of the series of the recording rules.")
+	f.DurationVar(&cfg.MaxFreshness, "querier.recording-rules.max-freshness", 5*time.Minute, "The parts of the queries more recent than now minus this duration are always executed, as the recording rules may not have been evaluated and remote-written for them yet.")
+}
+
+// Validate validates the config.
+func (cfg *RecordingRulesConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.SourceURL.URL == nil {
+		return errors.New("the source URL is required")
+	}
+	_, err := newRecordingRules(cfg.Rules)
+	return err
+}
+
+// RecordingRuleSource is the Prometheus-compatible source of the series
+// recorded by the recording rules.
+type RecordingRuleSource interface {
+	// QueryRange runs a PromQL range query for a tenant.
+	QueryRange(ctx context.Context, tenantID, query string, start, end time.Time, step time.Duration) (*queryrangebase.PrometheusResponse, error)
+}
+
+type prometheusSource struct {
+	url    *url.URL
+	client *http.Client
+}
+
+// NewPrometheusRecordingRuleSource returns a source querying the query_range
+// API of a Prometheus-compatible server.
+func NewPrometheusRecordingRuleSource(u *url.URL, timeout time.Duration) RecordingRuleSource {
+	return &prometheusSource{
+		url:    u,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *prometheusSource) QueryRange(ctx context.Context, tenantID, query string, start, end time.Time, step time.Duration) (*queryrangebase.PrometheusResponse, error) {
+	u := s.url.JoinPath("/api/v1/query_range")
+	u.RawQuery = url.Values{
+		"query": []string{query},
+		"start": []string{strconv.FormatFloat(float64(start.UnixMilli())/1e3, 'f', -1, 64)},
+		"end":   []string{strconv.FormatFloat(float64(end.UnixMilli())/1e3, 'f', -1, 64)},
+		"step":  []string{strconv.FormatFloat(step.Seconds(), 'f', -1, 64)},
+	}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(user.OrgIDHeaderName, tenantID)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	res, err := queryrangebase.PrometheusCodecForRangeQueries.DecodeResponse(ctx, resp, nil)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*queryrangebase.PrometheusResponse), nil
+}
+
+// normalizeExpr returns the string of an expression whose matchers and
+// grouping labels are sorted, so that equivalent expressions are equal.
+func normalizeExpr(expr syntax.Expr) (string, error) {
+	expr, err := syntax.Clone(expr)
+	if err != nil {
+		return "", err
+	}
+	expr.Walk(func(e syntax.Expr) {
+		switch e := e.(type) {
+		case *syntax.MatchersExpr:
+			sort.Slice(e.Mts, func(i, j int) bool {
+				if e.Mts[i].Name != e.Mts[j].Name {
+					return e.Mts[i].Name < e.Mts[j].Name
+				}
+				if e.Mts[i].Type != e.Mts[j].Type {
+					return e.Mts[i].Type < e.Mts[j].Type
+				}
+				return e.Mts[i].Value < e.Mts[j].Value
+			})
+		case *syntax.VectorAggregationExpr:
+			if e.Grouping != nil {
+				sort.Strings(e.Grouping.Groups)
+			}
+		case *syntax.RangeAggregationExpr:
+			if e.Grouping != nil {
+				sort.Strings(e.Grouping.Groups)
+			}
+		}
+	})
+	return expr.String(), nil
+}
+
+type recordingRule struct {
+	RecordingRule
+	// query is the query of the series recorded by the rule.
+	query   string
+	tenants map[string]struct{}
+}
+
+// recordingRules are the recording rules by normalized expression.
+type recordingRules map[string][]recordingRule
+
+func newRecordingRules(rules []RecordingRule) (recordingRules, error) {
+	res := recordingRules{}
+	for _, rule := range rules {
+		if !model.IsValidMetricName(model.LabelValue(rule.Record)) {
+			return nil, fmt.Errorf("invalid recording rule name %q", rule.Record)
+		}
+		expr, err := syntax.ParseSampleExpr(rule.Expr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid expression of recording rule %s", rule.Record)
+		}
+		normalized, err := normalizeExpr(expr)
+		if err != nil {
+			return nil, err
+		}
+
+		matchers := make([]string, 0, len(rule.Labels))
+		for name, value := range rule.Labels {
+			matchers = append(matchers, labels.MustNewMatcher(labels.MatchEqual, name, value).String())
+		}
+		sort.Strings(matchers)
+		r := recordingRule{
+			RecordingRule: rule,
+			query:         rule.Record + "{" + strings.Join(matchers, ",") + "}",
+		}
+		if len(rule.Tenants) > 0 {
+			r.tenants = map[string]struct{}{}
+			for _, id := range rule.Tenants {
+				r.tenants[id] = struct{}{}
+			}
+		}
+		res[normalized] = append(res[normalized], r)
+	}
+	return res, nil
+}
+
+// find returns the rule answering a query of a tenant with a step.
+func (r recordingRules) find(expr syntax.Expr, tenantID string, step time.Duration) (recordingRule, bool) {
+	normalized, err := normalizeExpr(expr)
+	if err != nil {
+		return recordingRule{}, false
+	}
+	for _, rule := range r[normalized] {
+		if _, ok := rule.tenants[tenantID]; rule.tenants != nil && !ok {
+			continue
+		}
+		if step < time.Duration(rule.Interval) {
+			continue
+		}
+		return rule, true
+	}
+	return recordingRule{}, false
+}
+
+// series returns the series recorded by the rule as the series of the query,
+// without the name and the labels added by the rule.
+func (r recordingRule) series(streams []queryrangebase.SampleStream) []queryrangebase.SampleStream {
+	for i, s := range streams {
+		lbls := make([]logproto.LabelAdapter, 0, len(s.Labels))
+		for _, l := range s.Labels {
+			if _, ok := r.Labels[l.Name]; ok || l.Name == labels.MetricName {
+				continue
+			}
+			lbls = append(lbls, l)
+		}
+		streams[i].Labels = lbls
+	}
+	return streams
+}
+
+// maxRecordingRuleGaps is the maximum number of time ranges without recorded
+// samples executed for a query answered by a recording rule. The queries whose
+// recorded series have more gaps are executed as a whole.
+const maxRecordingRuleGaps = 5
+
+type recordingRulesMiddleware struct {
+	next         queryrangebase.Handler
+	rules        recordingRules
+	source       RecordingRuleSource
+	maxFreshness time.Duration
+	limits       Limits
+	logger       log.Logger
+	now          func() time.Time
+}
+
+// NewRecordingRulesMiddleware answers the metric range queries matching a
+// recording rule with the series recorded by the rule. The steps of the
+// queries the source has no samples for, such as the time ranges before the
+// rule was created or the evaluations the rule missed, are executed by the
+// next handler, concurrently up to the max query parallelism of the tenant.
+func NewRecordingRulesMiddleware(rules []RecordingRule, source RecordingRuleSource, maxFreshness time.Duration, limits Limits, logger log.Logger) (queryrangebase.Middleware, error) {
+	r, err := newRecordingRules(rules)
+	if err != nil {
+		return nil, err
+	}
+	return queryrangebase.MiddlewareFunc(func(next queryrangebase.Handler) queryrangebase.Handler {
+		return &recordingRulesMiddleware{
+			next:         next,
+			rules:        r,
+			source:       source,
+			maxFreshness: maxFreshness,
+			limits:       limits,
+			logger:       logger,
+			now:          time.Now,
+		}
+	}), nil
+}
+
+func (m *recordingRulesMiddleware) Do(ctx context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+	req, ok := r.(*LokiRequest)
+	if !ok || req.Plan == nil || req.Step <= 0 {
+		return m.next.Do(ctx, r)
+	}
+	expr, ok := req.Plan.AST.(syntax.SampleExpr)
+	if !ok {
+		return m.next.Do(ctx, r)
+	}
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil || len(tenantIDs) != 1 {
+		return m.next.Do(ctx, r)
+	}
+	rule, ok := m.rules.find(expr, tenantIDs[0], time.Duration(req.Step)*time.Millisecond)
+	if !ok {
+		return m.next.Do(ctx, r)
+	}
+
+	logger := spanlogger.FromContext(ctx)
+	step := time.Duration(req.Step) * time.Millisecond
+	start, end := req.StartTs, req.EndTs
+	// the most recent steps may not be recorded yet.
+	if freshEnd := m.now().Add(-m.maxFreshness); freshEnd.Before(end) {
+		if freshEnd.Before(start) {
+			return m.next.Do(ctx, r)
+		}
+		end = start.Add(freshEnd.Sub(start) / step * step)
+	}
+
+	recorded, err := m.source.QueryRange(ctx, tenantIDs[0], rule.query, start, end, step)
+	if err != nil {
+		level.Warn(logger).Log("msg", "failed to query recording rule, executing the query", "record", rule.Record, "err", err)
+		return m.next.Do(ctx, r)
+	}
+
+	// the steps of the query the source has samples for.
+	recordedSteps := map[int64]struct{}{}
+	for _, s := range recorded.Data.Result {
+		for _, sample := range s.Samples {
+			recordedSteps[sample.TimestampMs] = struct{}{}
+		}
+	}
+	if len(recordedSteps) == 0 {
+		return m.next.Do(ctx, r)
+	}
+	level.Debug(logger).Log("msg", "answering query with recording rule", "record", rule.Record, "steps", len(recordedSteps))
+	series := rule.series(recorded.Data.Result)
+
+	// the consecutive steps without samples, such as the ones before the rule
+	// was created or the ones it missed an evaluation for, are executed.
+	var (
+		parts []recordedPart
+		gaps  int
+	)
+	stepMs, through := step.Milliseconds(), req.EndTs.UnixMilli()
+	for from := req.StartTs.UnixMilli(); from <= through; {
+		_, isRecorded := recordedSteps[from]
+		to := from
+		for ts := to + stepMs; ts <= through; ts += stepMs {
+			if _, ok := recordedSteps[ts]; ok != isRecorded {
+				break
+			}
+			to = ts
+		}
+		parts = append(parts, recordedPart{from: from, through: to, recorded: isRecorded})
+		if !isRecorded {
+			gaps++
+		}
+		from = to + stepMs
+	}
+	if gaps > maxRecordingRuleGaps {
+		level.Debug(logger).Log("msg", "too many gaps in recording rule, executing the query", "record", rule.Record, "gaps", gaps)
+		return m.next.Do(ctx, r)
+	}
+
+	responses := make([]queryrangebase.Response, len(parts))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(max(m.limits.MaxQueryParallelism(ctx, tenantIDs[0]), 1))
+	for i, part := range parts {
+		if part.recorded {
+			responses[i] = recordedResponse(series, part.from, part.through)
+			continue
+		}
+		i, part := i, part
+		g.Go(func() error {
+			resp, err := m.next.Do(gctx, req.WithStartEnd(time.UnixMilli(part.from), time.UnixMilli(part.through)))
+			responses[i] = resp
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return DefaultCodec.MergeResponse(responses...)
+}
+
+// recordedPart is a time range of consecutive steps of a query, inclusive,
+// which are either all recorded or all executed.
+type recordedPart struct {
+	from, through int64
+	recorded      bool
+}
+
+// recordedResponse returns the response holding the samples of the series
+// between from and through, inclusive.
+func recordedResponse(series []queryrangebase.SampleStream, from, through int64) *LokiPromResponse {
+	result := make([]queryrangebase.SampleStream, 0, len(series))
+	for _, s := range series {
+		i := sort.Search(len(s.Samples), func(i int) bool { return s.Samples[i].TimestampMs >= from })
+		j := sort.Search(len(s.Samples), func(i int) bool { return s.Samples[i].TimestampMs > through })
+		if i == j {
+			continue
+		}
+		result = append(result, queryrangebase.SampleStream{Labels: s.Labels, Samples: s.Samples[i:j]})
+	}
+	return &LokiPromResponse{
+		Response: &queryrangebase.PrometheusResponse{
+			Status: loghttp.QueryStatusSuccess,
+			Data: queryrangebase.PrometheusData{
+				ResultType: model.ValMatrix.String(),
+				Result:     result,
+			},
+		},
+	}
+}
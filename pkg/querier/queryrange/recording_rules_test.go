@@ -0,0 +1,300 @@
+package queryrange
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/querier/plan"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
+)
+
+func Test_normalizeExpr(t *testing.T) {
+	for _, tc := range []struct {
+		a, b  string
+		equal bool
+	}{
+		{`sum by (b, a) (rate({app="foo", env="prod"}[5m]))`, `sum by (a,b)(rate({env="prod",app="foo"}[5m]))`, true},
+		{`sum(count_over_time({app="foo"} |= "error" [1m]))`, `sum(count_over_time({app="foo"}|="error"[1m]))`, true},
+		{`sum by (a) (rate({app="foo"}[5m]))`, `sum without (a) (rate({app="foo"}[5m]))`, false},
+		{`sum(rate({app="foo"}[5m]))`, `sum(rate({app="foo"}[1m]))`, false},
+		{`sum(rate({app="foo"}[5m]))`, `sum(rate({app=~"foo"}[5m]))`, false},
+	} {
+		t.Run(tc.a, func(t *testing.T) {
+			a, err := normalizeExpr(syntax.MustParseExpr(tc.a))
+			require.NoError(t, err)
+			b, err := normalizeExpr(syntax.MustParseExpr(tc.b))
+			require.NoError(t, err)
+			require.Equal(t, tc.equal, a == b)
+		})
+	}
+
+	// the expression itself is left as is.
+	expr := syntax.MustParseExpr(`sum by (b, a) (rate({app="foo", env="prod"}[5m]))`)
+	_, err := normalizeExpr(expr)
+	require.NoError(t, err)
+	require.Equal(t, `sum by (b,a)(rate({app="foo", env="prod"}[5m]))`, expr.String())
+}
+
+type fakeRecordingRuleSource struct {
+	query string
+	resp  *queryrangebase.PrometheusResponse
+}
+
+func (f *fakeRecordingRuleSource) QueryRange(_ context.Context, _, query string, _, _ time.Time, _ time.Duration) (*queryrangebase.PrometheusResponse, error) {
+	f.query = query
+	return f.resp, nil
+}
+
+func promSamples(lbls []logproto.LabelAdapter, from, through, step int64) queryrangebase.SampleStream {
+	s := queryrangebase.SampleStream{Labels: lbls}
+	for ts := from; ts <= through; ts += step {
+		s.Samples = append(s.Samples, logproto.LegacySample{TimestampMs: ts, Value: float64(ts / step)})
+	}
+	return s
+}
+
+func Test_RecordingRulesMiddleware(t *testing.T) {
+	const query = `sum by (app) (rate({app="foo", env="prod"}[5m]))`
+	rules := []RecordingRule{{
+		Record:   "app:foo_rate:sum",
+		Expr:     `sum by (app) (rate({env="prod", app="foo"}[5m]))`,
+		Labels:   map[string]string{"source": "loki"},
+		Interval: model.Duration(time.Minute),
+		Tenants:  []string{"1"},
+	}}
+	app := []logproto.LabelAdapter{{Name: "app", Value: "foo"}}
+	recorded := []logproto.LabelAdapter{{Name: "__name__", Value: "app:foo_rate:sum"}, {Name: "app", Value: "foo"}, {Name: "source", Value: "loki"}}
+	step := time.Minute.Milliseconds()
+	now := time.UnixMilli(100 * step)
+
+	newRequest := func(q string, start, end, step int64) *LokiRequest {
+		return &LokiRequest{
+			Query:   q,
+			StartTs: time.UnixMilli(start),
+			EndTs:   time.UnixMilli(end),
+			Step:    step,
+			Plan:    &plan.QueryPlan{AST: syntax.MustParseExpr(q)},
+		}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		tenant   string
+		req      *LokiRequest
+		recorded []queryrangebase.SampleStream
+		// ranges of the requests executed by the next handler.
+		executed [][2]int64
+		samples  int
+	}{
+		{
+			name:     "recorded",
+			tenant:   "1",
+			req:      newRequest(query, 10*step, 20*step, step),
+			recorded: []queryrangebase.SampleStream{promSamples(recorded, 10*step, 20*step, step)},
+			samples:  11,
+		},
+		{
+			name:     "partially recorded",
+			tenant:   "1",
+			req:      newRequest(query, 10*step, 20*step, step),
+			recorded: []queryrangebase.SampleStream{promSamples(recorded, 13*step, 17*step, step)},
+			executed: [][2]int64{{10 * step, 12 * step}, {18 * step, 20 * step}},
+			samples:  11,
+		},
+		{
+			name:   "recorded with gaps",
+			tenant: "1",
+			req:    newRequest(query, 10*step, 20*step, step),
+			recorded: []queryrangebase.SampleStream{{
+				Labels: recorded,
+				Samples: append(append(
+					promSamples(recorded, 10*step, 13*step, step).Samples,
+					promSamples(recorded, 15*step, 15*step, step).Samples...),
+					promSamples(recorded, 18*step, 20*step, step).Samples...),
+			}},
+			executed: [][2]int64{{14 * step, 14 * step}, {16 * step, 17 * step}},
+			samples:  11,
+		},
+		{
+			name:     "recorded with too many gaps",
+			tenant:   "1",
+			req:      newRequest(query, 10*step, 22*step, step),
+			recorded: []queryrangebase.SampleStream{promSamples(recorded, 10*step, 22*step, 2*step)},
+			executed: [][2]int64{{10 * step, 22 * step}},
+			samples:  13,
+		},
+		{
+			name:     "not recorded yet",
+			tenant:   "1",
+			req:      newRequest(query, 90*step, 99*step, step),
+			recorded: []queryrangebase.SampleStream{promSamples(recorded, 90*step, 95*step, step)},
+			executed: [][2]int64{{96 * step, 99 * step}},
+			samples:  10,
+		},
+		{
+			name:     "not recorded",
+			tenant:   "1",
+			req:      newRequest(query, 10*step, 20*step, step),
+			executed: [][2]int64{{10 * step, 20 * step}},
+			samples:  11,
+		},
+		{
+			name:     "other query",
+			tenant:   "1",
+			req:      newRequest(`sum(rate({app="foo", env="prod"}[5m]))`, 10*step, 20*step, step),
+			executed: [][2]int64{{10 * step, 20 * step}},
+			samples:  11,
+		},
+		{
+			name:     "other tenant",
+			tenant:   "2",
+			req:      newRequest(query, 10*step, 20*step, step),
+			executed: [][2]int64{{10 * step, 20 * step}},
+			samples:  11,
+		},
+		{
+			name:     "step shorter than the rule interval",
+			tenant:   "1",
+			req:      newRequest(query, 10*step, 20*step, step/2),
+			executed: [][2]int64{{10 * step, 20 * step}},
+			samples:  21,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			source := &fakeRecordingRuleSource{resp: &queryrangebase.PrometheusResponse{
+				Status: loghttp.QueryStatusSuccess,
+				Data:   queryrangebase.PrometheusData{ResultType: model.ValMatrix.String(), Result: tc.recorded},
+			}}
+			mw, err := NewRecordingRulesMiddleware(rules, source, 5*time.Minute, fakeLimits{maxQueryParallelism: 2}, log.NewNopLogger())
+			require.NoError(t, err)
+
+			var (
+				mtx      sync.Mutex
+				executed [][2]int64
+			)
+			next := queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+				req := r.(*LokiRequest)
+				mtx.Lock()
+				executed = append(executed, [2]int64{req.StartTs.UnixMilli(), req.EndTs.UnixMilli()})
+				mtx.Unlock()
+				return &LokiPromResponse{Response: &queryrangebase.PrometheusResponse{
+					Status: loghttp.QueryStatusSuccess,
+					Data: queryrangebase.PrometheusData{
+						ResultType: model.ValMatrix.String(),
+						Result:     []queryrangebase.SampleStream{promSamples(app, req.StartTs.UnixMilli(), req.EndTs.UnixMilli(), req.Step)},
+					},
+				}}, nil
+			})
+			h := mw.Wrap(next).(*recordingRulesMiddleware)
+			h.now = func() time.Time { return now }
+
+			resp, err := h.Do(user.InjectOrgID(context.Background(), tc.tenant), tc.req)
+			require.NoError(t, err)
+			require.ElementsMatch(t, tc.executed, executed)
+
+			result := resp.(*LokiPromResponse).Response.Data.Result
+			require.Len(t, result, 1)
+			require.Equal(t, app, result[0].Labels)
+			require.Len(t, result[0].Samples, tc.samples)
+			for i, s := range result[0].Samples {
+				require.Equal(t, tc.req.StartTs.UnixMilli()+int64(i)*tc.req.Step, s.TimestampMs)
+			}
+			if len(tc.recorded) > 0 {
+				require.Equal(t, `app:foo_rate:sum{source="loki"}`, source.query)
+			}
+		})
+	}
+}
+
+func Test_RecordingRulesMiddleware_Parallelism(t *testing.T) {
+	const query = `sum by (app) (rate({app="foo"}[5m]))`
+	rules := []RecordingRule{{Record: "app:foo_rate:sum", Expr: query, Interval: model.Duration(time.Minute)}}
+	recorded := []logproto.LabelAdapter{{Name: "__name__", Value: "app:foo_rate:sum"}, {Name: "app", Value: "foo"}}
+	step := time.Minute.Milliseconds()
+
+	// every other step is recorded, leaving 5 gaps.
+	var samples []logproto.LegacySample
+	for ts := int64(0); ts <= 10*step; ts += 2 * step {
+		samples = append(samples, logproto.LegacySample{TimestampMs: ts, Value: 1})
+	}
+	source := &fakeRecordingRuleSource{resp: &queryrangebase.PrometheusResponse{
+		Status: loghttp.QueryStatusSuccess,
+		Data: queryrangebase.PrometheusData{
+			ResultType: model.ValMatrix.String(),
+			Result:     []queryrangebase.SampleStream{{Labels: recorded, Samples: samples}},
+		},
+	}}
+	mw, err := NewRecordingRulesMiddleware(rules, source, 5*time.Minute, fakeLimits{maxQueryParallelism: 2}, log.NewNopLogger())
+	require.NoError(t, err)
+
+	var inflight, maxInflight, executed atomic.Int32
+	next := queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+		n := inflight.Inc()
+		defer inflight.Dec()
+		for {
+			m := maxInflight.Load()
+			if n <= m || maxInflight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		executed.Inc()
+		time.Sleep(10 * time.Millisecond)
+		return &LokiPromResponse{Response: &queryrangebase.PrometheusResponse{
+			Status: loghttp.QueryStatusSuccess,
+			Data:   queryrangebase.PrometheusData{ResultType: model.ValMatrix.String()},
+		}}, nil
+	})
+	h := mw.Wrap(next).(*recordingRulesMiddleware)
+	h.now = func() time.Time { return time.UnixMilli(100 * step) }
+
+	_, err = h.Do(user.InjectOrgID(context.Background(), "1"), &LokiRequest{
+		Query:   query,
+		StartTs: time.UnixMilli(0),
+		EndTs:   time.UnixMilli(10 * step),
+		Step:    step,
+		Plan:    &plan.QueryPlan{AST: syntax.MustParseExpr(query)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(5), executed.Load())
+	require.Equal(t, int32(2), maxInflight.Load())
+}
+
+func Test_PrometheusRecordingRuleSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/prometheus/api/v1/query_range", r.URL.Path)
+		require.Equal(t, "1", r.Header.Get(user.OrgIDHeaderName))
+		require.Equal(t, url.Values{
+			"query": []string{"app:foo_rate:sum"},
+			"start": []string{"60"},
+			"end":   []string{"120.5"},
+			"step":  []string{"30"},
+		}, r.URL.Query())
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"__name__":"app:foo_rate:sum","app":"foo"},"values":[[60,"1"],[90,"2"]]}]}}`))
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL + "/prometheus")
+	require.NoError(t, err)
+	source := NewPrometheusRecordingRuleSource(u, time.Second)
+
+	resp, err := source.QueryRange(context.Background(), "1", "app:foo_rate:sum", time.Unix(60, 0), time.UnixMilli(120500), 30*time.Second)
+	require.NoError(t, err)
+	require.Equal(t, []queryrangebase.SampleStream{{
+		Labels:  []logproto.LabelAdapter{{Name: "__name__", Value: "app:foo_rate:sum"}, {Name: "app", Value: "foo"}},
+		Samples: []logproto.LegacySample{{TimestampMs: 60000, Value: 1}, {TimestampMs: 90000, Value: 2}},
+	}}, resp.Data.Result)
+}
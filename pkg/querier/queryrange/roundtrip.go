@@ -10,6 +10,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/flagext"
 	"github.com/grafana/dskit/httpgrpc"
 	"github.com/grafana/dskit/tenant"
 	"github.com/grafana/dskit/user"
@@ -22,6 +23,7 @@ import (
 	logqllog "github.com/grafana/loki/v3/pkg/logql/log"
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/v3/pkg/querier/macros"
 	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
 	base "github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/cache"
@@ -60,6 +62,11 @@ type Config struct {
 	SeriesCacheConfig            SeriesCacheConfig        `yaml:"series_results_cache" doc:"description=If series_results_cache is not configured and cache_series_results is true, the config for the results cache is used."`
 	CacheLabelResults            bool                     `yaml:"cache_label_results"`
 	LabelsCacheConfig            LabelsCacheConfig        `yaml:"label_results_cache" doc:"description=If label_results_cache is not configured and cache_label_results is true, the config for the results cache is used."`
+	CacheLogResultEntries        bool                     `yaml:"cache_log_result_entries"`
+	LogResultCacheMaxEntrySize   flagext.Bytes            `yaml:"log_result_cache_max_entry_size"`
+	Macros                       macros.Config            `yaml:"macros" category:"experimental"`
+	RecordingRules               RecordingRulesConfig     `yaml:"recording_rules" category:"experimental"`
+	DedupQueries                 bool                     `yaml:"dedup_queries" category:"experimental"`
 }
 
 // RegisterFlags adds the flags required to configure this flag set.
@@ -76,6 +83,21 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.SeriesCacheConfig.RegisterFlags(f)
 	f.BoolVar(&cfg.CacheLabelResults, "querier.cache-label-results", true, "Cache label query results.")
 	cfg.LabelsCacheConfig.RegisterFlags(f)
+	f.BoolVar(&cfg.CacheLogResultEntries, "querier.cache-log-result-entries", false, "Cache the results of log queries with entries, in addition to the empty ones. Requires cache_results.")
+	_ = cfg.LogResultCacheMaxEntrySize.Set("1MB")
+	f.Var(&cfg.LogResultCacheMaxEntrySize, "querier.log-result-cache-max-entry-size", "Maximum size of the cached entries of a split of a log query. Larger results are not cached.")
+	cfg.Macros.RegisterFlags(f)
+	cfg.RecordingRules.RegisterFlags(f)
+	f.BoolVar(&cfg.DedupQueries, "querier.dedup-queries", false, "Experimental. Execute the concurrent identical queries of a tenant and their identical requests to the queriers only once, sharing the response between them.")
+}
+
+// logResultCacheMaxEntriesSize returns the maximum size of the cached
+// entries of log queries, or 0 when they are not cached.
+func (cfg *Config) logResultCacheMaxEntriesSize() int {
+	if !cfg.CacheLogResultEntries {
+		return 0
+	}
+	return int(cfg.LogResultCacheMaxEntrySize)
 }
 
 // Validate validates the config.
@@ -89,6 +111,14 @@ func (cfg *Config) Validate() error {
 			return errors.Wrap(err, "invalid index_stats_results_cache config")
 		}
 	}
+
+	if err := cfg.Macros.Validate(); err != nil {
+		return errors.Wrap(err, "invalid macros config")
+	}
+
+	if err := cfg.RecordingRules.Validate(); err != nil {
+		return errors.Wrap(err, "invalid recording_rules config")
+	}
 	return nil
 }
 
@@ -125,7 +155,8 @@ func newResultsCacheFromConfig(cfg base.ResultsCacheConfig, registerer prometheu
 	return c, nil
 }
 
-// NewMiddleware returns a Middleware configured with middlewares to align, split and cache requests.
+// NewMiddleware returns a Middleware configured with middlewares to align, split and cache requests,
+// along with the cache of the results of the metric and log queries, nil when they aren't cached.
 func NewMiddleware(
 	cfg Config,
 	engineOpts logql.EngineOpts,
@@ -137,8 +168,9 @@ func NewMiddleware(
 	retentionEnabled bool,
 	registerer prometheus.Registerer,
 	metricsNamespace string,
-) (base.Middleware, Stopper, error) {
+) (base.Middleware, cache.Cache, Stopper, error) {
 	metrics := NewMetrics(registerer, metricsNamespace)
+	limits = deprioritizedLimits{Limits: limits}
 
 	var (
 		resultsCache       cache.Cache
@@ -153,42 +185,42 @@ func NewMiddleware(
 	if cfg.CacheResults {
 		resultsCache, err = newResultsCacheFromConfig(cfg.ResultsCacheConfig, registerer, log, stats.ResultCache)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 	}
 
 	if cfg.CacheIndexStatsResults {
 		statsCache, err = newResultsCacheFromConfig(cfg.StatsCacheConfig.ResultsCacheConfig, registerer, log, stats.StatsResultCache)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 	}
 
 	if cfg.CacheVolumeResults {
 		volumeCache, err = newResultsCacheFromConfig(cfg.VolumeCacheConfig.ResultsCacheConfig, registerer, log, stats.VolumeResultCache)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 	}
 
 	if cfg.CacheInstantMetricResults {
 		instantMetricCache, err = newResultsCacheFromConfig(cfg.InstantMetricCacheConfig.ResultsCacheConfig, registerer, log, stats.InstantMetricResultsCache)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 	}
 
 	if cfg.CacheSeriesResults {
 		seriesCache, err = newResultsCacheFromConfig(cfg.SeriesCacheConfig.ResultsCacheConfig, registerer, log, stats.SeriesResultCache)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 	}
 
 	if cfg.CacheLabelResults {
 		labelsCache, err = newResultsCacheFromConfig(cfg.LabelsCacheConfig.ResultsCacheConfig, registerer, log, stats.LabelResultCache)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 	}
 
@@ -197,45 +229,45 @@ func NewMiddleware(
 	indexStatsTripperware, err := NewIndexStatsTripperware(cfg, log, limits, schema, codec, iqo, statsCache,
 		cacheGenNumLoader, retentionEnabled, metrics, metricsNamespace)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	metricsTripperware, err := NewMetricTripperware(cfg, engineOpts, log, limits, schema, codec, iqo, resultsCache,
 		cacheGenNumLoader, retentionEnabled, PrometheusExtractor{}, metrics, indexStatsTripperware, metricsNamespace)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	limitedTripperware, err := NewLimitedTripperware(cfg, engineOpts, log, limits, schema, metrics, indexStatsTripperware, codec, iqo)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	// NOTE: When we would start caching response from non-metric queries we would have to consider cache gen headers as well in
 	// MergeResponse implementation for Loki codecs same as it is done in Cortex at https://github.com/cortexproject/cortex/blob/21bad57b346c730d684d6d0205efef133422ab28/pkg/querier/queryrange/query_range.go#L170
-	logFilterTripperware, err := NewLogFilterTripperware(cfg, engineOpts, log, limits, schema, codec, iqo, resultsCache, metrics, indexStatsTripperware, metricsNamespace)
+	logFilterTripperware, err := NewLogFilterTripperware(cfg, engineOpts, log, limits, schema, codec, iqo, resultsCache, cacheGenNumLoader, retentionEnabled, metrics, indexStatsTripperware, metricsNamespace)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	seriesTripperware, err := NewSeriesTripperware(cfg, log, limits, metrics, schema, codec, iqo, seriesCache, cacheGenNumLoader, retentionEnabled, metricsNamespace)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	labelsTripperware, err := NewLabelsTripperware(cfg, log, limits, codec, iqo, labelsCache, cacheGenNumLoader, retentionEnabled, metrics, schema, metricsNamespace)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	instantMetricTripperware, err := NewInstantMetricTripperware(cfg, engineOpts, log, limits, schema, metrics, codec, instantMetricCache, cacheGenNumLoader, retentionEnabled, indexStatsTripperware, metricsNamespace)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	seriesVolumeTripperware, err := NewVolumeTripperware(cfg, log, limits, schema, codec, iqo, volumeCache, cacheGenNumLoader, retentionEnabled, metrics, metricsNamespace)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	detectedFieldsTripperware, err := NewDetectedFieldsTripperware(
@@ -250,7 +282,7 @@ func NewMiddleware(
 		indexStatsTripperware,
 		metricsNamespace)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	detectedLabelsTripperware, err := NewDetectedLabelsTripperware(
@@ -264,9 +296,13 @@ func NewMiddleware(
 		metricsNamespace,
 		codec, limits, iqo)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	return base.MiddlewareFunc(func(next base.Handler) base.Handler {
+		if cfg.DedupQueries {
+			next = NewDedupMiddleware(dedupLevelDownstream, metrics.DedupMetrics).Wrap(next)
+		}
+
 		var (
 			metricRT         = metricsTripperware.Wrap(next)
 			limitedRT        = limitedTripperware.Wrap(next)
@@ -280,8 +316,12 @@ func NewMiddleware(
 			detectedLabelsRT = detectedLabelsTripperware.Wrap(next)
 		)
 
-		return newRoundTripper(log, next, limitedRT, logFilterRT, metricRT, seriesRT, labelsRT, instantRT, statsRT, seriesVolumeRT, detectedFieldsRT, detectedLabelsRT, limits)
-	}), StopperWrapper{resultsCache, statsCache, volumeCache}, nil
+		rt := newRoundTripper(log, next, limitedRT, logFilterRT, metricRT, seriesRT, labelsRT, instantRT, statsRT, seriesVolumeRT, detectedFieldsRT, detectedLabelsRT, limits)
+		if cfg.DedupQueries {
+			return NewDedupMiddleware(dedupLevelQuery, metrics.DedupMetrics).Wrap(rt)
+		}
+		return rt
+	}), resultsCache, StopperWrapper{resultsCache, statsCache, volumeCache}, nil
 }
 
 func NewDetectedLabelsTripperware(cfg Config, opts logql.EngineOpts, logger log.Logger, l Limits, schema config.SchemaConfig, metrics *Metrics, mw base.Middleware, namespace string, merger base.Merger, limits Limits, iqo util.IngesterQueryOptions) (base.Middleware, error) {
@@ -548,7 +588,7 @@ func getOperation(path string) string {
 }
 
 // NewLogFilterTripperware creates a new frontend tripperware responsible for handling log requests.
-func NewLogFilterTripperware(cfg Config, engineOpts logql.EngineOpts, log log.Logger, limits Limits, schema config.SchemaConfig, merger base.Merger, iqo util.IngesterQueryOptions, c cache.Cache, metrics *Metrics, indexStatsTripperware base.Middleware, metricsNamespace string) (base.Middleware, error) {
+func NewLogFilterTripperware(cfg Config, engineOpts logql.EngineOpts, log log.Logger, limits Limits, schema config.SchemaConfig, merger base.Merger, iqo util.IngesterQueryOptions, c cache.Cache, cacheGenNumLoader base.CacheGenNumberLoader, retentionEnabled bool, metrics *Metrics, indexStatsTripperware base.Middleware, metricsNamespace string) (base.Middleware, error) {
 	return base.MiddlewareFunc(func(next base.Handler) base.Handler {
 		statsHandler := indexStatsTripperware.Wrap(next)
 
@@ -570,6 +610,9 @@ func NewLogFilterTripperware(cfg Config, engineOpts logql.EngineOpts, log log.Lo
 					return !r.GetCachingOptions().Disabled
 				},
 				cfg.Transformer,
+				cacheGenNumLoader,
+				retentionEnabled,
+				cfg.logResultCacheMaxEntriesSize(),
 				metrics.LogResultCacheMetrics,
 			)
 			queryRangeMiddleware = append(
@@ -827,6 +870,16 @@ func NewMetricTripperware(cfg Config, engineOpts logql.EngineOpts, log log.Logge
 		}
 	}
 
+	var recordingRulesMiddleware base.Middleware
+	if cfg.RecordingRules.Enabled {
+		source := NewPrometheusRecordingRuleSource(cfg.RecordingRules.SourceURL.URL, cfg.RecordingRules.SourceTimeout)
+		var err error
+		recordingRulesMiddleware, err = NewRecordingRulesMiddleware(cfg.RecordingRules.Rules, source, cfg.RecordingRules.MaxFreshness, limits, log)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return base.MiddlewareFunc(func(next base.Handler) base.Handler {
 		statsHandler := indexStatsTripperware.Wrap(next)
 
@@ -844,8 +897,19 @@ func NewMetricTripperware(cfg Config, engineOpts logql.EngineOpts, log log.Logge
 			)
 		}
 
+		if recordingRulesMiddleware != nil {
+			queryRangeMiddleware = append(
+				queryRangeMiddleware,
+				base.InstrumentMiddleware("recording_rules", metrics.InstrumentMiddlewareMetrics),
+				recordingRulesMiddleware,
+			)
+		}
+
 		queryRangeMiddleware = append(
 			queryRangeMiddleware,
+			// the legs of a changes_vs query are split and cached on their own.
+			base.InstrumentMiddleware("changes_vs", metrics.InstrumentMiddlewareMetrics),
+			NewChangesVsMiddleware(),
 			NewQuerySizeLimiterMiddleware(schema.Configs, engineOpts, log, limits, statsHandler),
 			base.InstrumentMiddleware("split_by_interval", metrics.InstrumentMiddlewareMetrics),
 			SplitByIntervalMiddleware(schema.Configs, limits, merger, newMetricQuerySplitter(limits, iqo), metrics.SplitByMetrics),
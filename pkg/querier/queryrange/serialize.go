@@ -1,6 +1,8 @@
 package queryrange
 
 import (
+	"context"
+	"io"
 	"net/http"
 
 	"github.com/opentracing/opentracing-go"
@@ -33,6 +35,10 @@ func (rt *serializeRoundTripper) RoundTrip(r *http.Request) (*http.Response, err
 		return nil, err
 	}
 
+	if _, ok := request.(*LokiRequest); ok && AcceptsEventStream(r) {
+		return rt.stream(ctx, r, request), nil
+	}
+
 	response, err := rt.next.Do(ctx, request)
 	if err != nil {
 		return nil, err
@@ -41,6 +47,40 @@ func (rt *serializeRoundTripper) RoundTrip(r *http.Request) (*http.Response, err
 	return rt.codec.EncodeResponse(ctx, r, response)
 }
 
+// stream returns a response streaming the partial results of the request as
+// they complete, followed by its result. Errors are sent as events, the
+// status of the response having already been sent.
+func (rt *serializeRoundTripper) stream(ctx context.Context, r *http.Request, request queryrangebase.Request) *http.Response {
+	pr, pw := io.Pipe()
+	w := &eventStreamWriter{
+		w:           pw,
+		version:     loghttp.GetVersion(r.RequestURI),
+		encodeFlags: httpreq.ExtractEncodingFlags(r),
+	}
+
+	go func() {
+		sp, ctx := opentracing.StartSpanFromContext(ctx, "serializeRoundTripper.stream")
+		defer sp.Finish()
+
+		response, err := rt.next.Do(WithPartialResults(ctx, w.writePartial), request)
+		if err != nil {
+			w.writeError(err)
+		} else {
+			w.writeResponse(EventResult, response)
+		}
+		_ = pw.Close()
+	}()
+
+	return &http.Response{
+		Header: http.Header{
+			"Content-Type":  []string{EventStreamType},
+			"Cache-Control": []string{"no-cache"},
+		},
+		Body:       pr,
+		StatusCode: http.StatusOK,
+	}
+}
+
 type serializeHTTPHandler struct {
 	codec queryrangebase.Codec
 	next  queryrangebase.Handler
@@ -3,8 +3,10 @@ package queryrange
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -130,3 +132,62 @@ func TestResponseFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestEventStreamResponse(t *testing.T) {
+	matrix := &LokiPromResponse{
+		Response: &queryrangebase.PrometheusResponse{
+			Status: loghttp.QueryStatusSuccess,
+			Data: queryrangebase.PrometheusData{
+				ResultType: loghttp.ResultTypeMatrix,
+				Result: []queryrangebase.SampleStream{
+					{
+						Labels:  []logproto.LabelAdapter{{Name: "foo", Value: "bar"}},
+						Samples: []logproto.LegacySample{{TimestampMs: 1000, Value: 1}},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		name   string
+		err    error
+		events []string
+	}{
+		{"result", nil, []string{EventPartial, EventProgress, EventResult}},
+		{"error", fmt.Errorf("failed\nquery"), []string{EventPartial, EventProgress, EventError}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := queryrangebase.HandlerFunc(func(ctx context.Context, _ queryrangebase.Request) (queryrangebase.Response, error) {
+				send := partialResultsFromContext(ctx)
+				require.NotNil(t, send)
+				send(PartialResult{Response: matrix, Final: true})
+				send(PartialResult{Response: matrix})
+				return matrix, tc.err
+			})
+			rt := NewSerializeRoundTripper(handler, DefaultCodec)
+
+			req := httptest.NewRequest(http.MethodGet, "/loki/api/v1/query_range?start=0&end=1&query=count_over_time(%7Bfoo%3D%22bar%22%7D%5B1m%5D)", nil)
+			req.Header.Set("Accept", EventStreamType)
+			req = req.WithContext(user.InjectOrgID(context.Background(), "1"))
+			resp, err := rt.RoundTrip(req)
+			require.NoError(t, err)
+			require.Equal(t, EventStreamType, resp.Header.Get("Content-Type"))
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			events := strings.Split(strings.TrimSuffix(string(body), "\n\n"), "\n\n")
+			require.Len(t, events, len(tc.events))
+			for i, event := range events {
+				lines := strings.Split(event, "\n")
+				require.Equal(t, "event: "+tc.events[i], lines[0])
+				if tc.events[i] == EventError {
+					require.Equal(t, []string{"data: failed", "data: query"}, lines[1:])
+					continue
+				}
+				require.Len(t, lines, 2)
+				require.Contains(t, lines[1], `"resultType":"matrix"`)
+			}
+		})
+	}
+}
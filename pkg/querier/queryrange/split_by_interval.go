@@ -18,6 +18,8 @@ import (
 	"github.com/grafana/dskit/tenant"
 
 	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql"
+	"github.com/grafana/loki/v3/pkg/logql/log"
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
 	"github.com/grafana/loki/v3/pkg/storage/config"
@@ -120,10 +122,14 @@ func (h *splitByInterval) Process(
 		p = len(input)
 	}
 
+	// the parts of the result are sent in order as the splits complete, so
+	// the splits don't send their own.
+	partials := partialResultsFromContext(ctx)
+
 	// per request wrapped handler for limiting the amount of series.
 	next := newSeriesLimiter(maxSeries).Wrap(h.next)
 	for i := 0; i < p; i++ {
-		go h.loop(ctx, ch, next)
+		go h.loop(withoutPartialResults(ctx), ch, next)
 	}
 
 	for _, x := range input {
@@ -136,6 +142,11 @@ func (h *splitByInterval) Process(
 			}
 
 			responses = append(responses, data.resp)
+			if partials != nil {
+				if partial, ok := splitPartialResult(data.resp, threshold, unlimited); ok {
+					partials(partial)
+				}
+			}
 
 			// see if we can exit early if a limit has been reached
 			if casted, ok := data.resp.(*LokiResponse); !unlimited && ok {
@@ -214,15 +225,38 @@ func (h *splitByInterval) Do(ctx context.Context, r queryrangebase.Request) (que
 		return h.next.Do(ctx, intervals[0])
 	}
 
-	var limit int64
+	var (
+		limit int64
+
+		sortBy    *log.SortBy
+		dedup     *log.Dedup
+		sortLimit uint32
+	)
 	switch req := r.(type) {
 	case *LokiRequest:
 		limit = int64(req.Limit)
+		sortLimit = req.Limit
 		if req.Direction == logproto.BACKWARD {
 			for i, j := 0, len(intervals)-1; i < j; i, j = i+1, j-1 {
 				intervals[i], intervals[j] = intervals[j], intervals[i]
 			}
 		}
+		if req.Plan != nil {
+			if expr, ok := req.Plan.AST.(syntax.LogSelectorExpr); ok {
+				sortBy, sortLimit = logql.SortAndLimit(expr, req.Limit)
+				dedup = logql.DedupStage(expr)
+			}
+		}
+		if sortBy != nil || dedup != nil {
+			// entries of any split can be part of the result of a sort_by
+			// query, or be collapsed into an entry of a dedup query, so all of
+			// them must be processed, and none is part of the result as is.
+			limit = 0
+			ctx = withoutPartialResults(ctx)
+		} else if sortLimit < req.Limit {
+			// a limit stage lower than the limit of the request ends the query.
+			limit = int64(sortLimit)
+		}
 	case *DetectedFieldsRequest:
 		limit = int64(req.LineLimit)
 		for i, j := 0, len(intervals)-1; i < j; i, j = i+1, j-1 {
@@ -250,6 +284,15 @@ func (h *splitByInterval) Do(ctx context.Context, r queryrangebase.Request) (que
 	if err != nil {
 		return nil, err
 	}
+	if sortBy != nil {
+		return mergeSortedLokiResponse(sortBy, sortLimit, resps...), nil
+	}
+	if dedup != nil {
+		return mergeDedupLokiResponse(dedup, sortLimit, resps...), nil
+	}
+	if req, ok := r.(*LokiRequest); ok && sortLimit < req.Limit {
+		return mergeLimitedLokiResponse(sortLimit, resps...), nil
+	}
 	return h.merger.MergeResponse(resps...)
 }
 
@@ -270,13 +313,31 @@ func maxRangeVectorAndOffsetDuration(expr syntax.Expr) (time.Duration, time.Dura
 
 	var maxRVDuration, maxOffset time.Duration
 	expr.Walk(func(e syntax.Expr) {
-		if r, ok := e.(*syntax.LogRange); ok {
+		switch r := e.(type) {
+		case *syntax.LogRange:
 			if r.Interval > maxRVDuration {
 				maxRVDuration = r.Interval
 			}
 			if r.Offset > maxOffset {
 				maxOffset = r.Offset
 			}
+		case *syntax.SubqueryExpr:
+			// the inner query of a subquery looks back over the range of
+			// the subquery in addition to its own range vectors.
+			innerRV, innerOffset, _ := maxRangeVectorAndOffsetDuration(r.Left)
+			if r.Range+innerRV > maxRVDuration {
+				maxRVDuration = r.Range + innerRV
+			}
+			if r.Offset+innerOffset > maxOffset {
+				maxOffset = r.Offset + innerOffset
+			}
+		case *syntax.ChangesVsExpr:
+			// the shifted leg looks back over the shift in addition to the
+			// range vectors of the compared query.
+			innerRV, _, _ := maxRangeVectorAndOffsetDuration(r.Left)
+			if r.Shift+innerRV > maxRVDuration {
+				maxRVDuration = r.Shift + innerRV
+			}
 		}
 	})
 	return maxRVDuration, maxOffset, nil
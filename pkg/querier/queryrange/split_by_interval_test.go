@@ -1861,6 +1861,303 @@ func Test_ExitEarly(t *testing.T) {
 	require.Equal(t, expected, res)
 }
 
+func Test_SortBy_splitByInterval_Do(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "1")
+
+	var callCt int
+	var mtx sync.Mutex
+
+	next := queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		callCt++
+
+		start := r.(*LokiRequest).StartTs
+		return &LokiResponse{
+			Status:    loghttp.QueryStatusSuccess,
+			Direction: r.(*LokiRequest).Direction,
+			Limit:     r.(*LokiRequest).Limit,
+			Version:   uint32(loghttp.VersionV1),
+			Data: LokiData{
+				ResultType: loghttp.ResultTypeStream,
+				Result: []logproto.Stream{
+					{
+						Labels: fmt.Sprintf(`{duration="%ds", foo="bar"}`, start.Unix()),
+						Entries: []logproto.Entry{
+							{Timestamp: start, Line: fmt.Sprintf("%d", start.UnixNano())},
+						},
+					},
+				},
+			},
+		}, nil
+	})
+
+	l := WithSplitByLimits(fakeLimits{maxQueryParallelism: 1}, time.Hour)
+	defSplitter := newDefaultSplitter(fakeLimits{}, nil)
+	split := SplitByIntervalMiddleware(
+		testSchemas,
+		l,
+		DefaultCodec,
+		defSplitter,
+		nilMetrics,
+	).Wrap(next)
+
+	query := `{foo="bar"} | sort_by(duration desc) | limit 2`
+	req := &LokiRequest{
+		StartTs:   time.Unix(0, 0),
+		EndTs:     time.Unix(0, (4 * time.Hour).Nanoseconds()),
+		Query:     query,
+		Limit:     1,
+		Step:      1,
+		Direction: logproto.FORWARD,
+		Path:      "/api/prom/query_range",
+		Plan: &plan.QueryPlan{
+			AST: syntax.MustParseExpr(query),
+		},
+	}
+
+	res, err := split.Do(ctx, req)
+	require.NoError(t, err)
+
+	// no split is skipped since the last one has the greatest duration.
+	require.Equal(t, 4, callCt)
+	require.Equal(t, []logproto.Stream{
+		{
+			Labels: `{duration="10800s", foo="bar"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(0, 3*time.Hour.Nanoseconds()), Line: fmt.Sprintf("%d", 3*time.Hour.Nanoseconds())},
+			},
+		},
+	}, res.(*LokiResponse).Data.Result)
+}
+
+func Test_Limit_splitByInterval_Do(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "1")
+
+	var callCt int
+	var mtx sync.Mutex
+
+	next := queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		callCt++
+
+		start := r.(*LokiRequest).StartTs
+		return &LokiResponse{
+			Status:    loghttp.QueryStatusSuccess,
+			Direction: r.(*LokiRequest).Direction,
+			Limit:     r.(*LokiRequest).Limit,
+			Version:   uint32(loghttp.VersionV1),
+			Data: LokiData{
+				ResultType: loghttp.ResultTypeStream,
+				Result: []logproto.Stream{
+					{
+						Labels: `{foo="bar"}`,
+						Entries: []logproto.Entry{
+							{Timestamp: start, Line: "1"},
+							{Timestamp: start.Add(time.Second), Line: "2"},
+						},
+					},
+				},
+			},
+		}, nil
+	})
+
+	l := WithSplitByLimits(fakeLimits{maxQueryParallelism: 1}, time.Hour)
+	defSplitter := newDefaultSplitter(fakeLimits{}, nil)
+	split := SplitByIntervalMiddleware(
+		testSchemas,
+		l,
+		DefaultCodec,
+		defSplitter,
+		nilMetrics,
+	).Wrap(next)
+
+	query := `{foo="bar"} | limit 3`
+	req := &LokiRequest{
+		StartTs:   time.Unix(0, 0),
+		EndTs:     time.Unix(0, (4 * time.Hour).Nanoseconds()),
+		Query:     query,
+		Limit:     100,
+		Step:      1,
+		Direction: logproto.FORWARD,
+		Path:      "/api/prom/query_range",
+		Plan: &plan.QueryPlan{
+			AST: syntax.MustParseExpr(query),
+		},
+	}
+
+	res, err := split.Do(ctx, req)
+	require.NoError(t, err)
+
+	// the splits after the limit of the stage is reached are skipped.
+	mtx.Lock()
+	require.Less(t, callCt, 4)
+	mtx.Unlock()
+	require.Equal(t, []logproto.Stream{
+		{
+			Labels: `{foo="bar"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(0, 0), Line: "1"},
+				{Timestamp: time.Unix(1, 0), Line: "2"},
+				{Timestamp: time.Unix(3600, 0), Line: "1"},
+			},
+		},
+	}, res.(*LokiResponse).Data.Result)
+}
+
+func Test_Dedup_splitByInterval_Do(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "1")
+
+	var callCt int
+	var mtx sync.Mutex
+
+	next := queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		callCt++
+
+		start := r.(*LokiRequest).StartTs
+		return &LokiResponse{
+			Status:    loghttp.QueryStatusSuccess,
+			Direction: r.(*LokiRequest).Direction,
+			Limit:     r.(*LokiRequest).Limit,
+			Version:   uint32(loghttp.VersionV1),
+			Data: LokiData{
+				ResultType: loghttp.ResultTypeStream,
+				Result: []logproto.Stream{
+					{
+						Labels: `{__dedup_count__="2", foo="bar"}`,
+						Entries: []logproto.Entry{
+							{Timestamp: start, Line: "crash"},
+						},
+					},
+				},
+			},
+		}, nil
+	})
+
+	l := WithSplitByLimits(fakeLimits{maxQueryParallelism: 1}, time.Hour)
+	defSplitter := newDefaultSplitter(fakeLimits{}, nil)
+	split := SplitByIntervalMiddleware(
+		testSchemas,
+		l,
+		DefaultCodec,
+		defSplitter,
+		nilMetrics,
+	).Wrap(next)
+
+	query := `{foo="bar"} | dedup`
+	req := &LokiRequest{
+		StartTs:   time.Unix(0, 0),
+		EndTs:     time.Unix(0, (4 * time.Hour).Nanoseconds()),
+		Query:     query,
+		Limit:     1,
+		Step:      1,
+		Direction: logproto.FORWARD,
+		Path:      "/api/prom/query_range",
+		Plan: &plan.QueryPlan{
+			AST: syntax.MustParseExpr(query),
+		},
+	}
+
+	res, err := split.Do(ctx, req)
+	require.NoError(t, err)
+
+	// the entries of every split are collapsed into the first one.
+	require.Equal(t, 4, callCt)
+	require.Equal(t, []logproto.Stream{
+		{
+			Labels: `{__dedup_count__="8", foo="bar"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(0, 0), Line: "crash"},
+			},
+		},
+	}, res.(*LokiResponse).Data.Result)
+}
+
+func Test_PartialResults_splitByInterval_Do(t *testing.T) {
+	next := queryrangebase.HandlerFunc(func(ctx context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+		require.Nil(t, partialResultsFromContext(ctx))
+
+		// the most recent splits complete last.
+		start := r.(*LokiRequest).StartTs
+		time.Sleep(time.Duration(start.Unix()/3600) * 10 * time.Millisecond)
+		return &LokiResponse{
+			Status:    loghttp.QueryStatusSuccess,
+			Direction: r.(*LokiRequest).Direction,
+			Limit:     r.(*LokiRequest).Limit,
+			Version:   uint32(loghttp.VersionV1),
+			Data: LokiData{
+				ResultType: loghttp.ResultTypeStream,
+				Result: []logproto.Stream{
+					{
+						Labels: `{foo="bar"}`,
+						Entries: []logproto.Entry{
+							{Timestamp: start.Add(time.Minute), Line: "second"},
+							{Timestamp: start, Line: "first"},
+						},
+					},
+				},
+			},
+		}, nil
+	})
+
+	l := WithSplitByLimits(fakeLimits{maxQueryParallelism: 4}, time.Hour)
+	split := SplitByIntervalMiddleware(
+		testSchemas,
+		l,
+		DefaultCodec,
+		newDefaultSplitter(fakeLimits{}, nil),
+		nilMetrics,
+	).Wrap(next)
+
+	var partials []PartialResult
+	ctx := WithPartialResults(user.InjectOrgID(context.Background(), "1"), func(p PartialResult) {
+		partials = append(partials, p)
+	})
+	res, err := split.Do(ctx, &LokiRequest{
+		StartTs:   time.Unix(0, 0),
+		EndTs:     time.Unix(0, (4 * time.Hour).Nanoseconds()),
+		Query:     `{foo="bar"}`,
+		Limit:     3,
+		Direction: logproto.BACKWARD,
+		Path:      "/loki/api/v1/query_range",
+	})
+	require.NoError(t, err)
+
+	// the parts are sent in the order of the result, truncated to the limit.
+	require.Len(t, partials, 2)
+	var entries []logproto.Entry
+	for _, p := range partials {
+		require.True(t, p.Final)
+		for _, s := range p.Response.(*LokiResponse).Data.Result {
+			entries = append(entries, s.Entries...)
+		}
+	}
+	require.Equal(t, res.(*LokiResponse).Data.Result[0].Entries, entries)
+	require.Equal(t, []logproto.Entry{
+		{Timestamp: time.Unix(0, 0).Add(3*time.Hour + time.Minute), Line: "second"},
+		{Timestamp: time.Unix(0, 0).Add(3 * time.Hour), Line: "first"},
+		{Timestamp: time.Unix(0, 0).Add(2*time.Hour + time.Minute), Line: "second"},
+	}, entries)
+
+	// the splits of sort_by queries are not in the result as is.
+	partials = nil
+	query := `{foo="bar"} | sort_by(duration desc)`
+	_, err = split.Do(ctx, &LokiRequest{
+		StartTs:   time.Unix(0, 0),
+		EndTs:     time.Unix(0, (4 * time.Hour).Nanoseconds()),
+		Query:     query,
+		Limit:     3,
+		Direction: logproto.BACKWARD,
+		Path:      "/loki/api/v1/query_range",
+		Plan:      &plan.QueryPlan{AST: syntax.MustParseExpr(query)},
+	})
+	require.NoError(t, err)
+	require.Empty(t, partials)
+}
+
 func Test_DoesntDeadlock(t *testing.T) {
 	n := 10
 
@@ -1948,3 +2245,24 @@ func assertSplits(t *testing.T, want, splits []queryrangebase.Request) {
 		}
 	}
 }
+
+func Test_maxRangeVectorAndOffsetDuration(t *testing.T) {
+	for _, tc := range []struct {
+		query          string
+		expectedRange  time.Duration
+		expectedOffset time.Duration
+	}{
+		{`{app="foo"}`, 0, 0},
+		{`rate({app="foo"}[5m])`, 5 * time.Minute, 0},
+		{`rate({app="foo"}[5m] offset 1h) / rate({app="foo"}[10m])`, 10 * time.Minute, time.Hour},
+		{`max_over_time(rate({app="foo"}[5m])[1h:1m])`, time.Hour + 5*time.Minute, 0},
+		{`max_over_time(rate({app="foo"}[5m] offset 10m)[1h:1m] offset 1h)`, time.Hour + 5*time.Minute, time.Hour + 10*time.Minute},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			rng, offset, err := maxRangeVectorAndOffsetDurationFromQueryString(tc.query)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedRange, rng)
+			require.Equal(t, tc.expectedOffset, offset)
+		})
+	}
+}
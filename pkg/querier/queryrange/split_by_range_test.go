@@ -407,6 +407,22 @@ func Test_RangeVectorSplit(t *testing.T) {
 			},
 			expected: expectedMergedResponse(1 + 2 + 3),
 		},
+		{
+			in: &LokiInstantRequest{
+				Query:  `sum by (le) (histogram_over_time({app="foo"} | unwrap bar [3m], 1))`,
+				TimeTs: time.Unix(1, 0),
+				Path:   "/loki/api/v1/query",
+				Plan: &plan.QueryPlan{
+					AST: syntax.MustParseExpr(`sum by (le) (histogram_over_time({app="foo"} | unwrap bar [3m], 1))`),
+				},
+			},
+			subQueries: []queryrangebase.RequestResponse{
+				subQueryRequestResponse(`sum by (le)(histogram_over_time({app="foo"} | unwrap bar[1m],1))`, 1),
+				subQueryRequestResponse(`sum by (le)(histogram_over_time({app="foo"} | unwrap bar[1m] offset 1m0s,1))`, 2),
+				subQueryRequestResponse(`sum by (le)(histogram_over_time({app="foo"} | unwrap bar[1m] offset 2m0s,1))`, 3),
+			},
+			expected: expectedMergedResponse(1 + 2 + 3),
+		},
 	} {
 		tc := tc
 		t.Run(tc.in.GetQuery(), func(t *testing.T) {
@@ -249,12 +249,21 @@ func validateRuleNode(r *rulefmt.RuleNode, groupName string) error {
 
 	if r.Expr.Value == "" {
 		return errors.Errorf("field 'expr' must be set in rule")
-	} else if _, err := syntax.ParseExpr(r.Expr.Value); err != nil {
+	}
+	expr, err := syntax.ParseExpr(r.Expr.Value)
+	if err != nil {
 		if r.Record.Value != "" {
 			return errors.Wrapf(err, fmt.Sprintf("could not parse expression for record '%s' in group '%s'", r.Record.Value, groupName))
 		}
 		return errors.Wrapf(err, fmt.Sprintf("could not parse expression for alert '%s' in group '%s'", r.Alert.Value, groupName))
 	}
+	// macros are expanded by the query frontend, which rules don't go through.
+	if syntax.HasMacros(expr) {
+		if r.Record.Value != "" {
+			return errors.Errorf("macros can't be used in the expression of record '%s' in group '%s'", r.Record.Value, groupName)
+		}
+		return errors.Errorf("macros can't be used in the expression of alert '%s' in group '%s'", r.Alert.Value, groupName)
+	}
 
 	if r.Record.Value != "" {
 		if len(r.Annotations) > 0 {
@@ -74,6 +74,21 @@ func TestInvalidRuleExprParsing(t *testing.T) {
 	assert.Containsf(t, recordErr.Error(), expectedRecordErrorMsg, "expected error containing '%s', got '%s'", expectedRecordErrorMsg, recordErr)
 }
 
+// TestRuleExprWithMacros tests that a validation error is raised when a rule expression invokes macros
+func TestRuleExprWithMacros(t *testing.T) {
+	alertRule := &rulefmt.RuleNode{
+		Alert: yaml.Node{Value: "alert-1-name"},
+		Expr:  yaml.Node{Value: `sum(count_over_time({app="foo"} | @errors() [5m])) > 0`},
+	}
+	require.EqualError(t, validateRuleNode(alertRule, "test"), "macros can't be used in the expression of alert 'alert-1-name' in group 'test'")
+
+	recordRule := &rulefmt.RuleNode{
+		Record: yaml.Node{Value: "record-1-name"},
+		Expr:   yaml.Node{Value: `sum(count_over_time({app="foo"} | @errors() [5m]))`},
+	}
+	require.EqualError(t, validateRuleNode(recordRule, "test"), "macros can't be used in the expression of record 'record-1-name' in group 'test'")
+}
+
 // TestInvalidRemoteWriteConfig tests that a validation error is raised when config is invalid
 func TestInvalidRemoteWriteConfig(t *testing.T) {
 	// if remote-write is not enabled, validation fails
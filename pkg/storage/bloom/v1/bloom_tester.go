@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"strings"
 	"unicode/utf8"
 
 	"github.com/grafana/regexp"
@@ -56,6 +57,11 @@ func ExtractTestableLineFilters(expr syntax.Expr) []syntax.LineFilterExpr {
 				filters = append(filters, *e)
 			}
 		},
+		VisitSearchFn: func(v syntax.RootVisitor, e *syntax.SearchExpr) {
+			if e != nil && !e.Metadata && !lineFmtFound {
+				filters = append(filters, searchToLineFilters(e)...)
+			}
+		},
 		VisitLineFmtFn: func(v syntax.RootVisitor, e *syntax.LineFmtExpr) {
 			if e != nil {
 				lineFmtFound = true
@@ -66,6 +72,59 @@ func ExtractTestableLineFilters(expr syntax.Expr) []syntax.LineFilterExpr {
 	return filters
 }
 
+// ExtractTestableMetadataFilters extracts the terms of the full-text filters
+// searching the structured metadata, e.g. `|* --metadata "foo"`, as line filters.
+// A term can be found either in the line or in a structured metadata value,
+// which are both indexed by blooms using a schema that IndexesStructuredMetadata.
+// Searches after a parser or a format stage are skipped since they might match
+// content that was extracted, unescaped or added by the stage.
+func ExtractTestableMetadataFilters(expr syntax.Expr) []syntax.LineFilterExpr {
+	if expr == nil {
+		return nil
+	}
+
+	var filters []syntax.LineFilterExpr
+	var modified bool
+	markModified := func() { modified = true }
+	visitor := &syntax.DepthFirstTraversal{
+		VisitSearchFn: func(v syntax.RootVisitor, e *syntax.SearchExpr) {
+			if e != nil && e.Metadata && !modified {
+				filters = append(filters, searchToLineFilters(e)...)
+			}
+		},
+		VisitLineFmtFn:                func(syntax.RootVisitor, *syntax.LineFmtExpr) { markModified() },
+		VisitLabelFmtFn:               func(syntax.RootVisitor, *syntax.LabelFmtExpr) { markModified() },
+		VisitLabelParserFn:            func(syntax.RootVisitor, *syntax.LabelParserExpr) { markModified() },
+		VisitLogfmtParserFn:           func(syntax.RootVisitor, *syntax.LogfmtParserExpr) { markModified() },
+		VisitJSONExpressionParserFn:   func(syntax.RootVisitor, *syntax.JSONExpressionParser) { markModified() },
+		VisitLogfmtExpressionParserFn: func(syntax.RootVisitor, *syntax.LogfmtExpressionParser) { markModified() },
+		VisitCSVParserFn:              func(syntax.RootVisitor, *syntax.CSVParserExpr) { markModified() },
+		VisitSDParserFn:               func(syntax.RootVisitor, *syntax.SDParserExpr) { markModified() },
+		VisitXMLParserFn:              func(syntax.RootVisitor, *syntax.XMLParserExpr) { markModified() },
+	}
+	expr.Accept(visitor)
+	return filters
+}
+
+// HasTestableFilters returns whether the expression contains line filters or
+// full-text filters that can be tested against a bloom filter.
+func HasTestableFilters(expr syntax.Expr) bool {
+	return len(ExtractTestableLineFilters(expr)) > 0 || len(ExtractTestableMetadataFilters(expr)) > 0
+}
+
+// searchToLineFilters converts each term of a full-text filter to a line filter
+// matching the term.
+func searchToLineFilters(e *syntax.SearchExpr) []syntax.LineFilterExpr {
+	terms := log.SearchTerms(e.Search)
+	filters := make([]syntax.LineFilterExpr, 0, len(terms))
+	for _, term := range terms {
+		filters = append(filters, syntax.LineFilterExpr{
+			LineFilter: syntax.LineFilter{Ty: log.LineMatchEqual, Match: term},
+		})
+	}
+	return filters
+}
+
 // FiltersToBloomTest converts a list of line filters to a BloomTest.
 // Note that all the line filters should be testable against a bloom filter.
 // Use ExtractTestableLineFilters to extract testable line filters from an expression.
@@ -92,15 +151,18 @@ func FiltersToBloomTest(b NGramBuilder, filters ...syntax.LineFilterExpr) BloomT
 
 func simpleFilterToBloomTest(b NGramBuilder, filter syntax.LineFilter) BloomTest {
 	switch filter.Ty {
-	case log.LineMatchNotEqual, log.LineMatchNotRegexp, log.LineMatchNotPattern:
+	case log.LineMatchNotEqual, log.LineMatchNotRegexp, log.LineMatchNotPattern, log.LineMatchNotEqualFold, log.LineMatchNotWord:
 		// We cannot test _negated_ filters with a bloom filter since blooms are probabilistic
 		// filters that can only tell us if a string _might_ exist.
 		// For example, for `!= "foo"`, the bloom filter might tell us that the string "foo" might exist
 		// but because we are not sure, we cannot discard that chunk because it might actually not be there.
 		// Therefore, we return a test that always returns true.
 		return MatchAll
-	case log.LineMatchEqual:
+	case log.LineMatchEqual, log.LineMatchWord:
+		// a whole word is also a substring of the line
 		return newStringTest(b, filter.Match)
+	case log.LineMatchEqualFold:
+		return newCaseInsensitiveStringTest(b, filter.Match)
 	case log.LineMatchRegexp:
 		return MatchAll
 	case log.LineMatchPattern:
@@ -186,14 +248,93 @@ type stringTest struct {
 }
 
 func newStringTest(b NGramBuilder, search string) (res BloomTest) {
+	offsets, ok := searchNGrams(b, search)
+	if !ok {
+		return MatchAll
+	}
+
+	res = stringTest{ngrams: offsets[0]}
+	for _, ngrams := range offsets[1:] {
+		res = newOrTest(res, stringTest{ngrams: ngrams})
+	}
+	return res
+}
+
+// maxCaseVariants is the maximum number of case variants of an ngram tested
+// against a bloom filter, above which the ngram is considered a match.
+const maxCaseVariants = 1 << 8
+
+// newCaseInsensitiveStringTest creates a test matching a search regardless of
+// the case of its ASCII letters. Each ngram matches when any of its case
+// variants is found in the bloom filter. Non-ASCII searches can't be tested.
+func newCaseInsensitiveStringTest(b NGramBuilder, search string) (res BloomTest) {
+	for i := 0; i < len(search); i++ {
+		if search[i] >= utf8.RuneSelf {
+			return MatchAll
+		}
+	}
+	offsets, ok := searchNGrams(b, strings.ToLower(search))
+	if !ok {
+		return MatchAll
+	}
+
+	tests := make([]BloomTest, 0, len(offsets))
+	for _, ngrams := range offsets {
+		test := make(BloomTests, 0, len(ngrams))
+		for _, ngram := range ngrams {
+			variants := caseVariants(ngram)
+			if len(variants) > maxCaseVariants {
+				continue
+			}
+			var variantsTest BloomTest = stringTest{ngrams: variants[:1]}
+			for i := 1; i < len(variants); i++ {
+				variantsTest = newOrTest(variantsTest, stringTest{ngrams: variants[i : i+1]})
+			}
+			test = append(test, variantsTest)
+		}
+		tests = append(tests, test)
+	}
+
+	res = tests[0]
+	for _, t := range tests[1:] {
+		res = newOrTest(res, t)
+	}
+	return res
+}
+
+// caseVariants returns the variants of a lowercase ASCII ngram obtained by
+// changing the case of its letters, stopping once there are more than
+// maxCaseVariants of them.
+func caseVariants(ngram []byte) [][]byte {
+	variants := [][]byte{ngram}
+	for i, c := range ngram {
+		if c < 'a' || c > 'z' {
+			continue
+		}
+		if len(variants) > maxCaseVariants {
+			break
+		}
+		for _, v := range variants {
+			upper := make([]byte, len(v))
+			copy(upper, v)
+			upper[i] = c - ('a' - 'A')
+			variants = append(variants, upper)
+		}
+	}
+	return variants
+}
+
+// searchNGrams returns the ngrams of a search for each possible skip offset.
+// It returns false if the search is too short to be tested.
+func searchNGrams(b NGramBuilder, search string) ([][][]byte, bool) {
 	// search string must be longer than the combined ngram length and skip factor
 	// in order for all possible skip offsets to have at least 1 ngram
 	skip := b.SkipFactor()
 	if ct := utf8.RuneCountInString(search); ct < b.N()+skip {
-		return MatchAll
+		return nil, false
 	}
 
-	tests := make([]stringTest, 0, skip)
+	offsets := make([][][]byte, 0, skip+1)
 
 	for i := 0; i < skip+1; i++ {
 		searchWithOffset := search
@@ -203,21 +344,16 @@ func newStringTest(b NGramBuilder, search string) (res BloomTest) {
 			searchWithOffset = searchWithOffset[min(size, len(searchWithOffset)):]
 		}
 
-		var test stringTest
+		var ngrams [][]byte
 		it := b.Tokens(searchWithOffset)
 		for it.Next() {
 			ngram := make([]byte, len(it.At()))
 			copy(ngram, it.At())
-			test.ngrams = append(test.ngrams, ngram)
+			ngrams = append(ngrams, ngram)
 		}
-		tests = append(tests, test)
+		offsets = append(offsets, ngrams)
 	}
-
-	res = tests[0]
-	for _, t := range tests[1:] {
-		res = newOrTest(res, t)
-	}
-	return res
+	return offsets, true
 }
 
 // Matches implements the BloomTest interface
@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/loki/v3/pkg/logql/log"
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
 )
 
@@ -97,6 +98,60 @@ func TestBloomQueryingLogic(t *testing.T) {
 			query: `{app="fake"} |~ "(aaaaa|bbbbb)bazz"`,
 			match: true,
 		},
+		{
+			desc:  "case-insensitive",
+			line:  "abcdefghijklmnopqrstuvwxyz",
+			query: `{app="fake"} |=~i "NOPqrsTUVWXYZ"`,
+			match: true,
+		},
+		{
+			desc:  "case-insensitive nomatch",
+			line:  "abcdefghijklmnopqrstuvwxyz",
+			query: `{app="fake"} |=~i "NOPQRSTUVWXYZZZ"`,
+			match: false,
+		},
+		{
+			desc:  "case-insensitive uppercase line",
+			line:  "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+			query: `{app="fake"} |=~i "nopqrstuvwxyz"`,
+			match: true,
+		},
+		{
+			desc:  "case-insensitive non-ascii always match",
+			line:  "abcdefghijklmnopqrstuvwxyz",
+			query: `{app="fake"} |=~i "zzzzzzzzzzzzzÏ"`,
+			match: true,
+		},
+		{
+			desc:  "negated case-insensitive always match",
+			line:  "abcdefghijklmnopqrstuvwxyz",
+			query: `{app="fake"} !=~i "nopqrstuvwxyz"`,
+			match: true,
+		},
+		{
+			desc:  "whole word nomatch",
+			line:  "abcdefghijklmnopqrstuvwxyz",
+			query: `{app="fake"} |=~w "nopqrstuvwxyzzz"`,
+			match: false,
+		},
+		{
+			desc:  "search all terms",
+			line:  "connection refused by upstream",
+			query: `{app="fake"} |* "refused upstream"`,
+			match: true,
+		},
+		{
+			desc:  "search missing term",
+			line:  "connection refused by upstream",
+			query: `{app="fake"} |* "refused downstream"`,
+			match: false,
+		},
+		{
+			desc:  "search after line_format",
+			line:  "connection refused by upstream",
+			query: `{app="fake"} | line_format "downstream" |* "downstream"`,
+			match: true,
+		},
 	} {
 
 		// shortcut to enable specific tests
@@ -117,3 +172,28 @@ func TestBloomQueryingLogic(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractTestableMetadataFilters(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		terms []string
+	}{
+		{query: `{app="fake"} |= "foo"`},
+		{query: `{app="fake"} |* "foo bar"`},
+		{query: `{app="fake"} |* --metadata "foo bar"`, terms: []string{"foo", "bar"}},
+		{query: `{app="fake"} |* --metadata "foo" | json |* --metadata "bar"`, terms: []string{"foo"}},
+		{query: `{app="fake"} | label_format foo="bar" |* --metadata "bar"`},
+		{query: `sum(count_over_time({app="fake"} |* --metadata "foo" [5m]))`, terms: []string{"foo"}},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := syntax.ParseExpr(tc.query)
+			require.NoError(t, err)
+			var terms []string
+			for _, f := range ExtractTestableMetadataFilters(expr) {
+				require.Equal(t, log.LineMatchEqual, f.Ty)
+				terms = append(terms, f.Match)
+			}
+			require.Equal(t, tc.terms, terms)
+		})
+	}
+}
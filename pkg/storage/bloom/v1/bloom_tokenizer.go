@@ -26,8 +26,9 @@ Bloom filters are utilized for faster lookups of log lines.
 type BloomTokenizer struct {
 	metrics *Metrics
 
-	maxBloomSize  int
-	lineTokenizer *NGramTokenizer
+	maxBloomSize       int
+	structuredMetadata bool
+	lineTokenizer      *NGramTokenizer
 	cache         map[string]interface{}
 }
 
@@ -40,14 +41,17 @@ const eightBits = 8
 // 1) The token slices generated must not be mutated externally
 // 2) The token slice must not be used after the next call to `Tokens()` as it will repopulate the slice.
 // 2) This is not thread safe.
-func NewBloomTokenizer(nGramLen, nGramSkip int, maxBloomSize int, metrics *Metrics) *BloomTokenizer {
+// The structured metadata values of the entries are tokenized like their lines
+// when structuredMetadata is true, for schemas that IndexesStructuredMetadata.
+func NewBloomTokenizer(nGramLen, nGramSkip int, maxBloomSize int, structuredMetadata bool, metrics *Metrics) *BloomTokenizer {
 	// TODO(chaudum): Replace logger
 	level.Info(util_log.Logger).Log("msg", "create new bloom tokenizer", "ngram length", nGramLen, "ngram skip", nGramSkip)
 	return &BloomTokenizer{
 		metrics:       metrics,
 		cache:         make(map[string]interface{}, cacheSize),
-		lineTokenizer: NewNGramTokenizer(nGramLen, nGramSkip),
-		maxBloomSize:  maxBloomSize,
+		lineTokenizer:      NewNGramTokenizer(nGramLen, nGramSkip),
+		maxBloomSize:       maxBloomSize,
+		structuredMetadata: structuredMetadata,
 	}
 }
 
@@ -129,15 +133,28 @@ func (bt *BloomTokenizer) Populate(swb *SeriesWithBloom, chks Iterator[ChunkRefW
 			// TODO(owen-d): rather than iterate over the line twice, once for prefixed tokenizer & once for
 			// raw tokenizer, we could iterate once and just return (prefix, token) pairs from the tokenizer.
 			// Double points for them being different-ln references to the same data.
-			line := itr.Entry().Line
-			chunkBytes += len(line)
+			entry := itr.Entry()
+			chunkBytes += len(entry.Line)
+
+			// The structured metadata values are tokenized like the line so that
+			// full-text searches over the metadata can be tested against the blooms.
+			sources := make([]string, 0, 1+len(entry.StructuredMetadata))
+			sources = append(sources, entry.Line)
+			if bt.structuredMetadata {
+				for _, md := range entry.StructuredMetadata {
+					sources = append(sources, md.Value)
+				}
+			}
 
-			tokenItrs := []Iterator[[]byte]{
+			tokenItrs := make([]Iterator[[]byte], 0, 2*len(sources))
+			for _, src := range sources {
 				// two iterators, one for the raw tokens and one for the chunk prefixed tokens.
 				// Warning: the underlying line tokenizer (used in both iterators) uses the same buffer for tokens.
 				// They are NOT SAFE for concurrent use.
-				NewPrefixedTokenIter(tokenBuf, prefixLn, bt.lineTokenizer.Tokens(line)),
-				bt.lineTokenizer.Tokens(line),
+				tokenItrs = append(tokenItrs,
+					NewPrefixedTokenIter(tokenBuf, prefixLn, bt.lineTokenizer.Tokens(src)),
+					bt.lineTokenizer.Tokens(src),
+				)
 			}
 
 			for _, itr := range tokenItrs {
@@ -79,7 +79,7 @@ func TestPrefixedKeyCreation(t *testing.T) {
 
 func TestSetLineTokenizer(t *testing.T) {
 	t.Parallel()
-	bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, 0, metrics)
+	bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, 0, false, metrics)
 
 	// Validate defaults
 	require.Equal(t, bt.lineTokenizer.N(), DefaultNGramLength)
@@ -94,7 +94,7 @@ func TestSetLineTokenizer(t *testing.T) {
 func TestTokenizerPopulate(t *testing.T) {
 	t.Parallel()
 	var testLine = "this is a log line"
-	bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, 0, metrics)
+	bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, 0, false, metrics)
 
 	sbf := filter.NewScalableBloomFilter(1024, 0.01, 0.8)
 	var lbsList []labels.Labels
@@ -135,10 +135,57 @@ func TestTokenizerPopulate(t *testing.T) {
 	}
 }
 
+func TestTokenizerPopulateStructuredMetadata(t *testing.T) {
+	t.Parallel()
+	var testLine = "this is a log line"
+	var testValue = "trace-3fa85f64"
+
+	for _, structuredMetadata := range []bool{true, false} {
+		t.Run(fmt.Sprintf("structured metadata %t", structuredMetadata), func(t *testing.T) {
+			bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, 0, structuredMetadata, metrics)
+
+			memChunk := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, chunkenc.EncSnappy, chunkenc.ChunkHeadFormatFor(chunkenc.ChunkFormatV4), 256000, 1500000)
+			_ = memChunk.Append(&push.Entry{
+				Timestamp:          time.Unix(0, 1),
+				Line:               testLine,
+				StructuredMetadata: push.LabelsAdapter{{Name: "trace_id", Value: testValue}},
+			})
+			itr, err := memChunk.Iterator(
+				context.Background(),
+				time.Unix(0, 0),
+				time.Unix(0, math.MaxInt64),
+				logproto.FORWARD,
+				log.NewNoopPipeline().ForStream(labels.FromStrings("foo", "bar")),
+			)
+			require.Nil(t, err)
+
+			swb := SeriesWithBloom{
+				Bloom:  &Bloom{ScalableBloomFilter: *filter.NewScalableBloomFilter(1024, 0.01, 0.8)},
+				Series: &Series{Fingerprint: model.Fingerprint(labels.FromStrings("foo", "bar").Hash())},
+			}
+
+			_, _, err = bt.Populate(&swb, NewSliceIter([]ChunkRefWithIter{{Ref: ChunkRef{}, Itr: itr}}))
+			require.NoError(t, err)
+			tokenizer := NewNGramTokenizer(DefaultNGramLength, DefaultNGramSkip)
+			toks := tokenizer.Tokens(testLine)
+			for toks.Next() {
+				require.True(t, swb.Bloom.Test(toks.At()))
+			}
+			// the tokens of the value aren't all in the bloom when it isn't indexed.
+			indexed := true
+			toks = tokenizer.Tokens(testValue)
+			for toks.Next() {
+				indexed = indexed && swb.Bloom.Test(toks.At())
+			}
+			require.Equal(t, structuredMetadata, indexed)
+		})
+	}
+}
+
 func BenchmarkPopulateSeriesWithBloom(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		var testLine = lorem + lorem + lorem
-		bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, 0, metrics)
+		bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, 0, false, metrics)
 
 		sbf := filter.NewScalableBloomFilter(1024, 0.01, 0.8)
 		var lbsList []labels.Labels
@@ -175,7 +222,7 @@ func BenchmarkPopulateSeriesWithBloom(b *testing.B) {
 }
 
 func BenchmarkMapClear(b *testing.B) {
-	bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, 0, metrics)
+	bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, 0, false, metrics)
 	for i := 0; i < b.N; i++ {
 		for k := 0; k < cacheSize; k++ {
 			bt.cache[fmt.Sprint(k)] = k
@@ -186,7 +233,7 @@ func BenchmarkMapClear(b *testing.B) {
 }
 
 func BenchmarkNewMap(b *testing.B) {
-	bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, 0, metrics)
+	bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, 0, false, metrics)
 	for i := 0; i < b.N; i++ {
 		for k := 0; k < cacheSize; k++ {
 			bt.cache[fmt.Sprint(k)] = k
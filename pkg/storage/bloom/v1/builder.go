@@ -76,9 +76,16 @@ type BlockBuilder struct {
 	blooms *BloomBlockBuilder
 }
 
-func NewBlockOptions(enc chunkenc.Encoding, nGramLength, nGramSkip, maxBlockSizeBytes, maxBloomSizeBytes uint64) BlockOptions {
+// NewBlockOptions returns the options of the blocks whose blooms index the
+// structured metadata values of the entries, in addition to their lines, when
+// structuredMetadata is true.
+func NewBlockOptions(enc chunkenc.Encoding, structuredMetadata bool, nGramLength, nGramSkip, maxBlockSizeBytes, maxBloomSizeBytes uint64) BlockOptions {
+	version := DefaultSchemaVersion
+	if structuredMetadata {
+		version = V2
+	}
 	opts := NewBlockOptionsFromSchema(Schema{
-		version:     byte(1),
+		version:     version,
 		encoding:    enc,
 		nGramLength: nGramLength,
 		nGramSkip:   nGramSkip,
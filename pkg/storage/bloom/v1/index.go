@@ -28,6 +28,12 @@ func (s Schema) Compatible(other Schema) bool {
 	return s == other
 }
 
+// IndexesStructuredMetadata returns whether the blooms of the schema contain
+// the tokens of the structured metadata values in addition to the line tokens.
+func (s Schema) IndexesStructuredMetadata() bool {
+	return s.version >= V2
+}
+
 func (s Schema) NGramLen() int {
 	return int(s.nGramLength)
 }
@@ -78,8 +84,8 @@ func (s *Schema) Decode(dec *encoding.Decbuf) error {
 		return errors.Errorf("invalid magic number. expected %x, got  %x", magicNumber, number)
 	}
 	s.version = dec.Byte()
-	if s.version != 1 {
-		return errors.Errorf("invalid version. expected %d, got %d", 1, s.version)
+	if s.version != V1 && s.version != V2 {
+		return errors.Errorf("invalid version. expected %d or %d, got %d", V1, V2, s.version)
 	}
 
 	s.encoding = chunkenc.Encoding(dec.Byte())
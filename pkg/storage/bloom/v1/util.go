@@ -14,9 +14,14 @@ const (
 	magicNumber = uint32(0xCA7CAFE5)
 	// Add new versions below
 	V1 byte = iota
+	// V2 blooms also index the structured metadata values of the entries.
+	V2
 )
 
 const (
+	// DefaultSchemaVersion is the version of the blocks built unless their
+	// blooms index the structured metadata. V2 blocks can't be read by bloom
+	// gateways only supporting V1, so it stays V1 for rolling upgrades.
 	DefaultSchemaVersion = V1
 )
 
@@ -22,6 +22,7 @@ type CombinedLimits interface {
 	ingester.Limits
 	querier_limits.Limits
 	queryrange_limits.Limits
+	queryrange_limits.CostBudgetLimits
 	ruler.RulesLimits
 	scheduler_limits.Limits
 	storage.StoreLimits
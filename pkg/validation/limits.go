@@ -49,6 +49,13 @@ const (
 	// is used to keep track of the current number of healthy distributor replicas.
 	GlobalIngestionRateStrategy = "global"
 
+	// QueryCostBudgetReject rejects the queries of the tenants which exhausted
+	// their query cost budget.
+	QueryCostBudgetReject = "reject"
+	// QueryCostBudgetDeprioritize runs the queries of the tenants which
+	// exhausted their query cost budget with a max query parallelism of 1.
+	QueryCostBudgetDeprioritize = "deprioritize"
+
 	bytesInMB = 1048576
 
 	defaultPerStreamRateLimit   = 3 << 20 // 3MB
@@ -95,6 +102,7 @@ type Limits struct {
 	// Querier enforced limits.
 	MaxChunksPerQuery          int              `yaml:"max_chunks_per_query" json:"max_chunks_per_query"`
 	MaxQuerySeries             int              `yaml:"max_query_series" json:"max_query_series"`
+	MaxQueryJoinEntries        int              `yaml:"max_query_join_entries" json:"max_query_join_entries"`
 	MaxQueryLookback           model.Duration   `yaml:"max_query_lookback" json:"max_query_lookback"`
 	MaxQueryLength             model.Duration   `yaml:"max_query_length" json:"max_query_length"`
 	MaxQueryRange              model.Duration   `yaml:"max_query_range" json:"max_query_range"`
@@ -125,6 +133,11 @@ type Limits struct {
 	MinShardingLookback              model.Duration   `yaml:"min_sharding_lookback" json:"min_sharding_lookback"`
 	MaxQueryBytesRead                flagext.ByteSize `yaml:"max_query_bytes_read" json:"max_query_bytes_read"`
 	MaxQuerierBytesRead              flagext.ByteSize `yaml:"max_querier_bytes_read" json:"max_querier_bytes_read"`
+	QueryCostBudgetWindow            model.Duration   `yaml:"query_cost_budget_window" json:"query_cost_budget_window"`
+	QueryCostBudgetBytes             flagext.ByteSize `yaml:"query_cost_budget_bytes" json:"query_cost_budget_bytes"`
+	QueryCostBudgetChunks            int              `yaml:"query_cost_budget_chunks" json:"query_cost_budget_chunks"`
+	QueryCostBudgetExecTime          model.Duration   `yaml:"query_cost_budget_exec_time" json:"query_cost_budget_exec_time"`
+	QueryCostBudgetAction            string           `yaml:"query_cost_budget_action" json:"query_cost_budget_action"`
 	VolumeEnabled                    bool             `yaml:"volume_enabled" json:"volume_enabled" doc:"description=Enable log-volume endpoints."`
 	VolumeMaxSeries                  int              `yaml:"volume_max_series" json:"volume_max_series" doc:"description=The maximum number of aggregated series in a log-volume response"`
 
@@ -217,6 +230,8 @@ type Limits struct {
 	BloomFalsePositiveRate float64 `yaml:"bloom_false_positive_rate" json:"bloom_false_positive_rate" category:"experimental"`
 	BloomBlockEncoding     string  `yaml:"bloom_block_encoding" json:"bloom_block_encoding" category:"experimental"`
 
+	BloomIndexStructuredMetadata bool `yaml:"bloom_index_structured_metadata" json:"bloom_index_structured_metadata" category:"experimental"`
+
 	AllowStructuredMetadata           bool                  `yaml:"allow_structured_metadata,omitempty" json:"allow_structured_metadata,omitempty" doc:"description=Allow user to send structured metadata in push payload."`
 	MaxStructuredMetadataSize         flagext.ByteSize      `yaml:"max_structured_metadata_size" json:"max_structured_metadata_size" doc:"description=Maximum size accepted for structured metadata per log line."`
 	MaxStructuredMetadataEntriesCount int                   `yaml:"max_structured_metadata_entries_count" json:"max_structured_metadata_entries_count" doc:"description=Maximum number of structured metadata entries per log line."`
@@ -290,6 +305,7 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	_ = l.MaxQueryLength.Set("721h")
 	f.Var(&l.MaxQueryLength, "store.max-query-length", "The limit to length of chunk store queries. 0 to disable.")
 	f.IntVar(&l.MaxQuerySeries, "querier.max-query-series", 500, "Limit the maximum of unique series that is returned by a metric query. When the limit is reached an error is returned.")
+	f.IntVar(&l.MaxQueryJoinEntries, "querier.max-query-join-entries", 10000, "Limit the maximum number of log entries of the right-hand side of a join that are kept in memory while evaluating a log query. When the limit is reached an error is returned.")
 	_ = l.MaxQueryRange.Set("0s")
 	f.Var(&l.MaxQueryRange, "querier.max-query-range", "Limit the length of the [range] inside a range query. Default is 0 or unlimited")
 	_ = l.QueryTimeout.Set(DefaultPerTenantQueryTimeout)
@@ -323,6 +339,13 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	_ = l.MaxQuerierBytesRead.Set("150GB")
 	f.Var(&l.MaxQuerierBytesRead, "frontend.max-querier-bytes-read", "Max number of bytes a query can fetch after splitting and sharding. Enforced in log and metric queries only when TSDB is used. The default value of 0 disables this limit.")
 
+	_ = l.QueryCostBudgetWindow.Set("1h")
+	f.Var(&l.QueryCostBudgetWindow, "frontend.query-cost-budget-window", "Sliding window over which the cost of the queries of a tenant is accumulated and compared to its query cost budget.")
+	f.Var(&l.QueryCostBudgetBytes, "frontend.query-cost-budget-bytes", "Max number of bytes the queriers can process for the queries of a tenant within the query cost budget window. The default value of 0 disables this limit.")
+	f.IntVar(&l.QueryCostBudgetChunks, "frontend.query-cost-budget-chunks", 0, "Max number of chunks the queriers can fetch for the queries of a tenant within the query cost budget window. The default value of 0 disables this limit.")
+	f.Var(&l.QueryCostBudgetExecTime, "frontend.query-cost-budget-exec-time", "Max time the queriers can spend executing the queries of a tenant within the query cost budget window. The default value of 0 disables this limit.")
+	f.StringVar(&l.QueryCostBudgetAction, "frontend.query-cost-budget-action", QueryCostBudgetReject, "What to do with the queries of a tenant which exhausted its query cost budget: 'reject' them, or 'deprioritize' them by running them with a max query parallelism of 1.")
+
 	_ = l.MaxCacheFreshness.Set("10m")
 	f.Var(&l.MaxCacheFreshness, "frontend.max-cache-freshness", "Most recent allowed cacheable result per-tenant, to prevent caching very recent results that might still be in flux.")
 
@@ -379,6 +402,7 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.BloomNGramSkip, "bloom-compactor.ngram-skip", 1, "Experimental. Skip factor for the n-grams created when computing blooms from log lines.")
 	f.Float64Var(&l.BloomFalsePositiveRate, "bloom-compactor.false-positive-rate", 0.01, "Experimental. Scalable Bloom Filter desired false-positive rate.")
 	f.StringVar(&l.BloomBlockEncoding, "bloom-compactor.block-encoding", "none", "Experimental. Compression algorithm for bloom block pages.")
+	f.BoolVar(&l.BloomIndexStructuredMetadata, "bloom-build.index-structured-metadata", false, "Experimental. Whether the blooms also index the structured metadata values of the log lines, so that full-text filters over the structured metadata can use them. These blooms are built with a new block schema version that older bloom gateways can't read, so bloom gateways must be upgraded before enabling it.")
 	f.DurationVar(&l.BloomGatewayCacheKeyInterval, "bloom-gateway.cache-key-interval", 15*time.Minute, "Experimental. Interval for computing the cache key in the Bloom Gateway.")
 	_ = l.BloomCompactorMaxBlockSize.Set(defaultBloomCompactorMaxBlockSize)
 	f.Var(&l.BloomCompactorMaxBlockSize, "bloom-compactor.max-block-size",
@@ -493,6 +517,12 @@ func (l *Limits) Validate() error {
 		return err
 	}
 
+	switch l.QueryCostBudgetAction {
+	case "", QueryCostBudgetReject, QueryCostBudgetDeprioritize:
+	default:
+		return fmt.Errorf("invalid query cost budget action %q, must be one of %q or %q", l.QueryCostBudgetAction, QueryCostBudgetReject, QueryCostBudgetDeprioritize)
+	}
+
 	if l.TSDBMaxBytesPerShard <= 0 {
 		return errors.New("querier.tsdb-max-bytes-per-shard must be greater than 0")
 	}
@@ -629,6 +659,11 @@ func (o *Overrides) MaxQuerySeries(_ context.Context, userID string) int {
 	return o.getOverridesForUser(userID).MaxQuerySeries
 }
 
+// MaxQueryJoinEntries returns the limit of the entries buffered by a join in log queries.
+func (o *Overrides) MaxQueryJoinEntries(_ context.Context, userID string) int {
+	return o.getOverridesForUser(userID).MaxQueryJoinEntries
+}
+
 // MaxQueryRange returns the limit for the max [range] value that can be in a range query
 func (o *Overrides) MaxQueryRange(_ context.Context, userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).MaxQueryRange)
@@ -731,6 +766,31 @@ func (o *Overrides) MaxQuerierBytesRead(_ context.Context, userID string) int {
 	return o.getOverridesForUser(userID).MaxQuerierBytesRead.Val()
 }
 
+// QueryCostBudgetWindow returns the window over which the query cost budget applies.
+func (o *Overrides) QueryCostBudgetWindow(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).QueryCostBudgetWindow)
+}
+
+// QueryCostBudgetBytes returns the maximum bytes the queries of a tenant can process within the query cost budget window.
+func (o *Overrides) QueryCostBudgetBytes(userID string) int {
+	return o.getOverridesForUser(userID).QueryCostBudgetBytes.Val()
+}
+
+// QueryCostBudgetChunks returns the maximum chunks the queries of a tenant can fetch within the query cost budget window.
+func (o *Overrides) QueryCostBudgetChunks(userID string) int {
+	return o.getOverridesForUser(userID).QueryCostBudgetChunks
+}
+
+// QueryCostBudgetExecTime returns the maximum time the queries of a tenant can run for within the query cost budget window.
+func (o *Overrides) QueryCostBudgetExecTime(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).QueryCostBudgetExecTime)
+}
+
+// QueryCostBudgetAction returns what to do with the queries of a tenant which exhausted its query cost budget.
+func (o *Overrides) QueryCostBudgetAction(userID string) string {
+	return o.getOverridesForUser(userID).QueryCostBudgetAction
+}
+
 // MaxConcurrentTailRequests returns the limit to number of concurrent tail requests.
 func (o *Overrides) MaxConcurrentTailRequests(_ context.Context, userID string) int {
 	return o.getOverridesForUser(userID).MaxConcurrentTailRequests
@@ -1035,6 +1095,10 @@ func (o *Overrides) BloomBlockEncoding(userID string) string {
 	return o.getOverridesForUser(userID).BloomBlockEncoding
 }
 
+func (o *Overrides) BloomIndexStructuredMetadata(userID string) bool {
+	return o.getOverridesForUser(userID).BloomIndexStructuredMetadata
+}
+
 func (o *Overrides) AllowStructuredMetadata(userID string) bool {
 	return o.getOverridesForUser(userID).AllowStructuredMetadata
 }
@@ -341,6 +341,10 @@ func TestLimitsValidation(t *testing.T) {
 			limits:   Limits{DeletionMode: "disabled", BloomBlockEncoding: "unknown"},
 			expected: fmt.Errorf("invalid encoding: unknown, supported: %s", chunkenc.SupportedEncoding()),
 		},
+		{
+			limits:   Limits{DeletionMode: "disabled", BloomBlockEncoding: "none", QueryCostBudgetAction: "ignore"},
+			expected: fmt.Errorf(`invalid query cost budget action "ignore"`),
+		},
 	} {
 		desc := fmt.Sprintf("%s/%s", tc.limits.DeletionMode, tc.limits.BloomBlockEncoding)
 		t.Run(desc, func(t *testing.T) {